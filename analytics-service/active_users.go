@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Active user windows exposed in /stats
+var activeUserWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+}
+
+// activeSlot holds the users seen during one resolution-sized bucket
+type activeSlot struct {
+	bucket int64
+	users  map[int]struct{}
+}
+
+// ActiveUsers tracks distinct users over sliding windows using a ring of
+// per-bucket sets. Buckets older than the ring span are reused in place, so
+// memory is bounded by the distinct users seen in the longest window.
+type ActiveUsers struct {
+	mu         sync.Mutex
+	resolution time.Duration
+	slots      []activeSlot
+	maxPerSlot int // 0 means unlimited
+	overflowed int64
+}
+
+// NewActiveUsers creates a tracker covering span with the given bucket
+// resolution. maxPerSlot caps the users stored per bucket (0 for no cap).
+func NewActiveUsers(span, resolution time.Duration, maxPerSlot int) *ActiveUsers {
+	n := int(span / resolution)
+	if n < 1 {
+		n = 1
+	}
+	slots := make([]activeSlot, n)
+	for i := range slots {
+		slots[i].bucket = -1
+	}
+	return &ActiveUsers{
+		resolution: resolution,
+		slots:      slots,
+		maxPerSlot: maxPerSlot,
+	}
+}
+
+// Record marks userID as active at the given time
+func (a *ActiveUsers) Record(userID int, at time.Time) {
+	bucket := at.UnixNano() / int64(a.resolution)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	slot := &a.slots[a.index(bucket)]
+	switch {
+	case slot.bucket == bucket:
+	case slot.bucket < bucket:
+		// Slot holds an expired bucket; recycle it
+		slot.bucket = bucket
+		slot.users = make(map[int]struct{})
+	default:
+		// Event is older than the ring span
+		return
+	}
+
+	if _, ok := slot.users[userID]; !ok && a.maxPerSlot > 0 && len(slot.users) >= a.maxPerSlot {
+		a.overflowed++
+		return
+	}
+	slot.users[userID] = struct{}{}
+}
+
+// Count returns the number of distinct users seen in the window ending at now
+func (a *ActiveUsers) Count(window time.Duration, now time.Time) int {
+	nowBucket := now.UnixNano() / int64(a.resolution)
+	oldest := nowBucket - int64(window/a.resolution) + 1
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen := make(map[int]struct{})
+	for _, slot := range a.slots {
+		if slot.bucket < oldest || slot.bucket > nowBucket {
+			continue
+		}
+		for userID := range slot.users {
+			seen[userID] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+// Snapshot returns the active user counts for all exposed windows
+func (a *ActiveUsers) Snapshot(now time.Time) map[string]int {
+	counts := make(map[string]int, len(activeUserWindows))
+	for _, w := range activeUserWindows {
+		counts[w.name] = a.Count(w.duration, now)
+	}
+	return counts
+}
+
+// MemoryStats reports the number of stored user entries across all buckets
+func (a *ActiveUsers) MemoryStats() map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := 0
+	for _, slot := range a.slots {
+		entries += len(slot.users)
+	}
+	return map[string]interface{}{
+		"slots":        len(a.slots),
+		"entries":      entries,
+		"max_per_slot": a.maxPerSlot,
+		"overflowed":   a.overflowed,
+	}
+}
+
+func (a *ActiveUsers) index(bucket int64) int {
+	n := int64(len(a.slots))
+	return int(((bucket % n) + n) % n)
+}
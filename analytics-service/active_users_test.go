@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveUsers_CountsDistinctUsersPerWindow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	au := NewActiveUsers(24*time.Hour, time.Minute, 0)
+
+	au.Record(1, now.Add(-1*time.Minute))
+	au.Record(1, now.Add(-2*time.Minute)) // same user, counted once
+	au.Record(2, now.Add(-30*time.Minute))
+	au.Record(3, now.Add(-5*time.Hour))
+	au.Record(4, now.Add(-25*time.Hour)) // outside every window
+
+	snapshot := au.Snapshot(now)
+	if snapshot["5m"] != 1 {
+		t.Errorf("expected 1 user in 5m, got %d", snapshot["5m"])
+	}
+	if snapshot["1h"] != 2 {
+		t.Errorf("expected 2 users in 1h, got %d", snapshot["1h"])
+	}
+	if snapshot["24h"] != 3 {
+		t.Errorf("expected 3 users in 24h, got %d", snapshot["24h"])
+	}
+}
+
+func TestActiveUsers_RecyclesExpiredSlots(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	au := NewActiveUsers(10*time.Minute, time.Minute, 0)
+
+	au.Record(1, start)
+	// Same ring slot, one full span later
+	au.Record(2, start.Add(10*time.Minute))
+
+	if got := au.Count(10*time.Minute, start.Add(10*time.Minute)); got != 1 {
+		t.Errorf("expected expired user to be evicted, got %d users", got)
+	}
+}
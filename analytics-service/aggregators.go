@@ -0,0 +1,108 @@
+package main
+
+import "time"
+
+// Aggregator maintains one view of the consumed events. Analytics hands
+// every event to each registered aggregator in turn, once repeats have been
+// dropped; an aggregator ignores the event types it has no use for, so a new
+// metric is a new Aggregator rather than another case in ProcessEvent.
+// Implementations are not safe for concurrent use; Analytics guards them.
+type Aggregator interface {
+	// Name identifies the aggregator in logs and metrics
+	Name() string
+	// Handle updates the aggregates with event, processed at now
+	Handle(event *TransactionEvent, now time.Time)
+}
+
+// totalsAggregator counts created and paid transactions. Paid counts are
+// reconciled against created transactions, so events delivered out of order
+// never report more paid than created. paid only grows; payments reverted
+// afterwards are counted in reverted, so both can be exported as counters.
+type totalsAggregator struct {
+	transactions int64
+	amount       float64
+	paid         int64
+	reverted     int64
+	ledger       *paidLedger
+}
+
+func newTotalsAggregator(cfg AnalyticsConfig) *totalsAggregator {
+	return &totalsAggregator{ledger: newPaidLedger(cfg.PaidReconcileGrace)}
+}
+
+func (t *totalsAggregator) Name() string { return "totals" }
+
+func (t *totalsAggregator) Handle(event *TransactionEvent, now time.Time) {
+	t.paid += t.ledger.Expire(now)
+	switch event.EventType {
+	case "transaction.created":
+		t.transactions++
+		t.amount += event.Amount
+		t.paid += t.ledger.Created(event.UserID)
+	case "transaction.paid":
+		t.paid += t.ledger.Paid(event.UserID, event.TransactionsPaid, now)
+	case "transaction.payment_reverted":
+		// Compensates a paid event whose charge failed
+		t.reverted += t.ledger.Reverted(event.UserID, event.TransactionsReverted)
+	}
+}
+
+// userAggregator tracks the users behind the events: who is active in the
+// sliding windows, and per-user totals of created transactions
+type userAggregator struct {
+	users  UserAggregates
+	active *ActiveUsers
+}
+
+func newUserAggregator(cfg AnalyticsConfig) *userAggregator {
+	return &userAggregator{
+		users:  NewUserAggregates(cfg),
+		active: NewActiveUsers(24*time.Hour, activeUsersResolution, cfg.MaxActivePerMin),
+	}
+}
+
+func (u *userAggregator) Name() string { return "users" }
+
+func (u *userAggregator) Handle(event *TransactionEvent, _ time.Time) {
+	u.active.Record(event.UserID, event.Timestamp)
+	if event.EventType == "transaction.created" {
+		u.users.Add(event.UserID, event.Amount)
+	}
+}
+
+// timeBucketAggregator buckets events into hourly and daily event-time
+// windows
+type timeBucketAggregator struct {
+	hourly *EventTimeWindows
+	daily  *EventTimeWindows
+}
+
+func newTimeBucketAggregator(cfg AnalyticsConfig) *timeBucketAggregator {
+	return &timeBucketAggregator{
+		hourly: NewEventTimeWindows(time.Hour, cfg.AllowedLateness, cfg.HourlyRetention),
+		daily:  NewEventTimeWindows(24*time.Hour, cfg.AllowedLateness, cfg.DailyRetention),
+	}
+}
+
+func (b *timeBucketAggregator) Name() string { return "time_buckets" }
+
+func (b *timeBucketAggregator) Handle(event *TransactionEvent, now time.Time) {
+	// Bucket by event time; fall back to processing time for unstamped events
+	at := event.Timestamp
+	if at.IsZero() {
+		at = now
+	}
+	b.hourly.Add(event, at)
+	b.daily.Add(event, at)
+}
+
+// windows returns the hourly or daily windows, or nil for another interval
+func (b *timeBucketAggregator) windows(interval string) *EventTimeWindows {
+	switch interval {
+	case "hourly":
+		return b.hourly
+	case "daily":
+		return b.daily
+	}
+	return nil
+}
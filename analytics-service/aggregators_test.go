@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// refundCounter is an aggregator registered from outside NewAnalytics
+type refundCounter struct{ n int }
+
+func (r *refundCounter) Name() string { return "refunds" }
+
+func (r *refundCounter) Handle(event *TransactionEvent, _ time.Time) {
+	if event.EventType == "transaction.refunded" {
+		r.n++
+	}
+}
+
+func TestAnalytics_RegisteredAggregatorSeesEvents(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, HourlyRetention: 2})
+	refunds := &refundCounter{}
+	a.Register(refunds)
+
+	now := time.Now()
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 10, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.refunded", UserID: 1, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+	// A redelivered payment is dropped before any aggregator sees it
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+
+	if refunds.n != 1 {
+		t.Errorf("expected 1 refund, got %d", refunds.n)
+	}
+	stats := a.GetStats()
+	if stats.TotalTransactions != 1 || stats.TotalPaidTransactions != 1 || stats.EventsProcessed != 3 {
+		t.Errorf("expected the built-in aggregators unaffected, got %+v", stats)
+	}
+	series, _ := a.GetTimeSeries("hourly")
+	if len(series.Buckets) != 1 || series.Buckets[0].Transactions != 1 || series.Buckets[0].PaidTransactions != 1 {
+		t.Errorf("expected one hourly bucket with 1 created and 1 paid, got %+v", series.Buckets)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/tkaewplik/go-microservices/pkg/events"
+)
+
+// AlertThresholdCrossed is the event type published when a user's spend in
+// a period reaches their threshold
+const AlertThresholdCrossed = "alert.threshold_crossed"
+
+// alertPeriodLayout names the monthly periods spend is tracked in, in UTC
+const alertPeriodLayout = "2006-01"
+
+// maxAlertHistory bounds the alerts kept per user
+const maxAlertHistory = 12
+
+// ErrInvalidThreshold is returned for a negative spend threshold
+var ErrInvalidThreshold = errors.New("threshold must not be negative")
+
+// AlertEvent is published once per user and period when the user's spend
+// reaches their threshold
+type AlertEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version   int       `json:"version"`
+	UserID    int       `json:"user_id"`
+	Period    string    `json:"period"`
+	Threshold float64   `json:"threshold"`
+	Spend     float64   `json:"spend"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AlertStatus is a user's threshold, spend in the current period and most
+// recent alerts, newest last
+type AlertStatus struct {
+	UserID    int          `json:"user_id"`
+	Threshold float64      `json:"threshold"`
+	Period    string       `json:"period"`
+	Spend     float64      `json:"spend"`
+	Alerts    []AlertEvent `json:"alerts"`
+}
+
+// thresholdRequest is the body of PUT /alerts
+type thresholdRequest struct {
+	Threshold float64 `json:"threshold"`
+}
+
+// userSpend is the alert state of one user
+type userSpend struct {
+	threshold float64
+	period    string
+	spend     float64
+	alerted   bool
+	alerts    []AlertEvent
+}
+
+// SpendAlerts tracks each user's running monthly spend from
+// transaction.created events and raises one alert per period once it
+// reaches the user's threshold. Events are keyed by user_id, so a user's
+// spend is tracked by the replica consuming their partition.
+type SpendAlerts struct {
+	mu    sync.Mutex
+	users map[int]*userSpend
+}
+
+// NewSpendAlerts creates an empty SpendAlerts
+func NewSpendAlerts() *SpendAlerts {
+	return &SpendAlerts{users: make(map[int]*userSpend)}
+}
+
+// SetThreshold sets a user's spend threshold; 0 turns their alerts off. A
+// threshold already exceeded alerts on the user's next transaction.
+func (s *SpendAlerts) SetThreshold(userID int, threshold float64) error {
+	if threshold < 0 {
+		return ErrInvalidThreshold
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user(userID).threshold = threshold
+	return nil
+}
+
+// Record adds a transaction.created event to its user's spend and returns
+// the alert it raises, if any. Events from before the current period are
+// ignored.
+func (s *SpendAlerts) Record(event *TransactionEvent) *AlertEvent {
+	if event.EventType != "transaction.created" || event.UserID == 0 {
+		return nil
+	}
+	ts := event.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	period := ts.UTC().Format(alertPeriodLayout)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.user(event.UserID)
+	switch {
+	case period < u.period:
+		return nil
+	case period > u.period:
+		u.period, u.spend, u.alerted = period, 0, false
+	}
+	u.spend += event.Amount
+	if u.threshold <= 0 || u.alerted || u.spend < u.threshold {
+		return nil
+	}
+
+	u.alerted = true
+	alert := AlertEvent{
+		EventType: AlertThresholdCrossed,
+		Version:   events.SpendAlertVersion,
+		UserID:    event.UserID,
+		Period:    period,
+		Threshold: u.threshold,
+		Spend:     u.spend,
+		Timestamp: ts,
+	}
+	u.alerts = append(u.alerts, alert)
+	if len(u.alerts) > maxAlertHistory {
+		u.alerts = slices.Delete(u.alerts, 0, len(u.alerts)-maxAlertHistory)
+	}
+	return &alert
+}
+
+// Status returns a user's alert status as of now
+func (s *SpendAlerts) Status(userID int, now time.Time) AlertStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := AlertStatus{UserID: userID, Period: now.UTC().Format(alertPeriodLayout), Alerts: []AlertEvent{}}
+	u, ok := s.users[userID]
+	if !ok {
+		return status
+	}
+	status.Threshold = u.threshold
+	if u.period == status.Period {
+		status.Spend = u.spend
+	}
+	status.Alerts = append(status.Alerts, u.alerts...)
+	return status
+}
+
+// user returns userID's state, creating it; s.mu must be held
+func (s *SpendAlerts) user(userID int) *userSpend {
+	u, ok := s.users[userID]
+	if !ok {
+		u = &userSpend{}
+		s.users[userID] = u
+	}
+	return u
+}
+
+// mergeStatus adds a peer's partition-local status for the same user into
+// s. Only the replica owning the user has spend and alerts; every replica
+// has the threshold.
+func (s *AlertStatus) mergeStatus(peer *AlertStatus) {
+	s.Spend += peer.Spend
+	s.Threshold = max(s.Threshold, peer.Threshold)
+	s.Alerts = append(s.Alerts, peer.Alerts...)
+	slices.SortStableFunc(s.Alerts, func(a, b AlertEvent) int { return a.Timestamp.Compare(b.Timestamp) })
+}
+
+// KafkaAlertPublisher publishes alerts to a Kafka topic: spend alerts keyed
+// by user_id like transaction events, and rule alerts by rule name
+type KafkaAlertPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaAlertPublisher creates a publisher writing to topic
+func NewKafkaAlertPublisher(brokers []string, topic string) *KafkaAlertPublisher {
+	return &KafkaAlertPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+	}}
+}
+
+// Publish sends alert
+func (p *KafkaAlertPublisher) Publish(ctx context.Context, alert *AlertEvent) error {
+	return p.write(ctx, strconv.AppendInt(nil, int64(alert.UserID), 10), alert)
+}
+
+// PublishRuleAlert sends alert, keyed by its rule's name so the firing and
+// resolved events of a rule keep their order
+func (p *KafkaAlertPublisher) PublishRuleAlert(ctx context.Context, alert *RuleAlert) error {
+	return p.write(ctx, []byte(alert.Rule), alert)
+}
+
+func (p *KafkaAlertPublisher) write(ctx context.Context, key []byte, alert any) error {
+	value, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   key,
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish alert: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the writer
+func (p *KafkaAlertPublisher) Close() error {
+	return p.writer.Close()
+}
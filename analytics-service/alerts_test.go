@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func created(userID int, amount float64, ts time.Time) *TransactionEvent {
+	return &TransactionEvent{EventType: "transaction.created", UserID: userID, Amount: amount, Timestamp: ts}
+}
+
+func TestSpendAlerts_OncePerPeriod(t *testing.T) {
+	s := NewSpendAlerts()
+	if err := s.SetThreshold(1, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	march := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	if alert := s.Record(created(1, 60, march)); alert != nil {
+		t.Errorf("expected no alert below the threshold, got %+v", alert)
+	}
+	alert := s.Record(created(1, 50, march.Add(time.Hour)))
+	if alert == nil || alert.EventType != AlertThresholdCrossed || alert.Period != "2024-03" || alert.Spend != 110 {
+		t.Fatalf("expected an alert at 110, got %+v", alert)
+	}
+	if alert := s.Record(created(1, 50, march.Add(2*time.Hour))); alert != nil {
+		t.Errorf("expected one alert per period, got %+v", alert)
+	}
+
+	// A new month starts from zero; a late March event doesn't count
+	april := march.AddDate(0, 1, 0)
+	if alert := s.Record(created(1, 90, april)); alert != nil {
+		t.Errorf("expected no alert at 90 in April, got %+v", alert)
+	}
+	s.Record(created(1, 500, march))
+	if alert := s.Record(created(1, 10, april)); alert == nil || alert.Period != "2024-04" {
+		t.Errorf("expected an April alert at 100, got %+v", alert)
+	}
+
+	status := s.Status(1, april)
+	if status.Threshold != 100 || status.Spend != 100 || len(status.Alerts) != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestSpendAlerts_NoThreshold(t *testing.T) {
+	s := NewSpendAlerts()
+	now := time.Now()
+	if alert := s.Record(created(2, 1e6, now)); alert != nil {
+		t.Errorf("expected no alert without a threshold, got %+v", alert)
+	}
+	if err := s.SetThreshold(2, -1); err != ErrInvalidThreshold {
+		t.Errorf("expected ErrInvalidThreshold, got %v", err)
+	}
+	if status := s.Status(2, now); status.Spend != 1e6 || status.Alerts == nil {
+		t.Errorf("expected the spend tracked and an empty alert list, got %+v", status)
+	}
+}
+
+func TestCluster_GatherAlerts(t *testing.T) {
+	alert := AlertEvent{EventType: AlertThresholdCrossed, UserID: 1, Period: "2024-03", Threshold: 10, Spend: 12}
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("scope") != "local" {
+			t.Errorf("expected peers to be asked for local state, got %s", r.URL)
+		}
+		_ = json.NewEncoder(w).Encode(AlertStatus{UserID: 1, Threshold: 10, Period: "2024-03", Spend: 12, Alerts: []AlertEvent{alert}})
+	}))
+	defer peer.Close()
+
+	c := NewCluster([]string{peer.URL}, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	got := c.GatherAlerts(context.Background(), AlertStatus{UserID: 1, Threshold: 10, Period: "2024-03", Alerts: []AlertEvent{}})
+	if got.Spend != 12 || len(got.Alerts) != 1 {
+		t.Errorf("expected the owning peer's spend and alert, got %+v", got)
+	}
+	if err := c.SetThreshold(context.Background(), 1, 10); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
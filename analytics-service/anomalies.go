@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// defaultAnomalyZScore is how many standard deviations above the mean
+	// amount a transaction must be to be flagged when
+	// AnalyticsConfig.AnomalyZScore is unset
+	defaultAnomalyZScore = 4.0
+	// defaultAnomalyMinSamples is how many created transactions are seen
+	// before any is flagged when AnalyticsConfig.AnomalyMinSamples is unset
+	defaultAnomalyMinSamples = 100
+	// maxRecentAnomalies bounds the flagged transactions kept for /stats/anomalies
+	maxRecentAnomalies = 100
+)
+
+// Anomaly is a created transaction whose amount stood out
+type Anomaly struct {
+	TransactionID int       `json:"transaction_id"`
+	UserID        int       `json:"user_id"`
+	Amount        float64   `json:"amount"`
+	ZScore        float64   `json:"z_score"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Anomalies is the /stats/anomalies response
+type Anomalies struct {
+	Flagged int64     `json:"flagged"`
+	Mean    float64   `json:"mean"`
+	StdDev  float64   `json:"std_dev"`
+	Recent  []Anomaly `json:"recent"`
+}
+
+// anomalyAggregator flags created transactions whose amount is more than
+// zScore standard deviations above the mean of those seen so far. The mean
+// and variance are kept with Welford's method, so memory is fixed; flagged
+// transactions still count towards them.
+type anomalyAggregator struct {
+	zScore     float64
+	minSamples int64
+
+	n       int64
+	mean    float64
+	m2      float64 // sum of squared differences from the mean
+	flagged int64
+	recent  []Anomaly // newest last
+}
+
+func newAnomalyAggregator(cfg AnalyticsConfig) *anomalyAggregator {
+	a := &anomalyAggregator{zScore: cfg.AnomalyZScore, minSamples: int64(cfg.AnomalyMinSamples)}
+	if a.zScore <= 0 {
+		a.zScore = defaultAnomalyZScore
+	}
+	if a.minSamples <= 0 {
+		a.minSamples = defaultAnomalyMinSamples
+	}
+	return a
+}
+
+func (a *anomalyAggregator) Name() string { return "anomalies" }
+
+func (a *anomalyAggregator) Handle(event *TransactionEvent, _ time.Time) {
+	if event.EventType != "transaction.created" {
+		return
+	}
+
+	// Scored against the transactions before it, so one outlier can't
+	// mask itself
+	if a.n >= a.minSamples {
+		if sd := a.stdDev(); sd > 0 {
+			if z := (event.Amount - a.mean) / sd; z > a.zScore {
+				a.flag(Anomaly{
+					TransactionID: event.TransactionID,
+					UserID:        event.UserID,
+					Amount:        event.Amount,
+					ZScore:        z,
+					Timestamp:     event.Timestamp,
+				})
+			}
+		}
+	}
+
+	a.n++
+	delta := event.Amount - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (event.Amount - a.mean)
+}
+
+func (a *anomalyAggregator) flag(anomaly Anomaly) {
+	a.flagged++
+	if len(a.recent) == maxRecentAnomalies {
+		a.recent = a.recent[1:]
+	}
+	a.recent = append(a.recent, anomaly)
+}
+
+func (a *anomalyAggregator) stdDev() float64 {
+	if a.n < 2 {
+		return 0
+	}
+	return math.Sqrt(a.m2 / float64(a.n-1))
+}
+
+// Snapshot copies the flagged transactions, newest first
+func (a *anomalyAggregator) Snapshot() Anomalies {
+	recent := make([]Anomaly, len(a.recent))
+	copy(recent, a.recent)
+	slices.Reverse(recent)
+	return Anomalies{Flagged: a.flagged, Mean: a.mean, StdDev: a.stdDev(), Recent: recent}
+}
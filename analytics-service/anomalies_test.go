@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalies_FlagsOutlyingAmounts(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AnomalyZScore: 3, AnomalyMinSamples: 10})
+	created := func(id int, amount float64) {
+		a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: id, UserID: 1, Amount: amount, Timestamp: time.Now()})
+	}
+
+	for i := range 20 {
+		created(i+1, float64(10+i%3))
+	}
+	created(21, 13)
+	created(22, 500)
+
+	got := a.GetAnomalies()
+	if got.Flagged != 1 || len(got.Recent) != 1 {
+		t.Fatalf("expected one anomaly, got %+v", got)
+	}
+	if r := got.Recent[0]; r.TransactionID != 22 || r.ZScore <= 3 {
+		t.Errorf("expected transaction 22 flagged above 3 standard deviations, got %+v", r)
+	}
+}
+
+func TestAnomalies_WaitsForMinSamples(t *testing.T) {
+	agg := newAnomalyAggregator(AnalyticsConfig{AnomalyZScore: 3, AnomalyMinSamples: 10})
+	for _, amount := range []float64{10, 11, 10, 12, 1000} {
+		agg.Handle(&TransactionEvent{EventType: "transaction.created", Amount: amount}, time.Now())
+	}
+	if got := agg.Snapshot(); got.Flagged != 0 {
+		t.Errorf("expected nothing flagged before 10 samples, got %+v", got)
+	}
+}
+
+func TestAnomalies_KeepsRecent(t *testing.T) {
+	agg := newAnomalyAggregator(AnalyticsConfig{})
+	for i := range maxRecentAnomalies + 5 {
+		agg.flag(Anomaly{TransactionID: i})
+	}
+
+	got := agg.Snapshot()
+	if got.Flagged != maxRecentAnomalies+5 || len(got.Recent) != maxRecentAnomalies {
+		t.Fatalf("expected %d kept of %d, got %d of %d", maxRecentAnomalies, maxRecentAnomalies+5, len(got.Recent), got.Flagged)
+	}
+	if got.Recent[0].TransactionID != maxRecentAnomalies+4 {
+		t.Errorf("expected newest first, got %d", got.Recent[0].TransactionID)
+	}
+}
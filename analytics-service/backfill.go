@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// backfillMargin is how long before the cutoff a created event is matched
+// against the backfilled transaction IDs instead of its timestamp alone, for
+// transactions committed while the snapshot was being read
+const backfillMargin = time.Minute
+
+// Backfill is the history loaded from payment-service at startup. Events
+// stamped before the cutoff are already counted, so the consumer skips them.
+type Backfill struct {
+	Cutoff       time.Time
+	Transactions int
+	// recent holds the IDs of transactions created within backfillMargin of
+	// the cutoff
+	recent map[int]struct{}
+}
+
+// RunBackfill streams every transaction created before cutoff from
+// payment-service and passes record a transaction.created event for each,
+// followed by a transaction.paid event for those already paid. Paid events
+// are stamped with the creation time, as the payment time isn't stored.
+func RunBackfill(ctx context.Context, client paymentpb.PaymentServiceClient, serviceToken string, cutoff time.Time, record func(*TransactionEvent)) (*Backfill, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, serviceToken)
+	// Wait for payment-service rather than fail when both start together
+	stream, err := client.StreamAllTransactions(ctx, &paymentpb.StreamAllTransactionsRequest{Before: timestamppb.New(cutoff)}, grpc.WaitForReady(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream transactions: %w", err)
+	}
+
+	b := &Backfill{Cutoff: cutoff, recent: make(map[int]struct{})}
+	for {
+		tx, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return b, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive transaction: %w", err)
+		}
+
+		createdAt := tx.GetCreatedAt().AsTime()
+		record(&TransactionEvent{
+			EventType:     "transaction.created",
+			TransactionID: int(tx.GetId()),
+			UserID:        int(tx.GetUserId()),
+			Amount:        tx.GetAmount(),
+			Description:   tx.GetDescription(),
+			Timestamp:     createdAt,
+		})
+		if tx.GetIsPaid() {
+			record(&TransactionEvent{
+				EventType:        "transaction.paid",
+				UserID:           int(tx.GetUserId()),
+				TransactionsPaid: 1,
+				Timestamp:        createdAt,
+			})
+		}
+		if createdAt.After(cutoff.Add(-backfillMargin)) {
+			b.recent[int(tx.GetId())] = struct{}{}
+		}
+		b.Transactions++
+	}
+}
+
+// Skip reports whether event is already counted by the backfill. Unstamped
+// events are always counted.
+func (b *Backfill) Skip(event *TransactionEvent) bool {
+	if b == nil || event.Timestamp.IsZero() || !event.Timestamp.Before(b.Cutoff) {
+		return false
+	}
+	if event.EventType == "transaction.created" && event.Timestamp.After(b.Cutoff.Add(-backfillMargin)) {
+		_, ok := b.recent[event.TransactionID]
+		return ok
+	}
+	return true
+}
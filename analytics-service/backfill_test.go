@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/testutil"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func TestRunBackfill(t *testing.T) {
+	client := testutil.NewFakePaymentClient()
+	ctx := context.Background()
+	for _, req := range []*paymentpb.CreateTransactionRequest{
+		{UserId: 1, Amount: 10},
+		{UserId: 1, Amount: 20},
+		{UserId: 2, Amount: 30},
+	} {
+		if _, err := client.CreateTransaction(ctx, req); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+	if _, err := client.PayAllTransactions(ctx, &paymentpb.PayRequest{UserId: 1}); err != nil {
+		t.Fatalf("failed to pay: %v", err)
+	}
+
+	analytics := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	b, err := RunBackfill(ctx, client, "svc", time.Now().Add(time.Second), analytics.ProcessEvent)
+	if err != nil {
+		t.Fatalf("expected the backfill to succeed, got %v", err)
+	}
+	if b.Transactions != 3 {
+		t.Errorf("expected 3 transactions backfilled, got %d", b.Transactions)
+	}
+	stats := analytics.GetStats()
+	if stats.TotalTransactions != 3 || stats.TotalAmount != 60 || stats.TotalPaidTransactions != 2 {
+		t.Errorf("expected 3 transactions totalling 60 with 2 paid, got %+v", stats)
+	}
+}
+
+func TestBackfill_Skip(t *testing.T) {
+	cutoff := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &Backfill{Cutoff: cutoff, recent: map[int]struct{}{7: {}}}
+
+	tests := []struct {
+		name  string
+		event TransactionEvent
+		skip  bool
+	}{
+		{"created long before the cutoff", TransactionEvent{EventType: "transaction.created", TransactionID: 1, Timestamp: cutoff.Add(-time.Hour)}, true},
+		{"backfilled just before the cutoff", TransactionEvent{EventType: "transaction.created", TransactionID: 7, Timestamp: cutoff.Add(-time.Second)}, true},
+		{"missed by the snapshot", TransactionEvent{EventType: "transaction.created", TransactionID: 8, Timestamp: cutoff.Add(-time.Second)}, false},
+		{"created after the cutoff", TransactionEvent{EventType: "transaction.created", TransactionID: 9, Timestamp: cutoff.Add(time.Second)}, false},
+		{"paid before the cutoff", TransactionEvent{EventType: "transaction.paid", Timestamp: cutoff.Add(-time.Second)}, true},
+		{"unstamped", TransactionEvent{EventType: "transaction.created", TransactionID: 1}, false},
+	}
+	for _, tt := range tests {
+		if got := b.Skip(&tt.event); got != tt.skip {
+			t.Errorf("%s: expected skip %v, got %v", tt.name, tt.skip, got)
+		}
+	}
+
+	var none *Backfill
+	if none.Skip(&TransactionEvent{Timestamp: cutoff}) {
+		t.Error("expected nothing skipped without a backfill")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+// defaultPaidBatches is how many payment batch events are remembered when
+// AnalyticsConfig.PaidBatches is unset
+const defaultPaidBatches = 100000
+
+// paidBatches remembers the latest payment batch events, so a
+// transaction.paid or transaction.payment_reverted event delivered again by a
+// retried publish is counted once. The oldest ID
+// is forgotten once capacity is reached; retries arrive well before then.
+// It is not safe for concurrent use; Analytics guards it.
+type paidBatches struct {
+	ids   map[string]struct{}
+	order []string // ring of ids, oldest at next once full
+	next  int
+}
+
+func newPaidBatches(capacity int) *paidBatches {
+	if capacity <= 0 {
+		capacity = defaultPaidBatches
+	}
+	return &paidBatches{
+		ids:   make(map[string]struct{}),
+		order: make([]string, 0, capacity),
+	}
+}
+
+// Add records id, reporting false when it was already recorded
+func (b *paidBatches) Add(id string) bool {
+	if _, ok := b.ids[id]; ok {
+		return false
+	}
+	if len(b.order) < cap(b.order) {
+		b.order = append(b.order, id)
+	} else {
+		delete(b.ids, b.order[b.next])
+		b.order[b.next] = id
+		b.next = (b.next + 1) % len(b.order)
+	}
+	b.ids[id] = struct{}{}
+	return true
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalytics_DuplicatePaidBatch(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	paid := func(batchID string, n int64) *TransactionEvent {
+		return &TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: batchID, TransactionsPaid: n, Timestamp: time.Now()}
+	}
+	createTransactions(a, 1, 8)
+
+	a.ProcessEvent(paid("batch-1", 2))
+	a.ProcessEvent(paid("batch-1", 2)) // retried publish
+	a.ProcessEvent(paid("batch-2", 3))
+	// Events from before batch IDs are always counted
+	a.ProcessEvent(paid("", 1))
+	a.ProcessEvent(paid("", 1))
+
+	stats := a.GetStats()
+	if stats.TotalPaidTransactions != 7 || stats.EventsProcessed != 8+4 {
+		t.Errorf("expected the repeated batch dropped, got %d paid in %d events", stats.TotalPaidTransactions, stats.EventsProcessed)
+	}
+}
+
+func TestPaidBatches_Capacity(t *testing.T) {
+	b := newPaidBatches(2)
+	for _, id := range []string{"a", "b", "c"} {
+		if !b.Add(id) {
+			t.Fatalf("expected %s to be new", id)
+		}
+	}
+	if b.Add("c") {
+		t.Error("expected c to be remembered")
+	}
+	// a was forgotten to make room for c
+	if !b.Add("a") {
+		t.Error("expected the oldest ID to be forgotten")
+	}
+}
+
+func TestAnalytics_PaymentReverted(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	now := time.Now()
+	createTransactions(a, 1, 5)
+
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "batch-1", TransactionsPaid: 3, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "batch-2", TransactionsPaid: 2, Timestamp: now})
+	// The charge for batch-2 failed; its compensation is delivered twice
+	reverted := &TransactionEvent{EventType: "transaction.payment_reverted", UserID: 1, PaymentBatchID: "batch-2", TransactionsReverted: 2, Timestamp: now}
+	a.ProcessEvent(reverted)
+	a.ProcessEvent(reverted)
+
+	if stats := a.GetStats(); stats.TotalPaidTransactions != 3 {
+		t.Errorf("expected the reverted batch subtracted once, got %d paid", stats.TotalPaidTransactions)
+	}
+}
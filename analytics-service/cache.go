@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// activeUsersResolution is the bucket size of the active user windows, so
+// their counts only change at multiples of it
+const activeUsersResolution = time.Minute
+
+// LastModified is when the stats last changed as of now: the last event
+// processed, or the last time the active user windows slid, if later
+func (a *Analytics) LastModified(now time.Time) time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if slid := now.Truncate(activeUsersResolution); slid.After(a.modifiedAt) {
+		return slid
+	}
+	return a.modifiedAt
+}
+
+// statsCacheControl is the Cache-Control of /stats: revalidate every time,
+// or reuse for maxAge first when it is positive
+func statsCacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return "private, no-cache"
+	}
+	return "private, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// notModified sets Last-Modified and reports whether r's If-Modified-Since
+// shows the client already has the stats as of modified.
+//
+// HTTP dates have whole seconds, so a change later in the same second would
+// carry the same date. No Last-Modified is given while modified is in the
+// current second; a date a client sends back is then always from a second
+// that had ended, and any later change compares after it.
+func notModified(w http.ResponseWriter, r *http.Request, modified, now time.Time) bool {
+	modified = modified.Truncate(time.Second)
+	if !modified.Before(now.Truncate(time.Second)) {
+		return false
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
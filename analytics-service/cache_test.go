@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/tkaewplik/go-microservices/proto/analytics"
+)
+
+func TestAnalytics_LastModifiedSlidesWithActiveUsers(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	now := time.Now()
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 1, Timestamp: now})
+
+	if got := a.LastModified(now); got.Before(now.Truncate(activeUsersResolution)) {
+		t.Errorf("expected at least the current window start, got %v", got)
+	}
+	later := now.Add(3 * activeUsersResolution)
+	if got := a.LastModified(later); !got.Equal(later.Truncate(activeUsersResolution)) {
+		t.Errorf("expected the active user windows to have slid to %v, got %v", later.Truncate(activeUsersResolution), got)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 30, 500, time.UTC)
+	modified := now.Add(-10 * time.Second)
+
+	r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+	if notModified(w, r, modified, now) {
+		t.Error("expected a request without If-Modified-Since to get the stats")
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("expected a Last-Modified header")
+	}
+
+	r.Header.Set("If-Modified-Since", lastModified)
+	if !notModified(httptest.NewRecorder(), r, modified, now) {
+		t.Error("expected 304 for an unchanged resource")
+	}
+	if notModified(httptest.NewRecorder(), r, modified.Add(2*time.Second), now) {
+		t.Error("expected a later change to be sent")
+	}
+
+	// A change in the current second gets no validator
+	w = httptest.NewRecorder()
+	if notModified(w, r, now, now) || w.Header().Get("Last-Modified") != "" {
+		t.Errorf("expected no Last-Modified within the current second, got %q", w.Header().Get("Last-Modified"))
+	}
+}
+
+func TestAnalyticsServer_GetStatsIfChanged(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 5, Timestamp: time.Now()})
+	s := NewAnalyticsServer(a, nil, NewEventFeed())
+
+	resp, err := s.GetStatsIfChanged(context.Background(), &pb.GetStatsIfChangedRequest{Facets: []string{"channel"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.GetChanged() || resp.GetStats().GetTotalTransactions() != 1 || resp.GetStats().GetFacets()[FacetChannel] == nil {
+		t.Fatalf("expected the stats with the channel breakdown, got %+v", resp)
+	}
+
+	resp, err = s.GetStatsIfChanged(context.Background(), &pb.GetStatsIfChangedRequest{Since: resp.GetLastModified()})
+	if err != nil || resp.GetChanged() || resp.GetStats() != nil {
+		t.Errorf("expected unchanged stats, got %+v, %v", resp, err)
+	}
+
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, TransactionsPaid: 1, Timestamp: time.Now()})
+	resp, _ = s.GetStatsIfChanged(context.Background(), &pb.GetStatsIfChangedRequest{Since: timestamppb.New(time.Now().Add(-time.Hour))})
+	if !resp.GetChanged() || resp.GetStats().GetTotalPaidTransactions() != 1 {
+		t.Errorf("expected the changed stats, got %+v", resp)
+	}
+
+	if _, err := s.GetStatsIfChanged(context.Background(), &pb.GetStatsIfChangedRequest{Facets: []string{"city"}}); err == nil {
+		t.Error("expected an unknown facet to be rejected")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cluster merges stats from analytics replicas.
+//
+// Replicas share one Kafka consumer group, so each owns a disjoint set of
+// partitions and totals can simply be summed. Events are keyed by user_id;
+// when the producer partitions by key the state is also user-affine, so
+// unique and active user counts sum exactly. With a key-agnostic balancer
+// a user may be counted on several replicas.
+type Cluster struct {
+	peers  []string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewCluster creates a cluster view over the given peer base URLs (excluding self)
+func NewCluster(peers []string, timeout time.Duration, logger *slog.Logger) *Cluster {
+	trimmed := make([]string, 0, len(peers))
+	for _, p := range peers {
+		if p = strings.TrimRight(strings.TrimSpace(p), "/"); p != "" {
+			trimmed = append(trimmed, p)
+		}
+	}
+	return &Cluster{
+		peers:  trimmed,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// Enabled reports whether any peers are configured
+func (c *Cluster) Enabled() bool {
+	return len(c.peers) > 0
+}
+
+// Gather fetches the local stats of every peer, with the facets dimensions
+// and include views, and merges them with local. Unreachable peers are skipped and counted in
+// PeersFailed.
+func (c *Cluster) Gather(ctx context.Context, local Stats, facets, include []string) Stats {
+	results := make([]*Stats, len(c.peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range c.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			stats, err := c.fetch(ctx, peer, facets, include)
+			if err != nil {
+				c.logger.Warn("failed to fetch peer stats", "peer", peer, "error", err)
+				return
+			}
+			results[i] = stats
+		}(i, peer)
+	}
+	wg.Wait()
+
+	merged := newMergedStats(local)
+	for _, peer := range results {
+		if peer == nil {
+			merged.PeersFailed++
+			continue
+		}
+		merged.merge(peer)
+	}
+	return merged
+}
+
+func (c *Cluster) fetch(ctx context.Context, peer string, facets, include []string) (*Stats, error) {
+	query := url.Values{"scope": {"local"}}
+	if len(facets) > 0 {
+		query.Set("facets", strings.Join(facets, ","))
+	}
+	if len(include) > 0 {
+		query.Set("include", strings.Join(include, ","))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer+"/stats?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.logger.Error("failed to close peer response", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var stats Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// GatherAlerts merges every peer's local alert status for userID into
+// local. Unreachable peers are skipped.
+func (c *Cluster) GatherAlerts(ctx context.Context, local AlertStatus) AlertStatus {
+	results := make([]*AlertStatus, len(c.peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range c.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			var status AlertStatus
+			if err := c.do(ctx, http.MethodGet, peer, alertsQuery(local.UserID), nil, &status); err != nil {
+				c.logger.Warn("failed to fetch peer alerts", "peer", peer, "error", err)
+				return
+			}
+			results[i] = &status
+		}(i, peer)
+	}
+	wg.Wait()
+
+	merged := local
+	merged.Alerts = slices.Clone(local.Alerts)
+	for _, peer := range results {
+		if peer != nil {
+			merged.mergeStatus(peer)
+		}
+	}
+	return merged
+}
+
+// SetThreshold sets userID's threshold on every peer, as any of them may
+// own the user's partition. It returns the first failure after trying all.
+func (c *Cluster) SetThreshold(ctx context.Context, userID int, threshold float64) error {
+	body, err := json.Marshal(thresholdRequest{Threshold: threshold})
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(c.peers))
+	var wg sync.WaitGroup
+	for i, peer := range c.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			if err := c.do(ctx, http.MethodPut, peer, alertsQuery(userID), body, nil); err != nil {
+				errs[i] = fmt.Errorf("peer %s: %w", peer, err)
+			}
+		}(i, peer)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// alertsQuery addresses a replica's own alert state for userID
+func alertsQuery(userID int) string {
+	return "/alerts?" + url.Values{"scope": {"local"}, "user_id": {strconv.Itoa(userID)}}.Encode()
+}
+
+// do sends body to peer+path and decodes the response into out, if set
+func (c *Cluster) do(ctx context.Context, method, peer, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, peer+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.logger.Error("failed to close peer response", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// newMergedStats copies local as the starting point of a merge, so merging
+// peers doesn't modify its maps
+func newMergedStats(local Stats) Stats {
+	merged := local
+	merged.Instances = 1
+	merged.ActiveUsers = maps.Clone(local.ActiveUsers)
+	if merged.ActiveUsers == nil {
+		merged.ActiveUsers = make(map[string]int)
+	}
+	if local.Facets != nil {
+		merged.Facets = make(map[string]map[string]FacetTotals, len(local.Facets))
+		for dim, values := range local.Facets {
+			merged.Facets[dim] = maps.Clone(values)
+		}
+	}
+	return merged
+}
+
+// merge adds a peer's partition-local stats into s
+func (s *Stats) merge(peer *Stats) {
+	s.Instances++
+	s.TotalTransactions += peer.TotalTransactions
+	s.TotalAmount += peer.TotalAmount
+	s.TotalPaidTransactions += peer.TotalPaidTransactions
+	s.PendingPaidTransactions += peer.PendingPaidTransactions
+	s.EventsProcessed += peer.EventsProcessed
+	s.UniqueUsers += peer.UniqueUsers
+	if peer.LastEventTime > s.LastEventTime {
+		// RFC 3339 timestamps in UTC order lexically
+		s.LastEventTime = peer.LastEventTime
+	}
+	for window, count := range peer.ActiveUsers {
+		s.ActiveUsers[window] += count
+	}
+	for dim, values := range s.Facets {
+		for value, totals := range peer.Facets[dim] {
+			merged := values[value]
+			merged.Transactions += totals.Transactions
+			merged.Amount += totals.Amount
+			values[value] = merged
+		}
+	}
+	s.mergeViews(peer)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// amountBounds are the upper bounds of the amount distribution buckets; the
+// last bucket holds everything above them
+var amountBounds = []float64{10, 50, 100, 500, 1000, 5000}
+
+// AmountBucket counts created transactions with an amount up to Le, or above
+// every bound when Le is absent
+type AmountBucket struct {
+	Le           *float64 `json:"le,omitempty"`
+	Transactions int64    `json:"transactions"`
+	Amount       float64  `json:"amount"`
+}
+
+// distributionAggregator buckets created transactions by amount
+type distributionAggregator struct {
+	buckets []AmountBucket
+}
+
+func newDistributionAggregator() *distributionAggregator {
+	buckets := make([]AmountBucket, len(amountBounds)+1)
+	for i := range amountBounds {
+		buckets[i].Le = &amountBounds[i]
+	}
+	return &distributionAggregator{buckets: buckets}
+}
+
+func (d *distributionAggregator) Name() string { return "distribution" }
+
+func (d *distributionAggregator) Handle(event *TransactionEvent, _ time.Time) {
+	if event.EventType != "transaction.created" {
+		return
+	}
+	b := &d.buckets[d.index(event.Amount)]
+	b.Transactions++
+	b.Amount += event.Amount
+}
+
+func (d *distributionAggregator) index(amount float64) int {
+	for i, le := range amountBounds {
+		if amount <= le {
+			return i
+		}
+	}
+	return len(amountBounds)
+}
+
+// Snapshot copies the buckets, smallest amounts first
+func (d *distributionAggregator) Snapshot() []AmountBucket {
+	out := make([]AmountBucket, len(d.buckets))
+	copy(out, d.buckets)
+	return out
+}
+
+// bucketLe is b's upper bound, +Inf for the last bucket
+func bucketLe(b AmountBucket) float64 {
+	if b.Le == nil {
+		return math.Inf(1)
+	}
+	return *b.Le
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Facet dimensions of transaction source metadata
+const (
+	FacetChannel = "channel"
+	FacetCountry = "country"
+)
+
+// facetDimensions lists every dimension, in the order /stats?facets=all uses
+var facetDimensions = []string{FacetChannel, FacetCountry}
+
+const (
+	// unknownFacet counts events without the dimension, such as those
+	// published before sources were recorded
+	unknownFacet = "unknown"
+	// otherFacet counts values beyond maxFacetValues
+	otherFacet = "other"
+	// maxFacetValues bounds the values kept per dimension, so a misbehaving
+	// producer can't grow the breakdown without limit
+	maxFacetValues = 300
+)
+
+// FacetTotals are the created transactions for one value of a dimension
+type FacetTotals struct {
+	Transactions int64   `json:"transactions"`
+	Amount       float64 `json:"amount"`
+}
+
+// SourceFacets breaks created transactions down by where they came from
+type SourceFacets struct {
+	values map[string]map[string]*FacetTotals
+}
+
+// NewSourceFacets creates empty breakdowns for every dimension
+func NewSourceFacets() *SourceFacets {
+	values := make(map[string]map[string]*FacetTotals, len(facetDimensions))
+	for _, dim := range facetDimensions {
+		values[dim] = make(map[string]*FacetTotals)
+	}
+	return &SourceFacets{values: values}
+}
+
+// Add counts a created transaction under each dimension of source, which may
+// be nil
+func (f *SourceFacets) Add(source *EventSource, amount float64) {
+	var channel, country string
+	if source != nil {
+		channel, country = strings.ToLower(source.Channel), strings.ToUpper(source.Country)
+	}
+	f.add(FacetChannel, channel, amount)
+	f.add(FacetCountry, country, amount)
+}
+
+func (f *SourceFacets) add(dim, value string, amount float64) {
+	if value == "" {
+		value = unknownFacet
+	}
+	values := f.values[dim]
+	totals, ok := values[value]
+	if !ok {
+		if len(values) >= maxFacetValues {
+			value = otherFacet
+		}
+		if totals, ok = values[value]; !ok {
+			totals = &FacetTotals{}
+			values[value] = totals
+		}
+	}
+	totals.Transactions++
+	totals.Amount += amount
+}
+
+func (f *SourceFacets) Name() string { return "facets" }
+
+// Handle counts created transactions under their source
+func (f *SourceFacets) Handle(event *TransactionEvent, _ time.Time) {
+	if event.EventType == "transaction.created" {
+		f.Add(event.Source, event.Amount)
+	}
+}
+
+// Snapshot copies the breakdowns of dims
+func (f *SourceFacets) Snapshot(dims []string) map[string]map[string]FacetTotals {
+	out := make(map[string]map[string]FacetTotals, len(dims))
+	for _, dim := range dims {
+		values := make(map[string]FacetTotals, len(f.values[dim]))
+		for value, totals := range f.values[dim] {
+			values[value] = *totals
+		}
+		out[dim] = values
+	}
+	return out
+}
+
+// parseFacets parses the facets query parameter: a comma-separated list of
+// dimensions, or "all"
+func parseFacets(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s == "all" {
+		return facetDimensions, nil
+	}
+
+	var dims []string
+	for _, dim := range strings.Split(s, ",") {
+		dim = strings.TrimSpace(dim)
+		if !slices.Contains(facetDimensions, dim) {
+			return nil, fmt.Errorf("unknown facet %q", dim)
+		}
+		dims = append(dims, dim)
+	}
+	return dims, nil
+}
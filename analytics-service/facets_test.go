@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSourceFacets_AggregatesByDimension(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	for _, event := range []*TransactionEvent{
+		{EventType: "transaction.created", UserID: 1, Amount: 10, Source: &EventSource{Channel: "web", Country: "th"}},
+		{EventType: "transaction.created", UserID: 2, Amount: 5, Source: &EventSource{Channel: "mobile", Country: "TH"}},
+		{EventType: "transaction.created", UserID: 3, Amount: 1},
+		{EventType: "transaction.paid", UserID: 1, TransactionsPaid: 1, Source: &EventSource{Channel: "web"}},
+	} {
+		a.ProcessEvent(event)
+	}
+
+	facets := a.GetFacets(facetDimensions)
+	if got := facets[FacetCountry]["TH"]; got.Transactions != 2 || got.Amount != 15 {
+		t.Errorf("expected both TH transactions, got %+v", got)
+	}
+	if got := facets[FacetChannel]["web"]; got.Transactions != 1 || got.Amount != 10 {
+		t.Errorf("expected paid events to be left out, got %+v", got)
+	}
+	if got := facets[FacetChannel][unknownFacet]; got.Transactions != 1 {
+		t.Errorf("expected the unsourced event under %q, got %+v", unknownFacet, facets[FacetChannel])
+	}
+}
+
+func TestSourceFacets_BoundsValues(t *testing.T) {
+	f := NewSourceFacets()
+	for i := range maxFacetValues + 5 {
+		f.Add(&EventSource{Country: fmt.Sprintf("C%d", i)}, 1)
+	}
+
+	countries := f.Snapshot([]string{FacetCountry})[FacetCountry]
+	if len(countries) != maxFacetValues+1 || countries[otherFacet].Transactions != 5 {
+		t.Errorf("expected %d values plus %q with 5, got %d values, other %+v",
+			maxFacetValues, otherFacet, len(countries), countries[otherFacet])
+	}
+}
+
+func TestStats_MergeFacets(t *testing.T) {
+	local := Stats{Facets: map[string]map[string]FacetTotals{
+		FacetCountry: {"TH": {Transactions: 1, Amount: 10}},
+	}}
+	merged := newMergedStats(local)
+	merged.merge(&Stats{Facets: map[string]map[string]FacetTotals{
+		FacetCountry: {"TH": {Transactions: 2, Amount: 5}, "US": {Transactions: 1, Amount: 1}},
+	}})
+
+	if got := merged.Facets[FacetCountry]["TH"]; got.Transactions != 3 || got.Amount != 15 {
+		t.Errorf("unexpected TH totals %+v", got)
+	}
+	if got := merged.Facets[FacetCountry]["US"]; got.Transactions != 1 {
+		t.Errorf("expected the peer-only value, got %+v", got)
+	}
+	if local.Facets[FacetCountry]["TH"].Transactions != 1 {
+		t.Error("expected the local stats to be left untouched")
+	}
+}
+
+func TestParseFacets(t *testing.T) {
+	if dims, err := parseFacets("country, channel"); err != nil || len(dims) != 2 {
+		t.Errorf("expected two dimensions, got %v, %v", dims, err)
+	}
+	if dims, err := parseFacets("all"); err != nil || len(dims) != len(facetDimensions) {
+		t.Errorf("expected every dimension, got %v, %v", dims, err)
+	}
+	if _, err := parseFacets("city"); err == nil {
+		t.Error("expected an unknown dimension to be rejected")
+	}
+}
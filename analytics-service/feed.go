@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// feedBuffer is how many events a subscriber may fall behind by before it
+// is dropped
+const feedBuffer = 256
+
+// FeedFilter selects the events a subscriber receives. Filters are ANDed;
+// an empty filter matches everything.
+type FeedFilter struct {
+	UserIDs    []int
+	EventTypes []string
+}
+
+// Match reports whether event passes the filter
+func (f FeedFilter) Match(event *TransactionEvent) bool {
+	if len(f.UserIDs) > 0 && !slices.Contains(f.UserIDs, event.UserID) {
+		return false
+	}
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, event.EventType) {
+		return false
+	}
+	return true
+}
+
+// EventFeed relays processed events to in-process subscribers, so internal
+// services can follow them over gRPC without each running a Kafka consumer.
+// It is registered as an Aggregator, so it sees events once repeats have
+// been dropped. Publishing never blocks event processing: a subscriber whose
+// buffer is full is dropped, and its events channel closed.
+type EventFeed struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription is one subscriber's events
+type Subscription struct {
+	feed   *EventFeed
+	filter FeedFilter
+	events chan TransactionEvent
+}
+
+func NewEventFeed() *EventFeed {
+	return &EventFeed{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe starts relaying the events matching filter; Close the
+// subscription when done
+func (f *EventFeed) Subscribe(filter FeedFilter) *Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &Subscription{feed: f, filter: filter, events: make(chan TransactionEvent, feedBuffer)}
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Events delivers the subscribed events. It is closed when the subscriber
+// fell behind and was dropped.
+func (s *Subscription) Events() <-chan TransactionEvent {
+	return s.events
+}
+
+// Close stops the subscription; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	if _, ok := s.feed.subscribers[s]; ok {
+		delete(s.feed.subscribers, s)
+		close(s.events)
+	}
+}
+
+func (f *EventFeed) Name() string { return "feed" }
+
+// Handle sends event to every matching subscriber, dropping those that
+// can't take it
+func (f *EventFeed) Handle(event *TransactionEvent, _ time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- *event:
+		default:
+			delete(f.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
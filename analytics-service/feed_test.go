@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/tkaewplik/go-microservices/proto/analytics"
+)
+
+func TestEventFeed_RelaysMatchingEvents(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	feed := NewEventFeed()
+	a.Register(feed)
+
+	all := feed.Subscribe(FeedFilter{})
+	defer all.Close()
+	paid := feed.Subscribe(FeedFilter{UserIDs: []int{1}, EventTypes: []string{"transaction.paid"}})
+	defer paid.Close()
+
+	now := time.Now()
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 10, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 2, PaymentBatchID: "a", TransactionsPaid: 1, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+	// A redelivered payment is dropped before the feed sees it
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+
+	if n := len(all.Events()); n != 3 {
+		t.Errorf("expected 3 events for the unfiltered subscriber, got %d", n)
+	}
+	if n := len(paid.Events()); n != 1 {
+		t.Fatalf("expected 1 event for the filtered subscriber, got %d", n)
+	}
+	if event := <-paid.Events(); event.UserID != 1 || event.PaymentBatchID != "b" {
+		t.Errorf("expected user 1's paid event, got %+v", event)
+	}
+}
+
+func TestEventFeed_DropsSlowSubscriber(t *testing.T) {
+	feed := NewEventFeed()
+	sub := feed.Subscribe(FeedFilter{})
+	event := &TransactionEvent{EventType: "transaction.created", UserID: 1}
+	for range feedBuffer + 1 {
+		feed.Handle(event, time.Now())
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != feedBuffer {
+		t.Errorf("expected the %d buffered events before the channel closed, got %d", feedBuffer, received)
+	}
+	if len(feed.subscribers) != 0 {
+		t.Errorf("expected the subscriber dropped, got %d", len(feed.subscribers))
+	}
+	// Closing a dropped subscription is harmless
+	sub.Close()
+}
+
+func TestAnalyticsServer_SubscribeEvents(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	feed := NewEventFeed()
+	a.Register(feed)
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterAnalyticsServiceServer(server, NewAnalyticsServer(a, nil, feed))
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewAnalyticsServiceClient(conn).SubscribeEvents(ctx, &pb.SubscribeEventsRequest{EventTypes: []string{"transaction.created"}})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	waitForSubscribers(t, feed, 1)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 7, TransactionsPaid: 1, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{
+		EventType:     "transaction.created",
+		TransactionID: 42,
+		UserID:        7,
+		Amount:        12.5,
+		Source:        &EventSource{Channel: "web", Country: "TH"},
+		Timestamp:     now,
+	})
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+	if event.GetEventType() != "transaction.created" || event.GetTransactionId() != 42 || event.GetUserId() != 7 ||
+		event.GetAmount() != 12.5 || event.GetChannel() != "web" || event.GetCountry() != "TH" || !event.GetTimestamp().AsTime().Equal(now) {
+		t.Errorf("expected the created event, got %+v", event)
+	}
+
+	// The stream ends when the feed drops the subscriber
+	feed.mu.Lock()
+	for sub := range feed.subscribers {
+		delete(feed.subscribers, sub)
+		close(sub.events)
+	}
+	feed.mu.Unlock()
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func waitForSubscribers(t *testing.T, feed *EventFeed, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		feed.mu.Lock()
+		got := len(feed.subscribers)
+		feed.mu.Unlock()
+		if got == n {
+			return
+		}
+	}
+	t.Fatalf("expected %d subscribers", n)
+}
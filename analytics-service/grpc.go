@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/tkaewplik/go-microservices/proto/analytics"
+)
+
+// AnalyticsServer implements the gRPC AnalyticsService
+type AnalyticsServer struct {
+	pb.UnimplementedAnalyticsServiceServer
+	analytics *Analytics
+	cluster   *Cluster
+	feed      *EventFeed
+	now       func() time.Time
+}
+
+// NewAnalyticsServer creates an AnalyticsServer. With cluster enabled it
+// merges the peers' stats like /stats does. SubscribeEvents relays the
+// events published to feed.
+func NewAnalyticsServer(analytics *Analytics, cluster *Cluster, feed *EventFeed) *AnalyticsServer {
+	return &AnalyticsServer{analytics: analytics, cluster: cluster, feed: feed, now: time.Now}
+}
+
+// GetStatsIfChanged returns the stats unless they haven't changed since
+// req.Since. Merged cluster stats are always returned, as peers' changes
+// aren't known locally.
+func (s *AnalyticsServer) GetStatsIfChanged(ctx context.Context, req *pb.GetStatsIfChangedRequest) (*pb.GetStatsIfChangedResponse, error) {
+	facets, err := parseFacets(strings.Join(req.GetFacets(), ","))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	modified := s.analytics.LastModified(s.now())
+	resp := &pb.GetStatsIfChangedResponse{LastModified: timestamppb.New(modified)}
+	gather := s.cluster != nil && s.cluster.Enabled()
+	if req.Since != nil && !gather && !modified.After(req.Since.AsTime()) {
+		return resp, nil
+	}
+
+	stats := s.analytics.GetStats()
+	if len(facets) > 0 {
+		stats.Facets = s.analytics.GetFacets(facets)
+	}
+	if gather {
+		stats = s.cluster.Gather(ctx, stats, facets, nil)
+	}
+	resp.Changed = true
+	resp.Stats = statsToProto(stats)
+	return resp, nil
+}
+
+// SubscribeEvents streams the events processed from now on that match the
+// request's filters, until the client goes away or falls behind
+func (s *AnalyticsServer) SubscribeEvents(req *pb.SubscribeEventsRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	filter := FeedFilter{EventTypes: req.GetEventTypes()}
+	for _, userID := range req.GetUserIds() {
+		filter.UserIDs = append(filter.UserIDs, int(userID))
+	}
+	sub := s.feed.Subscribe(filter)
+	defer sub.Close()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-sub.Events():
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind the event feed")
+			}
+			if err := stream.Send(eventToProto(&event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(event *TransactionEvent) *pb.Event {
+	msg := &pb.Event{
+		EventType:            event.EventType,
+		Version:              int32(event.Version),
+		TransactionId:        int64(event.TransactionID),
+		UserId:               int64(event.UserID),
+		Amount:               event.Amount,
+		Description:          event.Description,
+		TransactionsPaid:     event.TransactionsPaid,
+		TransactionsReverted: event.TransactionsReverted,
+		PaymentBatchId:       event.PaymentBatchID,
+	}
+	if event.Source != nil {
+		msg.Channel = event.Source.Channel
+		msg.Country = event.Source.Country
+	}
+	if !event.Timestamp.IsZero() {
+		msg.Timestamp = timestamppb.New(event.Timestamp)
+	}
+	return msg
+}
+
+func statsToProto(stats Stats) *pb.Stats {
+	msg := &pb.Stats{
+		TotalTransactions:     stats.TotalTransactions,
+		TotalAmount:           stats.TotalAmount,
+		TotalPaidTransactions: stats.TotalPaidTransactions,
+		EventsProcessed:       stats.EventsProcessed,
+		LastEventTime:         stats.LastEventTime,
+		UniqueUsers:           int64(stats.UniqueUsers),
+		CardinalityMode:       stats.CardinalityMode,
+		ActiveUsers:           make(map[string]int64, len(stats.ActiveUsers)),
+		Instances:             int32(stats.Instances),
+		PeersFailed:           int32(stats.PeersFailed),
+	}
+	for window, n := range stats.ActiveUsers {
+		msg.ActiveUsers[window] = int64(n)
+	}
+	if len(stats.Facets) > 0 {
+		msg.Facets = make(map[string]*pb.FacetValues, len(stats.Facets))
+		for dim, values := range stats.Facets {
+			fv := &pb.FacetValues{Values: make(map[string]*pb.FacetTotals, len(values))}
+			for value, totals := range values {
+				fv.Values[value] = &pb.FacetTotals{Transactions: totals.Transactions, Amount: totals.Amount}
+			}
+			msg.Facets[dim] = fv
+		}
+	}
+	return msg
+}
@@ -3,77 +3,270 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/segmentio/kafka-go"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/tkaewplik/go-microservices/pkg/events"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+	"github.com/tkaewplik/go-microservices/pkg/middleware"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	pb "github.com/tkaewplik/go-microservices/proto/analytics"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
 )
 
 // TransactionEvent represents a transaction event from Kafka
 type TransactionEvent struct {
-	EventType        string    `json:"event_type"`
-	TransactionID    int       `json:"transaction_id,omitempty"`
-	UserID           int       `json:"user_id"`
-	Amount           float64   `json:"amount,omitempty"`
-	Description      string    `json:"description,omitempty"`
-	TransactionsPaid int64     `json:"transactions_paid,omitempty"`
-	Timestamp        time.Time `json:"timestamp"`
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version          int     `json:"version"`
+	TransactionID    int     `json:"transaction_id,omitempty"`
+	UserID           int     `json:"user_id"`
+	Amount           float64 `json:"amount,omitempty"`
+	Description      string  `json:"description,omitempty"`
+	TransactionsPaid int64   `json:"transactions_paid,omitempty"`
+	// TransactionsReverted is how many of a payment batch's transactions a
+	// transaction.payment_reverted event unpays
+	TransactionsReverted int64 `json:"transactions_reverted,omitempty"`
+	// PaymentBatchID identifies the payment behind a transaction.paid or
+	// transaction.payment_reverted event; absent on older events
+	PaymentBatchID string `json:"payment_batch_id,omitempty"`
+	// Source is where the request behind the event came from; absent on
+	// older events
+	Source    *EventSource `json:"source,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
 }
 
-// Analytics holds aggregated analytics data
+// EventSource is the client channel and country of an event
+type EventSource struct {
+	Channel string `json:"channel,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// AnalyticsConfig holds analytics aggregation settings
+type AnalyticsConfig struct {
+	CardinalityMode string // exact or approx
+	HLLPrecision    uint8  // approx mode: 2^precision bytes for distinct users
+	TopKCapacity    int    // approx mode: users tracked by the heavy-hitter sketches
+	MaxTrackedUsers int    // exact mode: LRU cap on per-user entries (0 = unlimited)
+	MaxActivePerMin int    // cap on users stored per active-user bucket (0 = unlimited)
+	PaidBatches     int    // payment batch IDs remembered to drop redelivered paid events (0 = default)
+	// PaidReconcileGrace is how long paid transactions wait for their created
+	// events before being counted anyway (0 = default)
+	PaidReconcileGrace time.Duration
+
+	AllowedLateness time.Duration // how far behind the newest event a window stays open
+	HourlyRetention int           // hourly windows kept
+	DailyRetention  int           // daily windows kept
+
+	AnomalyZScore     float64 // standard deviations above the mean amount that flag a transaction (0 = default)
+	AnomalyMinSamples int     // created transactions seen before any is flagged (0 = default)
+}
+
+// Analytics holds aggregated analytics data. Each view is kept by one of
+// its aggregators; Analytics drops repeated events and passes the rest to
+// all of them.
 type Analytics struct {
-	mu                    sync.RWMutex
-	TotalTransactions     int64           `json:"total_transactions"`
-	TotalAmount           float64         `json:"total_amount"`
-	TotalPaidTransactions int64           `json:"total_paid_transactions"`
-	EventsProcessed       int64           `json:"events_processed"`
-	LastEventTime         string          `json:"last_event_time,omitempty"`
-	TransactionsByUser    map[int]int64   `json:"transactions_by_user"`
-	AmountByUser          map[int]float64 `json:"amount_by_user"`
+	mu              sync.RWMutex
+	cfg             AnalyticsConfig
+	EventsProcessed int64  `json:"events_processed"`
+	LastEventTime   string `json:"last_event_time,omitempty"`
+	lastEventAt     time.Time
+	modifiedAt      time.Time // when an event last changed the aggregates
+	paidBatches     *paidBatches
+	duplicateEvents int64
+
+	aggregators  []Aggregator
+	totals       *totalsAggregator
+	users        *userAggregator
+	buckets      *timeBucketAggregator
+	facets       *SourceFacets
+	anomalies    *anomalyAggregator
+	distribution *distributionAggregator
+
+	viewLatency *viewLatency
 }
 
-func NewAnalytics() *Analytics {
-	return &Analytics{
-		TransactionsByUser: make(map[int]int64),
-		AmountByUser:       make(map[int]float64),
+func NewAnalytics(cfg AnalyticsConfig) *Analytics {
+	a := &Analytics{
+		cfg:          cfg,
+		paidBatches:  newPaidBatches(cfg.PaidBatches),
+		totals:       newTotalsAggregator(cfg),
+		users:        newUserAggregator(cfg),
+		buckets:      newTimeBucketAggregator(cfg),
+		facets:       NewSourceFacets(),
+		anomalies:    newAnomalyAggregator(cfg),
+		distribution: newDistributionAggregator(),
+		viewLatency:  newViewLatency(),
 	}
+	a.aggregators = []Aggregator{a.totals, a.users, a.buckets, a.facets, a.anomalies, a.distribution}
+	return a
+}
+
+// Register adds an aggregator that sees every event processed from now on
+func (a *Analytics) Register(agg Aggregator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.aggregators = append(a.aggregators, agg)
 }
 
 func (a *Analytics) ProcessEvent(event *TransactionEvent) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	// A payment event is published again when a publish is retried; the
+	// repeat is dropped whole
+	switch event.EventType {
+	case "transaction.paid", "transaction.payment_reverted":
+		if event.PaymentBatchID != "" && !a.paidBatches.Add(event.EventType+"/"+event.PaymentBatchID) {
+			a.duplicateEvents++
+			return
+		}
+	}
+
+	now := time.Now()
 	a.EventsProcessed++
-	a.LastEventTime = event.Timestamp.Format(time.RFC3339)
+	a.LastEventTime = event.Timestamp.UTC().Format(time.RFC3339)
+	a.lastEventAt = event.Timestamp
+	a.modifiedAt = now
+	for _, agg := range a.aggregators {
+		agg.Handle(event, now)
+	}
+}
 
-	switch event.EventType {
-	case "transaction.created":
-		a.TotalTransactions++
-		a.TotalAmount += event.Amount
-		a.TransactionsByUser[event.UserID]++
-		a.AmountByUser[event.UserID] += event.Amount
-	case "transaction.paid":
-		a.TotalPaidTransactions += event.TransactionsPaid
+// Stats is the /stats response
+type Stats struct {
+	TotalTransactions     int64   `json:"total_transactions"`
+	TotalAmount           float64 `json:"total_amount"`
+	TotalPaidTransactions int64   `json:"total_paid_transactions"`
+	// PendingPaidTransactions were reported paid before their created
+	// events arrived, and aren't counted in TotalPaidTransactions yet
+	PendingPaidTransactions int64          `json:"pending_paid_transactions,omitempty"`
+	EventsProcessed         int64          `json:"events_processed"`
+	LastEventTime           string         `json:"last_event_time"`
+	UniqueUsers             int            `json:"unique_users"`
+	CardinalityMode         string         `json:"cardinality_mode"`
+	ActiveUsers             map[string]int `json:"active_users"`
+	Instances               int            `json:"instances,omitempty"`
+	PeersFailed             int            `json:"peers_failed,omitempty"`
+	// Facets break created transactions down by the dimensions requested
+	// with ?facets=
+	Facets map[string]map[string]FacetTotals `json:"facets,omitempty"`
+	// The optional views requested with ?include=
+	PerUser      *PerUserView      `json:"per_user,omitempty"`
+	TimeSeries   *TimeSeriesView   `json:"time_series,omitempty"`
+	Distribution *DistributionView `json:"distribution,omitempty"`
+}
+
+func (a *Analytics) GetStats() Stats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return Stats{
+		TotalTransactions:       a.totals.transactions,
+		TotalAmount:             a.totals.amount,
+		TotalPaidTransactions:   a.totals.paid - a.totals.reverted,
+		PendingPaidTransactions: a.totals.ledger.pending,
+		EventsProcessed:         a.EventsProcessed,
+		LastEventTime:           a.LastEventTime,
+		UniqueUsers:             a.users.users.UniqueUsers(),
+		CardinalityMode:         a.cfg.CardinalityMode,
+		ActiveUsers:             a.users.active.Snapshot(time.Now()),
+	}
+}
+
+// GetFacets returns the breakdowns of created transactions by dims
+func (a *Analytics) GetFacets(dims []string) map[string]map[string]FacetTotals {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.facets.Snapshot(dims)
+}
+
+// TimeSeries is the /stats/timeseries response
+type TimeSeries struct {
+	Interval   string       `json:"interval"`
+	Watermark  time.Time    `json:"watermark"`
+	LateEvents int64        `json:"late_events"`
+	Buckets    []TimeBucket `json:"buckets"`
+	pagination.PageInfo
+}
+
+// Page keeps the buckets of page, oldest first. The cursor is the start of
+// the last bucket of the previous page; buckets evicted since are skipped.
+func (ts TimeSeries) Page(page pagination.PageRequest) (TimeSeries, error) {
+	page = page.Normalize(0)
+	buckets := ts.Buckets
+	if page.Cursor != "" {
+		var after time.Time
+		if err := pagination.DecodeCursor(page.Cursor, &after); err != nil {
+			return TimeSeries{}, err
+		}
+		i := sort.Search(len(buckets), func(i int) bool { return buckets[i].Start.After(after) })
+		buckets = buckets[i:]
+	}
+	if page.Paged() {
+		buckets = buckets[:min(len(buckets), page.FetchLimit())]
+	}
+
+	paged, err := pagination.NewPage(buckets, page, int64(len(ts.Buckets)), func(b TimeBucket) any { return b.Start })
+	if err != nil {
+		return TimeSeries{}, err
 	}
+	ts.Buckets, ts.PageInfo = paged.Items, paged.PageInfo
+	return ts, nil
+}
+
+// GetTimeSeries returns the hourly or daily event-time series
+func (a *Analytics) GetTimeSeries(interval string) (TimeSeries, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	windows := a.buckets.windows(interval)
+	if windows == nil {
+		return TimeSeries{}, false
+	}
+
+	return TimeSeries{
+		Interval:   interval,
+		Watermark:  windows.Watermark(),
+		LateEvents: windows.LateEvents(),
+		Buckets:    windows.Series(),
+	}, true
+}
+
+// GetAnomalies returns the transactions flagged for their amount
+func (a *Analytics) GetAnomalies() Anomalies {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.anomalies.Snapshot()
 }
 
-func (a *Analytics) GetStats() map[string]interface{} {
+// GetMemoryStats reports the sizes of the per-user structures
+func (a *Analytics) GetMemoryStats() map[string]interface{} {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	return map[string]interface{}{
-		"total_transactions":      a.TotalTransactions,
-		"total_amount":            a.TotalAmount,
-		"total_paid_transactions": a.TotalPaidTransactions,
-		"events_processed":        a.EventsProcessed,
-		"last_event_time":         a.LastEventTime,
-		"unique_users":            len(a.TransactionsByUser),
+		"users":        a.users.users.MemoryStats(),
+		"active_users": a.users.active.MemoryStats(),
 	}
 }
 
@@ -87,60 +280,149 @@ func main() {
 	// Kafka configuration
 	brokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
 	topic := getEnv("KAFKA_TOPIC", "transactions")
+	// KAFKA_TOPICS adds the topics the payment service's KAFKA_TOPIC_ROUTES
+	// sends events to
+	topics := splitTopics(getEnv("KAFKA_TOPICS", topic))
 	groupID := getEnv("KAFKA_GROUP_ID", "analytics-consumer")
 	port := getEnv("PORT", "8083")
+	metricsTopN := getEnvInt("METRICS_TOP_N", 10)
+	statsMaxAge := getEnvDuration("STATS_CACHE_MAX_AGE", 0)
+	grpcPort := getEnv("GRPC_PORT", "50053")
 
 	// Create analytics aggregator
-	analytics := NewAnalytics()
-
-	// Create Kafka reader
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        brokers,
-		Topic:          topic,
-		GroupID:        groupID,
-		MinBytes:       1,
-		MaxBytes:       10e6,
-		CommitInterval: time.Second,
-		StartOffset:    kafka.FirstOffset,
+	analyticsCfg := AnalyticsConfig{
+		CardinalityMode:    getEnv("CARDINALITY_MODE", CardinalityExact),
+		HLLPrecision:       uint8(getEnvInt("HLL_PRECISION", 14)),
+		TopKCapacity:       getEnvInt("TOPK_CAPACITY", 1000),
+		MaxTrackedUsers:    getEnvInt("MAX_TRACKED_USERS", 100000),
+		MaxActivePerMin:    getEnvInt("MAX_ACTIVE_USERS_PER_MINUTE", 100000),
+		PaidBatches:        getEnvInt("PAID_BATCH_DEDUPE_SIZE", defaultPaidBatches),
+		PaidReconcileGrace: getEnvDuration("PAID_RECONCILE_GRACE", defaultPaidReconcileGrace),
+		AllowedLateness:    getEnvDuration("WINDOW_ALLOWED_LATENESS", time.Hour),
+		HourlyRetention:    getEnvInt("WINDOW_HOURLY_RETENTION", 48),
+		DailyRetention:     getEnvInt("WINDOW_DAILY_RETENTION", 30),
+		AnomalyZScore:      getEnvFloat("ANOMALY_Z_SCORE", defaultAnomalyZScore),
+		AnomalyMinSamples:  getEnvInt("ANOMALY_MIN_SAMPLES", defaultAnomalyMinSamples),
+	}
+	analytics := NewAnalytics(analyticsCfg)
+
+	// Peer replicas for scatter-gather /stats
+	var peers []string
+	if v := getEnv("ANALYTICS_PEERS", ""); v != "" {
+		peers = strings.Split(v, ",")
+	}
+	cluster := NewCluster(peers, 2*time.Second, logger)
+
+	// Spend alerts are published for the notification service to deliver
+	alerts := NewSpendAlerts()
+	alertTopic := getEnv("ALERTS_TOPIC", "alerts")
+	alertPublisher := NewKafkaAlertPublisher(brokers, alertTopic)
+
+	// BACKFILL_ENABLED loads every transaction from payment-service before
+	// consuming, so stats are complete from the start rather than covering
+	// only the events still in the topic. A replica only consumes some
+	// partitions, so a cluster can't backfill without counting twice.
+	var backfilled *Backfill
+	if getEnv("BACKFILL_ENABLED", "false") == "true" {
+		if cluster.Enabled() {
+			logger.Warn("backfill skipped: not supported with ANALYTICS_PEERS")
+		} else {
+			locker, closeLocker := backfillLockerFromEnv(logger)
+			b, seeded, seededAlerts, err := backfill(analyticsCfg, locker)
+			closeLocker()
+			if err != nil {
+				logger.Error("backfill failed; counting consumed events only", "error", err)
+			} else {
+				analytics, alerts, backfilled = seeded, seededAlerts, b
+				logger.Info("backfill complete", "transactions", b.Transactions, "cutoff", b.Cutoff)
+			}
+		}
+	}
+
+	// Consumed events are relayed to SubscribeEvents streams; backfilled
+	// transactions aren't, as they happened before anyone subscribed
+	feed := NewEventFeed()
+	analytics.Register(feed)
+
+	// RULES_FILE loads alerting rules, evaluated every RULES_INTERVAL
+	// against the events consumed from then on. A replica only sees its own
+	// partitions, so rules aren't evaluated with ANALYTICS_PEERS.
+	var rules *RuleEngine
+	if path := getEnv("RULES_FILE", ""); path != "" {
+		if cluster.Enabled() {
+			logger.Warn("alerting rules skipped: not supported with ANALYTICS_PEERS")
+		} else {
+			parsed, err := LoadRules(path)
+			if err != nil {
+				logger.Error("failed to load alerting rules", "error", err, "path", path)
+				os.Exit(1)
+			}
+			rules = NewRuleEngine(parsed, alertPublisher.PublishRuleAlert, logger)
+			analytics.Register(rules)
+			logger.Info("alerting rules loaded", "rules", len(parsed))
+		}
+	}
+
+	// PRIORITY_TOPICS reads each topic with its .high and .bulk topics,
+	// always handling a waiting refund or payment failure before routine
+	// events; otherwise one consumer reads the topics
+	kafkaCfg := messaging.KafkaConfig{Brokers: brokers}
+	priorityTopics := getEnv("PRIORITY_TOPICS", "false") == "true"
+	consumer := eventSubscriber(topics, priorityTopics, func(topics []string) messaging.Subscriber {
+		return messaging.NewKafkaConsumer(kafkaCfg, topics, groupID, logger)
 	})
 
 	logger.Info("analytics service starting",
 		"port", port,
 		"kafka_brokers", brokers,
-		"kafka_topic", topic,
+		"kafka_topics", topics,
 		"kafka_group", groupID,
+		"priority_topics", priorityTopics,
+		"peers", peers,
 	)
 
 	// Context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if rules != nil {
+		go rules.Run(ctx, getEnvDuration("RULES_INTERVAL", defaultRuleInterval))
+	}
+
+	// processEvent updates the stats and spend alerts with one event
+	upcaster := events.Default()
+	processEvent := func(value []byte) (*TransactionEvent, error) {
+		event, err := decodeEvent(upcaster, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if backfilled.Skip(event) {
+			return event, nil
+		}
+		analytics.ProcessEvent(event)
+		if alert := alerts.Record(event); alert != nil {
+			if err := alertPublisher.Publish(ctx, alert); err != nil {
+				logger.Error("failed to publish spend alert", "error", err, "user_id", alert.UserID)
+			} else {
+				logger.Info("spend alert published", "user_id", alert.UserID, "period", alert.Period, "threshold", alert.Threshold)
+			}
+		}
+		return event, nil
+	}
+
 	// Start Kafka consumer in background
 	go func() {
-		for {
-			msg, err := reader.ReadMessage(ctx)
+		err := consumer.Consume(ctx, func(_, value []byte) error {
+			event, err := processEvent(value)
 			if err != nil {
-				if ctx.Err() != nil {
-					return // Context cancelled
-				}
-				logger.Error("failed to read message", "error", err)
-				continue
-			}
-
-			var event TransactionEvent
-			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				logger.Error("failed to unmarshal event", "error", err)
-				continue
+				return err
 			}
-
-			analytics.ProcessEvent(&event)
-
-			logger.Info("event processed",
-				"event_type", event.EventType,
-				"user_id", event.UserID,
-				"partition", msg.Partition,
-				"offset", msg.Offset,
-			)
+			logger.Info("event processed", "event_type", event.EventType, "user_id", event.UserID)
+			return nil
+		})
+		if err != nil {
+			logger.Error("Kafka consumer stopped", "error", err)
 		}
 	}()
 
@@ -156,15 +438,196 @@ func main() {
 		}
 	})
 
-	// Analytics stats endpoint
+	// Analytics stats endpoint; merges peer replicas unless scope=local.
+	// facets=channel,country (or all) adds per-dimension breakdowns, and
+	// include=per_user,time_series,distribution (or all) the costlier views.
+	// Local stats answer If-Modified-Since with 304 when nothing changed.
 	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		facets, err := parseFacets(r.URL.Query().Get("facets"))
+		var include []string
+		if err == nil {
+			include, err = parseInclude(r.URL.Query().Get("include"))
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Cache-Control", statsCacheControl(statsMaxAge))
+		// Peers' changes aren't known here, so merged stats are always sent
+		gather := cluster.Enabled() && r.URL.Query().Get("scope") != "local"
+		if now := time.Now(); !gather && notModified(w, r, analytics.LastModified(now), now) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		stats := analytics.GetStats()
+		if len(facets) > 0 {
+			stats.Facets = analytics.GetFacets(facets)
+		}
+		analytics.AddViews(&stats, include)
+		if gather {
+			stats = cluster.Gather(r.Context(), stats, facets, include)
+		}
 		if err := json.NewEncoder(w).Encode(stats); err != nil {
 			logger.Error("failed to encode stats", "error", err)
 		}
 	})
 
+	// Event-time series: /stats/timeseries?interval=hourly|daily, paged
+	// oldest first with ?limit= and ?cursor=
+	// Spend alerts of one user: GET returns the threshold, spend this month
+	// and recent alerts; PUT {"threshold": n} sets the threshold on every
+	// replica, 0 turning alerts off
+	mux.HandleFunc("/alerts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+		if err != nil || userID <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "user_id must be a positive integer"})
+			return
+		}
+		gather := cluster.Enabled() && r.URL.Query().Get("scope") != "local"
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req thresholdRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid request body"})
+				return
+			}
+			if err := alerts.SetThreshold(userID, req.Threshold); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			if gather {
+				if err := cluster.SetThreshold(r.Context(), userID, req.Threshold); err != nil {
+					logger.Error("failed to set threshold on peers", "error", err, "user_id", userID)
+					w.WriteHeader(http.StatusBadGateway)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": "failed to reach every replica; retry"})
+					return
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := alerts.Status(userID, time.Now())
+		if gather {
+			status = cluster.GatherAlerts(r.Context(), status)
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Error("failed to encode alerts", "error", err)
+		}
+	})
+
+	mux.HandleFunc("/stats/timeseries", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		interval := r.URL.Query().Get("interval")
+		if interval == "" {
+			interval = "hourly"
+		}
+		series, ok := analytics.GetTimeSeries(interval)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"interval must be hourly or daily"}`))
+			return
+		}
+		page, err := pagination.FromQuery(r.URL.Query())
+		if err == nil {
+			series, err = series.Page(page)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid limit or cursor"}`))
+			return
+		}
+		if err := json.NewEncoder(w).Encode(series); err != nil {
+			logger.Error("failed to encode time series", "error", err)
+		}
+	})
+
+	// Consumer lag, today's volume and active users for the gateway's
+	// /admin/overview; merges peer replicas unless scope=local
+	consumerLag := func() int64 {
+		if lagging, ok := consumer.(interface{ Lag() int64 }); ok {
+			return lagging.Lag()
+		}
+		return 0
+	}
+	mux.HandleFunc("/stats/overview", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		overview := analytics.GetOverview(time.Now(), consumerLag())
+		if cluster.Enabled() && r.URL.Query().Get("scope") != "local" {
+			overview = cluster.GatherOverview(r.Context(), overview)
+		}
+		if err := json.NewEncoder(w).Encode(overview); err != nil {
+			logger.Error("failed to encode overview", "error", err)
+		}
+	})
+
+	// Created transactions flagged for an unusually high amount, newest
+	// first; local to this replica
+	mux.HandleFunc("/stats/anomalies", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(analytics.GetAnomalies()); err != nil {
+			logger.Error("failed to encode anomalies", "error", err)
+		}
+	})
+
+	// Alerting rules firing now, by rule name; empty without RULES_FILE
+	mux.HandleFunc("/alerts/active", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		active := []RuleAlert{}
+		if rules != nil {
+			active = rules.Active()
+		}
+		if err := json.NewEncoder(w).Encode(active); err != nil {
+			logger.Error("failed to encode active alerts", "error", err)
+		}
+	})
+
+	// Memory usage of the per-user aggregates
+	mux.HandleFunc("/stats/memory", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(analytics.GetMemoryStats()); err != nil {
+			logger.Error("failed to encode memory stats", "error", err)
+		}
+	})
+
+	// Business KPIs are pushed over OTLP when an endpoint is configured
+	shutdownMetrics, err := SetupOTLPMetrics(context.Background(), analytics, metricsTopN)
+	if err != nil {
+		logger.Error("failed to set up OTLP metrics", "error", err)
+		os.Exit(1)
+	}
+	if OTLPMetricsEnabled() {
+		logger.Info("pushing metrics over OTLP")
+	}
+
+	// The same KPIs can also be scraped from /metrics. METRICS_ALLOW_CIDRS
+	// and METRICS_DENY_CIDRS limit who may scrape it; private networks only
+	// by default.
+	if getEnv("METRICS_ENDPOINT_ENABLED", "true") == "true" {
+		metricsFilter, err := middleware.ParseIPFilter(getEnv("METRICS_ALLOW_CIDRS", ""), getEnv("METRICS_DENY_CIDRS", ""))
+		if err != nil {
+			logger.Error("invalid metrics access list", "error", err)
+			os.Exit(1)
+		}
+		mux.Handle("/metrics", metricsFilter.Restrict(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			if err := analytics.WriteMetrics(w, metricsTopN); err != nil {
+				logger.Error("failed to write metrics", "error", err)
+			}
+		})))
+	}
+
 	// Start HTTP server
 	server := &http.Server{
 		Addr:    ":" + port,
@@ -177,6 +640,22 @@ func main() {
 		}
 	}()
 
+	// gRPC server for pollers that only want changed stats
+	grpcServer := grpc.NewServer()
+	pb.RegisterAnalyticsServiceServer(grpcServer, NewAnalyticsServer(analytics, cluster, feed))
+	go func() {
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			logger.Error("failed to listen for gRPC", "error", err, "port", grpcPort)
+			os.Exit(1)
+		}
+		logger.Info("gRPC server starting", "port", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("gRPC server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
 	logger.Info("analytics service ready", "port", port)
 
 	// Wait for shutdown signal
@@ -193,18 +672,154 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Error("HTTP server shutdown error", "error", err)
 	}
+	grpcServer.GracefulStop()
 
-	// Close Kafka reader
-	if err := reader.Close(); err != nil {
-		logger.Error("Kafka reader close error", "error", err)
+	if err := shutdownMetrics(shutdownCtx); err != nil {
+		logger.Error("OTLP metrics shutdown error", "error", err)
+	}
+
+	if err := alertPublisher.Close(); err != nil {
+		logger.Error("Kafka alert publisher close error", "error", err)
+	}
+
+	// Close Kafka consumer
+	if err := consumer.Close(); err != nil {
+		logger.Error("Kafka consumer close error", "error", err)
 	}
 
 	logger.Info("analytics service stopped")
 }
 
+// backfill loads the transactions in payment-service into fresh aggregates,
+// so a failure leaves nothing half counted. With a locker, replicas backfill
+// one at a time, and a backfill whose lock is lost is abandoned.
+func backfill(cfg AnalyticsConfig, locker *lock.Locker) (*Backfill, *Analytics, *SpendAlerts, error) {
+	conn, err := grpc.NewClient(getEnv("PAYMENT_GRPC_ADDR", "localhost:50052"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to connect to payment-service: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), getEnvDuration("BACKFILL_TIMEOUT", 5*time.Minute))
+	defer cancel()
+
+	var (
+		analytics *Analytics
+		alerts    *SpendAlerts
+		b         *Backfill
+	)
+	run := func(ctx context.Context) error {
+		analytics, alerts = NewAnalytics(cfg), NewSpendAlerts()
+		b, err = RunBackfill(ctx, paymentpb.NewPaymentServiceClient(conn), getEnv("SERVICE_TOKEN", ""), time.Now(), func(event *TransactionEvent) {
+			analytics.ProcessEvent(event)
+			// No thresholds are set yet, so this only sums the month's spend
+			alerts.Record(event)
+		})
+		return err
+	}
+	if locker != nil {
+		err = locker.Do(ctx, "analytics-service/backfill", run)
+	} else {
+		err = run(ctx)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return b, analytics, alerts, nil
+}
+
+// backfillLockerFromEnv returns a locker on the BACKFILL_LOCK_REDIS_ADDRS
+// servers and a func closing its clients, or a nil locker when unset
+func backfillLockerFromEnv(logger *slog.Logger) (*lock.Locker, func()) {
+	addrs := getEnv("BACKFILL_LOCK_REDIS_ADDRS", "")
+	if addrs == "" {
+		return nil, func() {}
+	}
+	var clients []*redis.Client
+	for _, addr := range strings.Split(addrs, ",") {
+		clients = append(clients, redis.NewClient(&redis.Options{Addr: strings.TrimSpace(addr)}))
+	}
+	logger.Info("backfills locked across replicas", "servers", len(clients))
+	return lock.New(lock.NewRedis(clients...), lock.WithRetryInterval(time.Second)), func() {
+		for _, client := range clients {
+			if err := client.Close(); err != nil {
+				logger.Error("failed to close Redis lock client", "error", err)
+			}
+		}
+	}
+}
+
+// decodeEvent decodes a consumed event, upcast to the current version of its
+// type first so events from producers on older versions read the same
+func decodeEvent(upcaster *events.Upcaster, value []byte) (*TransactionEvent, error) {
+	value, err := upcaster.Upcast(value)
+	if err != nil {
+		return nil, err
+	}
+	var event TransactionEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return &event, nil
+}
+
+// eventSubscriber returns the subscriber of the events on topics. With
+// prioritized, consumer reads each priority's topics and the subscriber
+// hands over the highest priority event waiting; otherwise consumer reads
+// topics alone.
+func eventSubscriber(topics []string, prioritized bool, consumer func(topics []string) messaging.Subscriber) messaging.Subscriber {
+	if !prioritized {
+		return consumer(topics)
+	}
+	subs := make(map[messaging.Priority]messaging.Subscriber)
+	for _, p := range []messaging.Priority{messaging.PriorityHigh, messaging.PriorityDefault, messaging.PriorityBulk} {
+		subs[p] = consumer(messaging.PriorityTopics(topics, p))
+	}
+	return messaging.NewPrioritySubscriber(subs)
+}
+
+// splitTopics splits a comma-separated topic list, dropping blanks and
+// repeats
+func splitTopics(s string) []string {
+	var topics []string
+	for _, topic := range strings.Split(s, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
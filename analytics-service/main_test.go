@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+)
+
+// topicSubscriber hands the messages published to its topics to the
+// handler, then waits for cancellation
+type topicSubscriber struct {
+	messages [][]byte
+}
+
+func (s *topicSubscriber) Consume(ctx context.Context, handler messaging.KeyedMessageHandler) error {
+	for _, m := range s.messages {
+		if err := handler(nil, m); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (s *topicSubscriber) Close() error { return nil }
+
+func TestEventSubscriber_RoutedEvents(t *testing.T) {
+	// The payment service with KAFKA_TOPIC_ROUTES=transaction.payment_reverted=refunds
+	routes := map[string]string{"transaction.payment_reverted": "refunds"}
+	now := time.Now()
+	published := []*TransactionEvent{
+		{EventType: "transaction.created", TransactionID: 1, UserID: 1, Amount: 10, Timestamp: now},
+		{EventType: "transaction.created", TransactionID: 2, UserID: 1, Amount: 15, Timestamp: now},
+		{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 2, Timestamp: now},
+		{EventType: "transaction.payment_reverted", UserID: 1, PaymentBatchID: "b", TransactionsReverted: 2, Timestamp: now},
+	}
+
+	for _, prioritized := range []bool{false, true} {
+		byTopic := make(map[string][][]byte)
+		for _, event := range published {
+			topic := "transactions"
+			if route, ok := routes[event.EventType]; ok {
+				topic = route
+			}
+			if prioritized {
+				topic = messaging.PriorityTopic(topic, messaging.EventPriority(event.EventType))
+			}
+			value, err := json.Marshal(event)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			byTopic[topic] = append(byTopic[topic], value)
+		}
+
+		var subscribed []string
+		sub := eventSubscriber(splitTopics("transactions, refunds"), prioritized, func(topics []string) messaging.Subscriber {
+			subscribed = append(subscribed, topics...)
+			s := &topicSubscriber{}
+			for _, topic := range topics {
+				s.messages = append(s.messages, byTopic[topic]...)
+			}
+			return s
+		})
+		if !slices.Contains(subscribed, "refunds") {
+			t.Errorf("prioritized=%v: expected the routed topic subscribed, got %v", prioritized, subscribed)
+		}
+
+		a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, HourlyRetention: 2})
+		processed := make(chan struct{}, len(published))
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			_ = sub.Consume(ctx, func(_, value []byte) error {
+				var event TransactionEvent
+				if err := json.Unmarshal(value, &event); err != nil {
+					return err
+				}
+				a.ProcessEvent(&event)
+				processed <- struct{}{}
+				return nil
+			})
+		}()
+		for range published {
+			select {
+			case <-processed:
+			case <-time.After(time.Second):
+				t.Fatalf("prioritized=%v: expected %d events processed", prioritized, len(published))
+			}
+		}
+		cancel()
+
+		a.mu.RLock()
+		paid, reverted := a.totals.paid, a.totals.reverted
+		a.mu.RUnlock()
+		if paid != 2 || reverted != 2 {
+			t.Errorf("prioritized=%v: expected the routed revert counted, got paid %d, reverted %d", prioritized, paid, reverted)
+		}
+	}
+}
+
+func TestSplitTopics(t *testing.T) {
+	if got := splitTopics(" transactions,refunds,,transactions "); !slices.Equal(got, []string{"transactions", "refunds"}) {
+		t.Errorf("expected transactions and refunds, got %v", got)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userMetric is a single per-user value
+type userMetric struct {
+	userID int
+	value  float64
+}
+
+// Metric types, as named in the Prometheus exposition
+const (
+	metricCounter = "counter"
+	metricGauge   = "gauge"
+	metricSummary = "summary"
+)
+
+// metricFamily is a metric and its samples, the unit both /metrics and the
+// OTLP exporter report
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+// metricSample is one value of a family. suffix names the series of a
+// summary it belongs to, "_sum" or "_count".
+type metricSample struct {
+	suffix string
+	labels []metricLabel
+	value  float64
+}
+
+type metricLabel struct {
+	name, value string
+}
+
+// single returns a family of one unlabelled sample
+func single(name, kind, help string, value float64) metricFamily {
+	return metricFamily{name: name, help: help, kind: kind, samples: []metricSample{{value: value}}}
+}
+
+// metricFamilies returns the aggregates as metrics. Per-user series are
+// limited to the topN users to keep label cardinality bounded.
+func (a *Analytics) metricFamilies(topN int) []metricFamily {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	families := []metricFamily{
+		single("analytics_transactions_total", metricCounter,
+			"Total number of transactions created.", float64(a.totals.transactions)),
+		single("analytics_transaction_amount_total", metricCounter,
+			"Sum of the amounts of all created transactions.", a.totals.amount),
+		single("analytics_paid_transactions_total", metricCounter,
+			"Total number of transactions marked as paid, including those whose payment was later reverted.", float64(a.totals.paid)),
+		single("analytics_reverted_transactions_total", metricCounter,
+			"Paid transactions made unpaid again by a reverted payment.", float64(a.totals.reverted)),
+		single("analytics_events_processed_total", metricCounter,
+			"Total number of events consumed from Kafka.", float64(a.EventsProcessed)),
+		single("analytics_duplicate_events_total", metricCounter,
+			"Redelivered payment events dropped by payment batch ID.", float64(a.duplicateEvents)),
+		single("analytics_pending_paid_transactions", metricGauge,
+			"Paid transactions waiting for their created events.", float64(a.totals.ledger.pending)),
+		single("analytics_out_of_order_paid_transactions_total", metricCounter,
+			"Paid transactions reported before their created events.", float64(a.totals.ledger.outOfOrder)),
+		single("analytics_unmatched_paid_transactions_total", metricCounter,
+			"Pending paid transactions counted after the grace period without a created event.", float64(a.totals.ledger.unmatched)),
+		single("analytics_anomalous_transactions_total", metricCounter,
+			"Created transactions flagged for an unusually high amount.", float64(a.anomalies.flagged)),
+		single("analytics_unique_users", metricGauge,
+			"Number of distinct users that created transactions.", float64(a.users.users.UniqueUsers())),
+	}
+
+	// Without an event yet, the family has no sample rather than a 0
+	lastEvent := metricFamily{name: "analytics_last_event_timestamp_seconds", kind: metricGauge,
+		help: "Unix time of the last processed event."}
+	if !a.lastEventAt.IsZero() {
+		lastEvent.samples = []metricSample{{value: float64(a.lastEventAt.Unix())}}
+	}
+	families = append(families, lastEvent)
+
+	active := metricFamily{name: "analytics_active_users", kind: metricGauge,
+		help: "Distinct users seen in the trailing window."}
+	for _, window := range activeUserWindows {
+		count := a.users.active.Count(window.duration, time.Now())
+		active.samples = append(active.samples, metricSample{
+			labels: []metricLabel{{"window", window.name}},
+			value:  float64(count),
+		})
+	}
+	families = append(families, active, a.viewLatency.family())
+
+	return append(families,
+		userFamily("analytics_user_transactions", "Transactions created by the top users.", a.users.users.TopByCount(topN)),
+		userFamily("analytics_user_amount", "Transaction amount of the top users.", a.users.users.TopByAmount(topN)),
+	)
+}
+
+func userFamily(name, help string, values []userMetric) metricFamily {
+	family := metricFamily{name: name, help: help, kind: metricGauge}
+	for _, v := range values {
+		family.samples = append(family.samples, metricSample{
+			labels: []metricLabel{{"user_id", strconv.Itoa(v.userID)}},
+			value:  v.value,
+		})
+	}
+	return family
+}
+
+// WriteMetrics writes the aggregates in the Prometheus text exposition format.
+// Per-user series are limited to the topN users to keep label cardinality bounded.
+func (a *Analytics) WriteMetrics(w io.Writer, topN int) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range a.metricFamilies(topN) {
+		writeFamily(bw, family)
+	}
+	return bw.Flush()
+}
+
+func writeFamily(w *bufio.Writer, family metricFamily) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind)
+	for _, s := range family.samples {
+		fmt.Fprintf(w, "%s%s%s %s\n", family.name, s.suffix, formatLabels(s.labels), formatValue(s.value))
+	}
+}
+
+func formatLabels(labels []metricLabel) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = fmt.Sprintf("%s=%q", l.name, l.value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalytics_WriteMetrics(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, HourlyRetention: 2})
+	now := time.Now()
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: 1, UserID: 1, Amount: 10, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: 2, UserID: 2, Amount: 25.5, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: 3, UserID: 2, Amount: 4.5, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+	// Redelivered, so dropped
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, PaymentBatchID: "b", TransactionsPaid: 1, Timestamp: now})
+	// A payment of user 2 whose charge failed
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 2, PaymentBatchID: "c", TransactionsPaid: 2, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.payment_reverted", UserID: 2, PaymentBatchID: "c", TransactionsReverted: 2, Timestamp: now})
+
+	var b strings.Builder
+	if err := a.WriteMetrics(&b, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# HELP analytics_transactions_total Total number of transactions created.\n" +
+			"# TYPE analytics_transactions_total counter\n" +
+			"analytics_transactions_total 3\n",
+		"# TYPE analytics_transaction_amount_total counter\nanalytics_transaction_amount_total 40\n",
+		// Reverts don't take back paid, so it never decreases
+		"# TYPE analytics_paid_transactions_total counter\nanalytics_paid_transactions_total 3\n",
+		"# TYPE analytics_reverted_transactions_total counter\nanalytics_reverted_transactions_total 2\n",
+		"# TYPE analytics_events_processed_total counter\nanalytics_events_processed_total 6\n",
+		"# TYPE analytics_duplicate_events_total counter\nanalytics_duplicate_events_total 1\n",
+		"# TYPE analytics_out_of_order_paid_transactions_total counter\nanalytics_out_of_order_paid_transactions_total 0\n",
+		"# TYPE analytics_anomalous_transactions_total counter\nanalytics_anomalous_transactions_total 0\n",
+		"# TYPE analytics_unique_users gauge\nanalytics_unique_users 2\n",
+		"# TYPE analytics_last_event_timestamp_seconds gauge\nanalytics_last_event_timestamp_seconds ",
+		"analytics_active_users{window=\"1h\"} 2\n",
+		// Only the top user by count and by amount
+		"# TYPE analytics_user_transactions gauge\nanalytics_user_transactions{user_id=\"2\"} 2\n# HELP",
+		"# TYPE analytics_user_amount gauge\nanalytics_user_amount{user_id=\"2\"} 30\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the exposition:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `user_id="1"`) {
+		t.Errorf("expected per-user series limited to the top user:\n%s", out)
+	}
+
+	// Every sample line belongs to a metric with HELP and TYPE before it
+	typed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			typed[strings.Fields(name)[0]] = true
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.Fields(line)[0], "{")
+		base := strings.TrimSuffix(strings.TrimSuffix(name, "_sum"), "_count")
+		if !typed[name] && !typed[base] {
+			t.Errorf("sample %q has no TYPE line", line)
+		}
+	}
+}
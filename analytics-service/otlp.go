@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// meterName names the meter the analytics metrics are observed on
+const meterName = "github.com/tkaewplik/go-microservices/analytics-service"
+
+// SetupOTLPMetrics pushes the metrics /metrics exposes over OTLP/gRPC to
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT every OTEL_METRIC_EXPORT_INTERVAL
+// milliseconds (default 60000), so dashboards chart them without scraping
+// the service. The exporter and resource follow the standard OTEL_*
+// variables. Without an endpoint, or with OTEL_SDK_DISABLED=true, nothing is
+// exported. The returned func pushes the last values and stops.
+func SetupOTLPMetrics(ctx context.Context, analytics *Analytics, topN int) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if !OTLPMetricsEnabled() {
+		return noop, nil
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return noop, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("analytics-service")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return noop, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	)
+	if err := registerMetrics(provider.Meter(meterName), analytics, topN); err != nil {
+		_ = provider.Shutdown(ctx)
+		return noop, err
+	}
+	return provider.Shutdown, nil
+}
+
+// OTLPMetricsEnabled reports whether the environment asks for metrics to be
+// pushed
+func OTLPMetricsEnabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != ""
+}
+
+// registerMetrics observes analytics' metric families on meter whenever its
+// readers collect: counters as cumulative sums, gauges as gauges, and the
+// _sum and _count series of a summary as sums of their own
+func registerMetrics(meter metric.Meter, analytics *Analytics, topN int) error {
+	instruments := make(map[string]metric.Float64Observable)
+	var observables []metric.Observable
+	for _, family := range analytics.metricFamilies(topN) {
+		names := []string{family.name}
+		if family.kind == metricSummary {
+			names = []string{family.name + "_sum", family.name + "_count"}
+		}
+		for _, name := range names {
+			var instrument metric.Float64Observable
+			var err error
+			if family.kind == metricGauge {
+				instrument, err = meter.Float64ObservableGauge(name, metric.WithDescription(family.help))
+			} else {
+				instrument, err = meter.Float64ObservableCounter(name, metric.WithDescription(family.help))
+			}
+			if err != nil {
+				return err
+			}
+			instruments[name] = instrument
+			observables = append(observables, instrument)
+		}
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, family := range analytics.metricFamilies(topN) {
+			for _, s := range family.samples {
+				instrument, ok := instruments[family.name+s.suffix]
+				if !ok {
+					continue
+				}
+				attrs := make([]attribute.KeyValue, len(s.labels))
+				for i, l := range s.labels {
+					attrs[i] = attribute.String(l.name, l.value)
+				}
+				o.ObserveFloat64(instrument, s.value, metric.WithAttributes(attrs...))
+			}
+		}
+		return nil
+	}, observables...)
+	return err
+}
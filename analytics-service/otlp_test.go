@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, HourlyRetention: 2})
+	now := time.Now()
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: 1, UserID: 1, Amount: 10, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", TransactionID: 2, UserID: 2, Amount: 25.5, Timestamp: now})
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	if err := registerMetrics(provider.Meter(meterName), a, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	total, ok := metrics["analytics_transactions_total"].(metricdata.Sum[float64])
+	if !ok || !total.IsMonotonic || total.Temporality != metricdata.CumulativeTemporality ||
+		len(total.DataPoints) != 1 || total.DataPoints[0].Value != 2 {
+		t.Errorf("expected a cumulative count of 2 transactions, got %+v", metrics["analytics_transactions_total"])
+	}
+
+	// Only the top user, labelled by ID
+	amount, ok := metrics["analytics_user_amount"].(metricdata.Gauge[float64])
+	if !ok || len(amount.DataPoints) != 1 || amount.DataPoints[0].Value != 25.5 {
+		t.Fatalf("expected the top user's amount as a gauge, got %+v", metrics["analytics_user_amount"])
+	}
+	if id, _ := amount.DataPoints[0].Attributes.Value(attribute.Key("user_id")); id.AsString() != "2" {
+		t.Errorf("expected user 2, got %v", id.AsString())
+	}
+
+	views, ok := metrics["analytics_stats_view_duration_seconds_count"].(metricdata.Sum[float64])
+	if !ok || len(views.DataPoints) != len(statsViews) {
+		t.Errorf("expected a view count per view, got %+v", metrics["analytics_stats_view_duration_seconds_count"])
+	}
+}
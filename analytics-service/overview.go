@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Overview is the analytics part of the gateway's ops dashboard
+type Overview struct {
+	// ConsumerLag is how many events are waiting to be consumed
+	ConsumerLag int64 `json:"consumer_lag"`
+	// Today holds the current UTC day's transactions by event time
+	Today           TimeBucket     `json:"today"`
+	ActiveUsers     map[string]int `json:"active_users"`
+	EventsProcessed int64          `json:"events_processed"`
+	LastEventTime   string         `json:"last_event_time"`
+	Instances       int            `json:"instances,omitempty"`
+	PeersFailed     int            `json:"peers_failed,omitempty"`
+}
+
+// GetOverview returns the overview as of now, with the consumer's lag
+func (a *Analytics) GetOverview(now time.Time, lag int64) Overview {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return Overview{
+		ConsumerLag:     lag,
+		Today:           a.buckets.daily.Bucket(now),
+		ActiveUsers:     a.users.active.Snapshot(now),
+		EventsProcessed: a.EventsProcessed,
+		LastEventTime:   a.LastEventTime,
+	}
+}
+
+// GatherOverview merges every peer's local overview into local. Lag and
+// totals sum like Gather's stats; unreachable peers are counted in
+// PeersFailed.
+func (c *Cluster) GatherOverview(ctx context.Context, local Overview) Overview {
+	results := make([]*Overview, len(c.peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range c.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			var overview Overview
+			if err := c.do(ctx, http.MethodGet, peer, "/stats/overview?scope=local", nil, &overview); err != nil {
+				c.logger.Warn("failed to fetch peer overview", "peer", peer, "error", err)
+				return
+			}
+			results[i] = &overview
+		}(i, peer)
+	}
+	wg.Wait()
+
+	merged := local
+	merged.Instances = 1
+	merged.ActiveUsers = maps.Clone(local.ActiveUsers)
+	if merged.ActiveUsers == nil {
+		merged.ActiveUsers = make(map[string]int)
+	}
+	for _, peer := range results {
+		if peer == nil {
+			merged.PeersFailed++
+			continue
+		}
+		merged.merge(peer)
+	}
+	return merged
+}
+
+// merge adds a peer's partition-local overview into o
+func (o *Overview) merge(peer *Overview) {
+	o.Instances++
+	o.ConsumerLag += peer.ConsumerLag
+	o.Today.Transactions += peer.Today.Transactions
+	o.Today.Amount += peer.Today.Amount
+	o.Today.PaidTransactions += peer.Today.PaidTransactions
+	o.EventsProcessed += peer.EventsProcessed
+	if peer.LastEventTime > o.LastEventTime {
+		o.LastEventTime = peer.LastEventTime
+	}
+	for window, count := range peer.ActiveUsers {
+		o.ActiveUsers[window] += count
+	}
+}
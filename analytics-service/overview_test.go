@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAnalytics_GetOverview(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, DailyRetention: 7})
+	now := time.Now().UTC()
+	yesterday := now.Add(-24 * time.Hour)
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 5, Timestamp: yesterday})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 1, Amount: 10, Timestamp: now})
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: 2, Amount: 2.5, Timestamp: now})
+
+	o := a.GetOverview(now, 7)
+	if o.ConsumerLag != 7 || o.EventsProcessed != 3 {
+		t.Errorf("unexpected overview %+v", o)
+	}
+	if o.Today.Transactions != 2 || o.Today.Amount != 12.5 || !o.Today.Start.Equal(now.Truncate(24*time.Hour)) {
+		t.Errorf("expected only today's transactions, got %+v", o.Today)
+	}
+	if o.ActiveUsers["5m"] != 2 {
+		t.Errorf("expected two active users, got %v", o.ActiveUsers)
+	}
+}
+
+func TestCluster_GatherOverview(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("scope") != "local" {
+			t.Errorf("expected peers to be asked for local state, got %s", r.URL)
+		}
+		_ = json.NewEncoder(w).Encode(Overview{
+			ConsumerLag:   3,
+			Today:         TimeBucket{Transactions: 2, Amount: 20},
+			ActiveUsers:   map[string]int{"5m": 1},
+			LastEventTime: "2026-10-16T10:00:00Z",
+		})
+	}))
+	defer peer.Close()
+
+	c := NewCluster([]string{peer.URL, "http://127.0.0.1:0"}, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	got := c.GatherOverview(context.Background(), Overview{
+		ConsumerLag:   1,
+		Today:         TimeBucket{Transactions: 1, Amount: 5},
+		ActiveUsers:   map[string]int{"5m": 2},
+		LastEventTime: "2026-10-16T09:00:00Z",
+	})
+	if got.ConsumerLag != 4 || got.Today.Transactions != 3 || got.Today.Amount != 25 || got.ActiveUsers["5m"] != 3 {
+		t.Errorf("expected summed totals, got %+v", got)
+	}
+	if got.Instances != 2 || got.PeersFailed != 1 || got.LastEventTime != "2026-10-16T10:00:00Z" {
+		t.Errorf("expected one failed peer and the latest event time, got %+v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import "time"
+
+// defaultPaidReconcileGrace is how long paid transactions wait for their
+// created events when AnalyticsConfig.PaidReconcileGrace is unset
+const defaultPaidReconcileGrace = 10 * time.Minute
+
+// paidLedger reconciles paid counts against created transactions per user.
+// A transaction.paid event only reports how many transactions were paid, so
+// a paid count is applied to the user's known unpaid transactions. Any excess
+// was paid before its transaction.created event arrived, as happens after a
+// redelivery or across priority topics; it is held as pending and counted as
+// paid when the created events catch up. Pending counts whose created events
+// don't arrive within the grace period, because they were published before
+// the service started, are counted anyway and reported as unmatched.
+// It is not safe for concurrent use; Analytics guards it.
+type paidLedger struct {
+	users map[int]*userLedger
+	grace time.Duration
+	// expiry lists users in the order their counts became pending
+	expiry []pendingSince
+
+	pending    int64 // paid transactions waiting for created events
+	outOfOrder int64 // paid transactions seen before their created event
+	unmatched  int64 // pending transactions released by the grace period
+}
+
+type userLedger struct {
+	unpaid  int64
+	pending int64
+	since   time.Time // when pending last became non-zero
+}
+
+type pendingSince struct {
+	userID int
+	since  time.Time
+}
+
+func newPaidLedger(grace time.Duration) *paidLedger {
+	if grace <= 0 {
+		grace = defaultPaidReconcileGrace
+	}
+	return &paidLedger{users: make(map[int]*userLedger), grace: grace}
+}
+
+func (l *paidLedger) user(userID int) *userLedger {
+	u, ok := l.users[userID]
+	if !ok {
+		u = &userLedger{}
+		l.users[userID] = u
+	}
+	return u
+}
+
+// forget drops u once it holds nothing, keeping the map to users with unpaid
+// or pending transactions
+func (l *paidLedger) forget(userID int, u *userLedger) {
+	if u.unpaid == 0 && u.pending == 0 {
+		delete(l.users, userID)
+	}
+}
+
+// Created records a new transaction, returning how many pending paid
+// transactions it settles: 1 when it was paid before it arrived, else 0
+func (l *paidLedger) Created(userID int) int64 {
+	u := l.user(userID)
+	defer l.forget(userID, u)
+	if u.pending > 0 {
+		u.pending--
+		l.pending--
+		return 1
+	}
+	u.unpaid++
+	return 0
+}
+
+// Paid records n paid transactions, returning how many are counted now.
+// The rest wait for their created events.
+func (l *paidLedger) Paid(userID int, n int64, now time.Time) int64 {
+	u := l.user(userID)
+	defer l.forget(userID, u)
+	applied := min(n, u.unpaid)
+	u.unpaid -= applied
+	if early := n - applied; early > 0 {
+		if u.pending == 0 {
+			u.since = now
+			l.expiry = append(l.expiry, pendingSince{userID: userID, since: now})
+		}
+		u.pending += early
+		l.pending += early
+		l.outOfOrder += early
+	}
+	return applied
+}
+
+// Reverted records n paid transactions becoming unpaid again, returning how
+// many of them had been counted as paid. Pending ones are cancelled first.
+func (l *paidLedger) Reverted(userID int, n int64) int64 {
+	u := l.user(userID)
+	defer l.forget(userID, u)
+	cancelled := min(n, u.pending)
+	u.pending -= cancelled
+	l.pending -= cancelled
+	counted := n - cancelled
+	u.unpaid += counted
+	return counted
+}
+
+// Expire releases the pending counts older than the grace period, returning
+// how many transactions to count as paid
+func (l *paidLedger) Expire(now time.Time) int64 {
+	var released int64
+	for len(l.expiry) > 0 && now.Sub(l.expiry[0].since) >= l.grace {
+		e := l.expiry[0]
+		l.expiry = l.expiry[1:]
+		// Skip users settled, or pending again since, after this entry
+		u, ok := l.users[e.userID]
+		if !ok || u.pending == 0 || !u.since.Equal(e.since) {
+			continue
+		}
+		released += u.pending
+		l.unmatched += u.pending
+		l.pending -= u.pending
+		u.pending = 0
+		l.forget(e.userID, u)
+	}
+	return released
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// createTransactions processes n transaction.created events for userID
+func createTransactions(a *Analytics, userID, n int) {
+	for range n {
+		a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: userID, Amount: 1, Timestamp: time.Now()})
+	}
+}
+
+func TestAnalytics_PaidBeforeCreated(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact})
+	createTransactions(a, 1, 1)
+
+	// Three paid, but only one created event has arrived
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, TransactionsPaid: 3, Timestamp: time.Now()})
+	stats := a.GetStats()
+	if stats.TotalPaidTransactions != 1 || stats.PendingPaidTransactions != 2 {
+		t.Fatalf("expected 1 paid and 2 pending, got %d and %d", stats.TotalPaidTransactions, stats.PendingPaidTransactions)
+	}
+
+	// The late created events settle the pending ones, and a new one is unpaid
+	createTransactions(a, 1, 3)
+	stats = a.GetStats()
+	if stats.TotalTransactions != 4 || stats.TotalPaidTransactions != 3 || stats.PendingPaidTransactions != 0 {
+		t.Errorf("expected 3 of 4 paid with none pending, got %+v", stats)
+	}
+	if a.totals.ledger.outOfOrder != 2 {
+		t.Errorf("expected 2 out-of-order paid transactions, got %d", a.totals.ledger.outOfOrder)
+	}
+
+	// Another user's created events don't settle user 1's
+	a.ProcessEvent(&TransactionEvent{EventType: "transaction.paid", UserID: 1, TransactionsPaid: 2, Timestamp: time.Now()})
+	createTransactions(a, 2, 1)
+	if stats := a.GetStats(); stats.TotalPaidTransactions != 4 || stats.PendingPaidTransactions != 1 {
+		t.Errorf("expected 4 paid and 1 pending, got %d and %d", stats.TotalPaidTransactions, stats.PendingPaidTransactions)
+	}
+}
+
+func TestPaidLedger_Expire(t *testing.T) {
+	l := newPaidLedger(time.Minute)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := l.Paid(1, 2, start); got != 0 {
+		t.Fatalf("expected nothing counted without created transactions, got %d", got)
+	}
+	l.Paid(2, 1, start.Add(30*time.Second))
+	if got := l.Expire(start.Add(59 * time.Second)); got != 0 {
+		t.Errorf("expected nothing released within the grace period, got %d", got)
+	}
+	if got := l.Expire(start.Add(time.Minute)); got != 2 {
+		t.Errorf("expected user 1's 2 released, got %d", got)
+	}
+	if got := l.Expire(start.Add(2 * time.Minute)); got != 1 {
+		t.Errorf("expected user 2's 1 released, got %d", got)
+	}
+	if l.pending != 0 || l.unmatched != 3 || len(l.users) != 0 {
+		t.Errorf("expected all released and forgotten, got pending %d, unmatched %d, %d users", l.pending, l.unmatched, len(l.users))
+	}
+	// Released transactions are counted; their late created events are unpaid
+	if got := l.Created(1); got != 0 {
+		t.Errorf("expected a late created event to be unpaid, got %d", got)
+	}
+}
+
+func TestPaidLedger_Reverted(t *testing.T) {
+	l := newPaidLedger(time.Minute)
+	now := time.Now()
+	l.Created(1)
+	l.Paid(1, 3, now) // 1 counted, 2 pending
+
+	if got := l.Reverted(1, 3); got != 1 {
+		t.Errorf("expected the pending ones cancelled first and 1 uncounted, got %d", got)
+	}
+	if l.pending != 0 || l.users[1].unpaid != 1 {
+		t.Errorf("expected one unpaid and none pending, got %+v", l.users[1])
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/tkaewplik/go-microservices/pkg/events"
+)
+
+// AnalyticsAlert is the event type published when an alerting rule starts
+// or stops firing
+const AnalyticsAlert = "analytics.alert"
+
+// Alert statuses
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Rule metrics, each computed over the rule's trailing window
+const (
+	// MetricTransactions counts created transactions
+	MetricTransactions = "transactions"
+	// MetricAmount sums the amounts of created transactions
+	MetricAmount = "amount"
+	// MetricAverageAmount is the mean amount of created transactions; a
+	// window without any leaves the rule as it was
+	MetricAverageAmount = "average_amount"
+	// MetricPaidTransactions counts transactions reported paid, net of
+	// reverted payments
+	MetricPaidTransactions = "paid_transactions"
+)
+
+const (
+	// ruleResolution is the width of the buckets rule metrics are summed
+	// from, so windows are measured to the minute
+	ruleResolution = time.Minute
+	// maxRuleWindow bounds rule windows, and with them the buckets kept
+	maxRuleWindow = 24 * time.Hour
+	// defaultRuleInterval is how often rules are evaluated when
+	// RULES_INTERVAL is unset
+	defaultRuleInterval = 30 * time.Second
+)
+
+// ErrInvalidRule is returned for rules that can't be evaluated
+var ErrInvalidRule = errors.New("invalid alerting rule")
+
+var comparators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// Rule fires while metric, over the trailing window, compares to threshold
+// as comparator says, e.g. transactions < 1 over 15m
+type Rule struct {
+	Name       string        `yaml:"name"`
+	Metric     string        `yaml:"metric"`
+	Comparator string        `yaml:"comparator"`
+	Threshold  float64       `yaml:"threshold"`
+	Window     time.Duration `yaml:"window"`
+}
+
+// validate checks that r names a known metric and comparator and has a
+// window between a minute and a day
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidRule)
+	}
+	switch r.Metric {
+	case MetricTransactions, MetricAmount, MetricAverageAmount, MetricPaidTransactions:
+	default:
+		return fmt.Errorf("%w: %s: unknown metric %q", ErrInvalidRule, r.Name, r.Metric)
+	}
+	if _, ok := comparators[r.Comparator]; !ok {
+		return fmt.Errorf("%w: %s: unknown comparator %q", ErrInvalidRule, r.Name, r.Comparator)
+	}
+	if r.Window < ruleResolution || r.Window > maxRuleWindow {
+		return fmt.Errorf("%w: %s: window must be between %v and %v", ErrInvalidRule, r.Name, ruleResolution, maxRuleWindow)
+	}
+	return nil
+}
+
+// ParseRules reads rules from YAML:
+//
+//	rules:
+//	  - name: traffic_stopped
+//	    metric: transactions
+//	    comparator: "<"
+//	    threshold: 1
+//	    window: 15m
+func ParseRules(data []byte) ([]Rule, error) {
+	var file struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	for _, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidRule, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return file.Rules, nil
+}
+
+// LoadRules reads rules from the YAML file at path
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+// RuleAlert is an analytics.alert event: a rule that started firing, or
+// stopped
+type RuleAlert struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version    int     `json:"version"`
+	Rule       string  `json:"rule"`
+	Metric     string  `json:"metric"`
+	Comparator string  `json:"comparator"`
+	Threshold  float64 `json:"threshold"`
+	Window     string  `json:"window"`
+	// Value is the metric when the rule was last evaluated
+	Value  float64 `json:"value"`
+	Status string  `json:"status"`
+	// Since is when the rule started firing
+	Since     time.Time `json:"since"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ruleBucket holds one minute of the rule metrics
+type ruleBucket struct {
+	start        time.Time
+	transactions int64
+	amount       float64
+	paid         int64
+}
+
+// RuleEngine evaluates alerting rules against metrics of the events it has
+// seen, publishing an analytics.alert event when a rule starts firing and
+// again when it resolves. It is registered as an Aggregator and keeps the
+// metrics in per-minute buckets covering the longest window; like the other
+// aggregates they are in memory, so rules start over on restart, and a rule
+// isn't evaluated until the engine has been running for its window.
+type RuleEngine struct {
+	rules   []Rule
+	publish func(context.Context, *RuleAlert) error
+	logger  *slog.Logger
+
+	mu sync.Mutex
+	// since is when the engine saw its first event or evaluation; a window
+	// reaching back before it is incomplete
+	since   time.Time
+	buckets []ruleBucket
+	// firing holds the alerts of the rules firing now, by rule name
+	firing map[string]*RuleAlert
+}
+
+// NewRuleEngine creates a RuleEngine for rules, which ParseRules has
+// checked. publish sends the alerts; a failed publish is logged and not
+// retried.
+func NewRuleEngine(rules []Rule, publish func(context.Context, *RuleAlert) error, logger *slog.Logger) *RuleEngine {
+	longest := ruleResolution
+	for _, rule := range rules {
+		longest = max(longest, rule.Window)
+	}
+	return &RuleEngine{
+		rules:   rules,
+		publish: publish,
+		logger:  logger,
+		// One more bucket than the window, for the minute in progress
+		buckets: make([]ruleBucket, longest/ruleResolution+1),
+		firing:  make(map[string]*RuleAlert),
+	}
+}
+
+func (e *RuleEngine) Name() string { return "rules" }
+
+func (e *RuleEngine) Handle(event *TransactionEvent, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.start(now)
+	b := e.bucket(now)
+	switch event.EventType {
+	case "transaction.created":
+		b.transactions++
+		b.amount += event.Amount
+	case "transaction.paid":
+		b.paid += event.TransactionsPaid
+	case "transaction.payment_reverted":
+		b.paid -= event.TransactionsReverted
+	}
+}
+
+// start records now as the start of the metrics if nothing came before;
+// e.mu must be held
+func (e *RuleEngine) start(now time.Time) {
+	if e.since.IsZero() {
+		e.since = now
+	}
+}
+
+// bucket returns the bucket for now, clearing it if it last held an older
+// minute; e.mu must be held
+func (e *RuleEngine) bucket(now time.Time) *ruleBucket {
+	start := now.Truncate(ruleResolution)
+	b := &e.buckets[int(start.Unix()/int64(ruleResolution/time.Second))%len(e.buckets)]
+	if !b.start.Equal(start) {
+		*b = ruleBucket{start: start}
+	}
+	return b
+}
+
+// value computes rule's metric over its window ending at now. ok is false
+// when the metric has no value: the window reaches back before the engine
+// started, or it is an average over no transactions. e.mu must be held.
+func (e *RuleEngine) value(rule Rule, now time.Time) (value float64, ok bool) {
+	if now.Sub(e.since) < rule.Window {
+		return 0, false
+	}
+	var sum ruleBucket
+	from := now.Add(-rule.Window)
+	for _, b := range e.buckets {
+		// A bucket counts if any of its minute is in the window
+		if b.start.IsZero() || !b.start.Add(ruleResolution).After(from) || b.start.After(now) {
+			continue
+		}
+		sum.transactions += b.transactions
+		sum.amount += b.amount
+		sum.paid += b.paid
+	}
+
+	switch rule.Metric {
+	case MetricTransactions:
+		return float64(sum.transactions), true
+	case MetricAmount:
+		return sum.amount, true
+	case MetricAverageAmount:
+		if sum.transactions == 0 {
+			return 0, false
+		}
+		return sum.amount / float64(sum.transactions), true
+	case MetricPaidTransactions:
+		return float64(sum.paid), true
+	}
+	return 0, false
+}
+
+// Evaluate checks every rule at now and returns the alerts of the rules that
+// started or stopped firing, after publishing them
+func (e *RuleEngine) Evaluate(ctx context.Context, now time.Time) []RuleAlert {
+	e.mu.Lock()
+	e.start(now)
+	var changed []RuleAlert
+	for _, rule := range e.rules {
+		value, ok := e.value(rule, now)
+		if !ok {
+			continue
+		}
+		firing := e.firing[rule.Name]
+		if firing != nil {
+			firing.Value = value
+		}
+
+		switch fires := comparators[rule.Comparator](value, rule.Threshold); {
+		case fires && firing == nil:
+			firing = &RuleAlert{
+				EventType:  AnalyticsAlert,
+				Version:    events.AnalyticsAlertVersion,
+				Rule:       rule.Name,
+				Metric:     rule.Metric,
+				Comparator: rule.Comparator,
+				Threshold:  rule.Threshold,
+				Window:     rule.Window.String(),
+				Value:      value,
+				Status:     AlertFiring,
+				Since:      now,
+				Timestamp:  now,
+			}
+			e.firing[rule.Name] = firing
+			changed = append(changed, *firing)
+		case !fires && firing != nil:
+			resolved := *firing
+			resolved.Status = AlertResolved
+			resolved.Timestamp = now
+			delete(e.firing, rule.Name)
+			changed = append(changed, resolved)
+		}
+	}
+	e.mu.Unlock()
+
+	for i := range changed {
+		alert := &changed[i]
+		if err := e.publish(ctx, alert); err != nil {
+			e.logger.Error("failed to publish analytics alert", "error", err, "rule", alert.Rule, "status", alert.Status)
+			continue
+		}
+		e.logger.Info("analytics alert published", "rule", alert.Rule, "status", alert.Status, "value", alert.Value)
+	}
+	return changed
+}
+
+// Active returns the alerts of the rules firing now, by rule name
+func (e *RuleEngine) Active() []RuleAlert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active := make([]RuleAlert, 0, len(e.firing))
+	for _, alert := range e.firing {
+		active = append(active, *alert)
+	}
+	slices.SortFunc(active, func(a, b RuleAlert) int { return cmp.Compare(a.Rule, b.Rule) })
+	return active
+}
+
+// Run evaluates the rules every interval until ctx is done
+func (e *RuleEngine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			e.Evaluate(ctx, now)
+		}
+	}
+}
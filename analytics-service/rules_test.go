@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const testRules = `
+rules:
+  - name: traffic_stopped
+    metric: transactions
+    comparator: "<"
+    threshold: 1
+    window: 5m
+  - name: big_spenders
+    metric: average_amount
+    comparator: ">="
+    threshold: 500
+    window: 10m
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	if len(rules) != 2 || rules[0].Window != 5*time.Minute || rules[1].Comparator != ">=" || rules[1].Threshold != 500 {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for name, yaml := range map[string]string{
+		"unknown metric":     "rules: [{name: a, metric: latency, comparator: '>', threshold: 1, window: 1m}]",
+		"unknown comparator": "rules: [{name: a, metric: amount, comparator: '=>', threshold: 1, window: 1m}]",
+		"window too short":   "rules: [{name: a, metric: amount, comparator: '>', threshold: 1, window: 30s}]",
+		"window too long":    "rules: [{name: a, metric: amount, comparator: '>', threshold: 1, window: 48h}]",
+		"missing name":       "rules: [{metric: amount, comparator: '>', threshold: 1, window: 1m}]",
+		"unknown field":      "rules: [{name: a, metric: amount, comparator: '>', threshold: 1, window: 1m, for: 5m}]",
+		"duplicate name": `rules:
+  - {name: a, metric: amount, comparator: '>', threshold: 1, window: 1m}
+  - {name: a, metric: amount, comparator: '<', threshold: 1, window: 1m}`,
+	} {
+		if _, err := ParseRules([]byte(yaml)); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: expected ErrInvalidRule, got %v", name, err)
+		}
+	}
+}
+
+func TestRuleEngine_FiresAndResolves(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published []RuleAlert
+	engine := NewRuleEngine(rules, func(_ context.Context, alert *RuleAlert) error {
+		published = append(published, *alert)
+		return nil
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Rules wait for a full window of events
+	engine.Handle(created(1, 600, start), start)
+	if changed := engine.Evaluate(ctx, start.Add(4*time.Minute)); len(changed) != 0 {
+		t.Fatalf("expected no rule evaluated before its window, got %+v", changed)
+	}
+
+	// The quiet minutes after the first transaction stop the traffic
+	now := start.Add(6*time.Minute + 30*time.Second)
+	changed := engine.Evaluate(ctx, now)
+	if len(changed) != 1 || changed[0].Rule != "traffic_stopped" || changed[0].Status != AlertFiring || changed[0].Value != 0 {
+		t.Fatalf("expected traffic_stopped firing, got %+v", changed)
+	}
+	if active := engine.Active(); len(active) != 1 || !active[0].Since.Equal(now) {
+		t.Errorf("expected traffic_stopped active since %v, got %+v", now, active)
+	}
+	if changed := engine.Evaluate(ctx, now.Add(30*time.Second)); len(changed) != 0 {
+		t.Errorf("expected a firing rule announced once, got %+v", changed)
+	}
+
+	// Traffic resumes; the large average is within big_spenders' window
+	// from its first evaluation
+	engine.Handle(created(2, 400, now.Add(time.Minute)), now.Add(time.Minute))
+	changed = engine.Evaluate(ctx, now.Add(2*time.Minute))
+	if len(changed) != 1 || changed[0].Rule != "traffic_stopped" || changed[0].Status != AlertResolved || changed[0].Value != 1 {
+		t.Fatalf("expected traffic_stopped resolved, got %+v", changed)
+	}
+	changed = engine.Evaluate(ctx, start.Add(10*time.Minute))
+	if len(changed) != 1 || changed[0].Rule != "big_spenders" || changed[0].Value != 500 {
+		t.Fatalf("expected big_spenders firing at an average of 500, got %+v", changed)
+	}
+	if len(published) != 3 || published[2].EventType != AnalyticsAlert || published[2].Window != "10m0s" {
+		t.Errorf("expected every change published, got %+v", published)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHyperLogLog_EstimateWithinError(t *testing.T) {
+	for _, n := range []int{100, 10000, 200000} {
+		h := NewHyperLogLog(14)
+		for i := 1; i <= n; i++ {
+			h.Add(uint64(i))
+			h.Add(uint64(i)) // duplicates must not change the estimate
+		}
+
+		got := float64(h.Count())
+		relErr := math.Abs(got-float64(n)) / float64(n)
+		if relErr > 0.03 {
+			t.Errorf("n=%d: estimate %.0f has relative error %.3f", n, got, relErr)
+		}
+	}
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	a, b := NewHyperLogLog(12), NewHyperLogLog(12)
+	for i := 0; i < 5000; i++ {
+		a.Add(uint64(i))
+		b.Add(uint64(i + 2500))
+	}
+	a.Merge(b)
+
+	got := float64(a.Count())
+	if math.Abs(got-7500)/7500 > 0.05 {
+		t.Errorf("expected merged estimate near 7500, got %.0f", got)
+	}
+}
+
+func TestSpaceSaving_FindsHeavyHitters(t *testing.T) {
+	s := NewSpaceSaving(10)
+	// Two heavy users interleaved with a long tail of light users
+	for i := 0; i < 1000; i++ {
+		s.Add(1, 1)
+		if i%2 == 0 {
+			s.Add(2, 1)
+		}
+		s.Add(1000+i, 1)
+	}
+
+	top := s.Top(2)
+	if len(top) != 2 || top[0].userID != 1 || top[1].userID != 2 {
+		t.Fatalf("expected users 1 and 2 as heavy hitters, got %+v", top)
+	}
+	if top[0].value < 1000 {
+		t.Errorf("space-saving must never underestimate, got %.0f", top[0].value)
+	}
+	if s.Len() != 10 {
+		t.Errorf("expected capacity to bound monitored keys, got %d", s.Len())
+	}
+}
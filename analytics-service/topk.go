@@ -0,0 +1,106 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ssItem is a monitored key in a SpaceSaving sketch.
+// err is the maximum overestimation inherited from the evicted key.
+type ssItem struct {
+	key   int
+	count float64
+	err   float64
+	index int
+}
+
+type ssHeap []*ssItem
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *ssHeap) Push(x any) {
+	item := x.(*ssItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+func (h *ssHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// SpaceSaving tracks the heaviest keys of a weighted stream in bounded memory
+// (Metwally et al.). Any key whose true weight exceeds total/capacity is
+// guaranteed to be monitored.
+type SpaceSaving struct {
+	capacity int
+	items    map[int]*ssItem
+	heap     ssHeap
+}
+
+// NewSpaceSaving creates a sketch monitoring at most capacity keys
+func NewSpaceSaving(capacity int) *SpaceSaving {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SpaceSaving{
+		capacity: capacity,
+		items:    make(map[int]*ssItem, capacity),
+		heap:     make(ssHeap, 0, capacity),
+	}
+}
+
+// Add increases the weight of key
+func (s *SpaceSaving) Add(key int, weight float64) {
+	if item, ok := s.items[key]; ok {
+		item.count += weight
+		heap.Fix(&s.heap, item.index)
+		return
+	}
+
+	if len(s.items) < s.capacity {
+		item := &ssItem{key: key, count: weight}
+		s.items[key] = item
+		heap.Push(&s.heap, item)
+		return
+	}
+
+	// Replace the lightest key; the newcomer inherits its count as error
+	min := s.heap[0]
+	delete(s.items, min.key)
+	min.key, min.err, min.count = key, min.count, min.count+weight
+	s.items[key] = min
+	heap.Fix(&s.heap, 0)
+}
+
+// Top returns up to n keys ordered by estimated weight, heaviest first
+func (s *SpaceSaving) Top(n int) []userMetric {
+	items := make([]*ssItem, len(s.heap))
+	copy(items, s.heap)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].count != items[j].count {
+			return items[i].count > items[j].count
+		}
+		return items[i].key < items[j].key
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+
+	top := make([]userMetric, len(items))
+	for i, item := range items {
+		top[i] = userMetric{userID: item.key, value: item.count}
+	}
+	return top
+}
+
+// Len returns the number of monitored keys
+func (s *SpaceSaving) Len() int {
+	return len(s.items)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"container/list"
+	"sort"
+)
+
+// Cardinality modes for per-user aggregates
+const (
+	CardinalityExact  = "exact"
+	CardinalityApprox = "approx"
+)
+
+// UserAggregates tracks per-user transaction counts and amounts.
+// Implementations are not safe for concurrent use; Analytics guards them.
+type UserAggregates interface {
+	// Add records a transaction for a user
+	Add(userID int, amount float64)
+	// UniqueUsers returns the (possibly estimated) number of distinct users
+	UniqueUsers() int
+	// TopByCount returns the n users with the most transactions
+	TopByCount(n int) []userMetric
+	// TopByAmount returns the n users with the highest transaction amount
+	TopByAmount(n int) []userMetric
+	// MemoryStats reports the size of the underlying structures
+	MemoryStats() map[string]interface{}
+}
+
+// NewUserAggregates creates the aggregates for the configured mode
+func NewUserAggregates(cfg AnalyticsConfig) UserAggregates {
+	if cfg.CardinalityMode == CardinalityApprox {
+		return &approxUserAggregates{
+			unique:  NewHyperLogLog(cfg.HLLPrecision),
+			counts:  NewSpaceSaving(cfg.TopKCapacity),
+			amounts: NewSpaceSaving(cfg.TopKCapacity),
+		}
+	}
+	return &exactUserAggregates{
+		maxUsers: cfg.MaxTrackedUsers,
+		entries:  make(map[int]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// userEntry is the exact aggregate for one user
+type userEntry struct {
+	userID int
+	count  int64
+	amount float64
+}
+
+// exactUserAggregates keeps one entry per user. With maxUsers set, the least
+// recently active users are evicted and their totals folded into overflow
+// counters so heap usage stays bounded.
+type exactUserAggregates struct {
+	maxUsers int // 0 means unlimited
+	entries  map[int]*list.Element
+	lru      *list.List // front is the most recently active user
+
+	evictedUsers        int64
+	evictedTransactions int64
+	evictedAmount       float64
+}
+
+func (e *exactUserAggregates) Add(userID int, amount float64) {
+	if elem, ok := e.entries[userID]; ok {
+		entry := elem.Value.(*userEntry)
+		entry.count++
+		entry.amount += amount
+		e.lru.MoveToFront(elem)
+		return
+	}
+
+	if e.maxUsers > 0 && len(e.entries) >= e.maxUsers {
+		e.evictOldest()
+	}
+	e.entries[userID] = e.lru.PushFront(&userEntry{userID: userID, count: 1, amount: amount})
+}
+
+func (e *exactUserAggregates) evictOldest() {
+	oldest := e.lru.Back()
+	if oldest == nil {
+		return
+	}
+	entry := e.lru.Remove(oldest).(*userEntry)
+	delete(e.entries, entry.userID)
+
+	e.evictedUsers++
+	e.evictedTransactions += entry.count
+	e.evictedAmount += entry.amount
+}
+
+func (e *exactUserAggregates) UniqueUsers() int {
+	return len(e.entries)
+}
+
+func (e *exactUserAggregates) TopByCount(n int) []userMetric {
+	values := make([]userMetric, 0, len(e.entries))
+	for userID, elem := range e.entries {
+		values = append(values, userMetric{userID: userID, value: float64(elem.Value.(*userEntry).count)})
+	}
+	return topN(values, n)
+}
+
+func (e *exactUserAggregates) TopByAmount(n int) []userMetric {
+	values := make([]userMetric, 0, len(e.entries))
+	for userID, elem := range e.entries {
+		values = append(values, userMetric{userID: userID, value: elem.Value.(*userEntry).amount})
+	}
+	return topN(values, n)
+}
+
+func (e *exactUserAggregates) MemoryStats() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":                 CardinalityExact,
+		"tracked_users":        len(e.entries),
+		"max_tracked_users":    e.maxUsers,
+		"evicted_users":        e.evictedUsers,
+		"evicted_transactions": e.evictedTransactions,
+		"evicted_amount":       e.evictedAmount,
+	}
+}
+
+// approxUserAggregates uses a HyperLogLog for distinct users and SpaceSaving
+// sketches for heavy hitters, so memory is fixed regardless of cardinality
+type approxUserAggregates struct {
+	unique  *HyperLogLog
+	counts  *SpaceSaving
+	amounts *SpaceSaving
+}
+
+func (a *approxUserAggregates) Add(userID int, amount float64) {
+	a.unique.Add(uint64(userID))
+	a.counts.Add(userID, 1)
+	a.amounts.Add(userID, amount)
+}
+
+func (a *approxUserAggregates) UniqueUsers() int {
+	return int(a.unique.Count())
+}
+
+func (a *approxUserAggregates) TopByCount(n int) []userMetric {
+	return a.counts.Top(n)
+}
+
+func (a *approxUserAggregates) TopByAmount(n int) []userMetric {
+	return a.amounts.Top(n)
+}
+
+func (a *approxUserAggregates) MemoryStats() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":                CardinalityApprox,
+		"hll_bytes":           a.unique.SizeBytes(),
+		"topk_count_entries":  a.counts.Len(),
+		"topk_amount_entries": a.amounts.Len(),
+	}
+}
+
+func topN(values []userMetric, n int) []userMetric {
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].value != values[j].value {
+			return values[i].value > values[j].value
+		}
+		return values[i].userID < values[j].userID
+	})
+	if len(values) > n {
+		values = values[:n]
+	}
+	return values
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestExactUserAggregates_EvictsLeastRecentlyActive(t *testing.T) {
+	users := NewUserAggregates(AnalyticsConfig{CardinalityMode: CardinalityExact, MaxTrackedUsers: 2})
+
+	users.Add(1, 10)
+	users.Add(2, 20)
+	users.Add(1, 5) // user 1 becomes most recent
+	users.Add(3, 30)
+
+	if users.UniqueUsers() != 2 {
+		t.Fatalf("expected 2 tracked users, got %d", users.UniqueUsers())
+	}
+	for _, m := range users.TopByCount(10) {
+		if m.userID == 2 {
+			t.Error("expected user 2 to be evicted")
+		}
+	}
+
+	stats := users.MemoryStats()
+	if stats["evicted_users"] != int64(1) || stats["evicted_amount"] != 20.0 {
+		t.Errorf("unexpected overflow counters: %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Optional /stats views, computed only when named in ?include=
+const (
+	ViewPerUser      = "per_user"
+	ViewTimeSeries   = "time_series"
+	ViewDistribution = "distribution"
+)
+
+// statsViews lists every view, in the order /stats?include=all uses
+var statsViews = []string{ViewPerUser, ViewTimeSeries, ViewDistribution}
+
+// statsTopUsers is how many users each per_user ranking lists
+const statsTopUsers = 10
+
+// UserTotal is one user's value in a per_user ranking
+type UserTotal struct {
+	UserID int     `json:"user_id"`
+	Value  float64 `json:"value"`
+}
+
+// PerUserView ranks the users with the most created transactions
+type PerUserView struct {
+	TopByCount  []UserTotal `json:"top_by_count"`
+	TopByAmount []UserTotal `json:"top_by_amount"`
+}
+
+// TimeSeriesView is the hourly event-time series
+type TimeSeriesView struct {
+	Interval string       `json:"interval"`
+	Buckets  []TimeBucket `json:"buckets"`
+}
+
+// DistributionView buckets created transactions by amount
+type DistributionView struct {
+	Buckets []AmountBucket `json:"buckets"`
+}
+
+// parseInclude parses the include query parameter: a comma-separated list of
+// views, or "all"
+func parseInclude(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s == "all" {
+		return statsViews, nil
+	}
+
+	var views []string
+	for _, view := range strings.Split(s, ",") {
+		view = strings.TrimSpace(view)
+		if !slices.Contains(statsViews, view) {
+			return nil, fmt.Errorf("unknown view %q", view)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// AddViews computes the views named in include into stats, timing each
+func (a *Analytics) AddViews(stats *Stats, include []string) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, view := range include {
+		start := time.Now()
+		switch view {
+		case ViewPerUser:
+			stats.PerUser = &PerUserView{
+				TopByCount:  userTotals(a.users.users.TopByCount(statsTopUsers)),
+				TopByAmount: userTotals(a.users.users.TopByAmount(statsTopUsers)),
+			}
+		case ViewTimeSeries:
+			stats.TimeSeries = &TimeSeriesView{Interval: "hourly", Buckets: a.buckets.hourly.Series()}
+		case ViewDistribution:
+			stats.Distribution = &DistributionView{Buckets: a.distribution.Snapshot()}
+		}
+		a.viewLatency.Observe(view, time.Since(start))
+	}
+}
+
+func userTotals(metrics []userMetric) []UserTotal {
+	out := make([]UserTotal, len(metrics))
+	for i, m := range metrics {
+		out[i] = UserTotal{UserID: m.userID, Value: m.value}
+	}
+	return out
+}
+
+// mergeUserTotals adds peer's ranking into local, summing users found in
+// both, and keeps the top statsTopUsers
+func mergeUserTotals(local, peer []UserTotal) []UserTotal {
+	values := make(map[int]float64, len(local)+len(peer))
+	for _, u := range slices.Concat(local, peer) {
+		values[u.UserID] += u.Value
+	}
+	merged := make([]userMetric, 0, len(values))
+	for userID, value := range values {
+		merged = append(merged, userMetric{userID: userID, value: value})
+	}
+	return userTotals(topN(merged, statsTopUsers))
+}
+
+// mergeViews adds a peer's partition-local views into s. A view is only
+// merged when s has it, as every peer was asked for the same ones.
+func (s *Stats) mergeViews(peer *Stats) {
+	if s.PerUser != nil && peer.PerUser != nil {
+		s.PerUser = &PerUserView{
+			TopByCount:  mergeUserTotals(s.PerUser.TopByCount, peer.PerUser.TopByCount),
+			TopByAmount: mergeUserTotals(s.PerUser.TopByAmount, peer.PerUser.TopByAmount),
+		}
+	}
+	if s.TimeSeries != nil && peer.TimeSeries != nil {
+		buckets := make(map[int64]TimeBucket)
+		for _, b := range slices.Concat(s.TimeSeries.Buckets, peer.TimeSeries.Buckets) {
+			merged, ok := buckets[b.Start.Unix()]
+			if !ok {
+				merged.Start = b.Start
+			}
+			merged.Transactions += b.Transactions
+			merged.Amount += b.Amount
+			merged.PaidTransactions += b.PaidTransactions
+			buckets[b.Start.Unix()] = merged
+		}
+		series := make([]TimeBucket, 0, len(buckets))
+		for _, b := range buckets {
+			series = append(series, b)
+		}
+		sort.Slice(series, func(i, j int) bool { return series[i].Start.Before(series[j].Start) })
+		s.TimeSeries = &TimeSeriesView{Interval: s.TimeSeries.Interval, Buckets: series}
+	}
+	if s.Distribution != nil && peer.Distribution != nil {
+		buckets := slices.Clone(s.Distribution.Buckets)
+		for _, pbucket := range peer.Distribution.Buckets {
+			for i := range buckets {
+				if bucketLe(buckets[i]) == bucketLe(pbucket) {
+					buckets[i].Transactions += pbucket.Transactions
+					buckets[i].Amount += pbucket.Amount
+				}
+			}
+		}
+		s.Distribution = &DistributionView{Buckets: buckets}
+	}
+}
+
+// viewLatency sums how long each /stats view took to compute. It has its
+// own lock, as views are computed under Analytics' read lock.
+type viewLatency struct {
+	mu    sync.Mutex
+	count map[string]int64
+	sum   map[string]time.Duration
+}
+
+func newViewLatency() *viewLatency {
+	return &viewLatency{count: make(map[string]int64), sum: make(map[string]time.Duration)}
+}
+
+// Observe records one computation of view
+func (l *viewLatency) Observe(view string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.count[view]++
+	l.sum[view] += d
+}
+
+// family returns the latencies as a summary without quantiles
+func (l *viewLatency) family() metricFamily {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	family := metricFamily{name: "analytics_stats_view_duration_seconds", kind: metricSummary,
+		help: "Time spent computing each optional /stats view."}
+	for _, view := range statsViews {
+		labels := []metricLabel{{"view", view}}
+		family.samples = append(family.samples,
+			metricSample{suffix: "_sum", labels: labels, value: l.sum[view].Seconds()},
+			metricSample{suffix: "_count", labels: labels, value: float64(l.count[view])},
+		)
+	}
+	return family
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInclude(t *testing.T) {
+	if views, err := parseInclude("all"); err != nil || len(views) != len(statsViews) {
+		t.Errorf("expected every view for all, got %v, %v", views, err)
+	}
+	if views, err := parseInclude("per_user, distribution"); err != nil || len(views) != 2 || views[1] != ViewDistribution {
+		t.Errorf("expected per_user and distribution, got %v, %v", views, err)
+	}
+	if _, err := parseInclude("per_user,histogram"); err == nil {
+		t.Error("expected an unknown view to be rejected")
+	}
+}
+
+func TestAnalytics_AddViewsComputesOnlyIncluded(t *testing.T) {
+	a := NewAnalytics(AnalyticsConfig{CardinalityMode: CardinalityExact, AllowedLateness: time.Hour, HourlyRetention: 2})
+	now := time.Now()
+	for _, e := range []struct {
+		userID int
+		amount float64
+	}{{1, 5}, {1, 20}, {2, 7000}} {
+		a.ProcessEvent(&TransactionEvent{EventType: "transaction.created", UserID: e.userID, Amount: e.amount, Timestamp: now})
+	}
+
+	stats := a.GetStats()
+	a.AddViews(&stats, []string{ViewPerUser, ViewDistribution})
+	if stats.TimeSeries != nil {
+		t.Error("expected the time series left out")
+	}
+	if top := stats.PerUser.TopByCount; len(top) != 2 || top[0].UserID != 1 || top[0].Value != 2 {
+		t.Errorf("expected user 1 first with 2 transactions, got %+v", top)
+	}
+	buckets := stats.Distribution.Buckets
+	if buckets[0].Transactions != 1 || buckets[1].Transactions != 1 || buckets[len(buckets)-1].Transactions != 1 || buckets[len(buckets)-1].Le != nil {
+		t.Errorf("expected one transaction each in the 10, 50 and unbounded buckets, got %+v", buckets)
+	}
+
+	var sb strings.Builder
+	w := bufio.NewWriter(&sb)
+	writeFamily(w, a.viewLatency.family())
+	_ = w.Flush()
+	if !strings.Contains(sb.String(), `analytics_stats_view_duration_seconds_count{view="per_user"} 1`) ||
+		!strings.Contains(sb.String(), `analytics_stats_view_duration_seconds_count{view="time_series"} 0`) {
+		t.Errorf("expected per-view counts, got:\n%s", sb.String())
+	}
+}
+
+func TestStats_MergeViews(t *testing.T) {
+	hour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	local := Stats{
+		PerUser:      &PerUserView{TopByCount: []UserTotal{{UserID: 1, Value: 3}}},
+		TimeSeries:   &TimeSeriesView{Interval: "hourly", Buckets: []TimeBucket{{Start: hour, Transactions: 2}}},
+		Distribution: &DistributionView{Buckets: newDistributionAggregator().Snapshot()},
+	}
+	peerDist := newDistributionAggregator()
+	peerDist.Handle(&TransactionEvent{EventType: "transaction.created", Amount: 75}, time.Now())
+	peer := &Stats{
+		PerUser:      &PerUserView{TopByCount: []UserTotal{{UserID: 2, Value: 5}}},
+		TimeSeries:   &TimeSeriesView{Interval: "hourly", Buckets: []TimeBucket{{Start: hour, Transactions: 1}, {Start: hour.Add(time.Hour), Transactions: 4}}},
+		Distribution: &DistributionView{Buckets: peerDist.Snapshot()},
+	}
+
+	merged := newMergedStats(local)
+	merged.merge(peer)
+	if top := merged.PerUser.TopByCount; len(top) != 2 || top[0].UserID != 2 {
+		t.Errorf("expected user 2 first, got %+v", top)
+	}
+	if b := merged.TimeSeries.Buckets; len(b) != 2 || b[0].Transactions != 3 || b[1].Transactions != 4 {
+		t.Errorf("expected buckets summed by start, got %+v", b)
+	}
+	if b := merged.Distribution.Buckets[2]; b.Transactions != 1 || b.Amount != 75 {
+		t.Errorf("expected the peer's transaction in the 100 bucket, got %+v", b)
+	}
+	if local.Distribution.Buckets[2].Transactions != 0 {
+		t.Error("expected the local distribution left unchanged")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeBucket holds the aggregates of one event-time window
+type TimeBucket struct {
+	Start            time.Time `json:"start"`
+	Transactions     int64     `json:"transactions"`
+	Amount           float64   `json:"amount"`
+	PaidTransactions int64     `json:"paid_transactions"`
+}
+
+// EventTimeWindows buckets events by their own timestamp rather than by
+// arrival order. The watermark trails the newest event time by the allowed
+// lateness; a window is closed once the watermark passes its end, and events
+// for closed windows are counted as late and dropped.
+type EventTimeWindows struct {
+	size      time.Duration
+	lateness  time.Duration
+	retention int
+	buckets   map[int64]*TimeBucket
+	maxEvent  time.Time
+	late      int64
+}
+
+// NewEventTimeWindows creates tumbling windows of the given size, keeping the
+// most recent retention windows
+func NewEventTimeWindows(size, allowedLateness time.Duration, retention int) *EventTimeWindows {
+	return &EventTimeWindows{
+		size:      size,
+		lateness:  allowedLateness,
+		retention: retention,
+		buckets:   make(map[int64]*TimeBucket),
+	}
+}
+
+// Add assigns an event to its window. It returns false if the event arrived
+// after its window was closed by the watermark.
+func (w *EventTimeWindows) Add(event *TransactionEvent, at time.Time) bool {
+	start := at.Truncate(w.size)
+	if !w.maxEvent.IsZero() && !start.Add(w.size).After(w.Watermark()) {
+		w.late++
+		return false
+	}
+
+	if at.After(w.maxEvent) {
+		w.maxEvent = at
+	}
+
+	key := start.Unix()
+	bucket, ok := w.buckets[key]
+	if !ok {
+		bucket = &TimeBucket{Start: start.UTC()}
+		w.buckets[key] = bucket
+		w.evict()
+	}
+
+	switch event.EventType {
+	case "transaction.created":
+		bucket.Transactions++
+		bucket.Amount += event.Amount
+	case "transaction.paid":
+		bucket.PaidTransactions += event.TransactionsPaid
+	case "transaction.payment_reverted":
+		bucket.PaidTransactions -= event.TransactionsReverted
+	}
+	return true
+}
+
+// Watermark returns the event time before which windows are considered complete
+func (w *EventTimeWindows) Watermark() time.Time {
+	if w.maxEvent.IsZero() {
+		return time.Time{}
+	}
+	return w.maxEvent.Add(-w.lateness)
+}
+
+// LateEvents returns the number of events dropped for arriving too late
+func (w *EventTimeWindows) LateEvents() int64 {
+	return w.late
+}
+
+// Bucket returns a copy of the window containing at, empty when it has no
+// events
+func (w *EventTimeWindows) Bucket(at time.Time) TimeBucket {
+	start := at.Truncate(w.size)
+	if b, ok := w.buckets[start.Unix()]; ok {
+		return *b
+	}
+	return TimeBucket{Start: start.UTC()}
+}
+
+// Series returns a copy of the retained windows, oldest first
+func (w *EventTimeWindows) Series() []TimeBucket {
+	series := make([]TimeBucket, 0, len(w.buckets))
+	for _, b := range w.buckets {
+		series = append(series, *b)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Start.Before(series[j].Start) })
+	return series
+}
+
+// evict drops the oldest windows beyond the retention limit
+func (w *EventTimeWindows) evict() {
+	if len(w.buckets) <= w.retention {
+		return
+	}
+	keys := make([]int64, 0, len(w.buckets))
+	for k := range w.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys[:len(keys)-w.retention] {
+		delete(w.buckets, k)
+	}
+}
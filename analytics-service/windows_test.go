@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+)
+
+func TestEventTimeWindows_AssignsByEventTime(t *testing.T) {
+	w := NewEventTimeWindows(time.Hour, 30*time.Minute, 48)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	created := &TransactionEvent{EventType: "transaction.created", Amount: 10}
+	w.Add(created, base.Add(70*time.Minute)) // 11:10
+	// Delayed event for 10:50 arrives after 11:10 but within allowed lateness
+	if !w.Add(created, base.Add(50*time.Minute)) {
+		t.Fatal("expected event within allowed lateness to be accepted")
+	}
+
+	series := w.Series()
+	if len(series) != 2 {
+		t.Fatalf("expected 2 hourly buckets, got %d", len(series))
+	}
+	if !series[0].Start.Equal(base) || series[0].Transactions != 1 {
+		t.Errorf("expected delayed event in the 10:00 bucket, got %+v", series[0])
+	}
+}
+
+func TestEventTimeWindows_DropsEventsBehindWatermark(t *testing.T) {
+	w := NewEventTimeWindows(time.Hour, 30*time.Minute, 48)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	created := &TransactionEvent{EventType: "transaction.created", Amount: 10}
+
+	w.Add(created, base.Add(3*time.Hour)) // watermark moves to 12:30
+	if w.Add(created, base.Add(90*time.Minute)) {
+		t.Error("expected event for closed 11:00 window to be dropped")
+	}
+	if w.LateEvents() != 1 {
+		t.Errorf("expected 1 late event, got %d", w.LateEvents())
+	}
+}
+
+func TestEventTimeWindows_Retention(t *testing.T) {
+	w := NewEventTimeWindows(time.Hour, 0, 3)
+	base := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	created := &TransactionEvent{EventType: "transaction.created", Amount: 1}
+
+	for i := 0; i < 5; i++ {
+		w.Add(created, base.Add(time.Duration(i)*time.Hour))
+	}
+
+	series := w.Series()
+	if len(series) != 3 || !series[0].Start.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("expected the 3 newest buckets, got %+v", series)
+	}
+}
+
+func TestTimeSeries_Page(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	series := TimeSeries{Interval: "hourly"}
+	for i := range 5 {
+		series.Buckets = append(series.Buckets, TimeBucket{Start: base.Add(time.Duration(i) * time.Hour)})
+	}
+
+	var starts []time.Time
+	page := pagination.PageRequest{Limit: 2}
+	for {
+		paged, err := series.Page(page)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if paged.Total != 5 {
+			t.Errorf("expected a total of 5, got %d", paged.Total)
+		}
+		for _, b := range paged.Buckets {
+			starts = append(starts, b.Start)
+		}
+		if paged.NextCursor == "" {
+			break
+		}
+		page.Cursor = paged.NextCursor
+	}
+	if len(starts) != 5 || !starts[4].Equal(base.Add(4*time.Hour)) {
+		t.Errorf("expected every bucket once, oldest first, got %v", starts)
+	}
+
+	if all, _ := series.Page(pagination.PageRequest{}); len(all.Buckets) != 5 || all.NextCursor != "" {
+		t.Errorf("expected an unpaged request to return every bucket, got %+v", all)
+	}
+}
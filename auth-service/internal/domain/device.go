@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// ClientInfo describes the client a login comes from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+	// DeviceID is an identifier the client keeps across sessions, if it
+	// sends one
+	DeviceID string
+	// Country and ASN locate IP when the gateway has GeoIP databases; empty
+	// otherwise
+	Country string
+	ASN     string
+}
+
+// Fingerprint identifies the client's device: its DeviceID when it sends
+// one, otherwise its user agent. The IP is left out so a phone changing
+// networks stays the same device.
+func (c ClientInfo) Fingerprint() string {
+	key := "ua:" + c.UserAgent
+	if c.DeviceID != "" {
+		key = "id:" + c.DeviceID
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Device is a client a user has logged in from
+type Device struct {
+	UserID      int       `json:"-"`
+	Fingerprint string    `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// DeviceRepository defines the interface for known-device data access
+type DeviceRepository interface {
+	// Record stores a login from device, adding it or refreshing its user
+	// agent, IP and last-seen time. isNew reports whether the user hadn't
+	// logged in from it before, and first whether it is the user's only
+	// device.
+	Record(ctx context.Context, device *Device) (isNew, first bool, err error)
+	// ListByUser returns the user's devices, most recently seen first
+	ListByUser(ctx context.Context, userID int) ([]Device, error)
+}
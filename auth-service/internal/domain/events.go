@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Account event types
+const (
+	EventAccountDeletionScheduled = "account.deletion_scheduled"
+	EventAccountDeletionCancelled = "account.deletion_cancelled"
+	EventNewDeviceLogin           = "user.new_device_login"
+)
+
+// AccountEventPublisher announces account lifecycle changes to other services
+type AccountEventPublisher interface {
+	// PublishAccountDeletionScheduled asks downstream services to purge the
+	// user's data at event.PurgeAt unless a cancellation arrives first
+	PublishAccountDeletionScheduled(ctx context.Context, event *AccountDeletionEvent) error
+	// PublishAccountDeletionCancelled withdraws a scheduled purge
+	PublishAccountDeletionCancelled(ctx context.Context, event *AccountDeletionEvent) error
+	// PublishNewDeviceLogin lets the notification service alert the user to
+	// a login from a device they haven't used before
+	PublishNewDeviceLogin(ctx context.Context, event *NewDeviceLoginEvent) error
+	// Close closes the publisher
+	Close() error
+}
+
+// AccountDeletionEvent represents a scheduled or cancelled account deletion
+type AccountDeletionEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version int `json:"version"`
+	UserID  int `json:"user_id"`
+	// PurgeAt is when the account's data is purged, or for a cancellation
+	// when it would have been
+	PurgeAt   time.Time `json:"purge_at"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewDeviceLoginEvent represents a login from a device new to the user
+type NewDeviceLoginEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version  int    `json:"version"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	// Email is empty when the user has none
+	Email     string `json:"email,omitempty"`
+	DeviceID  string `json:"device_id"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	// Country and ASN are where IP is registered, when known
+	Country   string    `json:"country,omitempty"`
+	ASN       string    `json:"asn,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
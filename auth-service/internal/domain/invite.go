@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Roles a user can hold. Registration grants RoleUser unless an invite code
+// presets another.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// InviteCode admits up to MaxUses registrations until ExpiresAt
+type InviteCode struct {
+	Code    string `json:"code"`
+	Role    string `json:"role"`
+	MaxUses int    `json:"max_uses"`
+	Uses    int    `json:"uses"`
+	// ExpiresAt is nil for codes that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Redeemable reports whether the code admits another registration at now
+func (c *InviteCode) Redeemable(now time.Time) bool {
+	return c.Uses < c.MaxUses && (c.ExpiresAt == nil || now.Before(*c.ExpiresAt))
+}
+
+// InviteRepository defines the interface for invite code data access.
+// Redemption happens in UserRepository.CreateWithInvite, together with the
+// insert it pays for.
+type InviteRepository interface {
+	// Create stores a new code
+	Create(ctx context.Context, code *InviteCode) (*InviteCode, error)
+	// FindByCode finds a code, returning nil if it doesn't exist
+	FindByCode(ctx context.Context, code string) (*InviteCode, error)
+	// List returns every code, newest first
+	List(ctx context.Context) ([]InviteCode, error)
+	// Update replaces a code's role, max uses and expiry, returning nil if
+	// it doesn't exist
+	Update(ctx context.Context, code *InviteCode) (*InviteCode, error)
+	// Delete removes a code, reporting whether it existed
+	Delete(ctx context.Context, code string) (bool, error)
+}
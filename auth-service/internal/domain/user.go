@@ -1,27 +1,98 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
+	// Email is optional and stored lowercased
+	Email    string `json:"email,omitempty"`
 	Password string `json:"password,omitempty"`
+	Role     string `json:"role"`
+	// DeletionScheduledAt is set while the account is pending deletion
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	// NotificationChannels are where the user wants notifications sent, in
+	// NotificationChannelList order; empty opts out of all of them
+	NotificationChannels []string `json:"notification_channels"`
+	Preferences
 }
 
+// Deleted reports whether the account's deletion grace period has passed
+// at now
+func (u *User) Deleted(now time.Time) bool {
+	return u.DeletionScheduledAt != nil && !now.Before(*u.DeletionScheduledAt)
+}
+
+// Preferences are a user's display and calendar settings
+type Preferences struct {
+	// Timezone is an IANA zone name, e.g. "Asia/Bangkok"
+	Timezone string `json:"timezone"`
+	// Locale is a BCP 47 language tag, e.g. "th-TH"
+	Locale string `json:"locale"`
+}
+
+// Default preferences for users who haven't chosen their own
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en-US"
+)
+
+// Notification channels. Delivery is the notification service's job; users
+// only choose which channels they want.
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push"
+)
+
+// NotificationChannelList lists every notification channel in display order
+var NotificationChannelList = []string{NotificationChannelEmail, NotificationChannelSMS, NotificationChannelPush}
+
+// DefaultNotificationChannels are a new user's channels
+var DefaultNotificationChannels = []string{NotificationChannelEmail}
+
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	// Create creates a new user and returns the created user with ID
 	Create(ctx context.Context, user *User) (*User, error)
+	// CreateWithInvite redeems one use of an invite code and creates the user
+	// with the code's role, atomically. It returns nil if the code doesn't
+	// exist, is used up or has expired.
+	CreateWithInvite(ctx context.Context, user *User, code string) (*User, error)
 	// FindByUsername finds a user by username
 	FindByUsername(ctx context.Context, username string) (*User, error)
+	// FindByUsernameOrEmail finds a user whose username equals login or whose
+	// email matches it case-insensitively, preferring the username match
+	FindByUsernameOrEmail(ctx context.Context, login string) (*User, error)
 	// FindByID finds a user by ID
 	FindByID(ctx context.Context, id int) (*User, error)
+	// List returns up to limit users with IDs above afterID, in ID order; a
+	// limit of 0 returns every such user
+	List(ctx context.Context, afterID, limit int) ([]User, error)
+	// Count returns the number of users
+	Count(ctx context.Context) (int64, error)
+	// UpdatePreferences replaces a user's preferences
+	UpdatePreferences(ctx context.Context, id int, prefs Preferences) error
+	// UpdateNotificationChannels replaces a user's notification channels
+	UpdateNotificationChannels(ctx context.Context, id int, channels []string) error
+	// SetDeletionSchedule sets when a user's account is deleted; nil cancels
+	// a pending deletion
+	SetDeletionSchedule(ctx context.Context, id int, at *time.Time) error
 }
 
 // AuthResponse represents the response after successful authentication
 type AuthResponse struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role"`
 	Token    string `json:"token"`
+	// DeletionScheduledAt tells a user logging in during the grace period
+	// that their account is about to be deleted
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	Preferences
 }
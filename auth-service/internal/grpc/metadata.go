@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+// Where grpc-gateway forwards the REST client's User-Agent and X-Device-ID
+// headers
+const (
+	metadataUserAgent = "grpcgateway-user-agent"
+	metadataDeviceID  = "grpcgateway-device-id"
+)
+
+// MetadataInterceptor fills request fields the caller left empty from its
+// metadata: token from the bearer token, and user_agent and device_id from the
+// REST client's User-Agent and X-Device-ID. REST clients of the gateway's
+// generated routes authenticate with an Authorization header like every other
+// route, rather than repeating the token in the body. Chain it before validation, which requires the token.
+func MetadataInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if msg, ok := req.(proto.Message); ok {
+			m := msg.ProtoReflect()
+			setIfEmpty(m, "token", grpcauth.BearerToken(ctx))
+			if values := metadata.ValueFromIncomingContext(ctx, metadataUserAgent); len(values) > 0 {
+				setIfEmpty(m, "user_agent", values[0])
+			}
+			if values := metadata.ValueFromIncomingContext(ctx, metadataDeviceID); len(values) > 0 {
+				setIfEmpty(m, "device_id", values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// setIfEmpty sets m's string field name to value when m has such a field and
+// it is empty
+func setIfEmpty(m protoreflect.Message, name protoreflect.Name, value string) {
+	fd := m.Descriptor().Fields().ByName(name)
+	if value == "" || fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() || m.Get(fd).String() != "" {
+		return
+	}
+	m.Set(fd, protoreflect.ValueOfString(value))
+}
@@ -2,27 +2,35 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
 	"github.com/tkaewplik/go-microservices/auth-service/internal/service"
-	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
 	pb "github.com/tkaewplik/go-microservices/proto/auth"
 )
 
 // AuthServer implements the gRPC AuthService
 type AuthServer struct {
 	pb.UnimplementedAuthServiceServer
-	authService *service.AuthService
-	jwtSecret   string
+	authService   *service.AuthService
+	inviteService *service.InviteService
+	serviceToken  string
 }
 
-// NewAuthServer creates a new gRPC AuthServer
-func NewAuthServer(authService *service.AuthService, jwtSecret string) *AuthServer {
+// NewAuthServer creates a new gRPC AuthServer. serviceToken guards the admin
+// RPCs; when empty they are disabled.
+func NewAuthServer(authService *service.AuthService, inviteService *service.InviteService, serviceToken string) *AuthServer {
 	return &AuthServer{
-		authService: authService,
-		jwtSecret:   jwtSecret,
+		authService:   authService,
+		inviteService: inviteService,
+		serviceToken:  serviceToken,
 	}
 }
 
@@ -32,56 +40,387 @@ func (s *AuthServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb
 		return nil, status.Error(codes.InvalidArgument, "username and password are required")
 	}
 
-	resp, err := s.authService.Register(ctx, req.Username, req.Password)
+	resp, err := s.authService.Register(ctx, req.Username, req.Email, req.Password, req.InviteCode, domain.Preferences{
+		Timezone: req.Timezone,
+		Locale:   req.Locale,
+	})
 	if err != nil {
-		if err == service.ErrUserAlreadyExists {
+		switch {
+		case errors.Is(err, service.ErrUserAlreadyExists):
 			return nil, status.Error(codes.AlreadyExists, "username already exists")
+		case errors.Is(err, service.ErrEmailAlreadyExists):
+			return nil, status.Error(codes.AlreadyExists, "email already registered")
+		case errors.Is(err, service.ErrInvalidEmail):
+			return nil, status.Error(codes.InvalidArgument, "invalid email")
+		case errors.Is(err, service.ErrInviteRequired):
+			return nil, status.Error(codes.PermissionDenied, "invite code required")
+		case errors.Is(err, service.ErrInvalidInvite):
+			return nil, status.Error(codes.PermissionDenied, "invalid invite code")
+		case errors.Is(err, service.ErrInvalidTimezone):
+			return nil, status.Error(codes.InvalidArgument, "invalid timezone")
+		case errors.Is(err, service.ErrInvalidLocale):
+			return nil, status.Error(codes.InvalidArgument, "invalid locale")
 		}
 		return nil, status.Error(codes.Internal, "failed to register user")
 	}
 
-	return &pb.AuthResponse{
-		Id:       int32(resp.ID),
-		Username: resp.Username,
-		Token:    resp.Token,
-	}, nil
+	return toPBAuthResponse(resp), nil
 }
 
-// Login authenticates a user and returns a token
+// Login authenticates a user by username or email and returns a token
 func (s *AuthServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.AuthResponse, error) {
-	if req.Username == "" || req.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	login := req.Username
+	if login == "" {
+		login = req.Email
+	}
+	if login == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "username or email and password are required")
 	}
 
-	resp, err := s.authService.Login(ctx, req.Username, req.Password)
+	country, asn := grpcauth.ClientGeo(ctx)
+	resp, err := s.authService.Login(ctx, login, req.Password, domain.ClientInfo{
+		UserAgent: req.UserAgent,
+		IP:        grpcauth.ClientIP(ctx),
+		DeviceID:  req.DeviceId,
+		Country:   country,
+		ASN:       asn,
+	})
 	if err != nil {
-		if err == service.ErrInvalidCredentials {
+		if errors.Is(err, service.ErrInvalidCredentials) {
 			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 		}
+		if errors.Is(err, service.ErrAccountDeleted) {
+			return nil, status.Error(codes.PermissionDenied, "account deleted")
+		}
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
-	return &pb.AuthResponse{
-		Id:       int32(resp.ID),
-		Username: resp.Username,
-		Token:    resp.Token,
-	}, nil
+	return toPBAuthResponse(resp), nil
 }
 
 // ValidateToken validates a JWT token and returns user info
 func (s *AuthServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-	if req.Token == "" {
-		return &pb.ValidateTokenResponse{Valid: false}, nil
-	}
-
-	claims, err := jwt.ValidateToken(req.Token, s.jwtSecret)
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
 	if err != nil {
 		return &pb.ValidateTokenResponse{Valid: false}, nil
 	}
 
 	return &pb.ValidateTokenResponse{
 		Valid:    true,
-		UserId:   int32(claims.UserID),
+		UserId:   int64(claims.UserID),
 		Username: claims.Username,
+		Timezone: claims.Timezone,
+		Locale:   claims.Locale,
+		Role:     claims.Role,
+		Scopes:   claims.Scopes,
 	}, nil
 }
+
+// UpdatePreferences changes the caller's timezone and locale
+func (s *AuthServer) UpdatePreferences(ctx context.Context, req *pb.UpdatePreferencesRequest) (*pb.AuthResponse, error) {
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	resp, err := s.authService.UpdatePreferences(ctx, claims.UserID, domain.Preferences{
+		Timezone: req.Timezone,
+		Locale:   req.Locale,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		case errors.Is(err, service.ErrAccountDeleted):
+			return nil, status.Error(codes.PermissionDenied, "account deleted")
+		case errors.Is(err, service.ErrInvalidTimezone):
+			return nil, status.Error(codes.InvalidArgument, "invalid timezone")
+		case errors.Is(err, service.ErrInvalidLocale):
+			return nil, status.Error(codes.InvalidArgument, "invalid locale")
+		}
+		return nil, status.Error(codes.Internal, "failed to update preferences")
+	}
+
+	return toPBAuthResponse(resp), nil
+}
+
+// DeleteAccount schedules the caller's account for deletion
+func (s *AuthServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	purgeAt, err := s.authService.DeleteAccount(ctx, claims.UserID, req.Password)
+	if err != nil {
+		return nil, accountError(err, "failed to delete account")
+	}
+
+	return &pb.DeleteAccountResponse{PurgeAt: timestamppb.New(purgeAt)}, nil
+}
+
+// CancelAccountDeletion keeps the caller's account
+func (s *AuthServer) CancelAccountDeletion(ctx context.Context, req *pb.CancelAccountDeletionRequest) (*pb.CancelAccountDeletionResponse, error) {
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if err := s.authService.CancelAccountDeletion(ctx, claims.UserID); err != nil {
+		return nil, accountError(err, "failed to cancel account deletion")
+	}
+
+	return &pb.CancelAccountDeletionResponse{}, nil
+}
+
+// ListDevices returns the devices the caller has logged in from
+func (s *AuthServer) ListDevices(ctx context.Context, req *pb.ListDevicesRequest) (*pb.DeviceList, error) {
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	devices, err := s.authService.ListDevices(ctx, claims.UserID)
+	if err != nil {
+		return nil, accountError(err, "failed to list devices")
+	}
+
+	list := &pb.DeviceList{Devices: make([]*pb.Device, 0, len(devices))}
+	for _, d := range devices {
+		list.Devices = append(list.Devices, &pb.Device{
+			Id:          d.Fingerprint,
+			UserAgent:   d.UserAgent,
+			LastIp:      d.LastIP,
+			FirstSeenAt: timestamppb.New(d.FirstSeenAt),
+			LastSeenAt:  timestamppb.New(d.LastSeenAt),
+		})
+	}
+	return list, nil
+}
+
+// accountError maps account deletion errors to gRPC statuses, falling back
+// to Internal with fallback as the message
+func accountError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+	case errors.Is(err, service.ErrAccountDeleted):
+		return status.Error(codes.PermissionDenied, "account deleted")
+	case errors.Is(err, service.ErrDeletionNotPending):
+		return status.Error(codes.FailedPrecondition, "account deletion not pending")
+	}
+	return status.Error(codes.Internal, fallback)
+}
+
+// GetNotificationChannels returns the caller's notification channels, or
+// user_id's for callers holding the service token
+func (s *AuthServer) GetNotificationChannels(ctx context.Context, req *pb.GetNotificationChannelsRequest) (*pb.NotificationChannels, error) {
+	var userID int
+	switch {
+	case req.Token != "":
+		claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		userID = claims.UserID
+	case grpcauth.HasServiceToken(ctx, s.serviceToken):
+		if req.UserId <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "user_id is required")
+		}
+		userID = int(req.UserId)
+	default:
+		return nil, status.Error(codes.Unauthenticated, "token or service token required")
+	}
+
+	channels, err := s.authService.NotificationChannels(ctx, userID)
+	if err != nil {
+		return nil, accountError(err, "failed to get notification channels")
+	}
+	return &pb.NotificationChannels{UserId: int64(userID), Channels: channels}, nil
+}
+
+// UpdateNotificationChannels replaces the caller's notification channels
+func (s *AuthServer) UpdateNotificationChannels(ctx context.Context, req *pb.UpdateNotificationChannelsRequest) (*pb.NotificationChannels, error) {
+	claims, err := s.authService.ValidateToken(req.Token, grpcauth.ClientIP(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	channels, err := s.authService.UpdateNotificationChannels(ctx, claims.UserID, req.Channels)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidChannel) {
+			return nil, status.Error(codes.InvalidArgument, "channels must be email, sms or push")
+		}
+		return nil, accountError(err, "failed to update notification channels")
+	}
+	return &pb.NotificationChannels{UserId: int64(claims.UserID), Channels: channels}, nil
+}
+
+// GetAuthMetrics returns token validation counters to callers holding the
+// service token
+func (s *AuthServer) GetAuthMetrics(ctx context.Context, req *pb.GetAuthMetricsRequest) (*pb.AuthMetrics, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	m := s.authService.AuthMetrics()
+	return &pb.AuthMetrics{
+		Validations:      m.Validations,
+		Failures:         m.Failures,
+		FailuresByReason: m.FailuresByReason,
+	}, nil
+}
+
+// CreateInviteCode generates an invite code
+func (s *AuthServer) CreateInviteCode(ctx context.Context, req *pb.CreateInviteCodeRequest) (*pb.InviteCode, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	invite, err := s.inviteService.Create(ctx, req.Role, int(req.MaxUses), optionalTime(req.ExpiresAt))
+	if err != nil {
+		return nil, inviteError(err, "failed to create invite code")
+	}
+	return toPBInviteCode(invite), nil
+}
+
+// GetInviteCode returns one invite code
+func (s *AuthServer) GetInviteCode(ctx context.Context, req *pb.GetInviteCodeRequest) (*pb.InviteCode, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	invite, err := s.inviteService.Get(ctx, req.Code)
+	if err != nil {
+		return nil, inviteError(err, "failed to get invite code")
+	}
+	return toPBInviteCode(invite), nil
+}
+
+// ListInviteCodes returns every invite code
+func (s *AuthServer) ListInviteCodes(ctx context.Context, req *pb.ListInviteCodesRequest) (*pb.InviteCodeList, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	invites, err := s.inviteService.List(ctx)
+	if err != nil {
+		return nil, inviteError(err, "failed to list invite codes")
+	}
+	list := &pb.InviteCodeList{InviteCodes: make([]*pb.InviteCode, len(invites))}
+	for i := range invites {
+		list.InviteCodes[i] = toPBInviteCode(&invites[i])
+	}
+	return list, nil
+}
+
+// UpdateInviteCode replaces an invite code's settings
+func (s *AuthServer) UpdateInviteCode(ctx context.Context, req *pb.UpdateInviteCodeRequest) (*pb.InviteCode, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	invite, err := s.inviteService.Update(ctx, req.Code, req.Role, int(req.MaxUses), optionalTime(req.ExpiresAt))
+	if err != nil {
+		return nil, inviteError(err, "failed to update invite code")
+	}
+	return toPBInviteCode(invite), nil
+}
+
+// DeleteInviteCode revokes an invite code
+func (s *AuthServer) DeleteInviteCode(ctx context.Context, req *pb.DeleteInviteCodeRequest) (*pb.DeleteInviteCodeResponse, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	if err := s.inviteService.Delete(ctx, req.Code); err != nil {
+		return nil, inviteError(err, "failed to delete invite code")
+	}
+	return &pb.DeleteInviteCodeResponse{}, nil
+}
+
+// ListUsers returns a page of accounts to callers holding the service token
+func (s *AuthServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.UserList, error) {
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return nil, status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	page, err := s.authService.ListUsers(ctx, pagination.FromProto(req.GetPage()))
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		return nil, status.Error(codes.Internal, "failed to list users")
+	}
+
+	list := &pb.UserList{Users: make([]*pb.User, len(page.Items)), Page: page.PageInfo.Proto()}
+	for i, user := range page.Items {
+		list.Users[i] = &pb.User{
+			Id:       int64(user.ID),
+			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+		}
+		if user.DeletionScheduledAt != nil {
+			list.Users[i].DeletionScheduledAt = timestamppb.New(*user.DeletionScheduledAt)
+		}
+	}
+	return list, nil
+}
+
+// inviteError maps InviteService errors to gRPC statuses, falling back to
+// Internal with fallback as the message
+func inviteError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrInviteNotFound):
+		return status.Error(codes.NotFound, "invite code not found")
+	case errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrInvalidMaxUses),
+		errors.Is(err, service.ErrInvalidExpiry):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrMaxUsesTooSmall):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, fallback)
+}
+
+// optionalTime converts an unset timestamp to nil
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func toPBInviteCode(invite *domain.InviteCode) *pb.InviteCode {
+	code := &pb.InviteCode{
+		Code:      invite.Code,
+		Role:      invite.Role,
+		MaxUses:   int32(invite.MaxUses),
+		Uses:      int32(invite.Uses),
+		CreatedAt: timestamppb.New(invite.CreatedAt),
+	}
+	if invite.ExpiresAt != nil {
+		code.ExpiresAt = timestamppb.New(*invite.ExpiresAt)
+	}
+	return code
+}
+
+func toPBAuthResponse(resp *domain.AuthResponse) *pb.AuthResponse {
+	pbResp := &pb.AuthResponse{
+		Id:       int64(resp.ID),
+		Username: resp.Username,
+		Email:    resp.Email,
+		Role:     resp.Role,
+		Token:    resp.Token,
+		Timezone: resp.Timezone,
+		Locale:   resp.Locale,
+	}
+	if resp.DeletionScheduledAt != nil {
+		pbResp.DeletionScheduledAt = timestamppb.New(*resp.DeletionScheduledAt)
+	}
+	return pbResp
+}
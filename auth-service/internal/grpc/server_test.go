@@ -0,0 +1,388 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/service"
+	"github.com/tkaewplik/go-microservices/auth-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/grpcvalidate"
+	pkgtestutil "github.com/tkaewplik/go-microservices/pkg/testutil"
+	pb "github.com/tkaewplik/go-microservices/proto/auth"
+	paginationpb "github.com/tkaewplik/go-microservices/proto/pagination"
+)
+
+const testServiceToken = "test-service-token"
+
+// newTestClient serves an AuthServer over bufconn with the same interceptors
+// as main
+func newTestClient(t *testing.T, opts ...service.Option) (pb.AuthServiceClient, *testutil.FakeUserRepository) {
+	t.Helper()
+
+	repo := testutil.NewFakeUserRepository()
+	repo.Invites = testutil.NewFakeInviteRepository()
+	svc := service.NewAuthService(repo, "test-secret", opts...)
+	invites := service.NewInviteService(repo.Invites)
+	conn := pkgtestutil.NewBufconnServer(t, func(s *grpc.Server) {
+		pb.RegisterAuthServiceServer(s, NewAuthServer(svc, invites, testServiceToken))
+	}, grpc.ChainUnaryInterceptor(MetadataInterceptor(), grpcvalidate.UnaryServerInterceptor()))
+	return pb.NewAuthServiceClient(conn), repo
+}
+
+func TestAuthServer_RegisterLoginValidate_RoundTrip(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	reg, err := client.Register(ctx, &pb.RegisterRequest{
+		Username: "alice",
+		Password: "password123",
+		Timezone: "Asia/Bangkok",
+		Locale:   "th-TH",
+		Email:    "alice@example.com",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if reg.GetId() == 0 || reg.GetUsername() != "alice" || reg.GetEmail() != "alice@example.com" || reg.GetToken() == "" {
+		t.Errorf("unexpected register response: %+v", reg)
+	}
+	if reg.GetTimezone() != "Asia/Bangkok" || reg.GetLocale() != "th-TH" {
+		t.Errorf("expected preferences to round-trip, got %q/%q", reg.GetTimezone(), reg.GetLocale())
+	}
+
+	login, err := client.Login(ctx, &pb.LoginRequest{Username: "alice", Password: "password123"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.Login(ctx, &pb.LoginRequest{Email: "Alice@example.com", Password: "password123"}); err != nil {
+		t.Fatalf("expected login by email to succeed, got %v", err)
+	}
+
+	v, err := client.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: login.GetToken()})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !v.GetValid() || v.GetUserId() != reg.GetId() || v.GetUsername() != "alice" || v.GetTimezone() != "Asia/Bangkok" {
+		t.Errorf("unexpected validation: %+v", v)
+	}
+
+	updated, err := client.UpdatePreferences(ctx, &pb.UpdatePreferencesRequest{
+		Token:    login.GetToken(),
+		Timezone: "Europe/London",
+		Locale:   "en-GB",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	v, err = client.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: updated.GetToken()})
+	if err != nil || v.GetTimezone() != "Europe/London" || v.GetLocale() != "en-GB" {
+		t.Errorf("expected new token to carry updated preferences, got %+v, %v", v, err)
+	}
+}
+
+func TestAuthServer_StatusCodes(t *testing.T) {
+	client, repo := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.Register(ctx, &pb.RegisterRequest{Username: "taken", Password: "pw", Email: "taken@example.com"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"duplicate username", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "taken", Password: "pw"})
+			return err
+		}, codes.AlreadyExists},
+		{"empty username", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Password: "pw"})
+			return err
+		}, codes.InvalidArgument},
+		{"duplicate email", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "bob", Password: "pw", Email: "Taken@Example.com"})
+			return err
+		}, codes.AlreadyExists},
+		{"invalid email", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "bob", Password: "pw", Email: "bob"})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid timezone", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "bob", Password: "pw", Timezone: "Mars/Olympus"})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid locale", func() error {
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "bob", Password: "pw", Locale: "not a locale"})
+			return err
+		}, codes.InvalidArgument},
+		{"wrong password", func() error {
+			_, err := client.Login(ctx, &pb.LoginRequest{Username: "taken", Password: "nope"})
+			return err
+		}, codes.Unauthenticated},
+		{"login without username or email", func() error {
+			_, err := client.Login(ctx, &pb.LoginRequest{Password: "pw"})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown user", func() error {
+			_, err := client.Login(ctx, &pb.LoginRequest{Username: "ghost", Password: "pw"})
+			return err
+		}, codes.Unauthenticated},
+		{"update with forged token", func() error {
+			_, err := client.UpdatePreferences(ctx, &pb.UpdatePreferencesRequest{Token: "forged"})
+			return err
+		}, codes.Unauthenticated},
+		{"repository failure", func() error {
+			repo.CreateErr = errors.New("connection reset")
+			defer func() { repo.CreateErr = nil }()
+			_, err := client.Register(ctx, &pb.RegisterRequest{Username: "carol", Password: "pw"})
+			return err
+		}, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthServer_ValidateToken_Invalid(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	// An invalid token is a normal answer, not an RPC error
+	v, err := client.ValidateToken(context.Background(), &pb.ValidateTokenRequest{Token: "forged"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if v.GetValid() {
+		t.Error("expected token to be invalid")
+	}
+
+	// An empty token is rejected by the validation rules before the handler
+	_, err = client.ValidateToken(context.Background(), &pb.ValidateTokenRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestAuthServer_GetAuthMetrics(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := client.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: "forged"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if _, err := client.GetAuthMetrics(ctx, &pb.GetAuthMetricsRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without service token, got %v", err)
+	}
+	wrong := metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, "wrong")
+	if _, err := client.GetAuthMetrics(wrong, &pb.GetAuthMetricsRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied with wrong service token, got %v", err)
+	}
+
+	admin := metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, testServiceToken)
+	m, err := client.GetAuthMetrics(admin, &pb.GetAuthMetricsRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if m.GetValidations() != 2 || m.GetFailures() != 2 || m.GetFailuresByReason()["malformed"] != 2 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestAuthServer_ListUsers(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if _, err := client.Register(ctx, &pb.RegisterRequest{Username: username, Password: "pw"}); err != nil {
+			t.Fatalf("register %s: expected no error, got %v", username, err)
+		}
+	}
+
+	if _, err := client.ListUsers(ctx, &pb.ListUsersRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without service token, got %v", err)
+	}
+
+	admin := metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, testServiceToken)
+	var usernames []string
+	req := &pb.ListUsersRequest{Page: &paginationpb.PageRequest{Limit: 2}}
+	for {
+		list, err := client.ListUsers(admin, req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if list.GetPage().GetTotal() != 3 {
+			t.Errorf("expected a total of 3, got %+v", list.GetPage())
+		}
+		for _, user := range list.GetUsers() {
+			usernames = append(usernames, user.GetUsername())
+		}
+		if list.GetPage().GetNextCursor() == "" {
+			break
+		}
+		req.Page.Cursor = list.GetPage().GetNextCursor()
+	}
+	if len(usernames) != 3 || usernames[0] != "alice" || usernames[2] != "carol" {
+		t.Errorf("expected every user in registration order, got %v", usernames)
+	}
+
+	req.Page.Cursor = "bogus"
+	if _, err := client.ListUsers(admin, req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad cursor, got %v", err)
+	}
+}
+
+func TestAuthServer_InviteOnlyRegistration(t *testing.T) {
+	client, _ := newTestClient(t, service.WithRegistrationMode(service.RegistrationInviteOnly))
+	ctx := context.Background()
+	admin := metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, testServiceToken)
+
+	if _, err := client.CreateInviteCode(ctx, &pb.CreateInviteCodeRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without service token, got %v", err)
+	}
+	if _, err := client.CreateInviteCode(admin, &pb.CreateInviteCodeRequest{Role: "root"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unknown role, got %v", err)
+	}
+
+	invite, err := client.CreateInviteCode(admin, &pb.CreateInviteCodeRequest{Role: "admin", MaxUses: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if invite.GetCode() == "" || invite.GetMaxUses() != 2 || invite.GetExpiresAt() != nil {
+		t.Errorf("unexpected invite code: %+v", invite)
+	}
+
+	if _, err := client.Register(ctx, &pb.RegisterRequest{Username: "alice", Password: "pw"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without an invite code, got %v", err)
+	}
+	if _, err := client.Register(ctx, &pb.RegisterRequest{Username: "alice", Password: "pw", InviteCode: "NOPE"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for an unknown invite code, got %v", err)
+	}
+
+	for _, username := range []string{"alice", "bob"} {
+		reg, err := client.Register(ctx, &pb.RegisterRequest{Username: username, Password: "pw", InviteCode: invite.GetCode()})
+		if err != nil {
+			t.Fatalf("register %s: expected no error, got %v", username, err)
+		}
+		if reg.GetRole() != "admin" {
+			t.Errorf("register %s: expected the preset admin role, got %q", username, reg.GetRole())
+		}
+	}
+	if _, err := client.Register(ctx, &pb.RegisterRequest{Username: "carol", Password: "pw", InviteCode: invite.GetCode()}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied once the code is used up, got %v", err)
+	}
+
+	got, err := client.GetInviteCode(admin, &pb.GetInviteCodeRequest{Code: invite.GetCode()})
+	if err != nil || got.GetUses() != 2 {
+		t.Errorf("expected 2 uses recorded, got %+v, %v", got, err)
+	}
+	if _, err := client.UpdateInviteCode(admin, &pb.UpdateInviteCodeRequest{Code: invite.GetCode(), MaxUses: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition lowering max uses below uses, got %v", err)
+	}
+	updated, err := client.UpdateInviteCode(admin, &pb.UpdateInviteCodeRequest{Code: invite.GetCode(), MaxUses: 3})
+	if err != nil || updated.GetMaxUses() != 3 || updated.GetRole() != "user" {
+		t.Errorf("expected max uses 3 and the default role, got %+v, %v", updated, err)
+	}
+
+	list, err := client.ListInviteCodes(admin, &pb.ListInviteCodesRequest{})
+	if err != nil || len(list.GetInviteCodes()) != 1 {
+		t.Errorf("expected one invite code, got %+v, %v", list, err)
+	}
+	if _, err := client.DeleteInviteCode(admin, &pb.DeleteInviteCodeRequest{Code: invite.GetCode()}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.GetInviteCode(admin, &pb.GetInviteCodeRequest{Code: invite.GetCode()}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after delete, got %v", err)
+	}
+}
+
+func TestAuthServer_NotificationChannels(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	registered, err := client.Register(ctx, &pb.RegisterRequest{Username: "alice", Password: "pw"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, err := client.GetNotificationChannels(ctx, &pb.GetNotificationChannelsRequest{Token: registered.GetToken()})
+	if err != nil || len(got.GetChannels()) != 1 || got.GetChannels()[0] != "email" {
+		t.Fatalf("expected email by default, got %v, %v", got, err)
+	}
+
+	updated, err := client.UpdateNotificationChannels(ctx, &pb.UpdateNotificationChannelsRequest{
+		Token: registered.GetToken(), Channels: []string{"push", "SMS", "push"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ch := updated.GetChannels(); len(ch) != 2 || ch[0] != "sms" || ch[1] != "push" {
+		t.Errorf("expected sms and push in canonical order, got %v", ch)
+	}
+	if _, err := client.UpdateNotificationChannels(ctx, &pb.UpdateNotificationChannelsRequest{
+		Token: registered.GetToken(), Channels: []string{"pigeon"},
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unknown channel, got %v", err)
+	}
+
+	// The notification service looks users up with the service token
+	req := &pb.GetNotificationChannelsRequest{UserId: registered.GetId()}
+	if _, err := client.GetNotificationChannels(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a token, got %v", err)
+	}
+	admin := metadata.AppendToOutgoingContext(ctx, grpcauth.MetadataServiceToken, testServiceToken)
+	got, err = client.GetNotificationChannels(admin, req)
+	if err != nil || got.GetUserId() != registered.GetId() || len(got.GetChannels()) != 2 {
+		t.Errorf("expected the user's channels, got %v, %v", got, err)
+	}
+
+	// Opting out of everything is allowed
+	updated, err = client.UpdateNotificationChannels(ctx, &pb.UpdateNotificationChannelsRequest{Token: registered.GetToken()})
+	if err != nil || len(updated.GetChannels()) != 0 {
+		t.Errorf("expected no channels, got %v, %v", updated, err)
+	}
+}
+
+func TestAuthServer_TokenUserAgentAndDeviceFromMetadata(t *testing.T) {
+	client, _ := newTestClient(t, service.WithDevices(testutil.NewFakeDeviceRepository()))
+	ctx := context.Background()
+	if _, err := client.Register(ctx, &pb.RegisterRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	// As grpc-gateway forwards a REST client's headers. The device ID keeps a
+	// new user agent on the same device.
+	var login *pb.AuthResponse
+	for _, userAgent := range []string{"curl/8.0", "curl/8.1"} {
+		var err error
+		login, err = client.Login(metadata.AppendToOutgoingContext(ctx, metadataUserAgent, userAgent, metadataDeviceID, "laptop-1"),
+			&pb.LoginRequest{Username: "alice", Password: "password123"})
+		if err != nil {
+			t.Fatalf("failed to login: %v", err)
+		}
+	}
+	devices, err := client.ListDevices(grpcauth.WithBearerToken(ctx, login.GetToken()), &pb.ListDevicesRequest{})
+	if err != nil {
+		t.Fatalf("expected the bearer token to stand in for the token field, got %v", err)
+	}
+	if len(devices.GetDevices()) != 1 || devices.GetDevices()[0].GetUserAgent() != "curl/8.1" {
+		t.Errorf("expected the forwarded user agent recorded on one device, got %v", devices.GetDevices())
+	}
+
+	if _, err := client.ListDevices(ctx, &pb.ListDevicesRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without any token, got %v", err)
+	}
+}
@@ -4,11 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
 	"github.com/tkaewplik/go-microservices/auth-service/internal/service"
+	"github.com/tkaewplik/go-microservices/pkg/request"
 )
 
+// deviceIDHeader carries an identifier the client keeps across sessions
+const deviceIDHeader = "X-Device-ID"
+
 // AuthHandler handles HTTP requests for authentication
 type AuthHandler struct {
 	authService *service.AuthService
@@ -27,17 +33,25 @@ func NewAuthHandler(authService *service.AuthService, logger *slog.Logger) *Auth
 type RegisterRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
+	// InviteCode is required when registration is invite only
+	InviteCode string `json:"invite_code"`
+	Timezone   string `json:"timezone"`
+	Locale     string `json:"locale"`
 }
 
-// LoginRequest represents the request body for login
+// LoginRequest represents the request body for login. Either field takes a
+// username or an email.
 type LoginRequest struct {
 	Username string `json:"username"`
+	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string               `json:"error"`
+	Fields []request.FieldError `json:"fields,omitempty"`
 }
 
 // Register handles user registration
@@ -45,9 +59,9 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := request.DecodeBody(r, &req); err != nil {
 		h.logger.Error("failed to decode register request", "error", err)
-		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		h.respondDecodeError(w, err)
 		return
 	}
 
@@ -56,7 +70,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Register(ctx, req.Username, req.Password)
+	response, err := h.authService.Register(ctx, req.Username, req.Email, req.Password, req.InviteCode, domain.Preferences{
+		Timezone: req.Timezone,
+		Locale:   req.Locale,
+	})
 	if err != nil {
 		h.logger.Error("failed to register user", "error", err, "username", req.Username)
 
@@ -65,6 +82,36 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, service.ErrEmailAlreadyExists) {
+			h.respondError(w, http.StatusConflict, "email already registered")
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidEmail) {
+			h.respondError(w, http.StatusBadRequest, "invalid email")
+			return
+		}
+
+		if errors.Is(err, service.ErrInviteRequired) {
+			h.respondError(w, http.StatusForbidden, "invite code required")
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidInvite) {
+			h.respondError(w, http.StatusForbidden, "invalid invite code")
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondError(w, http.StatusBadRequest, "invalid timezone")
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidLocale) {
+			h.respondError(w, http.StatusBadRequest, "invalid locale")
+			return
+		}
+
 		h.respondError(w, http.StatusInternalServerError, "failed to register user")
 		return
 	}
@@ -78,20 +125,24 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := request.DecodeBody(r, &req); err != nil {
 		h.logger.Error("failed to decode login request", "error", err)
-		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		h.respondDecodeError(w, err)
 		return
 	}
 
-	if req.Username == "" || req.Password == "" {
-		h.respondError(w, http.StatusBadRequest, "username and password are required")
+	login := req.Username
+	if login == "" {
+		login = req.Email
+	}
+	if login == "" || req.Password == "" {
+		h.respondError(w, http.StatusBadRequest, "username or email and password are required")
 		return
 	}
 
-	response, err := h.authService.Login(ctx, req.Username, req.Password)
+	response, err := h.authService.Login(ctx, login, req.Password, clientInfo(r))
 	if err != nil {
-		h.logger.Warn("login failed", "error", err, "username", req.Username)
+		h.logger.Warn("login failed", "error", err, "login", login)
 
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			h.respondError(w, http.StatusUnauthorized, "invalid credentials")
@@ -119,3 +170,31 @@ func (h *AuthHandler) respondJSON(w http.ResponseWriter, status int, data interf
 func (h *AuthHandler) respondError(w http.ResponseWriter, status int, message string) {
 	h.respondJSON(w, status, ErrorResponse{Error: message})
 }
+
+// respondDecodeError writes a 400 listing the malformed request fields, or a
+// 413 when the body is over the size limit
+func (h *AuthHandler) respondDecodeError(w http.ResponseWriter, err error) {
+	var decErr *request.DecodeError
+	if errors.Is(err, request.ErrBodyTooLarge) && errors.As(err, &decErr) {
+		h.respondJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: decErr.Message})
+		return
+	}
+	if errors.As(err, &decErr) {
+		h.respondJSON(w, http.StatusBadRequest, ErrorResponse{Error: decErr.Message, Fields: decErr.Fields})
+		return
+	}
+	h.respondError(w, http.StatusBadRequest, "invalid request body")
+}
+
+// clientInfo describes the client sending r, for device tracking
+func clientInfo(r *http.Request) domain.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return domain.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+		DeviceID:  r.Header.Get(deviceIDHeader),
+	}
+}
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/events"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+)
+
+// Publisher implements domain.AccountEventPublisher using Kafka
+type Publisher struct {
+	writer messaging.MessageWriter
+	logger *slog.Logger
+}
+
+// Config holds Kafka publisher configuration
+type Config struct {
+	Brokers []string
+	Topic   string
+	// Faults drops or fails a fraction of writes, for resilience testing
+	Faults messaging.PublishFaults
+}
+
+// NewPublisher creates a new Kafka publisher
+func NewPublisher(cfg Config, logger *slog.Logger) *Publisher {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+	}
+
+	logger.Info("Kafka publisher created", "brokers", cfg.Brokers, "topic", cfg.Topic)
+
+	p := &Publisher{
+		writer: writer,
+		logger: logger,
+	}
+	if cfg.Faults.Enabled() {
+		p.writer = messaging.NewFaultyWriter(writer, cfg.Faults, logger)
+	}
+	return p
+}
+
+// PublishAccountDeletionScheduled publishes an account deletion scheduled event
+func (p *Publisher) PublishAccountDeletionScheduled(ctx context.Context, event *domain.AccountDeletionEvent) error {
+	event.EventType = domain.EventAccountDeletionScheduled
+	event.Version = events.AccountDeletionVersion
+	event.Timestamp = time.Now()
+	return p.publish(ctx, event.EventType, event.UserID, event)
+}
+
+// PublishAccountDeletionCancelled publishes an account deletion cancelled event
+func (p *Publisher) PublishAccountDeletionCancelled(ctx context.Context, event *domain.AccountDeletionEvent) error {
+	event.EventType = domain.EventAccountDeletionCancelled
+	event.Version = events.AccountDeletionVersion
+	event.Timestamp = time.Now()
+	return p.publish(ctx, event.EventType, event.UserID, event)
+}
+
+// PublishNewDeviceLogin publishes a new device login event
+func (p *Publisher) PublishNewDeviceLogin(ctx context.Context, event *domain.NewDeviceLoginEvent) error {
+	event.EventType = domain.EventNewDeviceLogin
+	event.Version = events.NewDeviceLoginVersion
+	event.Timestamp = time.Now()
+	return p.publish(ctx, event.EventType, event.UserID, event)
+}
+
+// publish keys the event by user so a user's events, such as a deletion and
+// its cancellation, land on one partition in order
+func (p *Publisher) publish(ctx context.Context, eventType string, userID int, event interface{}) error {
+	value, err := messaging.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.writer.WriteMessages(ctx,
+		kafka.Message{
+			Key:   strconv.AppendInt(nil, int64(userID), 10),
+			Value: value.Bytes(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	value.Release()
+
+	p.logger.Info("account event published", "event_type", eventType, "user_id", userID)
+
+	return nil
+}
+
+// Close closes the Kafka writer
+func (p *Publisher) Close() error {
+	if err := p.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close Kafka writer: %w", err)
+	}
+	p.logger.Info("Kafka publisher closed")
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/database"
+)
+
+// PostgresDeviceRepository implements DeviceRepository using PostgreSQL
+type PostgresDeviceRepository struct {
+	db database.DBTX
+}
+
+// NewPostgresDeviceRepository creates a new PostgresDeviceRepository
+func NewPostgresDeviceRepository(db database.DBTX) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+// Record upserts the device. The count of the user's devices is taken from
+// the statement's snapshot, before the insert, and xmax is 0 only for rows
+// the statement inserted.
+func (r *PostgresDeviceRepository) Record(ctx context.Context, device *domain.Device) (bool, bool, error) {
+	query := `
+		WITH prior AS (
+			SELECT COUNT(*) AS n FROM user_devices WHERE user_id = $1
+		), upserted AS (
+			INSERT INTO user_devices (user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			ON CONFLICT (user_id, fingerprint) DO UPDATE
+			SET user_agent = EXCLUDED.user_agent, last_ip = EXCLUDED.last_ip, last_seen_at = EXCLUDED.last_seen_at
+			RETURNING (xmax = 0) AS inserted
+		)
+		SELECT inserted, inserted AND (SELECT n FROM prior) = 0 FROM upserted`
+
+	var isNew, first bool
+	err := r.db.QueryRowContext(ctx, query,
+		device.UserID, device.Fingerprint, device.UserAgent, device.LastIP, device.LastSeenAt,
+	).Scan(&isNew, &first)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to record device: %w", err)
+	}
+
+	return isNew, first, nil
+}
+
+// ListByUser returns the user's devices, most recently seen first
+func (r *PostgresDeviceRepository) ListByUser(ctx context.Context, userID int) ([]domain.Device, error) {
+	query := `
+		SELECT user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at
+		FROM user_devices
+		WHERE user_id = $1
+		ORDER BY last_seen_at DESC, fingerprint`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var devices []domain.Device
+	for rows.Next() {
+		var d domain.Device
+		if err := rows.Scan(&d.UserID, &d.Fingerprint, &d.UserAgent, &d.LastIP, &d.FirstSeenAt, &d.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating devices: %w", err)
+	}
+
+	return devices, nil
+}
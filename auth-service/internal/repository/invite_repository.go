@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/database"
+)
+
+// inviteColumns are scanned by scanInvite in this order
+const inviteColumns = "code, role, max_uses, uses, expires_at, created_at"
+
+// PostgresInviteRepository implements InviteRepository using PostgreSQL
+type PostgresInviteRepository struct {
+	db database.DBTX
+}
+
+// NewPostgresInviteRepository creates a new PostgresInviteRepository
+func NewPostgresInviteRepository(db database.DBTX) *PostgresInviteRepository {
+	return &PostgresInviteRepository{db: db}
+}
+
+// Create stores a new invite code
+func (r *PostgresInviteRepository) Create(ctx context.Context, code *domain.InviteCode) (*domain.InviteCode, error) {
+	query := "INSERT INTO invite_codes (code, role, max_uses, expires_at) VALUES ($1, $2, $3, $4) RETURNING " + inviteColumns
+
+	created, err := scanInvite(r.db.QueryRowContext(ctx, query, code.Code, code.Role, code.MaxUses, code.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invite code: %w", err)
+	}
+
+	return created, nil
+}
+
+// FindByCode finds an invite code
+func (r *PostgresInviteRepository) FindByCode(ctx context.Context, code string) (*domain.InviteCode, error) {
+	query := "SELECT " + inviteColumns + " FROM invite_codes WHERE code = $1"
+
+	invite, err := scanInvite(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Code not found
+		}
+		return nil, fmt.Errorf("failed to find invite code: %w", err)
+	}
+
+	return invite, nil
+}
+
+// List returns every invite code, newest first
+func (r *PostgresInviteRepository) List(ctx context.Context) ([]domain.InviteCode, error) {
+	query := "SELECT " + inviteColumns + " FROM invite_codes ORDER BY created_at DESC, code"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invite codes: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var invites []domain.InviteCode
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invite code: %w", err)
+		}
+		invites = append(invites, *invite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invite codes: %w", err)
+	}
+
+	return invites, nil
+}
+
+// Update replaces an invite code's role, max uses and expiry
+func (r *PostgresInviteRepository) Update(ctx context.Context, code *domain.InviteCode) (*domain.InviteCode, error) {
+	query := "UPDATE invite_codes SET role = $2, max_uses = $3, expires_at = $4 WHERE code = $1 RETURNING " + inviteColumns
+
+	updated, err := scanInvite(r.db.QueryRowContext(ctx, query, code.Code, code.Role, code.MaxUses, code.ExpiresAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Code not found
+		}
+		return nil, fmt.Errorf("failed to update invite code: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes an invite code. Users registered with it keep their role.
+func (r *PostgresInviteRepository) Delete(ctx context.Context, code string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM invite_codes WHERE code = $1", code)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete invite code: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// scanInvite scans inviteColumns from a *sql.Row or *sql.Rows
+func scanInvite(row interface{ Scan(...any) error }) (*domain.InviteCode, error) {
+	invite := &domain.InviteCode{}
+	var expiresAt sql.NullTime
+	if err := row.Scan(&invite.Code, &invite.Role, &invite.MaxUses, &invite.Uses, &expiresAt, &invite.CreatedAt); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		invite.ExpiresAt = &expiresAt.Time
+	}
+	return invite, nil
+}
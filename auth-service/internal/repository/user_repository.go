@@ -4,25 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/database"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
 )
 
+// userColumns are scanned into a domain.User in this order
+const userColumns = "id, username, COALESCE(email, ''), password, role, timezone, locale, deletion_scheduled_at, notification_channels"
+
 // PostgresUserRepository implements UserRepository using PostgreSQL
 type PostgresUserRepository struct {
-	db *sql.DB
+	db database.DBTX
 }
 
 // NewPostgresUserRepository creates a new PostgresUserRepository
-func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+func NewPostgresUserRepository(db database.DBTX) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
 // Create creates a new user in the database
 func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
-	query := "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id"
+	query := "INSERT INTO users (username, email, password, role, timezone, locale) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6) RETURNING id"
 
-	err := r.db.QueryRowContext(ctx, query, user.Username, user.Password).Scan(&user.ID)
+	err := r.db.QueryRowContext(ctx, query, user.Username, user.Email, user.Password, user.Role, user.Timezone, user.Locale).Scan(&user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -30,12 +38,36 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 	return user, nil
 }
 
+// CreateWithInvite redeems the code and inserts the user in one statement,
+// so a failed insert (e.g. a taken username) doesn't consume a use
+func (r *PostgresUserRepository) CreateWithInvite(ctx context.Context, user *domain.User, code string) (*domain.User, error) {
+	query := `
+		WITH redeemed AS (
+			UPDATE invite_codes SET uses = uses + 1
+			WHERE code = $1 AND uses < max_uses AND (expires_at IS NULL OR expires_at > NOW())
+			RETURNING role
+		)
+		INSERT INTO users (username, email, password, role, timezone, locale)
+		SELECT $2, NULLIF($3, ''), $4, redeemed.role, $5, $6 FROM redeemed
+		RETURNING id, role`
+
+	err := r.db.QueryRowContext(ctx, query, code, user.Username, user.Email, user.Password, user.Timezone, user.Locale).
+		Scan(&user.ID, &user.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Code not redeemable
+		}
+		return nil, fmt.Errorf("failed to create user with invite: %w", err)
+	}
+
+	return user, nil
+}
+
 // FindByUsername finds a user by username
 func (r *PostgresUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
-	query := "SELECT id, username, password FROM users WHERE username = $1"
+	query := "SELECT " + userColumns + " FROM users WHERE username = $1"
 
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, username))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // User not found
@@ -46,12 +78,31 @@ func (r *PostgresUserRepository) FindByUsername(ctx context.Context, username st
 	return user, nil
 }
 
+// FindByUsernameOrEmail finds a user by username or, case-insensitively, by
+// email. A username match wins if login is one user's username and
+// another's email.
+func (r *PostgresUserRepository) FindByUsernameOrEmail(ctx context.Context, login string) (*domain.User, error) {
+	query := "SELECT " + userColumns + ` FROM users
+		WHERE username = $1 OR LOWER(email) = LOWER($1)
+		ORDER BY username = $1 DESC
+		LIMIT 1`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, login))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to find user by username or email: %w", err)
+	}
+
+	return user, nil
+}
+
 // FindByID finds a user by ID
 func (r *PostgresUserRepository) FindByID(ctx context.Context, id int) (*domain.User, error) {
-	query := "SELECT id, username, password FROM users WHERE id = $1"
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
 
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // User not found
@@ -61,3 +112,102 @@ func (r *PostgresUserRepository) FindByID(ctx context.Context, id int) (*domain.
 
 	return user, nil
 }
+
+// List returns up to limit users with IDs above afterID, in ID order; a
+// limit of 0 returns every such user
+func (r *PostgresUserRepository) List(ctx context.Context, afterID, limit int) ([]domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE " +
+		pagination.KeysetCondition([]string{"id"}, false, 1) + " ORDER BY id" + pagination.LimitClause(limit)
+
+	rows, err := r.db.QueryContext(ctx, query, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+	return users, nil
+}
+
+// Count returns the number of users
+func (r *PostgresUserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// SetDeletionSchedule sets or, with nil, clears deletion_scheduled_at
+func (r *PostgresUserRepository) SetDeletionSchedule(ctx context.Context, id int, at *time.Time) error {
+	query := "UPDATE users SET deletion_scheduled_at = $1 WHERE id = $2"
+
+	if _, err := r.db.ExecContext(ctx, query, at, id); err != nil {
+		return fmt.Errorf("failed to set deletion schedule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePreferences replaces a user's timezone and locale
+func (r *PostgresUserRepository) UpdatePreferences(ctx context.Context, id int, prefs domain.Preferences) error {
+	query := "UPDATE users SET timezone = $1, locale = $2 WHERE id = $3"
+
+	if _, err := r.db.ExecContext(ctx, query, prefs.Timezone, prefs.Locale, id); err != nil {
+		return fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateNotificationChannels replaces a user's notification channels
+func (r *PostgresUserRepository) UpdateNotificationChannels(ctx context.Context, id int, channels []string) error {
+	query := "UPDATE users SET notification_channels = $1 WHERE id = $2"
+
+	if _, err := r.db.ExecContext(ctx, query, strings.Join(channels, ","), id); err != nil {
+		return fmt.Errorf("failed to update notification channels: %w", err)
+	}
+
+	return nil
+}
+
+// scanUser scans userColumns
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	user := &domain.User{}
+	var (
+		deletionScheduledAt sql.NullTime
+		channels            string
+	)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role,
+		&user.Timezone, &user.Locale, &deletionScheduledAt, &channels)
+	if err != nil {
+		return nil, err
+	}
+	user.NotificationChannels = []string{}
+	if channels != "" {
+		user.NotificationChannels = strings.Split(channels, ",")
+	}
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	return user, nil
+}
@@ -4,44 +4,213 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
 	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/language"
 )
 
 // Common errors
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrEmailAlreadyExists = errors.New("email already registered")
+	ErrInvalidEmail       = errors.New("invalid email")
 	ErrHashingPassword    = errors.New("failed to hash password")
 	ErrGeneratingToken    = errors.New("failed to generate token")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrInvalidLocale      = errors.New("invalid locale")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrInviteRequired     = errors.New("invite code required")
+	ErrInvalidInvite      = errors.New("invalid invite code")
+	ErrAccountDeleted     = errors.New("account deleted")
+	ErrDeletionNotPending = errors.New("account deletion not pending")
+	ErrInvalidChannel     = errors.New("invalid notification channel")
+)
+
+// DefaultDeletionGracePeriod is how long a deleted account can still log in
+// and cancel the deletion
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// Registration modes
+const (
+	// RegistrationOpen lets anyone register. Invite codes are optional and
+	// only preset the role.
+	RegistrationOpen = "open"
+	// RegistrationInviteOnly requires a redeemable invite code
+	RegistrationInviteOnly = "invite_only"
 )
 
 // AuthService handles authentication business logic
 type AuthService struct {
 	userRepo  domain.UserRepository
 	secretKey string
+	audit     *tokenAudit
+	// inviteOnly rejects registrations without an invite code
+	inviteOnly    bool
+	deletionGrace time.Duration
+	events        domain.AccountEventPublisher
+	devices       domain.DeviceRepository
+	logger        *slog.Logger
+	clock         clock.Clock
+	// clockSkew is how long past expiry tokens are still accepted
+	clockSkew time.Duration
+}
+
+// Option configures an AuthService
+type Option func(*AuthService)
+
+// WithFailureLog logs every token validation failure with its reason and the
+// client IP
+func WithFailureLog(logger *slog.Logger) Option {
+	return func(s *AuthService) {
+		s.audit.logger = logger
+	}
+}
+
+// WithRegistrationMode sets RegistrationOpen (the default) or
+// RegistrationInviteOnly
+func WithRegistrationMode(mode string) Option {
+	return func(s *AuthService) {
+		s.inviteOnly = mode == RegistrationInviteOnly
+	}
+}
+
+// WithDeletionGracePeriod sets how long after DeleteAccount the account can
+// still log in and cancel
+func WithDeletionGracePeriod(d time.Duration) Option {
+	return func(s *AuthService) {
+		if d > 0 {
+			s.deletionGrace = d
+		}
+	}
+}
+
+// WithAccountEvents publishes account deletions so other services purge the
+// user's data. Without it deletions only block logins.
+func WithAccountEvents(publisher domain.AccountEventPublisher) Option {
+	return func(s *AuthService) {
+		s.events = publisher
+	}
+}
+
+// WithDevices records the device of every login, so new devices can be
+// announced with WithAccountEvents and listed with ListDevices
+func WithDevices(repo domain.DeviceRepository) Option {
+	return func(s *AuthService) {
+		s.devices = repo
+	}
+}
+
+// WithLogger sets the logger for failures that don't fail the request, such
+// as an undelivered new device alert
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *AuthService) {
+		s.logger = logger
+	}
+}
+
+// WithClock sets the clock tokens are issued and checked by and deletion
+// deadlines are computed from (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(s *AuthService) {
+		s.clock = c
+	}
+}
+
+// WithClockSkew accepts tokens up to d past expiry, for clocks that drift
+// between instances
+func WithClockSkew(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.clockSkew = d
+	}
 }
 
 // NewAuthService creates a new AuthService
-func NewAuthService(userRepo domain.UserRepository, secretKey string) *AuthService {
-	return &AuthService{
-		userRepo:  userRepo,
-		secretKey: secretKey,
+func NewAuthService(userRepo domain.UserRepository, secretKey string, opts ...Option) *AuthService {
+	s := &AuthService{
+		userRepo:      userRepo,
+		secretKey:     secretKey,
+		audit:         newTokenAudit(),
+		deletionGrace: DefaultDeletionGracePeriod,
+		logger:        slog.Default(),
+		clock:         clock.Real,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ValidateToken checks a token and records the outcome in the audit counters.
+// clientIP only appears in the failure log.
+func (s *AuthService) ValidateToken(token, clientIP string) (*jwt.Claims, error) {
+	if token == "" {
+		s.audit.recordFailure(jwt.ReasonMissing, clientIP)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, jwt.ReasonMissing)
+	}
+
+	claims, err := jwt.ValidateToken(token, s.secretKey, jwt.ValidateWithClock(s.clock), jwt.WithLeeway(s.clockSkew))
+	if err != nil {
+		reason := jwt.FailureReason(err)
+		s.audit.recordFailure(reason, clientIP)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, reason)
 	}
+
+	s.audit.recordSuccess()
+	return claims, nil
+}
+
+// AuthMetrics returns the token validation counters since startup
+func (s *AuthService) AuthMetrics() AuthMetrics {
+	return s.audit.snapshot()
 }
 
-// Register creates a new user and returns authentication response
-func (s *AuthService) Register(ctx context.Context, username, password string) (*domain.AuthResponse, error) {
-	// Check if user already exists
-	existingUser, err := s.userRepo.FindByUsername(ctx, username)
+// Register creates a new user and returns authentication response.
+// email is optional, and so is inviteCode unless registration is invite
+// only. Empty preferences fall back to UTC and en-US.
+func (s *AuthService) Register(ctx context.Context, username, email, password, inviteCode string, prefs domain.Preferences) (*domain.AuthResponse, error) {
+	inviteCode = strings.TrimSpace(inviteCode)
+	if s.inviteOnly && inviteCode == "" {
+		return nil, ErrInviteRequired
+	}
+
+	prefs, err := normalizePreferences(prefs)
+	if err != nil {
+		return nil, err
+	}
+	email, err = normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user already exists. Usernames and emails share one login
+	// namespace, so neither may match another user's username or email.
+	existingUser, err := s.userRepo.FindByUsernameOrEmail(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing user: %w", err)
 	}
 	if existingUser != nil {
 		return nil, ErrUserAlreadyExists
 	}
+	if email != "" {
+		existingUser, err = s.userRepo.FindByUsernameOrEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing email: %w", err)
+		}
+		if existingUser != nil {
+			return nil, ErrEmailAlreadyExists
+		}
+	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -51,32 +220,39 @@ func (s *AuthService) Register(ctx context.Context, username, password string) (
 
 	// Create user
 	user := &domain.User{
-		Username: username,
-		Password: string(hashedPassword),
+		Username:    username,
+		Email:       email,
+		Password:    string(hashedPassword),
+		Role:        domain.RoleUser,
+		Preferences: prefs,
+		// The column default, so the returned user matches what is stored
+		NotificationChannels: domain.DefaultNotificationChannels,
 	}
 
-	createdUser, err := s.userRepo.Create(ctx, user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	if inviteCode != "" {
+		createdUser, err := s.userRepo.CreateWithInvite(ctx, user, inviteCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		if createdUser == nil {
+			return nil, ErrInvalidInvite
+		}
+		return s.authResponse(createdUser)
 	}
 
-	// Generate token
-	token, err := jwt.GenerateToken(createdUser.ID, createdUser.Username, s.secretKey)
+	createdUser, err := s.userRepo.Create(ctx, user)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrGeneratingToken, err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	return &domain.AuthResponse{
-		ID:       createdUser.ID,
-		Username: createdUser.Username,
-		Token:    token,
-	}, nil
+	return s.authResponse(createdUser)
 }
 
-// Login authenticates a user and returns authentication response
-func (s *AuthService) Login(ctx context.Context, username, password string) (*domain.AuthResponse, error) {
+// Login authenticates a user by username or email and returns
+// authentication response
+func (s *AuthService) Login(ctx context.Context, login, password string, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	// Find user
-	user, err := s.userRepo.FindByUsername(ctx, username)
+	user, err := s.userRepo.FindByUsernameOrEmail(ctx, strings.TrimSpace(login))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
@@ -88,16 +264,315 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (*do
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		return nil, ErrInvalidCredentials
 	}
+	if user.Deleted(s.clock.Now()) {
+		return nil, ErrAccountDeleted
+	}
+	if err := s.recordDevice(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user)
+}
+
+// recordDevice remembers the device a login came from and announces logins
+// from new devices, except from a user's first. A failed announcement
+// doesn't fail the login.
+func (s *AuthService) recordDevice(ctx context.Context, user *domain.User, client domain.ClientInfo) error {
+	if s.devices == nil {
+		return nil
+	}
 
-	// Generate token
-	token, err := jwt.GenerateToken(user.ID, user.Username, s.secretKey)
+	device := &domain.Device{
+		UserID:      user.ID,
+		Fingerprint: client.Fingerprint(),
+		UserAgent:   client.UserAgent,
+		LastIP:      client.IP,
+		LastSeenAt:  s.clock.Now().UTC(),
+	}
+	isNew, first, err := s.devices.Record(ctx, device)
+	if err != nil {
+		return err
+	}
+	if !isNew || first || s.events == nil {
+		return nil
+	}
+
+	err = s.events.PublishNewDeviceLogin(ctx, &domain.NewDeviceLoginEvent{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		DeviceID:  device.Fingerprint,
+		UserAgent: device.UserAgent,
+		IP:        device.LastIP,
+		Country:   client.Country,
+		ASN:       client.ASN,
+	})
+	if err != nil {
+		s.logger.Error("failed to publish new device login", "error", err, "user_id", user.ID)
+	}
+	return nil
+}
+
+// ListDevices returns the devices the user has logged in from, most
+// recently seen first
+func (s *AuthService) ListDevices(ctx context.Context, userID int) ([]domain.Device, error) {
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if s.devices == nil {
+		return nil, nil
+	}
+	return s.devices.ListByUser(ctx, userID)
+}
+
+// DeleteAccount schedules the user's account for deletion after the grace
+// period, returning when that is. password must be the user's current
+// password. Asking again while a deletion is pending returns the existing
+// schedule.
+func (s *AuthService) DeleteAccount(ctx context.Context, userID int, password string) (time.Time, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return time.Time{}, ErrInvalidCredentials
+	}
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, nil
+	}
+
+	purgeAt := s.clock.Now().Add(s.deletionGrace).UTC().Truncate(time.Second)
+	if err := s.userRepo.SetDeletionSchedule(ctx, userID, &purgeAt); err != nil {
+		return time.Time{}, err
+	}
+
+	// Without the event nothing downstream would purge the data, so a
+	// failed publish undoes the schedule and the user can retry
+	if s.events != nil {
+		event := &domain.AccountDeletionEvent{UserID: userID, PurgeAt: purgeAt}
+		if err := s.events.PublishAccountDeletionScheduled(ctx, event); err != nil {
+			if rerr := s.userRepo.SetDeletionSchedule(ctx, userID, nil); rerr != nil {
+				err = errors.Join(err, rerr)
+			}
+			return time.Time{}, fmt.Errorf("failed to schedule data purge: %w", err)
+		}
+	}
+
+	return purgeAt, nil
+}
+
+// CancelAccountDeletion keeps an account whose deletion is pending
+func (s *AuthService) CancelAccountDeletion(ctx context.Context, userID int) error {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return ErrDeletionNotPending
+	}
+	purgeAt := *user.DeletionScheduledAt
+
+	if err := s.userRepo.SetDeletionSchedule(ctx, userID, nil); err != nil {
+		return err
+	}
+
+	// A lost cancellation would purge a kept account, so a failed publish
+	// restores the schedule
+	if s.events != nil {
+		event := &domain.AccountDeletionEvent{UserID: userID, PurgeAt: purgeAt}
+		if err := s.events.PublishAccountDeletionCancelled(ctx, event); err != nil {
+			if rerr := s.userRepo.SetDeletionSchedule(ctx, userID, &purgeAt); rerr != nil {
+				err = errors.Join(err, rerr)
+			}
+			return fmt.Errorf("failed to cancel data purge: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// activeUser finds a user whose account hasn't been deleted. Deleted
+// accounts report ErrAccountDeleted, since their tokens may still be valid.
+func (s *AuthService) activeUser(ctx context.Context, userID int) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Deleted(s.clock.Now()) {
+		return nil, ErrAccountDeleted
+	}
+	return user, nil
+}
+
+// UpdatePreferences changes a user's timezone and locale and returns a new
+// token carrying them
+func (s *AuthService) UpdatePreferences(ctx context.Context, userID int, prefs domain.Preferences) (*domain.AuthResponse, error) {
+	prefs, err := normalizePreferences(prefs)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePreferences(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+	user.Preferences = prefs
+
+	return s.authResponse(user)
+}
+
+// NotificationChannels returns the channels a user wants notifications on
+func (s *AuthService) NotificationChannels(ctx context.Context, userID int) ([]string, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.NotificationChannels == nil {
+		return []string{}, nil
+	}
+	return user.NotificationChannels, nil
+}
+
+// UpdateNotificationChannels replaces the channels a user wants
+// notifications on, returning them in canonical order
+func (s *AuthService) UpdateNotificationChannels(ctx context.Context, userID int, channels []string) ([]string, error) {
+	channels, err := normalizeChannels(channels)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateNotificationChannels(ctx, userID, channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// normalizeChannels lowercases and deduplicates channels, ordering them as
+// domain.NotificationChannelList does
+func normalizeChannels(channels []string) ([]string, error) {
+	want := make(map[string]bool, len(channels))
+	for _, c := range channels {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(domain.NotificationChannelList, c) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidChannel, c)
+		}
+		want[c] = true
+	}
+	normalized := []string{}
+	for _, c := range domain.NotificationChannelList {
+		if want[c] {
+			normalized = append(normalized, c)
+		}
+	}
+	return normalized, nil
+}
+
+// DefaultUserPageSize is the page size of ListUsers when none is asked for
+const DefaultUserPageSize = 100
+
+// userCursor is the position in ListUsers, which pages in ID order
+type userCursor struct {
+	ID int `json:"i"`
+}
+
+// ListUsers returns a page of users in registration order, without their
+// password hashes
+func (s *AuthService) ListUsers(ctx context.Context, page pagination.PageRequest) (pagination.PageResponse[domain.User], error) {
+	page = page.Normalize(DefaultUserPageSize)
+	var after userCursor
+	if page.Cursor != "" {
+		if err := pagination.DecodeCursor(page.Cursor, &after); err != nil {
+			return pagination.PageResponse[domain.User]{}, err
+		}
+	}
+
+	users, err := s.userRepo.List(ctx, after.ID, page.FetchLimit())
+	if err != nil {
+		return pagination.PageResponse[domain.User]{}, err
+	}
+	total, err := s.userRepo.Count(ctx)
+	if err != nil {
+		return pagination.PageResponse[domain.User]{}, err
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+
+	return pagination.NewPage(users, page, total, func(u domain.User) any {
+		return userCursor{ID: u.ID}
+	})
+}
+
+// roleScopes are the API scopes granted to each role's tokens
+var roleScopes = map[string][]string{
+	domain.RoleUser:  {jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite},
+	domain.RoleAdmin: {jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite, jwt.ScopeAnalyticsRead},
+}
+
+// authResponse issues a token for user
+func (s *AuthService) authResponse(user *domain.User) (*domain.AuthResponse, error) {
+	token, err := jwt.IssueToken(user.ID, user.Username, s.secretKey,
+		jwt.WithPreferences(jwt.Preferences{Timezone: user.Timezone, Locale: user.Locale}),
+		jwt.WithRole(user.Role),
+		jwt.WithScopes(roleScopes[user.Role]...),
+		jwt.WithClock(s.clock),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrGeneratingToken, err)
 	}
 
 	return &domain.AuthResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Token:    token,
+		ID:                  user.ID,
+		Username:            user.Username,
+		Email:               user.Email,
+		Role:                user.Role,
+		Token:               token,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		Preferences:         user.Preferences,
 	}, nil
 }
+
+// normalizeEmail lowercases a bare address like "alice@example.com". Display
+// names ("Alice <alice@example.com>") are rejected; empty stays empty.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", fmt.Errorf("%w: %s", ErrInvalidEmail, email)
+	}
+	return email, nil
+}
+
+// normalizePreferences fills defaults and canonicalizes the timezone and locale
+func normalizePreferences(prefs domain.Preferences) (domain.Preferences, error) {
+	if prefs.Timezone == "" {
+		prefs.Timezone = domain.DefaultTimezone
+	}
+	if prefs.Locale == "" {
+		prefs.Locale = domain.DefaultLocale
+	}
+
+	if _, err := time.LoadLocation(prefs.Timezone); err != nil {
+		return prefs, fmt.Errorf("%w: %s", ErrInvalidTimezone, prefs.Timezone)
+	}
+	tag, err := language.Parse(prefs.Locale)
+	if err != nil {
+		return prefs, fmt.Errorf("%w: %s", ErrInvalidLocale, prefs.Locale)
+	}
+	prefs.Locale = tag.String()
+
+	return prefs, nil
+}
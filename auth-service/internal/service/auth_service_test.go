@@ -3,60 +3,23 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/auth-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
 )
 
-// MockUserRepository is a mock implementation of UserRepository for testing
-type MockUserRepository struct {
-	users     map[string]*domain.User
-	createErr error
-	findErr   error
-	nextID    int
-}
-
-func NewMockUserRepository() *MockUserRepository {
-	return &MockUserRepository{
-		users:  make(map[string]*domain.User),
-		nextID: 1,
-	}
-}
-
-func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
-	if m.createErr != nil {
-		return nil, m.createErr
-	}
-	user.ID = m.nextID
-	m.nextID++
-	m.users[user.Username] = user
-	return user, nil
-}
-
-func (m *MockUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	return m.users[username], nil
-}
-
-func (m *MockUserRepository) FindByID(ctx context.Context, id int) (*domain.User, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	for _, user := range m.users {
-		if user.ID == id {
-			return user, nil
-		}
-	}
-	return nil, nil
-}
-
 func TestAuthService_Register_Success(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := testutil.NewFakeUserRepository()
 	svc := NewAuthService(repo, "test-secret")
 
-	resp, err := svc.Register(context.Background(), "testuser", "password123")
+	resp, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -73,34 +36,34 @@ func TestAuthService_Register_Success(t *testing.T) {
 }
 
 func TestAuthService_Register_UserAlreadyExists(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := testutil.NewFakeUserRepository()
 	svc := NewAuthService(repo, "test-secret")
 
 	// First registration
-	_, err := svc.Register(context.Background(), "testuser", "password123")
+	_, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
 	if err != nil {
 		t.Fatalf("first registration should succeed: %v", err)
 	}
 
 	// Second registration with same username
-	_, err = svc.Register(context.Background(), "testuser", "password456")
+	_, err = svc.Register(context.Background(), "testuser", "", "password456", "", domain.Preferences{})
 	if !errors.Is(err, ErrUserAlreadyExists) {
 		t.Errorf("expected ErrUserAlreadyExists, got %v", err)
 	}
 }
 
 func TestAuthService_Login_Success(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := testutil.NewFakeUserRepository()
 	svc := NewAuthService(repo, "test-secret")
 
 	// Register first
-	_, err := svc.Register(context.Background(), "testuser", "password123")
+	_, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
 	if err != nil {
 		t.Fatalf("registration should succeed: %v", err)
 	}
 
 	// Login
-	resp, err := svc.Login(context.Background(), "testuser", "password123")
+	resp, err := svc.Login(context.Background(), "testuser", "password123", domain.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -114,29 +77,334 @@ func TestAuthService_Login_Success(t *testing.T) {
 }
 
 func TestAuthService_Login_InvalidCredentials(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := testutil.NewFakeUserRepository()
 	svc := NewAuthService(repo, "test-secret")
 
 	// Register first
-	_, err := svc.Register(context.Background(), "testuser", "password123")
+	_, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
 	if err != nil {
 		t.Fatalf("registration should succeed: %v", err)
 	}
 
 	// Login with wrong password
-	_, err = svc.Login(context.Background(), "testuser", "wrongpassword")
+	_, err = svc.Login(context.Background(), "testuser", "wrongpassword", domain.ClientInfo{})
 	if !errors.Is(err, ErrInvalidCredentials) {
 		t.Errorf("expected ErrInvalidCredentials, got %v", err)
 	}
 }
 
 func TestAuthService_Login_UserNotFound(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := testutil.NewFakeUserRepository()
 	svc := NewAuthService(repo, "test-secret")
 
 	// Login without registering
-	_, err := svc.Login(context.Background(), "nonexistent", "password123")
+	_, err := svc.Login(context.Background(), "nonexistent", "password123", domain.ClientInfo{})
 	if !errors.Is(err, ErrInvalidCredentials) {
 		t.Errorf("expected ErrInvalidCredentials, got %v", err)
 	}
 }
+
+func TestAuthService_Register_DefaultPreferences(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	svc := NewAuthService(repo, "test-secret")
+
+	resp, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Timezone != domain.DefaultTimezone || resp.Locale != domain.DefaultLocale {
+		t.Errorf("expected default preferences, got %+v", resp.Preferences)
+	}
+
+	claims, err := jwt.ValidateToken(resp.Token, "test-secret")
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if claims.Timezone != domain.DefaultTimezone || claims.Locale != domain.DefaultLocale {
+		t.Errorf("expected default preferences in token, got %+v", claims.Preferences)
+	}
+}
+
+func TestAuthService_Register_InvalidPreferences(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	svc := NewAuthService(repo, "test-secret")
+
+	_, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{Timezone: "Mars/Olympus"})
+	if !errors.Is(err, ErrInvalidTimezone) {
+		t.Errorf("expected ErrInvalidTimezone, got %v", err)
+	}
+
+	_, err = svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{Locale: "not a locale"})
+	if !errors.Is(err, ErrInvalidLocale) {
+		t.Errorf("expected ErrInvalidLocale, got %v", err)
+	}
+}
+
+func TestAuthService_Login_ByEmail(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	svc := NewAuthService(repo, "test-secret")
+
+	registered, err := svc.Register(context.Background(), "testuser", " Test.User@Example.com ", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("registration should succeed: %v", err)
+	}
+	if registered.Email != "test.user@example.com" {
+		t.Errorf("expected the email lowercased, got %q", registered.Email)
+	}
+
+	for _, login := range []string{"testuser", "test.user@example.com", "TEST.USER@example.COM"} {
+		resp, err := svc.Login(context.Background(), login, "password123", domain.ClientInfo{})
+		if err != nil {
+			t.Fatalf("login as %q: expected no error, got %v", login, err)
+		}
+		if resp.ID != registered.ID || resp.Username != "testuser" {
+			t.Errorf("login as %q: expected user %d, got %+v", login, registered.ID, resp)
+		}
+	}
+}
+
+func TestAuthService_Register_Email(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	svc := NewAuthService(repo, "test-secret")
+
+	if _, err := svc.Register(context.Background(), "alice", "alice@example.com", "pw", "", domain.Preferences{}); err != nil {
+		t.Fatalf("registration should succeed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		want     error
+	}{
+		{"email taken in another case", "bob", "ALICE@example.com", ErrEmailAlreadyExists},
+		{"username is a registered email", "alice@example.com", "", ErrUserAlreadyExists},
+		{"display name", "bob", "Bob <bob@example.com>", ErrInvalidEmail},
+		{"not an address", "bob", "bob", ErrInvalidEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Register(context.Background(), tt.username, tt.email, "pw", "", domain.Preferences{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAuthService_UpdatePreferences(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	svc := NewAuthService(repo, "test-secret")
+
+	registered, err := svc.Register(context.Background(), "testuser", "", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	resp, err := svc.UpdatePreferences(context.Background(), registered.ID, domain.Preferences{
+		Timezone: "Asia/Bangkok",
+		Locale:   "th-th",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Timezone != "Asia/Bangkok" || resp.Locale != "th-TH" {
+		t.Errorf("expected canonical preferences, got %+v", resp.Preferences)
+	}
+
+	login, err := svc.Login(context.Background(), "testuser", "password123", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	if login.Timezone != "Asia/Bangkok" {
+		t.Errorf("expected stored timezone after login, got %q", login.Timezone)
+	}
+
+	if _, err := svc.UpdatePreferences(context.Background(), 99, domain.Preferences{}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestAuthService_ValidateToken_RecordsFailures(t *testing.T) {
+	svc := NewAuthService(testutil.NewFakeUserRepository(), "test-secret")
+
+	token, err := jwt.GenerateToken(1, "testuser", "test-secret")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	forged, err := jwt.GenerateToken(1, "testuser", "attacker-secret")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if _, err := svc.ValidateToken(token, "10.0.0.1"); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	for _, tok := range []string{forged, forged, "garbage", ""} {
+		if _, err := svc.ValidateToken(tok, "10.0.0.2"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	}
+
+	m := svc.AuthMetrics()
+	if m.Validations != 5 || m.Failures != 4 {
+		t.Errorf("expected 5 validations and 4 failures, got %+v", m)
+	}
+	want := map[string]uint64{jwt.ReasonBadSignature: 2, jwt.ReasonMalformed: 1, jwt.ReasonMissing: 1}
+	for reason, n := range want {
+		if m.FailuresByReason[reason] != n {
+			t.Errorf("failures[%s] = %d, want %d", reason, m.FailuresByReason[reason], n)
+		}
+	}
+}
+
+func TestAuthService_DeleteAccount(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := &testutil.FakeAccountEventPublisher{}
+	svc := NewAuthService(testutil.NewFakeUserRepository(), "test-secret",
+		WithDeletionGracePeriod(7*24*time.Hour), WithAccountEvents(events))
+	c := clock.NewFake(now)
+	svc.clock = c
+	ctx := context.Background()
+
+	registered, err := svc.Register(ctx, "testuser", "", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	if _, err := svc.DeleteAccount(ctx, registered.ID, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+	purgeAt, err := svc.DeleteAccount(ctx, registered.ID, "password123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := now.Add(7 * 24 * time.Hour); !purgeAt.Equal(want) {
+		t.Errorf("expected purge at %v, got %v", want, purgeAt)
+	}
+	if again, err := svc.DeleteAccount(ctx, registered.ID, "password123"); err != nil || !again.Equal(purgeAt) {
+		t.Errorf("expected the existing schedule on repeat, got %v, %v", again, err)
+	}
+
+	// Logins keep working during the grace period, flagged as pending
+	login, err := svc.Login(ctx, "testuser", "password123", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("expected login during the grace period, got %v", err)
+	}
+	if login.DeletionScheduledAt == nil || !login.DeletionScheduledAt.Equal(purgeAt) {
+		t.Errorf("expected the pending deletion on login, got %v", login.DeletionScheduledAt)
+	}
+
+	if err := svc.CancelAccountDeletion(ctx, registered.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := svc.CancelAccountDeletion(ctx, registered.ID); !errors.Is(err, ErrDeletionNotPending) {
+		t.Errorf("expected ErrDeletionNotPending, got %v", err)
+	}
+
+	if _, err := svc.DeleteAccount(ctx, registered.ID, "password123"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	c.Advance(8 * 24 * time.Hour)
+	if _, err := svc.Login(ctx, "testuser", "password123", domain.ClientInfo{}); !errors.Is(err, ErrAccountDeleted) {
+		t.Errorf("expected ErrAccountDeleted after the grace period, got %v", err)
+	}
+	if err := svc.CancelAccountDeletion(ctx, registered.ID); !errors.Is(err, ErrAccountDeleted) {
+		t.Errorf("expected ErrAccountDeleted cancelling too late, got %v", err)
+	}
+
+	var types []string
+	for _, e := range events.Events() {
+		types = append(types, e.EventType)
+	}
+	want := []string{domain.EventAccountDeletionScheduled, domain.EventAccountDeletionCancelled, domain.EventAccountDeletionScheduled}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("expected events %v, got %v", want, types)
+	}
+}
+
+func TestAuthService_DeleteAccount_PublishFailure(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	events := &testutil.FakeAccountEventPublisher{Err: errors.New("broker down")}
+	svc := NewAuthService(repo, "test-secret", WithAccountEvents(events))
+	ctx := context.Background()
+
+	registered, err := svc.Register(ctx, "testuser", "", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	if _, err := svc.DeleteAccount(ctx, registered.ID, "password123"); err == nil {
+		t.Fatal("expected the publish failure to be returned")
+	}
+
+	user, _ := repo.FindByID(ctx, registered.ID)
+	if user.DeletionScheduledAt != nil {
+		t.Errorf("expected the schedule to be undone, got %v", user.DeletionScheduledAt)
+	}
+}
+
+func TestAuthService_Login_NewDeviceAlert(t *testing.T) {
+	events := &testutil.FakeAccountEventPublisher{}
+	devices := testutil.NewFakeDeviceRepository()
+	svc := NewAuthService(testutil.NewFakeUserRepository(), "test-secret",
+		WithDevices(devices), WithAccountEvents(events))
+	ctx := context.Background()
+
+	registered, err := svc.Register(ctx, "testuser", "testuser@example.com", "password123", "", domain.Preferences{})
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	laptop := domain.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"}
+	phone := domain.ClientInfo{UserAgent: "Safari", IP: "10.0.0.2", DeviceID: "phone-1", Country: "TH", ASN: "131445"}
+	logins := []domain.ClientInfo{
+		laptop,                                 // the first device isn't announced
+		{UserAgent: "Firefox", IP: "10.0.0.9"}, // same device, new network
+		phone,
+		{UserAgent: "Safari 2", IP: "10.0.0.3", DeviceID: "phone-1"}, // updated browser, same device id
+	}
+	for _, client := range logins {
+		if _, err := svc.Login(ctx, "testuser", "password123", client); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	alerts := events.DeviceLogins()
+	if len(alerts) != 1 {
+		t.Fatalf("expected one new device alert, got %+v", alerts)
+	}
+	if a := alerts[0]; a.UserID != registered.ID || a.Email != "testuser@example.com" ||
+		a.DeviceID != phone.Fingerprint() || a.UserAgent != "Safari" || a.IP != "10.0.0.2" || a.Country != "TH" || a.ASN != "131445" {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+
+	known, err := svc.ListDevices(ctx, registered.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(known) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", known)
+	}
+	for _, d := range known {
+		if d.Fingerprint == laptop.Fingerprint() && d.LastIP != "10.0.0.9" {
+			t.Errorf("expected the laptop's latest IP, got %q", d.LastIP)
+		}
+	}
+}
+
+func TestAuthService_Login_AlertFailureDoesNotFailLogin(t *testing.T) {
+	events := &testutil.FakeAccountEventPublisher{Err: errors.New("broker down")}
+	svc := NewAuthService(testutil.NewFakeUserRepository(), "test-secret",
+		WithDevices(testutil.NewFakeDeviceRepository()), WithAccountEvents(events),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, "testuser", "", "password123", "", domain.Preferences{}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	for _, ua := range []string{"Firefox", "Safari"} {
+		if _, err := svc.Login(ctx, "testuser", "password123", domain.ClientInfo{UserAgent: ua}); err != nil {
+			t.Fatalf("expected login despite the failed alert, got %v", err)
+		}
+	}
+}
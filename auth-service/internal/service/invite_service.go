@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+)
+
+// Invite errors
+var (
+	ErrInviteNotFound  = errors.New("invite code not found")
+	ErrInvalidRole     = errors.New("invalid role")
+	ErrInvalidMaxUses  = errors.New("max uses must be positive")
+	ErrInvalidExpiry   = errors.New("expiry must be in the future")
+	ErrGeneratingCode  = errors.New("failed to generate invite code")
+	ErrMaxUsesTooSmall = errors.New("max uses below uses already made")
+)
+
+// inviteCodeBytes of randomness make a 16-character code
+const inviteCodeBytes = 10
+
+// inviteEncoding spells codes in unambiguous uppercase letters and digits
+var inviteEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// InviteService manages signup invite codes. Codes are redeemed by
+// AuthService.Register.
+type InviteService struct {
+	repo domain.InviteRepository
+	now  func() time.Time
+}
+
+// NewInviteService creates a new InviteService
+func NewInviteService(repo domain.InviteRepository) *InviteService {
+	return &InviteService{repo: repo, now: time.Now}
+}
+
+// Create generates a code admitting maxUses registrations with role until
+// expiresAt. An empty role means domain.RoleUser, zero maxUses means single
+// use and a nil expiresAt never expires.
+func (s *InviteService) Create(ctx context.Context, role string, maxUses int, expiresAt *time.Time) (*domain.InviteCode, error) {
+	invite, err := s.newInvite("", role, maxUses, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	invite.Code = code
+
+	return s.repo.Create(ctx, invite)
+}
+
+// Get returns one code
+func (s *InviteService) Get(ctx context.Context, code string) (*domain.InviteCode, error) {
+	invite, err := s.repo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+// List returns every code, newest first
+func (s *InviteService) List(ctx context.Context) ([]domain.InviteCode, error) {
+	return s.repo.List(ctx)
+}
+
+// Update replaces a code's role, max uses and expiry, with the same defaults
+// as Create. maxUses may not drop below the uses already made.
+func (s *InviteService) Update(ctx context.Context, code, role string, maxUses int, expiresAt *time.Time) (*domain.InviteCode, error) {
+	existing, err := s.Get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := s.newInvite(code, role, maxUses, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if invite.MaxUses < existing.Uses {
+		return nil, fmt.Errorf("%w: %d used", ErrMaxUsesTooSmall, existing.Uses)
+	}
+
+	updated, err := s.repo.Update(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrInviteNotFound
+	}
+	return updated, nil
+}
+
+// Delete revokes a code. Users who registered with it are unaffected.
+func (s *InviteService) Delete(ctx context.Context, code string) error {
+	found, err := s.repo.Delete(ctx, code)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// newInvite applies the defaults and validates the result
+func (s *InviteService) newInvite(code, role string, maxUses int, expiresAt *time.Time) (*domain.InviteCode, error) {
+	invite := &domain.InviteCode{Code: code, Role: role, MaxUses: maxUses, ExpiresAt: expiresAt}
+	if invite.Role == "" {
+		invite.Role = domain.RoleUser
+	}
+	if invite.MaxUses == 0 {
+		invite.MaxUses = 1
+	}
+
+	if !domain.ValidRole(invite.Role) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRole, invite.Role)
+	}
+	if invite.MaxUses < 1 {
+		return nil, ErrInvalidMaxUses
+	}
+	if invite.ExpiresAt != nil && !invite.ExpiresAt.After(s.now()) {
+		return nil, ErrInvalidExpiry
+	}
+	return invite, nil
+}
+
+// generateInviteCode returns a random code like "K7Q2M4XW9PZC3H5D"
+func generateInviteCode() (string, error) {
+	b := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGeneratingCode, err)
+	}
+	return inviteEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/auth-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+func TestInviteService_Expiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := testutil.NewFakeUserRepository()
+	repo.Invites = testutil.NewFakeInviteRepository()
+	repo.Invites.Now = func() time.Time { return now }
+	invites := NewInviteService(repo.Invites)
+	invites.now = repo.Invites.Now
+	svc := NewAuthService(repo, "test-secret")
+	ctx := context.Background()
+
+	past := now.Add(-time.Minute)
+	if _, err := invites.Create(ctx, "", 0, &past); !errors.Is(err, ErrInvalidExpiry) {
+		t.Errorf("expected ErrInvalidExpiry, got %v", err)
+	}
+
+	expiry := now.Add(time.Hour)
+	invite, err := invites.Create(ctx, "", 0, &expiry)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if invite.Role != domain.RoleUser || invite.MaxUses != 1 || len(invite.Code) != 16 {
+		t.Errorf("expected a single-use user code, got %+v", invite)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := svc.Register(ctx, "alice", "", "pw", invite.Code, domain.Preferences{}); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("expected ErrInvalidInvite for an expired code, got %v", err)
+	}
+}
+
+func TestInviteService_RoleScopes(t *testing.T) {
+	repo := testutil.NewFakeUserRepository()
+	repo.Invites = testutil.NewFakeInviteRepository()
+	invites := NewInviteService(repo.Invites)
+	svc := NewAuthService(repo, "test-secret")
+	ctx := context.Background()
+
+	invite, err := invites.Create(ctx, domain.RoleAdmin, 1, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	tests := []struct {
+		username, invite string
+		analytics        bool
+	}{
+		{"root", invite.Code, true},
+		{"alice", "", false},
+	}
+	for _, tt := range tests {
+		resp, err := svc.Register(ctx, tt.username, "", "pw", tt.invite, domain.Preferences{})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.username, err)
+		}
+		claims, err := jwt.ValidateToken(resp.Token, "test-secret")
+		if err != nil {
+			t.Fatalf("failed to validate token: %v", err)
+		}
+		if claims.Role != resp.Role || !claims.HasScope(jwt.ScopePaymentsWrite) || claims.HasScope(jwt.ScopeAnalyticsRead) != tt.analytics {
+			t.Errorf("%s: unexpected role %q and scopes %v", tt.username, claims.Role, claims.Scopes)
+		}
+	}
+}
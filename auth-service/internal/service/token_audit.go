@@ -0,0 +1,59 @@
+package service
+
+import (
+	"log/slog"
+	"maps"
+	"sync"
+)
+
+// AuthMetrics is a snapshot of token validation counters
+type AuthMetrics struct {
+	Validations      uint64
+	Failures         uint64
+	FailuresByReason map[string]uint64
+}
+
+// tokenAudit counts token validations by outcome so forging attempts show up
+// as a rising bad_signature or malformed count
+type tokenAudit struct {
+	mu          sync.Mutex
+	validations uint64
+	failures    map[string]uint64
+	// logger receives one entry per failure with the client IP; nil disables it
+	logger *slog.Logger
+}
+
+func newTokenAudit() *tokenAudit {
+	return &tokenAudit{failures: make(map[string]uint64)}
+}
+
+func (a *tokenAudit) recordSuccess() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.validations++
+}
+
+func (a *tokenAudit) recordFailure(reason, clientIP string) {
+	a.mu.Lock()
+	a.validations++
+	a.failures[reason]++
+	a.mu.Unlock()
+
+	if a.logger != nil {
+		a.logger.Warn("token validation failed", "reason", reason, "client_ip", clientIP)
+	}
+}
+
+func (a *tokenAudit) snapshot() AuthMetrics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m := AuthMetrics{
+		Validations:      a.validations,
+		FailuresByReason: maps.Clone(a.failures),
+	}
+	for _, n := range a.failures {
+		m.Failures += n
+	}
+	return m
+}
@@ -0,0 +1,400 @@
+// Package testutil provides in-memory fakes of the auth-service domain
+// interfaces for tests
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/auth-service/internal/domain"
+)
+
+// FakeUserRepository is an in-memory domain.UserRepository.
+// Set CreateErr or FindErr to make the matching calls fail.
+type FakeUserRepository struct {
+	mu     sync.Mutex
+	users  map[string]*domain.User
+	nextID int
+
+	CreateErr error
+	FindErr   error
+	// Invites backs CreateWithInvite; nil means no code is redeemable
+	Invites *FakeInviteRepository
+}
+
+// NewFakeUserRepository creates an empty FakeUserRepository
+func NewFakeUserRepository() *FakeUserRepository {
+	return &FakeUserRepository{
+		users:  make(map[string]*domain.User),
+		nextID: 1,
+	}
+}
+
+func (f *FakeUserRepository) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.CreateErr != nil {
+		return nil, f.CreateErr
+	}
+	user.ID = f.nextID
+	f.nextID++
+	f.users[user.Username] = user
+	return user, nil
+}
+
+func (f *FakeUserRepository) CreateWithInvite(ctx context.Context, user *domain.User, code string) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.CreateErr != nil {
+		return nil, f.CreateErr
+	}
+	if f.Invites == nil {
+		return nil, nil
+	}
+	role, ok := f.Invites.redeem(code)
+	if !ok {
+		return nil, nil
+	}
+	user.ID = f.nextID
+	user.Role = role
+	f.nextID++
+	f.users[user.Username] = user
+	return user, nil
+}
+
+func (f *FakeUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	return f.users[username], nil
+}
+
+func (f *FakeUserRepository) FindByUsernameOrEmail(ctx context.Context, login string) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	if user, ok := f.users[login]; ok {
+		return user, nil
+	}
+	for _, user := range f.users {
+		if user.Email != "" && strings.EqualFold(user.Email, login) {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeUserRepository) FindByID(ctx context.Context, id int) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+// List returns copies of the users after afterID in ID order
+func (f *FakeUserRepository) List(ctx context.Context, afterID, limit int) ([]domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	var users []domain.User
+	for _, user := range f.users {
+		if user.ID > afterID {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// Count returns the number of users
+func (f *FakeUserRepository) Count(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return 0, f.FindErr
+	}
+	return int64(len(f.users)), nil
+}
+
+// UpdatePreferences is a no-op for unknown IDs, like the UPDATE it replaces
+func (f *FakeUserRepository) UpdatePreferences(ctx context.Context, id int, prefs domain.Preferences) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		if user.ID == id {
+			user.Preferences = prefs
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *FakeUserRepository) UpdateNotificationChannels(ctx context.Context, id int, channels []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		if user.ID == id {
+			user.NotificationChannels = channels
+			return nil
+		}
+	}
+	return nil
+}
+
+// FakeInviteRepository is an in-memory domain.InviteRepository
+type FakeInviteRepository struct {
+	mu      sync.Mutex
+	invites map[string]*domain.InviteCode
+	// Now stamps CreatedAt and decides expiry on redemption
+	Now func() time.Time
+}
+
+// NewFakeInviteRepository creates an empty FakeInviteRepository
+func NewFakeInviteRepository() *FakeInviteRepository {
+	return &FakeInviteRepository{
+		invites: make(map[string]*domain.InviteCode),
+		Now:     time.Now,
+	}
+}
+
+func (f *FakeInviteRepository) Create(ctx context.Context, code *domain.InviteCode) (*domain.InviteCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.invites[code.Code]; ok {
+		return nil, fmt.Errorf("invite code %q already exists", code.Code)
+	}
+	stored := *code
+	stored.Uses = 0
+	stored.CreatedAt = f.Now()
+	f.invites[code.Code] = &stored
+	created := stored
+	return &created, nil
+}
+
+func (f *FakeInviteRepository) FindByCode(ctx context.Context, code string) (*domain.InviteCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invite, ok := f.invites[code]
+	if !ok {
+		return nil, nil
+	}
+	found := *invite
+	return &found, nil
+}
+
+func (f *FakeInviteRepository) List(ctx context.Context) ([]domain.InviteCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invites := make([]domain.InviteCode, 0, len(f.invites))
+	for _, invite := range f.invites {
+		invites = append(invites, *invite)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreatedAt.Equal(invites[j].CreatedAt) {
+			return invites[i].CreatedAt.After(invites[j].CreatedAt)
+		}
+		return invites[i].Code < invites[j].Code
+	})
+	return invites, nil
+}
+
+func (f *FakeInviteRepository) Update(ctx context.Context, code *domain.InviteCode) (*domain.InviteCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invite, ok := f.invites[code.Code]
+	if !ok {
+		return nil, nil
+	}
+	invite.Role = code.Role
+	invite.MaxUses = code.MaxUses
+	invite.ExpiresAt = code.ExpiresAt
+	updated := *invite
+	return &updated, nil
+}
+
+func (f *FakeInviteRepository) Delete(ctx context.Context, code string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.invites[code]
+	delete(f.invites, code)
+	return ok, nil
+}
+
+// redeem uses up one registration of code, returning its role
+func (f *FakeInviteRepository) redeem(code string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invite, ok := f.invites[code]
+	if !ok || !invite.Redeemable(f.Now()) {
+		return "", false
+	}
+	invite.Uses++
+	return invite.Role, true
+}
+
+// SetDeletionSchedule is a no-op for unknown IDs, like the UPDATE it replaces
+func (f *FakeUserRepository) SetDeletionSchedule(ctx context.Context, id int, at *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		if user.ID == id {
+			user.DeletionScheduledAt = at
+			return nil
+		}
+	}
+	return nil
+}
+
+// FakeAccountEventPublisher records account events. Set Err to make
+// publishing fail.
+type FakeAccountEventPublisher struct {
+	mu           sync.Mutex
+	events       []domain.AccountDeletionEvent
+	deviceLogins []domain.NewDeviceLoginEvent
+
+	Err error
+}
+
+func (f *FakeAccountEventPublisher) PublishAccountDeletionScheduled(ctx context.Context, event *domain.AccountDeletionEvent) error {
+	event.EventType = domain.EventAccountDeletionScheduled
+	return f.record(event)
+}
+
+func (f *FakeAccountEventPublisher) PublishAccountDeletionCancelled(ctx context.Context, event *domain.AccountDeletionEvent) error {
+	event.EventType = domain.EventAccountDeletionCancelled
+	return f.record(event)
+}
+
+func (f *FakeAccountEventPublisher) PublishNewDeviceLogin(ctx context.Context, event *domain.NewDeviceLoginEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	event.EventType = domain.EventNewDeviceLogin
+	f.deviceLogins = append(f.deviceLogins, *event)
+	return nil
+}
+
+func (f *FakeAccountEventPublisher) Close() error {
+	return nil
+}
+
+// Events returns the events published so far
+func (f *FakeAccountEventPublisher) Events() []domain.AccountDeletionEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.AccountDeletionEvent(nil), f.events...)
+}
+
+// DeviceLogins returns the new device login events published so far
+func (f *FakeAccountEventPublisher) DeviceLogins() []domain.NewDeviceLoginEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.NewDeviceLoginEvent(nil), f.deviceLogins...)
+}
+
+func (f *FakeAccountEventPublisher) record(event *domain.AccountDeletionEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.events = append(f.events, *event)
+	return nil
+}
+
+// FakeDeviceRepository is an in-memory domain.DeviceRepository. Set Err to
+// make every call fail.
+type FakeDeviceRepository struct {
+	mu      sync.Mutex
+	devices map[int][]domain.Device
+
+	Err error
+}
+
+// NewFakeDeviceRepository creates an empty FakeDeviceRepository
+func NewFakeDeviceRepository() *FakeDeviceRepository {
+	return &FakeDeviceRepository{devices: make(map[int][]domain.Device)}
+}
+
+func (f *FakeDeviceRepository) Record(ctx context.Context, device *domain.Device) (bool, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return false, false, f.Err
+	}
+	devices := f.devices[device.UserID]
+	for i := range devices {
+		if devices[i].Fingerprint == device.Fingerprint {
+			devices[i].UserAgent = device.UserAgent
+			devices[i].LastIP = device.LastIP
+			devices[i].LastSeenAt = device.LastSeenAt
+			return false, false, nil
+		}
+	}
+	stored := *device
+	stored.FirstSeenAt = device.LastSeenAt
+	f.devices[device.UserID] = append(devices, stored)
+	return true, len(devices) == 0, nil
+}
+
+func (f *FakeDeviceRepository) ListByUser(ctx context.Context, userID int) ([]domain.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	devices := append([]domain.Device(nil), f.devices[userID]...)
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].LastSeenAt.After(devices[j].LastSeenAt)
+	})
+	return devices, nil
+}
+
+var (
+	_ domain.UserRepository        = (*FakeUserRepository)(nil)
+	_ domain.DeviceRepository      = (*FakeDeviceRepository)(nil)
+	_ domain.InviteRepository      = (*FakeInviteRepository)(nil)
+	_ domain.AccountEventPublisher = (*FakeAccountEventPublisher)(nil)
+)
@@ -1,29 +1,55 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 
 	authgrpc "github.com/tkaewplik/go-microservices/auth-service/internal/grpc"
 	"github.com/tkaewplik/go-microservices/auth-service/internal/handler"
+	"github.com/tkaewplik/go-microservices/auth-service/internal/kafka"
 	"github.com/tkaewplik/go-microservices/auth-service/internal/repository"
 	"github.com/tkaewplik/go-microservices/auth-service/internal/service"
 	"github.com/tkaewplik/go-microservices/pkg/database"
+	"github.com/tkaewplik/go-microservices/pkg/grpcvalidate"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+	"github.com/tkaewplik/go-microservices/pkg/middleware"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	"github.com/tkaewplik/go-microservices/pkg/requestid"
+	"github.com/tkaewplik/go-microservices/pkg/tracing"
 	pb "github.com/tkaewplik/go-microservices/proto/auth"
 )
 
 func main() {
-	// Setup structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// Setup structured logger; records logged with a call's context carry
+	// the gateway's request ID
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
+	// OTEL_EXPORTER_OTLP_ENDPOINT exports traces of the calls served,
+	// continuing the gateway's
+	shutdownTracing, err := tracing.Setup(context.Background(), "auth-service")
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
 	// Database configuration
 	dbConfig := database.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
@@ -45,10 +71,67 @@ func main() {
 		}
 	}()
 
+	// DB_PROFILE records per-statement latency and explains a sample of
+	// statements, logging slow plans. Development only.
+	var dbtx database.DBTX = db
+	var profiler *database.Profiler
+	if getEnv("DB_PROFILE", "false") == "true" {
+		profiler = database.NewProfiler(db, logger,
+			database.WithExplainSampleRate(getEnvFloat("DB_EXPLAIN_SAMPLE_RATE", database.DefaultExplainSampleRate)),
+			database.WithSlowPlanThreshold(time.Duration(getEnvInt("DB_SLOW_PLAN_MS", 100))*time.Millisecond),
+		)
+		defer func() {
+			if err := profiler.Close(); err != nil {
+				logger.Error("failed to close profiler", "error", err)
+			}
+		}()
+		dbtx = profiler
+		logger.Warn("database profiling enabled; EXPLAIN ANALYZE re-runs sampled statements")
+	}
+
 	// Initialize layers
-	userRepo := repository.NewPostgresUserRepository(db)
+	userRepo := repository.NewPostgresUserRepository(dbtx)
 	secretKey := getEnv("JWT_SECRET", "your-secret-key")
-	authService := service.NewAuthService(userRepo, secretKey)
+	authOpts := []service.Option{
+		service.WithLogger(logger),
+		service.WithDevices(repository.NewPostgresDeviceRepository(dbtx)),
+	}
+	if getEnv("AUDIT_LOG_TOKEN_FAILURES", "false") == "true" {
+		authOpts = append(authOpts, service.WithFailureLog(logger))
+	}
+	// REGISTRATION_MODE=invite_only requires an invite code to register
+	registrationMode := getEnv("REGISTRATION_MODE", service.RegistrationOpen)
+	if registrationMode != service.RegistrationOpen && registrationMode != service.RegistrationInviteOnly {
+		logger.Error("invalid REGISTRATION_MODE", "mode", registrationMode)
+		os.Exit(1)
+	}
+	authOpts = append(authOpts, service.WithRegistrationMode(registrationMode))
+
+	// Account deletions are announced on Kafka so other services purge the
+	// user's data once the grace period ends
+	authOpts = append(authOpts, service.WithDeletionGracePeriod(
+		getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", service.DefaultDeletionGracePeriod)))
+	if kafkaBrokers := getEnv("KAFKA_BROKERS", ""); kafkaBrokers != "" {
+		publisher := kafka.NewPublisher(kafka.Config{
+			Brokers: strings.Split(kafkaBrokers, ","),
+			Topic:   getEnv("KAFKA_TOPIC", messaging.TopicUserEvents),
+			Faults:  publishFaultsFromEnv(),
+		}, logger)
+		defer func() {
+			if err := publisher.Close(); err != nil {
+				logger.Error("failed to close Kafka publisher", "error", err)
+			}
+		}()
+		authOpts = append(authOpts, service.WithAccountEvents(publisher))
+	} else {
+		logger.Warn("KAFKA_BROKERS not set; account deletions will not purge data in other services and new device logins raise no alerts")
+	}
+	// Tokens from an instance whose clock runs behind are accepted this long
+	// past expiry
+	authOpts = append(authOpts, service.WithClockSkew(getEnvDuration("JWT_CLOCK_SKEW", 0)))
+	authService := service.NewAuthService(userRepo, secretKey, authOpts...)
+	inviteService := service.NewInviteService(repository.NewPostgresInviteRepository(dbtx))
+	logger.Info("registration mode", "mode", registrationMode)
 
 	// Start gRPC server
 	grpcPort := getEnv("GRPC_PORT", "50051")
@@ -59,8 +142,10 @@ func main() {
 			os.Exit(1)
 		}
 
-		grpcServer := grpc.NewServer()
-		authGRPCServer := authgrpc.NewAuthServer(authService, secretKey)
+		grpcServer := grpc.NewServer(
+			grpc.StatsHandler(tracing.ServerHandler()),
+			grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor(logger), authgrpc.MetadataInterceptor(), grpcvalidate.UnaryServerInterceptor()))
+		authGRPCServer := authgrpc.NewAuthServer(authService, inviteService, getEnv("SERVICE_TOKEN", ""))
 		pb.RegisterAuthServiceServer(grpcServer, authGRPCServer)
 
 		logger.Info("gRPC server starting", "port", grpcPort)
@@ -75,6 +160,16 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/register", authHandler.Register)
 	mux.HandleFunc("/login", authHandler.Login)
+	if profiler != nil {
+		// DEBUG_ALLOW_CIDRS and DEBUG_DENY_CIDRS limit who may read it;
+		// private networks only by default
+		debugFilter, err := middleware.ParseIPFilter(getEnv("DEBUG_ALLOW_CIDRS", ""), getEnv("DEBUG_DENY_CIDRS", ""))
+		if err != nil {
+			logger.Error("invalid debug access list", "error", err)
+			os.Exit(1)
+		}
+		mux.Handle("/debug/statements", debugFilter.Restrict(profiler))
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -84,12 +179,27 @@ func main() {
 	// Start HTTP server
 	port := getEnv("PORT", "8081")
 	logger.Info("HTTP server starting", "port", port)
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
+	request.MaxDepth = getEnvInt("MAX_JSON_DEPTH", request.MaxDepth)
+	maxBodyBytes := int64(getEnvInt("MAX_BODY_BYTES", 1<<20))
+	if err := http.ListenAndServe(":"+port, middleware.MaxBodyBytes(maxBodyBytes)(mux)); err != nil {
 		logger.Error("HTTP server failed", "error", err)
 		os.Exit(1)
 	}
 }
 
+// publishFaultsFromEnv reads FAULT_PUBLISH_DROP_RATE and
+// FAULT_PUBLISH_ERROR_RATE, injecting nothing unless FAULT_INJECTION_ENABLED
+// is true
+func publishFaultsFromEnv() messaging.PublishFaults {
+	if getEnv("FAULT_INJECTION_ENABLED", "false") != "true" {
+		return messaging.PublishFaults{}
+	}
+	return messaging.PublishFaults{
+		DropRate:  getEnvFloat("FAULT_PUBLISH_DROP_RATE", 0),
+		ErrorRate: getEnvFloat("FAULT_PUBLISH_ERROR_RATE", 0),
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -105,3 +215,21 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -28,6 +28,7 @@ const TransactionForm: React.FC<TransactionFormProps> = ({ userId, token, onTran
         headers: {
           'Content-Type': 'application/json',
           'Authorization': customAuthHeader,
+          'X-Client-Channel': 'web',
         },
         body: JSON.stringify({
           user_id: userId,
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/middleware"
+)
+
+// Route groups with their own access lists
+const (
+	// accessAdmin covers the /admin routes
+	accessAdmin = "admin"
+	// accessDebug covers /debug/pprof
+	accessDebug = "debug"
+)
+
+var errAddressForbidden = apperror.New(apperror.CodeForbidden, "not available from this address", http.StatusForbidden)
+
+// AccessList limits a route group to client addresses. Entries are CIDRs or
+// single addresses; denied entries win over allowed ones. Without an allow
+// list only private networks are admitted, so operational routes aren't
+// exposed to the internet by default.
+type AccessList struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+func (a AccessList) compile() (*middleware.IPFilter, error) {
+	return middleware.ParseIPFilter(strings.Join(a.Allow, ","), strings.Join(a.Deny, ","))
+}
+
+// compileAccessLists returns a filter for every route group, using the
+// private-network default for groups lists doesn't mention
+func compileAccessLists(lists map[string]AccessList) (map[string]*middleware.IPFilter, error) {
+	filters := make(map[string]*middleware.IPFilter)
+	for _, group := range []string{accessAdmin, accessDebug} {
+		filter, err := lists[group].compile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", group, err)
+		}
+		filters[group] = filter
+	}
+	for group := range lists {
+		if _, ok := filters[group]; !ok {
+			return nil, fmt.Errorf("unknown route group %q", group)
+		}
+	}
+	return filters, nil
+}
+
+// setAccessLists enforces lists from the next request on
+func (g *Gateway) setAccessLists(lists map[string]AccessList) error {
+	filters, err := compileAccessLists(lists)
+	if err != nil {
+		return fmt.Errorf("access_lists.%w", err)
+	}
+	g.access.Store(&filters)
+	return nil
+}
+
+// restricted rejects clients outside group's access list before next runs.
+// The connecting address is checked, not forwarding headers, which any
+// client can set.
+func (g *Gateway) restricted(group string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var filter *middleware.IPFilter
+		if filters := g.access.Load(); filters != nil {
+			filter = (*filters)[group]
+		}
+		if filter == nil {
+			filter = middleware.NewIPFilter(middleware.PrivateNetworks, nil)
+		}
+		if !filter.AllowedRequest(r) {
+			g.logger.WarnContext(r.Context(), "request refused by access list",
+				"group", group, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+			g.respondError(w, r, errAddressForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// envAccessLists reads <GROUP>_ALLOW_CIDRS and <GROUP>_DENY_CIDRS for each
+// route group
+func envAccessLists() map[string]AccessList {
+	lists := make(map[string]AccessList)
+	for _, group := range []string{accessAdmin, accessDebug} {
+		prefix := strings.ToUpper(group)
+		list := AccessList{
+			Allow: splitList(getEnv(prefix+"_ALLOW_CIDRS", "")),
+			Deny:  splitList(getEnv(prefix+"_DENY_CIDRS", "")),
+		}
+		if len(list.Allow) > 0 || len(list.Deny) > 0 {
+			lists[group] = list
+		}
+	}
+	return lists
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
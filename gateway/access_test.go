@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestricted(t *testing.T) {
+	g, _ := newTestGateway()
+	handler := g.restricted(accessAdmin, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	serve := func(remote string) int {
+		r := httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("X-Forwarded-For", "10.0.0.1")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	// Before any list is set, only private networks get through
+	if code := serve("10.1.2.3:5000"); code != http.StatusNoContent {
+		t.Errorf("expected a private address to pass, got %d", code)
+	}
+	if code := serve("203.0.113.7:5000"); code != http.StatusForbidden {
+		t.Errorf("expected a public address to be refused despite X-Forwarded-For, got %d", code)
+	}
+
+	if err := g.setAccessLists(map[string]AccessList{
+		accessAdmin: {Allow: []string{"203.0.113.0/24"}, Deny: []string{"203.0.113.9"}},
+	}); err != nil {
+		t.Fatalf("failed to set access lists: %v", err)
+	}
+	tests := []struct {
+		remote string
+		want   int
+	}{
+		{"203.0.113.7:5000", http.StatusNoContent},
+		{"203.0.113.9:5000", http.StatusForbidden},
+		{"10.1.2.3:5000", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if code := serve(tt.remote); code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.remote, tt.want, code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+)
+
+// spendAlert is an alert.threshold_crossed event as the analytics service
+// reports it
+type spendAlert struct {
+	Period    string    `json:"period"`
+	Threshold float64   `json:"threshold"`
+	Spend     float64   `json:"spend"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// spendAlerts is the caller's alert threshold, spend this month (UTC) and
+// recent alerts
+type spendAlerts struct {
+	Threshold float64      `json:"threshold"`
+	Period    string       `json:"period"`
+	Spend     float64      `json:"spend"`
+	Alerts    []spendAlert `json:"alerts"`
+}
+
+// handleAlerts serves /me/alerts. GET returns the caller's spend alerts;
+// PUT {"threshold": n} sets the monthly spend that raises an alert, 0
+// turning alerts off.
+func (g *Gateway) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	var body []byte
+	if r.Method == http.MethodPut {
+		var req struct {
+			Threshold float64 `json:"threshold"`
+		}
+		if err := request.DecodeJSON(r, &req); err != nil {
+			g.respondDecodeError(w, r, err)
+			return
+		}
+		if req.Threshold < 0 {
+			g.respondError(w, r, errInvalidThreshold)
+			return
+		}
+		body, _ = json.Marshal(req)
+	}
+
+	alertsURL := strings.TrimRight(g.currentConfig().AnalyticsURL, "/") + "/alerts?" +
+		url.Values{"user_id": {strconv.Itoa(userID)}}.Encode()
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, alertsURL, bytes.NewReader(body))
+	if err != nil {
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get spend alerts"))
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		g.logger.ErrorContext(r.Context(), "spend alerts request failed", "error", err)
+		g.respondError(w, r, errAlertsUnavailable)
+		return
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.ErrorContext(r.Context(), "failed to close alerts response", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		g.logger.ErrorContext(r.Context(), "spend alerts request failed", "status", resp.StatusCode)
+		g.respondError(w, r, errAlertsUnavailable)
+		return
+	}
+
+	var alerts spendAlerts
+	if err := json.NewDecoder(resp.Body).Decode(&alerts); err != nil {
+		g.logger.ErrorContext(r.Context(), "failed to decode spend alerts", "error", err)
+		g.respondError(w, r, errAlertsUnavailable)
+		return
+	}
+	if alerts.Alerts == nil {
+		alerts.Alerts = []spendAlert{}
+	}
+
+	g.respondJSON(w, r, http.StatusOK, alerts)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandleAlerts_ProxiesToAnalytics(t *testing.T) {
+	var gotQuery, gotBody string
+	analytics := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, _ = w.Write([]byte(`{"user_id":1,"threshold":500,"period":"2024-03","spend":120,"alerts":null}`))
+	}))
+	defer analytics.Close()
+
+	g, auth := newTestGateway()
+	g.httpClient = analytics.Client()
+	g.config.Store(&Config{AnalyticsURL: analytics.URL})
+	id, token := auth.AddUser("alice", "pw")
+
+	r := httptest.NewRequest(http.MethodPut, "/me/alerts", strings.NewReader(`{"threshold":500}`))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleAlerts(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if gotQuery != "user_id="+strconv.Itoa(id) || gotBody != `{"threshold":500}` {
+		t.Errorf("expected the caller's threshold forwarded, got %q with %q", gotQuery, gotBody)
+	}
+	var alerts spendAlerts
+	if err := json.NewDecoder(w.Body).Decode(&alerts); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if alerts.Threshold != 500 || alerts.Spend != 120 || alerts.Alerts == nil {
+		t.Errorf("unexpected alerts %+v", alerts)
+	}
+}
+
+func TestHandleAlerts_InvalidThreshold(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	r := httptest.NewRequest(http.MethodPut, "/me/alerts", strings.NewReader(`{"threshold":-5}`))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleAlerts(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Circuit breaker defaults
+const (
+	// DefaultBreakerFailures is how many calls in a row must fail before a
+	// backend's circuit opens
+	DefaultBreakerFailures = 5
+	// DefaultBreakerCooldown is how long an open circuit refuses calls
+	// before letting a trial call through
+	DefaultBreakerCooldown = 10 * time.Second
+)
+
+// Circuit states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStats is one backend's circuit, as served by /admin/breakers
+type BreakerStats struct {
+	Backend string `json:"backend"`
+	State   string `json:"state"`
+	// ConsecutiveFailures counts failed calls since the last success
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitzero"`
+	// Opens counts how often the circuit opened, and Rejected the calls
+	// refused while it was open
+	Opens    uint64 `json:"opens"`
+	Rejected uint64 `json:"rejected"`
+}
+
+// circuitBreaker tracks one backend. Calls that fail because the backend is
+// unreachable or too slow count as failures; any answer, including an
+// application error, is a success.
+type circuitBreaker struct {
+	stats BreakerStats
+	// trial is set while the one call allowed through a half-open circuit
+	// is in flight
+	trial bool
+}
+
+// Breakers fail calls to a backend fast once it looks down, so requests get
+// a 503 at once instead of each waiting out its timeout. After failures
+// calls in a row fail, the backend's circuit opens and refuses calls for the
+// cooldown; then one trial call goes through, closing the circuit if it
+// succeeds and reopening it if not.
+type Breakers struct {
+	failures int
+	cooldown time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	backends map[string]*circuitBreaker
+}
+
+// NewBreakers creates Breakers; non-positive settings use the defaults
+func NewBreakers(failures int, cooldown time.Duration, logger *slog.Logger) *Breakers {
+	if failures <= 0 {
+		failures = DefaultBreakerFailures
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &Breakers{
+		failures: failures,
+		cooldown: cooldown,
+		logger:   logger,
+		now:      time.Now,
+		backends: make(map[string]*circuitBreaker),
+	}
+}
+
+// WithBreakers puts a circuit breaker in front of the auth and payment
+// backends
+func WithBreakers(b *Breakers) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.breakers = b
+	}
+}
+
+// breakerOpenError is returned for calls refused by an open circuit
+type breakerOpenError struct {
+	backend string
+}
+
+func (e *breakerOpenError) Error() string {
+	return e.backend + " circuit breaker is open"
+}
+
+// GRPCStatus makes the refusal read as Unavailable, like the backend being down
+func (e *breakerOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// allow reports whether a call to backend may go ahead, or how long until
+// one may
+func (b *Breakers) allow(backend string) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.backend(backend)
+	switch cb.stats.State {
+	case BreakerOpen:
+		if wait := cb.stats.OpenedAt.Add(b.cooldown).Sub(b.now()); wait > 0 {
+			cb.stats.Rejected++
+			return false, wait
+		}
+		cb.stats.State = BreakerHalfOpen
+		cb.trial = true
+		return true, 0
+	case BreakerHalfOpen:
+		if cb.trial {
+			cb.stats.Rejected++
+			return false, b.cooldown
+		}
+		cb.trial = true
+	}
+	return true, 0
+}
+
+// record updates backend's circuit with the outcome of an allowed call
+func (b *Breakers) record(backend string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.backend(backend)
+	cb.trial = false
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		cb.stats.ConsecutiveFailures++
+		if cb.stats.State != BreakerOpen && (cb.stats.State == BreakerHalfOpen || cb.stats.ConsecutiveFailures >= b.failures) {
+			cb.stats.State = BreakerOpen
+			cb.stats.OpenedAt = b.now()
+			cb.stats.Opens++
+			b.logger.Warn("circuit breaker opened", "backend", backend,
+				"consecutive_failures", cb.stats.ConsecutiveFailures, "error", err)
+		}
+	case codes.Canceled:
+		// The caller gave up; says nothing about the backend
+	default:
+		if cb.stats.State != BreakerClosed {
+			b.logger.Info("circuit breaker closed", "backend", backend)
+		}
+		cb.stats.State = BreakerClosed
+		cb.stats.ConsecutiveFailures = 0
+		cb.stats.OpenedAt = time.Time{}
+	}
+}
+
+func (b *Breakers) backend(name string) *circuitBreaker {
+	cb := b.backends[name]
+	if cb == nil {
+		cb = &circuitBreaker{stats: BreakerStats{Backend: name, State: BreakerClosed}}
+		b.backends[name] = cb
+	}
+	return cb
+}
+
+// UnaryClientInterceptor guards the calls to backend. A refused call marks
+// the request behind it, so its error response is a 503 whatever the
+// handler made of the error.
+func (b *Breakers) UnaryClientInterceptor(backend string) grpc.UnaryClientInterceptor {
+	b.mu.Lock()
+	b.backend(backend)
+	b.mu.Unlock()
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ok, wait := b.allow(backend)
+		if !ok {
+			if info := routeInfoFrom(ctx); info != nil {
+				info.breakerWait.Store(int64(wait))
+			}
+			return &breakerOpenError{backend: backend}
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(backend, err)
+		return err
+	}
+}
+
+// Stats returns every backend's circuit, by name
+func (b *Breakers) Stats() []BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]BreakerStats, 0, len(b.backends))
+	for _, cb := range b.backends {
+		stats = append(stats, cb.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Backend < stats[j].Backend })
+	return stats
+}
+
+// ServeHTTP serves the circuit states as JSON
+func (b *Breakers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(b.Stats()); err != nil {
+		b.logger.Error("failed to encode circuit breaker states", "error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+)
+
+// breakerCall invokes interceptor with an invoker returning err, and
+// reports whether the invoker was reached
+func breakerCall(ctx context.Context, interceptor grpc.UnaryClientInterceptor, err error) (bool, error) {
+	invoked := false
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked = true
+		return err
+	}
+	got := interceptor(ctx, authpb.AuthService_ValidateToken_FullMethodName, &authpb.ValidateTokenRequest{}, &authpb.ValidateTokenResponse{}, nil, invoker)
+	return invoked, got
+}
+
+func TestBreakers_OpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	b := NewBreakers(3, 10*time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.now = func() time.Time { return now }
+	interceptor := b.UnaryClientInterceptor("auth")
+	down := status.Error(codes.Unavailable, "connection refused")
+
+	for range 3 {
+		if invoked, _ := breakerCall(context.Background(), interceptor, down); !invoked {
+			t.Fatal("expected calls to go through while the circuit is closed")
+		}
+	}
+	invoked, err := breakerCall(context.Background(), interceptor, nil)
+	if invoked || status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the open circuit to refuse the call as Unavailable, got invoked=%v, %v", invoked, err)
+	}
+
+	// After the cooldown one trial call goes through; its failure reopens
+	now = now.Add(10 * time.Second)
+	if invoked, _ := breakerCall(context.Background(), interceptor, down); !invoked {
+		t.Fatal("expected a trial call after the cooldown")
+	}
+	if invoked, _ := breakerCall(context.Background(), interceptor, nil); invoked {
+		t.Fatal("expected the failed trial to reopen the circuit")
+	}
+
+	now = now.Add(10 * time.Second)
+	if invoked, err := breakerCall(context.Background(), interceptor, nil); !invoked || err != nil {
+		t.Fatalf("expected the trial call to succeed, got invoked=%v, %v", invoked, err)
+	}
+	stats := b.Stats()
+	if len(stats) != 1 || stats[0].State != BreakerClosed || stats[0].Opens != 2 || stats[0].Rejected != 2 || stats[0].ConsecutiveFailures != 0 {
+		t.Errorf("expected a closed circuit that opened twice, got %+v", stats)
+	}
+}
+
+func TestBreakers_ApplicationErrorsDontCount(t *testing.T) {
+	b := NewBreakers(2, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	interceptor := b.UnaryClientInterceptor("payment")
+
+	for _, err := range []error{
+		status.Error(codes.DeadlineExceeded, "timeout"),
+		status.Error(codes.InvalidArgument, "bad cursor"),
+		status.Error(codes.DeadlineExceeded, "timeout"),
+		status.Error(codes.Canceled, "client went away"),
+	} {
+		_, _ = breakerCall(context.Background(), interceptor, err)
+	}
+	if stats := b.Stats(); stats[0].State != BreakerClosed || stats[0].ConsecutiveFailures != 1 {
+		t.Errorf("expected the backend's answer to reset the failures, got %+v", stats)
+	}
+}
+
+func TestRespondError_BreakerOpen(t *testing.T) {
+	g, _ := newTestGateway()
+	b := NewBreakers(1, 5*time.Second, g.logger)
+	interceptor := b.UnaryClientInterceptor("auth")
+	_, _ = breakerCall(context.Background(), interceptor, status.Error(codes.Unavailable, "connection refused"))
+
+	// The handler maps the failed token check to 401, but the request was
+	// refused by the breaker
+	handler := withRouteInfo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = breakerCall(r.Context(), interceptor, nil)
+		g.respondError(w, r, apperror.ErrUnauthorized)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/devices", nil))
+
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("expected 503 with Retry-After: 5, got %d, %q: %s", w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+}
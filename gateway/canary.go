@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// CanaryConfig sends part of a backend's traffic to another address. A request
+// goes to the canary when it carries Header, when its user is in UserIDs, or
+// when its user falls in the Percent cohort. Cohorts hash the user ID, so a
+// user stays on one side across requests and services; requests without a
+// known user are sampled individually.
+type CanaryConfig struct {
+	Addr    string  `json:"addr"`
+	Percent float64 `json:"percent"`
+	Header  string  `json:"header,omitempty"`
+	UserIDs []int   `json:"user_ids,omitempty"`
+}
+
+// Validate reports the first problem with c
+func (c CanaryConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("addr: %w", err)
+	}
+	if c.Percent < 0 || c.Percent > 100 {
+		return errors.New("percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// routeInfo is what canary routing knows about the current request. The
+// gateway fills in userID once the request is authenticated.
+type routeInfo struct {
+	header http.Header
+	roll   float64
+	userID int
+	// breakerWait is set when an open circuit breaker refused one of the
+	// request's backend calls: how long until it lets one through. Calls
+	// may be made concurrently.
+	breakerWait atomic.Int64
+}
+
+type routeInfoKey struct{}
+
+// withRouteInfo records r's routing inputs in its context so backend calls
+// made while serving it can be routed
+func withRouteInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := &routeInfo{header: r.Header, roll: rand.Float64()}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeInfoKey{}, info)))
+	})
+}
+
+func routeInfoFrom(ctx context.Context) *routeInfo {
+	info, _ := ctx.Value(routeInfoKey{}).(*routeInfo)
+	return info
+}
+
+// canaryRouter diverts a backend's calls to its canary. The canary connection
+// is dialed the first time a canary is configured and kept afterwards, so a
+// changed address switches gracefully like the primary's does.
+type canaryRouter struct {
+	name string
+	cfg  atomic.Pointer[CanaryConfig]
+
+	mu       sync.Mutex
+	conn     *grpc.ClientConn
+	resolver *manual.Resolver
+}
+
+func newCanaryRouter(name string) *canaryRouter {
+	return &canaryRouter{name: name}
+}
+
+// set applies cfg; nil turns the canary off
+func (c *canaryRouter) set(cfg *CanaryConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cfg != nil {
+		if c.conn == nil {
+			conn, r, err := dialBackend(c.name+"-canary", cfg.Addr)
+			if err != nil {
+				return err
+			}
+			c.conn, c.resolver = conn, r
+		} else if old := c.cfg.Load(); old == nil || old.Addr != cfg.Addr {
+			c.resolver.UpdateState(backendState(cfg.Addr))
+		}
+	}
+	c.cfg.Store(cfg)
+	return nil
+}
+
+// chooses reports whether the request behind ctx goes to the canary
+func (c *canaryRouter) chooses(ctx context.Context, cfg *CanaryConfig) bool {
+	info := routeInfoFrom(ctx)
+	if info == nil {
+		return false
+	}
+	if cfg.Header != "" && info.header.Get(cfg.Header) != "" {
+		return true
+	}
+	if info.userID != 0 {
+		return slices.Contains(cfg.UserIDs, info.userID) || userBucket(info.userID) < cfg.Percent
+	}
+	return info.roll*100 < cfg.Percent
+}
+
+// userBucket places userID in [0, 100)
+func userBucket(userID int) float64 {
+	h := fnv.New32a()
+	_, _ = h.Write(strconv.AppendInt(nil, int64(userID), 10))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// UnaryClientInterceptor sends chosen calls on the primary connection to the canary
+func (c *canaryRouter) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg := c.cfg.Load(); cfg != nil && c.chooses(ctx, cfg) {
+			c.mu.Lock()
+			conn := c.conn
+			c.mu.Unlock()
+			return conn.Invoke(ctx, method, req, reply, opts...)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Close closes the canary connection, if one was dialed
+func (c *canaryRouter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func routedContext(header http.Header, roll float64, userID int) context.Context {
+	return context.WithValue(context.Background(), routeInfoKey{}, &routeInfo{header: header, roll: roll, userID: userID})
+}
+
+func TestCanaryRouter_Chooses(t *testing.T) {
+	c := newCanaryRouter("payment")
+	canaryHeader := http.Header{"X-Canary": {"1"}}
+
+	tests := []struct {
+		name string
+		cfg  CanaryConfig
+		ctx  context.Context
+		want bool
+	}{
+		{"header", CanaryConfig{Header: "X-Canary"}, routedContext(canaryHeader, 0.99, 7), true},
+		{"header missing", CanaryConfig{Header: "X-Canary"}, routedContext(http.Header{}, 0, 0), false},
+		{"listed user", CanaryConfig{UserIDs: []int{7}}, routedContext(http.Header{}, 0, 7), true},
+		{"unlisted user", CanaryConfig{UserIDs: []int{7}}, routedContext(http.Header{}, 0, 8), false},
+		{"everyone", CanaryConfig{Percent: 100}, routedContext(http.Header{}, 0.5, 8), true},
+		{"nobody", CanaryConfig{Percent: 0}, routedContext(http.Header{}, 0, 8), false},
+		{"anonymous in sample", CanaryConfig{Percent: 10}, routedContext(http.Header{}, 0.05, 0), true},
+		{"anonymous outside sample", CanaryConfig{Percent: 10}, routedContext(http.Header{}, 0.5, 0), false},
+		{"no request", CanaryConfig{Percent: 100}, context.Background(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.chooses(tt.ctx, &tt.cfg); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserBucket_StableAndSpread(t *testing.T) {
+	if userBucket(42) != userBucket(42) {
+		t.Fatal("expected a user to stay in one bucket")
+	}
+	in := 0
+	for id := 1; id <= 10000; id++ {
+		if userBucket(id) < 10 {
+			in++
+		}
+	}
+	if in < 800 || in > 1200 {
+		t.Errorf("expected about 10%% of users in a 10%% cohort, got %d of 10000", in)
+	}
+}
+
+func TestCanaryRouter_RoutesCalls(t *testing.T) {
+	primary := startHealthServer(t, healthpb.HealthCheckResponse_SERVING)
+	canary := startHealthServer(t, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	router := newCanaryRouter("test")
+	t.Cleanup(func() { _ = router.Close() })
+	conn, err := grpc.NewClient(primary,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(router.UnaryClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	check := func(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		return resp.Status
+	}
+	tagged := routedContext(http.Header{"X-Canary": {"1"}}, 0.5, 0)
+	untagged := routedContext(http.Header{}, 0.5, 0)
+
+	if err := router.set(&CanaryConfig{Addr: canary, Header: "X-Canary"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := check(tagged); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected the tagged call on the canary, got %v", got)
+	}
+	if got := check(untagged); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected the untagged call on the primary, got %v", got)
+	}
+
+	if err := router.set(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := check(tagged); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected calls on the primary once the canary is off, got %v", got)
+	}
+}
+
+func TestWithRouteInfo_RecordsAuthenticatedUser(t *testing.T) {
+	g, auth := newTestGateway()
+	userID, token := auth.AddUser("alice", "pw")
+
+	var info *routeInfo
+	handler := withRouteInfo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := g.validateAuth(r); err != nil {
+			t.Errorf("expected a valid token, got %v", err)
+		}
+		info = routeInfoFrom(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/payment/transactions/summary", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if info == nil || info.userID != userID {
+		t.Errorf("expected the route to carry user %d, got %+v", userID, info)
+	}
+}
+
+func TestConfigValidate_Canaries(t *testing.T) {
+	for name, canaries := range map[string]map[string]CanaryConfig{
+		"unknown backend": {"analytics": {Addr: "analytics:1"}},
+		"bad address":     {"payment": {Addr: "payment-canary"}},
+		"bad percent":     {"auth": {Addr: "auth-canary:50051", Percent: 101}},
+	} {
+		cfg := validConfig()
+		cfg.Canaries = canaries
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+
+	cfg := validConfig()
+	cfg.Canaries = map[string]CanaryConfig{"payment": {Addr: "payment-canary:50052", Percent: 5}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a valid canary, got %v", err)
+	}
+}
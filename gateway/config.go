@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+var (
+	errQuotaToggle  = errors.New("enabling or disabling request quotas requires a restart")
+	errNoConfigFile = errors.New("GATEWAY_CONFIG_FILE is not set")
+)
+
+// Config is the gateway configuration that can be reloaded without a restart
+type Config struct {
+	AuthGRPCAddr      string          `json:"auth_grpc_addr"`
+	PaymentGRPCAddr   string          `json:"payment_grpc_addr"`
+	AnalyticsURL      string          `json:"analytics_url"`
+	DailyRequestQuota int64           `json:"daily_request_quota"`
+	MaintenanceMode   bool            `json:"maintenance_mode"`
+	Features          map[string]bool `json:"features"`
+	// Canaries by backend: "auth" or "payment"
+	Canaries map[string]CanaryConfig `json:"canaries,omitempty"`
+	// Policies are authorization rules checked in order; see PolicyRule
+	Policies []PolicyRule `json:"policies,omitempty"`
+	// AccessLists limit route groups ("admin", "debug") to client addresses
+	AccessLists map[string]AccessList `json:"access_lists,omitempty"`
+}
+
+// Validate reports the first problem with c
+func (c Config) Validate() error {
+	for name, addr := range map[string]string{"auth_grpc_addr": c.AuthGRPCAddr, "payment_grpc_addr": c.PaymentGRPCAddr} {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	u, err := url.Parse(c.AnalyticsURL)
+	if err != nil {
+		return fmt.Errorf("analytics_url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("analytics_url: %q is not an http(s) URL", c.AnalyticsURL)
+	}
+	if c.DailyRequestQuota < 0 {
+		return errors.New("daily_request_quota must not be negative")
+	}
+	for name := range c.Features {
+		if name == "" {
+			return errors.New("features: empty flag name")
+		}
+	}
+	for name, canary := range c.Canaries {
+		if name != "auth" && name != "payment" {
+			return fmt.Errorf("canaries: unknown backend %q", name)
+		}
+		if err := canary.Validate(); err != nil {
+			return fmt.Errorf("canaries.%s: %w", name, err)
+		}
+	}
+	if _, err := compilePolicy(c.Policies); err != nil {
+		return fmt.Errorf("policies.%w", err)
+	}
+	if _, err := compileAccessLists(c.AccessLists); err != nil {
+		return fmt.Errorf("access_lists.%w", err)
+	}
+	return nil
+}
+
+// loadConfigFile overlays the JSON file at path on base. Keys missing from
+// the file keep base's values; unknown keys are rejected so typos don't
+// silently do nothing.
+func loadConfigFile(path string, base Config) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := base
+	cfg.Features = maps.Clone(base.Features)
+	cfg.Canaries = maps.Clone(base.Canaries)
+	cfg.Policies = slices.Clone(base.Policies)
+	cfg.AccessLists = maps.Clone(base.AccessLists)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// currentConfig returns the running config, or a zero Config before one is applied
+func (g *Gateway) currentConfig() *Config {
+	if cfg := g.config.Load(); cfg != nil {
+		return cfg
+	}
+	return &Config{}
+}
+
+// applyConfig switches the gateway to cfg. Backend addresses change through
+// the gRPC resolvers, so in-flight calls finish on their old connections
+// while new ones go to the new address.
+func (g *Gateway) applyConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if (cfg.DailyRequestQuota > 0) != (g.quota != nil) {
+		return errQuotaToggle
+	}
+
+	if err := g.setCanaries(cfg.Canaries); err != nil {
+		return err
+	}
+	if err := g.setPolicy(cfg.Policies); err != nil {
+		return err
+	}
+	if err := g.setAccessLists(cfg.AccessLists); err != nil {
+		return err
+	}
+
+	old := g.currentConfig()
+	if g.authPool != nil && cfg.AuthGRPCAddr != old.AuthGRPCAddr {
+		g.authPool.setAddr(cfg.AuthGRPCAddr)
+	}
+	if g.paymentPool != nil && cfg.PaymentGRPCAddr != old.PaymentGRPCAddr {
+		g.paymentPool.setAddr(cfg.PaymentGRPCAddr)
+	}
+	if g.quota != nil {
+		g.quota.SetLimit(cfg.DailyRequestQuota)
+	}
+	// Only a change in the file flips maintenance mode, so a reload doesn't
+	// undo a switch made through /admin/maintenance
+	if cfg.MaintenanceMode != old.MaintenanceMode {
+		g.maintenance.Store(cfg.MaintenanceMode)
+	}
+	g.config.Store(&cfg)
+	return nil
+}
+
+// setCanaries points each backend's canary router at its entry in canaries
+func (g *Gateway) setCanaries(canaries map[string]CanaryConfig) error {
+	for name, router := range g.canaries {
+		var cfg *CanaryConfig
+		if canary, ok := canaries[name]; ok {
+			cfg = &canary
+		}
+		if err := router.set(cfg); err != nil {
+			return fmt.Errorf("canaries.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setPolicy compiles rules and enforces them from the next request on
+func (g *Gateway) setPolicy(rules []PolicyRule) error {
+	p, err := compilePolicy(rules)
+	if err != nil {
+		return fmt.Errorf("policies.%w", err)
+	}
+	g.policy.Store(p)
+	return nil
+}
+
+// reloadConfig loads and applies a new config, keeping the running one when
+// the new one is invalid
+func (g *Gateway) reloadConfig() error {
+	g.reloadMu.Lock()
+	defer g.reloadMu.Unlock()
+
+	cfg, err := Config{}, errNoConfigFile
+	if g.loadConfig != nil {
+		cfg, err = g.loadConfig()
+	}
+	if err == nil {
+		err = g.applyConfig(cfg)
+	}
+	if err != nil {
+		g.logger.Error("config reload rejected; keeping the running config", "error", err)
+		return err
+	}
+	g.logger.Info("config reloaded",
+		"auth_grpc", cfg.AuthGRPCAddr,
+		"payment_grpc", cfg.PaymentGRPCAddr,
+		"analytics_url", cfg.AnalyticsURL,
+		"daily_request_quota", cfg.DailyRequestQuota,
+		"maintenance_mode", cfg.MaintenanceMode,
+		"features", len(cfg.Features),
+		"canaries", len(cfg.Canaries),
+		"policies", len(cfg.Policies),
+		"access_lists", len(cfg.AccessLists),
+	)
+	return nil
+}
+
+// handleReload reloads the config file on POST and reports the running config on GET
+func (g *Gateway) handleReload(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := g.reloadConfig(); err != nil {
+			g.respondError(w, r, apperror.New(apperror.CodeValidationFailed, err.Error(), http.StatusBadRequest))
+			return
+		}
+	default:
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	g.respondJSON(w, r, http.StatusOK, g.currentConfig())
+}
+
+// handleGetFeatures lists the feature flags for clients
+func (g *Gateway) handleGetFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	features := g.currentConfig().Features
+	if features == nil {
+		features = map[string]bool{}
+	}
+	g.respondJSON(w, r, http.StatusOK, features)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func validConfig() Config {
+	return Config{
+		AuthGRPCAddr:    "auth-service:50051",
+		PaymentGRPCAddr: "payment-service:50052",
+		AnalyticsURL:    "http://analytics-service:8083",
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"missing port", func(c *Config) { c.AuthGRPCAddr = "auth-service" }},
+		{"empty payment address", func(c *Config) { c.PaymentGRPCAddr = "" }},
+		{"analytics without scheme", func(c *Config) { c.AnalyticsURL = "analytics-service:8083" }},
+		{"analytics ftp", func(c *Config) { c.AnalyticsURL = "ftp://analytics-service" }},
+		{"negative quota", func(c *Config) { c.DailyRequestQuota = -1 }},
+		{"empty flag", func(c *Config) { c.Features = map[string]bool{"": true} }},
+		{"bad policy", func(c *Config) { c.Policies = []PolicyRule{{Path: "/analytics/*", Require: "role:"}} }},
+		{"bad access list", func(c *Config) { c.AccessLists = map[string]AccessList{accessAdmin: {Allow: []string{"10.0.0.0/33"}}} }},
+		{"unknown route group", func(c *Config) { c.AccessLists = map[string]AccessList{"metrics": {}} }},
+	}
+
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gateway.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfig(t, `{"analytics_url":"https://analytics.internal","features":{"new_dashboard":true}}`)
+
+	cfg, err := loadConfigFile(path, validConfig())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.AnalyticsURL != "https://analytics.internal" || cfg.AuthGRPCAddr != "auth-service:50051" || !cfg.Features["new_dashboard"] {
+		t.Errorf("expected the file to overlay the base config, got %+v", cfg)
+	}
+
+	for _, body := range []string{`{"analytcs_url":"http://x"}`, `{"daily_request_quota":-5}`, `{`} {
+		if _, err := loadConfigFile(writeConfig(t, body), validConfig()); err == nil {
+			t.Errorf("expected %s to be rejected", body)
+		}
+	}
+}
+
+func TestReloadConfig_KeepsRunningConfigOnError(t *testing.T) {
+	g, _ := newTestGateway()
+	g.quota = NewQuota(NewMemoryQuotaStore(), 10)
+	running := validConfig()
+	running.DailyRequestQuota = 10
+	if err := g.applyConfig(running); err != nil {
+		t.Fatal(err)
+	}
+
+	next := running
+	next.DailyRequestQuota = 50
+	next.MaintenanceMode = true
+	next.Features = map[string]bool{"new_dashboard": true}
+	var loadErr error
+	g.loadConfig = func() (Config, error) { return next, loadErr }
+
+	if err := g.reloadConfig(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if g.quota.limit.Load() != 50 || !g.maintenance.Load() || !g.currentConfig().Features["new_dashboard"] {
+		t.Errorf("expected the new config to be applied, got %+v", g.currentConfig())
+	}
+
+	for _, bad := range []struct {
+		cfg Config
+		err error
+	}{
+		{Config{}, errors.New("no such file")},
+		{func() Config { c := next; c.AnalyticsURL = "nope"; return c }(), nil},
+		{func() Config { c := next; c.DailyRequestQuota = 0; return c }(), nil},
+	} {
+		next, loadErr = bad.cfg, bad.err
+		if err := g.reloadConfig(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad.cfg)
+		}
+		if g.quota.limit.Load() != 50 || g.currentConfig().AnalyticsURL != running.AnalyticsURL {
+			t.Errorf("expected the running config to be kept, got %+v", g.currentConfig())
+		}
+	}
+}
+
+func TestApplyConfig_MaintenanceOnlyFollowsChanges(t *testing.T) {
+	g, _ := newTestGateway()
+	if err := g.applyConfig(validConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Switched on through /admin/maintenance; a reload of an unchanged file keeps it on
+	g.maintenance.Store(true)
+	if err := g.applyConfig(validConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if !g.maintenance.Load() {
+		t.Error("expected a reload without a maintenance change to keep the runtime switch")
+	}
+}
+
+func TestHandleReload(t *testing.T) {
+	g, _ := newTestGateway()
+	g.adminToken = "admin-secret"
+	path := writeConfig(t, `{"features":{"beta":true}}`)
+	g.loadConfig = func() (Config, error) { return loadConfigFile(path, validConfig()) }
+
+	r := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	r.Header.Set(adminTokenHeader, "admin-secret")
+	w := httptest.NewRecorder()
+	g.adminOnly(g.handleReload)(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"beta":true`) {
+		t.Fatalf("expected the reloaded config, got %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	g.handleGetFeatures(w, httptest.NewRequest(http.MethodGet, "/features", nil))
+	if strings.TrimSpace(w.Body.String()) != `{"beta":true}` {
+		t.Errorf("unexpected features %s", w.Body)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"auth_grpc_addr":"nope"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	g.adminOnly(g.handleReload)(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "auth_grpc_addr") {
+		t.Errorf("expected the bad config to be rejected, got %d: %s", w.Code, w.Body)
+	}
+}
+
+// startHealthServer serves the gRPC health service reporting status
+func startHealthServer(t *testing.T, status healthpb.HealthCheckResponse_ServingStatus) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("", status)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestDialBackend_SwitchesAddress(t *testing.T) {
+	first := startHealthServer(t, healthpb.HealthCheckResponse_SERVING)
+	second := startHealthServer(t, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	conn, r, err := dialBackend("test", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		return resp.Status
+	}
+
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected the first backend, got %v", got)
+	}
+
+	r.UpdateState(backendState(second))
+	deadline := time.Now().Add(5 * time.Second)
+	for check() != healthpb.HealthCheckResponse_NOT_SERVING {
+		if time.Now().After(deadline) {
+			t.Fatal("calls never moved to the second backend")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden JSON contracts in testdata/contracts")
+
+// contractNow stamps every transaction the contract tests create
+var contractNow = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+// volatile matches the response values that differ from run to run: tokens
+// carry their issue time and ULIDs random bits. They're replaced before
+// comparing, keeping the field names and their JSON types.
+var volatile = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`"token":"[^"]*"`), `"token":"<token>"`},
+	{regexp.MustCompile(`"ulid":"[0-9A-Z]{26}"`), `"ulid":"<ulid>"`},
+}
+
+type contractCase struct {
+	name    string
+	method  string
+	path    string
+	handler func(*Gateway, http.ResponseWriter, *http.Request)
+	body    string
+	version string
+	// auth sends the test user's token
+	auth   bool
+	status int
+}
+
+// TestContracts pins the exact JSON each endpoint writes. The cases run in
+// order against one gateway, so later ones see the transactions earlier
+// ones created. Run with -update after an intended change to the schema.
+func TestContracts(t *testing.T) {
+	g, auth := newTestGateway()
+	payments := g.paymentClient.(*testutil.FakePaymentClient)
+	payments.MaxTotal = 100
+	payments.Now = func() time.Time { return contractNow }
+	_, token := auth.AddUser("alice", "pw")
+
+	legacyHandler := mustLegacyHandler(g)
+	legacy := func(_ *Gateway, w http.ResponseWriter, r *http.Request) { legacyHandler(w, r) }
+	register, login, create, list, summary, pay := legacy, legacy, legacy, legacy, legacy, legacy
+	mobile := (*Gateway).handleMobileTransactions
+
+	cases := []contractCase{
+		{name: "register", method: http.MethodPost, path: "/auth/register", handler: register,
+			body: `{"username":"bob","password":"pw","email":"bob@example.com","timezone":"Asia/Bangkok"}`, status: http.StatusCreated},
+		{name: "register_invalid_body", method: http.MethodPost, path: "/auth/register", handler: register,
+			body: `{"username":1}`, status: http.StatusBadRequest},
+		{name: "login", method: http.MethodPost, path: "/auth/login", handler: login,
+			body: `{"username":"alice","password":"pw"}`, status: http.StatusOK},
+		{name: "login_invalid_credentials", method: http.MethodPost, path: "/auth/login", handler: login,
+			body: `{"username":"alice","password":"wrong"}`, status: http.StatusUnauthorized},
+		{name: "login_method_not_allowed", method: http.MethodGet, path: "/auth/login", handler: login,
+			status: http.StatusMethodNotAllowed},
+		{name: "create_transaction", method: http.MethodPost, path: "/payment/transactions", handler: create,
+			body: `{"amount":40,"description":"groceries"}`, auth: true, status: http.StatusCreated},
+		{name: "create_transaction_v3", method: http.MethodPost, path: "/payment/transactions", handler: create,
+			body: `{"amount":25.5,"description":"taxi"}`, version: APIVersion3, auth: true, status: http.StatusCreated},
+		{name: "create_transaction_limit_exceeded", method: http.MethodPost, path: "/payment/transactions", handler: create,
+			body: `{"amount":50}`, auth: true, status: http.StatusBadRequest},
+		{name: "create_transaction_invalid_json", method: http.MethodPost, path: "/payment/transactions", handler: create,
+			body: `{"amount":`, auth: true, status: http.StatusBadRequest},
+		{name: "create_transaction_unauthorized", method: http.MethodPost, path: "/payment/transactions", handler: create,
+			body: `{"amount":10}`, status: http.StatusUnauthorized},
+		{name: "list_transactions_v1", method: http.MethodGet, path: "/payment/transactions/list", handler: list,
+			version: APIVersion1, auth: true, status: http.StatusOK},
+		{name: "list_transactions_v2", method: http.MethodGet, path: "/payment/transactions/list", handler: list,
+			version: APIVersion2, auth: true, status: http.StatusOK},
+		{name: "list_transactions_v3", method: http.MethodGet, path: "/payment/transactions/list", handler: list,
+			version: APIVersion3, auth: true, status: http.StatusOK},
+		{name: "list_transactions_invalid_sort", method: http.MethodGet, path: "/payment/transactions/list?sort_by=name", handler: list,
+			auth: true, status: http.StatusBadRequest},
+		{name: "list_transactions_unsupported_version", method: http.MethodGet, path: "/payment/transactions/list", handler: list,
+			version: "9", auth: true, status: http.StatusBadRequest},
+		{name: "mobile_transactions", method: http.MethodGet, path: "/mobile/v1/transactions", handler: mobile,
+			auth: true, status: http.StatusOK},
+		{name: "summary", method: http.MethodGet, path: "/payment/transactions/summary", handler: summary,
+			auth: true, status: http.StatusOK},
+		{name: "pay", method: http.MethodPost, path: "/payment/transactions/pay", handler: pay,
+			auth: true, status: http.StatusOK},
+		{name: "summary_after_pay", method: http.MethodGet, path: "/payment/transactions/summary", handler: summary,
+			auth: true, status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			r.Header.Set(requestIDHeader, "contract-"+tc.name)
+			if tc.version != "" {
+				r.Header.Set(apiVersionHeader, tc.version)
+			}
+			if tc.auth {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			g.withRequestMeta(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tc.handler(g, w, r)
+			})).ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, w.Code, w.Body)
+			}
+			got := w.Body.String()
+			for _, v := range volatile {
+				got = v.re.ReplaceAllString(got, v.repl)
+			}
+
+			path := filepath.Join("testdata", "contracts", tc.name+".json")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing golden file; run go test -run TestContracts -update: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s changed; if intended, run go test -run TestContracts -update\ngot:  %s\nwant: %s", path, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/tkaewplik/go-microservices/pkg/requestid"
+	paginationpb "github.com/tkaewplik/go-microservices/proto/pagination"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Headers selecting the API version and correlating a request with its logs
+const (
+	apiVersionHeader = "X-API-Version"
+	requestIDHeader  = requestid.Header
+)
+
+// API versions. Version 1 returns bare response bodies; version 2 wraps
+// successful JSON responses in an envelope. Version 3 also writes proto
+// messages with protojson, so their fields follow one naming policy.
+const (
+	APIVersion1 = "1"
+	APIVersion2 = "2"
+	APIVersion3 = "3"
+)
+
+// apiVersionSpec is how an API version shapes successful responses
+type apiVersionSpec struct {
+	envelope bool
+	naming   JSONNaming
+}
+
+// apiVersions maps each version to how it shapes responses
+var apiVersions = map[string]apiVersionSpec{
+	APIVersion1: {},
+	APIVersion2: {envelope: true},
+	APIVersion3: {envelope: true, naming: JSONNamingSnake},
+}
+
+// WithDefaultAPIVersion sets the version served to clients that don't send
+// X-API-Version; APIVersion1 when unset
+func WithDefaultAPIVersion(v string) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.apiVersion = v
+	}
+}
+
+// requestMeta is what withRequestMeta knows about a request
+type requestMeta struct {
+	id      string
+	version string
+}
+
+type requestMetaKey struct{}
+
+func requestMetaFrom(ctx context.Context) (requestMeta, bool) {
+	meta, ok := ctx.Value(requestMetaKey{}).(requestMeta)
+	return meta, ok
+}
+
+// withRequestMeta assigns the request an ID, keeping a well-formed
+// X-Request-ID from the client, and settles the API version. Both are echoed
+// in the response headers, and the ID is logged with the request and
+// forwarded to the backends.
+func (g *Gateway) withRequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := requestMeta{id: r.Header.Get(requestIDHeader), version: r.Header.Get(apiVersionHeader)}
+		if !requestid.Valid(meta.id) {
+			meta.id = requestid.New()
+		}
+		w.Header().Set(requestIDHeader, meta.id)
+		r = r.WithContext(requestid.NewContext(r.Context(), meta.id))
+
+		if meta.version == "" {
+			meta.version = g.defaultAPIVersion()
+		}
+		if _, ok := apiVersions[meta.version]; !ok {
+			g.respondError(w, r, errUnsupportedVersion)
+			return
+		}
+		w.Header().Set(apiVersionHeader, meta.version)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestMetaKey{}, meta)))
+	})
+}
+
+func (g *Gateway) defaultAPIVersion() string {
+	if g.apiVersion == "" {
+		return APIVersion1
+	}
+	return g.apiVersion
+}
+
+// envelope wraps a successful response for API versions that ask for it
+type envelope struct {
+	Data any          `json:"data"`
+	Meta responseMeta `json:"meta"`
+}
+
+// responseMeta is the metadata every enveloped response carries
+type responseMeta struct {
+	RequestID string `json:"request_id"`
+	// Pagination describes the page of a paged list
+	Pagination *paginationpb.PageInfo `json:"pagination,omitempty"`
+}
+
+// envelopeFor wraps data when r's API version envelopes responses. Page
+// details move from paged lists into the metadata, leaving the items as data.
+func envelopeFor(r *http.Request, data any) (envelope, bool) {
+	if r == nil {
+		return envelope{}, false
+	}
+	meta, ok := requestMetaFrom(r.Context())
+	if !ok || !apiVersions[meta.version].envelope {
+		return envelope{}, false
+	}
+
+	env := envelope{Data: data, Meta: responseMeta{RequestID: meta.id}}
+	if list, ok := data.(*paymentpb.TransactionList); ok {
+		txs := list.GetTransactions()
+		if txs == nil {
+			txs = []*paymentpb.Transaction{}
+		}
+		env.Data, env.Meta.Pagination = txs, list.GetPage()
+	}
+	return env, true
+}
+
+// checkAPIVersion validates a configured default version
+func checkAPIVersion(v string) error {
+	if _, ok := apiVersions[v]; v != "" && !ok {
+		return fmt.Errorf("unknown API version %q", v)
+	}
+	return nil
+}
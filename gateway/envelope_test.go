@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paginationpb "github.com/tkaewplik/go-microservices/proto/pagination"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func listTransactions(g *Gateway, token, version, requestID string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/payment/transactions/list", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	if version != "" {
+		r.Header.Set(apiVersionHeader, version)
+	}
+	if requestID != "" {
+		r.Header.Set(requestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	g.withRequestMeta(http.HandlerFunc(mustLegacyHandler(g))).ServeHTTP(w, r)
+	return w
+}
+
+func TestEnvelope_Version2(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":10}`, "")
+
+	w := listTransactions(g, token, APIVersion2, "req-123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Data []map[string]any `json:"data"`
+		Meta struct {
+			RequestID string `json:"request_id"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data) != 1 || body.Meta.RequestID != "req-123" {
+		t.Errorf("expected one transaction and the client's request ID, got %+v", body)
+	}
+	if got := w.Header().Get(requestIDHeader); got != "req-123" {
+		t.Errorf("expected the request ID to be echoed, got %q", got)
+	}
+}
+
+func TestEnvelope_Version1Unchanged(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	w := listTransactions(g, token, "", "bad id with spaces")
+	if strings.Contains(w.Body.String(), `"data"`) {
+		t.Errorf("expected a bare body by default, got %s", w.Body)
+	}
+	if id := w.Header().Get(requestIDHeader); len(id) != 32 {
+		t.Errorf("expected a generated request ID in place of the malformed one, got %q", id)
+	}
+	if got := w.Header().Get(apiVersionHeader); got != APIVersion1 {
+		t.Errorf("expected version %s, got %q", APIVersion1, got)
+	}
+}
+
+func TestEnvelope_ErrorsNotWrapped(t *testing.T) {
+	g, _ := newTestGateway()
+
+	w := listTransactions(g, "forged", APIVersion2, "")
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), `"data"`) {
+		t.Errorf("expected a bare 401 error, got %d: %s", w.Code, w.Body)
+	}
+
+	w = listTransactions(g, "forged", "4", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown version, got %d", w.Code)
+	}
+}
+
+func TestEnvelope_DefaultVersion(t *testing.T) {
+	g, auth := newTestGateway()
+	g.apiVersion = APIVersion2
+	_, token := auth.AddUser("alice", "pw")
+
+	if w := listTransactions(g, token, "", ""); !strings.HasPrefix(w.Body.String(), `{"data":`) {
+		t.Errorf("expected the configured default to envelope responses, got %s", w.Body)
+	}
+	if err := checkAPIVersion("9"); err == nil {
+		t.Error("expected an unknown default version to be rejected")
+	}
+}
+
+func TestAppendJSON_EnvelopeMatchesEncodingJSON(t *testing.T) {
+	for _, env := range []envelope{
+		{Data: []*paymentpb.Transaction{{Id: 1, Amount: 2.5}, nil}, Meta: responseMeta{RequestID: "abc"}},
+		{Data: []*paymentpb.Transaction{}, Meta: responseMeta{
+			RequestID:  "<id>",
+			Pagination: &paginationpb.PageInfo{Limit: 20, NextCursor: "eyJpIjoxfQ", Total: 57},
+		}},
+		{Data: &authpb.AuthResponse{Id: 3, Token: "a.b.c"}, Meta: responseMeta{RequestID: "r"}},
+	} {
+		assertSameJSON(t, env)
+	}
+
+	if _, ok := appendJSON(nil, envelope{Data: map[string]int{}}); ok {
+		t.Error("expected data without an encoder to fall back to encoding/json")
+	}
+}
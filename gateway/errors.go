@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+// Gateway errors. The code is stable for clients; the message is English and
+// is localized from Accept-Language when a translation exists.
+var (
+	errMethodNotAllowed   = apperror.New(apperror.CodeMethodNotAllowed, "method not allowed", http.StatusMethodNotAllowed)
+	errInvalidCredentials = apperror.New(apperror.CodeInvalidCredentials, "invalid credentials", http.StatusUnauthorized)
+	errInvalidBody        = apperror.New(apperror.CodeValidationFailed, "invalid request body", http.StatusBadRequest)
+	errBodyTooLarge       = apperror.New(apperror.CodePayloadTooLarge, "request body too large", http.StatusRequestEntityTooLarge)
+	errInvalidFields      = apperror.New(apperror.CodeInvalidQuery, "invalid fields parameter", http.StatusBadRequest)
+	errInvalidSortBy      = apperror.New(apperror.CodeInvalidQuery, "sort_by must be created_at or amount", http.StatusBadRequest)
+	errInvalidOrder       = apperror.New(apperror.CodeInvalidQuery, "order must be asc or desc", http.StatusBadRequest)
+	errInvalidLimit       = apperror.New(apperror.CodeInvalidQuery, "limit must be a non-negative integer", http.StatusBadRequest)
+	errInvalidCursor      = apperror.New(apperror.CodeInvalidQuery, "invalid cursor", http.StatusBadRequest)
+	errInvalidArchived    = apperror.New(apperror.CodeInvalidQuery, "include_archived must be true or false", http.StatusBadRequest)
+	errInvalidTimezone    = apperror.New(apperror.CodeInvalidTimezone, "invalid timezone", http.StatusBadRequest)
+	errLimitExceeded      = apperror.New(apperror.CodeLimitExceeded, "total amount exceeds maximum of 1000", http.StatusBadRequest)
+	errChargeFailed       = apperror.New(apperror.CodeChargeFailed, "charge failed; transactions were left unpaid", http.StatusPaymentRequired)
+	errInvalidStatsQuery  = apperror.New(apperror.CodeInvalidQuery, "facets must be channel, country or all; include must be per_user, time_series, distribution or all", http.StatusBadRequest)
+	errStatsUnavailable   = apperror.New(apperror.CodeUpstreamUnavailable, "failed to get stats", http.StatusBadGateway)
+	errAlertsUnavailable  = apperror.New(apperror.CodeUpstreamUnavailable, "spend alerts unavailable", http.StatusBadGateway)
+	errInvalidThreshold   = apperror.New(apperror.CodeValidationFailed, "threshold must not be negative", http.StatusBadRequest)
+	errQuotaExceeded      = apperror.New(apperror.CodeQuotaExceeded, "daily request quota exceeded", http.StatusTooManyRequests)
+	errRateLimited        = apperror.New(apperror.CodeRateLimited, "too many requests", http.StatusTooManyRequests)
+	errTooManyStreams     = apperror.New(apperror.CodeRateLimited, "too many open update streams", http.StatusTooManyRequests)
+	errQuotaDisabled      = apperror.New(apperror.CodeNotFound, "request quotas are not enabled", http.StatusNotFound)
+	errQuotaUnavailable   = apperror.New(apperror.CodeUpstreamUnavailable, "quota store unavailable", http.StatusServiceUnavailable)
+	errBackendUnavailable = apperror.New(apperror.CodeUpstreamUnavailable, "service temporarily unavailable", http.StatusServiceUnavailable)
+	errMaintenance        = apperror.New(apperror.CodeMaintenance, "down for maintenance; only reads are available", http.StatusServiceUnavailable)
+	errInvalidTxID        = apperror.New(apperror.CodeInvalidQuery, "transaction_id must be a positive integer or a ULID", http.StatusBadRequest)
+	errMissingReceipt     = apperror.New(apperror.CodeValidationFailed, "receipt file is required", http.StatusBadRequest)
+	errReceiptType        = apperror.New(apperror.CodeUnsupportedMedia, "receipt must be a JPEG, PNG or PDF file", http.StatusUnsupportedMediaType)
+	errReceiptLink        = apperror.New(apperror.CodeForbidden, "receipt link is invalid or expired", http.StatusForbidden)
+	errInvalidMonth       = apperror.New(apperror.CodeInvalidQuery, "month must be YYYY-MM", http.StatusBadRequest)
+	errStatementLink      = apperror.New(apperror.CodeForbidden, "statement link is invalid or expired", http.StatusForbidden)
+	errStatementsDisabled = apperror.New(apperror.CodeNotFound, "statements are not enabled", http.StatusNotFound)
+	errDeletionNotPending = apperror.New(apperror.CodeConflict, "account deletion not pending", http.StatusConflict)
+	errUnsupportedVersion = apperror.New(apperror.CodeValidationFailed, "X-API-Version must be 1, 2 or 3", http.StatusBadRequest)
+	errPolicyDenied       = apperror.New(apperror.CodeForbidden, "insufficient permissions", http.StatusForbidden)
+	errCSRF               = apperror.New(apperror.CodeCSRFFailed, "missing or invalid X-CSRF-Token header", http.StatusForbidden)
+)
+
+// upstreamError maps a gRPC error from a backend to an AppError, keeping the
+// backend's message for client errors and using fallback for everything else
+func upstreamError(err error, fallback *apperror.AppError) *apperror.AppError {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return apperror.ErrBadRequest.WithMessage(st.Message())
+	case codes.AlreadyExists:
+		return apperror.ErrConflict.WithMessage(st.Message())
+	case codes.NotFound:
+		return apperror.ErrNotFound.WithMessage(st.Message())
+	case codes.Unauthenticated:
+		return apperror.ErrUnauthorized
+	case codes.PermissionDenied:
+		return apperror.ErrForbidden.WithMessage(st.Message())
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/oschwald/geoip2-golang/v2"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+// Geo is where a client address is registered. Fields are empty when the
+// databases don't know the address, as for private networks.
+type Geo struct {
+	// Country is an ISO 3166-1 alpha-2 code such as "TH"
+	Country string
+	ASN     uint
+	ASOrg   string
+}
+
+// GeoLocator looks up client addresses
+type GeoLocator interface {
+	Locate(ip netip.Addr) (Geo, error)
+}
+
+// MaxMindLocator reads MaxMind GeoIP2 or GeoLite2 Country and ASN databases
+type MaxMindLocator struct {
+	country *geoip2.Reader
+	asn     *geoip2.Reader
+}
+
+// OpenMaxMind opens the databases at countryPath and asnPath. Either may be
+// empty to skip that lookup, but not both.
+func OpenMaxMind(countryPath, asnPath string) (*MaxMindLocator, error) {
+	if countryPath == "" && asnPath == "" {
+		return nil, errors.New("no GeoIP database configured")
+	}
+
+	l := &MaxMindLocator{}
+	var err error
+	if countryPath != "" {
+		if l.country, err = geoip2.Open(countryPath); err != nil {
+			return nil, fmt.Errorf("failed to open country database: %w", err)
+		}
+	}
+	if asnPath != "" {
+		if l.asn, err = geoip2.Open(asnPath); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("failed to open ASN database: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// Locate looks ip up in each open database
+func (l *MaxMindLocator) Locate(ip netip.Addr) (Geo, error) {
+	var geo Geo
+	if l.country != nil {
+		country, err := l.country.Country(ip)
+		if err != nil {
+			return Geo{}, fmt.Errorf("country lookup: %w", err)
+		}
+		geo.Country = country.Country.ISOCode
+	}
+	if l.asn != nil {
+		asn, err := l.asn.ASN(ip)
+		if err != nil {
+			return Geo{}, fmt.Errorf("ASN lookup: %w", err)
+		}
+		geo.ASN = asn.AutonomousSystemNumber
+		geo.ASOrg = asn.AutonomousSystemOrganization
+	}
+	return geo, nil
+}
+
+// Close closes the databases
+func (l *MaxMindLocator) Close() error {
+	var errs []error
+	for _, r := range []*geoip2.Reader{l.country, l.asn} {
+		if r != nil {
+			errs = append(errs, r.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithGeoLocator enables GeoIP enrichment of requests
+func WithGeoLocator(l GeoLocator) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.geo = l
+	}
+}
+
+type geoKey struct{}
+
+// geoFrom returns the client location withGeo found for a request
+func geoFrom(ctx context.Context) (Geo, bool) {
+	geo, ok := ctx.Value(geoKey{}).(Geo)
+	return geo, ok
+}
+
+// withGeo looks up the client's address and records the result in the
+// request context, where request-scoped logs pick it up, and in the gRPC
+// metadata of backend calls made while serving it. Without a locator
+// requests pass through untouched.
+func (g *Gateway) withGeo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.geo == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip, err := netip.ParseAddr(clientIP(r))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		geo, err := g.geo.Locate(ip.Unmap())
+		if err != nil {
+			g.logger.Debug("GeoIP lookup failed", "error", err, "ip", ip)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), geoKey{}, geo)
+		var asn string
+		if geo.ASN != 0 {
+			asn = strconv.FormatUint(uint64(geo.ASN), 10)
+		}
+		ctx = grpcauth.WithClientGeo(ctx, geo.Country, asn)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// geoLogHandler adds the client's country and ASN to records logged with a
+// request context
+type geoLogHandler struct {
+	slog.Handler
+}
+
+func (h geoLogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if geo, ok := geoFrom(ctx); ok {
+		if geo.Country != "" {
+			rec.AddAttrs(slog.String("country", geo.Country))
+		}
+		if geo.ASN != 0 {
+			rec.AddAttrs(slog.Uint64("asn", uint64(geo.ASN)))
+		}
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h geoLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return geoLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h geoLogHandler) WithGroup(name string) slog.Handler {
+	return geoLogHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+type fakeLocator map[netip.Addr]Geo
+
+func (f fakeLocator) Locate(ip netip.Addr) (Geo, error) {
+	geo, ok := f[ip]
+	if !ok {
+		return Geo{}, errors.New("not found")
+	}
+	return geo, nil
+}
+
+func TestWithGeo(t *testing.T) {
+	var logs bytes.Buffer
+	g, _ := newTestGateway()
+	g.logger = slog.New(geoLogHandler{slog.NewTextHandler(&logs, nil)})
+	g.geo = fakeLocator{netip.MustParseAddr("203.0.113.7"): {Country: "TH", ASN: 131445, ASOrg: "Example"}}
+
+	var (
+		geo  Geo
+		ok   bool
+		meta metadata.MD
+	)
+	handler := g.withGeo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		geo, ok = geoFrom(r.Context())
+		meta, _ = metadata.FromOutgoingContext(r.Context())
+		g.logger.InfoContext(r.Context(), "handled")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if !ok || geo.Country != "TH" || geo.ASN != 131445 {
+		t.Fatalf("expected the client's location in the context, got %+v", geo)
+	}
+	if got := meta.Get(grpcauth.MetadataClientCountry); len(got) != 1 || got[0] != "TH" {
+		t.Errorf("expected the country in outgoing metadata, got %v", meta)
+	}
+	if got := meta.Get(grpcauth.MetadataClientASN); len(got) != 1 || got[0] != "131445" {
+		t.Errorf("expected the ASN in outgoing metadata, got %v", meta)
+	}
+	if !strings.Contains(logs.String(), "country=TH asn=131445") {
+		t.Errorf("expected request logs to carry the location, got %q", logs.String())
+	}
+
+	// Unknown addresses pass through without a location
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.RemoteAddr = "192.0.2.1:5000"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if ok {
+		t.Errorf("expected no location for an unknown address, got %+v", geo)
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	graphqlotel "github.com/graph-gophers/graphql-go/trace/otel"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// graphqlPath is where the GraphQL endpoint is served
+const graphqlPath = "/graphql"
+
+// Query limits, so one request can't fan out into unbounded backend calls
+const (
+	graphqlMaxDepth       = 6
+	graphqlMaxQueryLength = 8 << 10
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// newGraphQLSchema parses the GraphQL schema with resolvers calling the
+// gateway's gRPC clients
+func newGraphQLSchema(g *Gateway) (*graphql.Schema, error) {
+	return graphql.ParseSchema(graphqlSchema, &graphqlResolver{g: g},
+		graphql.UseStringDescriptions(),
+		graphql.UseFieldResolvers(),
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxQueryLength(graphqlMaxQueryLength),
+		graphql.Tracer(graphqlotel.DefaultTracer()),
+	)
+}
+
+// graphqlRequest is the body of a GraphQL-over-HTTP POST
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// Extensions, such as persisted query hashes, aren't supported and are
+	// ignored
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+// graphqlCallKey carries the HTTP exchange a GraphQL request arrived in to
+// its resolvers
+type graphqlCallKey struct{}
+
+type graphqlCall struct {
+	w http.ResponseWriter
+	r *http.Request
+}
+
+// handleGraphQL executes a GraphQL query or mutation. Results and field
+// errors are written with 200 as GraphQL clients expect; only requests that
+// aren't GraphQL get HTTP errors.
+func (g *Gateway) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req graphqlRequest
+	if err := request.DecodeJSON(r, &req); err != nil {
+		g.respondDecodeError(w, r, err)
+		return
+	}
+	if req.Query == "" {
+		g.respondError(w, r, errInvalidBody.WithMessage("query is required"))
+		return
+	}
+
+	// Authenticate once rather than in each resolver, which run in parallel
+	if id, err := g.authenticate(r); err == nil {
+		r = r.WithContext(withIdentity(r.Context(), id))
+	}
+	ctx := context.WithValue(r.Context(), graphqlCallKey{}, &graphqlCall{w: w, r: r})
+	resp := g.graphql.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		g.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// graphqlResolver resolves the Query and Mutation fields
+type graphqlResolver struct {
+	g *Gateway
+}
+
+// call returns the HTTP exchange of the GraphQL request ctx belongs to
+func (*graphqlResolver) call(ctx context.Context) *graphqlCall {
+	return ctx.Value(graphqlCallKey{}).(*graphqlCall)
+}
+
+// caller returns the authenticated caller's ID
+func (res *graphqlResolver) caller(ctx context.Context) (int, error) {
+	userID, err := res.g.validateAuth(res.call(ctx).r)
+	if err != nil {
+		return 0, res.error(ctx, apperror.ErrUnauthorized)
+	}
+	return userID, nil
+}
+
+// graphqlError is a field error carrying the gateway's error code, and for
+// LIMIT_EXCEEDED the limit details, in its extensions
+type graphqlError struct {
+	message    string
+	extensions map[string]any
+}
+
+func (e *graphqlError) Error() string {
+	return e.message
+}
+
+func (e *graphqlError) Extensions() map[string]any {
+	return e.extensions
+}
+
+// error returns appErr as a field error in the client's language
+func (res *graphqlResolver) error(ctx context.Context, appErr *apperror.AppError) error {
+	r := res.call(ctx).r
+	tag := res.g.catalog.Match(r.Header.Get("Accept-Language"))
+	return &graphqlError{
+		message:    res.g.catalog.Message(tag, appErr.Code, appErr.Message),
+		extensions: map[string]any{"code": appErr.Code},
+	}
+}
+
+// upstreamError returns a backend error as a field error, mapped like the
+// REST routes map it
+func (res *graphqlResolver) upstreamError(ctx context.Context, err error, fallback *apperror.AppError) error {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.FailedPrecondition:
+		for _, detail := range st.Details() {
+			info, ok := detail.(*errdetails.ErrorInfo)
+			if !ok || info.Reason != apperror.CodeLimitExceeded {
+				continue
+			}
+			gqlErr := res.error(ctx, errLimitExceeded).(*graphqlError)
+			for _, key := range []string{"current_total", "max_allowed", "remaining_limit", "resets_at"} {
+				if v, ok := info.Metadata[key]; ok {
+					gqlErr.extensions[key] = v
+				}
+			}
+			return gqlErr
+		}
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return res.error(ctx, errBackendUnavailable)
+	}
+	appErr := upstreamError(err, fallback)
+	if appErr == fallback {
+		res.g.logger.ErrorContext(ctx, "GraphQL call failed", "error", err)
+	}
+	return res.error(ctx, appErr)
+}
+
+// writable refuses mutations that write while the gateway is in
+// maintenance mode
+func (res *graphqlResolver) writable(ctx context.Context) error {
+	if res.g.maintenance.Load() {
+		return res.error(ctx, errMaintenance)
+	}
+	return nil
+}
+
+// graphqlUser is the User type
+type graphqlUser struct {
+	ID       graphql.ID
+	Username string
+	Role     string
+	Scopes   []string
+}
+
+// Me resolves Query.me
+func (res *graphqlResolver) Me(ctx context.Context) (*graphqlUser, error) {
+	id, err := res.g.authenticate(res.call(ctx).r)
+	if err != nil {
+		return nil, res.error(ctx, apperror.ErrUnauthorized)
+	}
+	return &graphqlUser{
+		ID:       graphqlID(int64(id.userID)),
+		Username: id.username,
+		Role:     id.role,
+		Scopes:   append([]string{}, id.scopes...),
+	}, nil
+}
+
+// graphqlTransaction is the Transaction type
+type graphqlTransaction struct {
+	ID          graphql.ID
+	Ulid        string
+	Amount      float64
+	Description string
+	IsPaid      bool
+	CreatedAt   graphql.Time
+}
+
+func toGraphQLTransaction(tx *paymentpb.Transaction) *graphqlTransaction {
+	return &graphqlTransaction{
+		ID:          graphqlID(tx.GetId()),
+		Ulid:        tx.GetUlid(),
+		Amount:      tx.GetAmount(),
+		Description: tx.GetDescription(),
+		IsPaid:      tx.GetIsPaid(),
+		CreatedAt:   graphql.Time{Time: tx.GetCreatedAt().AsTime()},
+	}
+}
+
+// graphqlConnection is the TransactionConnection type
+type graphqlConnection struct {
+	Nodes    []*graphqlTransaction
+	PageInfo graphqlPageInfo
+}
+
+type graphqlPageInfo struct {
+	NextCursor *string
+	Total      int32
+}
+
+// Transactions resolves Query.transactions
+func (res *graphqlResolver) Transactions(ctx context.Context, args struct {
+	First           *int32
+	After           *string
+	SortBy          string
+	Order           string
+	IncludeArchived bool
+}) (*graphqlConnection, error) {
+	userID, err := res.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &paymentpb.GetTransactionsRequest{
+		UserId:          int64(userID),
+		SortBy:          paymentpb.SortBy(paymentpb.SortBy_value["SORT_BY_"+args.SortBy]),
+		Order:           paymentpb.SortOrder(paymentpb.SortOrder_value["SORT_ORDER_"+args.Order]),
+		IncludeArchived: args.IncludeArchived,
+	}
+	if args.First != nil || args.After != nil {
+		page := pagination.PageRequest{Cursor: deref(args.After)}
+		if args.First != nil {
+			if *args.First < 0 {
+				return nil, res.error(ctx, errInvalidLimit)
+			}
+			page.Limit = int(*args.First)
+		}
+		req.Page = page.Normalize(0).Proto()
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := res.g.paymentClient.GetTransactions(paymentContext(callCtx, res.call(ctx).r), req)
+	if err != nil {
+		if st := status.Convert(err); st.Code() == codes.InvalidArgument && st.Message() == "invalid cursor" {
+			return nil, res.error(ctx, errInvalidCursor)
+		}
+		return nil, res.upstreamError(ctx, err, apperror.ErrInternalServer.WithMessage("failed to get transactions"))
+	}
+
+	conn := &graphqlConnection{Nodes: make([]*graphqlTransaction, 0, len(resp.GetTransactions()))}
+	for _, tx := range resp.GetTransactions() {
+		conn.Nodes = append(conn.Nodes, toGraphQLTransaction(tx))
+	}
+	conn.PageInfo.Total = int32(len(conn.Nodes))
+	if page := resp.GetPage(); page != nil {
+		conn.PageInfo.Total = int32(page.GetTotal())
+		if cursor := page.GetNextCursor(); cursor != "" {
+			conn.PageInfo.NextCursor = &cursor
+		}
+	}
+	return conn, nil
+}
+
+// graphqlSummary is the Summary type
+type graphqlSummary struct {
+	UnpaidTotal      float64
+	PaidTotal        float64
+	UnpaidCount      int32
+	PaidCount        int32
+	TransactionCount int32
+	RemainingLimit   float64
+	PeriodTotal      float64
+	MaxAllowed       float64
+	ResetsAt         *graphql.Time
+	LimitWarnings    []float64
+}
+
+// Summary resolves Query.summary
+func (res *graphqlResolver) Summary(ctx context.Context) (*graphqlSummary, error) {
+	userID, err := res.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := res.g.paymentClient.GetSummary(paymentContext(callCtx, res.call(ctx).r), &paymentpb.GetSummaryRequest{
+		UserId: int64(userID),
+	})
+	if err != nil {
+		return nil, res.upstreamError(ctx, err, apperror.ErrInternalServer.WithMessage("failed to get summary"))
+	}
+
+	return &graphqlSummary{
+		UnpaidTotal:      resp.GetUnpaidTotal(),
+		PaidTotal:        resp.GetPaidTotal(),
+		UnpaidCount:      int32(resp.GetUnpaidCount()),
+		PaidCount:        int32(resp.GetPaidCount()),
+		TransactionCount: int32(resp.GetTransactionCount()),
+		RemainingLimit:   resp.GetRemainingLimit(),
+		PeriodTotal:      resp.GetPeriodTotal(),
+		MaxAllowed:       resp.GetMaxAllowed(),
+		ResetsAt:         graphqlTime(resp.GetResetsAt()),
+		LimitWarnings:    append([]float64{}, resp.GetLimitWarnings()...),
+	}, nil
+}
+
+// graphqlAuthPayload is the AuthPayload type
+type graphqlAuthPayload struct {
+	ID                  graphql.ID
+	Username            string
+	Token               string
+	Email               *string
+	Role                string
+	Timezone            string
+	Locale              string
+	DeletionScheduledAt *graphql.Time
+}
+
+func toGraphQLAuthPayload(resp *authpb.AuthResponse) *graphqlAuthPayload {
+	payload := &graphqlAuthPayload{
+		ID:                  graphqlID(resp.GetId()),
+		Username:            resp.GetUsername(),
+		Token:               resp.GetToken(),
+		Role:                resp.GetRole(),
+		Timezone:            resp.GetTimezone(),
+		Locale:              resp.GetLocale(),
+		DeletionScheduledAt: graphqlTime(resp.GetDeletionScheduledAt()),
+	}
+	if email := resp.GetEmail(); email != "" {
+		payload.Email = &email
+	}
+	return payload
+}
+
+// Register resolves Mutation.register
+func (res *graphqlResolver) Register(ctx context.Context, args struct {
+	Input struct {
+		Username   string
+		Password   string
+		Email      *string
+		InviteCode *string
+		Timezone   *string
+		Locale     *string
+	}
+}) (*graphqlAuthPayload, error) {
+	if err := res.writable(ctx); err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	in := args.Input
+	resp, err := res.g.authClient.Register(callCtx, &authpb.RegisterRequest{
+		Username:   in.Username,
+		Password:   in.Password,
+		Email:      deref(in.Email),
+		InviteCode: deref(in.InviteCode),
+		Timezone:   deref(in.Timezone),
+		Locale:     deref(in.Locale),
+	})
+	if err != nil {
+		return nil, res.upstreamError(ctx, err, apperror.ErrInternalServer.WithMessage("failed to register"))
+	}
+	return toGraphQLAuthPayload(resp), nil
+}
+
+// Login resolves Mutation.login
+func (res *graphqlResolver) Login(ctx context.Context, args struct {
+	Input struct {
+		Username *string
+		Email    *string
+		Password string
+	}
+}) (*graphqlAuthPayload, error) {
+	call := res.call(ctx)
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	in := args.Input
+	resp, err := res.g.authClient.Login(grpcauth.WithClientIP(callCtx, clientIP(call.r)), &authpb.LoginRequest{
+		Username:  deref(in.Username),
+		Email:     deref(in.Email),
+		Password:  in.Password,
+		UserAgent: call.r.UserAgent(),
+		DeviceId:  call.r.Header.Get(deviceIDHeader),
+	})
+	if err != nil {
+		res.g.logger.ErrorContext(ctx, "login failed", "error", err)
+		return nil, res.error(ctx, errInvalidCredentials)
+	}
+
+	if res.g.sessions != nil {
+		res.g.sessions.start(call.w, resp.Token)
+	}
+	return toGraphQLAuthPayload(resp), nil
+}
+
+// graphqlCreatedTransaction is the CreateTransactionPayload type
+type graphqlCreatedTransaction struct {
+	Transaction    *graphqlTransaction
+	CurrentTotal   float64
+	RemainingLimit float64
+}
+
+// CreateTransaction resolves Mutation.createTransaction
+func (res *graphqlResolver) CreateTransaction(ctx context.Context, args struct {
+	Input struct {
+		Amount      float64
+		Description *string
+	}
+}) (*graphqlCreatedTransaction, error) {
+	if err := res.writable(ctx); err != nil {
+		return nil, err
+	}
+	userID, err := res.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	in := args.Input
+	var header metadata.MD
+	tx, err := res.g.paymentClient.CreateTransaction(paymentContext(callCtx, res.call(ctx).r), &paymentpb.CreateTransactionRequest{
+		UserId:      int64(userID),
+		Amount:      in.Amount,
+		Description: deref(in.Description),
+	}, grpc.Header(&header))
+	if err != nil {
+		return nil, res.upstreamError(ctx, err, apperror.ErrInternalServer.WithMessage("failed to create transaction"))
+	}
+
+	return &graphqlCreatedTransaction{
+		Transaction:    toGraphQLTransaction(tx),
+		CurrentTotal:   headerAmount(header, "current-total"),
+		RemainingLimit: headerAmount(header, "remaining-limit"),
+	}, nil
+}
+
+// graphqlID formats an int64 ID as a GraphQL ID
+func graphqlID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+// graphqlTime returns ts as a nullable Time
+func graphqlTime(ts *timestamppb.Timestamp) *graphql.Time {
+	if ts == nil {
+		return nil
+	}
+	return &graphql.Time{Time: ts.AsTime()}
+}
+
+// deref returns *s, or "" for an omitted argument
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tkaewplik/go-microservices/pkg/testutil"
+)
+
+// graphqlResponse is a GraphQL result with the error fields clients read
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execGraphQL(t *testing.T, g *Gateway, token, query string, variables map[string]any) graphqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	r := httptest.NewRequest(http.MethodPost, graphqlPath, strings.NewReader(string(body)))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	g.handleGraphQL(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp graphqlResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func newGraphQLGateway(t *testing.T) (*Gateway, *testutil.FakeAuthClient) {
+	t.Helper()
+	g, auth := newTestGateway()
+	schema, err := newGraphQLSchema(g)
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	g.graphql = schema
+	return g, auth
+}
+
+func TestGraphQL(t *testing.T) {
+	g, _ := newGraphQLGateway(t)
+
+	resp := execGraphQL(t, g, "", `mutation($input: RegisterInput!) {
+		register(input: $input) { id username token role }
+	}`, map[string]any{"input": map[string]any{"username": "alice", "password": "pw"}})
+	var registered struct {
+		Register struct {
+			ID, Username, Token, Role string
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &registered); err != nil || len(resp.Errors) > 0 || registered.Register.Token == "" {
+		t.Fatalf("expected a registered user, got %s %+v", resp.Data, resp.Errors)
+	}
+	token := registered.Register.Token
+
+	resp = execGraphQL(t, g, token, `mutation {
+		createTransaction(input: {amount: 900, description: "laptop"}) { transaction { description isPaid } remainingLimit }
+	}`, nil)
+	if len(resp.Errors) > 0 || !strings.Contains(string(resp.Data), `"remainingLimit":100`) {
+		t.Fatalf("expected the created transaction, got %s %+v", resp.Data, resp.Errors)
+	}
+
+	// The user, summary and transactions in one round trip
+	resp = execGraphQL(t, g, token, `{
+		me { id username }
+		summary { unpaidTotal unpaidCount }
+		transactions(first: 10) { nodes { amount description } pageInfo { total nextCursor } }
+	}`, nil)
+	var dashboard struct {
+		Me      struct{ ID, Username string }
+		Summary struct {
+			UnpaidTotal float64
+			UnpaidCount int
+		}
+		Transactions struct {
+			Nodes []struct {
+				Amount      float64
+				Description string
+			}
+			PageInfo struct {
+				Total      int
+				NextCursor *string
+			}
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &dashboard); err != nil || len(resp.Errors) > 0 {
+		t.Fatalf("unexpected response %s %+v", resp.Data, resp.Errors)
+	}
+	if dashboard.Me.Username != "alice" || dashboard.Me.ID != registered.Register.ID {
+		t.Errorf("unexpected user %+v", dashboard.Me)
+	}
+	if dashboard.Summary.UnpaidTotal != 900 || dashboard.Summary.UnpaidCount != 1 {
+		t.Errorf("unexpected summary %+v", dashboard.Summary)
+	}
+	if len(dashboard.Transactions.Nodes) != 1 || dashboard.Transactions.Nodes[0].Description != "laptop" || dashboard.Transactions.PageInfo.NextCursor != nil {
+		t.Errorf("unexpected transactions %+v", dashboard.Transactions)
+	}
+}
+
+func TestGraphQL_Errors(t *testing.T) {
+	g, auth := newGraphQLGateway(t)
+	_, token := auth.AddUser("alice", "pw")
+
+	resp := execGraphQL(t, g, "", `{ me { username } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "UNAUTHORIZED" {
+		t.Errorf("expected UNAUTHORIZED without a token, got %+v", resp.Errors)
+	}
+
+	resp = execGraphQL(t, g, token, `mutation {
+		createTransaction(input: {amount: 2000}) { remainingLimit }
+	}`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "LIMIT_EXCEEDED" || resp.Errors[0].Extensions["max_allowed"] == nil {
+		t.Errorf("expected LIMIT_EXCEEDED with the limit details, got %+v", resp.Errors)
+	}
+
+	resp = execGraphQL(t, g, "", `mutation { login(input: {username: "alice", password: "wrong"}) { token } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "INVALID_CREDENTIALS" {
+		t.Errorf("expected INVALID_CREDENTIALS, got %+v", resp.Errors)
+	}
+
+	g.maintenance.Store(true)
+	resp = execGraphQL(t, g, token, `mutation { createTransaction(input: {amount: 1}) { remainingLimit } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "MAINTENANCE" {
+		t.Errorf("expected MAINTENANCE for a write in maintenance mode, got %+v", resp.Errors)
+	}
+	resp = execGraphQL(t, g, "", `mutation { login(input: {username: "alice", password: "pw"}) { token } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Errorf("expected login to work in maintenance mode, got %+v", resp.Errors)
+	}
+}
+
+func TestHandleGraphQL_RejectsNonGraphQL(t *testing.T) {
+	g, _ := newGraphQLGateway(t)
+	for _, tc := range []struct {
+		method, body string
+		want         int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, `{"query":`, http.StatusBadRequest},
+		{http.MethodPost, `{"variables":{}}`, http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		g.handleGraphQL(w, httptest.NewRequest(tc.method, graphqlPath, strings.NewReader(tc.body)))
+		if w.Code != tc.want {
+			t.Errorf("%s %q: expected %d, got %d", tc.method, tc.body, tc.want, w.Code)
+		}
+	}
+}
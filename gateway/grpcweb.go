@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Browser clients generated from the protos can call the backends through
+// the gateway with gRPC-Web or the Connect protocol, alongside REST. Only
+// unary calls are translated; the backends have no streaming methods.
+
+// Paths the gRPC-Web and Connect routes are served under
+const (
+	authServicePath    = "/" + authServiceName + "/"
+	paymentServicePath = "/" + paymentServiceName + "/"
+
+	authServiceName    = "auth.AuthService"
+	paymentServiceName = "payment.PaymentService"
+)
+
+// grpcWebMethods are the methods browsers may call, mapped to whether they
+// write. They match what REST exposes: admin methods stay internal, and
+// receipts go through the REST upload so the gateway stores the file.
+var grpcWebMethods = map[string]bool{
+	authpb.AuthService_Register_FullMethodName:                   true,
+	authpb.AuthService_Login_FullMethodName:                      false,
+	authpb.AuthService_UpdatePreferences_FullMethodName:          true,
+	authpb.AuthService_DeleteAccount_FullMethodName:              true,
+	authpb.AuthService_CancelAccountDeletion_FullMethodName:      true,
+	authpb.AuthService_ListDevices_FullMethodName:                false,
+	authpb.AuthService_GetNotificationChannels_FullMethodName:    false,
+	authpb.AuthService_UpdateNotificationChannels_FullMethodName: true,
+
+	paymentpb.PaymentService_CreateTransaction_FullMethodName:  true,
+	paymentpb.PaymentService_GetTransactions_FullMethodName:    false,
+	paymentpb.PaymentService_PayAllTransactions_FullMethodName: true,
+	paymentpb.PaymentService_GetSummary_FullMethodName:         false,
+}
+
+// rpcProtocol is the wire protocol of a browser call
+type rpcProtocol int
+
+const (
+	protocolGRPCWeb rpcProtocol = iota
+	protocolGRPCWebText
+	protocolConnectProto
+	protocolConnectJSON
+)
+
+// grpcWebTrailerFlag marks a gRPC-Web frame holding trailers
+const grpcWebTrailerFlag = 0x80
+
+// rpcProtocolOf returns the protocol named by a Content-Type. gRPC-Web with
+// JSON messages and Connect streaming aren't supported.
+func rpcProtocolOf(contentType string) (rpcProtocol, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, false
+	}
+	switch mediaType {
+	case "application/grpc-web", "application/grpc-web+proto":
+		return protocolGRPCWeb, true
+	case "application/grpc-web-text", "application/grpc-web-text+proto":
+		return protocolGRPCWebText, true
+	case "application/proto":
+		return protocolConnectProto, true
+	case "application/json":
+		return protocolConnectJSON, true
+	}
+	return 0, false
+}
+
+// handleRPC translates a unary gRPC-Web or Connect call into a gRPC call on
+// the backend serving its method. Identity travels as on REST: the bearer
+// token, client channel, address and location are forwarded as metadata.
+func (g *Gateway) handleRPC(w http.ResponseWriter, r *http.Request) {
+	protocol, ok := rpcProtocolOf(r.Header.Get("Content-Type"))
+	if r.Method != http.MethodPost || !ok {
+		w.Header().Set("Accept-Post", "application/grpc-web, application/grpc-web-text, application/proto, application/json")
+		http.Error(w, "unsupported RPC protocol", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	reply, header, err := g.invokeRPC(r, protocol)
+	// Both protocols carry a unary call's response headers as HTTP headers
+	for key, values := range header {
+		if name, ok := restResponseHeader(key); ok {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+	}
+	switch protocol {
+	case protocolGRPCWeb, protocolGRPCWebText:
+		writeGRPCWeb(w, protocol, reply, err)
+	default:
+		writeConnect(w, protocol, reply, err)
+	}
+}
+
+// invokeRPC decodes the request of r's method, calls the backend and returns
+// its reply and response headers
+func (g *Gateway) invokeRPC(r *http.Request, protocol rpcProtocol) (proto.Message, metadata.MD, error) {
+	method := r.URL.Path
+	writes, ok := grpcWebMethods[method]
+	if !ok {
+		return nil, nil, status.Errorf(codes.Unimplemented, "method %s is not available", method)
+	}
+	conn := g.rpcBackend(method)
+	if conn == nil {
+		return nil, nil, status.Error(codes.Unavailable, "backend unavailable")
+	}
+	if writes && g.maintenance.Load() {
+		return nil, nil, status.Error(codes.Unavailable, errMaintenance.Message)
+	}
+	if encoding := r.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return nil, nil, status.Errorf(codes.Unimplemented, "compression %q is not supported", encoding)
+	}
+
+	req, reply, err := rpcMessages(method)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, "failed to read request body")
+	}
+	if err := decodeRPCRequest(protocol, body, req); err != nil {
+		return nil, nil, err
+	}
+	upgradeLegacyIDs(req.ProtoReflect())
+
+	ctx, cancel := context.WithTimeout(paymentContext(r.Context(), r), 5*time.Second)
+	defer cancel()
+
+	var header metadata.MD
+	if err := conn.Invoke(grpcauth.WithClientIP(ctx, clientIP(r)), method, req, reply, grpc.Header(&header)); err != nil {
+		return nil, nil, err
+	}
+	addLegacyIDs(reply.ProtoReflect())
+	return reply, header, nil
+}
+
+// rpcBackend returns the connection serving method, nil when its service
+// has no backend
+func (g *Gateway) rpcBackend(method string) grpc.ClientConnInterface {
+	service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return g.rpcBackends[service]
+}
+
+// rpcMessages returns empty request and reply messages of method, found in
+// the registered proto descriptors
+func rpcMessages(method string) (req, reply proto.Message, err error) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unimplemented, "method %s is not available", method)
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Unimplemented, "method %s is not available", method)
+	}
+	in, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "unknown request type %s", md.Input().FullName())
+	}
+	out, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "unknown reply type %s", md.Output().FullName())
+	}
+	return in.New().Interface(), out.New().Interface(), nil
+}
+
+// decodeRPCRequest unmarshals body, framed for gRPC-Web or bare for Connect,
+// into req
+func decodeRPCRequest(protocol rpcProtocol, body []byte, req proto.Message) error {
+	switch protocol {
+	case protocolConnectJSON:
+		if err := protojson.Unmarshal(body, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		return nil
+	case protocolConnectProto:
+		if err := proto.Unmarshal(body, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		return nil
+	case protocolGRPCWebText:
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(body)))
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid base64 request body")
+		}
+		body = decoded
+	}
+
+	msg, err := readGRPCWebMessage(body)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(msg, req); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+	return nil
+}
+
+// readGRPCWebMessage returns the one message framed in body. Each frame is a
+// flags byte and a big-endian length before its payload.
+func readGRPCWebMessage(body []byte) ([]byte, error) {
+	var msg []byte
+	found := false
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return nil, status.Error(codes.InvalidArgument, "truncated gRPC-Web frame")
+		}
+		flags, size := body[0], binary.BigEndian.Uint32(body[1:5])
+		if uint64(len(body)-5) < uint64(size) {
+			return nil, status.Error(codes.InvalidArgument, "truncated gRPC-Web frame")
+		}
+		payload := body[5 : 5+size]
+		body = body[5+size:]
+		switch {
+		case flags&grpcWebTrailerFlag != 0:
+			continue
+		case flags&1 != 0:
+			return nil, status.Error(codes.Unimplemented, "compressed messages are not supported")
+		case found:
+			return nil, status.Error(codes.Unimplemented, "streaming requests are not supported")
+		}
+		msg, found = payload, true
+	}
+	if !found {
+		return nil, status.Error(codes.InvalidArgument, "missing request message")
+	}
+	return msg, nil
+}
+
+// writeGRPCWeb writes reply framed for gRPC-Web, followed by a trailer frame
+// carrying the status. The HTTP status is always 200; errors live in the
+// trailers.
+func writeGRPCWeb(w http.ResponseWriter, protocol rpcProtocol, reply proto.Message, err error) {
+	var body bytes.Buffer
+	if err == nil {
+		msg, merr := proto.Marshal(reply)
+		if merr != nil {
+			err = status.Errorf(codes.Internal, "failed to encode reply: %v", merr)
+		} else {
+			writeGRPCWebFrame(&body, 0, msg)
+		}
+	}
+	st := status.Convert(err)
+	trailers := fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\n", st.Code(), encodeGRPCMessage(st.Message()))
+	writeGRPCWebFrame(&body, grpcWebTrailerFlag, []byte(trailers))
+
+	contentType := "application/grpc-web+proto"
+	out := body.Bytes()
+	if protocol == protocolGRPCWebText {
+		contentType = "application/grpc-web-text+proto"
+		out = []byte(base64.StdEncoding.EncodeToString(out))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func writeGRPCWebFrame(buf *bytes.Buffer, flags byte, payload []byte) {
+	var header [5]byte
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	buf.Write(header[:])
+	buf.Write(payload)
+}
+
+// encodeGRPCMessage percent-encodes msg for the grpc-message trailer
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := range len(msg) {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// writeConnect writes reply as a Connect unary response, or err as a Connect
+// error with the matching HTTP status
+func writeConnect(w http.ResponseWriter, protocol rpcProtocol, reply proto.Message, err error) {
+	var body []byte
+	if err == nil {
+		if protocol == protocolConnectJSON {
+			body, err = protojson.Marshal(reply)
+		} else {
+			body, err = proto.Marshal(reply)
+		}
+		if err != nil {
+			err = status.Errorf(codes.Internal, "failed to encode reply: %v", err)
+		}
+	}
+	if err != nil {
+		st := status.Convert(err)
+		code, httpStatus := connectCode(st.Code())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(httpStatus)
+		_ = json.NewEncoder(w).Encode(struct {
+			Code    string `json:"code"`
+			Message string `json:"message,omitempty"`
+		}{code, st.Message()})
+		return
+	}
+
+	contentType := "application/proto"
+	if protocol == protocolConnectJSON {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// connectCode returns the Connect protocol name and HTTP status of a gRPC code
+func connectCode(c codes.Code) (string, int) {
+	switch c {
+	case codes.Canceled:
+		return "canceled", 499
+	case codes.InvalidArgument:
+		return "invalid_argument", http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return "deadline_exceeded", http.StatusGatewayTimeout
+	case codes.NotFound:
+		return "not_found", http.StatusNotFound
+	case codes.AlreadyExists:
+		return "already_exists", http.StatusConflict
+	case codes.PermissionDenied:
+		return "permission_denied", http.StatusForbidden
+	case codes.ResourceExhausted:
+		return "resource_exhausted", http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return "failed_precondition", http.StatusBadRequest
+	case codes.Aborted:
+		return "aborted", http.StatusConflict
+	case codes.OutOfRange:
+		return "out_of_range", http.StatusBadRequest
+	case codes.Unimplemented:
+		return "unimplemented", http.StatusNotImplemented
+	case codes.Internal:
+		return "internal", http.StatusInternalServerError
+	case codes.Unavailable:
+		return "unavailable", http.StatusServiceUnavailable
+	case codes.DataLoss:
+		return "data_loss", http.StatusInternalServerError
+	case codes.Unauthenticated:
+		return "unauthenticated", http.StatusUnauthorized
+	}
+	return "unknown", http.StatusInternalServerError
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// fakeRPCConn answers calls with handle and header, recording the last
+// call's metadata
+type fakeRPCConn struct {
+	md     metadata.MD
+	method string
+	handle func(req proto.Message) (proto.Message, error)
+	header metadata.MD
+}
+
+func (c *fakeRPCConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	c.method = method
+	resp, err := c.handle(args.(proto.Message))
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		if header, ok := opt.(grpc.HeaderCallOption); ok {
+			*header.HeaderAddr = c.header
+		}
+	}
+	proto.Merge(reply.(proto.Message), resp)
+	return nil
+}
+
+func (c *fakeRPCConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "no streams")
+}
+
+func newRPCTestGateway(handle func(req proto.Message) (proto.Message, error)) (*Gateway, *fakeRPCConn) {
+	g, _ := newTestGateway()
+	conn := &fakeRPCConn{handle: handle}
+	g.rpcBackends = map[string]grpc.ClientConnInterface{authServiceName: conn, paymentServiceName: conn}
+	return g, conn
+}
+
+func grpcWebFrame(flags byte, payload []byte) []byte {
+	var buf bytes.Buffer
+	writeGRPCWebFrame(&buf, flags, payload)
+	return buf.Bytes()
+}
+
+// readGRPCWebResponse splits a gRPC-Web response into its message, if any,
+// and trailers
+func readGRPCWebResponse(t *testing.T, body []byte) ([]byte, string) {
+	t.Helper()
+	var msg []byte
+	for len(body) >= 5 {
+		flags, size := body[0], binary.BigEndian.Uint32(body[1:5])
+		payload := body[5 : 5+size]
+		body = body[5+size:]
+		if flags&grpcWebTrailerFlag != 0 {
+			return msg, string(payload)
+		}
+		msg = payload
+	}
+	t.Fatal("expected a trailer frame")
+	return nil, ""
+}
+
+func TestHandleRPC_GRPCWeb(t *testing.T) {
+	g, conn := newRPCTestGateway(func(req proto.Message) (proto.Message, error) {
+		if tz := req.(*paymentpb.GetSummaryRequest).GetTimezone(); tz != "Asia/Bangkok" {
+			t.Errorf("expected the request to reach the backend, got timezone %q", tz)
+		}
+		return &paymentpb.Summary{PaidTotal: 350}, nil
+	})
+
+	msg, _ := proto.Marshal(&paymentpb.GetSummaryRequest{Timezone: "Asia/Bangkok"})
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_GetSummary_FullMethodName, bytes.NewReader(grpcWebFrame(0, msg)))
+	r.Header.Set("Content-Type", "application/grpc-web+proto")
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/grpc-web+proto" {
+		t.Fatalf("expected a 200 gRPC-Web response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	payload, trailers := readGRPCWebResponse(t, w.Body.Bytes())
+	if !strings.Contains(trailers, "grpc-status: 0\r\n") {
+		t.Errorf("expected an OK status, got %q", trailers)
+	}
+	var summary paymentpb.Summary
+	if err := proto.Unmarshal(payload, &summary); err != nil || summary.GetPaidTotal() != 350 {
+		t.Errorf("expected the backend's summary, got %v, %v", &summary, err)
+	}
+	if got := conn.md.Get("authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Errorf("expected the bearer token forwarded, got %v", got)
+	}
+}
+
+func TestHandleRPC_GRPCWebTextError(t *testing.T) {
+	g, _ := newRPCTestGateway(func(proto.Message) (proto.Message, error) {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive: 100%")
+	})
+
+	msg, _ := proto.Marshal(&paymentpb.CreateTransactionRequest{Amount: -1})
+	body := base64.StdEncoding.EncodeToString(grpcWebFrame(0, msg))
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_CreateTransaction_FullMethodName, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/grpc-web-text")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	decoded, err := base64.StdEncoding.DecodeString(w.Body.String())
+	if err != nil {
+		t.Fatalf("expected a base64 body, got %q: %v", w.Body, err)
+	}
+	payload, trailers := readGRPCWebResponse(t, decoded)
+	if payload != nil {
+		t.Errorf("expected no message on error, got %d bytes", len(payload))
+	}
+	if !strings.Contains(trailers, "grpc-status: 3\r\n") || !strings.Contains(trailers, "grpc-message: amount must be positive: 100%25\r\n") {
+		t.Errorf("expected the backend's status in the trailers, got %q", trailers)
+	}
+}
+
+func TestHandleRPC_Connect(t *testing.T) {
+	g, conn := newRPCTestGateway(func(proto.Message) (proto.Message, error) {
+		return &paymentpb.Summary{PaidTotal: 350}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_GetSummary_FullMethodName, strings.NewReader(`{"timezone":"UTC"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(channelHeader, "web")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	var summary struct {
+		PaidTotal float64 `json:"paidTotal"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil || w.Code != http.StatusOK || summary.PaidTotal != 350 {
+		t.Errorf("expected the summary as JSON, got %d %+v: %v", w.Code, summary, err)
+	}
+	if got := conn.md.Get("x-client-channel"); len(got) != 1 || got[0] != "web" {
+		t.Errorf("expected the client channel forwarded, got %v", got)
+	}
+}
+
+func TestHandleRPC_ResponseHeaders(t *testing.T) {
+	g, conn := newRPCTestGateway(func(proto.Message) (proto.Message, error) {
+		return &paymentpb.Transaction{Amount: 5}, nil
+	})
+	conn.header = metadata.Pairs("current-total", "905.00", "remaining-limit", "95.00", "x-internal", "secret")
+
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_CreateTransaction_FullMethodName, strings.NewReader(`{"amount":5}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Remaining-Limit") != "95.00" || w.Header().Get("Current-Total") != "905.00" {
+		t.Errorf("expected the headroom headers, got %d %v", w.Code, w.Header())
+	}
+	if w.Header().Get("X-Internal") != "" {
+		t.Error("expected other response metadata to stay internal")
+	}
+}
+
+func TestHandleRPC_ConnectErrors(t *testing.T) {
+	g, conn := newRPCTestGateway(func(proto.Message) (proto.Message, error) {
+		return &paymentpb.Transaction{}, nil
+	})
+	g.maintenance.Store(true)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"admin method", "/auth.AuthService/ListUsers", `{}`, http.StatusNotImplemented, "unimplemented"},
+		{"unknown method", "/payment.PaymentService/Refund", `{}`, http.StatusNotImplemented, "unimplemented"},
+		{"invalid body", paymentpb.PaymentService_GetSummary_FullMethodName, `{"timezone":1}`, http.StatusBadRequest, "invalid_argument"},
+		{"write in maintenance", paymentpb.PaymentService_CreateTransaction_FullMethodName, `{"amount":5}`, http.StatusServiceUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn.method = ""
+			r := httptest.NewRequest(http.MethodPost, tt.method, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			g.handleRPC(w, r)
+
+			var body struct {
+				Code string `json:"code"`
+			}
+			_ = json.NewDecoder(w.Body).Decode(&body)
+			if w.Code != tt.status || body.Code != tt.code {
+				t.Errorf("expected %d %s, got %d %s", tt.status, tt.code, w.Code, body.Code)
+			}
+			if conn.method != "" {
+				t.Errorf("expected no backend call, got %s", conn.method)
+			}
+		})
+	}
+}
+
+func TestHandleRPC_UnsupportedProtocol(t *testing.T) {
+	g, _ := newRPCTestGateway(nil)
+
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_GetSummary_FullMethodName, strings.NewReader(""))
+	r.Header.Set("Content-Type", "application/grpc")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415, got %d", w.Code)
+	}
+}
+
+func TestReadGRPCWebMessage(t *testing.T) {
+	msg := []byte("hello")
+	framed := append(grpcWebFrame(0, msg), grpcWebFrame(grpcWebTrailerFlag, []byte("x: y\r\n"))...)
+	if got, err := readGRPCWebMessage(framed); err != nil || string(got) != "hello" {
+		t.Errorf("expected the message before the trailers, got %q, %v", got, err)
+	}
+
+	tests := map[string][]byte{
+		"truncated":  grpcWebFrame(0, msg)[:7],
+		"compressed": grpcWebFrame(1, msg),
+		"streaming":  append(grpcWebFrame(0, msg), grpcWebFrame(0, msg)...),
+		"empty":      nil,
+	}
+	for name, body := range tests {
+		if _, err := readGRPCWebMessage(body); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
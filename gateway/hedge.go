@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Hedger defaults
+const (
+	// DefaultHedgeBudget is the fraction of calls that may be hedged
+	DefaultHedgeBudget = 0.1
+	// DefaultHedgeMinDelay is the shortest wait before hedging, so fast
+	// methods aren't hedged on noise
+	DefaultHedgeMinDelay = 10 * time.Millisecond
+
+	// hedgeWindow is how many recent latencies the delay is computed from,
+	// and hedgeWarmup how many must be seen before hedging starts
+	hedgeWindow = 200
+	hedgeWarmup = 20
+	// hedgeBurst caps the hedges saved up while traffic is fast
+	hedgeBurst = 10
+)
+
+// HedgeStats counts hedging for one method
+type HedgeStats struct {
+	Method string `json:"method"`
+	Calls  uint64 `json:"calls"`
+	Hedged uint64 `json:"hedged"`
+	// HedgeWins counts hedges that answered before the first attempt
+	HedgeWins uint64 `json:"hedge_wins"`
+	// Throttled counts hedges skipped because the budget was spent
+	Throttled uint64        `json:"throttled"`
+	Delay     time.Duration `json:"delay_ns"`
+}
+
+// Hedger cuts tail latency of idempotent reads: when a call hasn't answered
+// within the method's recent p95 latency, a second attempt is sent and the
+// first answer wins. A budget caps hedges at a fraction of calls, so a slow
+// backend sees at most that much extra load rather than double.
+type Hedger struct {
+	methods  map[string]*latencyWindow
+	budget   float64
+	minDelay time.Duration
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	tokens float64
+	stats  map[string]*HedgeStats
+}
+
+// HedgeOption configures a Hedger
+type HedgeOption func(*Hedger)
+
+// WithHedgeBudget sets the fraction of calls, from 0 to 1, that may be hedged
+func WithHedgeBudget(budget float64) HedgeOption {
+	return func(h *Hedger) {
+		h.budget = min(max(budget, 0), 1)
+	}
+}
+
+// WithHedgeMinDelay sets the shortest wait before hedging a call
+func WithHedgeMinDelay(d time.Duration) HedgeOption {
+	return func(h *Hedger) {
+		h.minDelay = d
+	}
+}
+
+// NewHedger creates a Hedger for the given full method names. Only list
+// idempotent methods: a hedged call runs twice.
+func NewHedger(methods []string, logger *slog.Logger, opts ...HedgeOption) *Hedger {
+	h := &Hedger{
+		methods:  make(map[string]*latencyWindow, len(methods)),
+		budget:   DefaultHedgeBudget,
+		minDelay: DefaultHedgeMinDelay,
+		logger:   logger,
+		stats:    make(map[string]*HedgeStats),
+	}
+	for _, method := range methods {
+		h.methods[method] = &latencyWindow{}
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// WithHedger hedges the Hedger's methods on the auth and payment backends
+func WithHedger(h *Hedger) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.hedger = h
+	}
+}
+
+// hedgeResult is the outcome of one attempt
+type hedgeResult struct {
+	reply proto.Message
+	err   error
+	hedge bool
+}
+
+// UnaryClientInterceptor hedges calls to the Hedger's methods
+func (h *Hedger) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		window := h.methods[method]
+		replyMsg, ok := reply.(proto.Message)
+		if window == nil || !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		h.earn()
+
+		delay, warm := window.p95()
+		if !warm {
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				window.add(time.Since(start))
+			}
+			h.record(method, func(s *HedgeStats) { s.Calls++ })
+			return err
+		}
+		delay = max(delay, h.minDelay)
+
+		// Attempts write to their own replies; the winner's is copied out
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results := make(chan hedgeResult, 2)
+		attempt := func(hedge bool) {
+			r := replyMsg.ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- hedgeResult{reply: r, err: err, hedge: hedge}
+		}
+
+		start := time.Now()
+		go attempt(false)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		pending, hedged, throttled := 1, false, false
+		var res hedgeResult
+		for {
+			select {
+			case <-timer.C:
+				if !h.spend() {
+					throttled = true
+					continue
+				}
+				hedged = true
+				pending++
+				go attempt(true)
+				continue
+			case res = <-results:
+				pending--
+			}
+			// A failed attempt waits for the other, which may still succeed
+			if res.err == nil || pending == 0 {
+				break
+			}
+		}
+
+		if res.err == nil {
+			window.add(time.Since(start))
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, res.reply)
+		}
+		h.record(method, func(s *HedgeStats) {
+			s.Calls++
+			s.Delay = delay
+			if hedged {
+				s.Hedged++
+			}
+			if res.hedge && res.err == nil {
+				s.HedgeWins++
+			}
+			if throttled {
+				s.Throttled++
+			}
+		})
+		return res.err
+	}
+}
+
+// earn adds one call's share of the budget
+func (h *Hedger) earn() {
+	h.mu.Lock()
+	h.tokens = min(h.tokens+h.budget, hedgeBurst)
+	h.mu.Unlock()
+}
+
+// spend takes one hedge from the budget, reporting whether there was one
+func (h *Hedger) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+func (h *Hedger) record(method string, update func(*HedgeStats)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.stats[method]
+	if !ok {
+		s = &HedgeStats{Method: method}
+		h.stats[method] = s
+	}
+	update(s)
+}
+
+// Stats returns the hedging counts per method
+func (h *Hedger) Stats() []HedgeStats {
+	h.mu.Lock()
+	result := make([]HedgeStats, 0, len(h.stats))
+	for _, s := range h.stats {
+		result = append(result, *s)
+	}
+	h.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Method < result[j].Method })
+	return result
+}
+
+// ServeHTTP writes Stats as JSON, for an admin endpoint
+func (h *Hedger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.Stats()); err != nil {
+		h.logger.Error("failed to encode hedging stats", "error", err)
+	}
+}
+
+// latencyWindow keeps the latest hedgeWindow latencies of a method
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	// cached is the p95 of samples, recomputed every few additions
+	cached time.Duration
+	stale  int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < hedgeWindow {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % hedgeWindow
+	}
+	if w.stale++; w.stale >= hedgeWarmup/2 || w.cached == 0 {
+		sorted := slices.Clone(w.samples)
+		slices.Sort(sorted)
+		w.cached = sorted[len(sorted)*95/100]
+		w.stale = 0
+	}
+}
+
+// p95 returns the 95th percentile latency, and whether enough calls have
+// been seen for it to mean anything
+func (w *latencyWindow) p95() (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cached, len(w.samples) >= hedgeWarmup
+}
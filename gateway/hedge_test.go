@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// warmHedger returns a Hedger for GetTransactions that has seen enough fast
+// calls to hedge
+func warmHedger(t *testing.T, opts ...HedgeOption) (*Hedger, grpc.UnaryClientInterceptor) {
+	t.Helper()
+	opts = append([]HedgeOption{WithHedgeMinDelay(5 * time.Millisecond)}, opts...)
+	h := NewHedger([]string{paymentpb.PaymentService_GetTransactions_FullMethodName}, slog.New(slog.NewTextHandler(io.Discard, nil)), opts...)
+	interceptor := h.UnaryClientInterceptor()
+	fast := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return nil }
+	for range hedgeWarmup {
+		if err := interceptor(context.Background(), paymentpb.PaymentService_GetTransactions_FullMethodName, &paymentpb.GetTransactionsRequest{}, &paymentpb.TransactionList{}, nil, fast); err != nil {
+			t.Fatalf("warm-up call failed: %v", err)
+		}
+	}
+	return h, interceptor
+}
+
+func TestHedger_HedgeWins(t *testing.T) {
+	h, interceptor := warmHedger(t)
+
+	// The first attempt hangs until canceled; the hedge answers
+	var attempts atomic.Int32
+	invoker := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		reply.(*paymentpb.TransactionList).Transactions = []*paymentpb.Transaction{{Id: 7}}
+		return nil
+	}
+
+	var reply paymentpb.TransactionList
+	err := interceptor(context.Background(), paymentpb.PaymentService_GetTransactions_FullMethodName, &paymentpb.GetTransactionsRequest{}, &reply, nil, invoker)
+	if err != nil {
+		t.Fatalf("expected the hedge to answer, got %v", err)
+	}
+	if len(reply.GetTransactions()) != 1 || reply.GetTransactions()[0].GetId() != 7 {
+		t.Errorf("expected the hedge's reply, got %v", &reply)
+	}
+	stats := h.Stats()
+	if len(stats) != 1 || stats[0].Hedged != 1 || stats[0].HedgeWins != 1 || stats[0].Calls != hedgeWarmup+1 {
+		t.Errorf("expected one winning hedge, got %+v", stats)
+	}
+}
+
+func TestHedger_Budget(t *testing.T) {
+	h, interceptor := warmHedger(t, WithHedgeBudget(0))
+
+	var attempts atomic.Int32
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	err := interceptor(context.Background(), paymentpb.PaymentService_GetTransactions_FullMethodName, &paymentpb.GetTransactionsRequest{}, &paymentpb.TransactionList{}, nil, invoker)
+	if err != nil {
+		t.Fatalf("expected the slow call to succeed, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected no hedge without budget, got %d attempts", attempts.Load())
+	}
+	if stats := h.Stats(); stats[0].Throttled != 1 || stats[0].Hedged != 0 {
+		t.Errorf("expected a throttled hedge, got %+v", stats)
+	}
+}
+
+func TestHedger_UnlistedMethod(t *testing.T) {
+	h, interceptor := warmHedger(t)
+
+	var attempts atomic.Int32
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	// Paying isn't idempotent, so it must never run twice
+	err := interceptor(context.Background(), paymentpb.PaymentService_PayAllTransactions_FullMethodName, &paymentpb.PayRequest{}, &paymentpb.PayResponse{}, nil, invoker)
+	if err != nil || attempts.Load() != 1 {
+		t.Errorf("expected one attempt, got %d: %v", attempts.Load(), err)
+	}
+	if stats := h.Stats(); stats[0].Calls != hedgeWarmup {
+		t.Errorf("expected unlisted calls not to be counted, got %+v", stats)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paginationpb "github.com/tkaewplik/go-microservices/proto/pagination"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Hand-written JSON encoders for the hottest responses. They produce exactly
+// what encoding/json does for the generated structs (same field names,
+// omitempty, HTML escaping and float formatting) without reflection, and
+// write into pooled buffers so a response costs no allocations.
+
+var jsonBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// maxPooledJSONBuf keeps one oversized list response from pinning its buffer
+// in the pool
+const maxPooledJSONBuf = 64 << 10
+
+func putJSONBuf(bp *[]byte) {
+	if cap(*bp) > maxPooledJSONBuf {
+		return
+	}
+	jsonBufPool.Put(bp)
+}
+
+// appendJSON appends the JSON encoding of data followed by a newline, as
+// json.Encoder does. ok is false for types without a hand-written encoder.
+func appendJSON(b []byte, data any) (out []byte, ok bool) {
+	switch v := data.(type) {
+	case *paymentpb.TransactionList:
+		b = appendTransactionList(b, v)
+	case *authpb.AuthResponse:
+		b = appendAuthResponse(b, v)
+	case envelope:
+		if b, ok = appendEnvelope(b, v); !ok {
+			return b, false
+		}
+	default:
+		return b, false
+	}
+	return append(b, '\n'), true
+}
+
+// appendEnvelope encodes env when its data has a hand-written encoder
+func appendEnvelope(b []byte, env envelope) ([]byte, bool) {
+	start := len(b)
+	b = append(b, `{"data":`...)
+	switch v := env.Data.(type) {
+	case []*paymentpb.Transaction:
+		b = appendTransactions(b, v)
+	case *authpb.AuthResponse:
+		b = appendAuthResponse(b, v)
+	default:
+		return b[:start], false
+	}
+	b = append(b, `,"meta":{"request_id":`...)
+	b = appendJSONString(b, env.Meta.RequestID)
+	if env.Meta.Pagination != nil {
+		b = append(b, `,"pagination":`...)
+		b = appendPageInfo(b, env.Meta.Pagination)
+	}
+	return append(b, "}}"...), true
+}
+
+func appendTransactionList(b []byte, list *paymentpb.TransactionList) []byte {
+	if list == nil {
+		return append(b, "null"...)
+	}
+	o := objectWriter{b: append(b, '{')}
+	if txs := list.GetTransactions(); len(txs) > 0 {
+		o.key(`"transactions":`)
+		o.b = appendTransactions(o.b, txs)
+	}
+	if list.Page != nil {
+		o.key(`"page":`)
+		o.b = appendPageInfo(o.b, list.Page)
+	}
+	return append(o.b, '}')
+}
+
+func appendTransactions(b []byte, txs []*paymentpb.Transaction) []byte {
+	if txs == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i, tx := range txs {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendTransaction(b, tx)
+	}
+	return append(b, ']')
+}
+
+func appendPageInfo(b []byte, page *paginationpb.PageInfo) []byte {
+	o := objectWriter{b: append(b, '{')}
+	if page.Limit != 0 {
+		o.key(`"limit":`)
+		o.b = strconv.AppendInt(o.b, int64(page.Limit), 10)
+	}
+	if page.NextCursor != "" {
+		o.key(`"next_cursor":`)
+		o.b = appendJSONString(o.b, page.NextCursor)
+	}
+	if page.Total != 0 {
+		o.key(`"total":`)
+		o.b = strconv.AppendInt(o.b, page.Total, 10)
+	}
+	return append(o.b, '}')
+}
+
+func appendTransaction(b []byte, tx *paymentpb.Transaction) []byte {
+	if tx == nil {
+		return append(b, "null"...)
+	}
+	o := objectWriter{b: append(b, '{')}
+	if tx.Id != 0 {
+		o.key(`"id":`)
+		o.b = strconv.AppendInt(o.b, tx.Id, 10)
+	}
+	if tx.Ulid != "" {
+		o.key(`"ulid":`)
+		o.b = appendJSONString(o.b, tx.Ulid)
+	}
+	if tx.UserId != 0 {
+		o.key(`"user_id":`)
+		o.b = strconv.AppendInt(o.b, tx.UserId, 10)
+	}
+	if tx.Amount != 0 {
+		o.key(`"amount":`)
+		o.b = appendJSONFloat(o.b, tx.Amount)
+	}
+	if tx.Description != "" {
+		o.key(`"description":`)
+		o.b = appendJSONString(o.b, tx.Description)
+	}
+	if tx.IsPaid {
+		o.key(`"is_paid":true`)
+	}
+	if tx.CreatedAt != nil {
+		o.key(`"created_at":`)
+		o.b = appendTimestamp(o.b, tx.CreatedAt)
+	}
+	return append(o.b, '}')
+}
+
+func appendAuthResponse(b []byte, resp *authpb.AuthResponse) []byte {
+	if resp == nil {
+		return append(b, "null"...)
+	}
+	o := objectWriter{b: append(b, '{')}
+	if resp.Id != 0 {
+		o.key(`"id":`)
+		o.b = strconv.AppendInt(o.b, resp.Id, 10)
+	}
+	if resp.Username != "" {
+		o.key(`"username":`)
+		o.b = appendJSONString(o.b, resp.Username)
+	}
+	if resp.Token != "" {
+		o.key(`"token":`)
+		o.b = appendJSONString(o.b, resp.Token)
+	}
+	if resp.Timezone != "" {
+		o.key(`"timezone":`)
+		o.b = appendJSONString(o.b, resp.Timezone)
+	}
+	if resp.Locale != "" {
+		o.key(`"locale":`)
+		o.b = appendJSONString(o.b, resp.Locale)
+	}
+	if resp.Email != "" {
+		o.key(`"email":`)
+		o.b = appendJSONString(o.b, resp.Email)
+	}
+	if resp.Role != "" {
+		o.key(`"role":`)
+		o.b = appendJSONString(o.b, resp.Role)
+	}
+	if resp.DeletionScheduledAt != nil {
+		o.key(`"deletion_scheduled_at":`)
+		o.b = appendTimestamp(o.b, resp.DeletionScheduledAt)
+	}
+	return append(o.b, '}')
+}
+
+// appendTimestamp matches encoding/json on timestamppb.Timestamp, which has
+// no MarshalJSON and so encodes as its seconds and nanos fields
+func appendTimestamp(b []byte, ts *timestamppb.Timestamp) []byte {
+	o := objectWriter{b: append(b, '{')}
+	if ts.Seconds != 0 {
+		o.key(`"seconds":`)
+		o.b = strconv.AppendInt(o.b, ts.Seconds, 10)
+	}
+	if ts.Nanos != 0 {
+		o.key(`"nanos":`)
+		o.b = strconv.AppendInt(o.b, int64(ts.Nanos), 10)
+	}
+	return append(o.b, '}')
+}
+
+// objectWriter inserts the commas between object members
+type objectWriter struct {
+	b       []byte
+	written bool
+}
+
+func (o *objectWriter) key(k string) {
+	if o.written {
+		o.b = append(o.b, ',')
+	}
+	o.written = true
+	o.b = append(o.b, k...)
+}
+
+// appendJSONFloat formats like encoding/json: shortest representation,
+// switching to exponent form outside [1e-6, 1e21). NaN and Inf can't come
+// from a valid amount and are written as 0 rather than failing the response.
+func appendJSONFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(b, '0')
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString quotes s like encoding/json with HTML escaping on
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 break JavaScript string literals
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paginationpb "github.com/tkaewplik/go-microservices/proto/pagination"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// encodeStd is what respondJSON wrote before the hand-written encoders
+func encodeStd(t testing.TB, data any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		t.Fatalf("encoding/json failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func assertSameJSON(t testing.TB, data any) {
+	t.Helper()
+	got, ok := appendJSON(nil, data)
+	if !ok {
+		t.Fatalf("no encoder for %T", data)
+	}
+	if want := encodeStd(t, data); !bytes.Equal(got, want) {
+		t.Errorf("encoding mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestAppendJSON_MatchesEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+	}{
+		{"empty list", &paymentpb.TransactionList{}},
+		{"nil list", (*paymentpb.TransactionList)(nil)},
+		{"list", &paymentpb.TransactionList{Transactions: []*paymentpb.Transaction{
+			{Id: 1, UserId: 7, Amount: 250.5, Description: "groceries", IsPaid: true, CreatedAt: timestamppb.New(time.Unix(1792174551, 485518053))},
+			{},
+			nil,
+			{Amount: -0.000001, Description: "<a href=\"x\">&</a>\n\t\x01"},
+			{Amount: 1e21, CreatedAt: &timestamppb.Timestamp{}},
+			{Amount: 1e-7, Description: "bad utf8 \xff line sep     ok ✓"},
+			{Id: math.MaxInt32, UserId: math.MinInt32, Amount: 123456789.125},
+		}}},
+		{"paged list", &paymentpb.TransactionList{
+			Transactions: []*paymentpb.Transaction{{Id: 1}},
+			Page:         &paginationpb.PageInfo{Limit: 1, NextCursor: "eyJpIjoxfQ", Total: 2},
+		}},
+		{"empty page", &paymentpb.TransactionList{Page: &paginationpb.PageInfo{}}},
+		{"empty auth", &authpb.AuthResponse{}},
+		{"auth", &authpb.AuthResponse{Id: 3, Username: "alice", Token: "a.b.c", Timezone: "Asia/Bangkok", Locale: "th-TH"}},
+		{"auth partial", &authpb.AuthResponse{Token: "a.b.c", Locale: "en"}},
+		{"auth full", &authpb.AuthResponse{
+			Id: 3, Username: "alice", Token: "a.b.c", Timezone: "UTC", Locale: "en-US",
+			Email: "alice@example.com", Role: "admin", DeletionScheduledAt: timestamppb.New(time.Unix(1792174551, 0)),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSameJSON(t, tt.data)
+		})
+	}
+}
+
+func TestAppendJSON_UnknownType(t *testing.T) {
+	if _, ok := appendJSON(nil, map[string]string{}); ok {
+		t.Error("expected no encoder for a map")
+	}
+}
+
+func FuzzAppendJSON(f *testing.F) {
+	f.Add("groceries", 250.5, int64(1792174551), int32(485518053))
+	f.Add("<script>& ", 1e-7, int64(-1), int32(0))
+	f.Add("\xff\x00\"\\", 1e21, int64(0), int32(1))
+
+	f.Fuzz(func(t *testing.T, s string, amount float64, seconds int64, nanos int32) {
+		if math.IsNaN(amount) || math.IsInf(amount, 0) {
+			// encoding/json refuses these; appendJSONFloat writes 0
+			return
+		}
+		assertSameJSON(t, &paymentpb.TransactionList{Transactions: []*paymentpb.Transaction{{
+			Id:          seconds,
+			Amount:      amount,
+			Description: s,
+			CreatedAt:   &timestamppb.Timestamp{Seconds: seconds, Nanos: nanos},
+		}}})
+		assertSameJSON(t, &authpb.AuthResponse{Username: s, Token: s, Email: s, Role: s})
+	})
+}
+
+func benchmarkList() *paymentpb.TransactionList {
+	list := &paymentpb.TransactionList{}
+	created := timestamppb.New(time.Unix(1792174551, 485518053))
+	for i := range 50 {
+		list.Transactions = append(list.Transactions, &paymentpb.Transaction{
+			Id:          int64(i + 1),
+			UserId:      7,
+			Amount:      float64(i) + 0.25,
+			Description: "coffee & cake",
+			IsPaid:      i%2 == 0,
+			CreatedAt:   created,
+		})
+	}
+	return list
+}
+
+func BenchmarkRespondJSON_TransactionList(b *testing.B) {
+	list := benchmarkList()
+
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := json.NewEncoder(io.Discard).Encode(list); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bp := jsonBufPool.Get().(*[]byte)
+			*bp, _ = appendJSON((*bp)[:0], list)
+			io.Discard.Write(*bp)
+			putJSONBuf(bp)
+		}
+	})
+}
+
+func BenchmarkRespondJSON_AuthResponse(b *testing.B) {
+	resp := &authpb.AuthResponse{Id: 3, Username: "alice", Token: "eyJhbGciOiJIUzI1NiJ9.eyJ1c2VyX2lkIjozfQ.c2ln", Timezone: "Asia/Bangkok", Locale: "th-TH"}
+
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := json.NewEncoder(io.Discard).Encode(resp); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bp := jsonBufPool.Get().(*[]byte)
+			*bp, _ = appendJSON((*bp)[:0], resp)
+			io.Discard.Write(*bp)
+			putJSONBuf(bp)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// JSONNaming is how an API version writes the proto messages in responses
+type JSONNaming int
+
+const (
+	// JSONNamingGo writes messages as encoding/json writes the generated
+	// structs: snake_case names, timestamps as seconds and nanos, and zero
+	// values left out. Versions 1 and 2 keep it so their schema is unchanged.
+	JSONNamingGo JSONNaming = iota
+	// JSONNamingSnake writes protojson with the proto field names
+	JSONNamingSnake
+	// JSONNamingCamel writes protojson with lowerCamelCase names
+	JSONNamingCamel
+)
+
+// ParseJSONNaming parses "snake" or "camel"
+func ParseJSONNaming(s string) (JSONNaming, error) {
+	switch s {
+	case "snake":
+		return JSONNamingSnake, nil
+	case "camel":
+		return JSONNamingCamel, nil
+	}
+	return 0, fmt.Errorf("unknown JSON naming %q; expected snake or camel", s)
+}
+
+// WithJSONNaming overrides the naming policy of an API version other than
+// 1 and 2, whose schema is frozen
+func WithJSONNaming(version string, naming JSONNaming) GatewayOption {
+	return func(o *gatewayOptions) {
+		if o.jsonNaming == nil {
+			o.jsonNaming = make(map[string]JSONNaming)
+		}
+		o.jsonNaming[version] = naming
+	}
+}
+
+// checkJSONNaming validates configured naming overrides
+func checkJSONNaming(overrides map[string]JSONNaming) error {
+	for version := range overrides {
+		if _, ok := apiVersions[version]; !ok {
+			return fmt.Errorf("unknown API version %q", version)
+		}
+		if version == APIVersion1 || version == APIVersion2 {
+			return fmt.Errorf("API version %s's JSON naming can't be changed", version)
+		}
+	}
+	return nil
+}
+
+// jsonNaming returns the naming policy of r's API version
+func (g *Gateway) jsonNaming(r *http.Request) JSONNaming {
+	if r == nil {
+		return JSONNamingGo
+	}
+	meta, ok := requestMetaFrom(r.Context())
+	if !ok {
+		return JSONNamingGo
+	}
+	if naming, ok := g.jsonNamingOverrides[meta.version]; ok {
+		return naming
+	}
+	return apiVersions[meta.version].naming
+}
+
+// protoJSON returns data with its proto messages replaced by their protojson
+// encoding under naming. Every field is written, zero or not, so clients see
+// one schema. ok is false when data holds no proto message.
+func protoJSON(data any, naming JSONNaming) (out any, ok bool, err error) {
+	opts := protojson.MarshalOptions{UseProtoNames: naming == JSONNamingSnake, EmitUnpopulated: true}
+	switch v := data.(type) {
+	case envelope:
+		if v.Data, ok, err = protoJSON(v.Data, naming); !ok || err != nil {
+			return data, ok, err
+		}
+		return v, true, nil
+	case CreateTransactionResponse:
+		// The headroom fields sit alongside the transaction's own
+		b, err := opts.Marshal(v.Transaction)
+		if err != nil {
+			return nil, true, err
+		}
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, true, err
+		}
+		currentTotal, remainingLimit := "current_total", "remaining_limit"
+		if naming == JSONNamingCamel {
+			currentTotal, remainingLimit = "currentTotal", "remainingLimit"
+		}
+		fields[currentTotal], _ = json.Marshal(v.CurrentTotal)
+		fields[remainingLimit], _ = json.Marshal(v.RemainingLimit)
+		return fields, true, nil
+	case []*paymentpb.Transaction:
+		txs := make([]json.RawMessage, len(v))
+		for i, tx := range v {
+			b, err := opts.Marshal(tx)
+			if err != nil {
+				return nil, true, err
+			}
+			txs[i] = b
+		}
+		return txs, true, nil
+	case proto.Message:
+		b, err := opts.Marshal(v)
+		if err != nil {
+			return nil, true, err
+		}
+		return json.RawMessage(b), true, nil
+	}
+	return data, false, nil
+}
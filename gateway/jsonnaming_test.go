@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONNaming_Version3(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":10}`, "")
+
+	w := listTransactions(g, token, APIVersion3, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || len(body.Data) != 1 {
+		t.Fatalf("expected one transaction, got %+v, %v", body, err)
+	}
+	tx := body.Data[0]
+	// protojson: int64s as strings, RFC 3339 timestamps, zero values present
+	if tx["user_id"] != "1" || tx["is_paid"] != false {
+		t.Errorf("expected snake_case protojson fields, got %v", tx)
+	}
+	if createdAt, ok := tx["created_at"].(string); !ok || !strings.HasSuffix(createdAt, "Z") {
+		t.Errorf("expected an RFC 3339 created_at, got %v", tx["created_at"])
+	}
+}
+
+func TestJSONNaming_Camel(t *testing.T) {
+	g, auth := newTestGateway()
+	g.jsonNamingOverrides = map[string]JSONNaming{APIVersion3: JSONNamingCamel}
+	_, token := auth.AddUser("alice", "pw")
+
+	r := httptest.NewRequest(http.MethodPost, "/payment/transactions", strings.NewReader(`{"amount":10}`))
+	r.Header.Set("Authorization", "Bearer "+token)
+	r.Header.Set(apiVersionHeader, APIVersion3)
+	w := httptest.NewRecorder()
+	g.withRequestMeta(http.HandlerFunc(mustLegacyHandler(g))).ServeHTTP(w, r)
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, key := range []string{"userId", "isPaid", "createdAt", "currentTotal", "remainingLimit"} {
+		if _, ok := body.Data[key]; !ok {
+			t.Errorf("expected %s in %v", key, body.Data)
+		}
+	}
+	if _, ok := body.Data["user_id"]; ok {
+		t.Errorf("expected no snake_case names, got %v", body.Data)
+	}
+}
+
+func TestJSONNaming_Version1Unchanged(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":10}`, "")
+
+	w := listTransactions(g, token, APIVersion1, "")
+	if !strings.Contains(w.Body.String(), `"user_id":1,`) || !strings.Contains(w.Body.String(), `"seconds":`) {
+		t.Errorf("expected the encoding/json schema, got %s", w.Body)
+	}
+}
+
+func TestCheckJSONNaming(t *testing.T) {
+	if err := checkJSONNaming(map[string]JSONNaming{APIVersion3: JSONNamingCamel}); err != nil {
+		t.Errorf("expected version 3 to be configurable, got %v", err)
+	}
+	for _, version := range []string{APIVersion1, APIVersion2, "9"} {
+		if err := checkJSONNaming(map[string]JSONNaming{version: JSONNamingCamel}); err == nil {
+			t.Errorf("expected version %s to be rejected", version)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// User and transaction IDs were int32 in the protos. Each became an int64
+// field under a new number and the old number was reserved, so browser
+// clients generated before the move would stop seeing IDs. The gateway
+// bridges them on gRPC-Web and Connect calls: IDs a request carries at an
+// old number are moved into the int64 field, and replies repeat each ID at
+// its old number while it still fits in an int32. JSON clients need nothing,
+// as field names didn't change and proto JSON accepts int64s as numbers or
+// strings.
+
+// legacyIDField is a reserved int32 field number and the int64 field that
+// replaced it
+type legacyIDField struct {
+	number protowire.Number
+	name   protoreflect.Name
+}
+
+// legacyIDFields lists the replaced ID fields of each message
+var legacyIDFields = map[protoreflect.FullName][]legacyIDField{
+	"auth.AuthResponse":                   {{1, "id"}},
+	"auth.ValidateTokenResponse":          {{2, "user_id"}},
+	"auth.User":                           {{1, "id"}},
+	"auth.GetNotificationChannelsRequest": {{2, "user_id"}},
+	"auth.NotificationChannels":           {{1, "user_id"}},
+
+	"payment.CreateTransactionRequest": {{1, "user_id"}},
+	"payment.GetTransactionsRequest":   {{1, "user_id"}},
+	"payment.PayRequest":               {{1, "user_id"}},
+	"payment.Transaction":              {{1, "id"}, {2, "user_id"}},
+	"payment.GetSummaryRequest":        {{1, "user_id"}},
+	"payment.AttachReceiptRequest":     {{1, "user_id"}, {2, "transaction_id"}},
+	"payment.GetReceiptRequest":        {{1, "user_id"}, {2, "transaction_id"}},
+	"payment.StatementRequest":         {{1, "user_id"}},
+}
+
+// upgradeLegacyIDs moves IDs sent at old int32 field numbers, in m and the
+// messages it holds, into the int64 fields that replaced them. An ID also
+// sent in its int64 field wins.
+func upgradeLegacyIDs(m protoreflect.Message) {
+	walkMessages(m, func(m protoreflect.Message) {
+		legacy := legacyIDFields[m.Descriptor().FullName()]
+		unknown := m.GetUnknown()
+		if len(legacy) == 0 || len(unknown) == 0 {
+			return
+		}
+
+		var kept protoreflect.RawFields
+		for len(unknown) > 0 {
+			num, typ, n := protowire.ConsumeTag(unknown)
+			if n < 0 {
+				return
+			}
+			size := protowire.ConsumeFieldValue(num, typ, unknown[n:])
+			if size < 0 {
+				return
+			}
+			field := unknown[:n+size]
+			unknown = unknown[n+size:]
+
+			fd := legacyIDDescriptor(m, legacy, num)
+			if fd == nil || typ != protowire.VarintType {
+				kept = append(kept, field...)
+				continue
+			}
+			v, _ := protowire.ConsumeVarint(field[n:])
+			if !m.Has(fd) {
+				// Decoded as the int32 it was sent as
+				m.Set(fd, protoreflect.ValueOfInt64(int64(int32(v))))
+			}
+		}
+		m.SetUnknown(kept)
+	})
+}
+
+// addLegacyIDs repeats the IDs in m and the messages it holds at their old
+// int32 field numbers. IDs past the int32 range are left out rather than
+// sent truncated.
+func addLegacyIDs(m protoreflect.Message) {
+	walkMessages(m, func(m protoreflect.Message) {
+		unknown := m.GetUnknown()
+		for _, f := range legacyIDFields[m.Descriptor().FullName()] {
+			id := m.Get(m.Descriptor().Fields().ByName(f.name)).Int()
+			if id == 0 || id != int64(int32(id)) {
+				continue
+			}
+			unknown = protowire.AppendTag(unknown, f.number, protowire.VarintType)
+			unknown = protowire.AppendVarint(unknown, uint64(id))
+		}
+		if len(unknown) > 0 {
+			m.SetUnknown(unknown)
+		}
+	})
+}
+
+// legacyIDDescriptor returns the int64 field that replaced number, nil when
+// number isn't a replaced ID
+func legacyIDDescriptor(m protoreflect.Message, legacy []legacyIDField, number protowire.Number) protoreflect.FieldDescriptor {
+	for _, f := range legacy {
+		if f.number == number {
+			return m.Descriptor().Fields().ByName(f.name)
+		}
+	}
+	return nil
+}
+
+// walkMessages calls fn with m and every message set in it, depth first
+func walkMessages(m protoreflect.Message, fn func(protoreflect.Message)) {
+	fn(m)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					walkMessages(v.Message(), fn)
+					return true
+				})
+			}
+		case fd.Message() == nil:
+		case fd.IsList():
+			list := v.List()
+			for i := range list.Len() {
+				walkMessages(list.Get(i).Message(), fn)
+			}
+		default:
+			walkMessages(v.Message(), fn)
+		}
+		return true
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func TestLegacyIDFields_MatchProtos(t *testing.T) {
+	for name, fields := range legacyIDFields {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+		if err != nil {
+			t.Errorf("expected message %s to exist, got %v", name, err)
+			continue
+		}
+		md := desc.(protoreflect.MessageDescriptor)
+		for _, f := range fields {
+			if !md.ReservedRanges().Has(f.number) {
+				t.Errorf("expected %s field %d to be reserved", name, f.number)
+			}
+			if fd := md.Fields().ByName(f.name); fd == nil || fd.Kind() != protoreflect.Int64Kind {
+				t.Errorf("expected %s.%s to be an int64 field", name, f.name)
+			}
+		}
+	}
+}
+
+func TestHandleRPC_LegacyIDs(t *testing.T) {
+	g, _ := newRPCTestGateway(func(req proto.Message) (proto.Message, error) {
+		if id := req.(*paymentpb.GetTransactionsRequest).GetUserId(); id != 7 {
+			t.Errorf("expected the old user_id moved to the int64 field, got %d", id)
+		}
+		return &paymentpb.TransactionList{Transactions: []*paymentpb.Transaction{
+			{Id: 5, UserId: 7},
+			{Id: 1 << 40, UserId: 7},
+		}}, nil
+	})
+
+	// A request from a client built when user_id was int32 field 1
+	msg := protowire.AppendTag(nil, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 7)
+	r := httptest.NewRequest(http.MethodPost, paymentpb.PaymentService_GetTransactions_FullMethodName, bytes.NewReader(grpcWebFrame(0, msg)))
+	r.Header.Set("Content-Type", "application/grpc-web+proto")
+	w := httptest.NewRecorder()
+	g.handleRPC(w, r)
+
+	payload, _ := readGRPCWebResponse(t, w.Body.Bytes())
+	var list paymentpb.TransactionList
+	if err := proto.Unmarshal(payload, &list); err != nil || len(list.GetTransactions()) != 2 {
+		t.Fatalf("expected two transactions, got %v, %v", &list, err)
+	}
+
+	legacy := func(pairs ...uint64) []byte {
+		var b []byte
+		for i := 0; i < len(pairs); i += 2 {
+			b = protowire.AppendTag(b, protowire.Number(pairs[i]), protowire.VarintType)
+			b = protowire.AppendVarint(b, pairs[i+1])
+		}
+		return b
+	}
+	if got, want := list.Transactions[0].ProtoReflect().GetUnknown(), legacy(1, 5, 2, 7); !bytes.Equal(got, want) {
+		t.Errorf("expected id and user_id repeated at fields 1 and 2, got %x", got)
+	}
+	// An ID past the int32 range is left out rather than truncated
+	if got, want := list.Transactions[1].ProtoReflect().GetUnknown(), legacy(2, 7); !bytes.Equal(got, want) {
+		t.Errorf("expected only user_id repeated, got %x", got)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// legacyRoute is what a route outside /v1/ keeps of the behaviour its
+// clients were built against. The generated handler of the route's
+// additional binding turns the request into the RPC call; the route only
+// shapes what goes in and what comes out.
+type legacyRoute struct {
+	// auth answers 401 before anything else without a valid token
+	auth bool
+	// body returns the message a request body is strictly decoded into; the
+	// body of a route without one is ignored
+	body func() proto.Message
+	// forms also accepts the body as an HTML form
+	forms bool
+	// query maps the route's query parameters to the request message's; the
+	// query of a route without one is ignored
+	query func(url.Values) (url.Values, *apperror.AppError)
+	// respond writes the RPC's response
+	respond func(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message)
+	// fail writes the RPC's error
+	fail func(g *Gateway, w http.ResponseWriter, r *http.Request, err error)
+}
+
+// legacyRoutes are keyed by method and path
+var legacyRoutes = map[string]legacyRoute{
+	"POST /auth/register": {
+		body:    func() proto.Message { return new(authpb.RegisterRequest) },
+		forms:   true,
+		respond: respondWith(http.StatusCreated),
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to register")),
+	},
+	"POST /auth/login": {
+		body:    func() proto.Message { return new(authpb.LoginRequest) },
+		forms:   true,
+		respond: respondLogin,
+		fail: func(g *Gateway, w http.ResponseWriter, r *http.Request, _ error) {
+			g.respondError(w, r, errInvalidCredentials)
+		},
+	},
+	"PUT /auth/preferences": {
+		auth:    true,
+		body:    func() proto.Message { return new(authpb.UpdatePreferencesRequest) },
+		respond: respondWith(http.StatusOK),
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to update preferences")),
+	},
+	"GET /auth/notifications": {
+		auth:    true,
+		respond: respondNotificationChannels,
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to get notification channels")),
+	},
+	"PUT /auth/notifications": {
+		auth:    true,
+		body:    func() proto.Message { return new(authpb.UpdateNotificationChannelsRequest) },
+		respond: respondNotificationChannels,
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to get notification channels")),
+	},
+	"DELETE /auth/account": {
+		auth:    true,
+		body:    func() proto.Message { return new(authpb.DeleteAccountRequest) },
+		respond: respondDeleteAccount,
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to delete account")),
+	},
+	"POST /auth/account/cancel-deletion": {
+		auth: true,
+		respond: func(_ *Gateway, w http.ResponseWriter, _ *http.Request, _ proto.Message) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+		},
+		fail: func(g *Gateway, w http.ResponseWriter, r *http.Request, err error) {
+			if status.Code(err) == codes.FailedPrecondition {
+				g.respondError(w, r, errDeletionNotPending)
+				return
+			}
+			g.respondError(w, r, upstreamError(err, apperror.ErrInternalServer.WithMessage("failed to cancel account deletion")))
+		},
+	},
+	"GET /auth/devices": {
+		auth:    true,
+		respond: respondDevices,
+		fail:    failWith(apperror.ErrInternalServer.WithMessage("failed to list devices")),
+	},
+	"POST /payment/transactions": {
+		auth:    true,
+		body:    func() proto.Message { return new(paymentpb.CreateTransactionRequest) },
+		respond: respondCreateTransaction,
+		fail:    (*Gateway).respondCreateTransactionError,
+	},
+	"GET /payment/transactions/list": {
+		auth:  true,
+		query: transactionsQuery,
+		respond: func(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+			g.respondNegotiated(w, r, http.StatusOK, resp, resp)
+		},
+		fail: func(g *Gateway, w http.ResponseWriter, r *http.Request, err error) {
+			if st := status.Convert(err); st.Code() == codes.InvalidArgument {
+				if st.Message() == "invalid cursor" {
+					g.respondError(w, r, errInvalidCursor)
+					return
+				}
+				g.respondError(w, r, errInvalidFields)
+				return
+			}
+			g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get transactions"))
+		},
+	},
+	"GET /payment/transactions/summary": {
+		auth:    true,
+		respond: respondSummary,
+		fail: func(g *Gateway, w http.ResponseWriter, r *http.Request, err error) {
+			if status.Code(err) == codes.InvalidArgument {
+				g.respondError(w, r, errInvalidTimezone)
+				return
+			}
+			g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get summary"))
+		},
+	},
+	"POST /payment/transactions/pay": {
+		auth:    true,
+		respond: respondWith(http.StatusOK),
+		fail: func(g *Gateway, w http.ResponseWriter, r *http.Request, err error) {
+			if status.Code(err) == codes.FailedPrecondition {
+				g.respondError(w, r, errChargeFailed)
+				return
+			}
+			g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to pay transactions"))
+		},
+	},
+}
+
+// respondWith writes the response as is with status
+func respondWith(status int) func(*Gateway, http.ResponseWriter, *http.Request, proto.Message) {
+	return func(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+		g.respondJSON(w, r, status, resp)
+	}
+}
+
+// failWith writes the backend's client errors, and fallback for the rest
+func failWith(fallback *apperror.AppError) func(*Gateway, http.ResponseWriter, *http.Request, error) {
+	return func(g *Gateway, w http.ResponseWriter, r *http.Request, err error) {
+		g.respondError(w, r, upstreamError(err, fallback))
+	}
+}
+
+// respondLogin also starts a cookie session for browsers
+func respondLogin(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	if g.sessions != nil {
+		g.sessions.start(w, resp.(*authpb.AuthResponse).Token)
+	}
+	g.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// respondNotificationChannels writes the channels as a list even when there
+// are none
+func respondNotificationChannels(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	channels := resp.(*authpb.NotificationChannels).Channels
+	if channels == nil {
+		channels = []string{}
+	}
+	g.respondJSON(w, r, http.StatusOK, map[string][]string{"channels": channels})
+}
+
+func respondDeleteAccount(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	purgeAt := resp.(*authpb.DeleteAccountResponse).GetPurgeAt().AsTime()
+	g.respondJSON(w, r, http.StatusAccepted, map[string]time.Time{"purge_at": purgeAt})
+}
+
+// DeviceResponse is one entry of GET /auth/devices
+type DeviceResponse struct {
+	ID          string    `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+func respondDevices(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	list := resp.(*authpb.DeviceList)
+	devices := make([]DeviceResponse, 0, len(list.Devices))
+	for _, d := range list.Devices {
+		devices = append(devices, DeviceResponse{
+			ID:          d.Id,
+			UserAgent:   d.UserAgent,
+			LastIP:      d.LastIp,
+			FirstSeenAt: d.GetFirstSeenAt().AsTime(),
+			LastSeenAt:  d.GetLastSeenAt().AsTime(),
+		})
+	}
+	g.respondJSON(w, r, http.StatusOK, devices)
+}
+
+// CreateTransactionResponse is the created transaction with the user's
+// spending headroom flattened alongside its fields
+type CreateTransactionResponse struct {
+	*paymentpb.Transaction
+	CurrentTotal   float64 `json:"current_total"`
+	RemainingLimit float64 `json:"remaining_limit"`
+}
+
+func respondCreateTransaction(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	md, _ := runtime.ServerMetadataFromContext(r.Context())
+	g.respondJSON(w, r, http.StatusCreated, CreateTransactionResponse{
+		Transaction:    resp.(*paymentpb.Transaction),
+		CurrentTotal:   headerAmount(md.HeaderMD, "current-total"),
+		RemainingLimit: headerAmount(md.HeaderMD, "remaining-limit"),
+	})
+}
+
+// headerAmount reads an amount CreateTransaction reports in its response
+// headers, 0 when missing
+func headerAmount(header metadata.MD, key string) float64 {
+	values := header.Get(key)
+	if len(values) == 0 {
+		return 0
+	}
+	amount, _ := strconv.ParseFloat(values[0], 64)
+	return amount
+}
+
+// respondCreateTransactionError maps a CreateTransaction status to HTTP,
+// copying limit details from its ErrorInfo
+func (g *Gateway) respondCreateTransactionError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		g.respondError(w, r, upstreamError(err, apperror.ErrInternalServer.WithMessage("failed to create transaction")))
+		return
+	}
+
+	resp := g.errorResponse(w, r, errLimitExceeded)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			resp.CurrentTotal = info.Metadata["current_total"]
+			resp.MaxAllowed = info.Metadata["max_allowed"]
+			resp.RemainingLimit = info.Metadata["remaining_limit"]
+			resp.ResetsAt = info.Metadata["resets_at"]
+		}
+	}
+	g.respondJSON(w, r, errLimitExceeded.Status, resp)
+}
+
+// transactionsQuery maps the query parameters of /payment/transactions/list
+// to GetTransactionsRequest's
+func transactionsQuery(query url.Values) (url.Values, *apperror.AppError) {
+	req := url.Values{}
+	// ?fields=id,amount,is_paid returns only the listed transaction fields
+	if fields := query.Get("fields"); fields != "" {
+		req.Set("field_mask", fields)
+	}
+	switch query.Get("sort_by") {
+	case "":
+	case "created_at":
+		req.Set("sort_by", paymentpb.SortBy_SORT_BY_CREATED_AT.String())
+	case "amount":
+		req.Set("sort_by", paymentpb.SortBy_SORT_BY_AMOUNT.String())
+	default:
+		return nil, errInvalidSortBy
+	}
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		req.Set("order", paymentpb.SortOrder_SORT_ORDER_ASC.String())
+	case "desc":
+		req.Set("order", paymentpb.SortOrder_SORT_ORDER_DESC.String())
+	default:
+		return nil, errInvalidOrder
+	}
+	// ?limit=20 pages the list; ?cursor= continues from page.next_cursor
+	page, err := pagination.FromQuery(query)
+	if err != nil {
+		return nil, errInvalidLimit
+	}
+	if page != (pagination.PageRequest{}) {
+		page = page.Normalize(0)
+		req.Set("page.limit", strconv.Itoa(page.Limit))
+		if page.Cursor != "" {
+			req.Set("page.cursor", page.Cursor)
+		}
+	}
+	// ?include_archived=true also lists paid transactions moved to the archive
+	if v := query.Get("include_archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errInvalidArchived
+		}
+		req.Set("include_archived", strconv.FormatBool(archived))
+	}
+	return req, nil
+}
+
+// SummaryResponse is the JSON body of GET /payment/transactions/summary
+type SummaryResponse struct {
+	UnpaidTotal      float64 `json:"unpaid_total"`
+	PaidTotal        float64 `json:"paid_total"`
+	UnpaidCount      int64   `json:"unpaid_count"`
+	PaidCount        int64   `json:"paid_count"`
+	TransactionCount int64   `json:"transaction_count"`
+	PeriodTotal      float64 `json:"period_total"`
+	RemainingLimit   float64 `json:"remaining_limit"`
+}
+
+func respondSummary(g *Gateway, w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	summary := resp.(*paymentpb.Summary)
+	// Spelled out so zero totals and counts are still present in JSON
+	g.respondNegotiated(w, r, http.StatusOK, summary, SummaryResponse{
+		UnpaidTotal:      summary.UnpaidTotal,
+		PaidTotal:        summary.PaidTotal,
+		UnpaidCount:      summary.UnpaidCount,
+		PaidCount:        summary.PaidCount,
+		TransactionCount: summary.TransactionCount,
+		PeriodTotal:      summary.PeriodTotal,
+		RemainingLimit:   summary.RemainingLimit,
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// LimitsResponse is the JSON body of GET /me/limits: the caller's spending
+// limit for the current period and how close they are to it
+type LimitsResponse struct {
+	Limit       float64 `json:"limit"`
+	PeriodTotal float64 `json:"period_total"`
+	Remaining   float64 `json:"remaining"`
+	// Warnings are the warning thresholds reached, in percent of Limit, e.g.
+	// [80, 95]. Each was announced by a limit.approaching event when crossed.
+	Warnings []int `json:"warnings"`
+	// ResetsAt is when the period ends; absent for the lifetime period
+	ResetsAt time.Time `json:"resets_at,omitzero"`
+}
+
+// handleGetLimits serves GET /me/limits. Periods follow the timezone in the
+// caller's token, like the summary.
+func (g *Gateway) handleGetLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := g.paymentClient.GetSummary(paymentContext(ctx, r), &paymentpb.GetSummaryRequest{
+		UserId: int64(userID),
+	})
+	if err != nil {
+		g.logger.ErrorContext(r.Context(), "get limits failed", "error", err)
+		if status.Code(err) == codes.InvalidArgument {
+			g.respondError(w, r, errInvalidTimezone)
+			return
+		}
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get limits"))
+		return
+	}
+
+	limits := LimitsResponse{
+		Limit:       resp.MaxAllowed,
+		PeriodTotal: resp.PeriodTotal,
+		Remaining:   resp.RemainingLimit,
+		Warnings:    make([]int, 0, len(resp.LimitWarnings)),
+	}
+	for _, threshold := range resp.LimitWarnings {
+		limits.Warnings = append(limits.Warnings, int(math.Round(threshold*100)))
+	}
+	if resp.ResetsAt != nil {
+		limits.ResetsAt = resp.ResetsAt.AsTime()
+	}
+	g.respondJSON(w, r, http.StatusOK, limits)
+}
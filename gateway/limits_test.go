@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHandleGetLimits(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	getLimits := func() LimitsResponse {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/me/limits", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		g.handleGetLimits(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var limits LimitsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &limits); err != nil {
+			t.Fatal(err)
+		}
+		return limits
+	}
+
+	if limits := getLimits(); limits.Limit != 1000 || limits.Remaining != 1000 || limits.Warnings == nil || len(limits.Warnings) != 0 {
+		t.Errorf("expected the full limit and no warnings, got %+v", limits)
+	}
+
+	for _, body := range []string{`{"amount": 700}`, `{"amount": 260}`} {
+		if w := createTransaction(g, token, body, ""); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	limits := getLimits()
+	if limits.PeriodTotal != 960 || limits.Remaining != 40 || !slices.Equal(limits.Warnings, []int{80, 95}) {
+		t.Errorf("expected both warnings at 960, got %+v", limits)
+	}
+}
+
+func TestHandleGetLimits_Unauthorized(t *testing.T) {
+	g, _ := newTestGateway()
+	w := httptest.NewRecorder()
+	g.handleGetLimits(w, httptest.NewRequest(http.MethodGet, "/me/limits", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
@@ -2,18 +2,41 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/graph-gophers/graphql-go"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/i18n"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
 	"github.com/tkaewplik/go-microservices/pkg/middleware"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	"github.com/tkaewplik/go-microservices/pkg/requestid"
+	"github.com/tkaewplik/go-microservices/pkg/tracing"
 	authpb "github.com/tkaewplik/go-microservices/proto/auth"
 	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
 )
@@ -21,213 +44,373 @@ import (
 type Gateway struct {
 	authClient    authpb.AuthServiceClient
 	paymentClient paymentpb.PaymentServiceClient
+	httpClient    *http.Client
+	catalog       *i18n.Catalog
+	quota         *Quota
+	maintenance   atomic.Bool
+	adminToken    string
 	logger        *slog.Logger
+
+	// Reloadable configuration; see config.go
+	config      atomic.Pointer[Config]
+	loadConfig  func() (Config, error)
+	reloadMu    sync.Mutex
+	authPool    *connPool
+	paymentPool *connPool
+	canaries    map[string]*canaryRouter
+	policy      atomic.Pointer[policy]
+	access      atomic.Pointer[map[string]*middleware.IPFilter]
+	receipts    *Receipts
+	statements  *Statements
+	geo         GeoLocator
+	trusted     *TrustedIdentity
+	sessions    *Sessions
+	slow        *SlowRequests
+	rates       *RequestRates
+	rateLimiter *RateLimiter
+	// apiVersion is served when requests don't name one
+	apiVersion          string
+	jsonNamingOverrides map[string]JSONNaming
+	// rpcBackends serve gRPC-Web and Connect calls, by service name
+	rpcBackends map[string]grpc.ClientConnInterface
+	// graphql serves /graphql when enabled
+	graphql *graphql.Schema
+	// updates serves /ws when enabled
+	updates *TransactionUpdates
 }
 
-func NewGateway(authGRPCAddr, paymentGRPCAddr string, logger *slog.Logger) (*Gateway, error) {
-	// Connect to auth service gRPC
-	authConn, err := grpc.NewClient(authGRPCAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, err
-	}
+// GatewayOption configures NewGateway
+type GatewayOption func(*gatewayOptions)
 
-	// Connect to payment service gRPC
-	paymentConn, err := grpc.NewClient(paymentGRPCAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, err
+type gatewayOptions struct {
+	poolSize        int
+	paymentDialOpts []grpc.DialOption
+	receipts        *Receipts
+	statements      *Statements
+	geo             GeoLocator
+	apiVersion      string
+	jsonNaming      map[string]JSONNaming
+	hedger          *Hedger
+	trusted         *TrustedIdentity
+	sessions        *Sessions
+	slow            *SlowRequests
+	errorRateWindow time.Duration
+	rateLimiter     *RateLimiter
+	breakers        *Breakers
+	retrier         *Retrier
+	updates         *TransactionUpdates
+}
+
+// WithPoolSize sets how many connections are kept per backend
+func WithPoolSize(n int) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.poolSize = max(n, 1)
 	}
+}
 
-	return &Gateway{
-		authClient:    authpb.NewAuthServiceClient(authConn),
-		paymentClient: paymentpb.NewPaymentServiceClient(paymentConn),
-		logger:        logger,
-	}, nil
+// WithPaymentDialOptions adds dial options for the payment backend, such as
+// a mirroring interceptor
+func WithPaymentDialOptions(opts ...grpc.DialOption) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.paymentDialOpts = append(o.paymentDialOpts, opts...)
+	}
 }
 
-// Auth handlers
-func (g *Gateway) handleRegister(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		g.respondError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
+// NewGateway creates a Gateway for cfg. Backends are dialed through manual
+// resolvers so a reloaded config can point them elsewhere.
+func NewGateway(cfg Config, logger *slog.Logger, opts ...GatewayOption) (*Gateway, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	var req struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+	o := gatewayOptions{poolSize: DefaultPoolSize, errorRateWindow: DefaultErrorRateWindow}
+	for _, opt := range opts {
+		opt(&o)
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		g.respondError(w, http.StatusBadRequest, "invalid request body")
-		return
+	if err := checkAPIVersion(o.apiVersion); err != nil {
+		return nil, err
 	}
-
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	resp, err := g.authClient.Register(ctx, &authpb.RegisterRequest{
-		Username: req.Username,
-		Password: req.Password,
-	})
-	if err != nil {
-		g.logger.Error("register failed", "error", err)
-		g.respondError(w, http.StatusBadRequest, err.Error())
-		return
+	if err := checkJSONNaming(o.jsonNaming); err != nil {
+		return nil, err
 	}
 
-	g.respondJSON(w, http.StatusCreated, resp)
-}
-
-func (g *Gateway) handleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		g.respondError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
+	canaries := map[string]*canaryRouter{
+		"auth":    newCanaryRouter("auth"),
+		"payment": newCanaryRouter("payment"),
 	}
 
-	var req struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+	// Hedged calls go through the canary choice again on each attempt. Every
+	// call carries the request ID.
+	authDialOpts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor())}
+	paymentDialOpts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor())}
+	if o.slow != nil {
+		// Outermost, so a call's timing includes its hedges
+		authDialOpts = append(authDialOpts, grpc.WithChainUnaryInterceptor(o.slow.UnaryClientInterceptor()))
+		paymentDialOpts = append(paymentDialOpts, grpc.WithChainUnaryInterceptor(o.slow.UnaryClientInterceptor()))
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		g.respondError(w, http.StatusBadRequest, "invalid request body")
-		return
+	if o.breakers != nil {
+		// Ahead of hedging, so a call and its hedge count once
+		authDialOpts = append(authDialOpts, grpc.WithChainUnaryInterceptor(o.breakers.UnaryClientInterceptor("auth")))
+		paymentDialOpts = append(paymentDialOpts, grpc.WithChainUnaryInterceptor(o.breakers.UnaryClientInterceptor("payment")))
 	}
-
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	resp, err := g.authClient.Login(ctx, &authpb.LoginRequest{
-		Username: req.Username,
-		Password: req.Password,
-	})
-	if err != nil {
-		g.logger.Error("login failed", "error", err)
-		g.respondError(w, http.StatusUnauthorized, "invalid credentials")
-		return
+	if o.retrier != nil {
+		// Inside the breakers, so a call and its retries count once and a
+		// refused call isn't retried
+		authDialOpts = append(authDialOpts, grpc.WithChainUnaryInterceptor(o.retrier.UnaryClientInterceptor()))
+		paymentDialOpts = append(paymentDialOpts, grpc.WithChainUnaryInterceptor(o.retrier.UnaryClientInterceptor()))
+	}
+	paymentDialOpts = append(paymentDialOpts, o.paymentDialOpts...)
+	if o.hedger != nil {
+		authDialOpts = append(authDialOpts, grpc.WithChainUnaryInterceptor(o.hedger.UnaryClientInterceptor()))
+		paymentDialOpts = append(paymentDialOpts, grpc.WithChainUnaryInterceptor(o.hedger.UnaryClientInterceptor()))
 	}
 
-	g.respondJSON(w, http.StatusOK, resp)
-}
-
-// Payment handlers with auth validation
-func (g *Gateway) handleCreateTransaction(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		g.respondError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
+	// Connect to auth service gRPC
+	authDialOpts = append(authDialOpts, grpc.WithChainUnaryInterceptor(canaries["auth"].UnaryClientInterceptor()))
+	authPool, err := dialPool("auth", cfg.AuthGRPCAddr, o.poolSize, authDialOpts...)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate token
-	userID, err := g.validateAuth(r)
+	// Connect to payment service gRPC
+	paymentDialOpts = append(paymentDialOpts, grpc.WithChainUnaryInterceptor(canaries["payment"].UnaryClientInterceptor()))
+	paymentPool, err := dialPool("payment", cfg.PaymentGRPCAddr, o.poolSize, paymentDialOpts...)
 	if err != nil {
-		g.respondError(w, http.StatusUnauthorized, "unauthorized")
-		return
+		_ = authPool.Close()
+		return nil, err
 	}
 
-	var req struct {
-		Amount      float64 `json:"amount"`
-		Description string  `json:"description"`
+	g := &Gateway{
+		authClient:          authpb.NewAuthServiceClient(authPool),
+		paymentClient:       paymentpb.NewPaymentServiceClient(paymentPool),
+		authPool:            authPool,
+		paymentPool:         paymentPool,
+		canaries:            canaries,
+		httpClient:          &http.Client{Timeout: 5 * time.Second},
+		catalog:             i18n.Default(),
+		logger:              logger,
+		receipts:            o.receipts,
+		statements:          o.statements,
+		geo:                 o.geo,
+		trusted:             o.trusted,
+		sessions:            o.sessions,
+		slow:                o.slow,
+		rates:               NewRequestRates(o.errorRateWindow),
+		rateLimiter:         o.rateLimiter,
+		updates:             o.updates,
+		apiVersion:          o.apiVersion,
+		jsonNamingOverrides: o.jsonNaming,
+		rpcBackends: map[string]grpc.ClientConnInterface{
+			authServiceName:    authPool,
+			paymentServiceName: paymentPool,
+		},
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		g.respondError(w, http.StatusBadRequest, "invalid request body")
-		return
+	if o.slow != nil {
+		g.httpClient.Transport = o.slow.Transport(http.DefaultTransport)
 	}
+	if err := g.setCanaries(cfg.Canaries); err != nil {
+		_ = g.Close()
+		return nil, err
+	}
+	if err := g.setPolicy(cfg.Policies); err != nil {
+		_ = g.Close()
+		return nil, err
+	}
+	if err := g.setAccessLists(cfg.AccessLists); err != nil {
+		_ = g.Close()
+		return nil, err
+	}
+	g.config.Store(&cfg)
+	g.maintenance.Store(cfg.MaintenanceMode)
+	return g, nil
+}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	resp, err := g.paymentClient.CreateTransaction(ctx, &paymentpb.CreateTransactionRequest{
-		UserId:      int32(userID),
-		Amount:      req.Amount,
-		Description: req.Description,
-	})
+// dialBackend creates a client for addr whose address can later be changed
+// through the returned resolver. Its calls are traced, so the backends
+// continue the request's trace.
+func dialBackend(name, addr string, opts ...grpc.DialOption) (*grpc.ClientConn, *manual.Resolver, error) {
+	r := manual.NewBuilderWithScheme("gateway-" + name)
+	r.InitialState(backendState(addr))
+	opts = append([]grpc.DialOption{
+		grpc.WithResolvers(r),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(tracing.ClientHandler()),
+	}, opts...)
+	conn, err := grpc.NewClient(r.Scheme()+":///"+name, opts...)
 	if err != nil {
-		g.logger.Error("create transaction failed", "error", err)
-		g.respondError(w, http.StatusBadRequest, err.Error())
-		return
+		return nil, nil, err
 	}
+	return conn, r, nil
+}
 
-	g.respondJSON(w, http.StatusCreated, resp)
+func backendState(addr string) resolver.State {
+	return resolver.State{Addresses: []resolver.Address{{Addr: addr}}}
+}
+
+// Close closes the backend gRPC connections
+func (g *Gateway) Close() error {
+	var errs []error
+	for _, pool := range []*connPool{g.authPool, g.paymentPool} {
+		if pool == nil {
+			continue
+		}
+		if err := pool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, router := range g.canaries {
+		if err := router.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (g *Gateway) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
+// Analytics handlers
+func (g *Gateway) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		g.respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		g.respondError(w, r, errMethodNotAllowed)
 		return
 	}
 
-	userID, err := g.validateAuth(r)
-	if err != nil {
-		g.respondError(w, http.StatusUnauthorized, "unauthorized")
+	if _, err := g.validateAuth(r); err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	resp, err := g.paymentClient.GetTransactions(ctx, &paymentpb.GetTransactionsRequest{
-		UserId: int32(userID),
-	})
+	// facets selects per-channel and per-country breakdowns, include the
+	// optional views
+	statsURL := strings.TrimRight(g.currentConfig().AnalyticsURL, "/") + "/stats"
+	query := url.Values{}
+	for _, key := range []string{"facets", "include"} {
+		if v := r.URL.Query().Get(key); v != "" {
+			query.Set(key, v)
+		}
+	}
+	if len(query) > 0 {
+		statsURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, statsURL, nil)
 	if err != nil {
-		g.logger.Error("get transactions failed", "error", err)
-		g.respondError(w, http.StatusInternalServerError, "failed to get transactions")
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get stats"))
 		return
 	}
 
-	g.respondJSON(w, http.StatusOK, resp)
-}
-
-func (g *Gateway) handlePayTransactions(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		g.respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		g.logger.ErrorContext(r.Context(), "get stats failed", "error", err)
+		g.respondError(w, r, errStatsUnavailable)
 		return
 	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.ErrorContext(r.Context(), "failed to close stats response", "error", err)
+		}
+	}()
 
-	userID, err := g.validateAuth(r)
-	if err != nil {
-		g.respondError(w, http.StatusUnauthorized, "unauthorized")
+	if resp.StatusCode == http.StatusBadRequest {
+		g.respondError(w, r, errInvalidStatsQuery)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		g.logger.ErrorContext(r.Context(), "get stats failed", "status", resp.StatusCode)
+		g.respondError(w, r, errStatsUnavailable)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	var stats map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		g.logger.ErrorContext(r.Context(), "failed to decode stats", "error", err)
+		g.respondError(w, r, errStatsUnavailable)
+		return
+	}
 
-	resp, err := g.paymentClient.PayAllTransactions(ctx, &paymentpb.PayRequest{
-		UserId: int32(userID),
-	})
+	msg, err := structpb.NewStruct(stats)
 	if err != nil {
-		g.logger.Error("pay transactions failed", "error", err)
-		g.respondError(w, http.StatusInternalServerError, "failed to pay transactions")
+		g.logger.ErrorContext(r.Context(), "failed to convert stats", "error", err)
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get stats"))
 		return
 	}
 
-	g.respondJSON(w, http.StatusOK, resp)
+	g.respondNegotiated(w, r, http.StatusOK, msg, stats)
 }
 
 // validateAuth validates the JWT token via gRPC call to auth service
 func (g *Gateway) validateAuth(r *http.Request) (int, error) {
+	id, err := g.authenticate(r)
+	if err != nil {
+		return 0, err
+	}
+	return id.userID, nil
+}
+
+// authenticate returns the caller identified by r's bearer token, reusing
+// the identity an earlier check stored in r's context
+func (g *Gateway) authenticate(r *http.Request) (*identity, error) {
+	if id, ok := r.Context().Value(authenticatedUserKey{}).(*identity); ok {
+		return id, nil
+	}
+
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		return 0, ErrUnauthorized
+		return nil, ErrUnauthorized
 	}
 
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return 0, ErrUnauthorized
+		return nil, ErrUnauthorized
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
 
-	resp, err := g.authClient.ValidateToken(ctx, &authpb.ValidateTokenRequest{
+	resp, err := g.authClient.ValidateToken(grpcauth.WithClientIP(ctx, clientIP(r)), &authpb.ValidateTokenRequest{
 		Token: parts[1],
 	})
 	if err != nil || !resp.Valid {
-		return 0, ErrUnauthorized
+		return nil, ErrUnauthorized
+	}
+
+	// Later backend calls can now be routed by user
+	if info := routeInfoFrom(r.Context()); info != nil {
+		info.userID = int(resp.UserId)
+	}
+	return &identity{userID: int(resp.UserId), username: resp.Username, role: resp.Role, scopes: resp.Scopes}, nil
+}
+
+// clientIP returns the address of the connecting client, which the auth
+// service records when a token fails validation
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
+	return host
+}
 
-	return int(resp.UserId), nil
+// paymentContext forwards the caller's bearer token so the payment service
+// derives the user from verified claims instead of trusting user_id fields,
+// and the client channel for analytics. Identities from trusted headers are
+// forwarded with the service token instead.
+func paymentContext(ctx context.Context, r *http.Request) context.Context {
+	if fwd, ok := forwardedIdentity(ctx); ok {
+		return grpcauth.WithClientChannel(fwd, clientChannel(r))
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return grpcauth.WithClientChannel(grpcauth.WithBearerToken(ctx, token), clientChannel(r))
+}
+
+// channelHeader names the kind of client making a request
+const channelHeader = "X-Client-Channel"
+
+// clientChannel returns the channel the client declared in channelHeader,
+// "web" or "mobile", or "api" for anything else
+func clientChannel(r *http.Request) string {
+	switch channel := strings.ToLower(r.Header.Get(channelHeader)); channel {
+	case "web", "mobile":
+		return channel
+	}
+	return "api"
 }
 
 var ErrUnauthorized = &Error{Message: "unauthorized"}
@@ -240,42 +423,464 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
-func (g *Gateway) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+// respondJSON writes data as JSON, wrapped in an envelope for successful
+// responses when r's API version asks for one
+func (g *Gateway) respondJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	if status < http.StatusBadRequest {
+		if env, ok := envelopeFor(r, data); ok {
+			data = env
+		}
+		if naming := g.jsonNaming(r); naming != JSONNamingGo {
+			converted, _, err := protoJSON(data, naming)
+			if err != nil {
+				g.logger.Error("failed to encode response", "error", err)
+				g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to encode response"))
+				return
+			}
+			data = converted
+		}
+	}
+
+	// Transaction lists and auth responses skip reflection; see jsonenc.go
+	bp := jsonBufPool.Get().(*[]byte)
+	defer putJSONBuf(bp)
+	if b, ok := appendJSON((*bp)[:0], data); ok {
+		*bp = b
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		w.WriteHeader(status)
+		if _, err := w.Write(b); err != nil {
+			g.logger.Error("failed to write response", "error", err)
+		}
+		return
+	}
+
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		g.logger.Error("failed to encode response", "error", err)
 	}
 }
 
-func (g *Gateway) respondError(w http.ResponseWriter, status int, message string) {
-	g.respondJSON(w, status, map[string]string{"error": message})
+// ErrorResponse is the error envelope returned by the gateway. Code is stable
+// across languages; Error is localized from the Accept-Language header.
+type ErrorResponse struct {
+	Code           string               `json:"code"`
+	Error          string               `json:"error"`
+	CurrentTotal   string               `json:"current_total,omitempty"`
+	MaxAllowed     string               `json:"max_allowed,omitempty"`
+	RemainingLimit string               `json:"remaining_limit,omitempty"`
+	ResetsAt       string               `json:"resets_at,omitempty"`
+	Fields         []request.FieldError `json:"fields,omitempty"`
+}
+
+// errorResponse builds the envelope for appErr in the client's language and
+// sets the matching Content-Language header
+func (g *Gateway) errorResponse(w http.ResponseWriter, r *http.Request, appErr *apperror.AppError) ErrorResponse {
+	tag := g.catalog.Match(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", tag.String())
+	w.Header().Add("Vary", "Accept-Language")
+	return ErrorResponse{
+		Code:  appErr.Code,
+		Error: g.catalog.Message(tag, appErr.Code, appErr.Message),
+	}
+}
+
+func (g *Gateway) respondError(w http.ResponseWriter, r *http.Request, appErr *apperror.AppError) {
+	// A backend call refused by an open circuit breaker failed the request,
+	// whatever the handler made of the error
+	if info := routeInfoFrom(r.Context()); info != nil {
+		if wait := time.Duration(info.breakerWait.Load()); wait > 0 {
+			setRetryAfter(w, wait)
+			appErr = errBackendUnavailable
+		}
+	}
+	g.respondJSON(w, r, appErr.Status, g.errorResponse(w, r, appErr))
+}
+
+// respondDecodeError writes a 400 listing the malformed request fields, or a
+// 413 when the body is over the size limit
+func (g *Gateway) respondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := errInvalidBody
+	var decErr *request.DecodeError
+	if errors.As(err, &decErr) {
+		appErr = errInvalidBody.WithMessage(decErr.Message)
+		if errors.Is(err, request.ErrBodyTooLarge) {
+			appErr = errBodyTooLarge.WithMessage(decErr.Message)
+		}
+	}
+	resp := g.errorResponse(w, r, appErr)
+	if decErr != nil {
+		resp.Fields = decErr.Fields
+	}
+	g.respondJSON(w, r, appErr.Status, resp)
 }
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(requestid.NewLogHandler(geoLogHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})}))
 	slog.SetDefault(logger)
 
-	authGRPCAddr := getEnv("AUTH_GRPC_ADDR", "localhost:50051")
-	paymentGRPCAddr := getEnv("PAYMENT_GRPC_ADDR", "localhost:50052")
+	// OTEL_EXPORTER_OTLP_ENDPOINT exports traces of the requests served
+	shutdownTracing, err := tracing.Setup(context.Background(), "gateway")
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	// The environment is the base config; GATEWAY_CONFIG_FILE overlays it
+	// and is re-read on SIGHUP or POST /admin/reload
+	envConfig := Config{
+		AuthGRPCAddr:      getEnv("AUTH_GRPC_ADDR", "localhost:50051"),
+		PaymentGRPCAddr:   getEnv("PAYMENT_GRPC_ADDR", "localhost:50052"),
+		AnalyticsURL:      getEnv("ANALYTICS_URL", "http://localhost:8083"),
+		DailyRequestQuota: int64(getEnvInt("DAILY_REQUEST_QUOTA", 0)),
+		MaintenanceMode:   getEnv("MAINTENANCE_MODE", "false") == "true",
+		Policies:          defaultPolicies(),
+		AccessLists:       envAccessLists(),
+	}
+	cfg := envConfig
+	var loadConfig func() (Config, error)
+	if path := getEnv("GATEWAY_CONFIG_FILE", ""); path != "" {
+		loadConfig = func() (Config, error) { return loadConfigFile(path, envConfig) }
+		loaded, err := loadConfig()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		cfg = loaded
+	}
+
+	// PAYMENT_SHADOW_ADDR mirrors a sample of payment reads to a second
+	// payment service, e.g. a new build, and compares its answers
+	gatewayOpts := []GatewayOption{WithPoolSize(getEnvInt("GRPC_POOL_SIZE", DefaultPoolSize))}
+	var mirror *Mirror
+	if addr := getEnv("PAYMENT_SHADOW_ADDR", ""); addr != "" {
+		shadowConn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to create shadow payment client: %v", err)
+		}
+		defer func() {
+			if err := shadowConn.Close(); err != nil {
+				logger.Error("failed to close shadow payment connection", "error", err)
+			}
+		}()
+		mirror = NewMirror(shadowConn, []string{
+			paymentpb.PaymentService_GetTransactions_FullMethodName,
+			paymentpb.PaymentService_GetSummary_FullMethodName,
+		}, logger, WithMirrorSampleRate(getEnvFloat("PAYMENT_SHADOW_SAMPLE_RATE", DefaultMirrorSampleRate)))
+		gatewayOpts = append(gatewayOpts, WithPaymentDialOptions(grpc.WithChainUnaryInterceptor(mirror.UnaryClientInterceptor())))
+		logger.Info("mirroring payment reads", "shadow_addr", addr)
+	}
+
+	receipts, err := newReceiptsFromEnv(logger)
+	if err != nil {
+		log.Fatalf("Failed to set up receipt storage: %v", err)
+	}
+	gatewayOpts = append(gatewayOpts, WithReceipts(receipts))
+
+	// STATEMENTS_ENABLED serves the payment service's monthly statements
+	// from STATEMENT_STORE, which must be the store it writes to
+	if getEnv("STATEMENTS_ENABLED", "false") == "true" {
+		statements, err := newStatementsFromEnv(logger)
+		if err != nil {
+			log.Fatalf("Failed to set up statement storage: %v", err)
+		}
+		gatewayOpts = append(gatewayOpts, WithStatements(statements))
+	}
+
+	// GEOIP_COUNTRY_DB and GEOIP_ASN_DB are MaxMind .mmdb files; with either
+	// set, requests are tagged with the client's country and network
+	if countryDB, asnDB := getEnv("GEOIP_COUNTRY_DB", ""), getEnv("GEOIP_ASN_DB", ""); countryDB != "" || asnDB != "" {
+		locator, err := OpenMaxMind(countryDB, asnDB)
+		if err != nil {
+			log.Fatalf("Failed to open GeoIP databases: %v", err)
+		}
+		defer func() {
+			if err := locator.Close(); err != nil {
+				logger.Error("failed to close GeoIP databases", "error", err)
+			}
+		}()
+		gatewayOpts = append(gatewayOpts, WithGeoLocator(locator))
+		logger.Info("GeoIP enrichment enabled", "country_db", countryDB, "asn_db", asnDB)
+	}
+	// TRUSTED_IDENTITY_ENABLED takes the caller from identity headers set by
+	// a mesh or ingress in TRUSTED_IDENTITY_CIDRS, which must be listed
+	if getEnv("TRUSTED_IDENTITY_ENABLED", "false") == "true" {
+		peers, err := middleware.ParseCIDRs(getEnv("TRUSTED_IDENTITY_CIDRS", ""))
+		if err != nil {
+			log.Fatalf("Invalid TRUSTED_IDENTITY_CIDRS: %v", err)
+		}
+		trusted, err := NewTrustedIdentity(peers, getEnv("SERVICE_TOKEN", ""),
+			WithTrustedHeaders(TrustedHeaders{
+				UserID:   getEnv("TRUSTED_IDENTITY_USER_ID_HEADER", ""),
+				Username: getEnv("TRUSTED_IDENTITY_USERNAME_HEADER", ""),
+				Role:     getEnv("TRUSTED_IDENTITY_ROLE_HEADER", ""),
+				Scopes:   getEnv("TRUSTED_IDENTITY_SCOPES_HEADER", ""),
+			}),
+			WithClientCertURI(getEnv("TRUSTED_IDENTITY_CLIENT_CERT_URI", "")),
+		)
+		if err != nil {
+			log.Fatalf("Failed to configure trusted identity: %v", err)
+		}
+		gatewayOpts = append(gatewayOpts, WithTrustedIdentity(trusted))
+		logger.Warn("trusting forwarded identity headers", "cidrs", getEnv("TRUSTED_IDENTITY_CIDRS", ""))
+	}
+	// SESSION_COOKIES_ENABLED logs browsers in with an HttpOnly cookie
+	// holding the token, for first-party web apps
+	if getEnv("SESSION_COOKIES_ENABLED", "false") == "true" {
+		sameSite, err := ParseSameSite(getEnv("SESSION_COOKIE_SAMESITE", "lax"))
+		if err != nil {
+			log.Fatalf("Invalid SESSION_COOKIE_SAMESITE: %v", err)
+		}
+		sessionOpts := []SessionOption{
+			WithSessionCookieNames(getEnv("SESSION_COOKIE_NAME", ""), getEnv("SESSION_CSRF_COOKIE_NAME", "")),
+			WithSessionDomain(getEnv("SESSION_COOKIE_DOMAIN", "")),
+			WithSessionSameSite(sameSite),
+		}
+		if getEnv("SESSION_COOKIE_SECURE", "true") != "true" {
+			sessionOpts = append(sessionOpts, WithInsecureSessionCookies())
+		}
+		sessions, err := NewSessions(sessionOpts...)
+		if err != nil {
+			log.Fatalf("Failed to configure session cookies: %v", err)
+		}
+		gatewayOpts = append(gatewayOpts, WithSessions(sessions))
+	}
+	// SLOW_REQUEST_THRESHOLD flags requests over budget, logging them with
+	// their backend call timings; 0 turns it off
+	var slow *SlowRequests
+	if threshold := getEnvDuration("SLOW_REQUEST_THRESHOLD", DefaultSlowThreshold); threshold > 0 {
+		slow = NewSlowRequests(threshold, logger,
+			WithSlowSampleRate(getEnvFloat("TRACE_SAMPLE_RATE", 0)),
+			WithSlowKeep(getEnvInt("SLOW_REQUEST_KEEP", DefaultSlowKeep)))
+		gatewayOpts = append(gatewayOpts, WithSlowRequests(slow))
+	}
+	// HEDGING_ENABLED sends a second attempt of slow token checks and
+	// transaction listings once they pass their recent p95 latency
+	var hedger *Hedger
+	if getEnv("HEDGING_ENABLED", "false") == "true" {
+		hedger = NewHedger([]string{
+			authpb.AuthService_ValidateToken_FullMethodName,
+			paymentpb.PaymentService_GetTransactions_FullMethodName,
+		}, logger,
+			WithHedgeBudget(getEnvFloat("HEDGE_BUDGET", DefaultHedgeBudget)),
+			WithHedgeMinDelay(getEnvDuration("HEDGE_MIN_DELAY", DefaultHedgeMinDelay)))
+		gatewayOpts = append(gatewayOpts, WithHedger(hedger))
+		logger.Info("hedging backend reads", "budget", getEnvFloat("HEDGE_BUDGET", DefaultHedgeBudget))
+	}
+	// RETRY_ATTEMPTS tries read-only backend calls that fail with
+	// Unavailable or DeadlineExceeded up to that many times in all; 1 turns
+	// retries off
+	var retrier *Retrier
+	if attempts := getEnvInt("RETRY_ATTEMPTS", DefaultRetryAttempts); attempts > 1 {
+		retrier = NewRetrier([]string{
+			authpb.AuthService_ValidateToken_FullMethodName,
+			authpb.AuthService_ListDevices_FullMethodName,
+			authpb.AuthService_GetInviteCode_FullMethodName,
+			authpb.AuthService_ListInviteCodes_FullMethodName,
+			authpb.AuthService_ListUsers_FullMethodName,
+			authpb.AuthService_GetNotificationChannels_FullMethodName,
+			paymentpb.PaymentService_GetTransactions_FullMethodName,
+			paymentpb.PaymentService_GetSummary_FullMethodName,
+			paymentpb.PaymentService_GetReceipt_FullMethodName,
+			paymentpb.PaymentService_GetStatement_FullMethodName,
+		}, logger,
+			WithRetryAttempts(attempts),
+			WithRetryBackoff(getEnvDuration("RETRY_BASE_DELAY", DefaultRetryBaseDelay), getEnvDuration("RETRY_MAX_DELAY", DefaultRetryMaxDelay)))
+		gatewayOpts = append(gatewayOpts, WithRetrier(retrier))
+	}
+	// Clients choose a version with X-API-Version; version 2 envelopes
+	// responses and version 3 also writes proto messages with protojson,
+	// snake_case unless API_V3_JSON_NAMING=camel
+	gatewayOpts = append(gatewayOpts, WithDefaultAPIVersion(getEnv("DEFAULT_API_VERSION", APIVersion1)))
+	if value := getEnv("API_V3_JSON_NAMING", ""); value != "" {
+		naming, err := ParseJSONNaming(value)
+		if err != nil {
+			logger.Error("invalid API_V3_JSON_NAMING", "error", err)
+			os.Exit(1)
+		}
+		gatewayOpts = append(gatewayOpts, WithJSONNaming(APIVersion3, naming))
+	}
+
+	// CIRCUIT_BREAKER_FAILURES failed calls in a row to a backend make the
+	// gateway refuse calls to it for CIRCUIT_BREAKER_COOLDOWN; 0 turns it off
+	var breakers *Breakers
+	if failures := getEnvInt("CIRCUIT_BREAKER_FAILURES", DefaultBreakerFailures); failures > 0 {
+		breakers = NewBreakers(failures, getEnvDuration("CIRCUIT_BREAKER_COOLDOWN", DefaultBreakerCooldown), logger)
+		gatewayOpts = append(gatewayOpts, WithBreakers(breakers))
+	}
+
+	// ERROR_RATE_WINDOW is how far back /admin/overview counts responses
+	gatewayOpts = append(gatewayOpts, WithErrorRateWindow(getEnvDuration("ERROR_RATE_WINDOW", DefaultErrorRateWindow)))
+
+	// RATE_LIMIT_PER_IP and RATE_LIMIT_GLOBAL cap requests per second from
+	// one client address and from all clients, before any backend is called
+	perIP := RateLimit{Rate: getEnvFloat("RATE_LIMIT_PER_IP", 0), Burst: getEnvInt("RATE_LIMIT_PER_IP_BURST", DefaultRateLimitBurst)}
+	global := RateLimit{Rate: getEnvFloat("RATE_LIMIT_GLOBAL", 0), Burst: getEnvInt("RATE_LIMIT_GLOBAL_BURST", DefaultRateLimitBurst)}
+	if perIP.Enabled() || global.Enabled() {
+		gatewayOpts = append(gatewayOpts, WithRateLimiter(NewRateLimiter(perIP, global)))
+		logger.Info("rate limiting requests", "per_ip", perIP.Rate, "per_ip_burst", perIP.Burst,
+			"global", global.Rate, "global_burst", global.Burst)
+	}
+
+	// WS_ENABLED pushes users' transaction events from KAFKA_TOPIC, or the
+	// KAFKA_TOPICS list when KAFKA_TOPIC_ROUTES sends some elsewhere, to their
+	// /ws streams. Every instance consumes every event, so the consumer group
+	// is this instance's own, and it starts at the end of the topic.
+	var (
+		updates         *TransactionUpdates
+		updatesConsumer *messaging.KafkaConsumer
+	)
+	if getEnv("WS_ENABLED", "false") == "true" {
+		updates = NewTransactionUpdates(logger,
+			WithUpdateOrigins(splitList(getEnv("WS_ALLOWED_ORIGINS", ""))...),
+			WithUpdateConnsPerUser(getEnvInt("WS_MAX_CONNS_PER_USER", DefaultUpdateConnsPerUser)))
+		hostname, _ := os.Hostname()
+		updatesConsumer = messaging.NewKafkaTailConsumer(
+			messaging.KafkaConfig{Brokers: splitList(getEnv("KAFKA_BROKERS", "localhost:9092"))},
+			splitList(getEnv("KAFKA_TOPICS", getEnv("KAFKA_TOPIC", messaging.TopicTransactions))),
+			getEnv("WS_KAFKA_GROUP", "gateway-ws-"+hostname), logger)
+		gatewayOpts = append(gatewayOpts, WithTransactionUpdates(updates))
+	}
 
-	gateway, err := NewGateway(authGRPCAddr, paymentGRPCAddr, logger)
+	gateway, err := NewGateway(cfg, logger, gatewayOpts...)
 	if err != nil {
 		log.Fatalf("Failed to create gateway: %v", err)
 	}
+	gateway.loadConfig = loadConfig
+
+	// DAILY_REQUEST_QUOTA limits authenticated requests per user per UTC day.
+	// Counters live in Redis when REDIS_ADDR is set, otherwise in process.
+	if cfg.DailyRequestQuota > 0 {
+		var store QuotaStore = NewMemoryQuotaStore()
+		if addr := getEnv("REDIS_ADDR", ""); addr != "" {
+			rdb := redis.NewClient(&redis.Options{Addr: addr})
+			defer func() {
+				if err := rdb.Close(); err != nil {
+					logger.Error("failed to close Redis client", "error", err)
+				}
+			}()
+			store = NewRedisQuotaStore(rdb)
+		} else {
+			logger.Warn("REDIS_ADDR not set; request quotas are counted per gateway instance")
+		}
+		gateway.quota = NewQuota(store, cfg.DailyRequestQuota)
+		logger.Info("request quotas enabled", "daily_limit", cfg.DailyRequestQuota)
+	}
+
+	// ADMIN_TOKEN enables the /admin routes
+	gateway.adminToken = getEnv("ADMIN_TOKEN", "")
+	if gateway.maintenance.Load() {
+		logger.Warn("starting in maintenance mode; write requests are refused")
+	}
 
 	mux := http.NewServeMux()
 
+	// REST routes generated from the protos' google.api.http annotations.
+	// The routes outside /v1/ are their additional bindings, kept for
+	// existing clients. Login stays open in maintenance mode.
+	rest, err := gateway.newRESTHandler(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to register REST routes: %v", err)
+	}
+	mux.HandleFunc(restPrefix, gateway.writable(gateway.metered(gateway.handleREST(rest))))
+	mux.HandleFunc("POST "+restPrefix+"auth/login", gateway.handleREST(rest))
+	legacyREST, err := gateway.newLegacyRESTHandler(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to register REST routes: %v", err)
+	}
+	legacy := gateway.handleLegacyREST(legacyREST)
+
 	// Auth routes
-	mux.HandleFunc("/auth/register", gateway.handleRegister)
-	mux.HandleFunc("/auth/login", gateway.handleLogin)
+	mux.HandleFunc("/auth/register", gateway.writable(legacy))
+	mux.HandleFunc("/auth/login", legacy)
+	if gateway.sessions != nil {
+		mux.HandleFunc(logoutPath, gateway.handleLogout)
+	}
+	mux.HandleFunc("/auth/preferences", gateway.writable(gateway.metered(legacy)))
+	mux.HandleFunc("/auth/notifications", gateway.writable(gateway.metered(legacy)))
+	mux.HandleFunc("/auth/account", gateway.writable(gateway.metered(legacy)))
+	mux.HandleFunc("/auth/devices", gateway.metered(legacy))
+	mux.HandleFunc("/auth/account/cancel-deletion", gateway.writable(gateway.metered(legacy)))
 
 	// Payment routes
-	mux.HandleFunc("/payment/transactions", gateway.handleCreateTransaction)
-	mux.HandleFunc("/payment/transactions/list", gateway.handleGetTransactions)
-	mux.HandleFunc("/payment/transactions/pay", gateway.handlePayTransactions)
+	mux.HandleFunc("/payment/transactions", gateway.writable(gateway.metered(legacy)))
+	mux.HandleFunc("/payment/transactions/list", gateway.metered(legacy))
+	mux.HandleFunc("/payment/transactions/summary", gateway.metered(legacy))
+	mux.HandleFunc("/payment/transactions/pay", gateway.writable(gateway.metered(legacy)))
+	mux.HandleFunc(receiptPath, gateway.writable(gateway.metered(gateway.handleReceipt)))
+	mux.HandleFunc(receiptDownloadPath, gateway.handleDownloadReceipt)
+	mux.HandleFunc(statementPath, gateway.writable(gateway.metered(gateway.handleStatement)))
+	mux.HandleFunc(statementDownloadPath, gateway.handleDownloadStatement)
+
+	// Analytics routes
+	mux.HandleFunc("/analytics/stats", gateway.metered(gateway.handleGetStats))
+
+	// Mobile BFF routes: composite, trimmed responses for the mobile app
+	mux.HandleFunc("/mobile/v1/login", gateway.handleMobileLogin)
+	mux.HandleFunc("/mobile/v1/dashboard", gateway.metered(gateway.handleMobileDashboard))
+	mux.HandleFunc("/mobile/v1/transactions", gateway.metered(gateway.handleMobileTransactions))
+
+	// gRPC-Web and Connect routes for browser clients generated from the protos
+	if getEnv("GRPC_WEB_ENABLED", "false") == "true" {
+		mux.HandleFunc(authServicePath, gateway.metered(gateway.handleRPC))
+		mux.HandleFunc(paymentServicePath, gateway.metered(gateway.handleRPC))
+	}
+
+	// GraphQL over the auth and payment services, for clients that want
+	// combined data in one round trip
+	if getEnv("GRAPHQL_ENABLED", "false") == "true" {
+		if gateway.graphql, err = newGraphQLSchema(gateway); err != nil {
+			log.Fatalf("Failed to parse GraphQL schema: %v", err)
+		}
+		mux.HandleFunc(graphqlPath, gateway.metered(gateway.handleGraphQL))
+	}
+
+	// Live transaction updates; a stream is metered once, when it opens
+	if updates != nil {
+		mux.HandleFunc(updatesPath, gateway.metered(gateway.handleUpdates))
+	}
+
+	// Quota of the calling user; reading it doesn't use any
+	mux.HandleFunc("/me/quota", gateway.handleGetQuota)
+	mux.HandleFunc("/me/alerts", gateway.handleAlerts)
+	mux.HandleFunc("/me/limits", gateway.metered(gateway.handleGetLimits))
+
+	// Ops dashboard data for admin users; unlike the routes below it takes
+	// an admin's bearer token rather than the admin token
+	mux.HandleFunc("/admin/overview", gateway.restricted(accessAdmin, gateway.handleAdminOverview))
+
+	// Admin routes
+	if gateway.adminToken != "" {
+		mux.HandleFunc("/admin/maintenance", gateway.restricted(accessAdmin, gateway.adminOnly(gateway.handleMaintenance)))
+		mux.HandleFunc("/admin/reload", gateway.restricted(accessAdmin, gateway.adminOnly(gateway.handleReload)))
+		if mirror != nil {
+			mux.HandleFunc("/admin/mirror", gateway.restricted(accessAdmin, gateway.adminOnly(mirror.ServeHTTP)))
+		}
+		if hedger != nil {
+			mux.HandleFunc("/admin/hedging", gateway.restricted(accessAdmin, gateway.adminOnly(hedger.ServeHTTP)))
+		}
+		if retrier != nil {
+			mux.HandleFunc("/admin/retries", gateway.restricted(accessAdmin, gateway.adminOnly(retrier.ServeHTTP)))
+		}
+		if slow != nil {
+			mux.HandleFunc("/admin/slow-requests", gateway.restricted(accessAdmin, gateway.adminOnly(slow.ServeHTTP)))
+		}
+		if breakers != nil {
+			mux.HandleFunc("/admin/breakers", gateway.restricted(accessAdmin, gateway.adminOnly(breakers.ServeHTTP)))
+		}
+	}
+
+	// PPROF_ENABLED serves Go's profiler to the debug access list
+	if getEnv("PPROF_ENABLED", "false") == "true" {
+		mux.HandleFunc("/debug/pprof/", gateway.restricted(accessDebug, pprof.Index))
+		mux.HandleFunc("/debug/pprof/cmdline", gateway.restricted(accessDebug, pprof.Cmdline))
+		mux.HandleFunc("/debug/pprof/profile", gateway.restricted(accessDebug, pprof.Profile))
+		mux.HandleFunc("/debug/pprof/symbol", gateway.restricted(accessDebug, pprof.Symbol))
+		mux.HandleFunc("/debug/pprof/trace", gateway.restricted(accessDebug, pprof.Trace))
+	}
+
+	// Feature flags from the gateway config, for clients
+	mux.HandleFunc("/features", gateway.handleGetFeatures)
 
 	// Health check
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -284,15 +889,180 @@ func main() {
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
 
-	handler := middleware.CORS(mux)
+	// Optional dashboard hosting: STATIC_DIR wins over an embedded build
+	staticFS := embeddedSPA()
+	if dir := getEnv("STATIC_DIR", ""); dir != "" {
+		staticFS = os.DirFS(dir)
+	}
+	if staticFS != nil {
+		mux.Handle("/", newSPAHandler(staticFS, gateway))
+		logger.Info("serving SPA", "static_dir", getEnv("STATIC_DIR", "embedded"))
+	}
+
+	// FAULT_INJECTION_ENABLED delays and fails a fraction of requests so
+	// client retries and breakers can be tested; never enable in production
+	faultCfg := faultConfigFromEnv()
+	if faultCfg.Enabled() {
+		logger.Warn("fault injection enabled", "latency_rate", faultCfg.LatencyRate,
+			"max_latency", faultCfg.MaxLatency, "error_rate", faultCfg.ErrorRate)
+	}
+	faults := middleware.Faults(faultCfg)
+
+	request.MaxDepth = getEnvInt("MAX_JSON_DEPTH", request.MaxDepth)
+	handler := middleware.CORS(gateway.withRequestMeta(gateway.withSessions(gateway.withRequestRates(gateway.withRateLimit(middleware.MaxBodyBytes(int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		middleware.WithPathLimit(receiptPath, receipts.maxBytes))(withRouteInfo(gateway.withSlowRequests(gateway.withGeo(gateway.withTrustedIdentity(gateway.authorize(faults(tracing.Routed(mux)))))))))))))
+	// Outermost, so a request's span covers all of the middleware
+	handler = tracing.HTTPHandler(handler, "gateway")
 
 	port := getEnv("PORT", "8080")
+	server, err := newHTTPServer(ServerConfig{
+		Addr:              ":" + port,
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+		MaxHeaderBytes:    getEnvInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		TLSCertFile:       os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:        os.Getenv("TLS_KEY_FILE"),
+	}, handler)
+	if err != nil {
+		log.Fatalf("Failed to configure HTTP server: %v", err)
+	}
+
 	logger.Info("API Gateway starting",
 		"port", port,
-		"auth_grpc", authGRPCAddr,
-		"payment_grpc", paymentGRPCAddr,
+		"tls", server.TLSConfig != nil,
+		"auth_grpc", cfg.AuthGRPCAddr,
+		"payment_grpc", cfg.PaymentGRPCAddr,
+		"analytics_url", cfg.AnalyticsURL,
+		"config_file", getEnv("GATEWAY_CONFIG_FILE", ""),
 	)
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if updates != nil {
+		// Shutdown doesn't wait for, or close, hijacked connections
+		server.RegisterOnShutdown(updates.Close)
+		go func() {
+			err := updatesConsumer.Consume(ctx, func(_, value []byte) error {
+				return updates.HandleEvent(value)
+			})
+			if err != nil {
+				logger.Error("transaction updates consumer stopped", "error", err)
+			}
+		}()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			_ = gateway.reloadConfig()
+		}
+	}()
+
+	shutdownTimeout := getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err := runServer(ctx, server, shutdownTimeout, logger); err != nil {
+		logger.Error("HTTP server failed", "error", err)
+	}
+
+	if mirror != nil {
+		mirror.Close()
+	}
+	if updatesConsumer != nil {
+		if err := updatesConsumer.Close(); err != nil {
+			logger.Error("failed to close transaction updates consumer", "error", err)
+		}
+	}
+	if err := gateway.Close(); err != nil {
+		logger.Error("failed to close gRPC connections", "error", err)
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+	cancel()
+	logger.Info("API Gateway stopped")
+}
+
+// newReceiptsFromEnv builds receipt storage from RECEIPT_STORE ("local" or
+// "s3") and its settings
+func newReceiptsFromEnv(logger *slog.Logger) (*Receipts, error) {
+	store, err := blob.New(blob.Config{
+		Kind: getEnv("RECEIPT_STORE", blob.KindLocal),
+		Dir:  getEnv("RECEIPT_DIR", "data/receipts"),
+		S3:   s3ConfigFromEnv(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secret := []byte(getEnv("RECEIPT_URL_SECRET", ""))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		logger.Warn("RECEIPT_URL_SECRET not set; receipt links only work on this instance until it restarts")
+	}
+
+	return NewReceipts(store, secret,
+		getEnvDuration("RECEIPT_URL_TTL", DefaultReceiptURLTTL),
+		int64(getEnvInt("RECEIPT_MAX_BYTES", DefaultReceiptMaxBytes))), nil
+}
+
+// newStatementsFromEnv reads statements from STATEMENT_STORE ("local" or
+// "s3") and its settings
+func newStatementsFromEnv(logger *slog.Logger) (*Statements, error) {
+	store, err := blob.New(blob.Config{
+		Kind: getEnv("STATEMENT_STORE", blob.KindLocal),
+		Dir:  getEnv("STATEMENT_DIR", "data/statements"),
+		S3:   s3ConfigFromEnv(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secret := []byte(getEnv("STATEMENT_URL_SECRET", ""))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		logger.Warn("STATEMENT_URL_SECRET not set; statement links only work on this instance until it restarts")
+	}
+
+	return NewStatements(store, secret, getEnvDuration("STATEMENT_URL_TTL", DefaultStatementURLTTL)), nil
+}
+
+// s3ConfigFromEnv reads the bucket settings shared by every S3-backed store
+func s3ConfigFromEnv() blob.S3Config {
+	return blob.S3Config{
+		Endpoint:  getEnv("S3_ENDPOINT", "s3.amazonaws.com"),
+		Region:    getEnv("S3_REGION", ""),
+		Bucket:    getEnv("S3_BUCKET", ""),
+		AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		SecretKey: getEnv("S3_SECRET_KEY", ""),
+		UseSSL:    getEnv("S3_USE_SSL", "true") == "true",
+	}
+}
+
+// faultConfigFromEnv reads the FAULT_* variables, injecting nothing unless
+// FAULT_INJECTION_ENABLED is true. Health checks and admin routes are
+// never faulted.
+func faultConfigFromEnv() middleware.FaultConfig {
+	if getEnv("FAULT_INJECTION_ENABLED", "false") != "true" {
+		return middleware.FaultConfig{}
+	}
+	return middleware.FaultConfig{
+		LatencyRate: getEnvFloat("FAULT_LATENCY_RATE", 0),
+		MaxLatency:  getEnvDuration("FAULT_LATENCY_MAX", 0),
+		ErrorRate:   getEnvFloat("FAULT_ERROR_RATE", 0),
+		ErrorStatus: getEnvInt("FAULT_ERROR_STATUS", http.StatusServiceUnavailable),
+		SkipPaths:   []string{"/health", "/admin/"},
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -301,3 +1071,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
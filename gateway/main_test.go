@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/i18n"
+	"github.com/tkaewplik/go-microservices/pkg/testutil"
+)
+
+func newTestGateway() (*Gateway, *testutil.FakeAuthClient) {
+	auth := testutil.NewFakeAuthClient("test-secret")
+	payments := testutil.NewFakePaymentClient()
+	payments.Secret = auth.Secret
+	return &Gateway{
+		authClient:    auth,
+		paymentClient: payments,
+		catalog:       i18n.Default(),
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, auth
+}
+
+// mustLegacyHandler serves g's routes outside /v1/
+func mustLegacyHandler(g *Gateway) http.HandlerFunc {
+	legacy, err := g.newLegacyRESTHandler(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return g.handleLegacyREST(legacy)
+}
+
+func createTransaction(g *Gateway, token, body, lang string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/payment/transactions", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	if lang != "" {
+		r.Header.Set("Accept-Language", lang)
+	}
+	w := httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	return w
+}
+
+func TestHandleCreateTransaction(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	w := createTransaction(g, token, `{"amount":900,"description":"laptop"}`, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var created CreateTransactionResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.CurrentTotal != 900 || created.RemainingLimit != 100 {
+		t.Errorf("unexpected headroom: %+v", created)
+	}
+}
+
+func TestHandlePayTransactions_ChargeFailed(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	g.paymentClient.(*testutil.FakePaymentClient).Err = status.Error(codes.FailedPrecondition, "charge failed; transactions were left unpaid")
+
+	r := httptest.NewRequest(http.MethodPost, "/payment/transactions/pay", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected 402, got %d: %s", w.Code, w.Body)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != apperror.CodeChargeFailed {
+		t.Errorf("expected code %s, got %s", apperror.CodeChargeFailed, resp.Code)
+	}
+}
+
+func TestHandleCreateTransaction_LimitExceededLocalized(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	createTransaction(g, token, `{"amount":900}`, "")
+	w := createTransaction(g, token, `{"amount":200}`, "th-TH")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Language"); got != "th" {
+		t.Errorf("expected Content-Language th, got %q", got)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != apperror.CodeLimitExceeded {
+		t.Errorf("expected code %s, got %s", apperror.CodeLimitExceeded, resp.Code)
+	}
+	if resp.Error == errLimitExceeded.Message {
+		t.Error("expected a Thai message")
+	}
+	if resp.RemainingLimit != "100.00" {
+		t.Errorf("expected remaining_limit 100.00, got %q", resp.RemainingLimit)
+	}
+}
+
+func TestHandleCreateTransaction_Unauthorized(t *testing.T) {
+	g, _ := newTestGateway()
+
+	w := createTransaction(g, "forged", `{"amount":10}`, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestClientChannel(t *testing.T) {
+	for header, want := range map[string]string{"web": "web", "Mobile": "mobile", "": "api", "tv": "api"} {
+		r := httptest.NewRequest(http.MethodGet, "/payment/transactions/list", nil)
+		r.Header.Set(channelHeader, header)
+		if got := clientChannel(r); got != want {
+			t.Errorf("%q: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+// FuzzHandlerBodies feeds arbitrary bodies to the JSON handlers. Malformed
+// input must be answered with a client error, never a panic or a 5xx.
+func FuzzHandlerBodies(f *testing.F) {
+	f.Add(`{"amount":10,"description":"coffee"}`)
+	f.Add(`{"amount":"ten"}`)
+	f.Add(`{"amount":1e309}`)
+	f.Add(`{"amount":-1}`)
+	f.Add(`{"username":"alice","password":"pw","timezone":"Mars/Olympus"}`)
+	f.Add(`{}}`)
+	f.Add(strings.Repeat(`{"a":`, 64))
+	f.Add("")
+
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	ok := map[int]bool{
+		http.StatusCreated:               true,
+		http.StatusBadRequest:            true,
+		http.StatusConflict:              true,
+		http.StatusRequestEntityTooLarge: true,
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		if w := createTransaction(g, token, body, ""); !ok[w.Code] {
+			t.Errorf("create transaction: unexpected status %d for %q: %s", w.Code, body, w.Body)
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mustLegacyHandler(g)(w, r)
+		if !ok[w.Code] {
+			t.Errorf("register: unexpected status %d for %q: %s", w.Code, body, w.Body)
+		}
+	})
+}
+
+func TestHandleDeleteAccount(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	legacy := mustLegacyHandler(g)
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		legacy(w, r)
+		return w
+	}
+
+	if w := call(http.MethodDelete, "/auth/account", `{"password":"wrong"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong password, got %d: %s", w.Code, w.Body)
+	}
+	w := call(http.MethodDelete, "/auth/account", `{"password":"pw"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body)
+	}
+	var scheduled struct {
+		PurgeAt time.Time `json:"purge_at"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&scheduled); err != nil || !scheduled.PurgeAt.After(time.Now()) {
+		t.Errorf("expected a future purge_at, got %+v, %v", scheduled, err)
+	}
+
+	if w := call(http.MethodPost, "/auth/account/cancel-deletion", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body)
+	}
+	if w := call(http.MethodPost, "/auth/account/cancel-deletion", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 with nothing to cancel, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestHandleListDevices(t *testing.T) {
+	g, auth := newTestGateway()
+	auth.AddUser("alice", "pw")
+
+	var token string
+	for _, ua := range []string{"Firefox", "Safari", "Firefox"} {
+		r := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"pw"}`))
+		r.Header.Set("User-Agent", ua)
+		w := httptest.NewRecorder()
+		mustLegacyHandler(g)(w, r)
+		var resp struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Token == "" {
+			t.Fatalf("expected a token, got %d: %v", w.Code, err)
+		}
+		token = resp.Token
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/devices", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var devices []DeviceResponse
+	if err := json.NewDecoder(w.Body).Decode(&devices); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(devices) != 2 || devices[0].UserAgent != "Firefox" || devices[1].UserAgent != "Safari" {
+		t.Errorf("expected the two user agents as devices, got %+v", devices)
+	}
+}
+
+func TestHandleRegisterAndLogin_Forms(t *testing.T) {
+	g, _ := newTestGateway()
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader("username=alice&password=pw&email=alice%40example.com"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for a form registration, got %d: %s", w.Code, w.Body)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("username", "alice")
+	_ = mw.WriteField("password", "pw")
+	_ = mw.Close()
+	r = httptest.NewRequest(http.MethodPost, "/auth/login", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("expected a token for a multipart login, got %d: %v", w.Code, err)
+	}
+
+	// Form fields are checked like JSON ones
+	r = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader("username=alice&password=pw&remember=1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "remember") {
+		t.Errorf("expected 400 naming the unknown field, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestHandleNotificationChannels(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	call := func(method, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/auth/notifications", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mustLegacyHandler(g)(w, r)
+		return w
+	}
+	channels := func(w *httptest.ResponseRecorder) []string {
+		var resp struct {
+			Channels []string `json:"channels"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp.Channels
+	}
+
+	w := call(http.MethodGet, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if got := channels(w); len(got) != 1 || got[0] != "email" {
+		t.Errorf("expected email by default, got %v", got)
+	}
+
+	w = call(http.MethodPut, `{"channels":["sms","push"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if got := channels(call(http.MethodGet, "")); len(got) != 2 || got[0] != "sms" || got[1] != "push" {
+		t.Errorf("expected sms and push, got %v", got)
+	}
+
+	if w := call(http.MethodPut, `{"channels":`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed body, got %d: %s", w.Code, w.Body)
+	}
+}
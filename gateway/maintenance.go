@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+)
+
+// adminTokenHeader carries ADMIN_TOKEN on /admin/* requests
+const adminTokenHeader = "X-Admin-Token"
+
+// maintenanceStatus is the body of /admin/maintenance requests and responses
+type maintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// writable rejects write requests with 503 while the gateway is in
+// maintenance mode. Reads pass through so clients keep working during
+// planned database migrations.
+func (g *Gateway) writable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.maintenance.Load() && !isReadMethod(r.Method) {
+			g.respondError(w, r, errMaintenance)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// adminOnly requires the X-Admin-Token header to match the gateway's admin
+// token. Without a configured token every request is refused.
+func (g *Gateway) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(adminTokenHeader)
+		if g.adminToken == "" || subtle.ConstantTimeCompare([]byte(got), []byte(g.adminToken)) != 1 {
+			g.respondError(w, r, apperror.ErrUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleMaintenance reports maintenance mode on GET and switches it on PUT
+func (g *Gateway) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req maintenanceStatus
+		if err := request.DecodeJSON(r, &req); err != nil {
+			g.respondDecodeError(w, r, err)
+			return
+		}
+		if g.maintenance.Swap(req.Enabled) != req.Enabled {
+			g.logger.Warn("maintenance mode changed", "enabled", req.Enabled, "remote_addr", r.RemoteAddr)
+		}
+	default:
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	g.respondJSON(w, r, http.StatusOK, maintenanceStatus{Enabled: g.maintenance.Load()})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWritable_MaintenanceBlocksWritesOnly(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	g.maintenance.Store(true)
+
+	r := httptest.NewRequest(http.MethodPost, "/payment/transactions", strings.NewReader(`{"amount":10}`))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.writable(mustLegacyHandler(g))(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"MAINTENANCE"`) {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/payment/transactions/summary", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	g.writable(mustLegacyHandler(g))(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected reads to be served, got %d", w.Code)
+	}
+
+	g.maintenance.Store(false)
+	if w := createTransaction(g, token, `{"amount":10}`, ""); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"id":1`) {
+		t.Errorf("expected the blocked write not to reach the backend, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func maintenanceRequest(g *Gateway, method, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/admin/maintenance", strings.NewReader(body))
+	if token != "" {
+		r.Header.Set(adminTokenHeader, token)
+	}
+	w := httptest.NewRecorder()
+	g.adminOnly(g.handleMaintenance)(w, r)
+	return w
+}
+
+func TestHandleMaintenance(t *testing.T) {
+	g, _ := newTestGateway()
+	g.adminToken = "admin-secret"
+
+	for _, token := range []string{"", "wrong"} {
+		if w := maintenanceRequest(g, http.MethodPut, token, `{"enabled":true}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: expected 401, got %d", token, w.Code)
+		}
+	}
+	if g.maintenance.Load() {
+		t.Fatal("expected maintenance to stay off without the admin token")
+	}
+
+	w := maintenanceRequest(g, http.MethodPut, "admin-secret", `{"enabled":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if !g.maintenance.Load() {
+		t.Error("expected maintenance to be on")
+	}
+
+	w = maintenanceRequest(g, http.MethodGet, "admin-secret", "")
+	var status maintenanceStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !status.Enabled {
+		t.Error("expected GET to report maintenance on")
+	}
+
+	if w := maintenanceRequest(g, http.MethodPost, "admin-secret", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestAdminOnly_NoTokenConfigured(t *testing.T) {
+	g, _ := newTestGateway()
+	if w := maintenanceRequest(g, http.MethodGet, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without an admin token configured, got %d", w.Code)
+	}
+}
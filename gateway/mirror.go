@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Mirror defaults
+const (
+	DefaultMirrorSampleRate  = 0.05
+	DefaultMirrorConcurrency = 16
+
+	// mirrorTimeout bounds one shadow call
+	mirrorTimeout = 5 * time.Second
+)
+
+// MirrorStats compares the shadow backend with the primary for one method
+type MirrorStats struct {
+	Method      string `json:"method"`
+	Calls       uint64 `json:"calls"`
+	Dropped     uint64 `json:"dropped"`
+	StatusDiffs uint64 `json:"status_diffs"`
+	BodyDiffs   uint64 `json:"body_diffs"`
+	// Latency totals over compared calls
+	PrimaryTotal time.Duration `json:"primary_total_ns"`
+	ShadowTotal  time.Duration `json:"shadow_total_ns"`
+}
+
+// Mirror replays a sample of read calls against a shadow backend, such as a
+// new build of a service, after the primary has answered. Shadow responses
+// are discarded; only status, body and latency differences are recorded, so
+// a rewrite can be validated on production traffic without serving it.
+type Mirror struct {
+	shadow     grpc.ClientConnInterface
+	methods    map[string]bool
+	logger     *slog.Logger
+	sampleRate float64
+
+	// inflight caps concurrent shadow calls; calls over it are dropped
+	// rather than queued so a slow shadow can't build up work
+	inflight chan struct{}
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	stats map[string]*MirrorStats
+}
+
+// MirrorOption configures a Mirror
+type MirrorOption func(*Mirror)
+
+// WithMirrorSampleRate sets the fraction of calls, from 0 to 1, that are mirrored
+func WithMirrorSampleRate(rate float64) MirrorOption {
+	return func(m *Mirror) {
+		m.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithMirrorConcurrency sets how many shadow calls may run at once
+func WithMirrorConcurrency(n int) MirrorOption {
+	return func(m *Mirror) {
+		m.inflight = make(chan struct{}, max(n, 1))
+	}
+}
+
+// NewMirror creates a Mirror sending the given full method names to shadow.
+// Only list read-only methods: the shadow runs every mirrored call again.
+func NewMirror(shadow grpc.ClientConnInterface, methods []string, logger *slog.Logger, opts ...MirrorOption) *Mirror {
+	m := &Mirror{
+		shadow:     shadow,
+		methods:    make(map[string]bool, len(methods)),
+		logger:     logger,
+		sampleRate: DefaultMirrorSampleRate,
+		inflight:   make(chan struct{}, DefaultMirrorConcurrency),
+		stats:      make(map[string]*MirrorStats),
+	}
+	for _, method := range methods {
+		m.methods[method] = true
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// UnaryClientInterceptor mirrors sampled calls made on the primary connection
+func (m *Mirror) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !m.methods[method] || rand.Float64() >= m.sampleRate {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.mirror(ctx, method, req, reply, err, time.Since(start))
+		return err
+	}
+}
+
+// mirror starts the shadow call for a primary call that has finished
+func (m *Mirror) mirror(ctx context.Context, method string, req, reply any, primaryErr error, primaryLatency time.Duration) {
+	reqMsg, ok := req.(proto.Message)
+	replyMsg, ok2 := reply.(proto.Message)
+	if !ok || !ok2 {
+		return
+	}
+
+	select {
+	case m.inflight <- struct{}{}:
+	default:
+		m.record(method, func(s *MirrorStats) { s.Dropped++ })
+		return
+	}
+
+	// Copy everything the caller may reuse once this call returns
+	reqMsg = proto.Clone(reqMsg)
+	replyMsg = proto.Clone(replyMsg)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() { <-m.inflight }()
+
+		shadowCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), mirrorTimeout)
+		defer cancel()
+
+		shadowReply := replyMsg.ProtoReflect().New().Interface()
+		start := time.Now()
+		shadowErr := m.shadow.Invoke(shadowCtx, method, reqMsg, shadowReply)
+		shadowLatency := time.Since(start)
+
+		primaryCode, shadowCode := status.Code(primaryErr), status.Code(shadowErr)
+		statusDiff := primaryCode != shadowCode
+		bodyDiff := !statusDiff && primaryErr == nil && !proto.Equal(replyMsg, shadowReply)
+
+		m.record(method, func(s *MirrorStats) {
+			s.Calls++
+			s.PrimaryTotal += primaryLatency
+			s.ShadowTotal += shadowLatency
+			if statusDiff {
+				s.StatusDiffs++
+			}
+			if bodyDiff {
+				s.BodyDiffs++
+			}
+		})
+		if statusDiff || bodyDiff {
+			m.logger.Warn("shadow response differs",
+				"method", method,
+				"primary_code", primaryCode.String(),
+				"shadow_code", shadowCode.String(),
+				"body_differs", bodyDiff,
+				"primary_ms", primaryLatency.Milliseconds(),
+				"shadow_ms", shadowLatency.Milliseconds(),
+			)
+		}
+	}()
+}
+
+func (m *Mirror) record(method string, update func(*MirrorStats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[method]
+	if !ok {
+		s = &MirrorStats{Method: method}
+		m.stats[method] = s
+	}
+	update(s)
+}
+
+// Stats returns the comparison per mirrored method
+func (m *Mirror) Stats() []MirrorStats {
+	m.mu.Lock()
+	result := make([]MirrorStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		result = append(result, *s)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Method < result[j].Method })
+	return result
+}
+
+// ServeHTTP writes Stats as JSON, for an admin endpoint
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Stats()); err != nil {
+		m.logger.Error("failed to encode mirror stats", "error", err)
+	}
+}
+
+// Close waits for in-flight shadow calls. It does not close the shadow connection.
+func (m *Mirror) Close() {
+	m.wg.Wait()
+}
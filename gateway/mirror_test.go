@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// fakeShadow answers every call with reply or err, optionally waiting for
+// release first, and remembers the metadata it was sent
+type fakeShadow struct {
+	reply   proto.Message
+	err     error
+	release chan struct{}
+	md      metadata.MD
+}
+
+func (s *fakeShadow) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.md, _ = metadata.FromOutgoingContext(ctx)
+	if s.err != nil {
+		return s.err
+	}
+	proto.Merge(reply.(proto.Message), s.reply)
+	return nil
+}
+
+func (s *fakeShadow) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "not supported")
+}
+
+// callMirrored sends a GetSummary request as method through m, with a primary
+// that returns reply or err
+func callMirrored(m *Mirror, method string, reply *paymentpb.Summary, err error) error {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+	invoker := func(ctx context.Context, method string, req, out any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if err != nil {
+			return err
+		}
+		proto.Merge(out.(proto.Message), reply)
+		return nil
+	}
+	return m.UnaryClientInterceptor()(ctx, method, &paymentpb.GetSummaryRequest{UserId: 1}, &paymentpb.Summary{}, nil, invoker)
+}
+
+func newTestMirror(shadow *fakeShadow, opts ...MirrorOption) *Mirror {
+	methods := []string{paymentpb.PaymentService_GetSummary_FullMethodName}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewMirror(shadow, methods, logger, append([]MirrorOption{WithMirrorSampleRate(1)}, opts...)...)
+}
+
+func TestMirror_RecordsDiffs(t *testing.T) {
+	primary := &paymentpb.Summary{UnpaidTotal: 10, UnpaidCount: 1}
+	tests := []struct {
+		name       string
+		shadow     *fakeShadow
+		primaryErr error
+		want       MirrorStats
+	}{
+		{"same", &fakeShadow{reply: primary}, nil, MirrorStats{Calls: 1}},
+		{"body differs", &fakeShadow{reply: &paymentpb.Summary{UnpaidTotal: 11, UnpaidCount: 1}}, nil, MirrorStats{Calls: 1, BodyDiffs: 1}},
+		{"status differs", &fakeShadow{err: status.Error(codes.Internal, "boom")}, nil, MirrorStats{Calls: 1, StatusDiffs: 1}},
+		{"both fail alike", &fakeShadow{err: status.Error(codes.NotFound, "no")}, status.Error(codes.NotFound, "no"), MirrorStats{Calls: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMirror(tt.shadow)
+			if err := callMirrored(m, paymentpb.PaymentService_GetSummary_FullMethodName, primary, tt.primaryErr); err != tt.primaryErr {
+				t.Fatalf("expected the primary's error %v, got %v", tt.primaryErr, err)
+			}
+			m.Close()
+
+			stats := m.Stats()
+			if len(stats) != 1 {
+				t.Fatalf("expected one method, got %+v", stats)
+			}
+			got := stats[0]
+			if got.Calls != tt.want.Calls || got.BodyDiffs != tt.want.BodyDiffs || got.StatusDiffs != tt.want.StatusDiffs {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if tt.shadow.md.Get("authorization")[0] != "Bearer token" {
+				t.Errorf("expected the caller's metadata on the shadow call, got %v", tt.shadow.md)
+			}
+		})
+	}
+}
+
+func TestMirror_SkipsUnlistedAndUnsampled(t *testing.T) {
+	shadow := &fakeShadow{reply: &paymentpb.Summary{}}
+
+	m := newTestMirror(shadow)
+	_ = callMirrored(m, paymentpb.PaymentService_PayAllTransactions_FullMethodName, &paymentpb.Summary{}, nil)
+	m.Close()
+	if len(m.Stats()) != 0 {
+		t.Errorf("expected unlisted methods not to be mirrored, got %+v", m.Stats())
+	}
+
+	m = newTestMirror(shadow, WithMirrorSampleRate(0))
+	_ = callMirrored(m, paymentpb.PaymentService_GetSummary_FullMethodName, &paymentpb.Summary{}, nil)
+	m.Close()
+	if len(m.Stats()) != 0 {
+		t.Errorf("expected a zero sample rate to mirror nothing, got %+v", m.Stats())
+	}
+}
+
+func TestMirror_DropsWhenShadowIsBusy(t *testing.T) {
+	shadow := &fakeShadow{reply: &paymentpb.Summary{}, release: make(chan struct{})}
+	m := newTestMirror(shadow, WithMirrorConcurrency(1))
+
+	for range 3 {
+		_ = callMirrored(m, paymentpb.PaymentService_GetSummary_FullMethodName, &paymentpb.Summary{}, nil)
+	}
+	close(shadow.release)
+	m.Close()
+
+	if s := m.Stats()[0]; s.Calls != 1 || s.Dropped != 2 {
+		t.Errorf("expected one call and two drops, got %+v", s)
+	}
+}
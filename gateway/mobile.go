@@ -0,0 +1,324 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/fanout"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Mobile page sizes. The mobile endpoints always page, keeping responses
+// small on slow links.
+const (
+	mobileDashboardSize   = 5
+	mobileDefaultPageSize = 20
+	mobileMaxPageSize     = 100
+)
+
+// mobileCallTimeout bounds each backend call of a composite response, so a
+// slow one leaves a gap rather than holding up the rest
+const mobileCallTimeout = 2 * time.Second
+
+// mobileTransactionFields are the transaction fields mobile screens show
+var mobileTransactionFields = []string{"id", "ulid", "amount", "description", "is_paid", "created_at"}
+
+// MobileUser is the profile returned with a mobile login
+type MobileUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Timezone string `json:"timezone,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+	// DeletionScheduledAt is set while the account is pending deletion
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// MobileSummary is the part of the transaction summary a dashboard shows
+type MobileSummary struct {
+	UnpaidTotal    float64 `json:"unpaid_total"`
+	UnpaidCount    int64   `json:"unpaid_count"`
+	PeriodTotal    float64 `json:"period_total"`
+	RemainingLimit float64 `json:"remaining_limit"`
+}
+
+// MobileTransaction is a transaction without the fields mobile clients
+// already know
+type MobileTransaction struct {
+	ID          int64     `json:"id"`
+	ULID        string    `json:"ulid,omitempty"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description,omitempty"`
+	IsPaid      bool      `json:"is_paid"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MobileLoginResponse is a token with the profile and summary the first
+// screen needs. Summary is omitted, and listed in Partial, when the payment
+// service is unavailable, so a login never fails on it.
+type MobileLoginResponse struct {
+	Token   string         `json:"token"`
+	User    MobileUser     `json:"user"`
+	Summary *MobileSummary `json:"summary,omitempty"`
+	Partial []string       `json:"partial,omitempty"`
+}
+
+// MobileDashboardResponse is the summary with the most recent transactions.
+// A section whose backend call failed is left empty and listed in Partial.
+type MobileDashboardResponse struct {
+	Summary    *MobileSummary      `json:"summary,omitempty"`
+	Recent     []MobileTransaction `json:"recent"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Partial    []string            `json:"partial,omitempty"`
+}
+
+// MobileTransactionsResponse is a page of transactions
+type MobileTransactionsResponse struct {
+	Transactions []MobileTransaction `json:"transactions"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
+}
+
+// mobileContext is paymentContext for the mobile endpoints, which speak
+// for the mobile channel whatever the client declares
+func mobileContext(ctx context.Context, token string) context.Context {
+	if fwd, ok := forwardedIdentity(ctx); ok {
+		return grpcauth.WithClientChannel(fwd, "mobile")
+	}
+	return grpcauth.WithClientChannel(grpcauth.WithBearerToken(ctx, token), "mobile")
+}
+
+// handleMobileLogin logs in and returns the user's profile and summary in one
+// round trip
+func (g *Gateway) handleMobileLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := request.DecodeJSON(r, &req); err != nil {
+		g.respondDecodeError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := g.authClient.Login(grpcauth.WithClientIP(ctx, clientIP(r)), &authpb.LoginRequest{
+		Username:  req.Username,
+		Email:     req.Email,
+		Password:  req.Password,
+		UserAgent: r.UserAgent(),
+		DeviceId:  r.Header.Get(deviceIDHeader),
+	})
+	if err != nil {
+		g.logger.ErrorContext(r.Context(), "mobile login failed", "error", err)
+		g.respondError(w, r, errInvalidCredentials)
+		return
+	}
+
+	body := MobileLoginResponse{Token: resp.Token, User: mobileUser(resp)}
+	var summary *paymentpb.Summary
+	group := fanout.New(mobileContext(ctx, resp.Token), fanout.WithTimeout(mobileCallTimeout))
+	group.Go("summary", func(ctx context.Context) (err error) {
+		summary, err = g.paymentClient.GetSummary(ctx, &paymentpb.GetSummaryRequest{UserId: resp.Id})
+		return err
+	})
+	result, _ := group.Wait()
+	if err := result.Err("summary"); err != nil {
+		g.logger.WarnContext(r.Context(), "mobile login without summary", "error", err, "user_id", resp.Id)
+	} else {
+		s := mobileSummary(summary)
+		body.Summary = &s
+	}
+	body.Partial = partialSections(result)
+
+	g.respondJSON(w, r, http.StatusOK, body)
+}
+
+// handleMobileDashboard returns the summary and the latest transactions,
+// fetched concurrently. It answers with whichever of the two arrives in
+// time, failing only when neither does.
+func (g *Gateway) handleMobileDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	ctx = mobileContext(ctx, bearerToken(r))
+
+	var (
+		summary *paymentpb.Summary
+		list    *paymentpb.TransactionList
+	)
+	group := fanout.New(ctx, fanout.WithTimeout(mobileCallTimeout))
+	group.Go("summary", func(ctx context.Context) (err error) {
+		summary, err = g.paymentClient.GetSummary(ctx, &paymentpb.GetSummaryRequest{
+			UserId: int64(userID),
+		})
+		return err
+	})
+	group.Go("recent", func(ctx context.Context) (err error) {
+		list, err = g.paymentClient.GetTransactions(ctx, mobileListRequest(userID, pagination.PageRequest{Limit: mobileDashboardSize}))
+		return err
+	})
+	result, _ := group.Wait()
+
+	summaryErr, listErr := result.Err("summary"), result.Err("recent")
+	if status.Code(summaryErr) == codes.InvalidArgument {
+		g.respondError(w, r, errInvalidTimezone)
+		return
+	}
+	if summaryErr != nil && listErr != nil {
+		g.logger.ErrorContext(r.Context(), "mobile dashboard failed", "error", cmp.Or(summaryErr, listErr))
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get dashboard"))
+		return
+	}
+
+	body := MobileDashboardResponse{Partial: partialSections(result)}
+	if summaryErr != nil {
+		g.logger.WarnContext(r.Context(), "mobile dashboard without summary", "error", summaryErr, "user_id", userID)
+	} else {
+		s := mobileSummary(summary)
+		body.Summary = &s
+	}
+	if listErr != nil {
+		g.logger.WarnContext(r.Context(), "mobile dashboard without recent transactions", "error", listErr, "user_id", userID)
+	} else {
+		body.Recent = mobileTransactions(list)
+		body.NextCursor = list.GetPage().GetNextCursor()
+	}
+
+	g.respondJSON(w, r, http.StatusOK, body)
+}
+
+// partialSections returns the sections missing from a composite response,
+// nil when it is complete
+func partialSections(result fanout.Result) []string {
+	if !result.Partial() {
+		return nil
+	}
+	return result.Missing()
+}
+
+// handleMobileTransactions pages the caller's transactions, newest first,
+// with only the fields mobile screens show
+func (g *Gateway) handleMobileTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	page, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		g.respondError(w, r, errInvalidLimit)
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = mobileDefaultPageSize
+	}
+	page.Limit = min(page.Limit, mobileMaxPageSize)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := g.paymentClient.GetTransactions(mobileContext(ctx, bearerToken(r)), mobileListRequest(userID, page))
+	if err != nil {
+		g.logger.ErrorContext(r.Context(), "mobile transactions failed", "error", err)
+		if status.Code(err) == codes.InvalidArgument {
+			g.respondError(w, r, errInvalidCursor)
+			return
+		}
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to get transactions"))
+		return
+	}
+
+	g.respondJSON(w, r, http.StatusOK, MobileTransactionsResponse{
+		Transactions: mobileTransactions(list),
+		NextCursor:   list.GetPage().GetNextCursor(),
+	})
+}
+
+// mobileListRequest lists a page of userID's transactions, newest first,
+// reading only mobileTransactionFields
+func mobileListRequest(userID int, page pagination.PageRequest) *paymentpb.GetTransactionsRequest {
+	return &paymentpb.GetTransactionsRequest{
+		UserId:    int64(userID),
+		FieldMask: &fieldmaskpb.FieldMask{Paths: mobileTransactionFields},
+		Page:      page.Normalize(mobileDefaultPageSize).Proto(),
+	}
+}
+
+func mobileUser(resp *authpb.AuthResponse) MobileUser {
+	user := MobileUser{
+		ID:       resp.Id,
+		Username: resp.Username,
+		Role:     resp.Role,
+		Timezone: resp.Timezone,
+		Locale:   resp.Locale,
+	}
+	if resp.DeletionScheduledAt != nil {
+		at := resp.DeletionScheduledAt.AsTime()
+		user.DeletionScheduledAt = &at
+	}
+	return user
+}
+
+func mobileSummary(s *paymentpb.Summary) MobileSummary {
+	return MobileSummary{
+		UnpaidTotal:    s.GetUnpaidTotal(),
+		UnpaidCount:    s.GetUnpaidCount(),
+		PeriodTotal:    s.GetPeriodTotal(),
+		RemainingLimit: s.GetRemainingLimit(),
+	}
+}
+
+func mobileTransactions(list *paymentpb.TransactionList) []MobileTransaction {
+	txs := make([]MobileTransaction, 0, len(list.GetTransactions()))
+	for _, tx := range list.GetTransactions() {
+		txs = append(txs, MobileTransaction{
+			ID:          tx.GetId(),
+			ULID:        tx.GetUlid(),
+			Amount:      tx.GetAmount(),
+			Description: tx.GetDescription(),
+			IsPaid:      tx.GetIsPaid(),
+			CreatedAt:   tx.GetCreatedAt().AsTime(),
+		})
+	}
+	return txs
+}
+
+// bearerToken returns the token of r's Authorization header
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/testutil"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func TestHandleMobileLogin(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":40}`, "")
+
+	r := httptest.NewRequest(http.MethodPost, "/mobile/v1/login", strings.NewReader(`{"username":"alice","password":"pw"}`))
+	w := httptest.NewRecorder()
+	g.handleMobileLogin(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+
+	var body MobileLoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Token == "" || body.User.Username != "alice" {
+		t.Errorf("expected a token and the profile, got %+v", body)
+	}
+	if body.Summary == nil || body.Summary.UnpaidTotal != 40 {
+		t.Errorf("expected the summary with the login, got %+v", body.Summary)
+	}
+}
+
+func TestHandleMobileLogin_WithoutSummary(t *testing.T) {
+	g, auth := newTestGateway()
+	auth.AddUser("alice", "pw")
+	g.paymentClient.(*testutil.FakePaymentClient).Err = errors.New("payment down")
+
+	r := httptest.NewRequest(http.MethodPost, "/mobile/v1/login", strings.NewReader(`{"username":"alice","password":"pw"}`))
+	w := httptest.NewRecorder()
+	g.handleMobileLogin(w, r)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"summary":`) {
+		t.Errorf("expected the login to succeed without a summary, got %d: %s", w.Code, w.Body)
+	}
+	var body MobileLoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || len(body.Partial) != 1 || body.Partial[0] != "summary" {
+		t.Errorf("expected the summary marked missing, got %+v: %v", body.Partial, err)
+	}
+}
+
+func TestHandleMobileDashboard(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":25,"description":"coffee"}`, "")
+
+	r := httptest.NewRequest(http.MethodGet, "/mobile/v1/dashboard", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleMobileDashboard(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "user_id") || strings.Contains(w.Body.String(), `"paid_total"`) {
+		t.Errorf("expected trimmed fields, got %s", w.Body)
+	}
+
+	var body MobileDashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Recent) != 1 || body.Recent[0].Description != "coffee" || body.Summary == nil || body.Summary.UnpaidTotal != 25 || body.Partial != nil {
+		t.Errorf("unexpected dashboard %+v", body)
+	}
+}
+
+// summaryDownClient is a payment backend whose summaries fail
+type summaryDownClient struct {
+	*testutil.FakePaymentClient
+}
+
+func (c summaryDownClient) GetSummary(context.Context, *paymentpb.GetSummaryRequest, ...grpc.CallOption) (*paymentpb.Summary, error) {
+	return nil, status.Error(codes.Unavailable, "summary down")
+}
+
+func TestHandleMobileDashboard_Partial(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+	createTransaction(g, token, `{"amount":25,"description":"coffee"}`, "")
+	payment := g.paymentClient.(*testutil.FakePaymentClient)
+	g.paymentClient = summaryDownClient{payment}
+
+	r := httptest.NewRequest(http.MethodGet, "/mobile/v1/dashboard", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleMobileDashboard(w, r)
+
+	var body MobileDashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", w.Code, err)
+	}
+	if body.Summary != nil || len(body.Recent) != 1 || len(body.Partial) != 1 || body.Partial[0] != "summary" {
+		t.Errorf("expected the recent transactions with the summary marked missing, got %+v", body)
+	}
+
+	// Without either section there is nothing to show
+	payment.Err = errors.New("payment down")
+	w = httptest.NewRecorder()
+	g.handleMobileDashboard(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when every section fails, got %d", w.Code)
+	}
+}
+
+func TestHandleMobileTransactions_Unauthorized(t *testing.T) {
+	g, _ := newTestGateway()
+
+	w := httptest.NewRecorder()
+	g.handleMobileTransactions(w, httptest.NewRequest(http.MethodGet, "/mobile/v1/transactions", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
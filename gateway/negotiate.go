@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+// Supported response content types
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeMsgpack  = "application/msgpack"
+)
+
+// negotiateContentType picks the best supported response encoding from the
+// Accept header, falling back to JSON when nothing else matches
+func negotiateContentType(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return contentTypeJSON
+	}
+
+	best, bestQ := contentTypeJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && key == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		var candidate string
+		switch mediaType {
+		case contentTypeProtobuf, "application/protobuf":
+			candidate = contentTypeProtobuf
+		case contentTypeMsgpack, "application/x-msgpack":
+			candidate = contentTypeMsgpack
+		case contentTypeJSON, "application/*", "*/*":
+			candidate = contentTypeJSON
+		default:
+			continue
+		}
+
+		if q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best
+}
+
+// respondNegotiated writes a response in the encoding requested by the client.
+// msg is used for protobuf responses, data for JSON and msgpack.
+func (g *Gateway) respondNegotiated(w http.ResponseWriter, r *http.Request, status int, msg proto.Message, data interface{}) {
+	switch negotiateContentType(r) {
+	case contentTypeProtobuf:
+		body, err := proto.Marshal(msg)
+		if err != nil {
+			g.logger.Error("failed to marshal protobuf response", "error", err)
+			g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to encode response"))
+			return
+		}
+		g.writeBody(w, status, contentTypeProtobuf, body)
+	case contentTypeMsgpack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		// Reuse the JSON field names so every encoding shares one schema
+		enc.SetCustomStructTag("json")
+		enc.UseCompactInts(true)
+		if err := enc.Encode(data); err != nil {
+			g.logger.Error("failed to marshal msgpack response", "error", err)
+			g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to encode response"))
+			return
+		}
+		g.writeBody(w, status, contentTypeMsgpack, buf.Bytes())
+	default:
+		w.Header().Add("Vary", "Accept")
+		g.respondJSON(w, r, status, data)
+	}
+}
+
+func (g *Gateway) writeBody(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		g.logger.Error("failed to write response", "error", err)
+	}
+}
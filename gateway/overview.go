@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+// DefaultErrorRateWindow is how far back /admin/overview counts responses
+const DefaultErrorRateWindow = 15 * time.Minute
+
+// Service statuses in /admin/overview
+const (
+	serviceUp      = "up"
+	serviceDown    = "down"
+	serviceUnknown = "unknown"
+)
+
+// RequestRates counts the gateway's responses by status class over a
+// sliding window of one-minute buckets
+type RequestRates struct {
+	mu      sync.Mutex
+	buckets []rateBucket
+}
+
+type rateBucket struct {
+	minute       int64
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+}
+
+// NewRequestRates counts responses over the last window, rounded up to whole
+// minutes
+func NewRequestRates(window time.Duration) *RequestRates {
+	n := max(int((window+time.Minute-1)/time.Minute), 1)
+	buckets := make([]rateBucket, n)
+	for i := range buckets {
+		buckets[i].minute = -1
+	}
+	return &RequestRates{buckets: buckets}
+}
+
+// WithErrorRateWindow sets how far back /admin/overview counts responses
+func WithErrorRateWindow(window time.Duration) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.errorRateWindow = window
+	}
+}
+
+// Record counts a response with status at the given time
+func (c *RequestRates) Record(status int, at time.Time) {
+	minute := at.Unix() / 60
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := &c.buckets[minute%int64(len(c.buckets))]
+	if b.minute != minute {
+		*b = rateBucket{minute: minute}
+	}
+	b.requests++
+	switch {
+	case status >= 500:
+		b.serverErrors++
+	case status >= 400:
+		b.clientErrors++
+	}
+}
+
+// RequestRateSnapshot is the responses counted in the window ending now.
+// ErrorRate is the fraction of them that were server errors.
+type RequestRateSnapshot struct {
+	Window       string  `json:"window"`
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate"`
+}
+
+// Snapshot totals the buckets of the window ending at now
+func (c *RequestRates) Snapshot(now time.Time) RequestRateSnapshot {
+	minute := now.Unix() / 60
+	oldest := minute - int64(len(c.buckets)) + 1
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := RequestRateSnapshot{Window: (time.Duration(len(c.buckets)) * time.Minute).String()}
+	for _, b := range c.buckets {
+		if b.minute < oldest || b.minute > minute {
+			continue
+		}
+		s.Requests += b.requests
+		s.ClientErrors += b.clientErrors
+		s.ServerErrors += b.serverErrors
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.ServerErrors) / float64(s.Requests)
+	}
+	return s
+}
+
+// withRequestRates counts every response for /admin/overview
+func (g *Gateway) withRequestRates(next http.Handler) http.Handler {
+	if g.rates == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		g.rates.Record(sw.status, time.Now())
+	})
+}
+
+// ServiceHealth is one backend's status in /admin/overview. State is the
+// gRPC connectivity state of backends the gateway keeps connections to.
+type ServiceHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	State  string `json:"state,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// analyticsOverview is the analytics service's /stats/overview
+type analyticsOverview struct {
+	ConsumerLag int64 `json:"consumer_lag"`
+	Today       struct {
+		Start            time.Time `json:"start"`
+		Transactions     int64     `json:"transactions"`
+		Amount           float64   `json:"amount"`
+		PaidTransactions int64     `json:"paid_transactions"`
+	} `json:"today"`
+	ActiveUsers     map[string]int `json:"active_users"`
+	EventsProcessed int64          `json:"events_processed"`
+	LastEventTime   string         `json:"last_event_time"`
+	Instances       int            `json:"instances,omitempty"`
+	PeersFailed     int            `json:"peers_failed,omitempty"`
+}
+
+// AdminOverview is the ops dashboard's view of the system
+type AdminOverview struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Services    []ServiceHealth `json:"services"`
+	// Analytics holds consumer lag, today's volume and active users; it is
+	// omitted when the analytics service is down
+	Analytics *analyticsOverview  `json:"analytics,omitempty"`
+	Requests  RequestRateSnapshot `json:"requests"`
+}
+
+// handleAdminOverview serves /admin/overview to admins. Each part is
+// gathered independently, so one backend being down shows up in its
+// status instead of failing the whole response.
+func (g *Gateway) handleAdminOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := g.authenticate(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+	if id.role != jwt.RoleAdmin {
+		g.respondError(w, r, errPolicyDenied)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	overview := AdminOverview{
+		GeneratedAt: now.UTC(),
+		Services: []ServiceHealth{
+			backendHealth("auth", g.authPool),
+			backendHealth("payment", g.paymentPool),
+		},
+	}
+	analytics := ServiceHealth{Name: "analytics", Status: serviceUp}
+	if stats, err := g.fetchAnalyticsOverview(ctx); err != nil {
+		g.logger.WarnContext(r.Context(), "analytics overview failed", "error", err)
+		analytics.Status, analytics.Error = serviceDown, err.Error()
+	} else {
+		overview.Analytics = stats
+	}
+	overview.Services = append(overview.Services, analytics)
+	if g.rates != nil {
+		overview.Requests = g.rates.Snapshot(now)
+	}
+
+	g.respondJSON(w, r, http.StatusOK, overview)
+}
+
+// backendHealth reports pool's best connection state. Idle connections
+// count as up, as they do when picking one for a call.
+func backendHealth(name string, pool *connPool) ServiceHealth {
+	health := ServiceHealth{Name: name, Status: serviceUnknown}
+	if pool == nil {
+		return health
+	}
+
+	best := connectivity.Shutdown
+	for _, c := range pool.conns {
+		if state := c.conn.GetState(); stateRank(state) > stateRank(best) {
+			best = state
+		}
+	}
+	health.State = best.String()
+	health.Status = serviceDown
+	if best == connectivity.Ready || best == connectivity.Idle {
+		health.Status = serviceUp
+	}
+	return health
+}
+
+// stateRank orders connectivity states from worst to best
+func stateRank(state connectivity.State) int {
+	switch state {
+	case connectivity.Ready:
+		return 4
+	case connectivity.Idle:
+		return 3
+	case connectivity.Connecting:
+		return 2
+	case connectivity.TransientFailure:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// fetchAnalyticsOverview gets consumer lag, today's volume and active users
+// from the analytics service
+func (g *Gateway) fetchAnalyticsOverview(ctx context.Context) (*analyticsOverview, error) {
+	overviewURL := strings.TrimRight(g.currentConfig().AnalyticsURL, "/") + "/stats/overview"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, overviewURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.Error("failed to close analytics overview response", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var overview analyticsOverview
+	if err := json.NewDecoder(resp.Body).Decode(&overview); err != nil {
+		return nil, fmt.Errorf("failed to decode overview: %w", err)
+	}
+	return &overview, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestRates(t *testing.T) {
+	rates := NewRequestRates(5 * time.Minute)
+	now := time.Date(2026, 10, 16, 12, 0, 30, 0, time.UTC)
+
+	rates.Record(http.StatusOK, now.Add(-10*time.Minute)) // outside the window
+	rates.Record(http.StatusOK, now.Add(-4*time.Minute))
+	rates.Record(http.StatusNotFound, now.Add(-time.Minute))
+	rates.Record(http.StatusBadGateway, now)
+	rates.Record(http.StatusOK, now)
+
+	got := rates.Snapshot(now)
+	want := RequestRateSnapshot{Window: "5m0s", Requests: 4, ClientErrors: 1, ServerErrors: 1, ErrorRate: 0.25}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestHandleAdminOverview(t *testing.T) {
+	analytics := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats/overview" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"consumer_lag":12,"today":{"transactions":3,"amount":42},"active_users":{"5m":2},"events_processed":9}`))
+	}))
+	defer analytics.Close()
+
+	g, auth := newTestGateway()
+	g.httpClient = analytics.Client()
+	g.config.Store(&Config{AnalyticsURL: analytics.URL})
+	g.rates = NewRequestRates(time.Minute)
+	g.rates.Record(http.StatusInternalServerError, time.Now())
+	_, admin := auth.AddUserWithRole("root", "pw", "admin")
+	_, user := auth.AddUser("alice", "pw")
+
+	call := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/admin/overview", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		g.handleAdminOverview(w, r)
+		return w
+	}
+
+	if w := call(user); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin, got %d", w.Code)
+	}
+	if w := call("bad-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+
+	w := call(admin)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var overview AdminOverview
+	if err := json.NewDecoder(w.Body).Decode(&overview); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if a := overview.Analytics; a == nil || a.ConsumerLag != 12 || a.Today.Transactions != 3 || a.ActiveUsers["5m"] != 2 {
+		t.Errorf("expected the analytics overview, got %+v", a)
+	}
+	if overview.Requests.ServerErrors != 1 || overview.Requests.ErrorRate != 1 {
+		t.Errorf("expected the recorded server error, got %+v", overview.Requests)
+	}
+	if len(overview.Services) != 3 || overview.Services[2].Status != serviceUp {
+		t.Errorf("expected analytics up, got %+v", overview.Services)
+	}
+
+	// Analytics being down is reported, not fatal
+	analytics.Close()
+	w = call(admin)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with analytics down, got %d: %s", w.Code, w.Body)
+	}
+	overview = AdminOverview{}
+	if err := json.NewDecoder(w.Body).Decode(&overview); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if overview.Analytics != nil || overview.Services[2].Status != serviceDown || overview.Services[2].Error == "" {
+		t.Errorf("expected analytics reported down, got %+v", overview.Services[2])
+	}
+}
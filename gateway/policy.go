@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+// PolicyRule makes requests matching Method and Path satisfy Require. Path is
+// an exact path, or a prefix when it ends in "/*"; an empty Method or "*"
+// matches any method.
+//
+// Require is "public", which lets anyone through, or an expression over
+// "authenticated", "role:<name>" and "scope:<name>" terms combined with "&&",
+// "||", "!" and parentheses, e.g. "role:admin || scope:analytics:read".
+type PolicyRule struct {
+	Method  string `json:"method,omitempty"`
+	Path    string `json:"path"`
+	Require string `json:"require"`
+}
+
+// policyPublic is the Require value that skips authentication
+const policyPublic = "public"
+
+// identity is the caller a bearer token authenticated
+type identity struct {
+	userID   int
+	username string
+	role     string
+	scopes   []string
+}
+
+// withIdentity records id so later auth checks for the request reuse it
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	return context.WithValue(ctx, authenticatedUserKey{}, id)
+}
+
+// authenticatedUserKey carries the identity already validated for a request,
+// so the auth service is called at most once per request
+type authenticatedUserKey struct{}
+
+// defaultPolicies require the token scope matching each API area. A config
+// file's "policies" replaces them.
+func defaultPolicies() []PolicyRule {
+	return []PolicyRule{
+		{Method: http.MethodGet, Path: "/payment/*", Require: "scope:" + jwt.ScopePaymentsRead},
+		{Path: "/payment/*", Require: "scope:" + jwt.ScopePaymentsWrite},
+		{Method: http.MethodGet, Path: "/v1/payment/*", Require: "scope:" + jwt.ScopePaymentsRead},
+		{Path: "/v1/payment/*", Require: "scope:" + jwt.ScopePaymentsWrite},
+		{Path: "/analytics/*", Require: "scope:" + jwt.ScopeAnalyticsRead},
+		{Method: http.MethodGet, Path: "/mobile/v1/*", Require: "scope:" + jwt.ScopePaymentsRead},
+		{Method: http.MethodGet, Path: updatesPath, Require: "scope:" + jwt.ScopePaymentsRead},
+	}
+}
+
+// policy is the compiled form of Config.Policies. The first matching rule
+// decides; requests no rule matches are left to their handlers.
+type policy struct {
+	rules []policyRule
+}
+
+type policyRule struct {
+	method  string
+	path    string
+	prefix  bool
+	require string
+	public  bool
+	expr    policyExpr
+}
+
+// compilePolicy parses rules, reporting the first invalid one
+func compilePolicy(rules []PolicyRule) (*policy, error) {
+	p := &policy{rules: make([]policyRule, 0, len(rules))}
+	for i, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		p.rules = append(p.rules, compiled)
+	}
+	return p, nil
+}
+
+func compileRule(rule PolicyRule) (policyRule, error) {
+	method := strings.ToUpper(strings.TrimSpace(rule.Method))
+	if method == "*" {
+		method = ""
+	}
+	if !strings.HasPrefix(rule.Path, "/") {
+		return policyRule{}, fmt.Errorf("path %q must start with /", rule.Path)
+	}
+	compiled := policyRule{method: method, path: rule.Path, require: rule.Require}
+	if prefix, ok := strings.CutSuffix(rule.Path, "/*"); ok {
+		compiled.path, compiled.prefix = prefix, true
+	}
+
+	if strings.TrimSpace(rule.Require) == policyPublic {
+		compiled.public = true
+		return compiled, nil
+	}
+	expr, err := parsePolicyExpr(rule.Require)
+	if err != nil {
+		return policyRule{}, fmt.Errorf("require %q: %w", rule.Require, err)
+	}
+	compiled.expr = expr
+	return compiled, nil
+}
+
+// match returns the rule deciding r, or nil when none applies
+func (p *policy) match(r *http.Request) *policyRule {
+	if p == nil {
+		return nil
+	}
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if rule.method != "" && rule.method != r.Method {
+			continue
+		}
+		if rule.path == r.URL.Path ||
+			rule.prefix && (rule.path == "" || strings.HasPrefix(r.URL.Path, rule.path+"/")) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// authorize enforces the configured policies before the mux routes r
+func (g *Gateway) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule := g.policy.Load().match(r)
+		if rule == nil || rule.public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := g.authenticate(r)
+		if err != nil {
+			g.respondError(w, r, apperror.ErrUnauthorized)
+			return
+		}
+		if !rule.expr.eval(id) {
+			g.logger.InfoContext(r.Context(), "request denied by policy",
+				"method", r.Method, "path", r.URL.Path, "user_id", id.userID, "require", rule.require)
+			g.respondError(w, r, errPolicyDenied)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), id)))
+	})
+}
+
+// policyExpr is a compiled Require expression
+type policyExpr interface {
+	eval(id *identity) bool
+}
+
+type (
+	authenticatedExpr struct{}
+	roleExpr          string
+	scopeExpr         string
+	notExpr           struct{ x policyExpr }
+	andExpr           struct{ l, r policyExpr }
+	orExpr            struct{ l, r policyExpr }
+)
+
+func (authenticatedExpr) eval(id *identity) bool { return id != nil }
+func (e roleExpr) eval(id *identity) bool        { return id != nil && id.role == string(e) }
+func (e scopeExpr) eval(id *identity) bool       { return id != nil && slices.Contains(id.scopes, string(e)) }
+func (e notExpr) eval(id *identity) bool         { return !e.x.eval(id) }
+func (e andExpr) eval(id *identity) bool         { return e.l.eval(id) && e.r.eval(id) }
+func (e orExpr) eval(id *identity) bool          { return e.l.eval(id) || e.r.eval(id) }
+
+// parsePolicyExpr parses a Require expression. "!" binds tightest, then "&&",
+// then "||".
+func parsePolicyExpr(s string) (policyExpr, error) {
+	tokens, err := tokenizePolicy(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	return expr, nil
+}
+
+// tokenizePolicy splits s into operators, parentheses and terms
+func tokenizePolicy(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, s[i:i+1])
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case isTermByte(c):
+			start := i
+			for i < len(s) && isTermByte(s[i]) {
+				i++
+			}
+			tokens = append(tokens, s[start:i])
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isTermByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '.' || c == ':'
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *exprParser) or() (policyExpr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for tok, ok := p.peek(); ok && tok == "||"; tok, ok = p.peek() {
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = orExpr{l, r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) and() (policyExpr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for tok, ok := p.peek(); ok && tok == "&&"; tok, ok = p.peek() {
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = andExpr{l, r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) unary() (policyExpr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of expression")
+	}
+	p.pos++
+	switch tok {
+	case "!":
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	case "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+	return parseTerm(tok)
+}
+
+// parseTerm parses "authenticated", "role:<name>" or "scope:<name>"
+func parseTerm(tok string) (policyExpr, error) {
+	if tok == "authenticated" {
+		return authenticatedExpr{}, nil
+	}
+	if tok == policyPublic {
+		return nil, errors.New("public must be the whole expression")
+	}
+	kind, name, ok := strings.Cut(tok, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("unknown term %q", tok)
+	}
+	switch kind {
+	case "role":
+		return roleExpr(name), nil
+	case "scope":
+		return scopeExpr(name), nil
+	default:
+		return nil, fmt.Errorf("unknown term %q", tok)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePolicyExpr(t *testing.T) {
+	admin := &identity{userID: 1, role: "admin", scopes: []string{"payments:read", "analytics:read"}}
+	user := &identity{userID: 2, role: "user", scopes: []string{"payments:read"}}
+
+	tests := []struct {
+		expr        string
+		admin, user bool
+	}{
+		{"authenticated", true, true},
+		{"role:admin", true, false},
+		{"!role:admin", false, true},
+		{"role:admin || role:user", true, true},
+		{"authenticated && !role:user", true, false},
+		{"!(role:admin || role:user)", false, false},
+		{"role:user || role:admin && !authenticated", false, true},
+		{"scope:payments:read", true, true},
+		{"scope:analytics:read || role:user", true, true},
+		{"scope:analytics:read && role:user", false, false},
+	}
+	for _, tt := range tests {
+		expr, err := parsePolicyExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.expr, err)
+		}
+		if got := expr.eval(admin); got != tt.admin {
+			t.Errorf("%q for admin: expected %v, got %v", tt.expr, tt.admin, got)
+		}
+		if got := expr.eval(user); got != tt.user {
+			t.Errorf("%q for user: expected %v, got %v", tt.expr, tt.user, got)
+		}
+	}
+}
+
+func TestParsePolicyExpr_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "role:", "scope:", "admin", "group:x", "role:admin ||", "(authenticated", "authenticated)", "role:a | role:b", "public && authenticated"} {
+		if _, err := parsePolicyExpr(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
+
+func TestPolicyMatch(t *testing.T) {
+	p, err := compilePolicy([]PolicyRule{
+		{Method: "get", Path: "/analytics/stats", Require: "public"},
+		{Path: "/analytics/*", Require: "role:admin"},
+		{Method: "*", Path: "/payment/transactions", Require: "authenticated"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/analytics/stats", "public"},
+		{http.MethodPost, "/analytics/stats", "role:admin"},
+		{http.MethodGet, "/analytics", "role:admin"},
+		{http.MethodGet, "/analytics/a/b", "role:admin"},
+		{http.MethodGet, "/analyticsx", ""},
+		{http.MethodDelete, "/payment/transactions", "authenticated"},
+		{http.MethodGet, "/payment/transactions/list", ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if rule := p.match(httptest.NewRequest(tt.method, tt.path, nil)); rule != nil {
+			got = rule.require
+		}
+		if got != tt.want {
+			t.Errorf("%s %s: expected rule %q, got %q", tt.method, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	g, auth := newTestGateway()
+	_, userToken := auth.AddUser("alice", "pw")
+	_, adminToken := auth.AddUserWithRole("root", "pw", "admin")
+	if err := g.setPolicy([]PolicyRule{
+		{Path: "/admin-only", Require: "role:admin"},
+		{Path: "/open", Require: "public"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotUser int
+	handler := g.authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = g.validateAuth(r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name, path, token string
+		want              int
+	}{
+		{"admin", "/admin-only", adminToken, http.StatusNoContent},
+		{"wrong role", "/admin-only", userToken, http.StatusForbidden},
+		{"no token", "/admin-only", "", http.StatusUnauthorized},
+		{"public", "/open", "", http.StatusNoContent},
+		{"unmatched", "/other", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/admin-only", nil)
+	r.Header.Set("Authorization", "Bearer "+adminToken)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if gotUser != 2 {
+		t.Errorf("expected the handler to see user 2, got %d", gotUser)
+	}
+}
+
+func TestAuthorize_DefaultPolicies(t *testing.T) {
+	g, auth := newTestGateway()
+	_, userToken := auth.AddUser("alice", "pw")
+	_, adminToken := auth.AddUserWithRole("root", "pw", "admin")
+	if err := g.setPolicy(defaultPolicies()); err != nil {
+		t.Fatal(err)
+	}
+	handler := g.authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodGet, "/payment/transactions/list", userToken, http.StatusNoContent},
+		{http.MethodPost, "/payment/transactions", userToken, http.StatusNoContent},
+		{http.MethodGet, "/analytics/stats", userToken, http.StatusForbidden},
+		{http.MethodGet, "/analytics/stats", adminToken, http.StatusNoContent},
+		{http.MethodPost, "/auth/login", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// DefaultPoolSize is how many connections the gateway keeps per backend
+const DefaultPoolSize = 2
+
+// connPool spreads calls to one backend over several connections, so a burst
+// of slow calls can't hold up everything behind them on a single HTTP/2
+// connection. Each call goes to the healthy connection with the fewest calls
+// in flight.
+type connPool struct {
+	conns []*pooledConn
+	// next rotates the starting point so ties don't all land on the first connection
+	next atomic.Uint32
+}
+
+type pooledConn struct {
+	conn     *grpc.ClientConn
+	resolver *manual.Resolver
+	pending  atomic.Int64
+}
+
+// dialPool dials size connections to addr, each with opts
+func dialPool(name, addr string, size int, opts ...grpc.DialOption) (*connPool, error) {
+	p := &connPool{}
+	for i := range max(size, 1) {
+		conn, r, err := dialBackend(name+"-"+strconv.Itoa(i), addr, opts...)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, &pooledConn{conn: conn, resolver: r})
+	}
+	return p, nil
+}
+
+// pick returns the least busy connection, preferring ones that aren't failing
+func (p *connPool) pick() *pooledConn {
+	start := int(p.next.Add(1))
+	var best *pooledConn
+	bestHealthy := false
+	for i := range p.conns {
+		c := p.conns[(start+i)%len(p.conns)]
+		healthy := c.healthy()
+		switch {
+		case best == nil,
+			healthy && !bestHealthy,
+			healthy == bestHealthy && c.pending.Load() < best.pending.Load():
+			best, bestHealthy = c, healthy
+		}
+	}
+	return best
+}
+
+// healthy reports whether c can take calls. Idle connections count: a call
+// wakes them up.
+func (c *pooledConn) healthy() bool {
+	state := c.conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+func (p *connPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c := p.pick()
+	c.pending.Add(1)
+	defer c.pending.Add(-1)
+	return c.conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream opens a stream on the least busy connection. Streams don't count
+// as pending; the backends only have unary methods today.
+func (p *connPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().conn.NewStream(ctx, desc, method, opts...)
+}
+
+// setAddr points every connection at addr; in-flight calls finish on the old one
+func (p *connPool) setAddr(addr string) {
+	for _, c := range p.conns {
+		c.resolver.UpdateState(backendState(addr))
+	}
+}
+
+// Close closes every connection in the pool
+func (p *connPool) Close() error {
+	var errs []error
+	for _, c := range p.conns {
+		if err := c.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestPool(t *testing.T, addr string, size int) *connPool {
+	t.Helper()
+	p, err := dialPool("test", addr, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+// closedAddr returns an address nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+	return addr
+}
+
+func TestConnPool_PicksLeastPending(t *testing.T) {
+	p := newTestPool(t, "127.0.0.1:1", 3)
+	p.conns[0].pending.Store(4)
+	p.conns[1].pending.Store(1)
+	p.conns[2].pending.Store(2)
+
+	for range 5 {
+		if got := p.pick(); got != p.conns[1] {
+			t.Fatalf("expected the connection with one call in flight, got %d", got.pending.Load())
+		}
+	}
+}
+
+func TestConnPool_SpreadsTies(t *testing.T) {
+	p := newTestPool(t, "127.0.0.1:1", 2)
+	seen := make(map[*pooledConn]bool)
+	for range 4 {
+		seen[p.pick()] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected idle connections to share calls, got %d in use", len(seen))
+	}
+}
+
+func TestConnPool_SkipsFailingConnections(t *testing.T) {
+	p := newTestPool(t, closedAddr(t), 2)
+	failing := p.conns[0]
+	failing.conn.Connect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for state := failing.conn.GetState(); state != connectivity.TransientFailure; state = failing.conn.GetState() {
+		if !failing.conn.WaitForStateChange(ctx, state) {
+			t.Fatal("connection never failed")
+		}
+	}
+
+	// Busier but healthy wins over idle but failing
+	p.conns[1].pending.Store(10)
+	if got := p.pick(); got != p.conns[1] {
+		t.Error("expected the failing connection to be skipped")
+	}
+}
+
+func TestConnPool_InvokeAndSwitchAddress(t *testing.T) {
+	first := startHealthServer(t, healthpb.HealthCheckResponse_SERVING)
+	second := startHealthServer(t, healthpb.HealthCheckResponse_NOT_SERVING)
+	p := newTestPool(t, first, 2)
+	client := healthpb.NewHealthClient(p)
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		return resp.Status
+	}
+
+	for range 4 {
+		if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected the first backend, got %v", got)
+		}
+	}
+	for _, c := range p.conns {
+		if n := c.pending.Load(); n != 0 {
+			t.Errorf("expected no calls in flight, got %d", n)
+		}
+	}
+
+	p.setAddr(second)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// Both connections must move, so two calls in a row must agree
+		if check() == healthpb.HealthCheckResponse_NOT_SERVING && check() == healthpb.HealthCheckResponse_NOT_SERVING {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("calls never moved to the second backend")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+// QuotaStore counts requests per key
+type QuotaStore interface {
+	// Incr adds one to key and returns the new count; the key expires at expireAt
+	Incr(ctx context.Context, key string, expireAt time.Time) (int64, error)
+	// Get returns the count for key, or 0 when it doesn't exist
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// Quota is a daily request allowance per user. Days are UTC so every
+// gateway instance agrees on when they reset.
+type Quota struct {
+	store QuotaStore
+	limit atomic.Int64
+	now   func() time.Time
+}
+
+// QuotaStatus is a user's allowance for the current day
+type QuotaStatus struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// Exceeded reports whether the request that produced s is over the limit
+func (s QuotaStatus) Exceeded() bool {
+	return s.Used > s.Limit
+}
+
+// NewQuota creates a Quota allowing limit requests per user per day
+func NewQuota(store QuotaStore, limit int64) *Quota {
+	q := &Quota{store: store, now: time.Now}
+	q.limit.Store(limit)
+	return q
+}
+
+// SetLimit changes the daily allowance; counts already made are kept
+func (q *Quota) SetLimit(limit int64) {
+	q.limit.Store(limit)
+}
+
+// Charge counts one request for userID and returns the allowance after it
+func (q *Quota) Charge(ctx context.Context, userID int) (QuotaStatus, error) {
+	key, resetsAt := q.window(userID)
+	// Keep the key a little past the reset so a slow clock can't recreate it
+	used, err := q.store.Incr(ctx, key, resetsAt.Add(time.Hour))
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	return q.status(used, resetsAt), nil
+}
+
+// Status returns the allowance without counting a request
+func (q *Quota) Status(ctx context.Context, userID int) (QuotaStatus, error) {
+	key, resetsAt := q.window(userID)
+	used, err := q.store.Get(ctx, key)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	return q.status(used, resetsAt), nil
+}
+
+// window returns the counter key for userID's current day and when it ends
+func (q *Quota) window(userID int) (string, time.Time) {
+	now := q.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return "quota:" + strconv.Itoa(userID) + ":" + day.Format("20060102"), day.AddDate(0, 0, 1)
+}
+
+func (q *Quota) status(used int64, resetsAt time.Time) QuotaStatus {
+	limit := q.limit.Load()
+	return QuotaStatus{
+		Limit:     limit,
+		Used:      used,
+		Remaining: max(limit-used, 0),
+		ResetsAt:  resetsAt,
+	}
+}
+
+// setQuotaHeaders writes the X-RateLimit-* headers for s
+func setQuotaHeaders(h http.Header, s QuotaStatus) {
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(s.Limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(s.Remaining, 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(s.ResetsAt.Unix(), 10))
+}
+
+// RedisQuotaStore keeps counters in Redis so all gateway instances share them
+type RedisQuotaStore struct {
+	client *redis.Client
+}
+
+// NewRedisQuotaStore creates a RedisQuotaStore
+func NewRedisQuotaStore(client *redis.Client) *RedisQuotaStore {
+	return &RedisQuotaStore{client: client}
+}
+
+func (s *RedisQuotaStore) Incr(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireAt(ctx, key, expireAt)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *RedisQuotaStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// MemoryQuotaStore keeps counters in process, for a single gateway instance
+type MemoryQuotaStore struct {
+	mu        sync.Mutex
+	counts    map[string]memoryCount
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type memoryCount struct {
+	n        int64
+	expireAt time.Time
+}
+
+// NewMemoryQuotaStore creates an empty MemoryQuotaStore
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counts: make(map[string]memoryCount), now: time.Now}
+}
+
+func (s *MemoryQuotaStore) Incr(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for k, c := range s.counts {
+			if !now.Before(c.expireAt) {
+				delete(s.counts, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	c := s.counts[key]
+	if !now.Before(c.expireAt) {
+		c = memoryCount{}
+	}
+	c.n++
+	c.expireAt = expireAt
+	s.counts[key] = c
+	return c.n, nil
+}
+
+func (s *MemoryQuotaStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counts[key]
+	if !ok || !s.now().Before(c.expireAt) {
+		return 0, nil
+	}
+	return c.n, nil
+}
+
+// metered charges the caller's daily quota before next runs. Unauthenticated
+// requests pass through for next to reject; a failing store lets requests
+// through rather than take the API down with it.
+func (g *Gateway) metered(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.quota == nil {
+			next(w, r)
+			return
+		}
+
+		id, err := g.authenticate(r)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		userID := id.userID
+
+		status, err := g.quota.Charge(r.Context(), userID)
+		if err != nil {
+			g.logger.Warn("quota check failed", "error", err, "user_id", userID)
+		} else {
+			setQuotaHeaders(w.Header(), status)
+			if status.Exceeded() {
+				retryAfter := max(status.ResetsAt.Sub(g.quota.now()).Round(time.Second), time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				g.respondError(w, r, errQuotaExceeded)
+				return
+			}
+		}
+
+		next(w, r.WithContext(withIdentity(r.Context(), id)))
+	}
+}
+
+// handleGetQuota reports the caller's allowance without using any of it
+func (g *Gateway) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	if g.quota == nil {
+		g.respondError(w, r, errQuotaDisabled)
+		return
+	}
+
+	status, err := g.quota.Status(r.Context(), userID)
+	if err != nil {
+		g.logger.Error("quota status failed", "error", err, "user_id", userID)
+		g.respondError(w, r, errQuotaUnavailable)
+		return
+	}
+
+	setQuotaHeaders(w.Header(), status)
+	g.respondJSON(w, r, http.StatusOK, status)
+}
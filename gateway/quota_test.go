@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingQuotaStore is a QuotaStore whose backend is down
+type failingQuotaStore struct{}
+
+func (failingQuotaStore) Incr(context.Context, string, time.Time) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (failingQuotaStore) Get(context.Context, string) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+// newQuotaGateway returns a test gateway with a limit of 2 requests on a fixed clock
+func newQuotaGateway(t *testing.T) (*Gateway, string, *time.Time) {
+	t.Helper()
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := NewMemoryQuotaStore()
+	store.now = clock
+	g.quota = NewQuota(store, 2)
+	g.quota.now = clock
+	return g, token, &now
+}
+
+func getSummary(g *Gateway, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/payment/transactions/summary", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.metered(mustLegacyHandler(g))(w, r)
+	return w
+}
+
+func getQuota(g *Gateway, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/me/quota", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleGetQuota(w, r)
+	return w
+}
+
+func TestMetered_HeadersAndLimit(t *testing.T) {
+	g, token, _ := newQuotaGateway(t)
+	reset := "1792195200" // 2026-10-17T00:00:00Z
+
+	for i, remaining := range []string{"1", "0"} {
+		w := getSummary(g, token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d: %s", i+1, w.Code, w.Body)
+		}
+		h := w.Header()
+		if h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != remaining || h.Get("X-RateLimit-Reset") != reset {
+			t.Errorf("request %d: unexpected headers %v", i+1, h)
+		}
+	}
+
+	w := getSummary(g, token)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "7200" {
+		t.Errorf("expected Retry-After of two hours, got %q", got)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"QUOTA_EXCEEDED"`) {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+}
+
+func TestMetered_ResetsAtMidnightUTC(t *testing.T) {
+	g, token, now := newQuotaGateway(t)
+	for range 3 {
+		getSummary(g, token)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if w := getSummary(g, token); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Errorf("expected a fresh allowance, got %d with remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestMetered_UnauthenticatedNotCharged(t *testing.T) {
+	g, token, _ := newQuotaGateway(t)
+
+	if w := getSummary(g, "forged"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if w := getSummary(g, token); w.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Errorf("expected the rejected request not to count, got remaining %q", w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestMetered_StoreFailureFailsOpen(t *testing.T) {
+	g, token, _ := newQuotaGateway(t)
+	g.quota.store = failingQuotaStore{}
+
+	w := getSummary(g, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when the quota store is down, got %d", w.Code)
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("expected no quota headers without a quota status")
+	}
+}
+
+func TestHandleGetQuota(t *testing.T) {
+	g, token, _ := newQuotaGateway(t)
+	getSummary(g, token)
+
+	for range 2 {
+		w := getQuota(g, token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+		}
+		var status QuotaStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if status.Limit != 2 || status.Used != 1 || status.Remaining != 1 || !status.ResetsAt.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected status %+v", status)
+		}
+	}
+}
+
+func TestHandleGetQuota_Disabled(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	if w := getQuota(g, token); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without quotas, got %d", w.Code)
+	}
+	if w := getQuota(g, "forged"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
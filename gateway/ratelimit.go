@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitBurst is how many requests a limit lets through at once
+// when no burst is configured
+const DefaultRateLimitBurst = 20
+
+// rateLimitSweep is how often buckets of clients gone quiet are dropped
+const rateLimitSweep = time.Minute
+
+// RateLimit is a token bucket: Rate requests per second on average, with
+// bursts of up to Burst. A zero Rate doesn't limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether l limits anything
+func (l RateLimit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// burst is the bucket size, at least one request
+func (l RateLimit) burst() float64 {
+	return float64(max(l.Burst, 1))
+}
+
+// tokenBucket holds the tokens left as of last
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since last, up to the limit's burst
+func (b *tokenBucket) refill(l RateLimit, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = l.burst()
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*l.Rate, l.burst())
+	}
+	b.last = now
+}
+
+// wait is how long until the bucket holds a whole token
+func (b *tokenBucket) wait(l RateLimit) time.Duration {
+	return time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+}
+
+// RateLimiter protects the backends from request floods with a token bucket
+// per client address and one shared by all clients. A request takes a token
+// from both or from neither, so requests refused by the global limit don't
+// use up their client's allowance. Limits are per gateway instance.
+type RateLimiter struct {
+	perIP  RateLimit
+	global RateLimit
+	now    func() time.Time
+
+	mu        sync.Mutex
+	shared    tokenBucket
+	clients   map[string]*tokenBucket
+	nextSweep time.Time
+}
+
+// NewRateLimiter creates a RateLimiter; either limit may be disabled
+func NewRateLimiter(perIP, global RateLimit) *RateLimiter {
+	return &RateLimiter{
+		perIP:   perIP,
+		global:  global,
+		now:     time.Now,
+		clients: make(map[string]*tokenBucket),
+	}
+}
+
+// WithRateLimiter limits requests before they reach any handler
+func WithRateLimiter(l *RateLimiter) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.rateLimiter = l
+	}
+}
+
+// Allow takes a token for a request from ip, or reports how long until one
+// would be available
+func (l *RateLimiter) Allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var wait time.Duration
+	var client *tokenBucket
+	if l.perIP.Enabled() {
+		client = l.clients[ip]
+		if client == nil {
+			client = &tokenBucket{}
+			l.clients[ip] = client
+		}
+		client.refill(l.perIP, now)
+		if client.tokens < 1 {
+			wait = client.wait(l.perIP)
+		}
+	}
+	if l.global.Enabled() {
+		l.shared.refill(l.global, now)
+		if l.shared.tokens < 1 {
+			wait = max(wait, l.shared.wait(l.global))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	if client != nil {
+		client.tokens--
+	}
+	if l.global.Enabled() {
+		l.shared.tokens--
+	}
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely, as a new bucket
+// would start out the same
+func (l *RateLimiter) sweep(now time.Time) {
+	if !l.perIP.Enabled() || now.Before(l.nextSweep) {
+		return
+	}
+	full := time.Duration(l.perIP.burst() / l.perIP.Rate * float64(time.Second))
+	for ip, b := range l.clients {
+		if now.Sub(b.last) >= full {
+			delete(l.clients, ip)
+		}
+	}
+	l.nextSweep = now.Add(rateLimitSweep)
+}
+
+// withRateLimit answers 429 with Retry-After to requests over the limits.
+// Health checks always pass, so a flood doesn't get the gateway restarted.
+func (g *Gateway) withRateLimit(next http.Handler) http.Handler {
+	if g.rateLimiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait := g.rateLimiter.Allow(clientIP(r))
+		if !ok {
+			setRetryAfter(w, wait)
+			g.respondError(w, r, errRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRetryAfter tells the client to retry after wait. Retry-After has whole
+// seconds; round up so a retry isn't early.
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
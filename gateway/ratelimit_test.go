@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter returns a RateLimiter on a clock the caller moves
+func newTestRateLimiter(perIP, global RateLimit) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(perIP, global)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	l, now := newTestRateLimiter(RateLimit{Rate: 2, Burst: 3}, RateLimit{})
+
+	for i := range 3 {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d: expected the burst to pass", i+1)
+		}
+	}
+	ok, wait := l.Allow("10.0.0.1")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected a refusal with a 500ms wait, got %v, %v", ok, wait)
+	}
+	if ok, _ := l.Allow("10.0.0.2"); !ok {
+		t.Error("expected another client unaffected")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("expected a token after 500ms")
+	}
+}
+
+func TestRateLimiter_GlobalRefusalKeepsClientTokens(t *testing.T) {
+	l, now := newTestRateLimiter(RateLimit{Rate: 1, Burst: 2}, RateLimit{Rate: 1, Burst: 1})
+
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Fatal("expected the first request to pass")
+	}
+	if ok, wait := l.Allow("10.0.0.1"); ok || wait != time.Second {
+		t.Fatalf("expected the global limit to refuse for 1s, got %v, %v", ok, wait)
+	}
+
+	// The client still holds the token the refused request didn't take
+	*now = now.Add(time.Second)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Fatal("expected a request once the global bucket refilled")
+	}
+	if got := l.clients["10.0.0.1"].tokens; got != 1 {
+		t.Errorf("expected 1 client token left, got %v", got)
+	}
+}
+
+func TestRateLimiter_SweepsRefilledClients(t *testing.T) {
+	l, now := newTestRateLimiter(RateLimit{Rate: 1, Burst: 5}, RateLimit{})
+	l.Allow("10.0.0.1")
+
+	*now = now.Add(rateLimitSweep)
+	l.Allow("10.0.0.2")
+	if _, ok := l.clients["10.0.0.1"]; ok || len(l.clients) != 1 {
+		t.Errorf("expected only the active client kept, got %d", len(l.clients))
+	}
+}
+
+func TestWithRateLimit_RespondsTooManyRequests(t *testing.T) {
+	g, _ := newTestGateway()
+	l, _ := newTestRateLimiter(RateLimit{Rate: 0.5, Burst: 1}, RateLimit{})
+	g.rateLimiter = l
+	handler := g.withRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = "203.0.113.7:51234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("/payment/transactions/list"); w.Code != http.StatusNoContent {
+		t.Fatalf("expected the first request served, got %d", w.Code)
+	}
+	w := serve("/payment/transactions/list")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After of 2 seconds, got %q", got)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"RATE_LIMITED"`) {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+	if w := serve("/health"); w.Code != http.StatusNoContent {
+		t.Errorf("expected health checks exempt, got %d", w.Code)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/ulid"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Receipt upload defaults
+const (
+	DefaultReceiptMaxBytes = 5 << 20
+	DefaultReceiptURLTTL   = 15 * time.Minute
+
+	receiptPath         = "/payment/transactions/receipt"
+	receiptDownloadPath = "/receipts"
+	receiptFormField    = "receipt"
+	receiptTxField      = "transaction_id"
+	// receiptMemory is how much of a multipart upload is buffered in memory
+	// before the rest spills to a temporary file
+	receiptMemory = 1 << 20
+)
+
+// receiptContentTypes are the sniffed types accepted as receipts
+var receiptContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// Receipts stores receipt files and signs links for downloading them. The
+// payment service keeps only the metadata and the blob key.
+type Receipts struct {
+	store    blob.Store
+	signer   *blob.URLSigner
+	ttl      time.Duration
+	maxBytes int64
+}
+
+// NewReceipts creates Receipts backed by store whose download links, signed
+// with secret, stay valid for ttl
+func NewReceipts(store blob.Store, secret []byte, ttl time.Duration, maxBytes int64) *Receipts {
+	return &Receipts{
+		store:    store,
+		signer:   blob.NewURLSigner(secret),
+		ttl:      ttl,
+		maxBytes: maxBytes,
+	}
+}
+
+// WithReceipts enables the receipt endpoints
+func WithReceipts(r *Receipts) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.receipts = r
+	}
+}
+
+// ReceiptResponse describes a transaction's receipt and a time-limited link
+// to download it
+type ReceiptResponse struct {
+	// TransactionID or TransactionULID echo how the request named the
+	// transaction
+	TransactionID   int64     `json:"transaction_id,omitempty"`
+	TransactionULID string    `json:"transaction_ulid,omitempty"`
+	Filename        string    `json:"filename,omitempty"`
+	ContentType     string    `json:"content_type"`
+	Size            int64     `json:"size"`
+	UploadedAt      time.Time `json:"uploaded_at"`
+	URL             string    `json:"url"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// transactionRef names a transaction by ID or, when ulid is set, by ULID
+type transactionRef struct {
+	id   int64
+	ulid string
+}
+
+// parseTransactionRef parses a positive integer ID or a ULID
+func parseTransactionRef(value string) (transactionRef, bool) {
+	if ulid.Valid(value) {
+		return transactionRef{ulid: strings.ToUpper(value)}, true
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return transactionRef{}, false
+	}
+	return transactionRef{id: id}, true
+}
+
+// String is the ID or ULID ref was parsed from
+func (ref transactionRef) String() string {
+	if ref.ulid != "" {
+		return ref.ulid
+	}
+	return strconv.FormatInt(ref.id, 10)
+}
+
+// handleReceipt uploads a receipt with POST and returns a download link with
+// GET, for the transaction named by ?transaction_id=, its ID or ULID. An
+// upload may instead name it in a transaction_id form field, as an HTML form
+// would.
+func (g *Gateway) handleReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		g.uploadReceipt(w, r, int64(userID))
+		return
+	}
+	tx, ok := parseTransactionRef(r.URL.Query().Get(receiptTxField))
+	if !ok {
+		g.respondError(w, r, errInvalidTxID)
+		return
+	}
+	g.getReceipt(w, r, int64(userID), tx)
+}
+
+func (g *Gateway) uploadReceipt(w http.ResponseWriter, r *http.Request, userID int64) {
+	err := r.ParseMultipartForm(receiptMemory)
+	if r.MultipartForm != nil {
+		defer func() { _ = r.MultipartForm.RemoveAll() }()
+	}
+	var (
+		file   multipart.File
+		header *multipart.FileHeader
+	)
+	if err == nil {
+		file, header, err = r.FormFile(receiptFormField)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			g.respondError(w, r, errBodyTooLarge)
+		case errors.Is(err, http.ErrMissingFile), errors.Is(err, http.ErrNotMultipart):
+			g.respondError(w, r, errMissingReceipt)
+		default:
+			g.respondError(w, r, errInvalidBody)
+		}
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	tx, ok := parseTransactionRef(cmp.Or(r.URL.Query().Get(receiptTxField), r.PostFormValue(receiptTxField)))
+	if !ok {
+		g.respondError(w, r, errInvalidTxID)
+		return
+	}
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		g.respondError(w, r, errInvalidBody)
+		return
+	}
+	if !receiptContentTypes[contentType] {
+		g.respondError(w, r, errReceiptType)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	key := receiptKey(userID, tx)
+	if err := g.receipts.store.Put(ctx, key, file, header.Size, contentType); err != nil {
+		g.logger.Error("store receipt failed", "error", err)
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to store receipt"))
+		return
+	}
+
+	resp, err := g.paymentClient.AttachReceipt(paymentContext(ctx, r), &paymentpb.AttachReceiptRequest{
+		UserId:          userID,
+		TransactionId:   tx.id,
+		TransactionUlid: tx.ulid,
+		Receipt: &paymentpb.Receipt{
+			Key:         key,
+			ContentType: contentType,
+			Size:        header.Size,
+			Filename:    header.Filename,
+			UploadedAt:  timestamppb.Now(),
+		},
+	})
+	if err != nil {
+		g.logger.Error("attach receipt failed", "error", err)
+		g.deleteReceiptBlob(key)
+		g.respondError(w, r, upstreamError(err, apperror.ErrInternalServer.WithMessage("failed to attach receipt")))
+		return
+	}
+	if resp.ReplacedKey != "" {
+		g.deleteReceiptBlob(resp.ReplacedKey)
+	}
+
+	g.respondJSON(w, r, http.StatusCreated, g.receiptResponse(tx, resp.Receipt))
+}
+
+func (g *Gateway) getReceipt(w http.ResponseWriter, r *http.Request, userID int64, tx transactionRef) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	receipt, err := g.paymentClient.GetReceipt(paymentContext(ctx, r), &paymentpb.GetReceiptRequest{
+		UserId:          userID,
+		TransactionId:   tx.id,
+		TransactionUlid: tx.ulid,
+	})
+	if err != nil {
+		g.logger.Error("get receipt failed", "error", err)
+		g.respondError(w, r, upstreamError(err, apperror.ErrInternalServer.WithMessage("failed to get receipt")))
+		return
+	}
+
+	g.respondJSON(w, r, http.StatusOK, g.receiptResponse(tx, receipt))
+}
+
+// receiptResponse describes receipt with a freshly signed download link
+func (g *Gateway) receiptResponse(tx transactionRef, receipt *paymentpb.Receipt) ReceiptResponse {
+	expiresAt := time.Now().Add(g.receipts.ttl).Truncate(time.Second)
+	link := url.URL{Path: receiptDownloadPath, RawQuery: g.receipts.signer.Sign(receipt.Key, expiresAt).Encode()}
+	return ReceiptResponse{
+		TransactionID:   tx.id,
+		TransactionULID: tx.ulid,
+		Filename:        receipt.Filename,
+		ContentType:     receipt.ContentType,
+		Size:            receipt.Size,
+		UploadedAt:      receipt.UploadedAt.AsTime(),
+		URL:             link.String(),
+		ExpiresAt:       expiresAt.UTC(),
+	}
+}
+
+// handleDownloadReceipt streams the receipt named by a link from
+// receiptResponse. The signature is the only credential, so links can be
+// opened directly by a browser.
+func (g *Gateway) handleDownloadReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	key, err := g.receipts.signer.Verify(r.URL.Query())
+	if err != nil {
+		g.respondError(w, r, errReceiptLink)
+		return
+	}
+
+	g.serveBlob(w, r, g.receipts.store, key, "receipt")
+}
+
+// serveBlob streams the blob at key to a private, uncached response. what
+// names the file in errors.
+func (g *Gateway) serveBlob(w http.ResponseWriter, r *http.Request, store blob.Store, key, what string) {
+	body, info, err := store.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		g.respondError(w, r, apperror.ErrNotFound.WithMessage(what+" not found"))
+		return
+	}
+	if err != nil {
+		g.logger.Error("read "+what+" failed", "error", err)
+		g.respondError(w, r, apperror.ErrInternalServer.WithMessage("failed to read "+what))
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		g.logger.Error("failed to write "+what, "error", err)
+	}
+}
+
+// deleteReceiptBlob removes a receipt that is no longer referenced. Failures
+// only leave an orphaned file, so they are logged rather than returned.
+func (g *Gateway) deleteReceiptBlob(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := g.receipts.store.Delete(ctx, key); err != nil {
+		g.logger.Warn("delete receipt failed", "key", key, "error", err)
+	}
+}
+
+// sniffContentType detects the type of file from its first bytes and rewinds
+// it, so the client's declared type is never trusted
+func sniffContentType(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// receiptKey returns a fresh blob key, so an upload that fails to attach
+// never clobbers the receipt already attached
+func receiptKey(userID int64, tx transactionRef) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "receipts/" + strconv.Itoa(int(userID)) + "/" + tx.String() + "/" + hex.EncodeToString(b)
+}
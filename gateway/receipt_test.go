@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+)
+
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func newReceiptGateway(t *testing.T) (*Gateway, string) {
+	t.Helper()
+	g, auth := newTestGateway()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.receipts = NewReceipts(store, []byte("secret"), time.Minute, DefaultReceiptMaxBytes)
+	_, token := auth.AddUser("alice", "pw")
+	if w := createTransaction(g, token, `{"amount":10}`, ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	return g, token
+}
+
+func uploadReceipt(g *Gateway, token, query, filename, content string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile(receiptFormField, filename)
+	_, _ = part.Write([]byte(content))
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, receiptPath+"?"+query, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleReceipt(w, r)
+	return w
+}
+
+func downloadReceipt(g *Gateway, link string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	g.handleDownloadReceipt(w, httptest.NewRequest(http.MethodGet, link, nil))
+	return w
+}
+
+func TestHandleReceipt_UploadAndDownload(t *testing.T) {
+	g, token := newReceiptGateway(t)
+
+	w := uploadReceipt(g, token, "transaction_id=1", "taxi.png", pngHeader+"first")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var first ReceiptResponse
+	if err := json.NewDecoder(w.Body).Decode(&first); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if first.ContentType != "image/png" || first.Filename != "taxi.png" || first.Size != int64(len(pngHeader)+5) {
+		t.Errorf("unexpected receipt: %+v", first)
+	}
+
+	w = downloadReceipt(g, first.URL)
+	if w.Code != http.StatusOK || w.Body.String() != pngHeader+"first" || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("unexpected download %d %q %v", w.Code, w.Body, w.Header())
+	}
+
+	// Replacing the receipt removes the old file
+	if w := uploadReceipt(g, token, "transaction_id=1", "taxi.png", pngHeader+"second"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	if w := downloadReceipt(g, first.URL); w.Code != http.StatusNotFound {
+		t.Errorf("expected the replaced receipt to be gone, got %d", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, receiptPath+"?transaction_id=1", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	g.handleReceipt(w, r)
+	var current ReceiptResponse
+	if err := json.NewDecoder(w.Body).Decode(&current); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w := downloadReceipt(g, current.URL); w.Body.String() != pngHeader+"second" {
+		t.Errorf("expected the second receipt, got %q", w.Body)
+	}
+
+	if w := downloadReceipt(g, strings.Replace(current.URL, "sig=", "sig=x", 1)); w.Code != http.StatusForbidden {
+		t.Errorf("expected a tampered link to be refused, got %d", w.Code)
+	}
+}
+
+func TestHandleReceipt_ByULID(t *testing.T) {
+	g, token := newReceiptGateway(t)
+	w := createTransaction(g, token, `{"amount":5}`, "")
+	var created struct {
+		ID   int32  `json:"id"`
+		ULID string `json:"ulid"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || created.ULID == "" {
+		t.Fatalf("expected a ULID in %s, got %v", w.Body, err)
+	}
+
+	w = uploadReceipt(g, token, "transaction_id="+strings.ToLower(created.ULID), "taxi.png", pngHeader+"ulid")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var uploaded ReceiptResponse
+	if err := json.NewDecoder(w.Body).Decode(&uploaded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if uploaded.TransactionULID != created.ULID || uploaded.TransactionID != 0 {
+		t.Errorf("expected the transaction named by its ULID, got %+v", uploaded)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, receiptPath+fmt.Sprintf("?transaction_id=%d", created.ID), nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	g.handleReceipt(w, r)
+	var byID ReceiptResponse
+	if err := json.NewDecoder(w.Body).Decode(&byID); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w := downloadReceipt(g, byID.URL); w.Body.String() != pngHeader+"ulid" {
+		t.Errorf("expected the receipt uploaded by ULID, got %q", w.Body)
+	}
+}
+
+func TestHandleReceipt_TransactionFormField(t *testing.T) {
+	g, token := newReceiptGateway(t)
+
+	// An HTML form names the transaction in a field next to the file
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField(receiptTxField, "1")
+	part, _ := mw.CreateFormFile(receiptFormField, "taxi.png")
+	_, _ = part.Write([]byte(pngHeader + "form"))
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, receiptPath, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleReceipt(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var resp ReceiptResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.TransactionID != 1 {
+		t.Errorf("expected the receipt attached to transaction 1, got %+v, %v", resp, err)
+	}
+}
+
+func TestHandleReceipt_Rejected(t *testing.T) {
+	g, token := newReceiptGateway(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		content string
+		want    int
+	}{
+		{"missing transaction", "", pngHeader, http.StatusBadRequest},
+		{"unknown transaction", "transaction_id=99", pngHeader, http.StatusNotFound},
+		{"unknown ULID", "transaction_id=01ARZ3NDEKTSV4RRFFQ69G5FAV", pngHeader, http.StatusNotFound},
+		{"neither ID nor ULID", "transaction_id=tx-1", pngHeader, http.StatusBadRequest},
+		{"not an image or PDF", "transaction_id=1", "<html><script>", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := uploadReceipt(g, token, tt.query, "r.png", tt.content); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body)
+			}
+		})
+	}
+
+	if w := uploadReceipt(g, "bad-token", "transaction_id=1", "r.png", pngHeader); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// RPCs annotated with google.api.http options in the protos are served as
+// REST under /v1/ by grpc-gateway, so a new RPC gets its route, and request
+// and response shapes follow the protos, without gateway code. Bodies and
+// responses are the messages' JSON with proto field names; int64 fields are
+// strings, as protojson writes them.
+//
+// The routes clients used before /v1/, such as /auth/login and
+// /payment/transactions/list, are additional bindings of the same RPCs and
+// are served by the same generated handlers. Each keeps its original
+// behaviour, from its status codes to its JSON and errors, through its entry
+// in legacyRoutes.
+
+// restPrefix is the path the generated routes are served under
+const restPrefix = "/v1/"
+
+// newRESTHandler returns the generated routes of the auth and payment
+// services, calling them through the gateway's clients
+func (g *Gateway) newRESTHandler(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(restHeader),
+		runtime.WithOutgoingHeaderMatcher(restResponseHeader),
+		runtime.WithMetadata(restMetadata),
+		runtime.WithErrorHandler(g.restError),
+		runtime.WithRoutingErrorHandler(g.restRoutingError),
+	)
+	if err := authpb.RegisterAuthServiceHandlerClient(ctx, mux, g.authClient); err != nil {
+		return nil, err
+	}
+	if err := paymentpb.RegisterPaymentServiceHandlerClient(ctx, mux, g.paymentClient); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// handleREST serves rest with the timeout of the gateway's other routes
+func (g *Gateway) handleREST(rest http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		rest.ServeHTTP(w, restRequest(ctx, r))
+	}
+}
+
+// restRequest returns r with ctx for a generated handler
+func restRequest(ctx context.Context, r *http.Request) *http.Request {
+	r = r.Clone(ctx)
+	// grpc-gateway forwards X-Forwarded-For ahead of the connecting address,
+	// and the backends record the first address as the client's. Like the
+	// other routes, trust only the connection.
+	r.Header.Del("X-Forwarded-For")
+	return r
+}
+
+// deviceIDHeader carries an identifier the client keeps across sessions
+const deviceIDHeader = "X-Device-ID"
+
+// restHeader forwards only the User-Agent and X-Device-ID, which the auth
+// service records for device recognition. Authorization is always forwarded;
+// other headers, Grpc-Metadata-* included, could otherwise spoof internal
+// metadata.
+func restHeader(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case "User-Agent":
+		return runtime.MetadataPrefix + "user-agent", true
+	case http.CanonicalHeaderKey(deviceIDHeader):
+		return runtime.MetadataPrefix + "device-id", true
+	}
+	return "", false
+}
+
+// restResponseHeader writes the spending headroom CreateTransaction reports
+// as the Current-Total and Remaining-Limit headers. Other response metadata
+// stays internal.
+func restResponseHeader(key string) (string, bool) {
+	switch key {
+	case "current-total", "remaining-limit":
+		return key, true
+	}
+	return "", false
+}
+
+// restMetadata forwards what the gateway's other routes do: the client
+// channel and geo, and identities from trusted headers. The bearer token
+// comes from the Authorization header grpc-gateway forwards itself.
+func restMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(paymentContext(ctx, r))
+	md = md.Copy()
+	md.Delete(grpcauth.MetadataAuthorization)
+	return md
+}
+
+// restError writes a backend error like the gateway's other routes do
+func (g *Gateway) restError(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.FailedPrecondition:
+		switch errorReason(st) {
+		case apperror.CodeLimitExceeded:
+			g.respondCreateTransactionError(w, r, err)
+		case apperror.CodeChargeFailed:
+			g.respondError(w, r, errChargeFailed)
+		default:
+			g.respondError(w, r, apperror.ErrConflict.WithMessage(st.Message()))
+		}
+		return
+	case codes.Unavailable, codes.DeadlineExceeded:
+		g.respondError(w, r, errBackendUnavailable)
+		return
+	}
+	appErr := upstreamError(err, apperror.ErrInternalServer)
+	if appErr == apperror.ErrInternalServer {
+		g.logger.ErrorContext(ctx, "REST call failed", "error", err, "method", r.Method, "path", r.URL.Path)
+	}
+	g.respondError(w, r, appErr)
+}
+
+// errorReason returns the reason of st's ErrorInfo, if any
+func errorReason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// restRoutingError writes 404s and 405s for requests no generated route
+// serves
+func (g *Gateway) restRoutingError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	if httpStatus == http.StatusMethodNotAllowed {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	g.respondError(w, r, apperror.ErrNotFound)
+}
+
+// newLegacyRESTHandler returns the generated routes for legacyRoutes. It
+// answers only requests handleLegacyREST has prepared.
+func (g *Gateway) newLegacyRESTHandler(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &legacyMarshaler{}),
+		runtime.WithIncomingHeaderMatcher(restHeader),
+		// Backends' response metadata stays internal
+		runtime.WithOutgoingHeaderMatcher(func(string) (string, bool) { return "", false }),
+		runtime.WithMetadata(restMetadata),
+		runtime.WithForwardResponseOption(g.legacyRespond),
+		runtime.WithErrorHandler(g.legacyError),
+		runtime.WithRoutingErrorHandler(g.restRoutingError),
+	)
+	if err := authpb.RegisterAuthServiceHandlerClient(ctx, mux, g.authClient); err != nil {
+		return nil, err
+	}
+	if err := paymentpb.RegisterPaymentServiceHandlerClient(ctx, mux, g.paymentClient); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// legacyCall is the route and request a generated handler is serving, for
+// the response and error it hands back
+type legacyCall struct {
+	route legacyRoute
+	r     *http.Request
+}
+
+type legacyCallKey struct{}
+
+// handleLegacyREST serves the route in legacyRoutes matching r from legacy.
+// Like the hand-written handlers the routes replaced, it checks the method,
+// then the token, then strictly decodes the body before calling the RPC.
+func (g *Gateway) handleLegacyREST(legacy http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route, ok := legacyRoutes[r.Method+" "+r.URL.Path]
+		if !ok {
+			g.respondError(w, r, errMethodNotAllowed)
+			return
+		}
+		if route.auth {
+			if _, err := g.validateAuth(r); err != nil {
+				g.respondError(w, r, apperror.ErrUnauthorized)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		call := &legacyCall{route: route}
+		r = restRequest(context.WithValue(ctx, legacyCallKey{}, call), r)
+		call.r = r
+
+		query := r.URL.Query()
+		r.URL.RawQuery = ""
+		if route.query != nil {
+			query, appErr := route.query(query)
+			if appErr != nil {
+				g.respondError(w, r, appErr)
+				return
+			}
+			r.URL.RawQuery = query.Encode()
+		}
+		if route.body == nil {
+			r.Body, r.ContentLength = http.NoBody, 0
+		} else if !g.decodeLegacyBody(w, r, route) {
+			return
+		}
+		legacy.ServeHTTP(w, r)
+	}
+}
+
+// decodeLegacyBody strictly decodes r's body into route's message and
+// replaces it with the message's proto JSON for the generated handler. It
+// writes the error and returns false for a malformed body.
+func (g *Gateway) decodeLegacyBody(w http.ResponseWriter, r *http.Request, route legacyRoute) bool {
+	msg := route.body()
+	decode := request.DecodeJSON
+	if route.forms {
+		decode = request.DecodeBody
+	}
+	if err := decode(r, msg); err != nil {
+		g.respondDecodeError(w, r, err)
+		return false
+	}
+	body, err := protojson.Marshal(msg)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrInternalServer)
+		return false
+	}
+	r.Body, r.ContentLength = io.NopCloser(bytes.NewReader(body)), int64(len(body))
+	r.Header.Set("Content-Type", "application/json")
+	return true
+}
+
+// legacyMarshaler reads proto JSON bodies and writes nothing: legacyRespond
+// has already written the route's response
+type legacyMarshaler struct {
+	runtime.JSONPb
+}
+
+func (*legacyMarshaler) Marshal(any) ([]byte, error) {
+	return nil, nil
+}
+
+// legacyRespond writes an RPC's response as its route does
+func (g *Gateway) legacyRespond(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	call := ctx.Value(legacyCallKey{}).(*legacyCall)
+	// ctx carries the RPC's response metadata
+	call.route.respond(g, w, call.r.WithContext(ctx), resp)
+	return nil
+}
+
+// legacyError writes an RPC's error as its route does
+func (g *Gateway) legacyError(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	g.logger.ErrorContext(ctx, "REST call failed", "error", err, "method", r.Method, "path", r.URL.Path)
+	call := ctx.Value(legacyCallKey{}).(*legacyCall)
+	call.route.fail(g, w, r, err)
+}
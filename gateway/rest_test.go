@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+func TestREST(t *testing.T) {
+	g, _ := newTestGateway()
+	rest, err := g.newRESTHandler(context.Background())
+	if err != nil {
+		t.Fatalf("failed to register REST routes: %v", err)
+	}
+	handler := g.handleREST(rest)
+	serve := func(method, path, body, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/v1/auth/register", `{"username":"alice","password":"pw"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from register, got %d: %s", w.Code, w.Body)
+	}
+	var registered struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&registered); err != nil || registered.Token == "" || registered.ID == "" {
+		t.Fatalf("expected the AuthResponse with proto field names, got %v: %s", err, w.Body)
+	}
+
+	body := `{"user_id":"` + registered.ID + `","amount":900,"description":"laptop"}`
+	w = serve(http.MethodPost, "/v1/payment/transactions", body, registered.Token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from create, got %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Description string `json:"description"`
+		IsPaid      bool   `json:"is_paid"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Description != "laptop" {
+		t.Errorf("unexpected create response %s", w.Body)
+	}
+	if total, remaining := w.Header().Get("Current-Total"), w.Header().Get("Remaining-Limit"); total != "900.00" || remaining != "100.00" {
+		t.Errorf("expected the headroom in headers, got %q and %q", total, remaining)
+	}
+	if !strings.Contains(w.Body.String(), `"is_paid":false`) {
+		t.Errorf("expected unpopulated fields emitted, got %s", w.Body)
+	}
+
+	// Backend errors are written like the hand-written routes write them
+	w = serve(http.MethodPost, "/v1/payment/transactions", body, registered.Token)
+	var limit ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&limit); err != nil || w.Code != http.StatusBadRequest || limit.Code != errLimitExceeded.Code || limit.CurrentTotal != "900.00" {
+		t.Errorf("expected 400 LIMIT_EXCEEDED with the limit details, got %d: %+v", w.Code, limit)
+	}
+	w = serve(http.MethodPost, "/v1/auth/register", `{"username":"alice","password":"pw"}`, "")
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a taken username, got %d: %s", w.Code, w.Body)
+	}
+	w = serve(http.MethodPost, "/v1/payment/transactions", `{"amount":`, registered.Token)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed body, got %d: %s", w.Code, w.Body)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/v1/unknown", http.StatusNotFound},
+		{http.MethodPatch, "/v1/payment/transactions", http.StatusMethodNotAllowed},
+		// Admin RPCs have no annotation, so no route
+		{http.MethodGet, "/v1/auth/users", http.StatusNotFound},
+	} {
+		if w := serve(tc.method, tc.path, "", registered.Token); w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.method, tc.path, tc.want, w.Code, w.Body)
+		}
+	}
+}
+
+func TestRESTHeader_ForwardsOnlyUserAgentAndDeviceID(t *testing.T) {
+	if key, ok := restHeader("User-Agent"); !ok || key != "grpcgateway-user-agent" {
+		t.Errorf("expected the user agent forwarded, got %q, %v", key, ok)
+	}
+	if key, ok := restHeader("x-device-id"); !ok || key != "grpcgateway-device-id" {
+		t.Errorf("expected the device ID forwarded, got %q, %v", key, ok)
+	}
+	for _, header := range []string{"Grpc-Metadata-X-Service-Token", "X-User-Id", "Cookie"} {
+		if _, ok := restHeader(header); ok {
+			t.Errorf("expected %s dropped", header)
+		}
+	}
+}
+
+func TestLegacyREST(t *testing.T) {
+	g, auth := newTestGateway()
+	auth.AddUser("alice", "pw")
+	userID, token := auth.AddUser("bob", "pw")
+	legacy := mustLegacyHandler(g)
+	serve := func(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		maps.Copy(r.Header, header)
+		w := httptest.NewRecorder()
+		legacy.ServeHTTP(w, r)
+		return w
+	}
+	bearer := http.Header{"Authorization": {"Bearer " + token}}
+
+	// The device ID header reaches the auth service, so a new browser
+	// version stays one device
+	for _, userAgent := range []string{"Firefox/120", "Firefox/121"} {
+		header := http.Header{"User-Agent": {userAgent}, deviceIDHeader: {"laptop"}}
+		if w := serve(http.MethodPost, "/auth/login", `{"username":"alice","password":"pw"}`, header); w.Code != http.StatusOK {
+			t.Fatalf("expected 200 from login, got %d: %s", w.Code, w.Body)
+		}
+	}
+	w := serve(http.MethodPost, "/auth/login", `{"username":"alice","password":"pw"}`, nil)
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&login); err != nil {
+		t.Fatalf("failed to decode login: %v", err)
+	}
+	w = serve(http.MethodGet, "/auth/devices", "", http.Header{"Authorization": {"Bearer " + login.Token}})
+	var devices []DeviceResponse
+	if err := json.NewDecoder(w.Body).Decode(&devices); err != nil || len(devices) != 2 || devices[0].ID != "laptop" || devices[0].UserAgent != "Firefox/121" {
+		t.Errorf("expected the laptop and the login without a device ID, got %+v, %v", devices, err)
+	}
+
+	// The transaction is the token's user's; another user's ID is refused
+	w = serve(http.MethodPost, "/payment/transactions", `{"amount":10}`, bearer)
+	var created CreateTransactionResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || w.Code != http.StatusCreated || created.GetUserId() != int64(userID) {
+		t.Errorf("expected 201 for the token's user, got %d: %+v", w.Code, created)
+	}
+	body := fmt.Sprintf(`{"amount":10,"user_id":%d}`, userID+1)
+	if w := serve(http.MethodPost, "/payment/transactions", body, bearer); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user's ID, got %d: %s", w.Code, w.Body)
+	}
+
+	for _, tc := range []struct {
+		name, method, path, body string
+		header                   http.Header
+		want                     int
+	}{
+		{"method", http.MethodDelete, "/payment/transactions/summary", "", bearer, http.StatusMethodNotAllowed},
+		{"no token", http.MethodGet, "/payment/transactions/summary", "", nil, http.StatusUnauthorized},
+		{"unknown field", http.MethodPut, "/auth/preferences", `{"theme":"dark"}`, bearer, http.StatusBadRequest},
+		{"empty body", http.MethodPost, "/payment/transactions", "", bearer, http.StatusBadRequest},
+		// Routes without a body or query ignore them, as they always have
+		{"ignored body", http.MethodPost, "/payment/transactions/pay", `{"user_id":99}`, bearer, http.StatusOK},
+		{"ignored query", http.MethodGet, "/payment/transactions/summary?user_id=99", "", bearer, http.StatusOK},
+		{"invalid limit", http.MethodGet, "/payment/transactions/list?limit=-1", "", bearer, http.StatusBadRequest},
+		{"list query", http.MethodGet, "/payment/transactions/list?sort_by=amount&order=desc&limit=5&fields=id,amount", "", bearer, http.StatusOK},
+	} {
+		if w := serve(tc.method, tc.path, tc.body, tc.header); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, w.Code, w.Body)
+		}
+	}
+}
+
+func TestTransactionsQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		want    string
+		wantErr *apperror.AppError
+	}{
+		{query: "", want: ""},
+		{query: "fields=id,amount&sort_by=amount&order=asc", want: "field_mask=id%2Camount&order=SORT_ORDER_ASC&sort_by=SORT_BY_AMOUNT"},
+		{query: "sort_by=created_at&order=desc", want: "order=SORT_ORDER_DESC&sort_by=SORT_BY_CREATED_AT"},
+		{query: "limit=5000&cursor=abc", want: "page.cursor=abc&page.limit=1000"},
+		{query: "cursor=abc", want: "page.cursor=abc&page.limit=0"},
+		{query: "include_archived=1", want: "include_archived=true"},
+		{query: "sort_by=name", wantErr: errInvalidSortBy},
+		{query: "order=up", wantErr: errInvalidOrder},
+		{query: "limit=ten", wantErr: errInvalidLimit},
+		{query: "include_archived=maybe", wantErr: errInvalidArchived},
+	} {
+		values, _ := url.ParseQuery(tc.query)
+		got, appErr := transactionsQuery(values)
+		if appErr != tc.wantErr {
+			t.Errorf("%q: expected error %v, got %v", tc.query, tc.wantErr, appErr)
+			continue
+		}
+		if appErr == nil && got.Encode() != tc.want {
+			t.Errorf("%q: expected %q, got %q", tc.query, tc.want, got.Encode())
+		}
+	}
+}
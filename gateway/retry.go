@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Retrier defaults
+const (
+	// DefaultRetryAttempts is how many times a call is tried in all
+	DefaultRetryAttempts = 3
+	// DefaultRetryBaseDelay is the backoff before the first retry, doubled
+	// for each retry after it
+	DefaultRetryBaseDelay = 50 * time.Millisecond
+	// DefaultRetryMaxDelay caps the backoff
+	DefaultRetryMaxDelay = time.Second
+)
+
+// RetryStats counts retries for one method
+type RetryStats struct {
+	Method  string `json:"method"`
+	Calls   uint64 `json:"calls"`
+	Retries uint64 `json:"retries"`
+	// Recovered counts calls that succeeded on a retry, and Exhausted those
+	// still failing transiently after the last attempt
+	Recovered uint64 `json:"recovered"`
+	Exhausted uint64 `json:"exhausted"`
+}
+
+// Retrier retries idempotent reads that fail transiently, because the
+// backend was unreachable or didn't answer in time, after an exponential
+// backoff with full jitter so retries from many requests don't arrive
+// together. A retry is skipped when the caller's deadline would pass during
+// the backoff.
+type Retrier struct {
+	methods   map[string]bool
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	logger    *slog.Logger
+	// jitter picks the wait from zero up to the backoff
+	jitter func(time.Duration) time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*RetryStats
+}
+
+// RetryOption configures a Retrier
+type RetryOption func(*Retrier)
+
+// WithRetryAttempts sets how many times a call is tried in all
+func WithRetryAttempts(n int) RetryOption {
+	return func(r *Retrier) {
+		r.attempts = max(n, 1)
+	}
+}
+
+// WithRetryBackoff sets the first retry's backoff and the cap it doubles up
+// to
+func WithRetryBackoff(base, maxDelay time.Duration) RetryOption {
+	return func(r *Retrier) {
+		if base > 0 {
+			r.baseDelay = base
+		}
+		if maxDelay > 0 {
+			r.maxDelay = maxDelay
+		}
+	}
+}
+
+// NewRetrier creates a Retrier for the given full method names. Only list
+// idempotent methods: a retried call may have run on the backend already.
+func NewRetrier(methods []string, logger *slog.Logger, opts ...RetryOption) *Retrier {
+	r := &Retrier{
+		methods:   make(map[string]bool, len(methods)),
+		attempts:  DefaultRetryAttempts,
+		baseDelay: DefaultRetryBaseDelay,
+		maxDelay:  DefaultRetryMaxDelay,
+		logger:    logger,
+		jitter: func(d time.Duration) time.Duration {
+			if d <= 0 {
+				return 0
+			}
+			return rand.N(d)
+		},
+		stats: make(map[string]*RetryStats),
+	}
+	for _, method := range methods {
+		r.methods[method] = true
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithRetrier retries the Retrier's methods on the auth and payment backends
+func WithRetrier(r *Retrier) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.retrier = r
+	}
+}
+
+// retryable reports whether err may pass on another attempt
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before retry n, counting from 1
+func (r *Retrier) backoff(n int) time.Duration {
+	d := r.baseDelay
+	for i := 1; i < n && d < r.maxDelay; i++ {
+		d *= 2
+	}
+	return r.jitter(min(d, r.maxDelay))
+}
+
+// UnaryClientInterceptor retries calls to the Retrier's methods
+func (r *Retrier) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !r.methods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var err error
+		retries := 0
+		for attempt := 1; ; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !retryable(err) || attempt == r.attempts || ctx.Err() != nil {
+				break
+			}
+
+			wait := r.backoff(attempt)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+				break
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			// A failed attempt may have left part of a reply behind
+			if msg, ok := reply.(proto.Message); ok {
+				proto.Reset(msg)
+			}
+			retries++
+		}
+
+		r.record(method, func(s *RetryStats) {
+			s.Calls++
+			s.Retries += uint64(retries)
+			switch {
+			case retries > 0 && err == nil:
+				s.Recovered++
+			case retries == r.attempts-1 && retryable(err):
+				s.Exhausted++
+			}
+		})
+		if retries > 0 && err != nil {
+			r.logger.Warn("backend call failed after retries", "method", method, "attempts", retries+1, "error", err)
+		}
+		return err
+	}
+}
+
+func (r *Retrier) record(method string, update func(*RetryStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[method]
+	if !ok {
+		s = &RetryStats{Method: method}
+		r.stats[method] = s
+	}
+	update(s)
+}
+
+// Stats returns the retry counts per method
+func (r *Retrier) Stats() []RetryStats {
+	r.mu.Lock()
+	result := make([]RetryStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		result = append(result, *s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Method < result[j].Method })
+	return result
+}
+
+// ServeHTTP writes Stats as JSON, for an admin endpoint
+func (r *Retrier) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Stats()); err != nil {
+		r.logger.Error("failed to encode retry stats", "error", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// retryCall invokes interceptor for method with an invoker returning errs in
+// turn, then nil, and reports how many attempts were made
+func retryCall(ctx context.Context, interceptor grpc.UnaryClientInterceptor, method string, errs ...error) (int, error) {
+	attempts := 0
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts++
+		if attempts <= len(errs) {
+			return errs[attempts-1]
+		}
+		return nil
+	}
+	err := interceptor(ctx, method, &paymentpb.GetTransactionsRequest{}, &paymentpb.TransactionList{}, nil, invoker)
+	return attempts, err
+}
+
+func newTestRetrier(opts ...RetryOption) *Retrier {
+	r := NewRetrier([]string{paymentpb.PaymentService_GetTransactions_FullMethodName}, slog.New(slog.NewTextHandler(io.Discard, nil)), opts...)
+	r.jitter = func(time.Duration) time.Duration { return 0 }
+	return r
+}
+
+func TestRetrier_RetriesTransientErrors(t *testing.T) {
+	r := newTestRetrier()
+	interceptor := r.UnaryClientInterceptor()
+	down := status.Error(codes.Unavailable, "connection refused")
+	slow := status.Error(codes.DeadlineExceeded, "timeout")
+
+	attempts, err := retryCall(context.Background(), interceptor, paymentpb.PaymentService_GetTransactions_FullMethodName, down, slow)
+	if attempts != 3 || err != nil {
+		t.Fatalf("expected success on the third attempt, got %d attempts, %v", attempts, err)
+	}
+	attempts, err = retryCall(context.Background(), interceptor, paymentpb.PaymentService_GetTransactions_FullMethodName, down, down, down)
+	if attempts != 3 || status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected three attempts and the last error, got %d attempts, %v", attempts, err)
+	}
+
+	stats := r.Stats()
+	if len(stats) != 1 || stats[0].Calls != 2 || stats[0].Retries != 4 || stats[0].Recovered != 1 || stats[0].Exhausted != 1 {
+		t.Errorf("expected one recovered and one exhausted call, got %+v", stats)
+	}
+}
+
+func TestRetrier_DoesNotRetry(t *testing.T) {
+	// Any backoff outlasts the deadline case's timeout
+	r := newTestRetrier(WithRetryBackoff(time.Second, time.Second))
+	r.jitter = func(d time.Duration) time.Duration { return d }
+	interceptor := r.UnaryClientInterceptor()
+	down := status.Error(codes.Unavailable, "connection refused")
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		method  string
+		err     error
+	}{
+		{name: "application error", method: paymentpb.PaymentService_GetTransactions_FullMethodName, err: status.Error(codes.InvalidArgument, "bad cursor")},
+		{name: "write", method: paymentpb.PaymentService_CreateTransaction_FullMethodName, err: down},
+		{name: "deadline before the backoff ends", timeout: 100 * time.Millisecond, method: paymentpb.PaymentService_GetTransactions_FullMethodName, err: down},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if attempts, err := retryCall(ctx, interceptor, tt.method, tt.err); attempts != 1 || err == nil {
+				t.Errorf("expected one failed attempt, got %d, %v", attempts, err)
+			}
+		})
+	}
+}
+
+func TestRetrier_Backoff(t *testing.T) {
+	r := newTestRetrier(WithRetryBackoff(50*time.Millisecond, time.Second))
+	r.jitter = func(d time.Duration) time.Duration { return d }
+
+	for n, want := range map[int]time.Duration{1: 50 * time.Millisecond, 2: 100 * time.Millisecond, 5: 800 * time.Millisecond, 6: time.Second, 20: time.Second} {
+		if got := r.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
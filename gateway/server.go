@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ServerConfig holds the gateway HTTP server settings
+type ServerConfig struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	TLSCertFile       string
+	TLSKeyFile        string
+}
+
+// newHTTPServer builds the gateway http.Server.
+// With a certificate configured it serves HTTP/1.1 and HTTP/2 over TLS;
+// otherwise it serves HTTP/1.1 and cleartext HTTP/2 (h2c) for internal
+// traffic behind a load balancer.
+func newHTTPServer(cfg ServerConfig, handler http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		Protocols:         new(http.Protocols),
+	}
+
+	server.Protocols.SetHTTP1(true)
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		server.Protocols.SetHTTP2(true)
+		server.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}
+	} else {
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return server, nil
+}
+
+// runServer serves until ctx is cancelled, then stops accepting new
+// connections and waits up to shutdownTimeout for in-flight requests to drain
+func runServer(ctx context.Context, server *http.Server, shutdownTimeout time.Duration, logger *slog.Logger) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Shutdown stops accepting connections, closes idle ones and sends
+	// GOAWAY to HTTP/2 clients before waiting for active requests
+	logger.Info("shutting down, draining connections", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// Drain deadline exceeded; force-close the remaining connections
+		if closeErr := server.Close(); closeErr != nil {
+			logger.Error("failed to close HTTP server", "error", closeErr)
+		}
+		return err
+	}
+	return <-errCh
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	cfg := ServerConfig{
+		Addr:              ":8080",
+		ReadHeaderTimeout: time.Second,
+		ReadTimeout:       2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    1 << 16,
+	}
+	server, err := newHTTPServer(cfg, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if server.Addr != cfg.Addr || server.ReadHeaderTimeout != cfg.ReadHeaderTimeout || server.ReadTimeout != cfg.ReadTimeout ||
+		server.WriteTimeout != cfg.WriteTimeout || server.IdleTimeout != cfg.IdleTimeout || server.MaxHeaderBytes != cfg.MaxHeaderBytes {
+		t.Errorf("expected the configured address, timeouts and header limit, got %+v", server)
+	}
+	if server.TLSConfig != nil || !server.Protocols.HTTP1() || !server.Protocols.UnencryptedHTTP2() || server.Protocols.HTTP2() {
+		t.Errorf("expected HTTP/1.1 and h2c without a certificate, got %v", server.Protocols)
+	}
+
+	cfg.TLSCertFile = filepath.Join(t.TempDir(), "missing.crt")
+	cfg.TLSKeyFile = filepath.Join(t.TempDir(), "missing.key")
+	if _, err := newHTTPServer(cfg, http.NotFoundHandler()); err == nil {
+		t.Error("expected an error for an unreadable certificate")
+	}
+}
+
+func TestNewHTTPServer_ReadHeaderTimeout(t *testing.T) {
+	server, err := newHTTPServer(ServerConfig{ReadHeaderTimeout: 50 * time.Millisecond}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ts := httptest.NewUnstartedServer(server.Handler)
+	ts.Config = server
+	ts.Start()
+	defer ts.Close()
+
+	// A client that never finishes its headers is disconnected
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: gateway\r\n"); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	_, err = io.ReadAll(conn)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("expected the server to close the connection")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the connection closed after the header timeout, took %v", elapsed)
+	}
+}
+
+// startServer runs server with runServer until the returned cancel is
+// called, and returns runServer's result channel once it accepts requests
+func startServer(t *testing.T, server *http.Server, shutdownTimeout time.Duration) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	server.Addr = l.Addr().String()
+	_ = l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runServer(ctx, server, shutdownTimeout, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		conn, err := net.Dial("tcp", server.Addr)
+		if err == nil {
+			_ = conn.Close()
+			return cancel, done
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("server didn't start: %v", err)
+		}
+	}
+}
+
+func TestRunServer_DrainsInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	server, err := newHTTPServer(ServerConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	cancel, done := startServer(t, server, 5*time.Second)
+	defer cancel()
+
+	conn, err := net.Dial("tcp", server.Addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: gateway\r\n\r\n"); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	<-started
+
+	// Shutting down waits for the request in flight
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("expected runServer to wait for the in-flight request, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := net.DialTimeout("tcp", server.Addr, time.Second); err == nil {
+		t.Error("expected new connections refused while draining")
+	}
+
+	close(release)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("expected the in-flight request to complete, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Errorf("expected 200 done, got %d %q", resp.StatusCode, body)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runServer didn't return after draining")
+	}
+}
+
+func TestRunServer_ShutdownTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	server, err := newHTTPServer(ServerConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	cancel, done := startServer(t, server, 50*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		if resp, err := http.Get("http://" + server.Addr); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// A request outlasting the drain timeout is cut off
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the drain deadline exceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runServer didn't return after its shutdown timeout")
+	}
+}
+
+func TestRunServer_ListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	server, err := newHTTPServer(ServerConfig{Addr: l.Addr().String()}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := runServer(context.Background(), server, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil))); err == nil {
+		t.Error("expected an error when the address is taken")
+	}
+}
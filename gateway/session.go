@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+// Default session cookie names
+const (
+	DefaultSessionCookie = "session"
+	DefaultCSRFCookie    = "csrf_token"
+)
+
+const (
+	// csrfHeader must echo the CSRF cookie on requests that change state
+	csrfHeader = "X-CSRF-Token"
+	logoutPath = "/auth/logout"
+)
+
+var errSameSiteNoneInsecure = errors.New("SameSite=None session cookies must be Secure")
+
+// Sessions keeps browser clients logged in with cookies. Login sets the JWT
+// in an HttpOnly cookie, which later requests are authenticated by when they
+// carry no Authorization header. Because the browser sends the cookie on
+// cross-site requests too, requests that change state must also echo the
+// CSRF cookie in the X-CSRF-Token header: the web app's script can read
+// that cookie, another site's can't (double-submit).
+type Sessions struct {
+	cookie     string
+	csrfCookie string
+	domain     string
+	secure     bool
+	sameSite   http.SameSite
+	ttl        time.Duration
+}
+
+// SessionOption configures Sessions
+type SessionOption func(*Sessions)
+
+// WithSessionCookieNames overrides the cookie names; empty names keep their
+// defaults
+func WithSessionCookieNames(session, csrf string) SessionOption {
+	return func(s *Sessions) {
+		if session != "" {
+			s.cookie = session
+		}
+		if csrf != "" {
+			s.csrfCookie = csrf
+		}
+	}
+}
+
+// WithSessionDomain shares the cookies with the domain's subdomains
+func WithSessionDomain(domain string) SessionOption {
+	return func(s *Sessions) {
+		s.domain = domain
+	}
+}
+
+// WithSessionSameSite sets the cookies' SameSite mode, Lax by default
+func WithSessionSameSite(mode http.SameSite) SessionOption {
+	return func(s *Sessions) {
+		s.sameSite = mode
+	}
+}
+
+// WithInsecureSessionCookies lets the cookies be sent over plain HTTP, for
+// local development
+func WithInsecureSessionCookies() SessionOption {
+	return func(s *Sessions) {
+		s.secure = false
+	}
+}
+
+// NewSessions creates Sessions whose cookies last as long as the tokens in
+// them
+func NewSessions(opts ...SessionOption) (*Sessions, error) {
+	s := &Sessions{
+		cookie:     DefaultSessionCookie,
+		csrfCookie: DefaultCSRFCookie,
+		secure:     true,
+		sameSite:   http.SameSiteLaxMode,
+		ttl:        jwt.TokenTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.sameSite == http.SameSiteNoneMode && !s.secure {
+		return nil, errSameSiteNoneInsecure
+	}
+	return s, nil
+}
+
+// ParseSameSite parses a SameSite mode: lax, strict or none
+func ParseSameSite(mode string) (http.SameSite, error) {
+	switch strings.ToLower(mode) {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("unknown SameSite mode %q", mode)
+}
+
+// WithSessions enables cookie sessions
+func WithSessions(s *Sessions) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.sessions = s
+	}
+}
+
+// start sets the cookies of a session authenticated by token, with a new
+// CSRF token
+func (s *Sessions) start(w http.ResponseWriter, token string) {
+	http.SetCookie(w, s.newCookie(s.cookie, token, true))
+	http.SetCookie(w, s.newCookie(s.csrfCookie, rand.Text(), false))
+}
+
+// end clears the session's cookies
+func (s *Sessions) end(w http.ResponseWriter) {
+	for _, name := range []string{s.cookie, s.csrfCookie} {
+		c := s.newCookie(name, "", name == s.cookie)
+		c.MaxAge = -1
+		http.SetCookie(w, c)
+	}
+}
+
+func (s *Sessions) newCookie(name, value string, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   s.domain,
+		MaxAge:   int(s.ttl / time.Second),
+		Secure:   s.secure,
+		HttpOnly: httpOnly,
+		SameSite: s.sameSite,
+	}
+}
+
+// checkCSRF reports whether r may use its session cookie: reads always may,
+// anything else must echo the CSRF cookie in csrfHeader
+func (s *Sessions) checkCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	c, err := r.Cookie(s.csrfCookie)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(c.Value)) == 1
+}
+
+// withSessions authenticates requests carrying a session cookie and no
+// Authorization header as if they sent the cookie's token as a bearer
+// token, once they pass the CSRF check. Without Sessions requests pass
+// through untouched.
+func (g *Gateway) withSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := g.sessions
+		if s == nil || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		c, err := r.Cookie(s.cookie)
+		if err != nil || c.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !s.checkCSRF(r) {
+			g.respondError(w, r, errCSRF)
+			return
+		}
+
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+c.Value)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleLogout ends a cookie session. The token stays valid until it
+// expires; only the browser forgets it.
+func (g *Gateway) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	g.sessions.end(w)
+	w.WriteHeader(http.StatusNoContent)
+}
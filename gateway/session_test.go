@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	g, auth := newTestGateway()
+	auth.AddUser("alice", "pw")
+	sessions, err := NewSessions()
+	if err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+	g.sessions = sessions
+
+	// Login sets the token in an HttpOnly cookie and a readable CSRF token
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"pw"}`))
+	w := httptest.NewRecorder()
+	mustLegacyHandler(g)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	session, csrf := cookies[DefaultSessionCookie], cookies[DefaultCSRFCookie]
+	if session == nil || !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteLaxMode || session.Value == "" {
+		t.Fatalf("expected a secure HttpOnly session cookie, got %+v", session)
+	}
+	if csrf == nil || csrf.HttpOnly || csrf.Value == "" {
+		t.Fatalf("expected a CSRF cookie the app can read, got %+v", csrf)
+	}
+
+	handler := g.withSessions(http.HandlerFunc(mustLegacyHandler(g)))
+	create := func(cookies []*http.Cookie, csrfToken, bearer string) int {
+		r := httptest.NewRequest(http.MethodPost, "/payment/transactions", strings.NewReader(`{"amount":10}`))
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		if csrfToken != "" {
+			r.Header.Set(csrfHeader, csrfToken)
+		}
+		if bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := create([]*http.Cookie{session, csrf}, csrf.Value, ""); code != http.StatusCreated {
+		t.Errorf("expected 201 with the session and CSRF token, got %d", code)
+	}
+	// A cross-site form can make the browser send the cookies, not the header
+	if code := create([]*http.Cookie{session, csrf}, "", ""); code != http.StatusForbidden {
+		t.Errorf("expected 403 without the CSRF header, got %d", code)
+	}
+	if code := create([]*http.Cookie{session, csrf}, "guess", ""); code != http.StatusForbidden {
+		t.Errorf("expected 403 with a wrong CSRF token, got %d", code)
+	}
+	// Bearer tokens can't be sent cross-site, so they need no CSRF token
+	if code := create([]*http.Cookie{session}, "", session.Value); code != http.StatusCreated {
+		t.Errorf("expected 201 for a bearer token, got %d", code)
+	}
+
+	// Reads need no CSRF token
+	r = httptest.NewRequest(http.MethodGet, "/payment/transactions/list", nil)
+	r.AddCookie(session)
+	w = httptest.NewRecorder()
+	g.withSessions(http.HandlerFunc(mustLegacyHandler(g))).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a read with the session cookie, got %d: %s", w.Code, w.Body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, logoutPath, nil)
+	w = httptest.NewRecorder()
+	g.handleLogout(w, r)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge >= 0 || c.Value != "" {
+			t.Errorf("expected logout to clear %s, got %+v", c.Name, c)
+		}
+	}
+	if len(w.Result().Cookies()) != 2 {
+		t.Errorf("expected both cookies cleared, got %v", w.Result().Cookies())
+	}
+}
+
+func TestNewSessions_SameSiteNoneNeedsSecure(t *testing.T) {
+	if _, err := NewSessions(WithSessionSameSite(http.SameSiteNoneMode), WithInsecureSessionCookies()); err == nil {
+		t.Error("expected SameSite=None without Secure refused")
+	}
+}
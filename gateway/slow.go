@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// SlowRequests defaults
+const (
+	// DefaultSlowThreshold is the latency budget a request may use before
+	// it is flagged
+	DefaultSlowThreshold = time.Second
+	// DefaultSlowKeep is how many traces are kept for /admin/slow-requests
+	DefaultSlowKeep = 100
+)
+
+// CallTiming is one backend call made while serving a request
+type CallTiming struct {
+	// Target is the gRPC method, or the HTTP method and URL
+	Target string `json:"target"`
+	// Start is when the call began, relative to the request's start
+	Start    time.Duration `json:"start_ns"`
+	Duration time.Duration `json:"duration_ns"`
+	// Code is the gRPC status code or HTTP status, empty on success
+	Code string `json:"code,omitempty"`
+}
+
+// RequestTrace is a request and the backend calls made for it
+type RequestTrace struct {
+	RequestID string        `json:"request_id"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	UserID    int           `json:"user_id,omitempty"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration_ns"`
+	// Slow marks traces kept for exceeding the threshold rather than sampled
+	Slow  bool         `json:"slow"`
+	Calls []CallTiming `json:"calls"`
+}
+
+// SlowRequests makes tail latency debuggable without tracing everything.
+// Every request records the timing of its backend calls, and the decision
+// to keep that trace is made once the request is done: requests over the
+// threshold are always logged in full and kept, others only at the sample
+// rate.
+type SlowRequests struct {
+	threshold  time.Duration
+	sampleRate float64
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	traces []RequestTrace
+	next   int
+	keep   int
+}
+
+// SlowOption configures SlowRequests
+type SlowOption func(*SlowRequests)
+
+// WithSlowSampleRate keeps this fraction, from 0 to 1, of traces of requests
+// within the threshold, for a baseline to compare slow ones against
+func WithSlowSampleRate(rate float64) SlowOption {
+	return func(s *SlowRequests) {
+		s.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithSlowKeep sets how many of the latest kept traces are served
+func WithSlowKeep(n int) SlowOption {
+	return func(s *SlowRequests) {
+		s.keep = max(n, 1)
+	}
+}
+
+// NewSlowRequests flags requests that take longer than threshold
+func NewSlowRequests(threshold time.Duration, logger *slog.Logger, opts ...SlowOption) *SlowRequests {
+	s := &SlowRequests{
+		threshold: threshold,
+		logger:    logger,
+		keep:      DefaultSlowKeep,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithSlowRequests times requests and their backend calls
+func WithSlowRequests(s *SlowRequests) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.slow = s
+	}
+}
+
+// callRecorder collects the backend calls of one request
+type callRecorder struct {
+	start time.Time
+	mu    sync.Mutex
+	calls []CallTiming
+}
+
+type callRecorderKey struct{}
+
+func (c *callRecorder) add(target string, start time.Time, code string) {
+	c.mu.Lock()
+	c.calls = append(c.calls, CallTiming{
+		Target:   target,
+		Start:    start.Sub(c.start),
+		Duration: time.Since(start),
+		Code:     code,
+	})
+	c.mu.Unlock()
+}
+
+// statusWriter remembers the status written to a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withSlowRequests records r's backend calls and, once it is served, flags
+// it if it was slow. Without SlowRequests requests pass through untouched.
+func (g *Gateway) withSlowRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := g.slow
+		if s == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &callRecorder{start: time.Now()}
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), callRecorderKey{}, rec)))
+
+		elapsed := time.Since(rec.start)
+		slow := elapsed > s.threshold
+		if !slow && (s.sampleRate == 0 || rand.Float64() >= s.sampleRate) {
+			return
+		}
+
+		if sw.status == 0 {
+			// Nothing written means an empty 200
+			sw.status = http.StatusOK
+		}
+		rec.mu.Lock()
+		trace := RequestTrace{
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Status:   sw.status,
+			Start:    rec.start,
+			Duration: elapsed,
+			Slow:     slow,
+			Calls:    append([]CallTiming(nil), rec.calls...),
+		}
+		rec.mu.Unlock()
+		if meta, ok := requestMetaFrom(r.Context()); ok {
+			trace.RequestID = meta.id
+		}
+		if info := routeInfoFrom(r.Context()); info != nil {
+			trace.UserID = info.userID
+		}
+		s.record(r.Context(), trace)
+	})
+}
+
+// record keeps trace, logging it when it was slow
+func (s *SlowRequests) record(ctx context.Context, trace RequestTrace) {
+	if trace.Slow {
+		s.logger.WarnContext(ctx, "slow request",
+			"request_id", trace.RequestID,
+			"method", trace.Method,
+			"path", trace.Path,
+			"status", trace.Status,
+			"user_id", trace.UserID,
+			"duration_ms", trace.Duration.Milliseconds(),
+			"threshold_ms", s.threshold.Milliseconds(),
+			"calls", trace.Calls,
+		)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.traces) < s.keep {
+		s.traces = append(s.traces, trace)
+	} else {
+		s.traces[s.next] = trace
+	}
+	s.next = (s.next + 1) % s.keep
+}
+
+// Traces returns the kept traces, newest first
+func (s *SlowRequests) Traces() []RequestTrace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.traces)
+	result := make([]RequestTrace, 0, n)
+	for i := range n {
+		// The newest trace is the one just before next
+		result = append(result, s.traces[(s.next-1-i+n)%n])
+	}
+	return result
+}
+
+// ServeHTTP writes Traces as JSON, for an admin endpoint
+func (s *SlowRequests) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Traces()); err != nil {
+		s.logger.Error("failed to encode slow request traces", "error", err)
+	}
+}
+
+// UnaryClientInterceptor records each backend call in the request's trace
+func (s *SlowRequests) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		rec, ok := ctx.Value(callRecorderKey{}).(*callRecorder)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		var code string
+		if err != nil {
+			code = status.Code(err).String()
+		}
+		rec.add(method, start, code)
+		return err
+	}
+}
+
+// Transport wraps base so HTTP backend calls are recorded in the request's
+// trace
+func (s *SlowRequests) Transport(base http.RoundTripper) http.RoundTripper {
+	return timedTransport{base: base}
+}
+
+type timedTransport struct {
+	base http.RoundTripper
+}
+
+func (t timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec, ok := req.Context().Value(callRecorderKey{}).(*callRecorder)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	var code string
+	switch {
+	case err != nil:
+		code = "error"
+	case resp.StatusCode >= http.StatusBadRequest:
+		code = http.StatusText(resp.StatusCode)
+	}
+	rec.add(req.Method+" "+req.URL.Redacted(), start, code)
+	return resp, err
+}
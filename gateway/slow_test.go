@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func TestWithSlowRequests(t *testing.T) {
+	var logs bytes.Buffer
+	g, _ := newTestGateway()
+	g.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	g.slow = NewSlowRequests(20*time.Millisecond, g.logger)
+	interceptor := g.slow.UnaryClientInterceptor()
+
+	// Each request makes one backend call taking delay
+	var delay time.Duration
+	handler := withRouteInfo(g.withSlowRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routeInfoFrom(r.Context()).userID = 7
+		_ = interceptor(r.Context(), paymentpb.PaymentService_GetTransactions_FullMethodName, nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				time.Sleep(delay)
+				return status.Error(codes.Unavailable, "down")
+			})
+		w.WriteHeader(http.StatusBadGateway)
+	})))
+	serve := func(d time.Duration) {
+		delay = d
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/payment/transactions/list", nil))
+	}
+
+	serve(0)
+	if traces := g.slow.Traces(); len(traces) != 0 {
+		t.Fatalf("expected fast requests not to be kept, got %+v", traces)
+	}
+
+	serve(30 * time.Millisecond)
+	traces := g.slow.Traces()
+	if len(traces) != 1 {
+		t.Fatalf("expected the slow request to be kept, got %+v", traces)
+	}
+	trace := traces[0]
+	if !trace.Slow || trace.Status != http.StatusBadGateway || trace.UserID != 7 || trace.Path != "/payment/transactions/list" {
+		t.Errorf("unexpected trace: %+v", trace)
+	}
+	if len(trace.Calls) != 1 || trace.Calls[0].Target != paymentpb.PaymentService_GetTransactions_FullMethodName ||
+		trace.Calls[0].Code != "Unavailable" || trace.Calls[0].Duration < 30*time.Millisecond {
+		t.Errorf("expected the backend call's timing, got %+v", trace.Calls)
+	}
+	if !strings.Contains(logs.String(), "slow request") || !strings.Contains(logs.String(), "PaymentService/GetTransactions") {
+		t.Errorf("expected the slow request logged with its calls, got %q", logs.String())
+	}
+}
+
+func TestSlowRequests_Traces(t *testing.T) {
+	s := NewSlowRequests(time.Second, slog.Default(), WithSlowKeep(2))
+	for _, path := range []string{"/a", "/b", "/c"} {
+		s.record(context.Background(), RequestTrace{Path: path})
+	}
+	traces := s.Traces()
+	if len(traces) != 2 || traces[0].Path != "/c" || traces[1].Path != "/b" {
+		t.Errorf("expected the latest two traces, newest first, got %+v", traces)
+	}
+}
+
+func TestSlowRequests_SampleRate(t *testing.T) {
+	g, _ := newTestGateway()
+	g.slow = NewSlowRequests(time.Minute, g.logger, WithSlowSampleRate(1))
+	handler := g.withSlowRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	traces := g.slow.Traces()
+	if len(traces) != 1 || traces[0].Slow || traces[0].Status != http.StatusOK {
+		t.Errorf("expected a sampled fast trace, got %+v", traces)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+// apiPrefixes are never served by the SPA fallback so that unknown API
+// paths still return JSON errors instead of index.html
+var apiPrefixes = []string{"/auth/", "/payment/", "/analytics/"}
+
+// spaHandler serves a single-page application from fsys.
+// Existing files are served as-is; any other path falls back to index.html
+// so the frontend router can handle it.
+type spaHandler struct {
+	fsys       fs.FS
+	fileServer http.Handler
+	gateway    *Gateway
+}
+
+func newSPAHandler(fsys fs.FS, g *Gateway) *spaHandler {
+	return &spaHandler{
+		fsys:       fsys,
+		fileServer: http.FileServerFS(fsys),
+		gateway:    g,
+	}
+}
+
+func (h *spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			h.gateway.respondError(w, r, apperror.ErrNotFound)
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.gateway.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err == nil && !info.IsDir() && name != "index.html" {
+		h.fileServer.ServeHTTP(w, r)
+		return
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		h.gateway.logger.Error("failed to stat static file", "error", err, "path", name)
+		h.gateway.respondError(w, r, apperror.ErrInternalServer)
+		return
+	}
+
+	// Missing asset files (with an extension) are real 404s
+	if err != nil && path.Ext(name) != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	index, err := fs.ReadFile(h.fsys, "index.html")
+	if err != nil {
+		h.gateway.logger.Error("failed to read index.html", "error", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	// index.html must always be revalidated so new deployments are picked up
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(index); err != nil {
+		h.gateway.logger.Error("failed to write index.html", "error", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build spa
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Build with `-tags spa` after copying the frontend build into gateway/static
+// to bundle the dashboard into the gateway binary.
+//
+//go:embed all:static
+var embeddedStatic embed.FS
+
+func embeddedSPA() fs.FS {
+	sub, err := fs.Sub(embeddedStatic, "static")
+	if err != nil {
+		return nil
+	}
+	return sub
+}
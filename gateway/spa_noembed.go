@@ -0,0 +1,9 @@
+//go:build !spa
+
+package main
+
+import "io/fs"
+
+func embeddedSPA() fs.FS {
+	return nil
+}
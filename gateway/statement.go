@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// Statement defaults
+const (
+	DefaultStatementURLTTL = 15 * time.Minute
+
+	statementPath         = "/payment/statements"
+	statementDownloadPath = "/statements"
+)
+
+// Statements signs links for downloading the monthly statement PDFs the
+// payment service renders into store
+type Statements struct {
+	store  blob.Store
+	signer *blob.URLSigner
+	ttl    time.Duration
+}
+
+// NewStatements creates Statements reading from store whose download links,
+// signed with secret, stay valid for ttl
+func NewStatements(store blob.Store, secret []byte, ttl time.Duration) *Statements {
+	return &Statements{
+		store:  store,
+		signer: blob.NewURLSigner(secret),
+		ttl:    ttl,
+	}
+}
+
+// WithStatements enables the statement endpoints
+func WithStatements(s *Statements) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.statements = s
+	}
+}
+
+// StatementResponse describes a monthly statement and a time-limited link
+// to download it
+type StatementResponse struct {
+	Month       string    `json:"month"`
+	Size        int64     `json:"size"`
+	GeneratedAt time.Time `json:"generated_at"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// handleStatement returns a download link for the statement of ?month= with
+// GET, and generates it, replacing any earlier one, with POST
+func (g *Gateway) handleStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	if g.statements == nil {
+		g.respondError(w, r, errStatementsDisabled)
+		return
+	}
+
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+
+	month := r.URL.Query().Get("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		g.respondError(w, r, errInvalidMonth)
+		return
+	}
+
+	// Rendering reads the whole month, so generating gets longer than a read
+	timeout, call, status := 5*time.Second, g.paymentClient.GetStatement, http.StatusOK
+	if r.Method == http.MethodPost {
+		timeout, call, status = 30*time.Second, g.paymentClient.GenerateStatement, http.StatusCreated
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	statement, err := call(paymentContext(ctx, r), &paymentpb.StatementRequest{
+		UserId: int64(userID),
+		Month:  month,
+	})
+	if err != nil {
+		g.logger.Error("statement failed", "method", r.Method, "month", month, "error", err)
+		g.respondError(w, r, upstreamError(err, apperror.ErrInternalServer.WithMessage("failed to get statement")))
+		return
+	}
+
+	g.respondJSON(w, r, status, g.statementResponse(statement))
+}
+
+// statementResponse describes statement with a freshly signed download link
+func (g *Gateway) statementResponse(statement *paymentpb.Statement) StatementResponse {
+	expiresAt := time.Now().Add(g.statements.ttl).Truncate(time.Second)
+	link := url.URL{Path: statementDownloadPath, RawQuery: g.statements.signer.Sign(statement.Key, expiresAt).Encode()}
+	return StatementResponse{
+		Month:       statement.Month,
+		Size:        statement.Size,
+		GeneratedAt: statement.GeneratedAt.AsTime(),
+		URL:         link.String(),
+		ExpiresAt:   expiresAt.UTC(),
+	}
+}
+
+// handleDownloadStatement streams the statement named by a link from
+// statementResponse. Like receipt links, the signature is the only
+// credential.
+func (g *Gateway) handleDownloadStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	if g.statements == nil {
+		g.respondError(w, r, errStatementsDisabled)
+		return
+	}
+
+	key, err := g.statements.signer.Verify(r.URL.Query())
+	if err != nil {
+		g.respondError(w, r, errStatementLink)
+		return
+	}
+	g.serveBlob(w, r, g.statements.store, key, "statement")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+)
+
+func statementRequest(g *Gateway, method, token, query string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, statementPath+"?"+query, nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.handleStatement(w, r)
+	return w
+}
+
+func TestHandleStatement(t *testing.T) {
+	g, auth := newTestGateway()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.statements = NewStatements(store, []byte("secret"), time.Minute)
+	_, token := auth.AddUser("alice", "pw")
+
+	if w := statementRequest(g, http.MethodGet, token, "month=2026-09"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before generating, got %d: %s", w.Code, w.Body)
+	}
+
+	w := statementRequest(g, http.MethodPost, token, "month=2026-09")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var generated StatementResponse
+	if err := json.NewDecoder(w.Body).Decode(&generated); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if generated.Month != "2026-09" || !strings.HasPrefix(generated.URL, statementDownloadPath+"?") {
+		t.Errorf("unexpected statement: %+v", generated)
+	}
+
+	// The payment service writes the PDF; stand in for it
+	link, _ := url.Parse(generated.URL)
+	key, err := g.statements.signer.Verify(link.Query())
+	if err != nil {
+		t.Fatalf("expected a valid link, got %v", err)
+	}
+	if err := store.Put(context.Background(), key, strings.NewReader("%PDF-1.4"), 8, "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+
+	w = statementRequest(g, http.MethodGet, token, "month=2026-09")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var current StatementResponse
+	if err := json.NewDecoder(w.Body).Decode(&current); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	download := func(link string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		g.handleDownloadStatement(w, httptest.NewRequest(http.MethodGet, link, nil))
+		return w
+	}
+	if w := download(current.URL); w.Code != http.StatusOK || w.Body.String() != "%PDF-1.4" || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("unexpected download %d %q %v", w.Code, w.Body, w.Header())
+	}
+	if w := download(strings.Replace(current.URL, "sig=", "sig=x", 1)); w.Code != http.StatusForbidden {
+		t.Errorf("expected a tampered link to be refused, got %d", w.Code)
+	}
+}
+
+func TestHandleStatement_Rejected(t *testing.T) {
+	g, auth := newTestGateway()
+	_, token := auth.AddUser("alice", "pw")
+
+	if w := statementRequest(g, http.MethodGet, token, "month=2026-09"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with statements disabled, got %d", w.Code)
+	}
+
+	g.statements = NewStatements(nil, []byte("secret"), time.Minute)
+	for _, query := range []string{"", "month=2026-13", "month=Sep"} {
+		if w := statementRequest(g, http.MethodPost, token, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", query, w.Code)
+		}
+	}
+	if w := statementRequest(g, http.MethodPost, "bad-token", "month=2026-09"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+// Default headers a mesh or ingress sets for the user it authenticated
+const (
+	DefaultTrustedUserIDHeader   = "X-Forwarded-User-Id"
+	DefaultTrustedUsernameHeader = "X-Forwarded-User"
+	DefaultTrustedRoleHeader     = "X-Forwarded-Role"
+	DefaultTrustedScopesHeader   = "X-Forwarded-Scopes"
+)
+
+// clientCertHeader is where Envoy-based meshes describe the client
+// certificate of the connection they terminated
+const clientCertHeader = "X-Forwarded-Client-Cert"
+
+var (
+	errNoTrustedPeers = errors.New("trusted identity needs at least one trusted CIDR")
+	errNoServiceToken = errors.New("trusted identity needs a service token to forward identities")
+)
+
+// TrustedHeaders names the headers carrying a forwarded identity
+type TrustedHeaders struct {
+	UserID   string
+	Username string
+	Role     string
+	// Scopes holds the caller's scopes separated by spaces
+	Scopes string
+}
+
+// TrustedIdentity takes the caller's identity from headers set by a service
+// mesh or ingress that already validated the user, instead of validating a
+// bearer token. Only peers in the trusted CIDRs are believed; anyone else's
+// identity headers are dropped and the request authenticates as usual.
+type TrustedIdentity struct {
+	peers        []netip.Prefix
+	headers      TrustedHeaders
+	serviceToken string
+	// clientCertURI, when set, must be the URI of the client certificate the
+	// mesh reports for the connection
+	clientCertURI string
+}
+
+// TrustedIdentityOption configures a TrustedIdentity
+type TrustedIdentityOption func(*TrustedIdentity)
+
+// WithTrustedHeaders overrides the identity header names; empty names keep
+// their defaults
+func WithTrustedHeaders(h TrustedHeaders) TrustedIdentityOption {
+	return func(t *TrustedIdentity) {
+		if h.UserID != "" {
+			t.headers.UserID = h.UserID
+		}
+		if h.Username != "" {
+			t.headers.Username = h.Username
+		}
+		if h.Role != "" {
+			t.headers.Role = h.Role
+		}
+		if h.Scopes != "" {
+			t.headers.Scopes = h.Scopes
+		}
+	}
+}
+
+// WithClientCertURI requires the mesh to report a client certificate with
+// uri, such as the SPIFFE ID of the ingress, before headers are trusted
+func WithClientCertURI(uri string) TrustedIdentityOption {
+	return func(t *TrustedIdentity) {
+		t.clientCertURI = uri
+	}
+}
+
+// NewTrustedIdentity trusts identity headers from peers in the given
+// prefixes. Identities are forwarded to the payment service with
+// serviceToken, which it must share.
+func NewTrustedIdentity(peers []netip.Prefix, serviceToken string, opts ...TrustedIdentityOption) (*TrustedIdentity, error) {
+	if len(peers) == 0 {
+		return nil, errNoTrustedPeers
+	}
+	if serviceToken == "" {
+		return nil, errNoServiceToken
+	}
+	t := &TrustedIdentity{
+		peers: peers,
+		headers: TrustedHeaders{
+			UserID:   DefaultTrustedUserIDHeader,
+			Username: DefaultTrustedUsernameHeader,
+			Role:     DefaultTrustedRoleHeader,
+			Scopes:   DefaultTrustedScopesHeader,
+		},
+		serviceToken: serviceToken,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// WithTrustedIdentity enables trusted identity headers
+func WithTrustedIdentity(t *TrustedIdentity) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.trusted = t
+	}
+}
+
+// trustedIdentityKey marks a request whose identity came from trusted
+// headers, holding the token that vouches for it downstream
+type trustedIdentityKey struct{}
+
+// withTrustedIdentity stores the identity a trusted peer forwarded, so the
+// policy and auth checks reuse it instead of asking for a bearer token.
+// Without a TrustedIdentity requests pass through untouched.
+func (g *Gateway) withTrustedIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := g.trusted
+		if t == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := t.identify(r)
+		if !ok {
+			// Nobody downstream should mistake spoofed headers for a mesh's
+			t.strip(r)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if info := routeInfoFrom(r.Context()); info != nil {
+			info.userID = id.userID
+		}
+		ctx := context.WithValue(withIdentity(r.Context(), id), trustedIdentityKey{}, t.serviceToken)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// identify returns the identity in r's headers if r comes from a trusted
+// peer and names a user
+func (t *TrustedIdentity) identify(r *http.Request) (*identity, bool) {
+	addr, err := netip.ParseAddr(clientIP(r))
+	if err != nil || !t.trusts(addr.Unmap()) {
+		return nil, false
+	}
+	if t.clientCertURI != "" && !clientCertHasURI(r.Header.Get(clientCertHeader), t.clientCertURI) {
+		return nil, false
+	}
+	userID, err := strconv.Atoi(r.Header.Get(t.headers.UserID))
+	if err != nil || userID <= 0 {
+		return nil, false
+	}
+	return &identity{
+		userID:   userID,
+		username: r.Header.Get(t.headers.Username),
+		role:     r.Header.Get(t.headers.Role),
+		scopes:   strings.Fields(r.Header.Get(t.headers.Scopes)),
+	}, true
+}
+
+func (t *TrustedIdentity) trusts(addr netip.Addr) bool {
+	for _, p := range t.peers {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TrustedIdentity) strip(r *http.Request) {
+	r.Header.Del(t.headers.UserID)
+	r.Header.Del(t.headers.Username)
+	r.Header.Del(t.headers.Role)
+	r.Header.Del(t.headers.Scopes)
+}
+
+// clientCertHasURI reports whether the last element of an
+// X-Forwarded-Client-Cert header, the one added by the nearest proxy, names
+// uri. Elements are comma-separated lists of Key=Value pairs joined by ';'.
+func clientCertHasURI(xfcc, uri string) bool {
+	if xfcc == "" {
+		return false
+	}
+	elements := strings.Split(xfcc, ",")
+	for _, pair := range strings.Split(elements[len(elements)-1], ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(key, "URI") && strings.Trim(value, `"`) == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedIdentity attaches the identity trusted headers supplied to
+// outgoing gRPC metadata, reporting false for requests that carry a bearer
+// token instead
+func forwardedIdentity(ctx context.Context) (context.Context, bool) {
+	token, ok := ctx.Value(trustedIdentityKey{}).(string)
+	if !ok {
+		return ctx, false
+	}
+	id, ok := ctx.Value(authenticatedUserKey{}).(*identity)
+	if !ok {
+		return ctx, false
+	}
+	return grpcauth.WithForwardedIdentity(ctx, token, grpcauth.Identity{
+		UserID:   id.userID,
+		Username: id.username,
+		Scopes:   id.scopes,
+	}), true
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+func TestWithTrustedIdentity(t *testing.T) {
+	g, _ := newTestGateway()
+	trusted, err := NewTrustedIdentity([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "svc",
+		WithClientCertURI("spiffe://cluster.local/ns/edge/sa/ingress"))
+	if err != nil {
+		t.Fatalf("failed to create trusted identity: %v", err)
+	}
+	g.trusted = trusted
+
+	var (
+		id     *identity
+		authed error
+		meta   metadata.MD
+	)
+	handler := g.withTrustedIdentity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, authed = g.authenticate(r)
+		meta, _ = metadata.FromOutgoingContext(paymentContext(r.Context(), r))
+	}))
+	request := func(remote, xfcc string) {
+		r := httptest.NewRequest(http.MethodGet, "/payment/transactions", nil)
+		r.RemoteAddr = remote
+		r.Header.Set(DefaultTrustedUserIDHeader, "42")
+		r.Header.Set(DefaultTrustedUsernameHeader, "alice")
+		r.Header.Set(DefaultTrustedScopesHeader, "transactions:read transactions:write")
+		if xfcc != "" {
+			r.Header.Set(clientCertHeader, xfcc)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	request("10.1.2.3:5000", `By=spiffe://cluster.local/ns/app/sa/gateway;URI=spiffe://cluster.local/ns/edge/sa/ingress`)
+	if authed != nil || id.userID != 42 || id.username != "alice" || len(id.scopes) != 2 {
+		t.Fatalf("expected the forwarded identity, got %+v, %v", id, authed)
+	}
+	if got := meta.Get(grpcauth.MetadataServiceToken); len(got) != 1 || got[0] != "svc" {
+		t.Errorf("expected the service token in outgoing metadata, got %v", meta)
+	}
+	if got := meta.Get(grpcauth.MetadataUserID); len(got) != 1 || got[0] != "42" {
+		t.Errorf("expected the user ID in outgoing metadata, got %v", meta)
+	}
+	if got := meta.Get(grpcauth.MetadataAuthorization); len(got) != 0 {
+		t.Errorf("expected no bearer token, got %v", got)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xfcc   string
+	}{
+		{"untrusted peer", "203.0.113.7:5000", `URI=spiffe://cluster.local/ns/edge/sa/ingress`},
+		{"missing client cert", "10.1.2.3:5000", ""},
+		{"other client cert", "10.1.2.3:5000", `URI=spiffe://cluster.local/ns/edge/sa/other`},
+		{"client cert from an earlier hop", "10.1.2.3:5000", `URI=spiffe://cluster.local/ns/edge/sa/ingress,URI=spiffe://cluster.local/ns/edge/sa/other`},
+	}
+	for _, tt := range tests {
+		request(tt.remote, tt.xfcc)
+		if authed == nil {
+			t.Errorf("%s: expected the headers to be ignored, got %+v", tt.name, id)
+		}
+	}
+}
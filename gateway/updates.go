@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+	"github.com/tkaewplik/go-microservices/pkg/events"
+)
+
+// updatesPath serves the WebSocket stream of the caller's transaction updates
+const updatesPath = "/ws"
+
+// TransactionUpdates defaults
+const (
+	// DefaultUpdateConnsPerUser is how many streams one user may hold open
+	DefaultUpdateConnsPerUser = 5
+	// updateBuffer is how many updates wait for a slow client before its
+	// stream is closed
+	updateBuffer = 32
+	// updatePingInterval keeps idle streams alive through proxies and
+	// notices clients that went away without closing
+	updatePingInterval = 30 * time.Second
+	// updateWriteTimeout bounds each write and ping
+	updateWriteTimeout = 5 * time.Second
+)
+
+// TransactionUpdate is a message pushed on /ws. Type is the event type;
+// the other fields are those of its event.
+type TransactionUpdate struct {
+	Type string `json:"type"`
+	// Set for transaction.created
+	TransactionID   int     `json:"transaction_id,omitempty"`
+	TransactionULID string  `json:"transaction_ulid,omitempty"`
+	Amount          float64 `json:"amount,omitempty"`
+	Description     string  `json:"description,omitempty"`
+	// Set for transaction.paid and transaction.payment_reverted
+	PaymentBatchID       string    `json:"payment_batch_id,omitempty"`
+	TransactionsPaid     int64     `json:"transactions_paid,omitempty"`
+	TransactionsReverted int64     `json:"transactions_reverted,omitempty"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// updateEventTypes are the transactions topic events pushed to clients. A
+// reverted payment is pushed too, as it undoes a transaction.paid the client
+// has already shown.
+var updateEventTypes = map[string]bool{
+	"transaction.created":          true,
+	"transaction.paid":             true,
+	"transaction.payment_reverted": true,
+}
+
+// TransactionUpdates pushes the transaction events consumed from Kafka to
+// the WebSocket streams of the users they belong to. Streams are served by
+// whichever gateway instance the client reached, so every instance consumes
+// every event; nothing is buffered for users with no stream open.
+type TransactionUpdates struct {
+	logger       *slog.Logger
+	upcaster     *events.Upcaster
+	origins      []string
+	connsPerUser int
+
+	mu     sync.Mutex
+	subs   map[int]map[*updateSub]struct{}
+	closed bool
+}
+
+// updateSub is one open stream. updates is closed when the stream must end,
+// with the reason in status and reason.
+type updateSub struct {
+	userID  int
+	updates chan TransactionUpdate
+	status  websocket.StatusCode
+	reason  string
+}
+
+// UpdatesOption configures TransactionUpdates
+type UpdatesOption func(*TransactionUpdates)
+
+// WithUpdateOrigins lets browser pages on these host patterns, such as
+// "app.example.com" or "*.example.com", open streams. Pages on the gateway's
+// own host always can; other origins are refused so a cookie session can't
+// be used from another site.
+func WithUpdateOrigins(patterns ...string) UpdatesOption {
+	return func(u *TransactionUpdates) {
+		u.origins = append(u.origins, patterns...)
+	}
+}
+
+// WithUpdateConnsPerUser sets how many streams one user may hold open
+func WithUpdateConnsPerUser(n int) UpdatesOption {
+	return func(u *TransactionUpdates) {
+		u.connsPerUser = max(n, 1)
+	}
+}
+
+// NewTransactionUpdates returns TransactionUpdates with no streams open
+func NewTransactionUpdates(logger *slog.Logger, opts ...UpdatesOption) *TransactionUpdates {
+	u := &TransactionUpdates{
+		logger:       logger,
+		upcaster:     events.Default(),
+		connsPerUser: DefaultUpdateConnsPerUser,
+		subs:         make(map[int]map[*updateSub]struct{}),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// WithTransactionUpdates serves /ws from u
+func WithTransactionUpdates(u *TransactionUpdates) GatewayOption {
+	return func(o *gatewayOptions) {
+		o.updates = u
+	}
+}
+
+// HandleEvent pushes a transactions topic message to its user's streams. It
+// is the handler of the topic's consumer.
+func (u *TransactionUpdates) HandleEvent(value []byte) error {
+	value, err := u.upcaster.Upcast(value)
+	if err != nil {
+		return err
+	}
+	var event struct {
+		TransactionUpdate
+		EventType string `json:"event_type"`
+		UserID    int    `json:"user_id"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	if !updateEventTypes[event.EventType] {
+		return nil
+	}
+
+	update := event.TransactionUpdate
+	update.Type = event.EventType
+	u.Publish(event.UserID, update)
+	return nil
+}
+
+// Publish pushes update to userID's streams. A stream whose client has
+// fallen updateBuffer updates behind is closed rather than waited on; the
+// client reconnects and refetches.
+func (u *TransactionUpdates) Publish(userID int, update TransactionUpdate) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for sub := range u.subs[userID] {
+		select {
+		case sub.updates <- update:
+		default:
+			u.logger.Warn("closing slow update stream", "user_id", userID)
+			u.end(sub, websocket.StatusPolicyViolation, "too slow to read updates")
+		}
+	}
+}
+
+// Subscribe opens a stream of userID's updates
+func (u *TransactionUpdates) Subscribe(userID int) (*updateSub, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closed {
+		return nil, errBackendUnavailable
+	}
+	if len(u.subs[userID]) >= u.connsPerUser {
+		return nil, errTooManyStreams
+	}
+	sub := &updateSub{userID: userID, updates: make(chan TransactionUpdate, updateBuffer)}
+	if u.subs[userID] == nil {
+		u.subs[userID] = make(map[*updateSub]struct{})
+	}
+	u.subs[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe ends sub, if Publish or Close hasn't already
+func (u *TransactionUpdates) Unsubscribe(sub *updateSub) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.subs[sub.userID][sub]; ok {
+		u.end(sub, websocket.StatusNormalClosure, "")
+	}
+}
+
+// Close ends every stream and refuses new ones, for shutdown: the server
+// doesn't track the hijacked connections streams are served on
+func (u *TransactionUpdates) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.closed = true
+	for _, subs := range u.subs {
+		for sub := range subs {
+			u.end(sub, websocket.StatusGoingAway, "server shutting down")
+		}
+	}
+}
+
+// Streams returns how many streams are open
+func (u *TransactionUpdates) Streams() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	n := 0
+	for _, subs := range u.subs {
+		n += len(subs)
+	}
+	return n
+}
+
+// end removes sub and closes its channel; u.mu must be held
+func (u *TransactionUpdates) end(sub *updateSub, status websocket.StatusCode, reason string) {
+	sub.status, sub.reason = status, reason
+	close(sub.updates)
+	delete(u.subs[sub.userID], sub)
+	if len(u.subs[sub.userID]) == 0 {
+		delete(u.subs, sub.userID)
+	}
+}
+
+// handleUpdates upgrades to a WebSocket that pushes the caller's transaction
+// updates as JSON text messages. Browsers authenticate with the session
+// cookie, as they can't set headers on WebSocket requests; other clients
+// send a bearer token. Messages from the client are ignored.
+func (g *Gateway) handleUpdates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		g.respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	userID, err := g.validateAuth(r)
+	if err != nil {
+		g.respondError(w, r, apperror.ErrUnauthorized)
+		return
+	}
+	sub, err := g.updates.Subscribe(userID)
+	if errors.Is(err, errTooManyStreams) {
+		g.respondError(w, r, errTooManyStreams)
+		return
+	} else if err != nil {
+		g.respondError(w, r, errBackendUnavailable)
+		return
+	}
+	defer g.updates.Unsubscribe(sub)
+
+	// The server's read and write timeouts would otherwise end the stream
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: g.updates.origins})
+	if err != nil {
+		g.logger.Debug("WebSocket upgrade failed", "error", err, "user_id", userID)
+		return
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	ctx := conn.CloseRead(r.Context())
+	ping := time.NewTicker(updatePingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-sub.updates:
+			if !ok {
+				_ = conn.Close(sub.status, sub.reason)
+				return
+			}
+			if err := writeUpdate(ctx, conn, update); err != nil {
+				return
+			}
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, updateWriteTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeUpdate(ctx context.Context, conn *websocket.Conn, update TransactionUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, updateWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, update)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestTransactionUpdates_HandleEvent(t *testing.T) {
+	u := NewTransactionUpdates(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	alice, _ := u.Subscribe(1)
+	bob, _ := u.Subscribe(2)
+
+	for _, event := range []string{
+		`{"event_type":"transaction.created","version":1,"transaction_id":7,"user_id":1,"amount":12.5,"description":"lunch","timestamp":"2026-01-02T03:04:05Z"}`,
+		`{"event_type":"transaction.paid","version":1,"user_id":1,"payment_batch_id":"b1","transactions_paid":3,"timestamp":"2026-01-02T03:04:06Z"}`,
+		// Not a transaction update
+		`{"event_type":"account.deletion_requested","version":1,"user_id":1}`,
+	} {
+		if err := u.HandleEvent([]byte(event)); err != nil {
+			t.Fatalf("HandleEvent(%s): %v", event, err)
+		}
+	}
+	if err := u.HandleEvent([]byte(`{"event_type":`)); err == nil {
+		t.Error("expected an error for a malformed event")
+	}
+
+	created := <-alice.updates
+	if created.Type != "transaction.created" || created.TransactionID != 7 || created.Amount != 12.5 || created.Description != "lunch" {
+		t.Errorf("unexpected created update %+v", created)
+	}
+	paid := <-alice.updates
+	if paid.Type != "transaction.paid" || paid.PaymentBatchID != "b1" || paid.TransactionsPaid != 3 {
+		t.Errorf("unexpected paid update %+v", paid)
+	}
+	if len(alice.updates) != 0 || len(bob.updates) != 0 {
+		t.Errorf("expected only alice's two updates, got %d more for alice and %d for bob", len(alice.updates), len(bob.updates))
+	}
+}
+
+func TestTransactionUpdates_Limits(t *testing.T) {
+	u := NewTransactionUpdates(slog.New(slog.NewTextHandler(io.Discard, nil)), WithUpdateConnsPerUser(2))
+	slow, _ := u.Subscribe(1)
+	if _, err := u.Subscribe(1); err != nil {
+		t.Fatalf("expected a second stream, got %v", err)
+	}
+	if _, err := u.Subscribe(1); !errors.Is(err, errTooManyStreams) {
+		t.Errorf("expected errTooManyStreams for a third stream, got %v", err)
+	}
+
+	for range updateBuffer + 1 {
+		u.Publish(1, TransactionUpdate{Type: "transaction.created"})
+	}
+	for range slow.updates {
+	}
+	if slow.status != websocket.StatusPolicyViolation {
+		t.Errorf("expected a slow stream closed with %v, got %v", websocket.StatusPolicyViolation, slow.status)
+	}
+	if n := u.Streams(); n != 0 {
+		t.Errorf("expected both unread streams closed, got %d open", n)
+	}
+
+	u.Close()
+	if _, err := u.Subscribe(2); err == nil {
+		t.Error("expected no new streams once closed")
+	}
+}
+
+func TestHandleUpdates(t *testing.T) {
+	g, auth := newTestGateway()
+	g.updates = NewTransactionUpdates(g.logger)
+	userID, token := auth.AddUser("alice", "pw")
+	server := httptest.NewServer(http.HandlerFunc(g.handleUpdates))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dial := func(header http.Header) (*websocket.Conn, *http.Response, error) {
+		return websocket.Dial(ctx, "ws"+server.URL[len("http"):]+updatesPath, &websocket.DialOptions{HTTPHeader: header})
+	}
+
+	if _, resp, err := dial(nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %v", err)
+	}
+	// A page on another site can't use the browser's session
+	if _, resp, err := dial(http.Header{"Authorization": {"Bearer " + token}, "Origin": {"https://evil.example"}}); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a cross-origin request, got %v", err)
+	}
+
+	conn, _, err := dial(http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = conn.CloseNow() }()
+	for g.updates.Streams() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	g.updates.Publish(userID, TransactionUpdate{Type: "transaction.created", TransactionID: 7, Amount: 12.5})
+	var update TransactionUpdate
+	if err := wsjson.Read(ctx, conn, &update); err != nil || update.TransactionID != 7 || update.Amount != 12.5 {
+		t.Fatalf("expected the created update, got %+v, %v", update, err)
+	}
+
+	g.updates.Close()
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Errorf("expected the stream closed with %v on shutdown, got %v", websocket.StatusGoingAway, err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS invite_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,4 @@
+-- Set when a user asks to delete their account. Logins keep working until
+-- this time so the request can be cancelled; after it the account is gone
+-- and downstream services purge the user's data.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
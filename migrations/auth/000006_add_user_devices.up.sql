@@ -0,0 +1,12 @@
+-- Devices users have logged in from, keyed by a fingerprint of the client's
+-- device id or user agent. A login with an unknown fingerprint raises a
+-- new-device alert.
+CREATE TABLE IF NOT EXISTS user_devices (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    last_ip VARCHAR(64) NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, fingerprint)
+);
@@ -0,0 +1,4 @@
+-- Channels a user receives notifications on, comma-separated, for the
+-- notification service to honour. Empty opts out of every channel.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS notification_channels VARCHAR(64) NOT NULL DEFAULT 'email';
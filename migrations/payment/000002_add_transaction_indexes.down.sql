@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_user_id_reference_id;
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_user_id_is_paid;
+DROP INDEX IF EXISTS idx_transactions_user_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS reference_id;
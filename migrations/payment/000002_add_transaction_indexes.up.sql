@@ -0,0 +1,10 @@
+-- Client-supplied reference that makes imports idempotent; ordinary creates
+-- leave it NULL, and NULLs never collide in the unique index
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reference_id TEXT;
+
+-- Indexes for the repository's query patterns. Keep the names in sync with
+-- repository.TransactionIndexes, which payment-service checks at startup.
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_is_paid ON transactions (user_id, is_paid);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_id_reference_id ON transactions (user_id, reference_id);
@@ -0,0 +1,5 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_uploaded_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_filename;
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_size;
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_content_type;
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_key;
@@ -0,0 +1,7 @@
+-- Receipt metadata; the file itself lives in blob storage under receipt_key.
+-- All columns are NULL until a receipt is attached.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_key TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_content_type TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_size BIGINT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_filename TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_uploaded_at TIMESTAMP;
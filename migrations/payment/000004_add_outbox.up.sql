@@ -0,0 +1,13 @@
+-- Transactional outbox for EVENT_DELIVERY=outbox. Rows are written in the same
+-- statement as the change they describe and deleted once published. Column
+-- names follow Debezium's outbox event router, so Debezium can publish them
+-- instead of the built-in relay; seq keeps the relay's order stable.
+CREATE TABLE IF NOT EXISTS outbox (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    aggregatetype VARCHAR(255) NOT NULL,
+    aggregateid VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS source_country;
+ALTER TABLE transactions DROP COLUMN IF EXISTS source_channel;
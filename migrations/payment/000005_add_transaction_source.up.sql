@@ -0,0 +1,4 @@
+-- Where each transaction was created from, for analytics breakdowns. NULL
+-- when the gateway didn't know; rows from before this migration stay NULL.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_channel TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_country TEXT;
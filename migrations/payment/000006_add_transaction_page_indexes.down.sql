@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_user_id_amount_id;
+DROP INDEX IF EXISTS idx_transactions_user_id_created_at_id;
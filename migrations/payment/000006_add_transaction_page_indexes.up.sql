@@ -0,0 +1,5 @@
+-- Keyset pagination reads a user's transactions in (sort column, id) order;
+-- these let each page start at the cursor instead of sorting every row.
+-- Keep the names in sync with repository.TransactionIndexes.
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at_id ON transactions (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_amount_id ON transactions (user_id, amount, id);
@@ -0,0 +1,11 @@
+-- Archived rows go back to transactions before the archive is dropped
+INSERT INTO transactions (id, user_id, amount, description, is_paid, created_at, reference_id,
+    receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+    source_channel, source_country)
+SELECT id, user_id, amount, description, is_paid, created_at, reference_id,
+    receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+    source_channel, source_country
+FROM transactions_archive
+ON CONFLICT (id) DO NOTHING;
+
+DROP TABLE IF EXISTS transactions_archive;
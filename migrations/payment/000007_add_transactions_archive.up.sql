@@ -0,0 +1,11 @@
+-- Paid transactions past payment-service's ARCHIVE_AFTER_DAYS move here, so
+-- the indexes hot queries use on transactions stay small. The columns mirror
+-- transactions; add new columns to both tables and to the repository's
+-- archivedColumns.
+CREATE TABLE IF NOT EXISTS transactions_archive (
+    LIKE transactions,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_archive_user_id_created_at_id ON transactions_archive (user_id, created_at, id);
@@ -0,0 +1,45 @@
+ALTER TABLE transactions RENAME TO transactions_partitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_partitioned_pkey;
+ALTER SEQUENCE transactions_id_seq OWNED BY NONE;
+
+-- Index names are schema-wide, so free them before recreating them
+DROP INDEX IF EXISTS idx_transactions_user_id;
+DROP INDEX IF EXISTS idx_transactions_user_id_is_paid;
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_user_id_reference_id;
+DROP INDEX IF EXISTS idx_transactions_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_transactions_user_id_amount_id;
+
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY DEFAULT nextval('transactions_id_seq'),
+    user_id INTEGER NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    description TEXT,
+    is_paid BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reference_id TEXT,
+    receipt_key TEXT,
+    receipt_content_type TEXT,
+    receipt_size BIGINT,
+    receipt_filename TEXT,
+    receipt_uploaded_at TIMESTAMP,
+    source_channel TEXT,
+    source_country TEXT
+);
+
+ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+INSERT INTO transactions SELECT id, user_id, amount, description, is_paid, created_at, reference_id,
+    receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+    source_channel, source_country
+FROM transactions_partitioned;
+
+-- Drops every partition with it
+DROP TABLE transactions_partitioned;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_is_paid ON transactions (user_id, is_paid);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_id_reference_id ON transactions (user_id, reference_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at_id ON transactions (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_amount_id ON transactions (user_id, amount, id);
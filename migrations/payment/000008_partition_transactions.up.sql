@@ -0,0 +1,67 @@
+-- Partition transactions by month of created_at, so queries on a created_at
+-- range read only the months they cover and whole months can be dropped.
+-- Partitions are named transactions_pYYYYMM; payment-service creates the
+-- coming months ahead of time, and rows outside every partition land in
+-- transactions_default.
+--
+-- Postgres requires unique indexes on a partitioned table to include
+-- created_at, so the primary key becomes (id, created_at) and reference_id
+-- is unique per user and creation time.
+
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_unpartitioned_pkey;
+-- Keep the sequence when the old table is dropped
+ALTER SEQUENCE transactions_id_seq OWNED BY NONE;
+
+CREATE TABLE transactions (
+    id INTEGER NOT NULL DEFAULT nextval('transactions_id_seq'),
+    user_id INTEGER NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    description TEXT,
+    is_paid BOOLEAN DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reference_id TEXT,
+    receipt_key TEXT,
+    receipt_content_type TEXT,
+    receipt_size BIGINT,
+    receipt_filename TEXT,
+    receipt_uploaded_at TIMESTAMP,
+    source_channel TEXT,
+    source_country TEXT,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+CREATE TABLE transactions_default PARTITION OF transactions DEFAULT;
+
+-- One partition per month from the oldest transaction to three months ahead
+DO $$
+DECLARE
+    month DATE := date_trunc('month', COALESCE((SELECT min(created_at) FROM transactions_unpartitioned), now()));
+BEGIN
+    WHILE month <= date_trunc('month', now()) + interval '3 months' LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF transactions FOR VALUES FROM (%L) TO (%L)',
+            'transactions_p' || to_char(month, 'YYYYMM'), month, month + interval '1 month');
+        month := month + interval '1 month';
+    END LOOP;
+END $$;
+
+INSERT INTO transactions (id, user_id, amount, description, is_paid, created_at, reference_id,
+    receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+    source_channel, source_country)
+SELECT id, user_id, amount, description, is_paid, COALESCE(created_at, CURRENT_TIMESTAMP), reference_id,
+    receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+    source_channel, source_country
+FROM transactions_unpartitioned;
+
+DROP TABLE transactions_unpartitioned;
+
+-- The same index names as before, now on every partition. Keep them in sync
+-- with repository.TransactionIndexes.
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_is_paid ON transactions (user_id, is_paid);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_id_reference_id ON transactions (user_id, reference_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at_id ON transactions (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_amount_id ON transactions (user_id, amount, id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_archive_user_id_ulid;
+DROP INDEX IF EXISTS idx_transactions_user_id_ulid;
+
+ALTER TABLE transactions_archive DROP COLUMN IF EXISTS ulid;
+ALTER TABLE transactions DROP COLUMN IF EXISTS ulid;
@@ -0,0 +1,11 @@
+-- ULIDs are the transactions' external identifiers, generated by
+-- payment-service on insert, so clients can't enumerate other users'
+-- transactions by counting serial IDs. Rows created before this migration
+-- have none. Partitioned tables can't have a unique index without
+-- created_at, and ULIDs are random enough not to need one.
+-- Keep the index names in sync with repository.TransactionIndexes.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ulid TEXT;
+ALTER TABLE transactions_archive ADD COLUMN IF NOT EXISTS ulid TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_ulid ON transactions (user_id, ulid);
+CREATE INDEX IF NOT EXISTS idx_transactions_archive_user_id_ulid ON transactions_archive (user_id, ulid);
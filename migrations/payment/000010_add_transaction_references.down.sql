@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_id_reference_id ON transactions (user_id, reference_id, created_at);
+
+DROP TABLE IF EXISTS transaction_references;
@@ -0,0 +1,27 @@
+-- Since transactions was partitioned (000008), its unique reference_id index
+-- has had to include created_at, which lets the same reference be imported
+-- again at another time. References are claimed here instead, in a plain
+-- table, by the same statement that inserts the transaction. Rows stay when
+-- their transaction is archived or its partition dropped, so a reference
+-- can never be reused.
+CREATE TABLE IF NOT EXISTS transaction_references (
+    user_id INTEGER NOT NULL,
+    reference_id TEXT NOT NULL,
+    transaction_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, reference_id)
+);
+
+-- The first transaction to use a reference claims it
+INSERT INTO transaction_references (user_id, reference_id, transaction_id, created_at)
+SELECT user_id, reference_id, id, created_at
+FROM (
+    SELECT user_id, reference_id, id, created_at FROM transactions WHERE reference_id IS NOT NULL
+    UNION ALL
+    SELECT user_id, reference_id, id, COALESCE(created_at, archived_at) FROM transactions_archive WHERE reference_id IS NOT NULL
+) refs
+ORDER BY created_at, id
+ON CONFLICT (user_id, reference_id) DO NOTHING;
+
+-- Keep the index names in sync with repository.TransactionIndexes
+DROP INDEX IF EXISTS idx_transactions_user_id_reference_id;
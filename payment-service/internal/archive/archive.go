@@ -0,0 +1,122 @@
+// Package archive moves paid transactions past a configured age out of the
+// transactions table, keeping the indexes hot queries read small.
+package archive
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+// Archive defaults
+const (
+	DefaultAge       = 90 * 24 * time.Hour
+	DefaultBatchSize = 1000
+	DefaultInterval  = time.Hour
+
+	// MinAge keeps transactions in place until the daily export, which reads
+	// only live transactions, has written them
+	MinAge = 48 * time.Hour
+)
+
+// Archiver periodically archives paid transactions older than its age
+type Archiver struct {
+	repo      domain.TransactionArchiver
+	logger    *slog.Logger
+	age       time.Duration
+	batchSize int
+	interval  time.Duration
+	clock     clock.Clock
+}
+
+// Option configures an Archiver
+type Option func(*Archiver)
+
+// WithAge sets how old a paid transaction gets before it is archived; ages
+// below MinAge are raised to it
+func WithAge(d time.Duration) Option {
+	return func(a *Archiver) {
+		a.age = max(d, MinAge)
+	}
+}
+
+// WithBatchSize sets how many transactions each statement moves, bounding
+// how long rows stay locked
+func WithBatchSize(n int) Option {
+	return func(a *Archiver) {
+		if n > 0 {
+			a.batchSize = n
+		}
+	}
+}
+
+// WithInterval sets how often Run looks for transactions to archive
+func WithInterval(d time.Duration) Option {
+	return func(a *Archiver) {
+		if d > 0 {
+			a.interval = d
+		}
+	}
+}
+
+// WithClock sets the clock archiving ages and Run ticks are measured by
+// (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(a *Archiver) {
+		a.clock = c
+	}
+}
+
+// NewArchiver creates an Archiver moving transactions through repo
+func NewArchiver(repo domain.TransactionArchiver, logger *slog.Logger, opts ...Option) *Archiver {
+	a := &Archiver{
+		repo:      repo,
+		logger:    logger,
+		age:       DefaultAge,
+		batchSize: DefaultBatchSize,
+		interval:  DefaultInterval,
+		clock:     clock.Real,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Run archives until ctx is cancelled. A failed run is retried on the next
+// tick; whatever it moved before failing stays archived.
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := a.clock.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := a.Archive(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.logger.Error("transaction archiving failed", "error", err, "archived", n)
+		} else if n > 0 {
+			a.logger.Info("transactions archived", "archived", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Archive moves every paid transaction older than the age, a batch at a
+// time, returning how many were moved
+func (a *Archiver) Archive(ctx context.Context) (int64, error) {
+	before := a.clock.Now().Add(-a.age)
+	var total int64
+	for {
+		n, err := a.repo.ArchivePaid(ctx, before, a.batchSize)
+		total += n
+		if err != nil || n < int64(a.batchSize) {
+			return total, err
+		}
+	}
+}
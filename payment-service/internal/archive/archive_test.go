@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+func TestArchiver_Archive(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-31 * 24 * time.Hour)
+	repo.Seed(
+		domain.Transaction{ID: 1, UserID: 1, Amount: 5, IsPaid: true, CreatedAt: old},
+		domain.Transaction{ID: 2, UserID: 1, Amount: 6, IsPaid: true, CreatedAt: old.Add(time.Hour)},
+		domain.Transaction{ID: 3, UserID: 2, Amount: 7, IsPaid: true, CreatedAt: old.Add(2 * time.Hour)},
+		// Unpaid and recent transactions stay
+		domain.Transaction{ID: 4, UserID: 1, Amount: 8, CreatedAt: old},
+		domain.Transaction{ID: 5, UserID: 1, Amount: 9, IsPaid: true, CreatedAt: now.Add(-24 * time.Hour)},
+	)
+
+	a := NewArchiver(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithAge(30*24*time.Hour), WithBatchSize(2))
+	a.clock = clock.NewFake(now)
+
+	n, err := a.Archive(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 || len(repo.Archived()) != 3 {
+		t.Errorf("expected 3 transactions archived over two batches, got %d (%d in the archive)", n, len(repo.Archived()))
+	}
+	for _, tx := range repo.Transactions() {
+		if tx.ID <= 3 {
+			t.Errorf("expected transaction %d to have left the live table", tx.ID)
+		}
+	}
+
+	if n, _ := a.Archive(context.Background()); n != 0 {
+		t.Errorf("expected nothing left to archive, got %d", n)
+	}
+}
+
+func TestWithAge_Minimum(t *testing.T) {
+	a := NewArchiver(testutil.NewFakeTransactionRepository(), slog.New(slog.NewTextHandler(io.Discard, nil)), WithAge(time.Hour))
+	if a.age != MinAge {
+		t.Errorf("expected the age to be raised to %v, got %v", MinAge, a.age)
+	}
+}
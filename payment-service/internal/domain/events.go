@@ -11,24 +11,107 @@ type EventPublisher interface {
 	PublishTransactionCreated(ctx context.Context, event *TransactionCreatedEvent) error
 	// PublishTransactionPaid publishes a transaction paid event
 	PublishTransactionPaid(ctx context.Context, event *TransactionPaidEvent) error
+	// PublishPaymentReverted publishes a payment reverted event
+	PublishPaymentReverted(ctx context.Context, event *PaymentRevertedEvent) error
+	// PublishLimitApproaching publishes a limit approaching event
+	PublishLimitApproaching(ctx context.Context, event *LimitApproachingEvent) error
 	// Close closes the publisher
 	Close() error
 }
 
 // TransactionCreatedEvent represents a transaction created event
 type TransactionCreatedEvent struct {
-	EventType     string    `json:"event_type"`
-	TransactionID int       `json:"transaction_id"`
-	UserID        int       `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	Description   string    `json:"description"`
-	Timestamp     time.Time `json:"timestamp"`
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version       int `json:"version"`
+	TransactionID int `json:"transaction_id"`
+	// TransactionULID is the transaction's external ID; empty for events
+	// from before ULIDs
+	TransactionULID string  `json:"transaction_ulid,omitempty"`
+	UserID          int     `json:"user_id"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description"`
+	// Source is where the create request came from, when known
+	Source    *EventSource `json:"source,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// EventSource describes the client behind a transaction, for analytics
+// breakdowns
+type EventSource struct {
+	// Channel is the kind of client: "web", "mobile" or "api"
+	Channel string `json:"channel,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 code of the client's address
+	Country string `json:"country,omitempty"`
 }
 
 // TransactionPaidEvent represents a transaction paid event
 type TransactionPaidEvent struct {
-	EventType        string    `json:"event_type"`
-	UserID           int       `json:"user_id"`
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version int `json:"version"`
+	UserID  int `json:"user_id"`
+	// PaymentBatchID identifies the payment the event reports. A retried
+	// publish carries the same ID, so consumers can drop the duplicate.
+	PaymentBatchID   string    `json:"payment_batch_id"`
 	TransactionsPaid int64     `json:"transactions_paid"`
 	Timestamp        time.Time `json:"timestamp"`
 }
+
+// PaymentRevertedEvent compensates for the TransactionPaidEvent of the same
+// payment batch when charging for it failed: the transactions are unpaid
+// again, so consumers subtract them
+type PaymentRevertedEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version              int       `json:"version"`
+	UserID               int       `json:"user_id"`
+	PaymentBatchID       string    `json:"payment_batch_id"`
+	TransactionsReverted int64     `json:"transactions_reverted"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// LimitApproachingEvent warns that a transaction brought a user's total for
+// the current limit period past a warning threshold, for the notification
+// service to tell the user. It is published once per threshold per period;
+// UserID, PeriodStart and Threshold identify it, so consumers can drop a
+// duplicate.
+type LimitApproachingEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version int `json:"version"`
+	UserID  int `json:"user_id"`
+	// Threshold is the fraction of MaxAllowed crossed, e.g. 0.8
+	Threshold      float64 `json:"threshold"`
+	CurrentTotal   float64 `json:"current_total"`
+	MaxAllowed     float64 `json:"max_allowed"`
+	RemainingLimit float64 `json:"remaining_limit"`
+	// PeriodStart and ResetsAt bound the limit period; both are zero for
+	// the lifetime period
+	PeriodStart time.Time `json:"period_start,omitzero"`
+	ResetsAt    time.Time `json:"resets_at,omitzero"`
+	// TransactionID is the transaction that crossed the threshold
+	TransactionID int       `json:"transaction_id"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// ExportPublisher announces finished transaction exports
+type ExportPublisher interface {
+	// PublishExportCompleted publishes an export completed event
+	PublishExportCompleted(ctx context.Context, event *ExportCompletedEvent) error
+}
+
+// ExportCompletedEvent announces that a day of transactions was written to
+// blob storage and can be loaded
+type ExportCompletedEvent struct {
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version int `json:"version"`
+	// Date is the exported day, YYYY-MM-DD in the export timezone
+	Date      string    `json:"date"`
+	Key       string    `json:"key"`
+	Format    string    `json:"format"`
+	Rows      int64     `json:"rows"`
+	Bytes     int64     `json:"bytes"`
+	Timestamp time.Time `json:"timestamp"`
+}
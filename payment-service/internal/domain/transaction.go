@@ -2,29 +2,218 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrDuplicateReference is returned when creating a transaction whose
+// reference ID the user has already used
+var ErrDuplicateReference = errors.New("reference_id already used")
+
 // Transaction represents a payment transaction
 type Transaction struct {
-	ID          int       `json:"id"`
+	ID int `json:"id"`
+	// ULID is the transaction's external identifier, safe to expose where
+	// sequential IDs would let clients enumerate others' transactions. It is
+	// generated on insert and empty for transactions created before ULIDs.
+	ULID        string    `json:"ulid,omitempty"`
 	UserID      int       `json:"user_id"`
 	Amount      float64   `json:"amount"`
 	Description string    `json:"description"`
 	IsPaid      bool      `json:"is_paid"`
 	CreatedAt   time.Time `json:"created_at"`
+	// ReferenceID is the client's identifier for an imported transaction,
+	// unique per user; empty for ordinary creates
+	ReferenceID string `json:"reference_id,omitempty"`
+	// Source is the client that created the transaction, when known. It is
+	// stored for analytics and not returned by the API.
+	Source *EventSource `json:"-"`
+}
+
+// Receipt is the metadata of a transaction's uploaded receipt. The file lives
+// in blob storage under Key.
+type Receipt struct {
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Filename    string    `json:"filename"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// Transaction field names, shared with the gRPC contract and field masks
+const (
+	FieldID          = "id"
+	FieldULID        = "ulid"
+	FieldUserID      = "user_id"
+	FieldAmount      = "amount"
+	FieldDescription = "description"
+	FieldIsPaid      = "is_paid"
+	FieldCreatedAt   = "created_at"
+)
+
+// TransactionFields lists every transaction field in column order
+var TransactionFields = []string{FieldID, FieldULID, FieldUserID, FieldAmount, FieldDescription, FieldIsPaid, FieldCreatedAt}
+
+// Sort keys and directions for listing transactions
+const (
+	SortByCreatedAt = "created_at"
+	SortByAmount    = "amount"
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ListOptions controls how transactions are listed
+type ListOptions struct {
+	// Fields limits the fields read from storage; empty reads every field
+	Fields []string
+	// SortBy is SortByCreatedAt (default) or SortByAmount
+	SortBy string
+	// Order is SortAsc or SortDesc (default)
+	Order string
+	// After, when set, lists only the transactions sorting after it
+	After *TransactionCursor
+	// Limit caps the transactions returned; 0 returns every one
+	Limit int
+	// IncludeArchived also lists transactions moved to the archive
+	IncludeArchived bool
+	// CreatedFrom and CreatedBefore, when set, list only the transactions
+	// created in [CreatedFrom, CreatedBefore)
+	CreatedFrom   time.Time
+	CreatedBefore time.Time
+}
+
+// TransactionCursor is the position of a transaction in a sorted listing.
+// Only the value of the sort field is set, alongside the ID breaking ties.
+type TransactionCursor struct {
+	SortBy    string    `json:"s"`
+	Order     string    `json:"o"`
+	CreatedAt time.Time `json:"c,omitzero"`
+	Amount    float64   `json:"a,omitempty"`
+	ID        int       `json:"i"`
+}
+
+// NewTransactionCursor returns the position of tx in a listing sorted by opts
+func NewTransactionCursor(tx Transaction, opts ListOptions) TransactionCursor {
+	cursor := TransactionCursor{SortBy: opts.SortBy, Order: opts.Order, ID: tx.ID}
+	if opts.SortBy == SortByAmount {
+		cursor.Amount = tx.Amount
+	} else {
+		cursor.CreatedAt = tx.CreatedAt
+	}
+	return cursor
+}
+
+// CreateTransactionResult is a created transaction with the user's spending
+// headroom after it
+type CreateTransactionResult struct {
+	*Transaction
+	CurrentTotal   float64 `json:"current_total"`
+	RemainingLimit float64 `json:"remaining_limit"`
+}
+
+// TransactionSummary aggregates a user's transactions
+type TransactionSummary struct {
+	UnpaidTotal      float64 `json:"unpaid_total"`
+	PaidTotal        float64 `json:"paid_total"`
+	UnpaidCount      int64   `json:"unpaid_count"`
+	PaidCount        int64   `json:"paid_count"`
+	TransactionCount int64   `json:"transaction_count"`
+	PeriodTotal      float64 `json:"period_total"`
+	RemainingLimit   float64 `json:"remaining_limit"`
+	MaxAllowed       float64 `json:"max_allowed"`
+	// ResetsAt is when the limit period ends; zero for the lifetime period
+	ResetsAt time.Time `json:"resets_at,omitzero"`
+	// LimitWarnings are the warning thresholds, as fractions of MaxAllowed,
+	// PeriodTotal has reached
+	LimitWarnings []float64 `json:"limit_warnings"`
 }
 
 // TransactionRepository defines the interface for transaction data access
 type TransactionRepository interface {
 	// Create creates a new transaction
 	Create(ctx context.Context, tx *Transaction) (*Transaction, error)
-	// FindByUserID finds all transactions for a user
-	FindByUserID(ctx context.Context, userID int) ([]Transaction, error)
-	// GetTotalAmountByUserID returns the total amount of all transactions for a user
-	GetTotalAmountByUserID(ctx context.Context, userID int) (float64, error)
+	// FindByUserID finds all transactions for a user, reading only opts.Fields when set
+	FindByUserID(ctx context.Context, userID int, opts ListOptions) ([]Transaction, error)
+	// CountByUserID returns the number of a user's transactions, counting
+	// archived ones when includeArchived is set
+	CountByUserID(ctx context.Context, userID int, includeArchived bool) (int64, error)
+	// GetTotalAmountByUserID returns the total amount of a user's transactions
+	// created at or after since, archived ones included; a zero since covers
+	// all transactions
+	GetTotalAmountByUserID(ctx context.Context, userID int, since time.Time) (float64, error)
+	// GetSummaryByUserID aggregates paid and unpaid totals and counts for a user,
+	// and the total of transactions created at or after periodStart. Archived
+	// transactions are included.
+	GetSummaryByUserID(ctx context.Context, userID int, periodStart time.Time) (*TransactionSummary, error)
 	// MarkAllAsPaid marks all unpaid transactions for a user as paid
 	MarkAllAsPaid(ctx context.Context, userID int) (int64, error)
+	// AttachReceipt sets the receipt of one of the user's transactions and
+	// returns the key of the receipt it replaced, if any. found is false when
+	// the user has no such transaction; archived transactions are read-only.
+	AttachReceipt(ctx context.Context, userID, transactionID int, receipt *Receipt) (replacedKey string, found bool, err error)
+	// FindReceipt returns the receipt of one of the user's transactions,
+	// archived or not, or nil when there is no such transaction or it has no
+	// receipt
+	FindReceipt(ctx context.Context, userID, transactionID int) (*Receipt, error)
+	// FindIDByULID returns the ID of the user's transaction with the given
+	// ULID, archived or not, or 0 when there is none
+	FindIDByULID(ctx context.Context, userID int, ulid string) (int, error)
+	// ForEachCreatedBetween calls fn for every transaction created in
+	// [from, to), in ID order, stopping at the first error fn returns
+	ForEachCreatedBetween(ctx context.Context, from, to time.Time, fn func(*Transaction) error) error
+	// ForEachCreatedBefore calls fn for every transaction created before
+	// before, archived ones included, in ID order, stopping at the first
+	// error fn returns
+	ForEachCreatedBefore(ctx context.Context, before time.Time, fn func(*Transaction) error) error
+}
+
+// TransactionBatchPayer pays a user's transactions as one batch that can be
+// reverted, for the pay saga. With an outbox, each call also records its
+// event, tagged with batchID.
+type TransactionBatchPayer interface {
+	// PayBatch marks the user's unpaid transactions paid and returns them
+	PayBatch(ctx context.Context, userID int, batchID string) ([]Transaction, error)
+	// RevertBatch marks the user's transactions ids unpaid again, returning
+	// how many were still paid
+	RevertBatch(ctx context.Context, userID int, batchID string, ids []int) (int64, error)
+}
+
+// PaymentProvider charges users for the transactions they pay
+type PaymentProvider interface {
+	// Charge charges the user for a batch of transactions. Charging the same
+	// batch twice must not charge twice.
+	Charge(ctx context.Context, charge *Charge) error
+}
+
+// Charge is a batch of a user's transactions charged together
+type Charge struct {
+	BatchID        string  `json:"batch_id"`
+	UserID         int     `json:"user_id"`
+	Amount         float64 `json:"amount"`
+	TransactionIDs []int   `json:"transaction_ids"`
+}
+
+// TransactionArchiver moves old paid transactions out of the table hot
+// queries read
+type TransactionArchiver interface {
+	// ArchivePaid moves up to limit paid transactions created before before
+	// to the archive, returning how many were moved
+	ArchivePaid(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// TransactionPartitioner maintains the monthly partitions of the
+// transactions table
+type TransactionPartitioner interface {
+	// EnsurePartitions creates the partitions for from's month through months
+	// after it, skipping those that exist
+	EnsurePartitions(ctx context.Context, from time.Time, months int) error
+	// ExpiredPartitions returns the names of the partitions of months ending
+	// on or before before, oldest first
+	ExpiredPartitions(ctx context.Context, before time.Time) ([]string, error)
+	// ArchivePartition moves a partition's paid transactions to the archive
+	// and drops it if none are left unpaid, reporting whether it was dropped
+	ArchivePartition(ctx context.Context, name string) (bool, error)
 }
 
 // CreateTransactionRequest represents the request to create a transaction
@@ -32,4 +221,13 @@ type CreateTransactionRequest struct {
 	UserID      int     `json:"user_id"`
 	Amount      float64 `json:"amount"`
 	Description string  `json:"description"`
+	// ReferenceID, when set, makes the create idempotent: a second
+	// transaction with the same reference is rejected with
+	// ErrDuplicateReference rather than imported twice
+	ReferenceID string `json:"reference_id,omitempty"`
+	// Timezone is the IANA zone used for limit period boundaries: the
+	// user's preference, never a client's choice
+	Timezone string `json:"-"`
+	// Source is the client making the request, when known
+	Source *EventSource `json:"-"`
 }
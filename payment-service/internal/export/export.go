@@ -0,0 +1,210 @@
+// Package export writes each day's transactions to blob storage for data
+// warehouses and announces every finished file with an export.completed event.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+// Export defaults
+const (
+	DefaultPrefix = "exports/transactions"
+	DefaultHour   = 1
+
+	// A failed export is retried this many times, retryDelay apart, before
+	// waiting for the next day's run
+	maxAttempts = 3
+	retryDelay  = time.Minute
+)
+
+// Exporter writes one file per day of transactions, keyed
+// {prefix}/date=YYYY-MM-DD/transactions.{ext} so warehouses can load the
+// prefix as a date-partitioned table. Re-exporting a day overwrites its file.
+type Exporter struct {
+	repo      domain.TransactionRepository
+	store     blob.Store
+	publisher domain.ExportPublisher
+	logger    *slog.Logger
+	format    Format
+	loc       *time.Location
+	hour      int
+	prefix    string
+	clock     clock.Clock
+}
+
+// Option configures an Exporter
+type Option func(*Exporter)
+
+// WithFormat sets the file format (default CSV)
+func WithFormat(format Format) Option {
+	return func(e *Exporter) {
+		e.format = format
+	}
+}
+
+// WithLocation sets the timezone that days are cut in (default UTC)
+func WithLocation(loc *time.Location) Option {
+	return func(e *Exporter) {
+		e.loc = loc
+	}
+}
+
+// WithHour sets the hour of day, in the export timezone, that Run exports
+// the previous day
+func WithHour(hour int) Option {
+	return func(e *Exporter) {
+		e.hour = min(max(hour, 0), 23)
+	}
+}
+
+// WithPrefix sets the key prefix exports are written under
+func WithPrefix(prefix string) Option {
+	return func(e *Exporter) {
+		e.prefix = prefix
+	}
+}
+
+// WithClock sets the clock Run schedules exports by (default the system
+// clock)
+func WithClock(c clock.Clock) Option {
+	return func(e *Exporter) {
+		e.clock = c
+	}
+}
+
+// NewExporter creates an Exporter reading from repo and writing to store. A
+// nil publisher skips the completion events.
+func NewExporter(repo domain.TransactionRepository, store blob.Store, publisher domain.ExportPublisher, logger *slog.Logger, opts ...Option) *Exporter {
+	e := &Exporter{
+		repo:      repo,
+		store:     store,
+		publisher: publisher,
+		logger:    logger,
+		format:    FormatCSV,
+		loc:       time.UTC,
+		hour:      DefaultHour,
+		prefix:    DefaultPrefix,
+		clock:     clock.Real,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run exports the previous day once a day at the configured hour until ctx
+// is cancelled
+func (e *Exporter) Run(ctx context.Context) {
+	for {
+		next := e.nextRun(e.clock.Now())
+		timer := e.clock.NewTimer(next.Sub(e.clock.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		day := next.AddDate(0, 0, -1)
+		for attempt := 1; attempt <= maxAttempts; attempt++ {
+			event, err := e.Export(ctx, day)
+			if err == nil {
+				e.logger.Info("transactions exported", "date", event.Date, "key", event.Key, "rows", event.Rows, "bytes", event.Bytes)
+				break
+			}
+			e.logger.Error("transaction export failed", "date", day.Format(time.DateOnly), "attempt", attempt, "error", err)
+			if attempt == maxAttempts {
+				break
+			}
+			retry := e.clock.NewTimer(retryDelay)
+			select {
+			case <-ctx.Done():
+				retry.Stop()
+				return
+			case <-retry.C():
+			}
+		}
+	}
+}
+
+// nextRun returns the first run time strictly after now
+func (e *Exporter) nextRun(now time.Time) time.Time {
+	local := now.In(e.loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), e.hour, 0, 0, 0, e.loc)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Export writes the transactions created on day, in the export timezone, and
+// publishes an export.completed event for the file
+func (e *Exporter) Export(ctx context.Context, day time.Time) (*domain.ExportCompletedEvent, error) {
+	local := day.In(e.loc)
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, e.loc)
+	to := from.AddDate(0, 0, 1)
+	date := from.Format(time.DateOnly)
+
+	// Spool to a temporary file so the upload has a known size
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w, err := newRowWriter(e.format, tmp)
+	if err != nil {
+		return nil, err
+	}
+	var rows int64
+	err = e.repo.ForEachCreatedBetween(ctx, from, to, func(tx *domain.Transaction) error {
+		rows++
+		return w.Write(tx)
+	})
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size export: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind export: %w", err)
+	}
+
+	key := fmt.Sprintf("%s/date=%s/transactions.%s", e.prefix, date, e.format.Extension())
+	if err := e.store.Put(ctx, key, tmp, size, e.format.ContentType()); err != nil {
+		return nil, err
+	}
+
+	event := &domain.ExportCompletedEvent{
+		Date:      date,
+		Key:       key,
+		Format:    string(e.format),
+		Rows:      rows,
+		Bytes:     size,
+		Timestamp: e.clock.Now(),
+	}
+	if e.publisher != nil {
+		if err := e.publisher.PublishExportCompleted(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
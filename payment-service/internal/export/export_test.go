@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+)
+
+func newTestExporter(t *testing.T, opts ...Option) (*Exporter, *testutil.FakeTransactionRepository, blob.Store, *testutil.FakeEventPublisher) {
+	t.Helper()
+	repo := testutil.NewFakeTransactionRepository()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := testutil.NewFakeEventPublisher()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewExporter(repo, store, publisher, logger, opts...), repo, store, publisher
+}
+
+func TestExporter_Export(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	e, repo, store, publisher := newTestExporter(t, WithLocation(bangkok))
+
+	repo.Seed(
+		// 2024-03-09 23:30 in Bangkok: the day before
+		domain.Transaction{ID: 1, UserID: 1, Amount: 5, CreatedAt: time.Date(2024, 3, 9, 16, 30, 0, 0, time.UTC)},
+		domain.Transaction{ID: 2, UserID: 1, Amount: 12.5, Description: "lunch, with tea", CreatedAt: time.Date(2024, 3, 9, 17, 0, 0, 0, time.UTC)},
+		domain.Transaction{ID: 3, UserID: 2, Amount: 40, IsPaid: true, CreatedAt: time.Date(2024, 3, 10, 16, 59, 0, 0, time.UTC)},
+		// 2024-03-11 00:00 in Bangkok: the day after
+		domain.Transaction{ID: 4, UserID: 2, Amount: 1, CreatedAt: time.Date(2024, 3, 10, 17, 0, 0, 0, time.UTC)},
+	)
+
+	event, err := e.Export(context.Background(), time.Date(2024, 3, 10, 12, 0, 0, 0, bangkok))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if event.Date != "2024-03-10" || event.Key != "exports/transactions/date=2024-03-10/transactions.csv.gz" || event.Rows != 2 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if got := publisher.ExportEvents(); len(got) != 1 || got[0].Key != event.Key {
+		t.Errorf("expected one published event, got %+v", got)
+	}
+
+	rc, info, err := store.Get(context.Background(), event.Key)
+	if err != nil {
+		t.Fatalf("expected the export to be stored, got %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+	if info.Size != event.Bytes {
+		t.Errorf("expected %d bytes, got %d", event.Bytes, info.Size)
+	}
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		t.Fatalf("expected gzip, got %v", err)
+	}
+	records, err := csv.NewReader(gz).ReadAll()
+	if err != nil {
+		t.Fatalf("expected CSV, got %v", err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"2", "1", "12.50", "lunch, with tea", "false", "2024-03-09T17:00:00Z"},
+		{"3", "2", "40.00", "", "true", "2024-03-10T16:59:00Z"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %v", len(want), records)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if records[i][j] != want[i][j] {
+				t.Errorf("record %d: expected %v, got %v", i, want[i], records[i])
+				break
+			}
+		}
+	}
+}
+
+func TestExporter_Export_Parquet(t *testing.T) {
+	e, repo, store, _ := newTestExporter(t, WithFormat(FormatParquet))
+	created := time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)
+	repo.Seed(
+		domain.Transaction{ID: 1, UserID: 7, Amount: 12.5, Description: "lunch", CreatedAt: created},
+		domain.Transaction{ID: 2, UserID: 8, Amount: 3, IsPaid: true, CreatedAt: created.Add(time.Hour)},
+	)
+
+	event, err := e.Export(context.Background(), created)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if event.Key != "exports/transactions/date=2024-03-10/transactions.parquet" || event.Format != "parquet" || event.Rows != 2 {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	rc, _, err := store.Get(context.Background(), event.Key)
+	if err != nil {
+		t.Fatalf("expected the export to be stored, got %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected Parquet, got %v", err)
+	}
+	want := []parquetRow{
+		{ID: 1, UserID: 7, Amount: 12.5, Description: "lunch", CreatedAt: created},
+		{ID: 2, UserID: 8, Amount: 3, IsPaid: true, CreatedAt: created.Add(time.Hour)},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), rows)
+	}
+	for i := range want {
+		if rows[i].ID != want[i].ID || rows[i].Amount != want[i].Amount || rows[i].Description != want[i].Description ||
+			rows[i].IsPaid != want[i].IsPaid || !rows[i].CreatedAt.Equal(want[i].CreatedAt) {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], rows[i])
+		}
+	}
+}
+
+func TestExporter_Export_Failures(t *testing.T) {
+	e, repo, _, publisher := newTestExporter(t)
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	repo.FindErr = errors.New("connection reset")
+	if _, err := e.Export(context.Background(), day); err == nil {
+		t.Error("expected a repository failure to fail the export")
+	}
+	repo.FindErr = nil
+
+	publisher.Err = errors.New("broker down")
+	if _, err := e.Export(context.Background(), day); err == nil {
+		t.Error("expected a publish failure to fail the export so it is retried")
+	}
+}
+
+func TestExporter_NextRun(t *testing.T) {
+	e, _, _, _ := newTestExporter(t, WithHour(2))
+
+	tests := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2024, 3, 10, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 2, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := e.nextRun(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextRun(%v): expected %v, got %v", tt.now, tt.want, got)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, want := range []Format{FormatCSV, FormatParquet} {
+		if f, err := ParseFormat(string(want)); err != nil || f != want {
+			t.Errorf("expected %q, got %q, %v", want, f, err)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+}
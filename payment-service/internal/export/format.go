@@ -0,0 +1,160 @@
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+)
+
+// Format is an export file format
+type Format string
+
+// Supported formats
+const (
+	// FormatCSV is gzip-compressed CSV with a header row
+	FormatCSV Format = "csv"
+	// FormatParquet is Snappy-compressed Parquet, loadable by warehouses
+	// without a conversion step
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat validates an EXPORT_FORMAT value
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatCSV, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q", s)
+	}
+}
+
+// Extension is the file extension used in export keys
+func (f Format) Extension() string {
+	switch f {
+	case FormatCSV:
+		return "csv.gz"
+	case FormatParquet:
+		return "parquet"
+	default:
+		return string(f)
+	}
+}
+
+// ContentType is the content type exports are stored with
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "application/gzip"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// csvHeader names the exported columns, in order
+var csvHeader = []string{"id", "user_id", "amount", "description", "is_paid", "created_at"}
+
+// rowWriter encodes transactions into an export file
+type rowWriter interface {
+	Write(tx *domain.Transaction) error
+	// Close flushes buffered rows; it doesn't close the underlying writer
+	Close() error
+}
+
+func newRowWriter(format Format, w io.Writer) (rowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	gz  *gzip.Writer
+	csv *csv.Writer
+	row []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	gz := gzip.NewWriter(w)
+	c := &csvWriter{gz: gz, csv: csv.NewWriter(gz), row: make([]string, len(csvHeader))}
+	if err := c.csv.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(tx *domain.Transaction) error {
+	c.row[0] = strconv.Itoa(tx.ID)
+	c.row[1] = strconv.Itoa(tx.UserID)
+	c.row[2] = strconv.FormatFloat(tx.Amount, 'f', 2, 64)
+	c.row[3] = tx.Description
+	c.row[4] = strconv.FormatBool(tx.IsPaid)
+	c.row[5] = tx.CreatedAt.UTC().Format(time.RFC3339)
+	return c.csv.Write(c.row)
+}
+
+func (c *csvWriter) Close() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return c.gz.Close()
+}
+
+// parquetRow is the Parquet schema of an exported transaction; columns match
+// csvHeader
+type parquetRow struct {
+	ID          int64     `parquet:"id"`
+	UserID      int64     `parquet:"user_id"`
+	Amount      float64   `parquet:"amount"`
+	Description string    `parquet:"description"`
+	IsPaid      bool      `parquet:"is_paid"`
+	CreatedAt   time.Time `parquet:"created_at,timestamp(millisecond)"`
+}
+
+// parquetRowGroupSize bounds how many rows are buffered in memory before a
+// row group is written out
+const parquetRowGroupSize = 100_000
+
+type parquetWriter struct {
+	w   *parquet.GenericWriter[parquetRow]
+	row [1]parquetRow
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w: parquet.NewGenericWriter[parquetRow](w,
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		),
+	}
+}
+
+func (p *parquetWriter) Write(tx *domain.Transaction) error {
+	p.row[0] = parquetRow{
+		ID:          int64(tx.ID),
+		UserID:      int64(tx.UserID),
+		Amount:      tx.Amount,
+		Description: tx.Description,
+		IsPaid:      tx.IsPaid,
+		CreatedAt:   tx.CreatedAt.UTC(),
+	}
+	_, err := p.w.Write(p.row[:])
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
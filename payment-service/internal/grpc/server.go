@@ -2,100 +2,248 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/service"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/statement"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
 	pb "github.com/tkaewplik/go-microservices/proto/payment"
 )
 
+// ErrorInfo reasons and domain attached to error details
+const (
+	ErrorDomain         = "payment-service"
+	ReasonLimitExceeded = "LIMIT_EXCEEDED"
+	ReasonChargeFailed  = "CHARGE_FAILED"
+)
+
+// Response headers CreateTransaction reports the user's spending headroom in
+const (
+	HeaderCurrentTotal   = "current-total"
+	HeaderRemainingLimit = "remaining-limit"
+)
+
+// MethodScopes are the token scopes each PaymentService method requires, for
+// grpcauth.Config.MethodScopes
+var MethodScopes = map[string]string{
+	pb.PaymentService_CreateTransaction_FullMethodName:  jwt.ScopePaymentsWrite,
+	pb.PaymentService_GetTransactions_FullMethodName:    jwt.ScopePaymentsRead,
+	pb.PaymentService_PayAllTransactions_FullMethodName: jwt.ScopePaymentsWrite,
+	pb.PaymentService_GetSummary_FullMethodName:         jwt.ScopePaymentsRead,
+	pb.PaymentService_AttachReceipt_FullMethodName:      jwt.ScopePaymentsWrite,
+	pb.PaymentService_GetReceipt_FullMethodName:         jwt.ScopePaymentsRead,
+	pb.PaymentService_GenerateStatement_FullMethodName:  jwt.ScopePaymentsWrite,
+	pb.PaymentService_GetStatement_FullMethodName:       jwt.ScopePaymentsRead,
+}
+
 // PaymentServer implements the gRPC PaymentService
 type PaymentServer struct {
 	pb.UnimplementedPaymentServiceServer
 	paymentService *service.PaymentService
+	serviceToken   string
+	statements     *statement.Generator
+}
+
+// ServerOption configures a PaymentServer
+type ServerOption func(*PaymentServer)
+
+// WithServiceToken lets callers holding token use the admin RPCs, which are
+// refused when it is empty
+func WithServiceToken(token string) ServerOption {
+	return func(s *PaymentServer) {
+		s.serviceToken = token
+	}
+}
+
+// WithStatements serves monthly statements from gen; without it the
+// statement RPCs are unavailable
+func WithStatements(gen *statement.Generator) ServerOption {
+	return func(s *PaymentServer) {
+		s.statements = gen
+	}
 }
 
 // NewPaymentServer creates a new gRPC PaymentServer
-func NewPaymentServer(paymentService *service.PaymentService) *PaymentServer {
-	return &PaymentServer{
+func NewPaymentServer(paymentService *service.PaymentService, opts ...ServerOption) *PaymentServer {
+	s := &PaymentServer{
 		paymentService: paymentService,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateTransaction creates a new transaction
 func (s *PaymentServer) CreateTransaction(ctx context.Context, req *pb.CreateTransactionRequest) (*pb.Transaction, error) {
-	if req.UserId <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
 	}
 	if req.Amount <= 0 {
 		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
 	}
+	timezone, err := resolveTimezone(ctx, req.Timezone)
+	if err != nil {
+		return nil, err
+	}
 
-	tx, err := s.paymentService.CreateTransaction(ctx, &domain.CreateTransactionRequest{
-		UserID:      int(req.UserId),
+	result, err := s.paymentService.CreateTransaction(ctx, &domain.CreateTransactionRequest{
+		UserID:      userID,
 		Amount:      req.Amount,
 		Description: req.Description,
+		ReferenceID: req.ReferenceId,
+		Timezone:    timezone,
+		Source:      clientSource(ctx),
 	})
 	if err != nil {
-		if err == service.ErrInvalidAmount {
+		var limitErr *service.LimitExceededError
+		switch {
+		case errors.Is(err, service.ErrInvalidAmount):
 			return nil, status.Error(codes.InvalidArgument, "amount must be positive")
-		}
-		if err == service.ErrInvalidUserID {
+		case errors.Is(err, service.ErrInvalidUserID):
 			return nil, status.Error(codes.InvalidArgument, "invalid user_id")
-		}
-		if err == service.ErrExceedsMaximum {
-			return nil, status.Error(codes.FailedPrecondition, "total amount exceeds maximum of 1000")
+		case errors.Is(err, service.ErrInvalidTimezone):
+			return nil, status.Error(codes.InvalidArgument, "invalid timezone")
+		case errors.Is(err, service.ErrInvalidReferenceID):
+			return nil, status.Error(codes.InvalidArgument, "reference_id is too long")
+		case errors.Is(err, service.ErrDuplicateReference):
+			return nil, status.Error(codes.AlreadyExists, "reference_id already used")
+		case errors.As(err, &limitErr):
+			return nil, limitExceededStatus(limitErr)
 		}
 		return nil, status.Error(codes.Internal, "failed to create transaction")
 	}
 
+	// The headroom goes in headers, so the response stays a Transaction for
+	// clients built before it was reported. The transaction is created either
+	// way, so failing to set them doesn't fail the call.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(
+		HeaderCurrentTotal, strconv.FormatFloat(result.CurrentTotal, 'f', 2, 64),
+		HeaderRemainingLimit, strconv.FormatFloat(result.RemainingLimit, 'f', 2, 64),
+	))
+
 	return &pb.Transaction{
-		Id:          int32(tx.ID),
-		UserId:      int32(tx.UserID),
-		Amount:      tx.Amount,
-		Description: tx.Description,
-		IsPaid:      tx.IsPaid,
-		CreatedAt:   timestamppb.New(tx.CreatedAt),
+		Id:          int64(result.ID),
+		UserId:      int64(result.UserID),
+		Amount:      result.Amount,
+		Description: result.Description,
+		IsPaid:      result.IsPaid,
+		CreatedAt:   timestamppb.New(result.CreatedAt),
+		Ulid:        result.ULID,
 	}, nil
 }
 
-// GetTransactions returns all transactions for a user
+// limitExceededStatus builds a FailedPrecondition status whose ErrorInfo
+// carries the user's current total and remaining headroom
+func limitExceededStatus(limitErr *service.LimitExceededError) error {
+	metadata := map[string]string{
+		"current_total":   strconv.FormatFloat(limitErr.CurrentTotal, 'f', 2, 64),
+		"max_allowed":     strconv.FormatFloat(limitErr.MaxAllowed, 'f', 2, 64),
+		"remaining_limit": strconv.FormatFloat(limitErr.RemainingLimit(), 'f', 2, 64),
+	}
+	if !limitErr.ResetsAt.IsZero() {
+		metadata["resets_at"] = limitErr.ResetsAt.UTC().Format(time.RFC3339)
+	}
+
+	st := status.New(codes.FailedPrecondition, "total amount exceeds maximum of 1000")
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   ReasonLimitExceeded,
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// GetTransactions returns a page of a user's transactions
 func (s *PaymentServer) GetTransactions(ctx context.Context, req *pb.GetTransactionsRequest) (*pb.TransactionList, error) {
-	if req.UserId <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts domain.ListOptions
+	if mask := req.GetFieldMask(); mask != nil {
+		if !mask.IsValid(&pb.Transaction{}) {
+			return nil, status.Error(codes.InvalidArgument, "invalid field_mask")
+		}
+		mask.Normalize()
+		opts.Fields = mask.GetPaths()
+	}
+	switch req.GetSortBy() {
+	case pb.SortBy_SORT_BY_CREATED_AT:
+		opts.SortBy = domain.SortByCreatedAt
+	case pb.SortBy_SORT_BY_AMOUNT:
+		opts.SortBy = domain.SortByAmount
 	}
+	switch req.GetOrder() {
+	case pb.SortOrder_SORT_ORDER_ASC:
+		opts.Order = domain.SortAsc
+	case pb.SortOrder_SORT_ORDER_DESC:
+		opts.Order = domain.SortDesc
+	}
+	opts.IncludeArchived = req.GetIncludeArchived()
 
-	transactions, err := s.paymentService.GetTransactions(ctx, int(req.UserId))
+	page, err := s.paymentService.GetTransactions(ctx, userID, opts, pagination.FromProto(req.GetPage()))
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidField) {
+			return nil, status.Error(codes.InvalidArgument, "invalid field_mask")
+		}
+		if errors.Is(err, service.ErrInvalidSort) {
+			return nil, status.Error(codes.InvalidArgument, "invalid sort options")
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
 		return nil, status.Error(codes.Internal, "failed to get transactions")
 	}
 
-	pbTransactions := make([]*pb.Transaction, len(transactions))
-	for i, tx := range transactions {
+	pbTransactions := make([]*pb.Transaction, len(page.Items))
+	for i, tx := range page.Items {
 		pbTransactions[i] = &pb.Transaction{
-			Id:          int32(tx.ID),
-			UserId:      int32(tx.UserID),
+			Id:          int64(tx.ID),
+			UserId:      int64(tx.UserID),
 			Amount:      tx.Amount,
 			Description: tx.Description,
 			IsPaid:      tx.IsPaid,
-			CreatedAt:   timestamppb.New(tx.CreatedAt),
+			Ulid:        tx.ULID,
+		}
+		// Left unset when excluded by the field mask
+		if !tx.CreatedAt.IsZero() {
+			pbTransactions[i].CreatedAt = timestamppb.New(tx.CreatedAt)
 		}
 	}
 
-	return &pb.TransactionList{Transactions: pbTransactions}, nil
+	return &pb.TransactionList{Transactions: pbTransactions, Page: page.PageInfo.Proto()}, nil
 }
 
 // PayAllTransactions marks all unpaid transactions as paid
 func (s *PaymentServer) PayAllTransactions(ctx context.Context, req *pb.PayRequest) (*pb.PayResponse, error) {
-	if req.UserId <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
 	}
 
-	count, err := s.paymentService.PayAllTransactions(ctx, int(req.UserId))
+	count, err := s.paymentService.PayAllTransactions(ctx, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrChargeFailed) {
+			return nil, chargeFailedStatus()
+		}
 		return nil, status.Error(codes.Internal, "failed to pay transactions")
 	}
 
@@ -104,3 +252,261 @@ func (s *PaymentServer) PayAllTransactions(ctx context.Context, req *pb.PayReque
 		TransactionsPaid: count,
 	}, nil
 }
+
+// chargeFailedStatus builds a FailedPrecondition status for a declined
+// charge, with an ErrorInfo telling it apart from the limit
+func chargeFailedStatus() error {
+	st := status.New(codes.FailedPrecondition, "charge failed; transactions were left unpaid")
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: ReasonChargeFailed,
+		Domain: ErrorDomain,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// GetSummary returns paid and unpaid totals, counts, the remaining limit and
+// the limit warnings reached
+func (s *PaymentServer) GetSummary(ctx context.Context, req *pb.GetSummaryRequest) (*pb.Summary, error) {
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	timezone, err := resolveTimezone(ctx, req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.paymentService.GetSummary(ctx, userID, timezone)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			return nil, status.Error(codes.InvalidArgument, "invalid timezone")
+		}
+		return nil, status.Error(codes.Internal, "failed to get summary")
+	}
+
+	resp := &pb.Summary{
+		UnpaidTotal:      summary.UnpaidTotal,
+		PaidTotal:        summary.PaidTotal,
+		UnpaidCount:      summary.UnpaidCount,
+		PaidCount:        summary.PaidCount,
+		TransactionCount: summary.TransactionCount,
+		RemainingLimit:   summary.RemainingLimit,
+		PeriodTotal:      summary.PeriodTotal,
+		MaxAllowed:       summary.MaxAllowed,
+		LimitWarnings:    summary.LimitWarnings,
+	}
+	// The lifetime period never resets
+	if !summary.ResetsAt.IsZero() {
+		resp.ResetsAt = timestamppb.New(summary.ResetsAt)
+	}
+	return resp, nil
+}
+
+// AttachReceipt records an uploaded receipt against one of the user's
+// transactions. The caller stores the file and deletes replaced_key.
+func (s *PaymentServer) AttachReceipt(ctx context.Context, req *pb.AttachReceiptRequest) (*pb.AttachReceiptResponse, error) {
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &domain.Receipt{
+		Key:         req.GetReceipt().GetKey(),
+		ContentType: req.GetReceipt().GetContentType(),
+		Size:        req.GetReceipt().GetSize(),
+		Filename:    req.GetReceipt().GetFilename(),
+	}
+	if req.GetReceipt().GetUploadedAt() != nil {
+		receipt.UploadedAt = req.GetReceipt().GetUploadedAt().AsTime()
+	}
+
+	txID, err := s.transactionID(ctx, userID, req.TransactionId, req.TransactionUlid)
+	var replacedKey string
+	if err == nil {
+		replacedKey, err = s.paymentService.AttachReceipt(ctx, userID, txID, receipt)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
+			return nil, status.Error(codes.NotFound, "transaction not found")
+		case errors.Is(err, service.ErrInvalidTransactionID):
+			return nil, status.Error(codes.InvalidArgument, "invalid transaction_id")
+		case errors.Is(err, service.ErrInvalidReceipt):
+			return nil, status.Error(codes.InvalidArgument, "invalid receipt")
+		}
+		return nil, status.Error(codes.Internal, "failed to attach receipt")
+	}
+
+	return &pb.AttachReceiptResponse{
+		Receipt:     receiptToProto(receipt),
+		ReplacedKey: replacedKey,
+	}, nil
+}
+
+// transactionID returns the ID of the transaction a request names by ID or by
+// ULID; naming it both ways is invalid
+func (s *PaymentServer) transactionID(ctx context.Context, userID int, id int64, txULID string) (int, error) {
+	if txULID == "" {
+		return int(id), nil
+	}
+	if id != 0 {
+		return 0, service.ErrInvalidTransactionID
+	}
+	return s.paymentService.ResolveTransactionULID(ctx, userID, txULID)
+}
+
+// GetReceipt returns the receipt attached to one of the user's transactions
+func (s *PaymentServer) GetReceipt(ctx context.Context, req *pb.GetReceiptRequest) (*pb.Receipt, error) {
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	txID, err := s.transactionID(ctx, userID, req.TransactionId, req.TransactionUlid)
+	var receipt *domain.Receipt
+	if err == nil {
+		receipt, err = s.paymentService.GetReceipt(ctx, userID, txID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrReceiptNotFound):
+			return nil, status.Error(codes.NotFound, "receipt not found")
+		case errors.Is(err, service.ErrTransactionNotFound):
+			return nil, status.Error(codes.NotFound, "transaction not found")
+		case errors.Is(err, service.ErrInvalidTransactionID):
+			return nil, status.Error(codes.InvalidArgument, "invalid transaction_id")
+		}
+		return nil, status.Error(codes.Internal, "failed to get receipt")
+	}
+
+	return receiptToProto(receipt), nil
+}
+
+// StreamAllTransactions streams every transaction created before the
+// request's cutoff to callers holding the service token
+func (s *PaymentServer) StreamAllTransactions(req *pb.StreamAllTransactionsRequest, stream pb.PaymentService_StreamAllTransactionsServer) error {
+	ctx := stream.Context()
+	if !grpcauth.HasServiceToken(ctx, s.serviceToken) {
+		return status.Error(codes.PermissionDenied, "service token required")
+	}
+
+	before := time.Now()
+	if req.Before != nil {
+		before = req.Before.AsTime()
+	}
+	err := s.paymentService.ForEachTransaction(ctx, before, func(tx *domain.Transaction) error {
+		return stream.Send(&pb.Transaction{
+			Id:          int64(tx.ID),
+			UserId:      int64(tx.UserID),
+			Amount:      tx.Amount,
+			Description: tx.Description,
+			IsPaid:      tx.IsPaid,
+			CreatedAt:   timestamppb.New(tx.CreatedAt),
+			Ulid:        tx.ULID,
+		})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Internal, "failed to stream transactions")
+	}
+	return nil
+}
+
+// GenerateStatement renders and stores the user's statement for a month
+func (s *PaymentServer) GenerateStatement(ctx context.Context, req *pb.StatementRequest) (*pb.Statement, error) {
+	return s.statement(ctx, req, s.statements.Generate)
+}
+
+// GetStatement returns the user's stored statement for a month
+func (s *PaymentServer) GetStatement(ctx context.Context, req *pb.StatementRequest) (*pb.Statement, error) {
+	return s.statement(ctx, req, s.statements.Get)
+}
+
+// statement runs a statement RPC through get, mapping its errors
+func (s *PaymentServer) statement(ctx context.Context, req *pb.StatementRequest, get func(context.Context, int, string) (*statement.Statement, error)) (*pb.Statement, error) {
+	if s.statements == nil {
+		return nil, status.Error(codes.Unavailable, "statements are not configured")
+	}
+	userID, err := resolveUserID(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := get(ctx, userID, req.Month)
+	if err != nil {
+		switch {
+		case errors.Is(err, statement.ErrInvalidMonth):
+			return nil, status.Error(codes.InvalidArgument, "invalid month")
+		case errors.Is(err, statement.ErrInvalidUserID):
+			return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+		case errors.Is(err, statement.ErrNotFound):
+			return nil, status.Error(codes.NotFound, "statement not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get statement")
+	}
+
+	return &pb.Statement{
+		Key:         st.Key,
+		Month:       st.Month,
+		Size:        st.Size,
+		GeneratedAt: timestamppb.New(st.GeneratedAt),
+	}, nil
+}
+
+func receiptToProto(receipt *domain.Receipt) *pb.Receipt {
+	return &pb.Receipt{
+		Key:         receipt.Key,
+		ContentType: receipt.ContentType,
+		Size:        receipt.Size,
+		Filename:    receipt.Filename,
+		UploadedAt:  timestamppb.New(receipt.UploadedAt),
+	}
+}
+
+// resolveUserID returns the user a call acts on. An authenticated identity from
+// metadata wins; a user_id field that disagrees with it is rejected so internal
+// callers cannot act on behalf of another user.
+func resolveUserID(ctx context.Context, requested int64) (int, error) {
+	if id, ok := grpcauth.FromContext(ctx); ok {
+		if requested != 0 && int(requested) != id.UserID {
+			return 0, status.Error(codes.PermissionDenied, "user_id does not match authenticated user")
+		}
+		return id.UserID, nil
+	}
+	if requested <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+	return int(requested), nil
+}
+
+// clientSource returns the client channel and country the gateway forwarded,
+// or nil when it forwarded neither
+func clientSource(ctx context.Context) *domain.EventSource {
+	channel := grpcauth.ClientChannel(ctx)
+	country, _ := grpcauth.ClientGeo(ctx)
+	if channel == "" && country == "" {
+		return nil
+	}
+	return &domain.EventSource{Channel: channel, Country: country}
+}
+
+// resolveTimezone returns the timezone of a call's limit periods. An
+// authenticated identity's preference wins, like its user ID in
+// resolveUserID: a timezone field that disagrees with it is rejected, as a
+// caller choosing the zone could start the next period early and spend the
+// limit again.
+func resolveTimezone(ctx context.Context, requested string) (string, error) {
+	if id, ok := grpcauth.FromContext(ctx); ok {
+		if requested != "" && requested != id.Timezone {
+			return "", status.Error(codes.PermissionDenied, "timezone does not match authenticated user's preference")
+		}
+		return id.Timezone, nil
+	}
+	return requested, nil
+}
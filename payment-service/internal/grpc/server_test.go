@@ -0,0 +1,464 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/service"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/statement"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/grpcvalidate"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	pkgtestutil "github.com/tkaewplik/go-microservices/pkg/testutil"
+	pb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+const (
+	testSecret       = "test-secret"
+	testServiceToken = "test-service-token"
+)
+
+// newTestClient serves a PaymentServer over bufconn with the same interceptor
+// chain as main
+func newTestClient(t *testing.T) (pb.PaymentServiceClient, *testutil.FakeTransactionRepository) {
+	t.Helper()
+
+	repo := testutil.NewFakeTransactionRepository()
+	svc := service.NewPaymentService(repo, testutil.NewFakeEventPublisher())
+	conn := pkgtestutil.NewBufconnServer(t, func(s *grpc.Server) {
+		pb.RegisterPaymentServiceServer(s, NewPaymentServer(svc, WithServiceToken(testServiceToken)))
+	}, grpc.ChainUnaryInterceptor(
+		grpcauth.UnaryServerInterceptor(grpcauth.Config{
+			SecretKey:    testSecret,
+			ServiceToken: testServiceToken,
+			Required:     true,
+			MethodScopes: MethodScopes,
+		}),
+		grpcvalidate.UnaryServerInterceptor(),
+	))
+	return pb.NewPaymentServiceClient(conn), repo
+}
+
+func userContext(t *testing.T, userID int) context.Context {
+	t.Helper()
+	return scopedContext(t, userID, jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite)
+}
+
+func scopedContext(t *testing.T, userID int, scopes ...string) context.Context {
+	t.Helper()
+	token, err := jwt.IssueToken(userID, "testuser", testSecret, jwt.WithScopes(scopes...))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return grpcauth.WithBearerToken(context.Background(), token)
+}
+
+func TestPaymentServer_CreateTransaction_RoundTrip(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	var header metadata.MD
+	tx, err := client.CreateTransaction(userContext(t, 7), &pb.CreateTransactionRequest{
+		Amount:      250.5,
+		Description: "groceries",
+	}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if tx.GetId() == 0 || tx.GetUserId() != 7 || tx.GetAmount() != 250.5 || tx.GetDescription() != "groceries" {
+		t.Errorf("unexpected transaction: %+v", tx)
+	}
+	if tx.GetCreatedAt() == nil {
+		t.Error("expected created_at to be set")
+	}
+	total, remaining := header.Get(HeaderCurrentTotal), header.Get(HeaderRemainingLimit)
+	if len(total) != 1 || total[0] != "250.50" || len(remaining) != 1 || remaining[0] != "749.50" {
+		t.Errorf("unexpected headroom: total %v, remaining %v", total, remaining)
+	}
+}
+
+func TestPaymentServer_CreateTransaction_Source(t *testing.T) {
+	client, repo := newTestClient(t)
+
+	ctx := grpcauth.WithClientGeo(grpcauth.WithClientChannel(userContext(t, 7), "mobile"), "TH", "")
+	if _, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 10}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.CreateTransaction(userContext(t, 7), &pb.CreateTransactionRequest{Amount: 10}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	txs := repo.Transactions()
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if src := txs[0].Source; src == nil || src.Channel != "mobile" || src.Country != "TH" {
+		t.Errorf("expected the forwarded source, got %+v", src)
+	}
+	if txs[1].Source != nil {
+		t.Errorf("expected no source without metadata, got %+v", txs[1].Source)
+	}
+}
+
+func TestPaymentServer_CreateTransaction_LimitExceeded(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := userContext(t, 1)
+
+	if _, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 950}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 100})
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if i, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = i
+		}
+	}
+	if info == nil {
+		t.Fatal("expected ErrorInfo detail")
+	}
+	if info.Reason != ReasonLimitExceeded || info.Domain != ErrorDomain {
+		t.Errorf("unexpected reason/domain: %s/%s", info.Reason, info.Domain)
+	}
+	want := map[string]string{"current_total": "950.00", "max_allowed": "1000.00", "remaining_limit": "50.00"}
+	for key, value := range want {
+		if info.Metadata[key] != value {
+			t.Errorf("metadata[%s] = %q, want %q", key, info.Metadata[key], value)
+		}
+	}
+	if info.Metadata["resets_at"] == "" {
+		t.Error("expected resets_at for the monthly period")
+	}
+}
+
+func TestPaymentServer_StatusCodes(t *testing.T) {
+	client, repo := newTestClient(t)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"missing credentials", func() error {
+			_, err := client.CreateTransaction(context.Background(), &pb.CreateTransactionRequest{Amount: 10})
+			return err
+		}, codes.Unauthenticated},
+		{"forged token", func() error {
+			ctx := grpcauth.WithBearerToken(context.Background(), "not-a-token")
+			_, err := client.GetSummary(ctx, &pb.GetSummaryRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"other user's id", func() error {
+			_, err := client.GetTransactions(userContext(t, 1), &pb.GetTransactionsRequest{UserId: 2})
+			return err
+		}, codes.PermissionDenied},
+		{"negative amount", func() error {
+			_, err := client.CreateTransaction(userContext(t, 1), &pb.CreateTransactionRequest{Amount: -5})
+			return err
+		}, codes.InvalidArgument},
+		{"timezone other than the user's", func() error {
+			_, err := client.GetSummary(userContext(t, 1), &pb.GetSummaryRequest{Timezone: "Pacific/Kiritimati"})
+			return err
+		}, codes.PermissionDenied},
+		{"unknown field mask path", func() error {
+			_, err := client.GetTransactions(userContext(t, 1), &pb.GetTransactionsRequest{
+				FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"password"}},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"repository failure", func() error {
+			repo.UpdateErr = errors.New("connection reset")
+			defer func() { repo.UpdateErr = nil }()
+			_, err := client.PayAllTransactions(userContext(t, 1), &pb.PayRequest{})
+			return err
+		}, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPaymentServer_TimezoneFromIdentity(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	token, err := jwt.IssueToken(1, "testuser", testSecret,
+		jwt.WithScopes(jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite),
+		jwt.WithPreferences(jwt.Preferences{Timezone: "Europe/Berlin"}))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	ctx := grpcauth.WithBearerToken(context.Background(), token)
+
+	for _, tz := range []string{"", "Europe/Berlin"} {
+		if _, err := client.GetSummary(ctx, &pb.GetSummaryRequest{Timezone: tz}); err != nil {
+			t.Errorf("timezone %q: expected the token's timezone to be used, got %v", tz, err)
+		}
+	}
+	// A zone far ahead of the user's would start the next limit period early
+	for _, tz := range []string{"Pacific/Kiritimati", "Etc/GMT+12"} {
+		if _, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 10, Timezone: tz}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("timezone %q: expected PermissionDenied, got %v", tz, err)
+		}
+	}
+}
+
+func TestPaymentServer_ForwardedIdentity(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	ctx := grpcauth.WithForwardedIdentity(context.Background(), testServiceToken, grpcauth.Identity{
+		UserID:   9,
+		Username: "bob",
+		Scopes:   []string{jwt.ScopePaymentsWrite},
+	})
+	tx, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tx.GetUserId() != 9 {
+		t.Errorf("expected user 9 from metadata, got %d", tx.GetUserId())
+	}
+
+	ctx = grpcauth.WithForwardedIdentity(context.Background(), "wrong-token", grpcauth.Identity{UserID: 9})
+	if _, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 10}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for a bad service token, got %v", err)
+	}
+}
+
+func TestPaymentServer_ListAndSummary_RoundTrip(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := userContext(t, 3)
+
+	if _, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 42, Description: "book"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.PayAllTransactions(ctx, &pb.PayRequest{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	list, err := client.GetTransactions(ctx, &pb.GetTransactionsRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(list.GetTransactions()) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(list.GetTransactions()))
+	}
+	tx := list.GetTransactions()[0]
+	if tx.GetAmount() != 42 || tx.GetDescription() != "book" || !tx.GetIsPaid() || tx.GetCreatedAt() == nil {
+		t.Errorf("unexpected transaction: %+v", tx)
+	}
+
+	summary, err := client.GetSummary(ctx, &pb.GetSummaryRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if summary.GetPaidTotal() != 42 || summary.GetPaidCount() != 1 || summary.GetUnpaidCount() != 0 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestPaymentServer_Receipt_RoundTrip(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := userContext(t, 4)
+
+	created, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 15, Description: "taxi"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	txID := created.GetId()
+
+	if _, err := client.GetReceipt(ctx, &pb.GetReceiptRequest{TransactionId: txID}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound before upload, got %v", err)
+	}
+
+	receipt := &pb.Receipt{Key: "receipts/4/1/a", ContentType: "image/png", Size: 128, Filename: "taxi.png"}
+	resp, err := client.AttachReceipt(ctx, &pb.AttachReceiptRequest{TransactionId: txID, Receipt: receipt})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.GetReplacedKey() != "" || resp.GetReceipt().GetUploadedAt() == nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	receipt.Key = "receipts/4/1/b"
+	resp, err = client.AttachReceipt(ctx, &pb.AttachReceiptRequest{TransactionId: txID, Receipt: receipt})
+	if err != nil || resp.GetReplacedKey() != "receipts/4/1/a" {
+		t.Fatalf("expected the first key to be replaced, got %+v, %v", resp, err)
+	}
+
+	got, err := client.GetReceipt(ctx, &pb.GetReceiptRequest{TransactionId: txID})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.GetKey() != "receipts/4/1/b" || got.GetFilename() != "taxi.png" || got.GetSize() != 128 {
+		t.Errorf("unexpected receipt: %+v", got)
+	}
+
+	if _, err := client.AttachReceipt(userContext(t, 5), &pb.AttachReceiptRequest{TransactionId: txID, Receipt: receipt}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's transaction, got %v", err)
+	}
+	if _, err := client.AttachReceipt(ctx, &pb.AttachReceiptRequest{TransactionId: txID}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without a receipt, got %v", err)
+	}
+}
+
+func TestPaymentServer_Receipt_ByULID(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := userContext(t, 4)
+
+	created, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 15, Description: "taxi"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	txULID := created.GetUlid()
+	if len(txULID) != 26 {
+		t.Fatalf("expected a ULID, got %q", txULID)
+	}
+
+	receipt := &pb.Receipt{Key: "receipts/4/1/a", ContentType: "image/png", Size: 128}
+	if _, err := client.AttachReceipt(ctx, &pb.AttachReceiptRequest{TransactionUlid: txULID, Receipt: receipt}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, err := client.GetReceipt(ctx, &pb.GetReceiptRequest{TransactionUlid: strings.ToLower(txULID)})
+	if err != nil || got.GetKey() != "receipts/4/1/a" {
+		t.Fatalf("expected the receipt by ULID, got %+v, %v", got, err)
+	}
+
+	if _, err := client.GetReceipt(userContext(t, 5), &pb.GetReceiptRequest{TransactionUlid: txULID}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's transaction, got %v", err)
+	}
+	for _, req := range []*pb.GetReceiptRequest{
+		{TransactionUlid: "not-a-ulid"},
+		{TransactionUlid: "01ARZ3NDEKTSV4RRFFQ69G5FAU"},
+		{TransactionUlid: txULID, TransactionId: created.GetId()},
+	} {
+		if _, err := client.GetReceipt(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%+v: expected InvalidArgument, got %v", req, err)
+		}
+	}
+}
+
+func TestPaymentServer_RequiresScopes(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := scopedContext(t, 7, jwt.ScopePaymentsRead)
+
+	if _, err := client.GetSummary(ctx, &pb.GetSummaryRequest{}); err != nil {
+		t.Fatalf("expected a read with payments:read to succeed, got %v", err)
+	}
+	_, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{Amount: 10})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without payments:write, got %v", err)
+	}
+}
+
+func TestPaymentServer_StreamAllTransactions(t *testing.T) {
+	client, repo := newTestClient(t)
+	cutoff := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo.Seed(
+		domain.Transaction{ID: 2, UserID: 7, Amount: 20, CreatedAt: cutoff.Add(-time.Hour)},
+		domain.Transaction{ID: 1, UserID: 8, Amount: 10, IsPaid: true, CreatedAt: cutoff.Add(-48 * time.Hour)},
+		domain.Transaction{ID: 3, UserID: 7, Amount: 30, CreatedAt: cutoff},
+	)
+	req := &pb.StreamAllTransactionsRequest{Before: timestamppb.New(cutoff)}
+
+	// A user token isn't enough, whatever its scopes
+	stream, err := client.StreamAllTransactions(userContext(t, 7), req)
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied without the service token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcauth.MetadataServiceToken, testServiceToken)
+	stream, err = client.StreamAllTransactions(ctx, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var ids []int64
+	for {
+		tx, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected the stream to end cleanly, got %v", err)
+		}
+		ids = append(ids, tx.GetId())
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected transactions 1 and 2 in ID order, got %v", ids)
+	}
+}
+
+func TestPaymentServer_Statements(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewPaymentService(repo, testutil.NewFakeEventPublisher())
+	gen := statement.NewGenerator(repo, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	conn := pkgtestutil.NewBufconnServer(t, func(s *grpc.Server) {
+		pb.RegisterPaymentServiceServer(s, NewPaymentServer(svc, WithStatements(gen)))
+	}, grpc.ChainUnaryInterceptor(
+		grpcauth.UnaryServerInterceptor(grpcauth.Config{SecretKey: testSecret, Required: true, MethodScopes: MethodScopes}),
+		grpcvalidate.UnaryServerInterceptor(),
+	))
+	client := pb.NewPaymentServiceClient(conn)
+	ctx := userContext(t, 7)
+	month := time.Now().UTC().Format(statement.MonthFormat)
+
+	if _, err := client.GetStatement(ctx, &pb.StatementRequest{Month: month}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound before generating, got %v", err)
+	}
+	if _, err := client.GenerateStatement(ctx, &pb.StatementRequest{Month: "2026-13"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad month, got %v", err)
+	}
+
+	generated, err := client.GenerateStatement(ctx, &pb.StatementRequest{Month: month})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if generated.GetKey() != "statements/user=7/"+month+".pdf" || generated.GetSize() == 0 {
+		t.Errorf("unexpected statement %+v", generated)
+	}
+	got, err := client.GetStatement(ctx, &pb.StatementRequest{Month: month})
+	if err != nil || got.GetKey() != generated.GetKey() {
+		t.Errorf("expected the generated statement, got %+v, %v", got, err)
+	}
+}
+
+func TestPaymentServer_StatementsUnconfigured(t *testing.T) {
+	client, _ := newTestClient(t)
+	_, err := client.GenerateStatement(userContext(t, 7), &pb.StatementRequest{Month: "2026-09"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+}
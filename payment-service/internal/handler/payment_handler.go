@@ -5,10 +5,22 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/service"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/middleware"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+)
+
+// Response headers describing a page of transactions
+const (
+	TotalCountHeader = "X-Total-Count"
+	NextCursorHeader = "X-Next-Cursor"
 )
 
 // PaymentHandler handles HTTP requests for payments
@@ -27,9 +39,12 @@ func NewPaymentHandler(paymentService *service.PaymentService, logger *slog.Logg
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error        string `json:"error"`
-	CurrentTotal string `json:"current_total,omitempty"`
-	MaxAllowed   string `json:"max_allowed,omitempty"`
+	Error          string               `json:"error"`
+	CurrentTotal   string               `json:"current_total,omitempty"`
+	MaxAllowed     string               `json:"max_allowed,omitempty"`
+	RemainingLimit string               `json:"remaining_limit,omitempty"`
+	ResetsAt       string               `json:"resets_at,omitempty"`
+	Fields         []request.FieldError `json:"fields,omitempty"`
 }
 
 // PayResponse represents a pay response
@@ -43,11 +58,18 @@ func (h *PaymentHandler) CreateTransaction(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 
 	var req domain.CreateTransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := request.DecodeJSON(r, &req); err != nil {
 		h.logger.Error("failed to decode create transaction request", "error", err)
-		h.respondError(w, http.StatusBadRequest, "invalid request body", nil)
+		h.respondDecodeError(w, err)
+		return
+	}
+
+	userID, ok := h.resolveUserID(w, r, req.UserID)
+	if !ok {
 		return
 	}
+	req.UserID = userID
+	req.Timezone = limitTimezone(r, userID)
 
 	tx, err := h.paymentService.CreateTransaction(ctx, &req)
 	if err != nil {
@@ -63,14 +85,33 @@ func (h *PaymentHandler) CreateTransaction(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		if errors.Is(err, service.ErrExceedsMaximum) {
-			// Get current total for detailed error
-			currentTotal, _ := h.paymentService.GetCurrentTotal(ctx, req.UserID)
-			h.respondJSON(w, http.StatusBadRequest, ErrorResponse{
-				Error:        "total amount exceeds maximum of 1000",
-				CurrentTotal: formatFloat(currentTotal),
-				MaxAllowed:   "1000.00",
-			})
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondError(w, http.StatusBadRequest, "invalid timezone", nil)
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidReferenceID) {
+			h.respondError(w, http.StatusBadRequest, "reference_id is too long", nil)
+			return
+		}
+
+		if errors.Is(err, service.ErrDuplicateReference) {
+			h.respondError(w, http.StatusConflict, "reference_id already used", nil)
+			return
+		}
+
+		var limitErr *service.LimitExceededError
+		if errors.As(err, &limitErr) {
+			resp := ErrorResponse{
+				Error:          "total amount exceeds maximum of 1000",
+				CurrentTotal:   formatFloat(limitErr.CurrentTotal),
+				MaxAllowed:     formatFloat(limitErr.MaxAllowed),
+				RemainingLimit: formatFloat(limitErr.RemainingLimit()),
+			}
+			if !limitErr.ResetsAt.IsZero() {
+				resp.ResetsAt = limitErr.ResetsAt.UTC().Format(time.RFC3339)
+			}
+			h.respondJSON(w, http.StatusBadRequest, resp)
 			return
 		}
 
@@ -86,19 +127,24 @@ func (h *PaymentHandler) CreateTransaction(w http.ResponseWriter, r *http.Reques
 func (h *PaymentHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		h.respondError(w, http.StatusBadRequest, "user_id query parameter required", nil)
+	userID, ok := h.queryUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid limit", nil)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
+	includeArchived, err := parseIncludeArchived(r.URL.Query())
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid user_id", nil)
+		h.respondError(w, http.StatusBadRequest, "invalid include_archived", nil)
 		return
 	}
 
-	transactions, err := h.paymentService.GetTransactions(ctx, userID)
+	transactions, err := h.paymentService.GetTransactions(ctx, userID, domain.ListOptions{IncludeArchived: includeArchived}, page)
 	if err != nil {
 		h.logger.Error("failed to get transactions", "error", err, "user_id", userID)
 
@@ -107,26 +153,29 @@ func (h *PaymentHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			h.respondError(w, http.StatusBadRequest, "invalid cursor", nil)
+			return
+		}
+
 		h.respondError(w, http.StatusInternalServerError, "failed to get transactions", nil)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, transactions)
+	// The body stays a plain array; paging details travel in headers
+	w.Header().Set(TotalCountHeader, strconv.FormatInt(transactions.Total, 10))
+	if transactions.NextCursor != "" {
+		w.Header().Set(NextCursorHeader, transactions.NextCursor)
+	}
+	h.respondJSON(w, http.StatusOK, transactions.Items)
 }
 
 // PayAllTransactions handles paying all transactions for a user
 func (h *PaymentHandler) PayAllTransactions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		h.respondError(w, http.StatusBadRequest, "user_id query parameter required", nil)
-		return
-	}
-
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid user_id", nil)
+	userID, ok := h.queryUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -138,6 +187,10 @@ func (h *PaymentHandler) PayAllTransactions(w http.ResponseWriter, r *http.Reque
 			h.respondError(w, http.StatusBadRequest, "invalid user_id", nil)
 			return
 		}
+		if errors.Is(err, service.ErrChargeFailed) {
+			h.respondError(w, http.StatusPaymentRequired, "charge failed; transactions were left unpaid", nil)
+			return
+		}
 
 		h.respondError(w, http.StatusInternalServerError, "failed to pay transactions", nil)
 		return
@@ -150,6 +203,92 @@ func (h *PaymentHandler) PayAllTransactions(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// GetSummary handles getting a user's transaction summary
+func (h *PaymentHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := h.queryUserID(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.paymentService.GetSummary(ctx, userID, limitTimezone(r, userID))
+	if err != nil {
+		h.logger.Error("failed to get summary", "error", err, "user_id", userID)
+
+		if errors.Is(err, service.ErrInvalidUserID) {
+			h.respondError(w, http.StatusBadRequest, "invalid user_id", nil)
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondError(w, http.StatusBadRequest, "invalid timezone", nil)
+			return
+		}
+
+		h.respondError(w, http.StatusInternalServerError, "failed to get summary", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, summary)
+}
+
+// resolveUserID returns the user a request acts on: the authenticated user,
+// or for admins the requested one. Other users naming someone else in
+// requested are refused. It writes the error response and returns false when
+// the request can't proceed.
+func (h *PaymentHandler) resolveUserID(w http.ResponseWriter, r *http.Request, requested int) (int, bool) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return 0, false
+	}
+	if requested == 0 || requested == claims.UserID {
+		return claims.UserID, true
+	}
+	if claims.Role != jwt.RoleAdmin {
+		h.respondError(w, http.StatusForbidden, "user_id does not match authenticated user", nil)
+		return 0, false
+	}
+	h.logger.Info("admin acting on behalf of user", "admin_id", claims.UserID, "user_id", requested)
+	return requested, true
+}
+
+// limitTimezone returns the timezone of userID's limit periods: the
+// preference in the caller's token when it is userID's own, otherwise the
+// service default. Clients can't choose it, or they could start the next
+// period early.
+func limitTimezone(r *http.Request, userID int) string {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID != userID {
+		return ""
+	}
+	return claims.Timezone
+}
+
+// parseIncludeArchived reads the optional include_archived query parameter
+func parseIncludeArchived(query url.Values) (bool, error) {
+	v := query.Get("include_archived")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// queryUserID is resolveUserID for the optional user_id query parameter
+func (h *PaymentHandler) queryUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	var requested int
+	if s := r.URL.Query().Get("user_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil || id <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid user_id", nil)
+			return 0, false
+		}
+		requested = id
+	}
+	return h.resolveUserID(w, r, requested)
+}
+
 // respondJSON writes a JSON response
 func (h *PaymentHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -169,6 +308,21 @@ func (h *PaymentHandler) respondError(w http.ResponseWriter, status int, message
 	h.respondJSON(w, status, resp)
 }
 
+// respondDecodeError writes a 400 listing the malformed request fields, or a
+// 413 when the body is over the size limit
+func (h *PaymentHandler) respondDecodeError(w http.ResponseWriter, err error) {
+	var decErr *request.DecodeError
+	if errors.Is(err, request.ErrBodyTooLarge) && errors.As(err, &decErr) {
+		h.respondJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: decErr.Message})
+		return
+	}
+	if errors.As(err, &decErr) {
+		h.respondJSON(w, http.StatusBadRequest, ErrorResponse{Error: decErr.Message, Fields: decErr.Fields})
+		return
+	}
+	h.respondError(w, http.StatusBadRequest, "invalid request body", nil)
+}
+
 func formatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', 2, 64)
 }
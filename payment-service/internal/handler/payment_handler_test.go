@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/service"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/middleware"
+)
+
+const testSecret = "test-secret"
+
+func newTestHandler() (*PaymentHandler, *middleware.AuthMiddleware, *testutil.FakeTransactionRepository) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := service.NewPaymentService(repo, nil)
+	return NewPaymentHandler(svc, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		middleware.NewAuthMiddleware(testSecret), repo
+}
+
+func serve(t *testing.T, handler http.HandlerFunc, userID int, role, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := jwt.IssueToken(userID, "testuser", testSecret, jwt.WithRole(role))
+	if err != nil {
+		t.Fatalf("failed to issue token: %v", err)
+	}
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestPaymentHandler_UsesAuthenticatedUser(t *testing.T) {
+	h, auth, repo := newTestHandler()
+
+	w := serve(t, auth.Authenticate(h.CreateTransaction), 7, "user", http.MethodPost, "/transactions", `{"amount":10}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	if txs := repo.Transactions(); len(txs) != 1 || txs[0].UserID != 7 {
+		t.Errorf("expected the transaction to belong to the caller, got %+v", txs)
+	}
+
+	w = serve(t, auth.Authenticate(h.GetTransactions), 7, "user", http.MethodGet, "/transactions/list", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"user_id":7`) {
+		t.Errorf("expected the caller's transactions without user_id, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestPaymentHandler_TimezoneNotClientChosen(t *testing.T) {
+	h, auth, repo := newTestHandler()
+
+	w := serve(t, auth.Authenticate(h.CreateTransaction), 7, "user", http.MethodPost, "/transactions", `{"amount":10,"timezone":"Pacific/Kiritimati"}`)
+	if w.Code != http.StatusBadRequest || len(repo.Transactions()) != 0 {
+		t.Errorf("expected a body naming a timezone refused, got %d: %s", w.Code, w.Body)
+	}
+	// An unknown zone would be refused if it were used
+	w = serve(t, auth.Authenticate(h.GetSummary), 7, "user", http.MethodGet, "/transactions/summary?timezone=Mars/Olympus", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the timezone parameter ignored, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestPaymentHandler_PagesTransactions(t *testing.T) {
+	h, auth, _ := newTestHandler()
+	for range 3 {
+		serve(t, auth.Authenticate(h.CreateTransaction), 7, "user", http.MethodPost, "/transactions", `{"amount":10}`)
+	}
+
+	w := serve(t, auth.Authenticate(h.GetTransactions), 7, "user", http.MethodGet, "/transactions/list?limit=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if w.Header().Get(TotalCountHeader) != "3" || w.Header().Get(NextCursorHeader) == "" {
+		t.Errorf("expected a total of 3 and a next cursor, got %v", w.Header())
+	}
+	if n := strings.Count(w.Body.String(), `"id"`); n != 2 {
+		t.Errorf("expected 2 transactions, got %d: %s", n, w.Body)
+	}
+
+	w = serve(t, auth.Authenticate(h.GetTransactions), 7, "user", http.MethodGet, "/transactions/list?cursor=bogus", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid cursor, got %d", w.Code)
+	}
+}
+
+func TestPaymentHandler_RefusesOtherUsers(t *testing.T) {
+	h, auth, repo := newTestHandler()
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+	}{
+		{"create", h.CreateTransaction, http.MethodPost, "/transactions", `{"user_id":8,"amount":10}`},
+		{"list", h.GetTransactions, http.MethodGet, "/transactions/list?user_id=8", ""},
+		{"pay", h.PayAllTransactions, http.MethodPost, "/transactions/pay?user_id=8", ""},
+		{"summary", h.GetSummary, http.MethodGet, "/transactions/summary?user_id=8", ""},
+	} {
+		if w := serve(t, auth.Authenticate(tc.handler), 7, "user", tc.method, tc.target, tc.body); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d: %s", tc.name, w.Code, w.Body)
+		}
+	}
+	if txs := repo.Transactions(); len(txs) != 0 {
+		t.Errorf("expected nothing to be created, got %+v", txs)
+	}
+}
+
+func TestPaymentHandler_AdminOverride(t *testing.T) {
+	h, auth, repo := newTestHandler()
+
+	w := serve(t, auth.Authenticate(h.CreateTransaction), 1, jwt.RoleAdmin, http.MethodPost, "/transactions", `{"user_id":8,"amount":10}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	if txs := repo.Transactions(); len(txs) != 1 || txs[0].UserID != 8 {
+		t.Errorf("expected the transaction to belong to user 8, got %+v", txs)
+	}
+}
+
+func TestPaymentHandler_RequiresClaims(t *testing.T) {
+	h, _, _ := newTestHandler()
+
+	r := httptest.NewRequest(http.MethodGet, "/transactions/list?user_id=7", nil)
+	w := httptest.NewRecorder()
+	h.GetTransactions(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the auth middleware, got %d", w.Code)
+	}
+}
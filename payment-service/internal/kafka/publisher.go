@@ -1,68 +1,214 @@
 package kafka
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/outbox"
+	"github.com/tkaewplik/go-microservices/pkg/events"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+	"github.com/tkaewplik/go-microservices/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
 )
 
-// Publisher implements domain.EventPublisher using Kafka
+// DefaultCloseTimeout bounds how long Close waits for writes in flight
+const DefaultCloseTimeout = 10 * time.Second
+
+var (
+	// ErrPublisherClosed is returned by publishes after Close
+	ErrPublisherClosed = errors.New("kafka: publisher closed")
+	// ErrCloseTimeout is returned by Close when writes were still in flight
+	// after the close timeout
+	ErrCloseTimeout = errors.New("kafka: timed out flushing writes on close")
+	// ErrInvalidRoutes is returned by ParseRoutes for malformed routes
+	ErrInvalidRoutes = errors.New("kafka: invalid topic route, expected event_type=topic")
+)
+
+// Publisher implements domain.EventPublisher using Kafka. Events keep the
+// Timestamp the service set; those without one are stamped when published.
 type Publisher struct {
-	writer *kafka.Writer
-	logger *slog.Logger
+	// writers holds a writer per topic events are routed to
+	writers map[string]messaging.MessageWriter
+	logger  *slog.Logger
+	// topic takes the events routes doesn't name
+	topic          string
+	routes         map[string]string
+	priorityTopics bool
+	closeTimeout   time.Duration
+
+	// mu orders writes starting against Close, so none starts unseen by
+	// the wait for writes in flight
+	mu       sync.Mutex
+	closing  atomic.Bool
+	closed   atomic.Bool
+	inflight sync.WaitGroup
+	stats    writerStats
+}
+
+// writerStats counts writes since the publisher was created
+type writerStats struct {
+	writes     atomic.Int64
+	messages   atomic.Int64
+	errors     atomic.Int64
+	latency    atomic.Int64 // nanoseconds, summed over writes
+	maxLatency atomic.Int64
+	pending    atomic.Int64
+	flushed    atomic.Int64
+	dropped    atomic.Int64
+}
+
+// WriterStats is a snapshot of a publisher's writes
+type WriterStats struct {
+	// Writes and Messages count the writes attempted and the messages in
+	// them; Errors counts the writes that failed
+	Writes   int64
+	Messages int64
+	Errors   int64
+	// Latency is the total time spent in writes, and MaxLatency the longest
+	Latency    time.Duration
+	MaxLatency time.Duration
+	// Pending is the number of messages in writes in flight
+	Pending int64
+	// Flushed and Dropped count the messages delivered and lost once Close
+	// was called. Writes still in flight when Close gives up are dropped.
+	Flushed int64
+	Dropped int64
 }
 
 // Config holds Kafka publisher configuration
 type Config struct {
 	Brokers []string
 	Topic   string
+	// Routes sends events of the types it names to their own topics, such
+	// as refunds and payments apart from transactions; other events go to
+	// Topic. Events of one user keep their order within a topic only.
+	Routes map[string]string
+	// PriorityTopics sends events to the topic of their priority, see
+	// messaging.PriorityTopic, rather than to the routed topic itself
+	PriorityTopics bool
+	// Partitioner picks the partition of each event. Events are keyed by
+	// user ID, so a key-hashing partitioner keeps each user's events in
+	// order; the zero value is messaging.DefaultPartitioner.
+	Partitioner messaging.Partitioner
+	// Faults drops or fails a fraction of writes, for resilience testing
+	Faults messaging.PublishFaults
+	// CloseTimeout bounds how long Close waits for writes in flight;
+	// DefaultCloseTimeout when zero
+	CloseTimeout time.Duration
+}
+
+// ParseRoutes parses Config.Routes from comma-separated event_type=topic
+// pairs, such as "transaction.paid=payments". An empty string routes nothing.
+func ParseRoutes(s string) (map[string]string, error) {
+	routes := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return routes, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		eventType, topic, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		eventType, topic = strings.TrimSpace(eventType), strings.TrimSpace(topic)
+		if !ok || eventType == "" || topic == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRoutes, pair)
+		}
+		routes[eventType] = topic
+	}
+	return routes, nil
 }
 
 // NewPublisher creates a new Kafka publisher
 func NewPublisher(cfg Config, logger *slog.Logger) *Publisher {
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(cfg.Brokers...),
-		Topic:        cfg.Topic,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-		RequiredAcks: kafka.RequireOne,
+	p := &Publisher{
+		writers:        make(map[string]messaging.MessageWriter),
+		logger:         logger,
+		topic:          cfg.Topic,
+		routes:         cfg.Routes,
+		priorityTopics: cfg.PriorityTopics,
+		closeTimeout:   cfg.CloseTimeout,
+	}
+	if p.closeTimeout <= 0 {
+		p.closeTimeout = DefaultCloseTimeout
+	}
+	for _, topic := range append([]string{cfg.Topic}, slices.Sorted(maps.Values(cfg.Routes))...) {
+		if _, ok := p.writers[topic]; ok {
+			continue
+		}
+		writer := &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Balancer:     cfg.Partitioner.Balancer(),
+			BatchTimeout: 10 * time.Millisecond,
+			RequiredAcks: kafka.RequireOne,
+		}
+		// A writer's topic can't be overridden per message
+		if !cfg.PriorityTopics {
+			writer.Topic = topic
+		}
+		p.writers[topic] = writer
+		if cfg.Faults.Enabled() {
+			p.writers[topic] = messaging.NewFaultyWriter(writer, cfg.Faults, logger)
+		}
 	}
 
-	logger.Info("Kafka publisher created", "brokers", cfg.Brokers, "topic", cfg.Topic)
+	logger.Info("Kafka publisher created", "brokers", cfg.Brokers, "topic", cfg.Topic, "routes", cfg.Routes, "priority_topics", cfg.PriorityTopics, "partitioner", cfg.Partitioner)
 
-	return &Publisher{
-		writer: writer,
-		logger: logger,
+	return p
+}
+
+// route returns the topic events of eventType go to
+func (p *Publisher) route(eventType string) string {
+	if topic, ok := p.routes[eventType]; ok {
+		return topic
 	}
+	return p.topic
+}
+
+// message returns a message of eventType, tagged with its priority and sent
+// to the topic of that priority when the publisher has priority topics
+func (p *Publisher) message(eventType string, key, value []byte) kafka.Message {
+	priority := messaging.EventPriority(eventType)
+	msg := kafka.Message{
+		Key:     key,
+		Value:   value,
+		Headers: []kafka.Header{{Key: messaging.PriorityHeader, Value: []byte(priority.String())}},
+	}
+	if p.priorityTopics {
+		msg.Topic = messaging.PriorityTopic(p.route(eventType), priority)
+	}
+	return msg
 }
 
 // PublishTransactionCreated publishes a transaction created event
 func (p *Publisher) PublishTransactionCreated(ctx context.Context, event *domain.TransactionCreatedEvent) error {
 	event.EventType = "transaction.created"
-	event.Timestamp = time.Now()
+	event.Version = events.TransactionCreatedVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
 
-	value, err := json.Marshal(event)
+	value, err := messaging.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	key := strconv.Itoa(event.UserID)
-
-	err = p.writer.WriteMessages(ctx,
-		kafka.Message{
-			Key:   []byte(key),
-			Value: value,
-		},
+	err = p.write(ctx, p.route(event.EventType),
+		p.message(event.EventType, strconv.AppendInt(nil, int64(event.UserID), 10), value.Bytes()),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
+	value.Release()
 
 	p.logger.Info("transaction.created event published",
 		"transaction_id", event.TransactionID,
@@ -76,24 +222,23 @@ func (p *Publisher) PublishTransactionCreated(ctx context.Context, event *domain
 // PublishTransactionPaid publishes a transaction paid event
 func (p *Publisher) PublishTransactionPaid(ctx context.Context, event *domain.TransactionPaidEvent) error {
 	event.EventType = "transaction.paid"
-	event.Timestamp = time.Now()
+	event.Version = events.TransactionPaidVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
 
-	value, err := json.Marshal(event)
+	value, err := messaging.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	key := strconv.Itoa(event.UserID)
-
-	err = p.writer.WriteMessages(ctx,
-		kafka.Message{
-			Key:   []byte(key),
-			Value: value,
-		},
+	err = p.write(ctx, p.route(event.EventType),
+		p.message(event.EventType, strconv.AppendInt(nil, int64(event.UserID), 10), value.Bytes()),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
+	value.Release()
 
 	p.logger.Info("transaction.paid event published",
 		"user_id", event.UserID,
@@ -103,11 +248,261 @@ func (p *Publisher) PublishTransactionPaid(ctx context.Context, event *domain.Tr
 	return nil
 }
 
-// Close closes the Kafka writer
-func (p *Publisher) Close() error {
-	if err := p.writer.Close(); err != nil {
-		return fmt.Errorf("failed to close Kafka writer: %w", err)
+// PublishPaymentReverted publishes a payment reverted event
+func (p *Publisher) PublishPaymentReverted(ctx context.Context, event *domain.PaymentRevertedEvent) error {
+	event.EventType = "transaction.payment_reverted"
+	event.Version = events.PaymentRevertedVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
 	}
-	p.logger.Info("Kafka publisher closed")
+
+	value, err := messaging.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.write(ctx, p.route(event.EventType),
+		p.message(event.EventType, strconv.AppendInt(nil, int64(event.UserID), 10), value.Bytes()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	value.Release()
+
+	p.logger.Info("transaction.payment_reverted event published",
+		"user_id", event.UserID,
+		"payment_batch_id", event.PaymentBatchID,
+		"transactions_reverted", event.TransactionsReverted,
+	)
+
 	return nil
 }
+
+// PublishLimitApproaching publishes a limit approaching event
+func (p *Publisher) PublishLimitApproaching(ctx context.Context, event *domain.LimitApproachingEvent) error {
+	event.EventType = "limit.approaching"
+	event.Version = events.LimitApproachingVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	value, err := messaging.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.write(ctx, p.route(event.EventType),
+		p.message(event.EventType, strconv.AppendInt(nil, int64(event.UserID), 10), value.Bytes()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	value.Release()
+
+	p.logger.Info("limit.approaching event published",
+		"user_id", event.UserID,
+		"threshold", event.Threshold,
+		"current_total", event.CurrentTotal,
+	)
+
+	return nil
+}
+
+// PublishExportCompleted publishes an export completed event, keyed by the
+// exported date
+func (p *Publisher) PublishExportCompleted(ctx context.Context, event *domain.ExportCompletedEvent) error {
+	event.EventType = "export.completed"
+	event.Version = events.ExportCompletedVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	value, err := messaging.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.write(ctx, p.route(event.EventType),
+		p.message(event.EventType, []byte(event.Date), value.Bytes()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	value.Release()
+
+	p.logger.Info("export.completed event published",
+		"date", event.Date,
+		"key", event.Key,
+		"rows", event.Rows,
+	)
+
+	return nil
+}
+
+// PublishOutbox publishes relayed outbox messages as written by the
+// repository, keyed by aggregate ID and prioritized by type. The "id" header carries the event ID for
+// deduplication, as Debezium's outbox router does.
+func (p *Publisher) PublishOutbox(ctx context.Context, msgs []outbox.Message) error {
+	// One write per topic, keeping the relay's order within each
+	var topics []string
+	byTopic := make(map[string][]kafka.Message)
+	for _, m := range msgs {
+		msg := p.message(m.Type, []byte(m.AggregateID), m.Payload)
+		msg.Headers = append(msg.Headers,
+			kafka.Header{Key: "id", Value: []byte(m.ID)},
+			kafka.Header{Key: "type", Value: []byte(m.Type)},
+		)
+		topic := p.route(m.Type)
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], msg)
+	}
+	for _, topic := range topics {
+		if err := p.write(ctx, topic, byTopic[topic]...); err != nil {
+			return fmt.Errorf("failed to publish outbox messages: %w", err)
+		}
+	}
+	p.logger.Debug("outbox messages published", "count", len(msgs))
+	return nil
+}
+
+// write writes msgs with the writer of topic, recording the write in the
+// publisher's stats
+func (p *Publisher) write(ctx context.Context, topic string, msgs ...kafka.Message) error {
+	n := int64(len(msgs))
+	p.mu.Lock()
+	if p.closing.Load() {
+		p.mu.Unlock()
+		p.stats.dropped.Add(n)
+		return ErrPublisherClosed
+	}
+	p.inflight.Add(1)
+	p.mu.Unlock()
+	defer p.inflight.Done()
+
+	// Consumers continue the trace of the call that published
+	ctx, span := tracing.StartPublish(ctx, topic, msgs)
+	defer span.End()
+
+	p.stats.pending.Add(n)
+	start := time.Now()
+	err := p.writers[topic].WriteMessages(ctx, msgs...)
+	elapsed := int64(time.Since(start))
+	p.stats.pending.Add(-n)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "write failed")
+	}
+
+	p.stats.writes.Add(1)
+	p.stats.messages.Add(n)
+	p.stats.latency.Add(elapsed)
+	for {
+		longest := p.stats.maxLatency.Load()
+		if elapsed <= longest || p.stats.maxLatency.CompareAndSwap(longest, elapsed) {
+			break
+		}
+	}
+	if err != nil {
+		p.stats.errors.Add(1)
+	}
+	// Writes outliving a timed out Close were already counted as dropped
+	if p.closing.Load() && !p.closed.Load() {
+		if err != nil {
+			p.stats.dropped.Add(n)
+		} else {
+			p.stats.flushed.Add(n)
+		}
+	}
+	return err
+}
+
+// Stats returns a snapshot of the publisher's writes
+func (p *Publisher) Stats() WriterStats {
+	return WriterStats{
+		Writes:     p.stats.writes.Load(),
+		Messages:   p.stats.messages.Load(),
+		Errors:     p.stats.errors.Load(),
+		Latency:    time.Duration(p.stats.latency.Load()),
+		MaxLatency: time.Duration(p.stats.maxLatency.Load()),
+		Pending:    p.stats.pending.Load(),
+		Flushed:    p.stats.flushed.Load(),
+		Dropped:    p.stats.dropped.Load(),
+	}
+}
+
+// WriteMetrics writes the publisher's stats in the Prometheus text
+// exposition format
+func (p *Publisher) WriteMetrics(w io.Writer) error {
+	s := p.Stats()
+	bw := bufio.NewWriter(w)
+	writeMetric(bw, "payment_kafka_writes_total", "counter",
+		"Kafka writes attempted.", float64(s.Writes))
+	writeMetric(bw, "payment_kafka_messages_total", "counter",
+		"Messages in attempted Kafka writes.", float64(s.Messages))
+	writeMetric(bw, "payment_kafka_write_errors_total", "counter",
+		"Kafka writes that failed.", float64(s.Errors))
+	fmt.Fprintf(bw, "# HELP payment_kafka_write_duration_seconds Time spent in Kafka writes.\n# TYPE payment_kafka_write_duration_seconds summary\n")
+	fmt.Fprintf(bw, "payment_kafka_write_duration_seconds_sum %s\n", formatValue(s.Latency.Seconds()))
+	fmt.Fprintf(bw, "payment_kafka_write_duration_seconds_count %d\n", s.Writes)
+	writeMetric(bw, "payment_kafka_write_duration_max_seconds", "gauge",
+		"Longest Kafka write.", s.MaxLatency.Seconds())
+	writeMetric(bw, "payment_kafka_pending_messages", "gauge",
+		"Messages in Kafka writes in flight.", float64(s.Pending))
+	return bw.Flush()
+}
+
+func writeMetric(w *bufio.Writer, name, metricType, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, metricType, name, formatValue(value))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Close stops new publishes, flushes writes in flight and closes the Kafka
+// writers, waiting up to the close timeout. It logs how many messages in
+// flight were flushed and how many were dropped, and returns
+// ErrCloseTimeout when writes were still in flight at the timeout.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	p.closing.Store(true)
+	p.mu.Unlock()
+	pending := p.stats.pending.Load()
+
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for _, w := range p.writers {
+			errs = append(errs, w.Close())
+		}
+		p.inflight.Wait()
+		done <- errors.Join(errs...)
+	}()
+
+	timer := time.NewTimer(p.closeTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+		if err != nil {
+			err = fmt.Errorf("failed to close Kafka writers: %w", err)
+		}
+	case <-timer.C:
+		// Writes still in flight are given up on
+		p.closed.Store(true)
+		p.stats.dropped.Add(p.stats.pending.Load())
+		err = ErrCloseTimeout
+	}
+	p.closed.Store(true)
+
+	stats := p.Stats()
+	p.logger.Info("Kafka publisher closed",
+		"pending", pending,
+		"flushed", stats.Flushed,
+		"dropped", stats.Dropped,
+		"timeout", errors.Is(err, ErrCloseTimeout),
+	)
+	return err
+}
@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/outbox"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
+)
+
+// blockingWriter holds each write until release is closed
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (w *blockingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.started <- struct{}{}
+	<-w.release
+	return w.err
+}
+
+func (w *blockingWriter) Close() error { return nil }
+
+func newTestPublisher(w *blockingWriter, closeTimeout time.Duration) *Publisher {
+	return &Publisher{
+		writers:      map[string]messaging.MessageWriter{"transactions": w},
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		topic:        "transactions",
+		closeTimeout: closeTimeout,
+	}
+}
+
+func TestPublisher_CloseFlushesWritesInFlight(t *testing.T) {
+	w := newBlockingWriter()
+	p := newTestPublisher(w, time.Second)
+
+	published := make(chan error, 1)
+	go func() {
+		published <- p.PublishTransactionPaid(context.Background(), &domain.TransactionPaidEvent{UserID: 1})
+	}()
+	<-w.started
+
+	closed := make(chan error, 1)
+	go func() { closed <- p.Close() }()
+	// Close refuses new publishes while it waits
+	for !p.closing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.PublishTransactionPaid(context.Background(), &domain.TransactionPaidEvent{UserID: 2}); !errors.Is(err, ErrPublisherClosed) {
+		t.Errorf("expected ErrPublisherClosed, got %v", err)
+	}
+
+	close(w.release)
+	if err := <-closed; err != nil {
+		t.Fatalf("expected a clean close, got %v", err)
+	}
+	if err := <-published; err != nil {
+		t.Errorf("expected the write in flight to succeed, got %v", err)
+	}
+	if s := p.Stats(); s.Flushed != 1 || s.Dropped != 1 || s.Pending != 0 {
+		t.Errorf("expected 1 flushed and 1 dropped, got %+v", s)
+	}
+}
+
+func TestPublisher_CloseTimeout(t *testing.T) {
+	w := newBlockingWriter()
+	defer close(w.release)
+	p := newTestPublisher(w, 10*time.Millisecond)
+
+	go func() {
+		_ = p.PublishTransactionPaid(context.Background(), &domain.TransactionPaidEvent{UserID: 1})
+	}()
+	<-w.started
+
+	if err := p.Close(); !errors.Is(err, ErrCloseTimeout) {
+		t.Fatalf("expected ErrCloseTimeout, got %v", err)
+	}
+	if s := p.Stats(); s.Flushed != 0 || s.Dropped != 1 {
+		t.Errorf("expected the write in flight dropped, got %+v", s)
+	}
+}
+
+func TestPublisher_WriteMetrics(t *testing.T) {
+	w := newBlockingWriter()
+	w.err = errors.New("broker down")
+	close(w.release)
+	p := newTestPublisher(w, time.Second)
+
+	if err := p.PublishTransactionPaid(context.Background(), &domain.TransactionPaidEvent{UserID: 1}); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+
+	var b strings.Builder
+	if err := p.WriteMetrics(&b); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{
+		"payment_kafka_writes_total 1\n",
+		"payment_kafka_messages_total 1\n",
+		"payment_kafka_write_errors_total 1\n",
+		"payment_kafka_write_duration_seconds_count 1\n",
+		"payment_kafka_pending_messages 0\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+// recordingWriter records the messages written to it
+type recordingWriter struct {
+	msgs []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+func TestPublisher_Routes(t *testing.T) {
+	transactions, payments, refunds := &recordingWriter{}, &recordingWriter{}, &recordingWriter{}
+	p := &Publisher{
+		writers: map[string]messaging.MessageWriter{"transactions": transactions, "payments": payments, "refunds": refunds},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		topic:   "transactions",
+		routes:  map[string]string{"transaction.paid": "payments", "transaction.payment_reverted": "refunds"},
+	}
+
+	ctx := context.Background()
+	if err := p.PublishTransactionCreated(ctx, &domain.TransactionCreatedEvent{UserID: 1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := p.PublishTransactionPaid(ctx, &domain.TransactionPaidEvent{UserID: 1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := p.PublishOutbox(ctx, []outbox.Message{
+		{ID: "a", AggregateID: "1", Type: "transaction.payment_reverted"},
+		{ID: "b", AggregateID: "1", Type: "transaction.created"},
+		{ID: "c", AggregateID: "2", Type: "transaction.payment_reverted"},
+	}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(transactions.msgs) != 2 || len(payments.msgs) != 1 || len(refunds.msgs) != 2 {
+		t.Fatalf("expected 2 transactions, 1 payment and 2 refunds, got %d, %d and %d",
+			len(transactions.msgs), len(payments.msgs), len(refunds.msgs))
+	}
+	if string(refunds.msgs[0].Key) != "1" || string(refunds.msgs[1].Key) != "2" {
+		t.Errorf("expected refunds in relay order, got keys %q and %q", refunds.msgs[0].Key, refunds.msgs[1].Key)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(" transaction.paid=payments, transaction.payment_reverted = refunds ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(routes) != 2 || routes["transaction.paid"] != "payments" || routes["transaction.payment_reverted"] != "refunds" {
+		t.Errorf("expected two routes, got %v", routes)
+	}
+	if routes, err := ParseRoutes(""); err != nil || len(routes) != 0 {
+		t.Errorf("expected no routes, got %v, %v", routes, err)
+	}
+	for _, s := range []string{"transaction.paid", "=payments", "transaction.paid=", "a=b,,c=d"} {
+		if _, err := ParseRoutes(s); !errors.Is(err, ErrInvalidRoutes) {
+			t.Errorf("expected ErrInvalidRoutes for %q, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package outbox relays events written to the outbox table by
+// repository.WithOutbox. Because the rows commit with the changes they
+// describe, no event is lost when the service crashes after a write and none
+// is sent for a write that rolled back. Delivery is at least once: a crash
+// between publishing and deleting a batch sends it again, so consumers should
+// dedupe on the message ID.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// Message is one outbox row
+type Message struct {
+	Seq int64
+	// ID is unique per event, for consumer-side deduplication
+	ID            string
+	AggregateType string
+	// AggregateID keys the message, keeping one user's events in order
+	AggregateID string
+	Type        string
+	Payload     []byte
+}
+
+// Store reads and removes pending outbox rows
+type Store interface {
+	// Process passes up to limit pending messages, oldest first, to fn and
+	// removes them if fn succeeds. It returns how many were processed.
+	Process(ctx context.Context, limit int, fn func([]Message) error) (int, error)
+}
+
+// relayLockID is the advisory lock held while a batch is relayed, so only
+// one instance relays at a time and events leave in commit order
+const relayLockID = 0x6f7574626f78 // "outbox"
+
+// PostgresStore is the outbox table in PostgreSQL
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Process runs in one database transaction. When another instance holds the
+// relay lock it processes nothing.
+func (s *PostgresStore) Process(ctx context.Context, limit int, fn func([]Message) error) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockID).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	msgs, err := pending(ctx, tx, limit)
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+	if err := fn(msgs); err != nil {
+		return 0, err
+	}
+
+	seqs := make([]int64, len(msgs))
+	for i, m := range msgs {
+		seqs[i] = m.Seq
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM outbox WHERE seq = ANY($1)", pq.Array(seqs)); err != nil {
+		return 0, fmt.Errorf("failed to delete relayed messages: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	return len(msgs), nil
+}
+
+func pending(ctx context.Context, tx *sql.Tx, limit int) ([]Message, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT seq, id, aggregatetype, aggregateid, type, payload
+		FROM outbox
+		ORDER BY seq
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var msgs []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.Seq, &m.ID, &m.AggregateType, &m.AggregateID, &m.Type, &m.Payload); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox: %w", err)
+	}
+	return msgs, nil
+}
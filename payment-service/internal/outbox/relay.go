@@ -0,0 +1,97 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Relay defaults
+const (
+	DefaultBatchSize    = 100
+	DefaultPollInterval = 500 * time.Millisecond
+)
+
+// Publisher sends relayed messages to the broker
+type Publisher interface {
+	// PublishOutbox sends msgs in order, failing if any is not acknowledged
+	PublishOutbox(ctx context.Context, msgs []Message) error
+}
+
+// Relay polls a Store and publishes what it finds
+type Relay struct {
+	store     Store
+	publisher Publisher
+	logger    *slog.Logger
+	batchSize int
+	interval  time.Duration
+}
+
+// RelayOption configures a Relay
+type RelayOption func(*Relay)
+
+// WithBatchSize sets how many messages are published per database transaction
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithPollInterval sets how often an empty outbox is checked again
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// NewRelay creates a Relay from store to publisher
+func NewRelay(store Store, publisher Publisher, logger *slog.Logger, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:     store,
+		publisher: publisher,
+		logger:    logger,
+		batchSize: DefaultBatchSize,
+		interval:  DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run relays until ctx is cancelled. Failed batches stay in the outbox and
+// are retried on the next poll.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("outbox relay failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes batches until the outbox is empty, returning how many
+// messages were published
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	var total int
+	for {
+		n, err := r.store.Process(ctx, r.batchSize, func(msgs []Message) error {
+			return r.publisher.PublishOutbox(ctx, msgs)
+		})
+		total += n
+		if err != nil || n < r.batchSize {
+			return total, err
+		}
+	}
+}
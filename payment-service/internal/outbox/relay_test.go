@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeStore is an in-memory outbox with the same remove-on-success contract
+// as PostgresStore
+type fakeStore struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+func (s *fakeStore) add(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		seq := int64(len(s.msgs) + 1)
+		s.msgs = append(s.msgs, Message{Seq: seq, ID: strconv.FormatInt(seq, 10), AggregateID: "1", Type: "transaction.created"})
+	}
+}
+
+func (s *fakeStore) Process(ctx context.Context, limit int, fn func([]Message) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch := append([]Message(nil), s.msgs[:min(limit, len(s.msgs))]...)
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := fn(batch); err != nil {
+		return 0, err
+	}
+	s.msgs = s.msgs[len(batch):]
+	return len(batch), nil
+}
+
+func (s *fakeStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.msgs)
+}
+
+type fakePublisher struct {
+	sent    []Message
+	batches int
+	err     error
+}
+
+func (p *fakePublisher) PublishOutbox(ctx context.Context, msgs []Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.batches++
+	p.sent = append(p.sent, msgs...)
+	return nil
+}
+
+func TestRelay_Drain(t *testing.T) {
+	store := &fakeStore{}
+	store.add(5)
+	publisher := &fakePublisher{}
+	relay := NewRelay(store, publisher, slog.New(slog.NewTextHandler(io.Discard, nil)), WithBatchSize(2))
+
+	n, err := relay.Drain(context.Background())
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5 messages relayed, got %d, %v", n, err)
+	}
+	if publisher.batches != 3 {
+		t.Errorf("expected batches of 2, 2 and 1, got %d batches", publisher.batches)
+	}
+	for i, m := range publisher.sent {
+		if m.Seq != int64(i+1) {
+			t.Fatalf("expected messages in outbox order, got %+v", publisher.sent)
+		}
+	}
+	if store.len() != 0 {
+		t.Errorf("expected an empty outbox, %d left", store.len())
+	}
+}
+
+func TestRelay_DrainKeepsFailedBatches(t *testing.T) {
+	store := &fakeStore{}
+	store.add(3)
+	publisher := &fakePublisher{err: errors.New("broker down")}
+	relay := NewRelay(store, publisher, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if _, err := relay.Drain(context.Background()); err == nil {
+		t.Fatal("expected the publish failure to be returned")
+	}
+	if store.len() != 3 {
+		t.Fatalf("expected unpublished messages to stay, %d left", store.len())
+	}
+
+	publisher.err = nil
+	if n, err := relay.Drain(context.Background()); err != nil || n != 3 {
+		t.Errorf("expected the retry to relay all 3, got %d, %v", n, err)
+	}
+}
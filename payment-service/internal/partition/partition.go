@@ -0,0 +1,141 @@
+// Package partition keeps the monthly partitions of the transactions table
+// ahead of the clock and, optionally, archives months past their retention.
+package partition
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+// Maintenance defaults
+const (
+	DefaultMonthsAhead = 3
+	DefaultInterval    = 24 * time.Hour
+)
+
+// Maintainer creates upcoming partitions so new transactions never land in
+// the default partition, and archives expired ones when a retention is set
+type Maintainer struct {
+	repo        domain.TransactionPartitioner
+	logger      *slog.Logger
+	monthsAhead int
+	retention   int
+	interval    time.Duration
+	clock       clock.Clock
+}
+
+// Option configures a Maintainer
+type Option func(*Maintainer)
+
+// WithMonthsAhead sets how many months past the current one have partitions
+func WithMonthsAhead(n int) Option {
+	return func(m *Maintainer) {
+		if n > 0 {
+			m.monthsAhead = n
+		}
+	}
+}
+
+// WithRetention moves the paid transactions of months more than n months
+// before the current one to the archive and drops their partitions. A month
+// with unpaid transactions keeps its partition until they are paid. 0 (the
+// default) keeps every month.
+func WithRetention(months int) Option {
+	return func(m *Maintainer) {
+		m.retention = max(months, 0)
+	}
+}
+
+// WithInterval sets how often Run maintains the partitions
+func WithInterval(d time.Duration) Option {
+	return func(m *Maintainer) {
+		if d > 0 {
+			m.interval = d
+		}
+	}
+}
+
+// WithClock sets the clock that decides the current month and Run ticks by
+// (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(m *Maintainer) {
+		m.clock = c
+	}
+}
+
+// NewMaintainer creates a Maintainer for repo's partitions
+func NewMaintainer(repo domain.TransactionPartitioner, logger *slog.Logger, opts ...Option) *Maintainer {
+	m := &Maintainer{
+		repo:        repo,
+		logger:      logger,
+		monthsAhead: DefaultMonthsAhead,
+		interval:    DefaultInterval,
+		clock:       clock.Real,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Run maintains the partitions now and then every interval until ctx is
+// cancelled
+func (m *Maintainer) Run(ctx context.Context) {
+	ticker := m.clock.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("partition maintenance failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Maintain creates the partitions from the current month through the months
+// ahead, then archives those past the retention
+func (m *Maintainer) Maintain(ctx context.Context) error {
+	now := m.clock.Now().UTC()
+	if err := m.repo.EnsurePartitions(ctx, now, m.monthsAhead); err != nil {
+		return err
+	}
+	if m.retention == 0 {
+		return nil
+	}
+
+	cutoff := time.Date(now.Year(), now.Month()-time.Month(m.retention), 1, 0, 0, 0, 0, time.UTC)
+	expired, err := m.repo.ExpiredPartitions(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	var dropped, kept []string
+	defer func() {
+		if len(dropped) > 0 {
+			m.logger.Info("expired partitions archived and dropped", "partitions", dropped)
+		}
+		if len(kept) > 0 {
+			m.logger.Warn("expired partitions kept for their unpaid transactions", "partitions", kept)
+		}
+	}()
+	for _, name := range expired {
+		ok, err := m.repo.ArchivePartition(ctx, name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			dropped = append(dropped, name)
+		} else {
+			kept = append(kept, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package partition
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+type fakePartitioner struct {
+	ensuredFrom time.Time
+	months      int
+	dropBefore  time.Time
+	// expired are the partitions ExpiredPartitions returns, and unpaid those
+	// of them holding unpaid transactions
+	expired  []string
+	unpaid   map[string]bool
+	archived []string
+}
+
+func (f *fakePartitioner) EnsurePartitions(ctx context.Context, from time.Time, months int) error {
+	f.ensuredFrom, f.months = from, months
+	return nil
+}
+
+func (f *fakePartitioner) ExpiredPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	f.dropBefore = before
+	return f.expired, nil
+}
+
+func (f *fakePartitioner) ArchivePartition(ctx context.Context, name string) (bool, error) {
+	f.archived = append(f.archived, name)
+	return !f.unpaid[name], nil
+}
+
+func TestMaintainer_Maintain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	repo := &fakePartitioner{}
+	m := NewMaintainer(repo, logger, WithMonthsAhead(2))
+	m.clock = clock.NewFake(now)
+	if err := m.Maintain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !repo.ensuredFrom.Equal(now) || repo.months != 2 {
+		t.Errorf("expected partitions from now for 2 months ahead, got %v and %d", repo.ensuredFrom, repo.months)
+	}
+	if !repo.dropBefore.IsZero() {
+		t.Errorf("expected nothing dropped without a retention, got a cutoff of %v", repo.dropBefore)
+	}
+
+	repo = &fakePartitioner{}
+	m = NewMaintainer(repo, logger, WithRetention(12))
+	m.clock = clock.NewFake(now)
+	if err := m.Maintain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC); !repo.dropBefore.Equal(want) {
+		t.Errorf("expected months before %v dropped, got %v", want, repo.dropBefore)
+	}
+}
+
+func TestMaintainer_KeepsPartitionsWithUnpaidTransactions(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	repo := &fakePartitioner{
+		expired: []string{"transactions_p202301", "transactions_p202302"},
+		unpaid:  map[string]bool{"transactions_p202302": true},
+	}
+	m := NewMaintainer(repo, logger, WithRetention(12))
+	m.clock = clock.NewFake(time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC))
+	if err := m.Maintain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.archived) != 2 {
+		t.Errorf("expected the paid transactions of both months archived, got %v", repo.archived)
+	}
+	if !strings.Contains(logs.String(), `msg="expired partitions archived and dropped" partitions=[transactions_p202301]`) {
+		t.Errorf("expected only the paid month dropped, got logs:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), `msg="expired partitions kept for their unpaid transactions" partitions=[transactions_p202302]`) {
+		t.Errorf("expected the month with an unpaid transaction kept, got logs:\n%s", logs.String())
+	}
+}
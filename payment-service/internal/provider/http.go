@@ -0,0 +1,74 @@
+// Package provider charges users through an external payment provider.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+)
+
+// DefaultTimeout bounds a charge request
+const DefaultTimeout = 10 * time.Second
+
+// HTTPProvider posts each charge as JSON to a provider endpoint. The batch ID
+// is sent as the Idempotency-Key, so a retried charge isn't charged twice.
+// Any 2xx response means the charge succeeded.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// Option configures an HTTPProvider
+type Option func(*HTTPProvider)
+
+// WithTimeout bounds each charge request
+func WithTimeout(d time.Duration) Option {
+	return func(p *HTTPProvider) {
+		p.client.Timeout = d
+	}
+}
+
+// NewHTTPProvider creates an HTTPProvider posting charges to url
+func NewHTTPProvider(url string, opts ...Option) *HTTPProvider {
+	p := &HTTPProvider{
+		url:    url,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Charge posts charge to the provider
+func (p *HTTPProvider) Charge(ctx context.Context, charge *domain.Charge) error {
+	body, err := json.Marshal(charge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal charge: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build charge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", charge.BatchID)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to charge: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("charge declined: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+var _ domain.PaymentProvider = (*HTTPProvider)(nil)
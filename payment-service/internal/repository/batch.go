@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+)
+
+// Batching defaults
+const (
+	DefaultMaxBatch = 100
+	DefaultMaxDelay = 2 * time.Millisecond
+
+	// flushTimeout bounds one batch INSERT, which no single caller owns
+	flushTimeout = 5 * time.Second
+)
+
+// errBatchRejected marks a CreateMany failure where the database refused the
+// statement, so retrying its rows one by one can't duplicate any
+var errBatchRejected = errors.New("batch insert rejected")
+
+// BatchCreator is a repository that can insert several transactions in one
+// statement
+type BatchCreator interface {
+	domain.TransactionRepository
+	// CreateMany inserts txs atomically, wrapping errBatchRejected when
+	// nothing was written
+	CreateMany(ctx context.Context, txs []*domain.Transaction) error
+}
+
+// BatchingTransactionRepository is a write-behind decorator that groups
+// concurrent Create calls into multi-row INSERTs. A call waits until its batch
+// fills or the max delay passes, then gets back its own row. Everything but Create
+// goes straight to the wrapped repository.
+type BatchingTransactionRepository struct {
+	BatchCreator
+
+	maxBatch int
+	maxDelay time.Duration
+
+	requests  chan *pendingCreate
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// pendingCreate is one Create waiting for its batch
+type pendingCreate struct {
+	ctx    context.Context
+	tx     *domain.Transaction
+	result chan error
+}
+
+// BatchOption configures a BatchingTransactionRepository
+type BatchOption func(*BatchingTransactionRepository)
+
+// WithMaxBatch sets how many rows are inserted at most per statement
+func WithMaxBatch(n int) BatchOption {
+	return func(r *BatchingTransactionRepository) {
+		if n > 0 {
+			r.maxBatch = n
+		}
+	}
+}
+
+// WithMaxDelay sets how long the first row of a batch waits for others
+func WithMaxDelay(d time.Duration) BatchOption {
+	return func(r *BatchingTransactionRepository) {
+		if d > 0 {
+			r.maxDelay = d
+		}
+	}
+}
+
+// NewBatchingTransactionRepository wraps repo and starts its flush loop.
+// Call Close to flush outstanding rows and stop it.
+func NewBatchingTransactionRepository(repo BatchCreator, opts ...BatchOption) *BatchingTransactionRepository {
+	r := &BatchingTransactionRepository{
+		BatchCreator: repo,
+		maxBatch:     DefaultMaxBatch,
+		maxDelay:     DefaultMaxDelay,
+		requests:     make(chan *pendingCreate),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	go r.run()
+	return r
+}
+
+// Create queues tx for the next batch and waits for it to be written.
+// Once queued the row may still be inserted after ctx ends, so the call waits
+// for the outcome rather than report a failure that didn't happen.
+func (r *BatchingTransactionRepository) Create(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
+	p := &pendingCreate{ctx: ctx, tx: tx, result: make(chan error, 1)}
+	select {
+	case r.requests <- p:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.quit:
+		return r.BatchCreator.Create(ctx, tx)
+	}
+
+	if err := <-p.result; err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Close flushes queued rows and stops the flush loop. Later Creates go
+// straight to the wrapped repository.
+func (r *BatchingTransactionRepository) Close() error {
+	r.closeOnce.Do(func() { close(r.quit) })
+	<-r.done
+	return nil
+}
+
+func (r *BatchingTransactionRepository) run() {
+	defer close(r.done)
+
+	batch := make([]*pendingCreate, 0, r.maxBatch)
+	timer := time.NewTimer(r.maxDelay)
+	timer.Stop()
+	var deadline <-chan time.Time
+
+	flush := func() {
+		r.flush(batch)
+		batch = batch[:0]
+		timer.Stop()
+		deadline = nil
+	}
+
+	for {
+		select {
+		case p := <-r.requests:
+			batch = append(batch, p)
+			if len(batch) == 1 {
+				timer.Reset(r.maxDelay)
+				deadline = timer.C
+			}
+			if len(batch) >= r.maxBatch {
+				flush()
+			}
+		case <-deadline:
+			flush()
+		case <-r.quit:
+			flush()
+			return
+		}
+	}
+}
+
+// flush writes batch with one INSERT. If the database rejects it the rows are
+// retried one by one, so a single bad row fails only its own caller; any
+// other error may have written the rows and goes back to every caller.
+func (r *BatchingTransactionRepository) flush(batch []*pendingCreate) {
+	live := make([]*pendingCreate, 0, len(batch))
+	for _, p := range batch {
+		if err := p.ctx.Err(); err != nil {
+			p.result <- err
+			continue
+		}
+		live = append(live, p)
+	}
+
+	switch len(live) {
+	case 0:
+		return
+	case 1:
+		_, err := r.BatchCreator.Create(live[0].ctx, live[0].tx)
+		live[0].result <- err
+		return
+	}
+
+	txs := make([]*domain.Transaction, len(live))
+	for i, p := range live {
+		txs[i] = p.tx
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	err := r.BatchCreator.CreateMany(ctx, txs)
+	if err == nil || !errors.Is(err, errBatchRejected) {
+		for _, p := range live {
+			p.result <- err
+		}
+		return
+	}
+
+	log.Printf("batch insert of %d transactions failed, retrying individually: %v", len(live), err)
+	for _, p := range live {
+		_, err := r.BatchCreator.Create(p.ctx, p.tx)
+		p.result <- err
+	}
+}
+
+var (
+	_ BatchCreator                 = (*PostgresTransactionRepository)(nil)
+	_ domain.TransactionRepository = (*BatchingTransactionRepository)(nil)
+)
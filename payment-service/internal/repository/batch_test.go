@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+)
+
+// fakeBatchCreator adds CreateMany to the in-memory repository and records
+// the size of every batch
+type fakeBatchCreator struct {
+	*testutil.FakeTransactionRepository
+
+	mu      sync.Mutex
+	batches []int
+	// ManyErr fails CreateMany without writing anything
+	ManyErr error
+}
+
+func newFakeBatchCreator() *fakeBatchCreator {
+	return &fakeBatchCreator{FakeTransactionRepository: testutil.NewFakeTransactionRepository()}
+}
+
+func (f *fakeBatchCreator) CreateMany(ctx context.Context, txs []*domain.Transaction) error {
+	f.mu.Lock()
+	f.batches = append(f.batches, len(txs))
+	err := f.ManyErr
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range txs {
+		if _, err := f.FakeTransactionRepository.Create(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeBatchCreator) Batches() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.batches...)
+}
+
+// createConcurrently runs n Creates at once, one per user, and returns the
+// results by user
+func createConcurrently(t *testing.T, repo domain.TransactionRepository, n int) map[int]*domain.Transaction {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[int]*domain.Transaction)
+	for userID := 1; userID <= n; userID++ {
+		wg.Go(func() {
+			tx, err := repo.Create(context.Background(), &domain.Transaction{
+				UserID:      userID,
+				Amount:      float64(userID),
+				Description: fmt.Sprintf("user %d", userID),
+			})
+			if err != nil {
+				t.Errorf("user %d: expected no error, got %v", userID, err)
+				return
+			}
+			mu.Lock()
+			results[userID] = tx
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+func TestBatchingRepository_GroupsAndCorrelates(t *testing.T) {
+	inner := newFakeBatchCreator()
+	repo := NewBatchingTransactionRepository(inner, WithMaxBatch(10), WithMaxDelay(50*time.Millisecond))
+	defer repo.Close()
+
+	results := createConcurrently(t, repo, 30)
+
+	for userID, tx := range results {
+		if tx.UserID != userID || tx.Amount != float64(userID) || tx.ID == 0 || tx.CreatedAt.IsZero() {
+			t.Errorf("user %d got the wrong row back: %+v", userID, tx)
+		}
+	}
+	if got := len(inner.Transactions()); got != 30 {
+		t.Errorf("expected 30 stored transactions, got %d", got)
+	}
+
+	batches := inner.Batches()
+	if len(batches) == 0 || len(batches) > 6 {
+		t.Errorf("expected the creates to be grouped, got batches %v", batches)
+	}
+	for _, size := range batches {
+		if size > 10 {
+			t.Errorf("batch of %d exceeds the max of 10", size)
+		}
+	}
+}
+
+func TestBatchingRepository_FlushesAfterDelay(t *testing.T) {
+	inner := newFakeBatchCreator()
+	repo := NewBatchingTransactionRepository(inner, WithMaxBatch(100), WithMaxDelay(time.Millisecond))
+	defer repo.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := repo.Create(context.Background(), &domain.Transaction{UserID: 1, Amount: 5})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a lone create was never flushed")
+	}
+}
+
+func TestBatchingRepository_RejectedBatchRetriesIndividually(t *testing.T) {
+	inner := newFakeBatchCreator()
+	inner.ManyErr = fmt.Errorf("wrapped: %w", errBatchRejected)
+	repo := NewBatchingTransactionRepository(inner, WithMaxBatch(5), WithMaxDelay(50*time.Millisecond))
+	defer repo.Close()
+
+	results := createConcurrently(t, repo, 5)
+
+	if len(results) != 5 || len(inner.Transactions()) != 5 {
+		t.Errorf("expected every row written by the fallback, got %d results and %d rows", len(results), len(inner.Transactions()))
+	}
+}
+
+func TestBatchingRepository_AmbiguousErrorNotRetried(t *testing.T) {
+	inner := newFakeBatchCreator()
+	inner.ManyErr = errors.New("connection reset")
+	repo := NewBatchingTransactionRepository(inner, WithMaxBatch(2), WithMaxDelay(time.Second))
+	defer repo.Close()
+
+	errs := make(chan error, 2)
+	for userID := 1; userID <= 2; userID++ {
+		go func() {
+			_, err := repo.Create(context.Background(), &domain.Transaction{UserID: userID, Amount: 1})
+			errs <- err
+		}()
+	}
+
+	for range 2 {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "connection reset") {
+			t.Errorf("expected the batch error, got %v", err)
+		}
+	}
+	if got := len(inner.Transactions()); got != 0 {
+		t.Errorf("expected no individual retries, got %d rows", got)
+	}
+}
+
+func TestBatchingRepository_CancelledBeforeFlush(t *testing.T) {
+	inner := newFakeBatchCreator()
+	repo := NewBatchingTransactionRepository(inner, WithMaxBatch(100), WithMaxDelay(50*time.Millisecond))
+	defer repo.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := repo.Create(ctx, &domain.Transaction{UserID: 1, Amount: 5})
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if got := len(inner.Transactions()); got != 0 {
+		t.Errorf("expected the cancelled row to be skipped, got %d rows", got)
+	}
+}
+
+func TestBatchingRepository_CreateAfterClose(t *testing.T) {
+	inner := newFakeBatchCreator()
+	repo := NewBatchingTransactionRepository(inner)
+	if err := repo.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := repo.Create(context.Background(), &domain.Transaction{UserID: 1, Amount: 5}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(inner.Batches()) != 0 || len(inner.Transactions()) != 1 {
+		t.Error("expected a direct insert after Close")
+	}
+}
+
+func TestMultiInsertValues(t *testing.T) {
+	want := "INSERT INTO transactions (user_id, amount, description, is_paid, source_channel, source_country, created_at, ulid, reference_id) VALUES " +
+		"($1, $2, $3, false, NULLIF($4, ''), NULLIF($5, ''), COALESCE($6::timestamp, LOCALTIMESTAMP), $7, NULLIF($8, '')), " +
+		"($9, $10, $11, false, NULLIF($12, ''), NULLIF($13, ''), COALESCE($14::timestamp, LOCALTIMESTAMP), $15, NULLIF($16, ''))"
+	if got := multiInsertValues(2); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInsertQuery_ClaimsReferences(t *testing.T) {
+	for _, r := range []*PostgresTransactionRepository{
+		NewPostgresTransactionRepository(nil),
+		NewPostgresTransactionRepository(nil, WithOutbox()),
+	} {
+		query := r.insertQuery(multiInsertValues(1), "id")
+		if !strings.Contains(query, "INSERT INTO transaction_references") {
+			t.Errorf("expected reference IDs claimed in the insert statement:\n%s", query)
+		}
+		if strings.Contains(query, "INSERT INTO outbox") != r.outbox {
+			t.Errorf("expected an outbox row only with the outbox:\n%s", query)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// partitionPrefix and partitionLayout name the monthly partitions of
+// transactions, e.g. transactions_p202403, as migration 000008 does
+const (
+	partitionPrefix = "transactions_p"
+	partitionLayout = "200601"
+)
+
+// partitionName returns the name of the partition holding month
+func partitionName(month time.Time) string {
+	return partitionPrefix + month.Format(partitionLayout)
+}
+
+// monthStart returns the start of t's month in UTC, the zone created_at is
+// stored in
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Partitioned reports whether transactions is partitioned, i.e. whether
+// migration 000008 has run
+func (r *PostgresTransactionRepository) Partitioned(ctx context.Context) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_partitioned_table p
+			JOIN pg_class c ON c.oid = p.partrelid
+			WHERE c.relname = 'transactions' AND c.relnamespace = current_schema()::regnamespace)`
+
+	var partitioned bool
+	if err := r.db.QueryRowContext(ctx, query).Scan(&partitioned); err != nil {
+		return false, fmt.Errorf("failed to check partitioning: %w", err)
+	}
+	return partitioned, nil
+}
+
+// EnsurePartitions creates the monthly partitions from from's month through
+// months after it, skipping those that exist. Creating a partition fails if
+// transactions_default already holds rows for its month.
+func (r *PostgresTransactionRepository) EnsurePartitions(ctx context.Context, from time.Time, months int) error {
+	month := monthStart(from)
+	for range months + 1 {
+		next := month.AddDate(0, 1, 0)
+		// Bounds are formatted by us, never taken from input
+		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF transactions FOR VALUES FROM ('%s') TO ('%s')",
+			pq.QuoteIdentifier(partitionName(month)), month.Format(time.DateOnly), next.Format(time.DateOnly))
+		if _, err := r.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", partitionName(month), err)
+		}
+		month = next
+	}
+	return nil
+}
+
+// ExpiredPartitions returns the monthly partitions that end on or before
+// before, oldest first. The default partition is never among them.
+func (r *PostgresTransactionRepository) ExpiredPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	names, err := r.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for _, name := range names {
+		suffix, ok := strings.CutPrefix(name, partitionPrefix)
+		month, err := time.Parse(partitionLayout, suffix)
+		if !ok || err != nil {
+			continue // Not a monthly partition
+		}
+		if month.AddDate(0, 1, 0).After(before) {
+			continue
+		}
+		expired = append(expired, name)
+	}
+	return expired, nil
+}
+
+// ArchivePartition moves the paid transactions of partition name to
+// transactions_archive, then drops the partition if that emptied it, and
+// reports whether it was dropped. Unpaid transactions keep their partition:
+// they still count toward their users' totals and can still be paid.
+// Archived transactions keep their receipt keys, so their receipts stay
+// readable.
+func (r *PostgresTransactionRepository) ArchivePartition(ctx context.Context, name string) (bool, error) {
+	if _, err := r.db.ExecContext(ctx, archivePartitionQuery(name)); err != nil {
+		return false, fmt.Errorf("failed to archive partition %s: %w", name, err)
+	}
+
+	var dropped bool
+	if err := r.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NULL", name).Scan(&dropped); err != nil {
+		return false, fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+	return dropped, nil
+}
+
+// archivePartitionQuery moves a partition's paid transactions and drops it
+// once empty in one statement, so nothing is dropped that wasn't archived.
+// A row reverted to unpaid while the rows are moved is rechecked and stays.
+func archivePartitionQuery(name string) string {
+	// name comes from pg_inherits and matched partitionPrefix and a month,
+	// never from input
+	partition := pq.QuoteIdentifier(name)
+	return `
+		DO $$
+		BEGIN
+			WITH moved AS (
+				DELETE FROM ` + partition + `
+				WHERE is_paid
+				RETURNING ` + archivedColumns + `
+			)
+			INSERT INTO transactions_archive (` + archivedColumns + `)
+			SELECT ` + archivedColumns + ` FROM moved;
+
+			IF NOT EXISTS (SELECT 1 FROM ` + partition + `) THEN
+				DROP TABLE ` + partition + `;
+			END IF;
+		END $$`
+}
+
+// partitions lists the partitions of transactions by name
+func (r *PostgresTransactionRepository) partitions(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'transactions'::regclass`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan partition name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating partitions: %w", err)
+	}
+
+	// The month suffix sorts chronologically
+	sort.Strings(names)
+	return names, nil
+}
@@ -3,31 +3,127 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/database"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	"github.com/tkaewplik/go-microservices/pkg/ulid"
 )
 
 // PostgresTransactionRepository implements TransactionRepository using PostgreSQL
 type PostgresTransactionRepository struct {
-	db *sql.DB
+	db     database.DBTX
+	outbox bool
+}
+
+// RepositoryOption configures a PostgresTransactionRepository
+type RepositoryOption func(*PostgresTransactionRepository)
+
+// WithOutbox writes a transaction.created or transaction.paid row to the
+// outbox table in the same statement as each change, so an event exists if
+// and only if the change committed. A relay, or Debezium's outbox event
+// router, then publishes the rows.
+func WithOutbox() RepositoryOption {
+	return func(r *PostgresTransactionRepository) {
+		r.outbox = true
+	}
+}
+
+// TransactionIndexes are the indexes the queries and constraints below rely
+// on, by table; migrations 000002, 000006, 000009 and 000010 create them,
+// 000008 recreates them on the partitioned table, and main warns at startup
+// when any is missing or has other columns
+var TransactionIndexes = map[string][]database.Index{
+	"transactions": {
+		{Name: "idx_transactions_user_id", Columns: []string{"user_id"}},
+		{Name: "idx_transactions_user_id_is_paid", Columns: []string{"user_id", "is_paid"}},
+		{Name: "idx_transactions_created_at", Columns: []string{"created_at"}},
+		{Name: "idx_transactions_user_id_created_at_id", Columns: []string{"user_id", "created_at", "id"}},
+		{Name: "idx_transactions_user_id_amount_id", Columns: []string{"user_id", "amount", "id"}},
+		{Name: "idx_transactions_user_id_ulid", Columns: []string{"user_id", "ulid"}},
+	},
+	"transaction_references": {
+		{Name: "transaction_references_pkey", Columns: []string{"user_id", "reference_id"}, Unique: true},
+	},
 }
 
 // NewPostgresTransactionRepository creates a new PostgresTransactionRepository
-func NewPostgresTransactionRepository(db *sql.DB) *PostgresTransactionRepository {
-	return &PostgresTransactionRepository{db: db}
+func NewPostgresTransactionRepository(db database.DBTX, opts ...RepositoryOption) *PostgresTransactionRepository {
+	r := &PostgresTransactionRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// Create creates a new transaction in the database
+// createdEventsCTE inserts a transaction.created outbox row for every row of
+// a preceding "tx" CTE, which must return the source columns. The payload
+// matches domain.TransactionCreatedEvent at events.TransactionCreatedVersion.
+const createdEventsCTE = `
+		event AS (
+			INSERT INTO outbox (aggregatetype, aggregateid, type, payload)
+			SELECT 'transaction', user_id::text, 'transaction.created', jsonb_build_object(
+				'event_type', 'transaction.created',
+				'version', 1,
+				'transaction_id', id,
+				'transaction_ulid', ulid,
+				'user_id', user_id,
+				'amount', amount,
+				'description', COALESCE(description, ''),
+				'timestamp', created_at AT TIME ZONE 'UTC') ||
+				CASE WHEN source_channel IS NULL AND source_country IS NULL THEN '{}'::jsonb
+				ELSE jsonb_build_object('source', jsonb_strip_nulls(jsonb_build_object(
+					'channel', source_channel,
+					'country', source_country)))
+				END
+			FROM tx
+		)`
+
+// referencesCTE claims the reference ID of every row of a preceding "tx"
+// CTE that has one. The transactions table is partitioned, so it can't keep
+// reference IDs unique per user itself; a claimed reference fails the whole
+// statement with a unique violation of transaction_references_pkey.
+const referencesCTE = `
+		ref AS (
+			INSERT INTO transaction_references (user_id, reference_id, transaction_id, created_at)
+			SELECT user_id, reference_id, id, created_at FROM tx WHERE reference_id IS NOT NULL
+		)`
+
+// insertQuery wraps insert, an INSERT INTO transactions, in a statement that
+// also claims the rows' reference IDs and, with the outbox, records their
+// events, and selects columns of the inserted rows
+func (r *PostgresTransactionRepository) insertQuery(insert, columns string) string {
+	query := "WITH tx AS (" + insert + `
+		RETURNING id, user_id, amount, description, is_paid, created_at, source_channel, source_country, ulid, reference_id),` + referencesCTE
+	if r.outbox {
+		query += "," + createdEventsCTE
+	}
+	return query + `
+		SELECT ` + columns + ` FROM tx`
+}
+
+// Create creates a new transaction in the database, generating its ULID
 func (r *PostgresTransactionRepository) Create(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
-	query := `
-		INSERT INTO transactions (user_id, amount, description, is_paid) 
-		VALUES ($1, $2, $3, false) 
-		RETURNING id, user_id, amount, description, is_paid, created_at`
+	query := r.insertQuery(`
+		INSERT INTO transactions (user_id, amount, description, is_paid, source_channel, source_country, created_at, ulid, reference_id) 
+		VALUES ($1, $2, $3, false, NULLIF($4, ''), NULLIF($5, ''), COALESCE($6::timestamp, LOCALTIMESTAMP), $7, NULLIF($8, ''))`,
+		"id, user_id, amount, description, is_paid, created_at")
 
-	err := r.db.QueryRowContext(ctx, query, tx.UserID, tx.Amount, tx.Description).Scan(
+	setULID(tx)
+	channel, country := sourceArgs(tx.Source)
+	err := r.db.QueryRowContext(ctx, query, tx.UserID, tx.Amount, tx.Description, channel, country, createdAtArg(tx.CreatedAt), tx.ULID, tx.ReferenceID).Scan(
 		&tx.ID, &tx.UserID, &tx.Amount, &tx.Description, &tx.IsPaid, &tx.CreatedAt)
+	if isDuplicateReference(err) {
+		return nil, fmt.Errorf("failed to create transaction: %w", domain.ErrDuplicateReference)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
@@ -35,15 +131,178 @@ func (r *PostgresTransactionRepository) Create(ctx context.Context, tx *domain.T
 	return tx, nil
 }
 
-// FindByUserID finds all transactions for a user
-func (r *PostgresTransactionRepository) FindByUserID(ctx context.Context, userID int) ([]domain.Transaction, error) {
-	query := `
-		SELECT id, user_id, amount, description, is_paid, created_at 
-		FROM transactions 
-		WHERE user_id = $1 
-		ORDER BY created_at DESC`
+// CreateMany inserts txs with one multi-row INSERT and fills in each one's
+// ID, ULID, stored Amount and CreatedAt. Either every row is inserted or none
+// is.
+func (r *PostgresTransactionRepository) CreateMany(ctx context.Context, txs []*domain.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	args := make([]interface{}, 0, len(txs)*insertParams)
+	for _, tx := range txs {
+		setULID(tx)
+		channel, country := sourceArgs(tx.Source)
+		args = append(args, tx.UserID, tx.Amount, tx.Description, channel, country, createdAtArg(tx.CreatedAt), tx.ULID, tx.ReferenceID)
+	}
+
+	query := r.insertQuery(multiInsertValues(len(txs)), "id, amount, created_at")
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			// The server rejected the statement, so no row was written
+			return fmt.Errorf("failed to create transactions: %w: %w", errBatchRejected, err)
+		}
+		return fmt.Errorf("failed to create transactions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var inserted []domain.Transaction
+	for rows.Next() {
+		var t domain.Transaction
+		if err := rows.Scan(&t.ID, &t.Amount, &t.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan created transaction: %w", err)
+		}
+		inserted = append(inserted, t)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating created transactions: %w", err)
+	}
+	if len(inserted) != len(txs) {
+		return fmt.Errorf("created %d transactions, expected %d", len(inserted), len(txs))
+	}
+
+	// RETURNING order isn't guaranteed, but one statement draws serial IDs in
+	// VALUES order, so sorting by ID lines the rows up with txs
+	sort.Slice(inserted, func(i, j int) bool { return inserted[i].ID < inserted[j].ID })
+	for i, tx := range txs {
+		tx.ID = inserted[i].ID
+		tx.Amount = inserted[i].Amount
+		tx.CreatedAt = inserted[i].CreatedAt
+		tx.IsPaid = false
+	}
+
+	return nil
+}
+
+// insertParams is the number of query parameters per inserted row
+const insertParams = 8
+
+// isDuplicateReference reports whether err is a violation of the
+// transaction_references primary key, i.e. the reference ID was claimed
+func isDuplicateReference(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation &&
+		pqErr.Constraint == "transaction_references_pkey"
+}
+
+// uniqueViolation is the Postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// setULID gives tx a ULID for its creation time, or now, unless it has one
+func setULID(tx *domain.Transaction) {
+	if tx.ULID != "" {
+		return
+	}
+	at := tx.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	tx.ULID = ulid.Make(at)
+}
+
+// createdAtArg returns the created_at query parameter of a new transaction.
+// A zero time is stored as the database's current time.
+func createdAtArg(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// sourceArgs returns the channel and country query parameters of source;
+// empty strings are stored as NULL
+func sourceArgs(source *domain.EventSource) (channel, country string) {
+	if source == nil {
+		return "", ""
+	}
+	return source.Channel, source.Country
+}
+
+// multiInsertValues builds an INSERT of n rows of (user_id, amount,
+// description, source_channel, source_country, created_at, ulid,
+// reference_id)
+func multiInsertValues(n int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO transactions (user_id, amount, description, is_paid, source_channel, source_country, created_at, ulid, reference_id) VALUES ")
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		p := i * insertParams
+		fmt.Fprintf(&b, "($%d, $%d, $%d, false, NULLIF($%d, ''), NULLIF($%d, ''), COALESCE($%d::timestamp, LOCALTIMESTAMP), $%d, NULLIF($%d, ''))", p+1, p+2, p+3, p+4, p+5, p+6, p+7, p+8)
+	}
+	return b.String()
+}
+
+// transactionColumns maps domain field names to their columns
+var transactionColumns = map[string]string{
+	domain.FieldID:          "id",
+	domain.FieldULID:        "COALESCE(ulid, '')",
+	domain.FieldUserID:      "user_id",
+	domain.FieldAmount:      "amount",
+	domain.FieldDescription: "description",
+	domain.FieldIsPaid:      "is_paid",
+	domain.FieldCreatedAt:   "created_at",
+}
+
+// sortColumns and sortDirections whitelist the ORDER BY options
+var (
+	sortColumns = map[string]string{
+		"":                     "created_at",
+		domain.SortByCreatedAt: "created_at",
+		domain.SortByAmount:    "amount",
+	}
+	sortDirections = map[string]string{
+		"":              "DESC",
+		domain.SortAsc:  "ASC",
+		domain.SortDesc: "DESC",
+	}
+)
+
+// orderByClause builds the ORDER BY clause for opts. id breaks ties so pages
+// are stable when several rows share a sort value.
+func orderByClause(opts domain.ListOptions) (string, error) {
+	column, ok := sortColumns[opts.SortBy]
+	if !ok {
+		return "", fmt.Errorf("unknown sort field %q", opts.SortBy)
+	}
+	direction, ok := sortDirections[opts.Order]
+	if !ok {
+		return "", fmt.Errorf("unknown sort order %q", opts.Order)
+	}
+	return column + " " + direction + ", id " + direction, nil
+}
+
+// FindByUserID finds a user's transactions, sorted and paged by opts.
+// Only the columns for opts.Fields are selected; unselected fields are left zero.
+func (r *PostgresTransactionRepository) FindByUserID(ctx context.Context, userID int, opts domain.ListOptions) ([]domain.Transaction, error) {
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = domain.TransactionFields
+	}
+
+	query, args, err := listQuery(userID, fields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
@@ -56,7 +315,7 @@ func (r *PostgresTransactionRepository) FindByUserID(ctx context.Context, userID
 	var transactions []domain.Transaction
 	for rows.Next() {
 		var t domain.Transaction
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.IsPaid, &t.CreatedAt); err != nil {
+		if err := rows.Scan(scanTargets(&t, fields)...); err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
 		transactions = append(transactions, t)
@@ -69,12 +328,123 @@ func (r *PostgresTransactionRepository) FindByUserID(ctx context.Context, userID
 	return transactions, nil
 }
 
-// GetTotalAmountByUserID returns the total amount of all transactions for a user
-func (r *PostgresTransactionRepository) GetTotalAmountByUserID(ctx context.Context, userID int) (float64, error) {
-	query := "SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = $1"
+// listQuery builds the query listing a user's transactions. Rows after
+// opts.After are selected by comparing (sort column, id) with the cursor, in
+// the same order as ORDER BY, so a page is read straight off an index.
+func listQuery(userID int, fields []string, opts domain.ListOptions) (string, []interface{}, error) {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		column, ok := transactionColumns[field]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown transaction field %q", field)
+		}
+		columns[i] = column
+	}
+
+	orderBy, err := orderByClause(opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	table := "transactions"
+	if opts.IncludeArchived {
+		table = withArchive("id, ulid, user_id, amount, description, is_paid, created_at")
+	}
+
+	where := "user_id = $1"
+	args := []interface{}{userID}
+	if after := opts.After; after != nil {
+		desc := sortDirections[opts.Order] == "DESC"
+		where += " AND " + pagination.KeysetCondition([]string{sortColumns[opts.SortBy], "id"}, desc, 2)
+		var value interface{} = after.CreatedAt.UTC()
+		if opts.SortBy == domain.SortByAmount {
+			value = after.Amount
+		}
+		args = append(args, value, after.ID)
+		// Partition pruning ignores row comparisons, so bound created_at on
+		// its own too and skip the months past the cursor
+		if opts.SortBy != domain.SortByAmount {
+			if desc {
+				where += " AND created_at <= $2"
+			} else {
+				where += " AND created_at >= $2"
+			}
+		}
+	}
+
+	if !opts.CreatedFrom.IsZero() {
+		args = append(args, opts.CreatedFrom.UTC())
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		args = append(args, opts.CreatedBefore.UTC())
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	// Columns and ORDER BY come from whitelists, never from user input
+	query := fmt.Sprintf(`
+		SELECT %s 
+		FROM %s 
+		WHERE %s 
+		ORDER BY %s%s`, strings.Join(columns, ", "), table, where, orderBy, pagination.LimitClause(opts.Limit))
+	return query, args, nil
+}
+
+// withArchive returns a FROM item named transactions holding columns of
+// both live and archived transactions. Postgres pushes WHERE conditions into
+// both halves, so each still reads its own user_id index.
+func withArchive(columns string) string {
+	return "(SELECT " + columns + " FROM transactions UNION ALL SELECT " + columns + " FROM transactions_archive) transactions"
+}
+
+// CountByUserID returns the number of a user's transactions
+func (r *PostgresTransactionRepository) CountByUserID(ctx context.Context, userID int, includeArchived bool) (int64, error) {
+	table := "transactions"
+	if includeArchived {
+		table = withArchive("user_id")
+	}
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE user_id = $1", userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count, nil
+}
+
+// scanTargets returns pointers into t for each field, in order
+func scanTargets(t *domain.Transaction, fields []string) []interface{} {
+	targets := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		case domain.FieldID:
+			targets[i] = &t.ID
+		case domain.FieldULID:
+			targets[i] = &t.ULID
+		case domain.FieldUserID:
+			targets[i] = &t.UserID
+		case domain.FieldAmount:
+			targets[i] = &t.Amount
+		case domain.FieldDescription:
+			targets[i] = &t.Description
+		case domain.FieldIsPaid:
+			targets[i] = &t.IsPaid
+		case domain.FieldCreatedAt:
+			targets[i] = &t.CreatedAt
+		}
+	}
+	return targets
+}
+
+// GetTotalAmountByUserID returns the total amount of a user's transactions
+// created at or after since
+func (r *PostgresTransactionRepository) GetTotalAmountByUserID(ctx context.Context, userID int, since time.Time) (float64, error) {
+	// Archived rows are old, so the archive's index usually rules them out
+	query := "SELECT COALESCE(SUM(amount), 0) FROM " + withArchive("user_id, amount, created_at") +
+		" WHERE user_id = $1 AND created_at >= $2"
 
 	var total float64
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&total)
+	err := r.db.QueryRowContext(ctx, query, userID, since.UTC()).Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total amount: %w", err)
 	}
@@ -82,8 +452,36 @@ func (r *PostgresTransactionRepository) GetTotalAmountByUserID(ctx context.Conte
 	return total, nil
 }
 
+// GetSummaryByUserID aggregates paid and unpaid totals and counts in one query
+func (r *PostgresTransactionRepository) GetSummaryByUserID(ctx context.Context, userID int, periodStart time.Time) (*domain.TransactionSummary, error) {
+	query := `
+		SELECT 
+			COALESCE(SUM(amount) FILTER (WHERE NOT is_paid), 0),
+			COALESCE(SUM(amount) FILTER (WHERE is_paid), 0),
+			COUNT(*) FILTER (WHERE NOT is_paid),
+			COUNT(*) FILTER (WHERE is_paid),
+			COUNT(*),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0)
+		FROM ` + withArchive("user_id, amount, is_paid, created_at") + ` 
+		WHERE user_id = $1`
+
+	var summary domain.TransactionSummary
+	err := r.db.QueryRowContext(ctx, query, userID, periodStart.UTC()).Scan(
+		&summary.UnpaidTotal, &summary.PaidTotal, &summary.UnpaidCount, &summary.PaidCount,
+		&summary.TransactionCount, &summary.PeriodTotal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
 // MarkAllAsPaid marks all unpaid transactions for a user as paid
 func (r *PostgresTransactionRepository) MarkAllAsPaid(ctx context.Context, userID int) (int64, error) {
+	if r.outbox {
+		return r.markAllAsPaidWithEvent(ctx, userID)
+	}
+
 	query := "UPDATE transactions SET is_paid = true WHERE user_id = $1 AND is_paid = false"
 
 	result, err := r.db.ExecContext(ctx, query, userID)
@@ -98,3 +496,306 @@ func (r *PostgresTransactionRepository) MarkAllAsPaid(ctx context.Context, userI
 
 	return rowsAffected, nil
 }
+
+// markAllAsPaidWithEvent is MarkAllAsPaid plus a transaction.paid outbox row,
+// written only when something was paid. The payload matches
+// domain.TransactionPaidEvent at events.TransactionPaidVersion.
+func (r *PostgresTransactionRepository) markAllAsPaidWithEvent(ctx context.Context, userID int) (int64, error) {
+	query := `
+		WITH paid AS (
+			UPDATE transactions SET is_paid = true
+			WHERE user_id = $1::int AND is_paid = false
+			RETURNING id
+		), event AS (
+			INSERT INTO outbox (aggregatetype, aggregateid, type, payload)
+			SELECT 'transaction', $1::int::text, 'transaction.paid', jsonb_build_object(
+				'event_type', 'transaction.paid',
+				'version', 1,
+				'user_id', $1::int,
+				'payment_batch_id', gen_random_uuid()::text,
+				'transactions_paid', n,
+				'timestamp', now())
+			FROM (SELECT count(*) AS n FROM paid) counted
+			WHERE n > 0
+		)
+		SELECT count(*) FROM paid`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to mark transactions as paid: %w", err)
+	}
+	return count, nil
+}
+
+// PayBatch marks all unpaid transactions for a user as paid and returns them.
+// With an outbox it also writes a transaction.paid row carrying batchID.
+func (r *PostgresTransactionRepository) PayBatch(ctx context.Context, userID int, batchID string) ([]domain.Transaction, error) {
+	query := `
+		UPDATE transactions SET is_paid = true
+		WHERE user_id = $1 AND is_paid = false
+		RETURNING id, user_id, amount, description, is_paid, created_at`
+	args := []any{userID}
+	if r.outbox {
+		query = `
+		WITH paid AS (
+			UPDATE transactions SET is_paid = true
+			WHERE user_id = $1::int AND is_paid = false
+			RETURNING id, user_id, amount, description, is_paid, created_at
+		), event AS (
+			INSERT INTO outbox (aggregatetype, aggregateid, type, payload)
+			SELECT 'transaction', $1::int::text, 'transaction.paid', jsonb_build_object(
+				'event_type', 'transaction.paid',
+				'version', 1,
+				'user_id', $1::int,
+				'payment_batch_id', $2::text,
+				'transactions_paid', n,
+				'timestamp', now())
+			FROM (SELECT count(*) AS n FROM paid) counted
+			WHERE n > 0
+		)
+		SELECT id, user_id, amount, description, is_paid, created_at FROM paid`
+		args = append(args, batchID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark transactions as paid: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	var paid []domain.Transaction
+	for rows.Next() {
+		var t domain.Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.IsPaid, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		paid = append(paid, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+	return paid, nil
+}
+
+// RevertBatch marks the user's transactions ids unpaid again. With an outbox
+// it also writes a transaction.payment_reverted row carrying batchID; the
+// payload matches domain.PaymentRevertedEvent.
+func (r *PostgresTransactionRepository) RevertBatch(ctx context.Context, userID int, batchID string, ids []int) (int64, error) {
+	query := `
+		WITH reverted AS (
+			UPDATE transactions SET is_paid = false
+			WHERE user_id = $1::int AND id = ANY($2) AND is_paid = true
+			RETURNING id
+		)
+		SELECT count(*) FROM reverted`
+	args := []any{userID, pq.Array(ids)}
+	if r.outbox {
+		query = `
+		WITH reverted AS (
+			UPDATE transactions SET is_paid = false
+			WHERE user_id = $1::int AND id = ANY($2) AND is_paid = true
+			RETURNING id
+		), event AS (
+			INSERT INTO outbox (aggregatetype, aggregateid, type, payload)
+			SELECT 'transaction', $1::int::text, 'transaction.payment_reverted', jsonb_build_object(
+				'event_type', 'transaction.payment_reverted',
+				'version', 1,
+				'user_id', $1::int,
+				'payment_batch_id', $3::text,
+				'transactions_reverted', n,
+				'timestamp', now())
+			FROM (SELECT count(*) AS n FROM reverted) counted
+			WHERE n > 0
+		)
+		SELECT count(*) FROM reverted`
+		args = append(args, batchID)
+	}
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to revert paid transactions: %w", err)
+	}
+	return count, nil
+}
+
+// AttachReceipt replaces the receipt columns of the user's transaction. The
+// row is locked while the old key is read so concurrent uploads each learn
+// which key they replaced.
+func (r *PostgresTransactionRepository) AttachReceipt(ctx context.Context, userID, transactionID int, receipt *domain.Receipt) (string, bool, error) {
+	query := `
+		UPDATE transactions t
+		SET receipt_key = $3, receipt_content_type = $4, receipt_size = $5,
+			receipt_filename = $6, receipt_uploaded_at = $7
+		FROM (SELECT id, receipt_key FROM transactions WHERE id = $1 AND user_id = $2 FOR UPDATE) old
+		WHERE t.id = old.id
+		RETURNING COALESCE(old.receipt_key, '')`
+
+	var replacedKey string
+	err := r.db.QueryRowContext(ctx, query, transactionID, userID,
+		receipt.Key, receipt.ContentType, receipt.Size, receipt.Filename, receipt.UploadedAt.UTC()).Scan(&replacedKey)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to attach receipt: %w", err)
+	}
+
+	return replacedKey, true, nil
+}
+
+// FindReceipt returns the receipt columns of the user's transaction
+func (r *PostgresTransactionRepository) FindReceipt(ctx context.Context, userID, transactionID int) (*domain.Receipt, error) {
+	query := `
+		SELECT receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at
+		FROM ` + withArchive("id, user_id, receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at") + `
+		WHERE id = $1 AND user_id = $2`
+
+	var (
+		key, contentType, filename sql.NullString
+		size                       sql.NullInt64
+		uploadedAt                 sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, query, transactionID, userID).Scan(&key, &contentType, &size, &filename, &uploadedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Transaction not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find receipt: %w", err)
+	}
+	if !key.Valid {
+		return nil, nil // No receipt attached
+	}
+
+	return &domain.Receipt{
+		Key:         key.String,
+		ContentType: contentType.String,
+		Size:        size.Int64,
+		Filename:    filename.String,
+		UploadedAt:  uploadedAt.Time,
+	}, nil
+}
+
+// FindIDByULID returns the ID of the user's transaction with the given ULID
+func (r *PostgresTransactionRepository) FindIDByULID(ctx context.Context, userID int, txULID string) (int, error) {
+	query := "SELECT id FROM " + withArchive("id, user_id, ulid") + " WHERE user_id = $1 AND ulid = $2"
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, userID, txULID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find transaction: %w", err)
+	}
+	return id, nil
+}
+
+// ForEachCreatedBetween streams the transactions created in [from, to) so an
+// export never holds a whole day in memory
+func (r *PostgresTransactionRepository) ForEachCreatedBetween(ctx context.Context, from, to time.Time, fn func(*domain.Transaction) error) error {
+	query := `
+		SELECT id, user_id, amount, description, is_paid, created_at
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var t domain.Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.IsPaid, &t.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transactions: %w", err)
+	}
+	return nil
+}
+
+// ForEachCreatedBefore streams every transaction created before before,
+// archived ones included, in ID order
+func (r *PostgresTransactionRepository) ForEachCreatedBefore(ctx context.Context, before time.Time, fn func(*domain.Transaction) error) error {
+	query := `
+		SELECT id, COALESCE(ulid, ''), user_id, amount, description, is_paid, created_at
+		FROM ` + withArchive("id, ulid, user_id, amount, description, is_paid, created_at") + `
+		WHERE created_at < $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, before.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var t domain.Transaction
+		if err := rows.Scan(&t.ID, &t.ULID, &t.UserID, &t.Amount, &t.Description, &t.IsPaid, &t.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transactions: %w", err)
+	}
+	return nil
+}
+
+// archivedColumns are the transactions columns copied to the archive
+const archivedColumns = `id, user_id, amount, description, is_paid, created_at, reference_id,
+			receipt_key, receipt_content_type, receipt_size, receipt_filename, receipt_uploaded_at,
+			source_channel, source_country, ulid`
+
+// ArchivePaid moves the oldest paid transactions created before before to
+// transactions_archive in one statement, so a row is never in both tables or
+// neither. Rows locked by other writers are skipped until the next run.
+func (r *PostgresTransactionRepository) ArchivePaid(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		WITH moved AS (
+			DELETE FROM transactions
+			WHERE id IN (
+				SELECT id FROM transactions
+				WHERE is_paid AND created_at < $1
+				ORDER BY created_at
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED)
+			RETURNING ` + archivedColumns + `
+		)
+		INSERT INTO transactions_archive (` + archivedColumns + `)
+		SELECT ` + archivedColumns + ` FROM moved`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive transactions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+)
+
+func TestListQuery_Keyset(t *testing.T) {
+	after := &domain.TransactionCursor{Amount: 12.5, CreatedAt: time.Now(), ID: 9}
+	query, args, err := listQuery(1, []string{domain.FieldID}, domain.ListOptions{
+		SortBy: domain.SortByAmount, Order: domain.SortAsc, After: after, Limit: 21,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"(amount, id) > ($2, $3)", "ORDER BY amount ASC, id ASC LIMIT 21"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in query:\n%s", want, query)
+		}
+	}
+	if len(args) != 3 || args[1] != 12.5 || args[2] != 9 {
+		t.Errorf("expected the amount and ID as keyset arguments, got %v", args)
+	}
+
+	query, args, _ = listQuery(1, []string{domain.FieldID}, domain.ListOptions{})
+	if strings.Contains(query, "LIMIT") || strings.Contains(query, "$2") || len(args) != 1 {
+		t.Errorf("expected an unpaged query, got %v:\n%s", args, query)
+	}
+}
+
+func TestListQuery_IncludeArchived(t *testing.T) {
+	query, _, err := listQuery(1, []string{domain.FieldID}, domain.ListOptions{IncludeArchived: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "UNION ALL SELECT id, ulid, user_id, amount, description, is_paid, created_at FROM transactions_archive") {
+		t.Errorf("expected the archive in the query:\n%s", query)
+	}
+
+	query, _, _ = listQuery(1, []string{domain.FieldID}, domain.ListOptions{})
+	if strings.Contains(query, "transactions_archive") {
+		t.Errorf("expected only live transactions by default:\n%s", query)
+	}
+}
+
+func TestListQuery_PrunesPartitions(t *testing.T) {
+	after := &domain.TransactionCursor{CreatedAt: time.Now(), ID: 9}
+	query, _, _ := listQuery(1, []string{domain.FieldID}, domain.ListOptions{After: after})
+	if !strings.Contains(query, "created_at <= $2") {
+		t.Errorf("expected created_at bounded on its own for partition pruning:\n%s", query)
+	}
+}
+
+func TestListQuery_CreatedBetween(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	after := &domain.TransactionCursor{CreatedAt: from, ID: 9}
+	query, args, _ := listQuery(1, []string{domain.FieldID}, domain.ListOptions{
+		After: after, CreatedFrom: from, CreatedBefore: from.AddDate(0, 1, 0),
+	})
+	for _, want := range []string{"created_at >= $4", "created_at < $5"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in query:\n%s", want, query)
+		}
+	}
+	if len(args) != 5 || args[3] != from {
+		t.Errorf("expected the bounds after the keyset arguments, got %v", args)
+	}
+}
+
+func TestPartitionName(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	// 2024-03-01 02:00 in Bangkok is still February in UTC
+	month := monthStart(time.Date(2024, 3, 1, 2, 0, 0, 0, bangkok))
+	if got := partitionName(month); got != "transactions_p202402" {
+		t.Errorf("expected transactions_p202402, got %s", got)
+	}
+}
+
+func TestArchivePartitionQuery(t *testing.T) {
+	query := archivePartitionQuery("transactions_p202401")
+	for _, want := range []string{
+		`DELETE FROM "transactions_p202401"
+				WHERE is_paid`,
+		"INSERT INTO transactions_archive (" + archivedColumns + ")",
+		`IF NOT EXISTS (SELECT 1 FROM "transactions_p202401") THEN
+				DROP TABLE "transactions_p202401";`,
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in query:\n%s", want, query)
+		}
+	}
+}
+
+func TestIsDuplicateReference(t *testing.T) {
+	dup := &pq.Error{Code: uniqueViolation, Constraint: "transaction_references_pkey"}
+	if !isDuplicateReference(fmt.Errorf("insert: %w", dup)) {
+		t.Error("expected a claimed reference to be a duplicate reference")
+	}
+	if isDuplicateReference(&pq.Error{Code: uniqueViolation, Constraint: "transactions_pkey"}) {
+		t.Error("expected another unique index's violation not to be a duplicate reference")
+	}
+	if isDuplicateReference(errors.New("connection reset")) {
+		t.Error("expected a non-Postgres error not to be a duplicate reference")
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+)
+
+// limitOp is one generated call against the service
+type limitOp struct {
+	pay    bool
+	userID int
+	amount float64
+}
+
+func drawOps(t *rapid.T) []limitOp {
+	return rapid.SliceOfN(rapid.Custom(func(t *rapid.T) limitOp {
+		return limitOp{
+			pay:    rapid.IntRange(0, 4).Draw(t, "kind") == 0,
+			userID: rapid.IntRange(1, 3).Draw(t, "user"),
+			// Whole cents keep the float totals exact enough to compare
+			amount: float64(rapid.IntRange(1, 60000).Draw(t, "cents")) / 100,
+		}
+	}), 1, 60).Draw(t, "ops")
+}
+
+// newLifetimeService uses the lifetime period so results don't depend on when
+// the test runs
+func newLifetimeService() (*PaymentService, *testutil.FakeTransactionRepository) {
+	repo := testutil.NewFakeTransactionRepository()
+	return NewPaymentService(repo, nil, WithLimitPeriod(PeriodLifetime, time.UTC)), repo
+}
+
+// checkLimitInvariant fails if any user's total, or unpaid total, is over the limit
+func checkLimitInvariant(t *rapid.T, repo *testutil.FakeTransactionRepository) {
+	totals := make(map[int]float64)
+	unpaid := make(map[int]float64)
+	for _, tx := range repo.Transactions() {
+		totals[tx.UserID] += tx.Amount
+		if !tx.IsPaid {
+			unpaid[tx.UserID] += tx.Amount
+		}
+	}
+	for userID, total := range totals {
+		if total > MaxTransactionTotal {
+			t.Fatalf("user %d total %.2f exceeds limit %.2f", userID, total, MaxTransactionTotal)
+		}
+		if unpaid[userID] > MaxTransactionTotal {
+			t.Fatalf("user %d unpaid total %.2f exceeds limit %.2f", userID, unpaid[userID], MaxTransactionTotal)
+		}
+	}
+}
+
+// TestLimitProperty_Sequential compares the service with a model of the limit:
+// a create succeeds exactly when it keeps the user's total within the limit
+func TestLimitProperty_Sequential(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		svc, repo := newLifetimeService()
+		ctx := context.Background()
+		model := make(map[int]float64)
+
+		for _, op := range drawOps(t) {
+			if op.pay {
+				if _, err := svc.PayAllTransactions(ctx, op.userID); err != nil {
+					t.Fatalf("pay failed: %v", err)
+				}
+				continue
+			}
+
+			result, err := svc.CreateTransaction(ctx, &domain.CreateTransactionRequest{UserID: op.userID, Amount: op.amount})
+			fits := model[op.userID]+op.amount <= MaxTransactionTotal
+			switch {
+			case fits && err != nil:
+				t.Fatalf("create of %.2f with total %.2f failed: %v", op.amount, model[op.userID], err)
+			case !fits && !errors.Is(err, ErrExceedsMaximum):
+				t.Fatalf("create of %.2f with total %.2f: expected ErrExceedsMaximum, got %v", op.amount, model[op.userID], err)
+			case fits:
+				model[op.userID] += op.amount
+				if result.CurrentTotal != model[op.userID] || result.RemainingLimit < 0 {
+					t.Fatalf("unexpected headroom %+v for model total %.2f", result, model[op.userID])
+				}
+			}
+		}
+
+		checkLimitInvariant(t, repo)
+	})
+}
+
+// TestLimitProperty_Concurrent runs the generated operations from several
+// goroutines at once; the per-user lock must keep every total within the limit
+func TestLimitProperty_Concurrent(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		svc, repo := newLifetimeService()
+		repo.Latency = 50 * time.Microsecond
+		ops := drawOps(t)
+		workers := rapid.IntRange(2, 8).Draw(t, "workers")
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(ops))
+		for w := range workers {
+			wg.Go(func() {
+				ctx := context.Background()
+				for i := w; i < len(ops); i += workers {
+					op := ops[i]
+					var err error
+					if op.pay {
+						_, err = svc.PayAllTransactions(ctx, op.userID)
+					} else {
+						_, err = svc.CreateTransaction(ctx, &domain.CreateTransactionRequest{UserID: op.userID, Amount: op.amount})
+						if errors.Is(err, ErrExceedsMaximum) {
+							err = nil
+						}
+					}
+					if err != nil {
+						errs <- err
+					}
+				}
+			})
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkLimitInvariant(t, repo)
+	})
+}
@@ -1,38 +1,230 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
+	"github.com/tkaewplik/go-microservices/pkg/ulid"
 )
 
 const MaxTransactionTotal = 1000.0
 
+// LimitWarningThresholds are the fractions of MaxTransactionTotal at which a
+// user is warned that they are approaching the limit
+var LimitWarningThresholds = []float64{0.8, 0.95}
+
+// MaxReferenceIDLength caps a transaction's client-supplied reference ID
+const MaxReferenceIDLength = 128
+
+// userUnlockTimeout bounds releasing a user's shared lock; a lock that
+// can't be released expires after its TTL
+const userUnlockTimeout = 5 * time.Second
+
 // Common errors
 var (
-	ErrInvalidAmount  = errors.New("amount must be positive")
-	ErrExceedsMaximum = errors.New("total amount exceeds maximum")
-	ErrInvalidUserID  = errors.New("invalid user ID")
+	ErrInvalidAmount   = errors.New("amount must be positive")
+	ErrExceedsMaximum  = errors.New("total amount exceeds maximum")
+	ErrInvalidUserID   = errors.New("invalid user ID")
+	ErrInvalidField    = errors.New("invalid transaction field")
+	ErrInvalidSort     = errors.New("invalid sort option")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+
+	ErrInvalidReferenceID = errors.New("invalid reference_id")
+	// ErrDuplicateReference is returned when the user already has a
+	// transaction with the reference ID being created
+	ErrDuplicateReference = domain.ErrDuplicateReference
+
+	ErrInvalidTransactionID = errors.New("invalid transaction ID")
+	ErrInvalidReceipt       = errors.New("invalid receipt")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrReceiptNotFound      = errors.New("receipt not found")
+
+	// ErrChargeFailed is returned when the payment provider refused a
+	// charge; the transactions were left unpaid
+	ErrChargeFailed = errors.New("charge failed")
 )
 
+// LimitExceededError is returned when a transaction would push the user's
+// total for the current period over MaxTransactionTotal. It matches
+// ErrExceedsMaximum with errors.Is.
+type LimitExceededError struct {
+	CurrentTotal float64
+	Requested    float64
+	MaxAllowed   float64
+	// ResetsAt is when the period ends; zero for the lifetime period
+	ResetsAt time.Time
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%v: current total %.2f, requested %.2f, max %.2f",
+		ErrExceedsMaximum, e.CurrentTotal, e.Requested, e.MaxAllowed)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrExceedsMaximum
+}
+
+// RemainingLimit returns how much the user can still spend
+func (e *LimitExceededError) RemainingLimit() float64 {
+	return max(e.MaxAllowed-e.CurrentTotal, 0)
+}
+
 // PaymentService handles payment business logic
 type PaymentService struct {
 	txRepo    domain.TransactionRepository
 	publisher domain.EventPublisher
+	period    LimitPeriod
+	location  *time.Location
+	clock     clock.Clock
+	// provider and payer run the pay saga, when set
+	provider domain.PaymentProvider
+	payer    domain.TransactionBatchPayer
+	// userLocks holds a lock per user ID so the limit check and insert of
+	// one user's transactions can't interleave within this process. Entries
+	// are removed when their last holder or waiter unlocks.
+	userLocksMu sync.Mutex
+	userLocks   map[int]*userLock
+	// userLocker, when set, extends that to every instance
+	userLocker *lock.Locker
+}
+
+// Option configures a PaymentService
+type Option func(*PaymentService)
+
+// WithLimitPeriod applies MaxTransactionTotal per period, with period
+// boundaries in loc unless a request names its own timezone
+func WithLimitPeriod(period LimitPeriod, loc *time.Location) Option {
+	return func(s *PaymentService) {
+		s.period = period
+		s.location = loc
+	}
 }
 
-// NewPaymentService creates a new PaymentService
-func NewPaymentService(txRepo domain.TransactionRepository, publisher domain.EventPublisher) *PaymentService {
-	return &PaymentService{
+// WithPaymentProvider charges users through provider when they pay. Paying
+// becomes a saga: payer marks the transactions paid, provider charges for
+// them, and when the charge fails payer marks them unpaid again and a
+// transaction.payment_reverted event compensates for the transaction.paid one.
+func WithPaymentProvider(provider domain.PaymentProvider, payer domain.TransactionBatchPayer) Option {
+	return func(s *PaymentService) {
+		s.provider = provider
+		s.payer = payer
+	}
+}
+
+// WithUserLocker also takes a lock from locker for each user while their
+// limit is checked, so instances sharing the database can't together let a
+// user past the limit
+func WithUserLocker(locker *lock.Locker) Option {
+	return func(s *PaymentService) {
+		s.userLocker = locker
+	}
+}
+
+// WithClock sets the clock limit periods, created_at and receipt times are
+// read from (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(s *PaymentService) {
+		s.clock = c
+	}
+}
+
+// NewPaymentService creates a new PaymentService.
+// The limit resets every calendar month in UTC unless configured otherwise.
+func NewPaymentService(txRepo domain.TransactionRepository, publisher domain.EventPublisher, opts ...Option) *PaymentService {
+	s := &PaymentService{
 		txRepo:    txRepo,
 		publisher: publisher,
+		period:    PeriodMonth,
+		location:  time.UTC,
+		clock:     clock.Real,
+		userLocks: make(map[int]*userLock),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// currentPeriod returns the bounds of the limit period containing now, in the
+// named IANA timezone or the service default when empty
+func (s *PaymentService) currentPeriod(timezone string) (start, end time.Time, err error) {
+	loc := s.location
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+		}
+	}
+	start, end = s.period.Bounds(s.clock.Now(), loc)
+	return start, end, nil
+}
+
+// lockUser serializes limit checks for userID and returns the unlock func.
+// With a user locker the returned context is cancelled if the shared lock is
+// lost before unlocking.
+func (s *PaymentService) lockUser(ctx context.Context, userID int) (context.Context, func(), error) {
+	unlockLocal := s.lockUserLocal(userID)
+	if s.userLocker == nil {
+		return ctx, unlockLocal, nil
+	}
+
+	lease, err := s.userLocker.Acquire(ctx, fmt.Sprintf("payment-service/user/%d", userID))
+	if err != nil {
+		unlockLocal()
+		return nil, nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+	leaseCtx, cancel := lease.Context(ctx)
+	return leaseCtx, func() {
+		cancel()
+		releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), userUnlockTimeout)
+		_ = lease.Release(releaseCtx)
+		cancelRelease()
+		unlockLocal()
+	}, nil
+}
+
+// userLock is a user's in-process lock, counting the calls holding or
+// waiting for it
+type userLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockUserLocal takes userID's in-process lock and returns its unlock func
+func (s *PaymentService) lockUserLocal(userID int) func() {
+	s.userLocksMu.Lock()
+	l, ok := s.userLocks[userID]
+	if !ok {
+		l = &userLock{}
+		s.userLocks[userID] = l
+	}
+	l.refs++
+	s.userLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.userLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.userLocks, userID)
+		}
+		s.userLocksMu.Unlock()
 	}
 }
 
-// CreateTransaction creates a new transaction with validation
-func (s *PaymentService) CreateTransaction(ctx context.Context, req *domain.CreateTransactionRequest) (*domain.Transaction, error) {
+// CreateTransaction creates a new transaction with validation and returns it
+// with the user's total and remaining limit
+func (s *PaymentService) CreateTransaction(ctx context.Context, req *domain.CreateTransactionRequest) (*domain.CreateTransactionResult, error) {
 	// Validate amount
 	if req.Amount <= 0 {
 		return nil, ErrInvalidAmount
@@ -42,15 +234,34 @@ func (s *PaymentService) CreateTransaction(ctx context.Context, req *domain.Crea
 		return nil, ErrInvalidUserID
 	}
 
-	// Check if total amount exceeds maximum
-	currentTotal, err := s.txRepo.GetTotalAmountByUserID(ctx, req.UserID)
+	if len(req.ReferenceID) > MaxReferenceIDLength {
+		return nil, ErrInvalidReferenceID
+	}
+
+	periodStart, periodEnd, err := s.currentPeriod(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, unlock, err := s.lockUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Check if the total for the current period exceeds maximum
+	currentTotal, err := s.txRepo.GetTotalAmountByUserID(ctx, req.UserID, periodStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current total: %w", err)
 	}
 
 	if currentTotal+req.Amount > MaxTransactionTotal {
-		return nil, fmt.Errorf("%w: current total %.2f, requested %.2f, max %.2f",
-			ErrExceedsMaximum, currentTotal, req.Amount, MaxTransactionTotal)
+		return nil, &LimitExceededError{
+			CurrentTotal: currentTotal,
+			Requested:    req.Amount,
+			MaxAllowed:   MaxTransactionTotal,
+			ResetsAt:     periodEnd,
+		}
 	}
 
 	// Create transaction
@@ -58,6 +269,9 @@ func (s *PaymentService) CreateTransaction(ctx context.Context, req *domain.Crea
 		UserID:      req.UserID,
 		Amount:      req.Amount,
 		Description: req.Description,
+		CreatedAt:   s.clock.Now().UTC(),
+		ReferenceID: req.ReferenceID,
+		Source:      req.Source,
 	}
 
 	createdTx, err := s.txRepo.Create(ctx, tx)
@@ -65,42 +279,174 @@ func (s *PaymentService) CreateTransaction(ctx context.Context, req *domain.Crea
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	// Publish event to Kafka (non-blocking, log errors but don't fail the request)
+	// Publish event to Kafka (non-blocking, log errors but don't fail the
+	// request). The event outlives the call but continues its trace.
 	if s.publisher != nil {
+		publishCtx := context.WithoutCancel(ctx)
 		go func() {
 			event := &domain.TransactionCreatedEvent{
-				TransactionID: createdTx.ID,
-				UserID:        createdTx.UserID,
-				Amount:        createdTx.Amount,
-				Description:   createdTx.Description,
+				TransactionID:   createdTx.ID,
+				UserID:          createdTx.UserID,
+				Amount:          createdTx.Amount,
+				Description:     createdTx.Description,
+				Source:          req.Source,
+				Timestamp:       createdTx.CreatedAt,
+				TransactionULID: createdTx.ULID,
 			}
-			if err := s.publisher.PublishTransactionCreated(context.Background(), event); err != nil {
+			if err := s.publisher.PublishTransactionCreated(publishCtx, event); err != nil {
 				// Log error but don't fail the transaction
 				fmt.Printf("failed to publish transaction.created event: %v\n", err)
 			}
 		}()
 	}
 
-	return createdTx, nil
+	newTotal := currentTotal + createdTx.Amount
+	s.warnApproachingLimit(ctx, createdTx, currentTotal, newTotal, periodStart, periodEnd)
+
+	return &domain.CreateTransactionResult{
+		Transaction:    createdTx,
+		CurrentTotal:   newTotal,
+		RemainingLimit: max(MaxTransactionTotal-newTotal, 0),
+	}, nil
 }
 
-// GetTransactions returns all transactions for a user
-func (s *PaymentService) GetTransactions(ctx context.Context, userID int) ([]domain.Transaction, error) {
+// warnApproachingLimit publishes a limit.approaching event for each warning
+// threshold tx took the user's period total from below to at or above. The
+// total only grows within a period and the caller holds the user's lock, so
+// each threshold is crossed once per period.
+func (s *PaymentService) warnApproachingLimit(ctx context.Context, tx *domain.Transaction, before, after float64, periodStart, periodEnd time.Time) {
+	if s.publisher == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, threshold := range LimitWarningThresholds {
+		limit := threshold * MaxTransactionTotal
+		if before >= limit || after < limit {
+			continue
+		}
+		event := &domain.LimitApproachingEvent{
+			UserID:         tx.UserID,
+			Threshold:      threshold,
+			CurrentTotal:   after,
+			MaxAllowed:     MaxTransactionTotal,
+			RemainingLimit: max(MaxTransactionTotal-after, 0),
+			PeriodStart:    periodStart,
+			ResetsAt:       periodEnd,
+			TransactionID:  tx.ID,
+			Timestamp:      tx.CreatedAt,
+		}
+		// Like transaction.created, a failed warning doesn't fail the
+		// transaction
+		go func() {
+			if err := s.publisher.PublishLimitApproaching(ctx, event); err != nil {
+				fmt.Printf("failed to publish limit.approaching event: %v\n", err)
+			}
+		}()
+	}
+}
+
+// limitWarnings returns the warning thresholds total has reached
+func limitWarnings(total float64) []float64 {
+	var reached []float64
+	for _, threshold := range LimitWarningThresholds {
+		if total >= threshold*MaxTransactionTotal {
+			reached = append(reached, threshold)
+		}
+	}
+	return reached
+}
+
+// GetTransactions returns a page of a user's transactions; an unpaged
+// request returns every transaction
+func (s *PaymentService) GetTransactions(ctx context.Context, userID int, opts domain.ListOptions, page pagination.PageRequest) (pagination.PageResponse[domain.Transaction], error) {
+	var none pagination.PageResponse[domain.Transaction]
 	if userID <= 0 {
-		return nil, ErrInvalidUserID
+		return none, ErrInvalidUserID
+	}
+	for _, field := range opts.Fields {
+		if !slices.Contains(domain.TransactionFields, field) {
+			return none, fmt.Errorf("%w: %s", ErrInvalidField, field)
+		}
+	}
+	switch opts.SortBy {
+	case "", domain.SortByCreatedAt, domain.SortByAmount:
+	default:
+		return none, fmt.Errorf("%w: sort_by %s", ErrInvalidSort, opts.SortBy)
+	}
+	switch opts.Order {
+	case "", domain.SortAsc, domain.SortDesc:
+	default:
+		return none, fmt.Errorf("%w: order %s", ErrInvalidSort, opts.Order)
+	}
+	opts.SortBy = cmp.Or(opts.SortBy, domain.SortByCreatedAt)
+	opts.Order = cmp.Or(opts.Order, domain.SortDesc)
+
+	page = page.Normalize(0)
+	if page.Cursor != "" {
+		var after domain.TransactionCursor
+		if err := pagination.DecodeCursor(page.Cursor, &after); err != nil {
+			return none, err
+		}
+		// A cursor only means something in the order it was issued for
+		if after.SortBy != opts.SortBy || after.Order != opts.Order {
+			return none, pagination.ErrInvalidCursor
+		}
+		opts.After = &after
 	}
+	opts.Limit = page.FetchLimit()
 
-	transactions, err := s.txRepo.FindByUserID(ctx, userID)
+	// The next cursor needs the sort field and ID of the last transaction,
+	// even when the field mask leaves them out
+	var added []string
+	if page.Paged() && len(opts.Fields) > 0 {
+		sortField := domain.FieldCreatedAt
+		if opts.SortBy == domain.SortByAmount {
+			sortField = domain.FieldAmount
+		}
+		for _, field := range []string{domain.FieldID, sortField} {
+			if !slices.Contains(opts.Fields, field) {
+				added = append(added, field)
+			}
+		}
+		opts.Fields = append(slices.Clip(opts.Fields), added...)
+	}
+
+	transactions, err := s.txRepo.FindByUserID(ctx, userID, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+		return none, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
-	// Return empty slice instead of nil
-	if transactions == nil {
-		transactions = []domain.Transaction{}
+	total := int64(len(transactions))
+	if page.Paged() || opts.After != nil {
+		if total, err = s.txRepo.CountByUserID(ctx, userID, opts.IncludeArchived); err != nil {
+			return none, fmt.Errorf("failed to count transactions: %w", err)
+		}
 	}
 
-	return transactions, nil
+	result, err := pagination.NewPage(transactions, page, total, func(tx domain.Transaction) any {
+		return domain.NewTransactionCursor(tx, opts)
+	})
+	if err != nil {
+		return none, err
+	}
+	for i := range result.Items {
+		clearFields(&result.Items[i], added)
+	}
+	return result, nil
+}
+
+// clearFields zeroes fields of tx that were read only to build a cursor
+func clearFields(tx *domain.Transaction, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case domain.FieldID:
+			tx.ID = 0
+		case domain.FieldAmount:
+			tx.Amount = 0
+		case domain.FieldCreatedAt:
+			tx.CreatedAt = time.Time{}
+		}
+	}
 }
 
 // PayAllTransactions marks all unpaid transactions for a user as paid
@@ -109,6 +455,10 @@ func (s *PaymentService) PayAllTransactions(ctx context.Context, userID int) (in
 		return 0, ErrInvalidUserID
 	}
 
+	if s.provider != nil {
+		return s.payAndCharge(ctx, userID)
+	}
+
 	rowsAffected, err := s.txRepo.MarkAllAsPaid(ctx, userID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to pay transactions: %w", err)
@@ -116,25 +466,193 @@ func (s *PaymentService) PayAllTransactions(ctx context.Context, userID int) (in
 
 	// Publish event to Kafka (non-blocking)
 	if s.publisher != nil && rowsAffected > 0 {
+		go s.publishPaid(context.WithoutCancel(ctx), userID, rand.Text(), rowsAffected)
+	}
+
+	return rowsAffected, nil
+}
+
+// payAndCharge is the pay saga: the transactions are marked paid, then
+// charged for, and marked unpaid again when the charge fails
+func (s *PaymentService) payAndCharge(ctx context.Context, userID int) (int64, error) {
+	batchID := rand.Text()
+	paid, err := s.payer.PayBatch(ctx, userID, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pay transactions: %w", err)
+	}
+	if len(paid) == 0 {
+		return 0, nil
+	}
+
+	charge := &domain.Charge{BatchID: batchID, UserID: userID}
+	for _, tx := range paid {
+		charge.Amount += tx.Amount
+		charge.TransactionIDs = append(charge.TransactionIDs, tx.ID)
+	}
+	chargeErr := s.provider.Charge(ctx, charge)
+	if chargeErr == nil {
+		if s.publisher != nil {
+			go s.publishPaid(context.WithoutCancel(ctx), userID, batchID, int64(len(paid)))
+		}
+		return int64(len(paid)), nil
+	}
+
+	// Compensate even when the caller has gone, or the transactions would
+	// stay paid without a charge
+	reverted, err := s.payer.RevertBatch(context.WithoutCancel(ctx), userID, batchID, charge.TransactionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revert payment after charge failed (%v): %w", chargeErr, err)
+	}
+	if s.publisher != nil {
+		// Consumers see the batch paid and then reverted, as an outbox would
+		// record it
+		publishCtx := context.WithoutCancel(ctx)
 		go func() {
-			event := &domain.TransactionPaidEvent{
-				UserID:           userID,
-				TransactionsPaid: rowsAffected,
-			}
-			if err := s.publisher.PublishTransactionPaid(context.Background(), event); err != nil {
-				fmt.Printf("failed to publish transaction.paid event: %v\n", err)
-			}
+			s.publishPaid(publishCtx, userID, batchID, int64(len(paid)))
+			s.publishReverted(publishCtx, userID, batchID, reverted)
 		}()
 	}
+	return 0, fmt.Errorf("%w: %v", ErrChargeFailed, chargeErr)
+}
 
-	return rowsAffected, nil
+func (s *PaymentService) publishPaid(ctx context.Context, userID int, batchID string, count int64) {
+	event := &domain.TransactionPaidEvent{
+		UserID:           userID,
+		PaymentBatchID:   batchID,
+		TransactionsPaid: count,
+		Timestamp:        s.clock.Now(),
+	}
+	if err := s.publisher.PublishTransactionPaid(ctx, event); err != nil {
+		fmt.Printf("failed to publish transaction.paid event: %v\n", err)
+	}
+}
+
+func (s *PaymentService) publishReverted(ctx context.Context, userID int, batchID string, count int64) {
+	if count == 0 {
+		return
+	}
+	event := &domain.PaymentRevertedEvent{
+		UserID:               userID,
+		PaymentBatchID:       batchID,
+		TransactionsReverted: count,
+		Timestamp:            s.clock.Now(),
+	}
+	if err := s.publisher.PublishPaymentReverted(ctx, event); err != nil {
+		fmt.Printf("failed to publish transaction.payment_reverted event: %v\n", err)
+	}
 }
 
-// GetCurrentTotal returns the current total amount for a user
-func (s *PaymentService) GetCurrentTotal(ctx context.Context, userID int) (float64, error) {
+// GetCurrentTotal returns a user's total for the current limit period
+func (s *PaymentService) GetCurrentTotal(ctx context.Context, userID int, timezone string) (float64, error) {
 	if userID <= 0 {
 		return 0, ErrInvalidUserID
 	}
 
-	return s.txRepo.GetTotalAmountByUserID(ctx, userID)
+	periodStart, _, err := s.currentPeriod(timezone)
+	if err != nil {
+		return 0, err
+	}
+	return s.txRepo.GetTotalAmountByUserID(ctx, userID, periodStart)
+}
+
+// GetSummary returns a user's paid and unpaid totals, counts, the amount
+// left before the limit for the current period is reached and the limit
+// warnings reached
+func (s *PaymentService) GetSummary(ctx context.Context, userID int, timezone string) (*domain.TransactionSummary, error) {
+	if userID <= 0 {
+		return nil, ErrInvalidUserID
+	}
+
+	periodStart, periodEnd, err := s.currentPeriod(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.txRepo.GetSummaryByUserID(ctx, userID, periodStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+
+	summary.RemainingLimit = max(MaxTransactionTotal-summary.PeriodTotal, 0)
+	summary.MaxAllowed = MaxTransactionTotal
+	summary.ResetsAt = periodEnd
+	summary.LimitWarnings = limitWarnings(summary.PeriodTotal)
+	return summary, nil
+}
+
+// AttachReceipt records receipt as the receipt of one of the user's
+// transactions and returns the key of the receipt it replaced, if any. The
+// caller owns the blob behind each key.
+func (s *PaymentService) AttachReceipt(ctx context.Context, userID, transactionID int, receipt *domain.Receipt) (string, error) {
+	if userID <= 0 {
+		return "", ErrInvalidUserID
+	}
+	if transactionID <= 0 {
+		return "", ErrInvalidTransactionID
+	}
+	if receipt == nil || receipt.Key == "" || receipt.ContentType == "" || receipt.Size <= 0 {
+		return "", ErrInvalidReceipt
+	}
+	if receipt.UploadedAt.IsZero() {
+		receipt.UploadedAt = s.clock.Now()
+	}
+
+	replacedKey, found, err := s.txRepo.AttachReceipt(ctx, userID, transactionID, receipt)
+	if err != nil {
+		return "", fmt.Errorf("failed to attach receipt: %w", err)
+	}
+	if !found {
+		return "", ErrTransactionNotFound
+	}
+	return replacedKey, nil
+}
+
+// ResolveTransactionULID returns the ID of the user's transaction with the
+// given ULID, so lookups by external ID reuse the ID-based methods
+func (s *PaymentService) ResolveTransactionULID(ctx context.Context, userID int, txULID string) (int, error) {
+	if userID <= 0 {
+		return 0, ErrInvalidUserID
+	}
+	if !ulid.Valid(txULID) {
+		return 0, ErrInvalidTransactionID
+	}
+
+	// ULIDs are stored upper case
+	id, err := s.txRepo.FindIDByULID(ctx, userID, strings.ToUpper(txULID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find transaction: %w", err)
+	}
+	if id == 0 {
+		return 0, ErrTransactionNotFound
+	}
+	return id, nil
+}
+
+// GetReceipt returns the receipt of one of the user's transactions
+func (s *PaymentService) GetReceipt(ctx context.Context, userID, transactionID int) (*domain.Receipt, error) {
+	if userID <= 0 {
+		return nil, ErrInvalidUserID
+	}
+	if transactionID <= 0 {
+		return nil, ErrInvalidTransactionID
+	}
+
+	receipt, err := s.txRepo.FindReceipt(ctx, userID, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	if receipt == nil {
+		return nil, ErrReceiptNotFound
+	}
+	return receipt, nil
+}
+
+// ForEachTransaction calls fn for every transaction created before before,
+// archived ones included, in ID order, for backfilling consumers that missed
+// the events
+func (s *PaymentService) ForEachTransaction(ctx context.Context, before time.Time, fn func(*domain.Transaction) error) error {
+	if err := s.txRepo.ForEachCreatedBefore(ctx, before, fn); err != nil {
+		return fmt.Errorf("failed to stream transactions: %w", err)
+	}
+	return nil
 }
@@ -1,116 +1,26 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+	"github.com/tkaewplik/go-microservices/pkg/pagination"
 )
 
-// MockTransactionRepository is a mock implementation of TransactionRepository
-type MockTransactionRepository struct {
-	transactions []domain.Transaction
-	createErr    error
-	findErr      error
-	updateErr    error
-	nextID       int
-}
-
-func NewMockTransactionRepository() *MockTransactionRepository {
-	return &MockTransactionRepository{
-		transactions: []domain.Transaction{},
-		nextID:       1,
-	}
-}
-
-func (m *MockTransactionRepository) Create(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
-	if m.createErr != nil {
-		return nil, m.createErr
-	}
-	tx.ID = m.nextID
-	m.nextID++
-	m.transactions = append(m.transactions, *tx)
-	return tx, nil
-}
-
-func (m *MockTransactionRepository) FindByUserID(ctx context.Context, userID int) ([]domain.Transaction, error) {
-	if m.findErr != nil {
-		return nil, m.findErr
-	}
-	var result []domain.Transaction
-	for _, tx := range m.transactions {
-		if tx.UserID == userID {
-			result = append(result, tx)
-		}
-	}
-	return result, nil
-}
-
-func (m *MockTransactionRepository) GetTotalAmountByUserID(ctx context.Context, userID int) (float64, error) {
-	if m.findErr != nil {
-		return 0, m.findErr
-	}
-	var total float64
-	for _, tx := range m.transactions {
-		if tx.UserID == userID {
-			total += tx.Amount
-		}
-	}
-	return total, nil
-}
-
-func (m *MockTransactionRepository) MarkAllAsPaid(ctx context.Context, userID int) (int64, error) {
-	if m.updateErr != nil {
-		return 0, m.updateErr
-	}
-	var count int64
-	for i := range m.transactions {
-		if m.transactions[i].UserID == userID && !m.transactions[i].IsPaid {
-			m.transactions[i].IsPaid = true
-			count++
-		}
-	}
-	return count, nil
-}
-
-// MockEventPublisher is a mock implementation of EventPublisher for testing
-type MockEventPublisher struct {
-	createdEvents []domain.TransactionCreatedEvent
-	paidEvents    []domain.TransactionPaidEvent
-	publishErr    error
-}
-
-func NewMockEventPublisher() *MockEventPublisher {
-	return &MockEventPublisher{
-		createdEvents: []domain.TransactionCreatedEvent{},
-		paidEvents:    []domain.TransactionPaidEvent{},
-	}
-}
-
-func (m *MockEventPublisher) PublishTransactionCreated(ctx context.Context, event *domain.TransactionCreatedEvent) error {
-	if m.publishErr != nil {
-		return m.publishErr
-	}
-	m.createdEvents = append(m.createdEvents, *event)
-	return nil
-}
-
-func (m *MockEventPublisher) PublishTransactionPaid(ctx context.Context, event *domain.TransactionPaidEvent) error {
-	if m.publishErr != nil {
-		return m.publishErr
-	}
-	m.paidEvents = append(m.paidEvents, *event)
-	return nil
-}
-
-func (m *MockEventPublisher) Close() error {
-	return nil
-}
-
 func TestPaymentService_CreateTransaction_Success(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	req := &domain.CreateTransactionRequest{
@@ -130,11 +40,14 @@ func TestPaymentService_CreateTransaction_Success(t *testing.T) {
 	if tx.Amount != 100.50 {
 		t.Errorf("expected amount 100.50, got %f", tx.Amount)
 	}
+	if tx.CurrentTotal != 100.50 || tx.RemainingLimit != 899.50 {
+		t.Errorf("expected total 100.50 and remaining 899.50, got %f and %f", tx.CurrentTotal, tx.RemainingLimit)
+	}
 }
 
 func TestPaymentService_CreateTransaction_InvalidAmount(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	req := &domain.CreateTransactionRequest{
@@ -150,8 +63,8 @@ func TestPaymentService_CreateTransaction_InvalidAmount(t *testing.T) {
 }
 
 func TestPaymentService_CreateTransaction_NegativeAmount(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	req := &domain.CreateTransactionRequest{
@@ -166,9 +79,44 @@ func TestPaymentService_CreateTransaction_NegativeAmount(t *testing.T) {
 	}
 }
 
+func TestPaymentService_CreateTransaction_DuplicateReferenceRejected(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := NewPaymentService(repo, testutil.NewFakeEventPublisher())
+	ctx := context.Background()
+
+	req := &domain.CreateTransactionRequest{UserID: 1, Amount: 10, ReferenceID: "import-42"}
+	if _, err := svc.CreateTransaction(ctx, req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err := svc.CreateTransaction(ctx, &domain.CreateTransactionRequest{UserID: 1, Amount: 10, ReferenceID: "import-42"})
+	if !errors.Is(err, ErrDuplicateReference) {
+		t.Fatalf("expected ErrDuplicateReference re-importing the same reference, got %v", err)
+	}
+
+	// References are unique per user, and ordinary creates have none
+	if _, err := svc.CreateTransaction(ctx, &domain.CreateTransactionRequest{UserID: 2, Amount: 10, ReferenceID: "import-42"}); err != nil {
+		t.Errorf("expected another user's reference not to collide, got %v", err)
+	}
+	for range 2 {
+		if _, err := svc.CreateTransaction(ctx, &domain.CreateTransactionRequest{UserID: 1, Amount: 10}); err != nil {
+			t.Errorf("expected creates without a reference not to collide, got %v", err)
+		}
+	}
+
+	txs := repo.Transactions()
+	if len(txs) != 4 || txs[0].ReferenceID != "import-42" {
+		t.Errorf("expected the reference stored once for user 1, got %+v", txs)
+	}
+
+	long := &domain.CreateTransactionRequest{UserID: 1, Amount: 10, ReferenceID: strings.Repeat("x", MaxReferenceIDLength+1)}
+	if _, err := svc.CreateTransaction(ctx, long); !errors.Is(err, ErrInvalidReferenceID) {
+		t.Errorf("expected ErrInvalidReferenceID for an overlong reference, got %v", err)
+	}
+}
+
 func TestPaymentService_CreateTransaction_ExceedsMaximum(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	// Create first transaction close to limit
@@ -192,11 +140,80 @@ func TestPaymentService_CreateTransaction_ExceedsMaximum(t *testing.T) {
 	if !errors.Is(err, ErrExceedsMaximum) {
 		t.Errorf("expected ErrExceedsMaximum, got %v", err)
 	}
+
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected LimitExceededError, got %T", err)
+	}
+	if limitErr.CurrentTotal != 900 || limitErr.RemainingLimit() != 100 {
+		t.Errorf("expected total 900 and remaining 100, got %+v", limitErr)
+	}
+}
+
+func TestPaymentService_CreateTransaction_UserLockedAcrossInstances(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	locker := lock.New(lock.NewLocal(nil), lock.WithRetryInterval(time.Millisecond))
+	instances := []*PaymentService{
+		NewPaymentService(repo, nil, WithUserLocker(locker)),
+		NewPaymentService(repo, nil, WithUserLocker(locker)),
+	}
+
+	// Each instance alone would pass the limit check before the other's
+	// insert; the shared lock lets only one through
+	var wg sync.WaitGroup
+	errs := make([]error, len(instances))
+	for i, svc := range instances {
+		wg.Go(func() {
+			_, errs[i] = svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: 600})
+		})
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrExceedsMaximum):
+			t.Fatalf("expected ErrExceedsMaximum, got %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("expected one transaction created, got %d", created)
+	}
+}
+
+func TestPaymentService_CreateTransaction_UserLocksReleased(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	repo.Latency = time.Millisecond
+	svc := NewPaymentService(repo, nil)
+
+	// Several creates per user contend for each user's lock
+	var wg sync.WaitGroup
+	for userID := 1; userID <= 50; userID++ {
+		for range 3 {
+			wg.Go(func() {
+				if _, err := svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: userID, Amount: 400}); err != nil && !errors.Is(err, ErrExceedsMaximum) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+		}
+	}
+	wg.Wait()
+
+	if n := len(repo.Transactions()); n != 100 {
+		t.Errorf("expected two transactions per user under the limit, got %d", n)
+	}
+	svc.userLocksMu.Lock()
+	defer svc.userLocksMu.Unlock()
+	if len(svc.userLocks) != 0 {
+		t.Errorf("expected every user's lock removed once unlocked, %d remain", len(svc.userLocks))
+	}
 }
 
 func TestPaymentService_CreateTransaction_ExactlyAtMaximum(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	// Create transaction exactly at limit
@@ -215,8 +232,58 @@ func TestPaymentService_CreateTransaction_ExactlyAtMaximum(t *testing.T) {
 	}
 }
 
+func TestPaymentService_CreateTransaction_WarnsApproachingLimit(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+	svc.clock = clock.NewFake(time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC))
+
+	// Each threshold is warned about once, by the transaction crossing it
+	for _, amount := range []float64{700, 150, 50, 60, 20} {
+		if _, err := svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: amount}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	// One transaction can cross both
+	if _, err := svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 2, Amount: 990}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	waitForEvents(t, func() bool { return len(publisher.LimitEvents()) >= 4 })
+
+	events := publisher.LimitEvents()
+	slices.SortFunc(events, func(a, b domain.LimitApproachingEvent) int {
+		return cmp.Or(cmp.Compare(a.UserID, b.UserID), cmp.Compare(a.Threshold, b.Threshold))
+	})
+	if len(events) != 4 {
+		t.Fatalf("expected 4 warnings, got %+v", events)
+	}
+	for i, want := range []struct {
+		userID    int
+		threshold float64
+		total     float64
+	}{{1, 0.8, 850}, {1, 0.95, 960}, {2, 0.8, 990}, {2, 0.95, 990}} {
+		got := events[i]
+		if got.UserID != want.userID || got.Threshold != want.threshold || got.CurrentTotal != want.total {
+			t.Errorf("warning %d: expected user %d at %v with total %v, got %+v", i, want.userID, want.threshold, want.total, got)
+		}
+	}
+	if got := events[0]; got.MaxAllowed != MaxTransactionTotal || got.RemainingLimit != 150 ||
+		!got.PeriodStart.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) ||
+		!got.ResetsAt.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the limit and period in the warning, got %+v", got)
+	}
+
+	summary, err := svc.GetSummary(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(summary.LimitWarnings, LimitWarningThresholds) {
+		t.Errorf("expected both warnings reached, got %v", summary.LimitWarnings)
+	}
+}
+
 func TestPaymentService_CreateTransaction_NilPublisher(t *testing.T) {
-	repo := NewMockTransactionRepository()
+	repo := testutil.NewFakeTransactionRepository()
 	// Test with nil publisher - should still work
 	svc := NewPaymentService(repo, nil)
 
@@ -237,8 +304,8 @@ func TestPaymentService_CreateTransaction_NilPublisher(t *testing.T) {
 }
 
 func TestPaymentService_GetTransactions_Success(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	// Create a transaction
@@ -249,37 +316,111 @@ func TestPaymentService_GetTransactions_Success(t *testing.T) {
 	}
 	_, _ = svc.CreateTransaction(context.Background(), req)
 
-	transactions, err := svc.GetTransactions(context.Background(), 1)
+	transactions, err := svc.GetTransactions(context.Background(), 1, domain.ListOptions{}, pagination.PageRequest{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
-	if len(transactions) != 1 {
-		t.Errorf("expected 1 transaction, got %d", len(transactions))
+	if len(transactions.Items) != 1 || transactions.Total != 1 {
+		t.Errorf("expected 1 transaction, got %+v", transactions)
 	}
 }
 
 func TestPaymentService_GetTransactions_EmptyList(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
-	transactions, err := svc.GetTransactions(context.Background(), 1)
+	transactions, err := svc.GetTransactions(context.Background(), 1, domain.ListOptions{}, pagination.PageRequest{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
-	if transactions == nil {
+	if transactions.Items == nil {
 		t.Error("expected empty slice, got nil")
 	}
-	if len(transactions) != 0 {
-		t.Errorf("expected 0 transactions, got %d", len(transactions))
+	if len(transactions.Items) != 0 {
+		t.Errorf("expected 0 transactions, got %d", len(transactions.Items))
+	}
+}
+
+func TestPaymentService_GetTransactions_Pages(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := NewPaymentService(repo, nil)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		// Two transactions share each amount, so the ID breaks ties
+		_, _ = repo.Create(context.Background(), &domain.Transaction{UserID: 1, Amount: float64(i / 2), CreatedAt: start.Add(time.Duration(i) * time.Hour)})
+	}
+
+	opts := domain.ListOptions{SortBy: domain.SortByAmount, Order: domain.SortAsc, Fields: []string{domain.FieldDescription}}
+	var ids []int
+	page := pagination.PageRequest{Limit: 2}
+	for {
+		result, err := svc.GetTransactions(context.Background(), 1, opts, page)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.Total != 5 {
+			t.Errorf("expected a total of 5, got %d", result.Total)
+		}
+		for _, tx := range result.Items {
+			if tx.ID != 0 || tx.Amount != 0 {
+				t.Errorf("expected fields outside the mask to stay zero, got %+v", tx)
+			}
+		}
+		// The fake repository doesn't apply the mask, so the cursor shows the order
+		var cursor domain.TransactionCursor
+		if result.NextCursor == "" {
+			break
+		}
+		if err := pagination.DecodeCursor(result.NextCursor, &cursor); err != nil {
+			t.Fatalf("failed to decode cursor: %v", err)
+		}
+		ids = append(ids, cursor.ID)
+		page.Cursor = result.NextCursor
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 4 {
+		t.Errorf("expected pages ending at IDs 2 and 4, got %v", ids)
+	}
+
+	_, err := svc.GetTransactions(context.Background(), 1, domain.ListOptions{}, page)
+	if !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Errorf("expected a cursor from another order to be rejected, got %v", err)
+	}
+}
+
+func TestPaymentService_GetTransactions_IncludeArchived(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := NewPaymentService(repo, nil)
+	old := time.Now().AddDate(0, -6, 0)
+	repo.Seed(
+		domain.Transaction{ID: 1, UserID: 1, Amount: 100, IsPaid: true, CreatedAt: old},
+		domain.Transaction{ID: 2, UserID: 1, Amount: 20, CreatedAt: time.Now()},
+	)
+	if _, err := repo.ArchivePaid(context.Background(), time.Now().AddDate(0, -1, 0), 10); err != nil {
+		t.Fatalf("failed to archive: %v", err)
+	}
+
+	live, _ := svc.GetTransactions(context.Background(), 1, domain.ListOptions{}, pagination.PageRequest{Limit: 10})
+	all, _ := svc.GetTransactions(context.Background(), 1, domain.ListOptions{IncludeArchived: true}, pagination.PageRequest{Limit: 10})
+	if len(live.Items) != 1 || live.Total != 1 || len(all.Items) != 2 || all.Total != 2 {
+		t.Errorf("expected 1 live and 2 transactions in all, got %+v and %+v", live.PageInfo, all.PageInfo)
+	}
+
+	// Archiving doesn't change what the user has paid
+	summary, err := svc.GetSummary(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if summary.PaidTotal != 100 || summary.TransactionCount != 2 {
+		t.Errorf("expected archived transactions in the summary, got %+v", summary)
 	}
 }
 
 func TestPaymentService_PayAllTransactions_Success(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	// Create two transactions
@@ -302,9 +443,82 @@ func TestPaymentService_PayAllTransactions_Success(t *testing.T) {
 	}
 }
 
+// waitForEvents polls until ready reports the asynchronously published
+// events are in
+func waitForEvents(t *testing.T, ready func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !ready() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for events")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPaymentService_PayAllTransactions_Charged(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	provider := &testutil.FakePaymentProvider{}
+	svc := NewPaymentService(repo, publisher, WithPaymentProvider(provider, repo))
+	repo.Seed(
+		domain.Transaction{UserID: 1, Amount: 50},
+		domain.Transaction{UserID: 1, Amount: 25.5},
+		domain.Transaction{UserID: 2, Amount: 10},
+	)
+
+	count, err := svc.PayAllTransactions(context.Background(), 1)
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 transactions paid, got %d: %v", count, err)
+	}
+	charges := provider.Charges()
+	if len(charges) != 1 || charges[0].Amount != 75.5 || len(charges[0].TransactionIDs) != 2 || charges[0].BatchID == "" {
+		t.Fatalf("expected one charge of 75.5 for 2 transactions, got %+v", charges)
+	}
+
+	waitForEvents(t, func() bool { return len(publisher.PaidEvents()) == 1 })
+	if paid := publisher.PaidEvents()[0]; paid.PaymentBatchID != charges[0].BatchID || paid.TransactionsPaid != 2 {
+		t.Errorf("expected the paid event to carry the charge's batch, got %+v", paid)
+	}
+	if len(publisher.RevertedEvents()) != 0 {
+		t.Errorf("expected no compensation, got %+v", publisher.RevertedEvents())
+	}
+}
+
+func TestPaymentService_PayAllTransactions_ChargeFailed(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	provider := &testutil.FakePaymentProvider{Err: errors.New("card declined")}
+	svc := NewPaymentService(repo, publisher, WithPaymentProvider(provider, repo))
+	repo.Seed(
+		domain.Transaction{UserID: 1, Amount: 50},
+		domain.Transaction{UserID: 1, Amount: 30, IsPaid: true},
+	)
+
+	_, err := svc.PayAllTransactions(context.Background(), 1)
+	if !errors.Is(err, ErrChargeFailed) {
+		t.Fatalf("expected ErrChargeFailed, got %v", err)
+	}
+	for _, tx := range repo.Transactions() {
+		// The earlier payment must survive the compensation
+		if tx.IsPaid != (tx.Amount == 30) {
+			t.Errorf("expected only the earlier payment to stand, got %+v", tx)
+		}
+	}
+
+	waitForEvents(t, func() bool { return len(publisher.RevertedEvents()) == 1 })
+	paid, reverted := publisher.PaidEvents(), publisher.RevertedEvents()
+	if len(paid) != 1 || paid[0].TransactionsPaid != 1 {
+		t.Fatalf("expected the paid event before the compensation, got %+v", paid)
+	}
+	if reverted[0].PaymentBatchID != paid[0].PaymentBatchID || reverted[0].TransactionsReverted != 1 || reverted[0].UserID != 1 {
+		t.Errorf("expected the paid batch reverted, got %+v", reverted[0])
+	}
+}
+
 func TestPaymentService_InvalidUserID(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	req := &domain.CreateTransactionRequest{
@@ -320,19 +534,47 @@ func TestPaymentService_InvalidUserID(t *testing.T) {
 }
 
 func TestPaymentService_GetTransactions_InvalidUserID(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
-	_, err := svc.GetTransactions(context.Background(), 0)
+	_, err := svc.GetTransactions(context.Background(), 0, domain.ListOptions{}, pagination.PageRequest{})
 	if !errors.Is(err, ErrInvalidUserID) {
 		t.Errorf("expected ErrInvalidUserID, got %v", err)
 	}
 }
 
+func TestPaymentService_GetTransactions_InvalidField(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+
+	_, err := svc.GetTransactions(context.Background(), 1, domain.ListOptions{Fields: []string{"id", "password"}}, pagination.PageRequest{})
+	if !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField, got %v", err)
+	}
+}
+
+func TestPaymentService_GetTransactions_InvalidSort(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+
+	tests := []domain.ListOptions{
+		{SortBy: "description"},
+		{SortBy: domain.SortByAmount, Order: "sideways"},
+	}
+	for _, opts := range tests {
+		_, err := svc.GetTransactions(context.Background(), 1, opts, pagination.PageRequest{})
+		if !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("%+v: expected ErrInvalidSort, got %v", opts, err)
+		}
+	}
+}
+
 func TestPaymentService_PayAllTransactions_InvalidUserID(t *testing.T) {
-	repo := NewMockTransactionRepository()
-	publisher := NewMockEventPublisher()
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
 	svc := NewPaymentService(repo, publisher)
 
 	_, err := svc.PayAllTransactions(context.Background(), -1)
@@ -340,3 +582,187 @@ func TestPaymentService_PayAllTransactions_InvalidUserID(t *testing.T) {
 		t.Errorf("expected ErrInvalidUserID, got %v", err)
 	}
 }
+
+func TestPaymentService_GetSummary(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+	svc.clock = clock.NewFake(time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC))
+
+	for _, amount := range []float64{100, 250} {
+		_, _ = svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: amount})
+	}
+	_, _ = svc.PayAllTransactions(context.Background(), 1)
+	_, _ = svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: 50})
+
+	summary, err := svc.GetSummary(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := domain.TransactionSummary{
+		UnpaidTotal:      50,
+		PaidTotal:        350,
+		UnpaidCount:      1,
+		PaidCount:        2,
+		TransactionCount: 3,
+		PeriodTotal:      400,
+		RemainingLimit:   600,
+		MaxAllowed:       MaxTransactionTotal,
+		ResetsAt:         time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(*summary, want) {
+		t.Errorf("expected %+v, got %+v", want, *summary)
+	}
+}
+
+func TestPaymentService_GetSummary_InvalidUserID(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+
+	_, err := svc.GetSummary(context.Background(), 0, "")
+	if !errors.Is(err, ErrInvalidUserID) {
+		t.Errorf("expected ErrInvalidUserID, got %v", err)
+	}
+}
+
+func TestPaymentService_CreateTransaction_LimitResetsMonthly(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+	svc.clock = clock.NewFake(time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC))
+
+	// Last month's spending no longer counts
+	repo.Seed(domain.Transaction{
+		ID: 1, UserID: 1, Amount: 900, CreatedAt: time.Date(2024, time.February, 28, 12, 0, 0, 0, time.UTC),
+	})
+
+	result, err := svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: 800})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.CurrentTotal != 800 {
+		t.Errorf("expected period total 800, got %f", result.CurrentTotal)
+	}
+
+	_, err = svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: 300})
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected LimitExceededError, got %v", err)
+	}
+	if want := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC); !limitErr.ResetsAt.Equal(want) {
+		t.Errorf("expected reset at %v, got %v", want, limitErr.ResetsAt)
+	}
+}
+
+func TestPaymentService_CreateTransaction_CreatedAtFromClock(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := NewPaymentService(repo, nil)
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	svc.clock = clock.NewFake(now)
+
+	result, err := svc.CreateTransaction(context.Background(), &domain.CreateTransactionRequest{UserID: 1, Amount: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.CreatedAt.Equal(now) {
+		t.Errorf("expected created_at %v, got %v", now, result.CreatedAt)
+	}
+	if stored := repo.Transactions(); len(stored) != 1 || !stored[0].CreatedAt.Equal(now) {
+		t.Errorf("expected the stored transaction created at %v, got %+v", now, stored)
+	}
+}
+
+func TestPaymentService_CreateTransaction_PeriodInUserTimezone(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	publisher := testutil.NewFakeEventPublisher()
+	svc := NewPaymentService(repo, publisher)
+	// Already April 1st in Bangkok (UTC+7), still March in UTC
+	svc.clock = clock.NewFake(time.Date(2024, time.March, 31, 20, 0, 0, 0, time.UTC))
+
+	repo.Seed(domain.Transaction{
+		ID: 1, UserID: 1, Amount: 900, CreatedAt: time.Date(2024, time.March, 31, 10, 0, 0, 0, time.UTC),
+	})
+
+	req := &domain.CreateTransactionRequest{UserID: 1, Amount: 500}
+	if _, err := svc.CreateTransaction(context.Background(), req); !errors.Is(err, ErrExceedsMaximum) {
+		t.Errorf("expected ErrExceedsMaximum in UTC, got %v", err)
+	}
+
+	req.Timezone = "Asia/Bangkok"
+	if _, err := svc.CreateTransaction(context.Background(), req); err != nil {
+		t.Errorf("expected new period in Asia/Bangkok, got %v", err)
+	}
+
+	req.Timezone = "Mars/Olympus"
+	if _, err := svc.CreateTransaction(context.Background(), req); !errors.Is(err, ErrInvalidTimezone) {
+		t.Errorf("expected ErrInvalidTimezone, got %v", err)
+	}
+}
+
+func TestPaymentService_AttachReceipt(t *testing.T) {
+	repo := testutil.NewFakeTransactionRepository()
+	svc := NewPaymentService(repo, nil)
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	svc.clock = clock.NewFake(now)
+	repo.Seed(domain.Transaction{ID: 1, UserID: 1, Amount: 10}, domain.Transaction{ID: 2, UserID: 2, Amount: 10})
+	ctx := context.Background()
+
+	if _, err := svc.GetReceipt(ctx, 1, 1); !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("expected ErrReceiptNotFound before upload, got %v", err)
+	}
+
+	first := &domain.Receipt{Key: "receipts/1/1/a", ContentType: "image/png", Size: 10}
+	replaced, err := svc.AttachReceipt(ctx, 1, 1, first)
+	if err != nil || replaced != "" {
+		t.Fatalf("expected a first receipt to replace nothing, got %q, %v", replaced, err)
+	}
+	if !first.UploadedAt.Equal(now) {
+		t.Errorf("expected uploaded_at %v, got %v", now, first.UploadedAt)
+	}
+
+	replaced, err = svc.AttachReceipt(ctx, 1, 1, &domain.Receipt{Key: "receipts/1/1/b", ContentType: "application/pdf", Size: 20})
+	if err != nil || replaced != "receipts/1/1/a" {
+		t.Fatalf("expected the first key to be replaced, got %q, %v", replaced, err)
+	}
+
+	receipt, err := svc.GetReceipt(ctx, 1, 1)
+	if err != nil || receipt.Key != "receipts/1/1/b" {
+		t.Errorf("expected the second receipt, got %+v, %v", receipt, err)
+	}
+
+	if _, err := svc.AttachReceipt(ctx, 1, 2, first); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected another user's transaction to be not found, got %v", err)
+	}
+	if _, err := svc.GetReceipt(ctx, 2, 1); !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("expected another user's receipt to be hidden, got %v", err)
+	}
+}
+
+func TestPaymentService_AttachReceipt_Invalid(t *testing.T) {
+	svc := NewPaymentService(testutil.NewFakeTransactionRepository(), nil)
+	ctx := context.Background()
+	valid := domain.Receipt{Key: "k", ContentType: "image/png", Size: 1}
+
+	tests := []struct {
+		name    string
+		userID  int
+		txID    int
+		receipt *domain.Receipt
+		want    error
+	}{
+		{"user", 0, 1, &valid, ErrInvalidUserID},
+		{"transaction", 1, 0, &valid, ErrInvalidTransactionID},
+		{"nil receipt", 1, 1, nil, ErrInvalidReceipt},
+		{"empty key", 1, 1, &domain.Receipt{ContentType: "image/png", Size: 1}, ErrInvalidReceipt},
+		{"empty file", 1, 1, &domain.Receipt{Key: "k", ContentType: "image/png"}, ErrInvalidReceipt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.AttachReceipt(ctx, tt.userID, tt.txID, tt.receipt); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitPeriod is the window over which MaxTransactionTotal applies
+type LimitPeriod string
+
+// Supported limit periods
+const (
+	PeriodDay      LimitPeriod = "day"
+	PeriodWeek     LimitPeriod = "week"
+	PeriodMonth    LimitPeriod = "month"
+	PeriodLifetime LimitPeriod = "lifetime"
+)
+
+// ParseLimitPeriod parses a LIMIT_PERIOD value
+func ParseLimitPeriod(s string) (LimitPeriod, error) {
+	switch p := LimitPeriod(s); p {
+	case PeriodDay, PeriodWeek, PeriodMonth, PeriodLifetime:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown limit period %q", s)
+	}
+}
+
+// Bounds returns the [start, end) of the period containing now, with calendar
+// boundaries taken in loc. Weeks start on Monday. The lifetime period has zero
+// bounds.
+func (p LimitPeriod) Bounds(now time.Time, loc *time.Location) (start, end time.Time) {
+	local := now.In(loc)
+	year, month, day := local.Date()
+
+	switch p {
+	case PeriodDay:
+		start = time.Date(year, month, day, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 0, 1)
+	case PeriodWeek:
+		offset := (int(local.Weekday()) + 6) % 7
+		start = time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	}
+	return start, end
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimitPeriod_Bounds(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	// Wednesday 2024-01-31 20:00 UTC is Thursday 2024-02-01 03:00 in Bangkok
+	now := time.Date(2024, time.January, 31, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period     LimitPeriod
+		loc        *time.Location
+		start, end time.Time
+	}{
+		{PeriodDay, time.UTC, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeek, time.UTC, time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonth, time.UTC, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonth, bangkok, time.Date(2024, 2, 1, 0, 0, 0, 0, bangkok), time.Date(2024, 3, 1, 0, 0, 0, 0, bangkok)},
+		{PeriodLifetime, time.UTC, time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		start, end := tt.period.Bounds(now, tt.loc)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s in %s: expected [%v, %v), got [%v, %v)", tt.period, tt.loc, tt.start, tt.end, start, end)
+		}
+	}
+}
+
+func TestParseLimitPeriod(t *testing.T) {
+	if p, err := ParseLimitPeriod("month"); err != nil || p != PeriodMonth {
+		t.Errorf("expected month, got %q, %v", p, err)
+	}
+	if _, err := ParseLimitPeriod("fortnight"); err == nil {
+		t.Error("expected error for unknown period")
+	}
+}
@@ -0,0 +1,269 @@
+// Package statement renders users' monthly transaction statements as PDFs in
+// blob storage, on demand and for every active user once a month.
+package statement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/report"
+)
+
+// Statement defaults
+const (
+	DefaultPrefix = "statements"
+	DefaultHour   = 2
+
+	// MonthFormat is how statement months are named
+	MonthFormat = "2006-01"
+
+	contentType = "application/pdf"
+)
+
+// Errors returned by a Generator
+var (
+	ErrInvalidMonth  = errors.New("invalid statement month")
+	ErrInvalidUserID = errors.New("invalid user_id")
+	ErrNotFound      = errors.New("statement not found")
+)
+
+// Statement is a rendered statement in blob storage
+type Statement struct {
+	Key         string
+	Month       string
+	Size        int64
+	GeneratedAt time.Time
+}
+
+// Generator renders statements keyed {prefix}/user={id}/{YYYY-MM}.pdf.
+// Regenerating a month overwrites its file, so a statement for the current
+// month can be refreshed as transactions arrive.
+type Generator struct {
+	repo   domain.TransactionRepository
+	store  blob.Store
+	logger *slog.Logger
+	loc    *time.Location
+	hour   int
+	prefix string
+	clock  clock.Clock
+}
+
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithLocation sets the timezone that months are cut in (default UTC)
+func WithLocation(loc *time.Location) Option {
+	return func(g *Generator) {
+		g.loc = loc
+	}
+}
+
+// WithHour sets the hour, in the statement timezone, on the first of each
+// month that Run generates the previous month's statements
+func WithHour(hour int) Option {
+	return func(g *Generator) {
+		g.hour = min(max(hour, 0), 23)
+	}
+}
+
+// WithPrefix sets the key prefix statements are written under
+func WithPrefix(prefix string) Option {
+	return func(g *Generator) {
+		g.prefix = prefix
+	}
+}
+
+// WithClock sets the clock Run schedules statements by and the current
+// month is read from (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(g *Generator) {
+		g.clock = c
+	}
+}
+
+// NewGenerator creates a Generator reading from repo and writing to store
+func NewGenerator(repo domain.TransactionRepository, store blob.Store, logger *slog.Logger, opts ...Option) *Generator {
+	g := &Generator{
+		repo:   repo,
+		store:  store,
+		logger: logger,
+		loc:    time.UTC,
+		hour:   DefaultHour,
+		prefix: DefaultPrefix,
+		clock:  clock.Real,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// ParseMonth returns the start of month, YYYY-MM, in the statement timezone
+func (g *Generator) ParseMonth(month string) (time.Time, error) {
+	start, err := time.ParseInLocation(MonthFormat, month, g.loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidMonth, month)
+	}
+	return start, nil
+}
+
+// Key returns where the user's statement for month is stored
+func (g *Generator) Key(userID int, month string) string {
+	return fmt.Sprintf("%s/user=%d/%s.pdf", g.prefix, userID, month)
+}
+
+// Generate renders the user's statement for month, YYYY-MM, archived
+// transactions included, and stores it. Months that haven't started are
+// refused.
+func (g *Generator) Generate(ctx context.Context, userID int, month string) (*Statement, error) {
+	if userID <= 0 {
+		return nil, ErrInvalidUserID
+	}
+	from, err := g.ParseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	now := g.clock.Now()
+	if from.After(now) {
+		return nil, fmt.Errorf("%w: %s has not started", ErrInvalidMonth, month)
+	}
+	to := from.AddDate(0, 1, 0)
+
+	transactions, err := g.repo.FindByUserID(ctx, userID, domain.ListOptions{
+		SortBy:          domain.SortByCreatedAt,
+		Order:           domain.SortAsc,
+		IncludeArchived: true,
+		CreatedFrom:     from,
+		CreatedBefore:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	s := report.Statement{
+		UserID:       userID,
+		From:         from,
+		To:           to,
+		GeneratedAt:  now,
+		Transactions: make([]report.StatementLine, len(transactions)),
+	}
+	for i, tx := range transactions {
+		s.Transactions[i] = report.StatementLine{
+			ID:          tx.ID,
+			CreatedAt:   tx.CreatedAt,
+			Description: tx.Description,
+			Amount:      tx.Amount,
+			Paid:        tx.IsPaid,
+		}
+	}
+
+	// A statement is a few kilobytes per page, so it is rendered in memory
+	var buf bytes.Buffer
+	if err := report.RenderStatement(&buf, s); err != nil {
+		return nil, fmt.Errorf("failed to render statement: %w", err)
+	}
+	size := int64(buf.Len())
+	key := g.Key(userID, month)
+	if err := g.store.Put(ctx, key, &buf, size, contentType); err != nil {
+		return nil, err
+	}
+
+	return &Statement{Key: key, Month: month, Size: size, GeneratedAt: now}, nil
+}
+
+// Get returns the user's stored statement for month, or ErrNotFound
+func (g *Generator) Get(ctx context.Context, userID int, month string) (*Statement, error) {
+	if userID <= 0 {
+		return nil, ErrInvalidUserID
+	}
+	if _, err := g.ParseMonth(month); err != nil {
+		return nil, err
+	}
+
+	body, info, err := g.store.Get(ctx, g.Key(userID, month))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = body.Close()
+	return &Statement{Key: info.Key, Month: month, Size: info.Size, GeneratedAt: info.ModTime}, nil
+}
+
+// Run generates the previous month's statements for every user with
+// transactions in it, on the first of each month at the configured hour,
+// until ctx is cancelled
+func (g *Generator) Run(ctx context.Context) {
+	for {
+		next := g.nextRun(g.clock.Now())
+		timer := g.clock.NewTimer(next.Sub(g.clock.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		month := next.AddDate(0, -1, 0).Format(MonthFormat)
+		generated, failed, err := g.GenerateMonth(ctx, month)
+		if err != nil {
+			g.logger.Error("monthly statements failed", "month", month, "error", err)
+			continue
+		}
+		g.logger.Info("monthly statements generated", "month", month, "statements", generated, "failed", failed)
+	}
+}
+
+// nextRun returns the first run time strictly after now
+func (g *Generator) nextRun(now time.Time) time.Time {
+	local := now.In(g.loc)
+	next := time.Date(local.Year(), local.Month(), 1, g.hour, 0, 0, 0, g.loc)
+	if !next.After(local) {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next
+}
+
+// GenerateMonth generates month's statement for every user who created
+// transactions in it. A user whose statement fails is logged and skipped so
+// one bad account doesn't hold up the rest.
+func (g *Generator) GenerateMonth(ctx context.Context, month string) (generated, failed int, err error) {
+	from, err := g.ParseMonth(month)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var users []int
+	seen := make(map[int]bool)
+	err = g.repo.ForEachCreatedBetween(ctx, from, from.AddDate(0, 1, 0), func(tx *domain.Transaction) error {
+		if !seen[tx.UserID] {
+			seen[tx.UserID] = true
+			users = append(users, tx.UserID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return generated, failed, ctx.Err()
+		}
+		if _, err := g.Generate(ctx, userID, month); err != nil {
+			g.logger.Error("statement failed", "month", month, "user_id", userID, "error", err)
+			failed++
+			continue
+		}
+		generated++
+	}
+	return generated, failed, nil
+}
@@ -0,0 +1,161 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/testutil"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+func newTestGenerator(t *testing.T, now time.Time, opts ...Option) (*Generator, *testutil.FakeTransactionRepository, blob.Store) {
+	t.Helper()
+	repo := testutil.NewFakeTransactionRepository()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewGenerator(repo, store, logger, opts...)
+	g.clock = clock.NewFake(now)
+	return g, repo, store
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	now := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	g, repo, store := newTestGenerator(t, now)
+	repo.Seed(
+		domain.Transaction{ID: 1, UserID: 1, Amount: 5, Description: "august", CreatedAt: time.Date(2026, 8, 31, 23, 59, 0, 0, time.UTC)},
+		domain.Transaction{ID: 2, UserID: 1, Amount: 12.5, Description: "lunch", CreatedAt: time.Date(2026, 9, 3, 12, 0, 0, 0, time.UTC)},
+		domain.Transaction{ID: 3, UserID: 1, Amount: 40, Description: "rent", IsPaid: true, CreatedAt: time.Date(2026, 9, 20, 12, 0, 0, 0, time.UTC)},
+		domain.Transaction{ID: 4, UserID: 2, Amount: 1, Description: "other user", CreatedAt: time.Date(2026, 9, 4, 12, 0, 0, 0, time.UTC)},
+	)
+	// Archived transactions still belong on their month's statement
+	if _, err := repo.ArchivePaid(context.Background(), now, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := g.Generate(context.Background(), 1, "2026-09")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if s.Key != "statements/user=1/2026-09.pdf" || s.Month != "2026-09" || s.Size == 0 || !s.GeneratedAt.Equal(now) {
+		t.Errorf("unexpected statement %+v", s)
+	}
+
+	rc, info, err := store.Get(context.Background(), s.Key)
+	if err != nil {
+		t.Fatalf("expected the statement stored, got %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+	pdf, _ := io.ReadAll(rc)
+	if info.ContentType != "application/pdf" || !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Errorf("expected a PDF, got %s", info.ContentType)
+	}
+	for _, want := range []string{"(lunch)", "(rent)", "(52.50)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected the statement to contain %s", want)
+		}
+	}
+	for _, unwanted := range []string{"(august)", "(other user)"} {
+		if bytes.Contains(pdf, []byte(unwanted)) {
+			t.Errorf("expected the statement not to contain %s", unwanted)
+		}
+	}
+
+	got, err := g.Get(context.Background(), 1, "2026-09")
+	if err != nil || got.Key != s.Key || got.Size != s.Size {
+		t.Errorf("expected the stored statement, got %+v, %v", got, err)
+	}
+}
+
+func TestGenerator_Invalid(t *testing.T) {
+	g, _, _ := newTestGenerator(t, time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	for _, month := range []string{"", "2026-13", "09-2026", "2026-11"} {
+		if _, err := g.Generate(ctx, 1, month); !errors.Is(err, ErrInvalidMonth) {
+			t.Errorf("month %q: expected ErrInvalidMonth, got %v", month, err)
+		}
+	}
+	if _, err := g.Generate(ctx, 0, "2026-09"); !errors.Is(err, ErrInvalidUserID) {
+		t.Errorf("expected ErrInvalidUserID, got %v", err)
+	}
+	if _, err := g.Get(ctx, 1, "2026-09"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound before generating, got %v", err)
+	}
+}
+
+func TestGenerator_GenerateMonth(t *testing.T) {
+	g, repo, store := newTestGenerator(t, time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC))
+	repo.Seed(
+		domain.Transaction{UserID: 1, Amount: 5, CreatedAt: time.Date(2026, 9, 3, 12, 0, 0, 0, time.UTC)},
+		domain.Transaction{UserID: 2, Amount: 5, CreatedAt: time.Date(2026, 9, 4, 12, 0, 0, 0, time.UTC)},
+		domain.Transaction{UserID: 1, Amount: 5, CreatedAt: time.Date(2026, 9, 5, 12, 0, 0, 0, time.UTC)},
+		domain.Transaction{UserID: 3, Amount: 5, CreatedAt: time.Date(2026, 8, 5, 12, 0, 0, 0, time.UTC)},
+	)
+
+	generated, failed, err := g.GenerateMonth(context.Background(), "2026-09")
+	if err != nil || generated != 2 || failed != 0 {
+		t.Fatalf("expected two statements, got %d generated, %d failed, %v", generated, failed, err)
+	}
+	for _, key := range []string{"statements/user=1/2026-09.pdf", "statements/user=2/2026-09.pdf"} {
+		rc, _, err := store.Get(context.Background(), key)
+		if err != nil {
+			t.Errorf("expected %s stored, got %v", key, err)
+			continue
+		}
+		_ = rc.Close()
+	}
+}
+
+func TestGenerator_NextRun(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	g, _, _ := newTestGenerator(t, time.Time{}, WithLocation(bangkok), WithHour(2))
+
+	for _, tc := range []struct{ now, want time.Time }{
+		{time.Date(2026, 9, 15, 0, 0, 0, 0, bangkok), time.Date(2026, 10, 1, 2, 0, 0, 0, bangkok)},
+		{time.Date(2026, 10, 1, 1, 0, 0, 0, bangkok), time.Date(2026, 10, 1, 2, 0, 0, 0, bangkok)},
+		{time.Date(2026, 10, 1, 2, 0, 0, 0, bangkok), time.Date(2026, 11, 1, 2, 0, 0, 0, bangkok)},
+		{time.Date(2026, 12, 31, 23, 0, 0, 0, bangkok), time.Date(2027, 1, 1, 2, 0, 0, 0, bangkok)},
+	} {
+		if got := g.nextRun(tc.now); !got.Equal(tc.want) {
+			t.Errorf("nextRun(%v): expected %v, got %v", tc.now, tc.want, got)
+		}
+	}
+}
+
+func TestGenerator_Run(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC))
+	g, repo, store := newTestGenerator(t, time.Time{})
+	g.clock = c
+	repo.Seed(domain.Transaction{UserID: 1, Amount: 5, CreatedAt: time.Date(2026, 9, 3, 12, 0, 0, 0, time.UTC)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		g.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Run waits for 02:00 on the first, then generates September's statements
+	c.BlockUntil(1)
+	c.Set(time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC))
+	c.BlockUntil(1) // waiting for November again
+	rc, _, err := store.Get(context.Background(), "statements/user=1/2026-09.pdf")
+	if err != nil {
+		t.Fatalf("expected September's statement generated, got %v", err)
+	}
+	_ = rc.Close()
+}
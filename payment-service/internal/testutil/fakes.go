@@ -0,0 +1,545 @@
+// Package testutil provides in-memory fakes of the payment-service domain
+// interfaces for tests
+package testutil
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/pkg/ulid"
+)
+
+// FakeTransactionRepository is an in-memory domain.TransactionRepository.
+// Set the *Err fields to make the matching calls fail.
+type FakeTransactionRepository struct {
+	mu           sync.Mutex
+	transactions []domain.Transaction
+	archived     []domain.Transaction
+	receipts     map[int]domain.Receipt
+	nextID       int
+
+	CreateErr error
+	FindErr   error
+	UpdateErr error
+	// Latency is added to every call, outside the lock, to widen race
+	// windows the way a database round trip would
+	Latency time.Duration
+}
+
+// NewFakeTransactionRepository creates an empty FakeTransactionRepository
+func NewFakeTransactionRepository() *FakeTransactionRepository {
+	return &FakeTransactionRepository{nextID: 1, receipts: make(map[int]domain.Receipt)}
+}
+
+// Seed stores transactions as-is, assigning IDs to those without one
+func (f *FakeTransactionRepository) Seed(txs ...domain.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, tx := range txs {
+		if tx.ID == 0 {
+			tx.ID = f.nextID
+		}
+		f.nextID = max(f.nextID, tx.ID+1)
+		f.transactions = append(f.transactions, tx)
+	}
+}
+
+// Transactions returns a copy of every stored transaction
+func (f *FakeTransactionRepository) Transactions() []domain.Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.Transaction(nil), f.transactions...)
+}
+
+// Archived returns a copy of every archived transaction
+func (f *FakeTransactionRepository) Archived() []domain.Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.Transaction(nil), f.archived...)
+}
+
+// all returns the live and archived transactions; f.mu must be held
+func (f *FakeTransactionRepository) all() []domain.Transaction {
+	return append(slices.Clip(f.transactions), f.archived...)
+}
+
+func (f *FakeTransactionRepository) Create(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.CreateErr != nil {
+		return nil, f.CreateErr
+	}
+	if tx.ReferenceID != "" {
+		for _, existing := range f.all() {
+			if existing.UserID == tx.UserID && existing.ReferenceID == tx.ReferenceID {
+				return nil, domain.ErrDuplicateReference
+			}
+		}
+	}
+	tx.ID = f.nextID
+	f.nextID++
+	if tx.CreatedAt.IsZero() {
+		tx.CreatedAt = time.Now()
+	}
+	if tx.ULID == "" {
+		tx.ULID = ulid.Make(tx.CreatedAt)
+	}
+	f.transactions = append(f.transactions, *tx)
+	return tx, nil
+}
+
+// FindByUserID returns the user's transactions sorted and paged by opts like
+// the Postgres repository; opts.Fields is not applied
+func (f *FakeTransactionRepository) FindByUserID(ctx context.Context, userID int, opts domain.ListOptions) ([]domain.Transaction, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	source := f.transactions
+	if opts.IncludeArchived {
+		source = f.all()
+	}
+	var result []domain.Transaction
+	for _, tx := range source {
+		if tx.UserID != userID ||
+			(!opts.CreatedFrom.IsZero() && tx.CreatedAt.Before(opts.CreatedFrom)) ||
+			(!opts.CreatedBefore.IsZero() && !tx.CreatedAt.Before(opts.CreatedBefore)) {
+			continue
+		}
+		result = append(result, tx)
+	}
+
+	compare := func(a, b domain.Transaction) int {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if opts.SortBy == domain.SortByAmount {
+			c = cmp.Compare(a.Amount, b.Amount)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if opts.Order != domain.SortAsc {
+			c = -c
+		}
+		return c
+	}
+	slices.SortStableFunc(result, compare)
+	if opts.After != nil {
+		after := domain.Transaction{ID: opts.After.ID, CreatedAt: opts.After.CreatedAt, Amount: opts.After.Amount}
+		result = slices.DeleteFunc(result, func(tx domain.Transaction) bool { return compare(tx, after) <= 0 })
+	}
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
+	}
+	return result, nil
+}
+
+// CountByUserID returns the number of the user's transactions
+func (f *FakeTransactionRepository) CountByUserID(ctx context.Context, userID int, includeArchived bool) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return 0, f.FindErr
+	}
+	source := f.transactions
+	if includeArchived {
+		source = f.all()
+	}
+	var count int64
+	for _, tx := range source {
+		if tx.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *FakeTransactionRepository) GetTotalAmountByUserID(ctx context.Context, userID int, since time.Time) (float64, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return 0, f.FindErr
+	}
+	var total float64
+	for _, tx := range f.all() {
+		if tx.UserID == userID && !tx.CreatedAt.Before(since) {
+			total += tx.Amount
+		}
+	}
+	return total, nil
+}
+
+func (f *FakeTransactionRepository) GetSummaryByUserID(ctx context.Context, userID int, periodStart time.Time) (*domain.TransactionSummary, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	summary := &domain.TransactionSummary{}
+	for _, tx := range f.all() {
+		if tx.UserID != userID {
+			continue
+		}
+		summary.TransactionCount++
+		if !tx.CreatedAt.Before(periodStart) {
+			summary.PeriodTotal += tx.Amount
+		}
+		if tx.IsPaid {
+			summary.PaidTotal += tx.Amount
+			summary.PaidCount++
+		} else {
+			summary.UnpaidTotal += tx.Amount
+			summary.UnpaidCount++
+		}
+	}
+	return summary, nil
+}
+
+func (f *FakeTransactionRepository) MarkAllAsPaid(ctx context.Context, userID int) (int64, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UpdateErr != nil {
+		return 0, f.UpdateErr
+	}
+	var count int64
+	for i := range f.transactions {
+		if f.transactions[i].UserID == userID && !f.transactions[i].IsPaid {
+			f.transactions[i].IsPaid = true
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *FakeTransactionRepository) PayBatch(ctx context.Context, userID int, batchID string) ([]domain.Transaction, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UpdateErr != nil {
+		return nil, f.UpdateErr
+	}
+	var paid []domain.Transaction
+	for i := range f.transactions {
+		if f.transactions[i].UserID == userID && !f.transactions[i].IsPaid {
+			f.transactions[i].IsPaid = true
+			paid = append(paid, f.transactions[i])
+		}
+	}
+	return paid, nil
+}
+
+func (f *FakeTransactionRepository) RevertBatch(ctx context.Context, userID int, batchID string, ids []int) (int64, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UpdateErr != nil {
+		return 0, f.UpdateErr
+	}
+	var count int64
+	for i := range f.transactions {
+		tx := &f.transactions[i]
+		if tx.UserID == userID && tx.IsPaid && slices.Contains(ids, tx.ID) {
+			tx.IsPaid = false
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *FakeTransactionRepository) AttachReceipt(ctx context.Context, userID, transactionID int, receipt *domain.Receipt) (string, bool, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UpdateErr != nil {
+		return "", false, f.UpdateErr
+	}
+	if !f.owns(f.transactions, userID, transactionID) {
+		return "", false, nil
+	}
+	replaced := f.receipts[transactionID].Key
+	f.receipts[transactionID] = *receipt
+	return replaced, true, nil
+}
+
+func (f *FakeTransactionRepository) FindReceipt(ctx context.Context, userID, transactionID int) (*domain.Receipt, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return nil, f.FindErr
+	}
+	receipt, ok := f.receipts[transactionID]
+	if !ok || !f.owns(f.all(), userID, transactionID) {
+		return nil, nil
+	}
+	return &receipt, nil
+}
+
+func (f *FakeTransactionRepository) FindIDByULID(ctx context.Context, userID int, txULID string) (int, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.FindErr != nil {
+		return 0, f.FindErr
+	}
+	for _, tx := range f.all() {
+		if tx.UserID == userID && tx.ULID != "" && tx.ULID == txULID {
+			return tx.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// ForEachCreatedBetween visits matching transactions in insertion order
+func (f *FakeTransactionRepository) ForEachCreatedBetween(ctx context.Context, from, to time.Time, fn func(*domain.Transaction) error) error {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	if f.FindErr != nil {
+		f.mu.Unlock()
+		return f.FindErr
+	}
+	var matched []domain.Transaction
+	for _, tx := range f.transactions {
+		if !tx.CreatedAt.Before(from) && tx.CreatedAt.Before(to) {
+			matched = append(matched, tx)
+		}
+	}
+	f.mu.Unlock()
+
+	for i := range matched {
+		if err := fn(&matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachCreatedBefore visits matching transactions, archived ones included,
+// in ID order
+func (f *FakeTransactionRepository) ForEachCreatedBefore(ctx context.Context, before time.Time, fn func(*domain.Transaction) error) error {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	if f.FindErr != nil {
+		f.mu.Unlock()
+		return f.FindErr
+	}
+	var matched []domain.Transaction
+	for _, tx := range f.all() {
+		if tx.CreatedAt.Before(before) {
+			matched = append(matched, tx)
+		}
+	}
+	f.mu.Unlock()
+
+	slices.SortFunc(matched, func(a, b domain.Transaction) int { return cmp.Compare(a.ID, b.ID) })
+	for i := range matched {
+		if err := fn(&matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchivePaid moves paid transactions created before before to the archive,
+// oldest first
+func (f *FakeTransactionRepository) ArchivePaid(ctx context.Context, before time.Time, limit int) (int64, error) {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UpdateErr != nil {
+		return 0, f.UpdateErr
+	}
+	var due []domain.Transaction
+	for _, tx := range f.transactions {
+		if tx.IsPaid && tx.CreatedAt.Before(before) {
+			due = append(due, tx)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b domain.Transaction) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	f.transactions = slices.DeleteFunc(f.transactions, func(tx domain.Transaction) bool {
+		return slices.ContainsFunc(due, func(d domain.Transaction) bool { return d.ID == tx.ID })
+	})
+	f.archived = append(f.archived, due...)
+	return int64(len(due)), nil
+}
+
+// owns reports whether transactionID belongs to userID among txs; f.mu must
+// be held
+func (f *FakeTransactionRepository) owns(txs []domain.Transaction, userID, transactionID int) bool {
+	for _, tx := range txs {
+		if tx.ID == transactionID {
+			return tx.UserID == userID
+		}
+	}
+	return false
+}
+
+// FakeEventPublisher records published events in memory.
+// Set Err to make publishing fail.
+type FakeEventPublisher struct {
+	mu       sync.Mutex
+	created  []domain.TransactionCreatedEvent
+	paid     []domain.TransactionPaidEvent
+	reverted []domain.PaymentRevertedEvent
+	limits   []domain.LimitApproachingEvent
+	exports  []domain.ExportCompletedEvent
+
+	Err error
+}
+
+// NewFakeEventPublisher creates an empty FakeEventPublisher
+func NewFakeEventPublisher() *FakeEventPublisher {
+	return &FakeEventPublisher{}
+}
+
+func (f *FakeEventPublisher) PublishTransactionCreated(ctx context.Context, event *domain.TransactionCreatedEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.created = append(f.created, *event)
+	return nil
+}
+
+func (f *FakeEventPublisher) PublishTransactionPaid(ctx context.Context, event *domain.TransactionPaidEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.paid = append(f.paid, *event)
+	return nil
+}
+
+func (f *FakeEventPublisher) PublishPaymentReverted(ctx context.Context, event *domain.PaymentRevertedEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.reverted = append(f.reverted, *event)
+	return nil
+}
+
+func (f *FakeEventPublisher) PublishLimitApproaching(ctx context.Context, event *domain.LimitApproachingEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.limits = append(f.limits, *event)
+	return nil
+}
+
+func (f *FakeEventPublisher) PublishExportCompleted(ctx context.Context, event *domain.ExportCompletedEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.exports = append(f.exports, *event)
+	return nil
+}
+
+func (f *FakeEventPublisher) Close() error {
+	return nil
+}
+
+// CreatedEvents returns a copy of the published transaction.created events
+func (f *FakeEventPublisher) CreatedEvents() []domain.TransactionCreatedEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.TransactionCreatedEvent(nil), f.created...)
+}
+
+// PaidEvents returns a copy of the published transaction.paid events
+func (f *FakeEventPublisher) PaidEvents() []domain.TransactionPaidEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.TransactionPaidEvent(nil), f.paid...)
+}
+
+// RevertedEvents returns a copy of the published
+// transaction.payment_reverted events
+func (f *FakeEventPublisher) RevertedEvents() []domain.PaymentRevertedEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.PaymentRevertedEvent(nil), f.reverted...)
+}
+
+// LimitEvents returns a copy of the published limit.approaching events
+func (f *FakeEventPublisher) LimitEvents() []domain.LimitApproachingEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.LimitApproachingEvent(nil), f.limits...)
+}
+
+// ExportEvents returns a copy of the published export.completed events
+func (f *FakeEventPublisher) ExportEvents() []domain.ExportCompletedEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.ExportCompletedEvent(nil), f.exports...)
+}
+
+// FakePaymentProvider records charges in memory. Set Err to decline them.
+type FakePaymentProvider struct {
+	mu      sync.Mutex
+	charges []domain.Charge
+
+	Err error
+}
+
+func (f *FakePaymentProvider) Charge(ctx context.Context, charge *domain.Charge) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.charges = append(f.charges, *charge)
+	return f.Err
+}
+
+// Charges returns a copy of every attempted charge
+func (f *FakePaymentProvider) Charges() []domain.Charge {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.Charge(nil), f.charges...)
+}
+
+var (
+	_ domain.TransactionRepository = (*FakeTransactionRepository)(nil)
+	_ domain.TransactionArchiver   = (*FakeTransactionRepository)(nil)
+	_ domain.TransactionBatchPayer = (*FakeTransactionRepository)(nil)
+	_ domain.EventPublisher        = (*FakeEventPublisher)(nil)
+	_ domain.ExportPublisher       = (*FakeEventPublisher)(nil)
+	_ domain.PaymentProvider       = (*FakePaymentProvider)(nil)
+)
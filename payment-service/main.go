@@ -1,32 +1,71 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 
+	"github.com/tkaewplik/go-microservices/payment-service/internal/archive"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/domain"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/export"
 	paymentgrpc "github.com/tkaewplik/go-microservices/payment-service/internal/grpc"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/handler"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/kafka"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/outbox"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/partition"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/provider"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/repository"
 	"github.com/tkaewplik/go-microservices/payment-service/internal/service"
+	"github.com/tkaewplik/go-microservices/payment-service/internal/statement"
+	"github.com/tkaewplik/go-microservices/pkg/blob"
 	"github.com/tkaewplik/go-microservices/pkg/database"
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+	"github.com/tkaewplik/go-microservices/pkg/grpcvalidate"
+	"github.com/tkaewplik/go-microservices/pkg/jobs"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+	"github.com/tkaewplik/go-microservices/pkg/messaging"
 	"github.com/tkaewplik/go-microservices/pkg/middleware"
+	"github.com/tkaewplik/go-microservices/pkg/request"
+	"github.com/tkaewplik/go-microservices/pkg/requestid"
+	"github.com/tkaewplik/go-microservices/pkg/tracing"
 	pb "github.com/tkaewplik/go-microservices/proto/payment"
 )
 
 func main() {
-	// Setup structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// Setup structured logger; records logged with a call's context carry
+	// the gateway's request ID
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
+	// OTEL_EXPORTER_OTLP_ENDPOINT exports traces of the calls served,
+	// continuing the gateway's
+	shutdownTracing, err := tracing.Setup(context.Background(), "payment-service")
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
 	// Database configuration
 	dbConfig := database.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
@@ -48,13 +87,71 @@ func main() {
 		}
 	}()
 
+	// Missing indexes don't break anything, but the per-user queries turn
+	// into table scans, so say so loudly
+	for table, indexes := range repository.TransactionIndexes {
+		missing, err := database.MissingIndexes(context.Background(), db, table, indexes)
+		if err != nil {
+			logger.Warn("failed to check indexes", "table", table, "error", err)
+		} else if len(missing) > 0 {
+			logger.Warn("expected indexes are missing or differ; run make migrate-payment-up", "table", table, "missing", missing)
+		}
+	}
+
+	// DB_PROFILE records per-statement latency and explains a sample of
+	// statements, logging slow plans. Development only.
+	var dbtx database.DBTX = db
+	var profiler *database.Profiler
+	if getEnv("DB_PROFILE", "false") == "true" {
+		profiler = database.NewProfiler(db, logger,
+			database.WithExplainSampleRate(getEnvFloat("DB_EXPLAIN_SAMPLE_RATE", database.DefaultExplainSampleRate)),
+			database.WithSlowPlanThreshold(time.Duration(getEnvInt("DB_SLOW_PLAN_MS", 100))*time.Millisecond),
+		)
+		defer func() {
+			if err := profiler.Close(); err != nil {
+				logger.Error("failed to close profiler", "error", err)
+			}
+		}()
+		dbtx = profiler
+		logger.Warn("database profiling enabled; EXPLAIN ANALYZE re-runs sampled statements")
+	}
+
 	// Initialize Kafka publisher
 	kafkaBrokers := getEnv("KAFKA_BROKERS", "localhost:9092")
 	kafkaTopic := getEnv("KAFKA_TOPIC", "transactions")
 
+	// PRIORITY_TOPICS sends refunds and payment failures to KAFKA_TOPIC.high,
+	// which consumers read ahead of KAFKA_TOPIC
+	// KAFKA_PARTITIONER picks partitions by hashing the user ID key unless
+	// set to least_bytes, which spreads load but loses per-user order
+	partitioner, err := messaging.ParsePartitioner(getEnv("KAFKA_PARTITIONER", ""))
+	if err != nil {
+		logger.Error("invalid KAFKA_PARTITIONER", "error", err)
+		os.Exit(1)
+	}
+	// KAFKA_TOPIC_ROUTES sends the event types it names to their own
+	// topics, e.g. transaction.payment_reverted=refunds
+	topicRoutes, err := kafka.ParseRoutes(getEnv("KAFKA_TOPIC_ROUTES", ""))
+	if err != nil {
+		logger.Error("invalid KAFKA_TOPIC_ROUTES", "error", err)
+		os.Exit(1)
+	}
+	// Limit warnings are for the notification service, not the transaction
+	// consumers: unless routed above they go to LIMIT_WARNINGS_TOPIC, next
+	// to the analytics service's spend alerts
+	if _, ok := topicRoutes["limit.approaching"]; !ok {
+		topicRoutes["limit.approaching"] = getEnv("LIMIT_WARNINGS_TOPIC", "alerts")
+	}
 	kafkaCfg := kafka.Config{
-		Brokers: strings.Split(kafkaBrokers, ","),
-		Topic:   kafkaTopic,
+		Brokers:        strings.Split(kafkaBrokers, ","),
+		Topic:          kafkaTopic,
+		Routes:         topicRoutes,
+		PriorityTopics: getEnv("PRIORITY_TOPICS", "false") == "true",
+		Partitioner:    partitioner,
+		Faults:         publishFaultsFromEnv(),
+		// KAFKA_CLOSE_TIMEOUT bounds how long shutdown waits for events
+		// being written; the rest are logged as dropped
+		CloseTimeout: getEnvDuration("KAFKA_CLOSE_TIMEOUT", kafka.DefaultCloseTimeout),
 	}
 
 	publisher := kafka.NewPublisher(kafkaCfg, logger)
@@ -64,12 +161,163 @@ func main() {
 		}
 	}()
 
+	// EVENT_DELIVERY=outbox records events in the outbox table with the change
+	// itself instead of publishing them from the request path
+	var repoOpts []repository.RepositoryOption
+	var eventPublisher domain.EventPublisher = publisher
+	switch delivery := getEnv("EVENT_DELIVERY", "direct"); delivery {
+	case "direct":
+	case "outbox":
+		repoOpts = append(repoOpts, repository.WithOutbox())
+		eventPublisher = nil
+		// Leave the relay off when Debezium publishes the outbox instead
+		if getEnv("OUTBOX_RELAY_ENABLED", "true") == "true" {
+			relay := outbox.NewRelay(outbox.NewPostgresStore(db), publisher, logger,
+				outbox.WithBatchSize(getEnvInt("OUTBOX_BATCH_SIZE", outbox.DefaultBatchSize)),
+				outbox.WithPollInterval(time.Duration(getEnvInt("OUTBOX_POLL_INTERVAL_MS", 500))*time.Millisecond),
+			)
+			relayCtx, stopRelay := context.WithCancel(context.Background())
+			defer stopRelay()
+			go relay.Run(relayCtx)
+		}
+		logger.Info("transactional outbox enabled", "relay", getEnv("OUTBOX_RELAY_ENABLED", "true"))
+	default:
+		logger.Error("invalid EVENT_DELIVERY; expected direct or outbox", "value", delivery)
+		os.Exit(1)
+	}
+
 	// Initialize layers
-	txRepo := repository.NewPostgresTransactionRepository(db)
-	paymentService := service.NewPaymentService(txRepo, publisher)
+	pgRepo := repository.NewPostgresTransactionRepository(dbtx, repoOpts...)
+	var txRepo domain.TransactionRepository = pgRepo
+	if batchSize := getEnvInt("WRITE_BATCH_SIZE", 0); batchSize > 1 {
+		// Write-behind mode: concurrent creates share multi-row INSERTs
+		batchDelay := time.Duration(getEnvInt("WRITE_BATCH_DELAY_MS", 2)) * time.Millisecond
+		batching := repository.NewBatchingTransactionRepository(pgRepo,
+			repository.WithMaxBatch(batchSize),
+			repository.WithMaxDelay(batchDelay),
+		)
+		defer func() {
+			if err := batching.Close(); err != nil {
+				logger.Error("failed to flush batched writes", "error", err)
+			}
+		}()
+		txRepo = batching
+		logger.Info("write batching enabled", "max_batch", batchSize, "max_delay", batchDelay)
+	}
+	limitPeriod, err := service.ParseLimitPeriod(getEnv("LIMIT_PERIOD", string(service.PeriodMonth)))
+	if err != nil {
+		logger.Error("invalid LIMIT_PERIOD", "error", err)
+		os.Exit(1)
+	}
+	limitLocation, err := time.LoadLocation(getEnv("LIMIT_TIMEZONE", "UTC"))
+	if err != nil {
+		logger.Error("invalid LIMIT_TIMEZONE", "error", err)
+		os.Exit(1)
+	}
+	// Scheduled jobs and, with USER_LOCK_ENABLED, users' limit checks are
+	// locked across instances
+	locker, closeLocker, err := newLockerFromEnv(db, logger)
+	if err != nil {
+		logger.Error("invalid lock configuration", "error", err)
+		os.Exit(1)
+	}
+	defer closeLocker()
+
+	serviceOpts := []service.Option{service.WithLimitPeriod(limitPeriod, limitLocation)}
+	if getEnv("USER_LOCK_ENABLED", "false") == "true" {
+		serviceOpts = append(serviceOpts, service.WithUserLocker(locker))
+		logger.Info("per-user limit locks shared across instances")
+	}
+	// PAYMENT_PROVIDER_URL charges users when they pay, reverting the payment
+	// when the charge is declined. Write batching only groups inserts, so the
+	// saga pays through pgRepo directly.
+	if url := getEnv("PAYMENT_PROVIDER_URL", ""); url != "" {
+		charger := provider.NewHTTPProvider(url, provider.WithTimeout(time.Duration(getEnvInt("PAYMENT_PROVIDER_TIMEOUT_SECONDS", 10))*time.Second))
+		serviceOpts = append(serviceOpts, service.WithPaymentProvider(charger, pgRepo))
+		logger.Info("payment provider enabled", "url", url)
+	}
+	paymentService := service.NewPaymentService(txRepo, eventPublisher, serviceOpts...)
+
+	// Scheduled jobs run on one instance at a time: instances elect a leader
+	// by holding a shared lock, and only the leader runs them
+	jobRunner := jobs.NewRunner(locker, "payment-service/jobs", logger,
+		jobs.WithElectionInterval(getEnvDuration("JOB_ELECTION_INTERVAL", jobs.DefaultElectionInterval)))
+
+	// EXPORT_ENABLED writes each day's transactions to blob storage
+	if getEnv("EXPORT_ENABLED", "false") == "true" {
+		exporter, closeExporter, err := newExporterFromEnv(pgRepo, kafkaCfg.Brokers, logger)
+		if err != nil {
+			logger.Error("failed to set up transaction export", "error", err)
+			os.Exit(1)
+		}
+		defer closeExporter()
+		jobRunner.Add("export", exporter.Run)
+	}
+
+	// STATEMENTS_ENABLED serves monthly statement PDFs, stored in blob
+	// storage the gateway signs download links for. STATEMENT_MONTHLY_ENABLED
+	// also renders last month's statements on the first of each month.
+	var statements *statement.Generator
+	if getEnv("STATEMENTS_ENABLED", "false") == "true" {
+		statements, err = newStatementsFromEnv(pgRepo, logger)
+		if err != nil {
+			logger.Error("failed to set up statements", "error", err)
+			os.Exit(1)
+		}
+		if getEnv("STATEMENT_MONTHLY_ENABLED", "false") == "true" {
+			jobRunner.Add("statements", statements.Run)
+		}
+	}
+
+	// Once migration 000008 has partitioned transactions by month, keep the
+	// coming months' partitions created
+	if getEnv("PARTITION_MAINTENANCE_ENABLED", "true") == "true" {
+		if partitioned, err := pgRepo.Partitioned(context.Background()); err != nil {
+			logger.Warn("failed to check partitioning", "error", err)
+		} else if partitioned {
+			maintainer := partition.NewMaintainer(pgRepo, logger,
+				partition.WithMonthsAhead(getEnvInt("PARTITION_MONTHS_AHEAD", partition.DefaultMonthsAhead)),
+				partition.WithRetention(getEnvInt("PARTITION_RETENTION_MONTHS", 0)),
+			)
+			jobRunner.Add("partitions", maintainer.Run)
+		}
+	}
+
+	// ARCHIVE_ENABLED moves paid transactions older than ARCHIVE_AFTER_DAYS
+	// to transactions_archive
+	if getEnv("ARCHIVE_ENABLED", "false") == "true" {
+		archiver := archive.NewArchiver(pgRepo, logger,
+			archive.WithAge(time.Duration(getEnvInt("ARCHIVE_AFTER_DAYS", 90))*24*time.Hour),
+			archive.WithBatchSize(getEnvInt("ARCHIVE_BATCH_SIZE", archive.DefaultBatchSize)),
+			archive.WithInterval(time.Duration(getEnvInt("ARCHIVE_INTERVAL_MINUTES", 60))*time.Minute),
+		)
+		jobRunner.Add("archive", archiver.Run)
+		logger.Info("transaction archiving enabled", "after_days", getEnvInt("ARCHIVE_AFTER_DAYS", 90))
+	}
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobRunner.Run(jobsCtx)
 
 	// Start gRPC server
 	grpcPort := getEnv("GRPC_PORT", "50052")
+	secretKey := getEnv("JWT_SECRET", "your-secret-key")
+	clockSkew := getEnvDuration("JWT_CLOCK_SKEW", 0)
+	grpcAuth := grpcauth.UnaryServerInterceptor(grpcauth.Config{
+		SecretKey:    secretKey,
+		ServiceToken: getEnv("SERVICE_TOKEN", ""),
+		Required:     getEnv("GRPC_AUTH_REQUIRED", "true") == "true",
+		MethodScopes: paymentgrpc.MethodScopes,
+		ClockSkew:    clockSkew,
+	})
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(tracing.ServerHandler()),
+		grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor(logger), grpcAuth, grpcvalidate.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(requestid.StreamServerInterceptor(logger)))
+	serverOpts := []paymentgrpc.ServerOption{paymentgrpc.WithServiceToken(getEnv("SERVICE_TOKEN", ""))}
+	if statements != nil {
+		serverOpts = append(serverOpts, paymentgrpc.WithStatements(statements))
+	}
+	pb.RegisterPaymentServiceServer(grpcServer, paymentgrpc.NewPaymentServer(paymentService, serverOpts...))
 	go func() {
 		lis, err := net.Listen("tcp", ":"+grpcPort)
 		if err != nil {
@@ -77,10 +325,6 @@ func main() {
 			os.Exit(1)
 		}
 
-		grpcServer := grpc.NewServer()
-		paymentGRPCServer := paymentgrpc.NewPaymentServer(paymentService)
-		pb.RegisterPaymentServiceServer(grpcServer, paymentGRPCServer)
-
 		logger.Info("gRPC server starting", "port", grpcPort)
 		if err := grpcServer.Serve(lis); err != nil {
 			logger.Error("gRPC server failed", "error", err)
@@ -90,19 +334,44 @@ func main() {
 
 	// HTTP server (for backwards compatibility)
 	paymentHandler := handler.NewPaymentHandler(paymentService, logger)
-	secretKey := getEnv("JWT_SECRET", "your-secret-key")
-	authMiddleware := middleware.NewAuthMiddleware(secretKey)
+	authMiddleware := middleware.NewAuthMiddleware(secretKey, middleware.WithClockSkew(clockSkew))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/transactions", authMiddleware.Authenticate(paymentHandler.CreateTransaction))
 	mux.HandleFunc("/transactions/list", authMiddleware.Authenticate(paymentHandler.GetTransactions))
 	mux.HandleFunc("/transactions/pay", authMiddleware.Authenticate(paymentHandler.PayAllTransactions))
+	mux.HandleFunc("/transactions/summary", authMiddleware.Authenticate(paymentHandler.GetSummary))
+	if profiler != nil {
+		// DEBUG_ALLOW_CIDRS and DEBUG_DENY_CIDRS limit who may read it;
+		// private networks only by default
+		debugFilter, err := middleware.ParseIPFilter(getEnv("DEBUG_ALLOW_CIDRS", ""), getEnv("DEBUG_DENY_CIDRS", ""))
+		if err != nil {
+			logger.Error("invalid debug access list", "error", err)
+			os.Exit(1)
+		}
+		mux.Handle("/debug/statements", debugFilter.Restrict(profiler))
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	// Prometheus metrics for the Kafka publisher. METRICS_ALLOW_CIDRS and
+	// METRICS_DENY_CIDRS limit who may scrape it; private networks only by
+	// default.
+	metricsFilter, err := middleware.ParseIPFilter(getEnv("METRICS_ALLOW_CIDRS", ""), getEnv("METRICS_DENY_CIDRS", ""))
+	if err != nil {
+		logger.Error("invalid metrics access list", "error", err)
+		os.Exit(1)
+	}
+	mux.Handle("/metrics", metricsFilter.Restrict(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := publisher.WriteMetrics(w); err != nil {
+			logger.Error("failed to write metrics", "error", err)
+		}
+	})))
+
 	// Start HTTP server
 	port := getEnv("PORT", "8082")
 	logger.Info("HTTP server starting",
@@ -110,9 +379,151 @@ func main() {
 		"grpc_port", grpcPort,
 		"kafka_brokers", kafkaBrokers,
 	)
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
-		logger.Error("HTTP server failed", "error", err)
-		os.Exit(1)
+	request.MaxDepth = getEnvInt("MAX_JSON_DEPTH", request.MaxDepth)
+	maxBodyBytes := int64(getEnvInt("MAX_BODY_BYTES", 1<<20))
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: middleware.MaxBodyBytes(maxBodyBytes)(mux),
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Returning runs the deferred closes, which flush the Kafka publisher
+	// and batched writes
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	logger.Info("shutting down...")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown error", "error", err)
+	}
+	grpcServer.GracefulStop()
+}
+
+// newExporterFromEnv builds the daily transaction exporter. The returned func
+// closes its event publisher.
+func newExporterFromEnv(repo domain.TransactionRepository, brokers []string, logger *slog.Logger) (*export.Exporter, func(), error) {
+	format, err := export.ParseFormat(getEnv("EXPORT_FORMAT", string(export.FormatCSV)))
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(getEnv("EXPORT_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := blob.New(blob.Config{
+		Kind: getEnv("EXPORT_STORE", blob.KindLocal),
+		Dir:  getEnv("EXPORT_DIR", "data/exports"),
+		S3:   s3ConfigFromEnv(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Export events go to their own topic so transaction consumers never
+	// see them
+	exportPublisher := kafka.NewPublisher(kafka.Config{
+		Brokers:      brokers,
+		Topic:        getEnv("EXPORT_TOPIC", "exports"),
+		CloseTimeout: getEnvDuration("KAFKA_CLOSE_TIMEOUT", kafka.DefaultCloseTimeout),
+	}, logger)
+	closePublisher := func() {
+		if err := exportPublisher.Close(); err != nil {
+			logger.Error("failed to close export publisher", "error", err)
+		}
+	}
+
+	hour := getEnvInt("EXPORT_HOUR", export.DefaultHour)
+	logger.Info("daily transaction export enabled", "format", format, "hour", hour, "timezone", loc.String())
+	return export.NewExporter(repo, store, exportPublisher, logger,
+		export.WithFormat(format),
+		export.WithLocation(loc),
+		export.WithHour(hour),
+		export.WithPrefix(getEnv("EXPORT_PREFIX", export.DefaultPrefix)),
+	), closePublisher, nil
+}
+
+// newStatementsFromEnv builds the statement generator, writing to
+// STATEMENT_STORE ("local" or "s3"). The gateway must read the same store.
+// newLockerFromEnv creates the locker instances share: Postgres advisory
+// locks on db, or with LOCK_BACKEND=redis keys on a majority of the
+// LOCK_REDIS_ADDRS servers
+func newLockerFromEnv(db *sql.DB, logger *slog.Logger) (*lock.Locker, func(), error) {
+	ttl := lock.WithTTL(getEnvDuration("LOCK_TTL", lock.DefaultTTL))
+	switch backend := getEnv("LOCK_BACKEND", "postgres"); backend {
+	case "postgres":
+		return lock.New(lock.NewPostgres(db), ttl), func() {}, nil
+	case "redis":
+		var clients []*redis.Client
+		for _, addr := range strings.Split(getEnv("LOCK_REDIS_ADDRS", "localhost:6379"), ",") {
+			clients = append(clients, redis.NewClient(&redis.Options{Addr: strings.TrimSpace(addr)}))
+		}
+		closeClients := func() {
+			for _, client := range clients {
+				if err := client.Close(); err != nil {
+					logger.Error("failed to close Redis lock client", "error", err)
+				}
+			}
+		}
+		logger.Info("Redis locks enabled", "servers", len(clients))
+		return lock.New(lock.NewRedis(clients...), ttl), closeClients, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown LOCK_BACKEND %q", backend)
+	}
+}
+
+func newStatementsFromEnv(repo domain.TransactionRepository, logger *slog.Logger) (*statement.Generator, error) {
+	loc, err := time.LoadLocation(getEnv("STATEMENT_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, err
+	}
+	store, err := blob.New(blob.Config{
+		Kind: getEnv("STATEMENT_STORE", blob.KindLocal),
+		Dir:  getEnv("STATEMENT_DIR", "data/statements"),
+		S3:   s3ConfigFromEnv(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hour := getEnvInt("STATEMENT_HOUR", statement.DefaultHour)
+	logger.Info("statements enabled", "hour", hour, "timezone", loc.String())
+	return statement.NewGenerator(repo, store, logger,
+		statement.WithLocation(loc),
+		statement.WithHour(hour),
+		statement.WithPrefix(getEnv("STATEMENT_PREFIX", statement.DefaultPrefix)),
+	), nil
+}
+
+// s3ConfigFromEnv reads the bucket settings shared by every S3-backed store
+func s3ConfigFromEnv() blob.S3Config {
+	return blob.S3Config{
+		Endpoint:  getEnv("S3_ENDPOINT", "s3.amazonaws.com"),
+		Region:    getEnv("S3_REGION", ""),
+		Bucket:    getEnv("S3_BUCKET", ""),
+		AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		SecretKey: getEnv("S3_SECRET_KEY", ""),
+		UseSSL:    getEnv("S3_USE_SSL", "true") == "true",
+	}
+}
+
+// publishFaultsFromEnv reads FAULT_PUBLISH_DROP_RATE and
+// FAULT_PUBLISH_ERROR_RATE, injecting nothing unless FAULT_INJECTION_ENABLED
+// is true
+func publishFaultsFromEnv() messaging.PublishFaults {
+	if getEnv("FAULT_INJECTION_ENABLED", "false") != "true" {
+		return messaging.PublishFaults{}
+	}
+	return messaging.PublishFaults{
+		DropRate:  getEnvFloat("FAULT_PUBLISH_DROP_RATE", 0),
+		ErrorRate: getEnvFloat("FAULT_PUBLISH_ERROR_RATE", 0),
 	}
 }
 
@@ -131,3 +542,21 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
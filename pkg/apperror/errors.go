@@ -36,6 +36,20 @@ const (
 	CodeConflict            = "CONFLICT"
 	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
 	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+	CodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	CodeInvalidQuery        = "INVALID_QUERY"
+	CodeInvalidTimezone     = "INVALID_TIMEZONE"
+	CodeInvalidLocale       = "INVALID_LOCALE"
+	CodeLimitExceeded       = "LIMIT_EXCEEDED"
+	CodeChargeFailed        = "CHARGE_FAILED"
+	CodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+	CodePayloadTooLarge     = "PAYLOAD_TOO_LARGE"
+	CodeQuotaExceeded       = "QUOTA_EXCEEDED"
+	CodeMaintenance         = "MAINTENANCE"
+	CodeUnsupportedMedia    = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeCSRFFailed          = "CSRF_FAILED"
 )
 
 // Predefined errors
@@ -0,0 +1,81 @@
+// Package blob stores opaque files, such as receipts, by key.
+//
+// Keys are slash-separated paths like "receipts/7/42/3f2a". Stores keep the
+// content type given at upload and return it on download. Access control is
+// the caller's job; URLSigner helps hand out time-limited download links.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Common errors
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Info describes a stored blob
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store saves and serves blobs
+type Store interface {
+	// Put stores size bytes from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob at key; the caller closes it. Missing keys return ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes the blob at key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Store kinds for Config.Kind
+const (
+	KindLocal = "local"
+	KindS3    = "s3"
+)
+
+// Config selects and configures a Store
+type Config struct {
+	// Kind is KindLocal or KindS3
+	Kind string
+	// Dir is the root directory of a local store
+	Dir string
+	// S3 locates the bucket of an S3 store
+	S3 S3Config
+}
+
+// New creates the Store described by cfg
+func New(cfg Config) (Store, error) {
+	switch cfg.Kind {
+	case KindLocal:
+		return NewLocalStore(cfg.Dir)
+	case KindS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Kind)
+	}
+}
+
+// ValidateKey rejects keys that could escape a store's root: empty, absolute,
+// or containing empty, "." or ".." segments
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, '\\') {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
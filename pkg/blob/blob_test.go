@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"receipts/1/2/abc", "a"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("expected %q to be valid, got %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "/etc/passwd", "a/../../b", "a//b", "./a", "a/", `a\b`} {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected %q to be rejected, got %v", key, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := "receipts/1/2/abc"
+
+	if err := s.Put(ctx, key, strings.NewReader("%PDF-1.7"), 8, "application/pdf"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rc, info, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(data) != "%PDF-1.7" || info.Size != 8 || info.ContentType != "application/pdf" {
+		t.Errorf("unexpected blob %q %+v", data, info)
+	}
+
+	if err := s.Put(ctx, "receipts/short", strings.NewReader("abc"), 10, "text/plain"); err == nil {
+		t.Error("expected a size mismatch to fail")
+	}
+	if _, _, err := s.Get(ctx, "receipts/short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a failed upload to leave nothing behind, got %v", err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+	}
+	if _, _, err := s.Get(ctx, "../outside"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	s := NewURLSigner([]byte("secret"))
+	s.now = func() time.Time { return now }
+
+	q := s.Sign("receipts/1/2/abc", now.Add(time.Minute))
+	if key, err := s.Verify(q); err != nil || key != "receipts/1/2/abc" {
+		t.Fatalf("expected the key back, got %q, %v", key, err)
+	}
+
+	tampered := q.Get("key")
+	q.Set("key", "receipts/9/9/xyz")
+	if _, err := s.Verify(q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a changed key to be rejected, got %v", err)
+	}
+	q.Set("key", tampered)
+
+	q.Set("expires", "9999999999")
+	if _, err := s.Verify(q); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a changed expiry to be rejected, got %v", err)
+	}
+
+	if _, err := NewURLSigner([]byte("other")).Verify(s.Sign("k", now.Add(time.Minute))); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected another secret's signature to be rejected, got %v", err)
+	}
+
+	expired := s.Sign("k", now)
+	if _, err := s.Verify(expired); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("expected ErrURLExpired, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(Config{Kind: KindLocal, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := store.(*LocalStore); !ok {
+		t.Errorf("expected a LocalStore, got %T", store)
+	}
+	if _, err := New(Config{Kind: "ftp"}); err == nil {
+		t.Error("expected an unknown kind to fail")
+	}
+}
@@ -0,0 +1,63 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Signed URL errors
+var (
+	ErrInvalidSignature = errors.New("invalid blob signature")
+	ErrURLExpired       = errors.New("blob URL expired")
+)
+
+// URLSigner makes and checks time-limited download links for blob keys, so a
+// server can hand a link to a client without storing anything
+type URLSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewURLSigner creates a URLSigner using secret as the HMAC key
+func NewURLSigner(secret []byte) *URLSigner {
+	return &URLSigner{secret: secret, now: time.Now}
+}
+
+// Sign returns the query parameters granting access to key until expiresAt
+func (s *URLSigner) Sign(key string, expiresAt time.Time) url.Values {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return url.Values{
+		"key":     {key},
+		"expires": {expires},
+		"sig":     {s.signature(key, expires)},
+	}
+}
+
+// Verify checks query parameters made by Sign and returns the key they grant
+func (s *URLSigner) Verify(q url.Values) (string, error) {
+	key, expires, sig := q.Get("key"), q.Get("expires"), q.Get("sig")
+	if !hmac.Equal([]byte(sig), []byte(s.signature(key, expires))) {
+		return "", ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if !s.now().Before(time.Unix(unix, 0)) {
+		return "", ErrURLExpired
+	}
+	return key, nil
+}
+
+func (s *URLSigner) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
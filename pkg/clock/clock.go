@@ -0,0 +1,57 @@
+// Package clock lets code read the time and wait through a Clock, so due
+// dates, token expiry and scheduled jobs can be driven deterministically in
+// tests with a Fake instead of depending on time.Now.
+package clock
+
+import "time"
+
+// Clock tells the time and makes timers that fire by it
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a Timer that fires once d has passed
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a Ticker that fires every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a time.Timer made by a Clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a time.Ticker made by a Clock
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Or returns c, or Real when c is nil, for constructors whose clock is
+// optional
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
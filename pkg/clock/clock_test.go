@@ -0,0 +1,95 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// fired returns what ch holds, or the zero time when it is empty
+func fired(ch <-chan time.Time) time.Time {
+	select {
+	case t := <-ch:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func TestFake_Timer(t *testing.T) {
+	c := NewFake(start)
+	timer := c.NewTimer(time.Minute)
+
+	c.Advance(59 * time.Second)
+	if got := fired(timer.C()); !got.IsZero() {
+		t.Fatalf("expected the timer pending, fired at %v", got)
+	}
+	c.Advance(2 * time.Second)
+	if got := fired(timer.C()); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected the timer to fire at its deadline, got %v", got)
+	}
+	if !c.Now().Equal(start.Add(61 * time.Second)) {
+		t.Errorf("expected the clock advanced fully, got %v", c.Now())
+	}
+	if timer.Stop() {
+		t.Error("expected Stop to report a fired timer")
+	}
+
+	stopped := c.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("expected Stop to report a pending timer")
+	}
+	c.Advance(time.Hour)
+	if got := fired(stopped.C()); !got.IsZero() {
+		t.Errorf("expected a stopped timer not to fire, got %v", got)
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(start)
+	ticker := c.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	c.Advance(10 * time.Second)
+	if got := fired(ticker.C()); !got.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("expected a tick at 10s, got %v", got)
+	}
+	// Unread ticks are dropped, as with time.Ticker
+	c.Advance(35 * time.Second)
+	if got := fired(ticker.C()); !got.Equal(start.Add(20 * time.Second)) {
+		t.Errorf("expected the first unread tick kept, got %v", got)
+	}
+	if got := fired(ticker.C()); !got.IsZero() {
+		t.Errorf("expected later ticks dropped, got %v", got)
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		timer := c.NewTimer(time.Hour)
+		done <- <-timer.C()
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	select {
+	case got := <-done:
+		if !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("expected the timer to fire at its deadline, got %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer never fired")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("expected nil to mean the real clock")
+	}
+	if c := NewFake(start); Or(c) != c {
+		t.Error("expected a set clock kept")
+	}
+}
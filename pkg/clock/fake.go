@@ -0,0 +1,138 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers and tickers fire
+// during Advance and Set, in deadline order, each time seen by their
+// channel being the moment they were due.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	// armed is signalled whenever a timer or ticker is created
+	armed chan struct{}
+}
+
+// fakeWaiter is a timer, or a ticker when period is set
+type fakeWaiter struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration
+	ch       chan time.Time
+}
+
+// NewFake returns a Fake reading now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, armed: make(chan struct{}, 1)}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer returns a Timer firing once the clock has advanced by d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+// NewTicker returns a Ticker firing each time the clock advances past
+// another d. Like time.Ticker, it drops ticks nobody has read.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	w := &fakeWaiter{clock: f, deadline: f.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.mu.Unlock()
+
+	select {
+	case f.armed <- struct{}{}:
+	default:
+	}
+	if d <= 0 {
+		f.Advance(0)
+	}
+	return w
+}
+
+// Advance moves the clock forward by d, firing what falls due
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing what falls due on the way. The clock
+// never moves backwards.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool { return f.waiters[i].deadline.Before(f.waiters[j].deadline) })
+		if len(f.waiters) == 0 || f.waiters[0].deadline.After(t) {
+			break
+		}
+		w := f.waiters[0]
+		if w.deadline.After(f.now) {
+			f.now = w.deadline
+		}
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+	}
+	if t.After(f.now) {
+		f.now = t
+	}
+}
+
+// Waiters returns how many timers and tickers are pending
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n timers and tickers are pending, so a
+// test can advance the clock once the code under test is waiting on it
+func (f *Fake) BlockUntil(n int) {
+	for f.Waiters() < n {
+		<-f.armed
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.ch }
+
+type fakeTicker struct{ *fakeWaiter }
+
+func (t fakeTicker) Stop() { t.fakeWaiter.Stop() }
+
+// Stop stops the timer or ticker, reporting whether it was still pending
+func (w *fakeWaiter) Stop() bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Index is an index a table is expected to have
+type Index struct {
+	Name string
+	// Columns are the index's key columns, in order
+	Columns []string
+	Unique  bool
+}
+
+// indexesQuery lists a table's indexes in the current schema with their key
+// columns, in order, comma-separated. Expression keys have no column and are
+// listed as "?".
+const indexesQuery = `
+	SELECT i.relname, ix.indisunique, string_agg(COALESCE(a.attname, '?'), ',' ORDER BY k.ord)
+	FROM pg_index ix
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_class t ON t.oid = ix.indrelid
+	CROSS JOIN LATERAL unnest(ix.indkey::smallint[]) WITH ORDINALITY AS k(attnum, ord)
+	LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum AND k.attnum > 0
+	WHERE t.relname = $1 AND t.relnamespace = current_schema()::regnamespace AND k.ord <= ix.indnkeyatts
+	GROUP BY i.relname, ix.indisunique`
+
+// MissingIndexes returns the names in expected that don't exist on table in
+// the current schema, or exist with other key columns or uniqueness: an index
+// recreated under the same name with another definition no longer serves the
+// queries, or enforces the constraint, it was created for
+func MissingIndexes(ctx context.Context, db DBTX, table string, expected []Index) ([]string, error) {
+	rows, err := db.QueryContext(ctx, indexesQuery, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}()
+
+	existing := make(map[string]Index)
+	for rows.Next() {
+		var index Index
+		var columns string
+		if err := rows.Scan(&index.Name, &index.Unique, &columns); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		index.Columns = strings.Split(columns, ",")
+		existing[index.Name] = index
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indexes: %w", err)
+	}
+
+	var missing []string
+	for _, want := range expected {
+		got, ok := existing[want.Name]
+		if !ok || got.Unique != want.Unique || !slices.Equal(got.Columns, want.Columns) {
+			missing = append(missing, want.Name)
+		}
+	}
+	return missing, nil
+}
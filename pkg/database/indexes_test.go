@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+)
+
+func TestMissingIndexes(t *testing.T) {
+	d := &fakeDriver{result: []string{
+		"transactions_pkey\ttrue\tid,created_at",
+		"idx_transactions_user_id\tfalse\tuser_id",
+		"idx_transactions_user_id_reference_id\ttrue\tuser_id,reference_id,created_at",
+		"idx_transactions_user_id_is_paid\ttrue\tuser_id,is_paid",
+	}}
+	db := sql.OpenDB(connector{d})
+	defer db.Close()
+
+	missing, err := MissingIndexes(context.Background(), db, "transactions", []Index{
+		{Name: "idx_transactions_user_id", Columns: []string{"user_id"}},
+		{Name: "idx_transactions_created_at", Columns: []string{"created_at"}},
+		{Name: "idx_transactions_user_id_reference_id", Columns: []string{"user_id", "reference_id"}, Unique: true},
+		{Name: "idx_transactions_user_id_is_paid", Columns: []string{"user_id", "is_paid"}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := []string{"idx_transactions_created_at", "idx_transactions_user_id_reference_id", "idx_transactions_user_id_is_paid"}
+	if !slices.Equal(missing, want) {
+		t.Errorf("expected absent indexes and those with other columns or uniqueness, got %v", missing)
+	}
+}
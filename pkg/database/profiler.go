@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DBTX is the query surface repositories need; *sql.DB and *Profiler both
+// implement it
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Profiler defaults
+const (
+	DefaultExplainSampleRate = 0.01
+	DefaultSlowPlanThreshold = 100 * time.Millisecond
+
+	// explainTimeout bounds one background EXPLAIN ANALYZE
+	explainTimeout = 10 * time.Second
+)
+
+// StatementStats is the latency record of one normalized statement
+type StatementStats struct {
+	Query   string        `json:"query"`
+	Calls   uint64        `json:"calls"`
+	Errors  uint64        `json:"errors"`
+	Total   time.Duration `json:"total_ns"`
+	Max     time.Duration `json:"max_ns"`
+	Explain uint64        `json:"explained"`
+}
+
+// Profiler wraps a *sql.DB to record per-statement latency and, for a sampled
+// fraction of statements, run EXPLAIN ANALYZE in the background and log plans
+// slower than the threshold. It is a development aid: EXPLAIN ANALYZE runs
+// the statement a second time (inside a rolled-back transaction, though
+// sequences still advance).
+type Profiler struct {
+	db            *sql.DB
+	logger        *slog.Logger
+	sampleRate    float64
+	slowThreshold time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*StatementStats
+
+	// explaining limits background plans to one at a time
+	explaining atomic.Bool
+	wg         sync.WaitGroup
+}
+
+// ProfilerOption configures a Profiler
+type ProfilerOption func(*Profiler)
+
+// WithExplainSampleRate sets the fraction of statements, from 0 to 1, that
+// are explained
+func WithExplainSampleRate(rate float64) ProfilerOption {
+	return func(p *Profiler) {
+		p.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithSlowPlanThreshold sets the execution time above which a plan is logged
+func WithSlowPlanThreshold(d time.Duration) ProfilerOption {
+	return func(p *Profiler) {
+		p.slowThreshold = d
+	}
+}
+
+// NewProfiler creates a Profiler over db
+func NewProfiler(db *sql.DB, logger *slog.Logger, opts ...ProfilerOption) *Profiler {
+	p := &Profiler{
+		db:            db,
+		logger:        logger,
+		sampleRate:    DefaultExplainSampleRate,
+		slowThreshold: DefaultSlowPlanThreshold,
+		stats:         make(map[string]*StatementStats),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Profiler) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := p.db.ExecContext(ctx, query, args...)
+	p.observe(query, args, time.Since(start), err)
+	return result, err
+}
+
+func (p *Profiler) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	p.observe(query, args, time.Since(start), err)
+	return rows, err
+}
+
+func (p *Profiler) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := p.db.QueryRowContext(ctx, query, args...)
+	p.observe(query, args, time.Since(start), row.Err())
+	return row
+}
+
+// Stats returns the recorded statements, slowest in total first
+func (p *Profiler) Stats() []StatementStats {
+	p.mu.Lock()
+	result := make([]StatementStats, 0, len(p.stats))
+	for _, s := range p.stats {
+		result = append(result, *s)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Total > result[j].Total })
+	return result
+}
+
+// ServeHTTP writes Stats as JSON, for a debug endpoint
+func (p *Profiler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.Stats()); err != nil {
+		p.logger.Error("failed to encode statement stats", "error", err)
+	}
+}
+
+// Close waits for a background EXPLAIN to finish. It does not close the
+// database.
+func (p *Profiler) Close() error {
+	p.wg.Wait()
+	return nil
+}
+
+func (p *Profiler) observe(query string, args []any, elapsed time.Duration, err error) {
+	key := normalizeQuery(query)
+
+	p.mu.Lock()
+	s, ok := p.stats[key]
+	if !ok {
+		s = &StatementStats{Query: key}
+		p.stats[key] = s
+	}
+	s.Calls++
+	s.Total += elapsed
+	s.Max = max(s.Max, elapsed)
+	if err != nil {
+		s.Errors++
+	}
+	p.mu.Unlock()
+
+	if err != nil || p.sampleRate == 0 || rand.Float64() >= p.sampleRate {
+		return
+	}
+	if !p.explaining.CompareAndSwap(false, true) {
+		return
+	}
+	p.wg.Go(func() {
+		defer p.explaining.Store(false)
+		p.explain(key, query, args)
+	})
+}
+
+// explain runs EXPLAIN ANALYZE for query in a transaction that is always
+// rolled back, and logs the plan if it ran slower than the threshold
+func (p *Profiler) explain(key, query string, args []any) {
+	ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+	defer cancel()
+
+	plan, err := p.explainPlan(ctx, query, args)
+	if err != nil {
+		p.logger.Warn("failed to explain statement", "query", key, "error", err)
+		return
+	}
+
+	p.mu.Lock()
+	p.stats[key].Explain++
+	p.mu.Unlock()
+
+	execTime, ok := planExecutionTime(plan)
+	if !ok || execTime < p.slowThreshold {
+		return
+	}
+	p.logger.Warn("slow statement plan",
+		"query", key,
+		"execution_time", execTime,
+		"seq_scan", strings.Contains(plan, "Seq Scan"),
+		"plan", plan,
+	)
+}
+
+func (p *Profiler) explainPlan(ctx context.Context, query string, args []any) (string, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin: %w", err)
+	}
+	defer func() {
+		// Writes made by ANALYZE must never land
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// planExecutionTime reads the "Execution Time: 1.234 ms" line of a plan
+func planExecutionTime(plan string) (time.Duration, bool) {
+	const prefix = "Execution Time:"
+	for line := range strings.Lines(plan) {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		value := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, prefix)), " ms")
+		ms, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	return 0, false
+}
+
+// normalizeQuery collapses whitespace so one statement has one key
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+var (
+	_ DBTX = (*sql.DB)(nil)
+	_ DBTX = (*Profiler)(nil)
+)
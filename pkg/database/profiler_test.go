@@ -0,0 +1,199 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver answers EXPLAIN with a canned plan and everything else with the
+// lines of result, recording each statement and whether its transaction
+// committed
+type fakeDriver struct {
+	mu         sync.Mutex
+	plan       string
+	result     []string
+	statements []string
+	commits    int
+	rollbacks  int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) record(query string) {
+	d.mu.Lock()
+	d.statements = append(d.statements, query)
+	d.mu.Unlock()
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return &fakeTx{d: c.d}, nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(query)
+	if strings.HasPrefix(query, "FAIL") {
+		return nil, errors.New("syntax error")
+	}
+	if strings.HasPrefix(query, "EXPLAIN") {
+		return &fakeRows{lines: strings.Split(c.d.plan, "\n")}, nil
+	}
+	return &fakeRows{lines: append([]string(nil), c.d.result...)}, nil
+}
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t *fakeTx) Commit() error {
+	t.d.mu.Lock()
+	t.d.commits++
+	t.d.mu.Unlock()
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.d.mu.Lock()
+	t.d.rollbacks++
+	t.d.mu.Unlock()
+	return nil
+}
+
+// fakeRows returns each line as a row. Tabs in the first line separate
+// columns; without any there is one.
+type fakeRows struct{ lines []string }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Columns() []string {
+	if len(r.lines) == 0 {
+		return []string{"QUERY PLAN"}
+	}
+	return strings.Split(r.lines[0], "\t")
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.lines) == 0 {
+		return io.EOF
+	}
+	for i, field := range strings.SplitN(r.lines[0], "\t", len(dest)) {
+		dest[i] = field
+	}
+	r.lines = r.lines[1:]
+	return nil
+}
+
+const slowPlan = `Seq Scan on transactions  (cost=0.00..35.50 rows=10 width=8) (actual time=0.010..250.000 rows=3 loops=1)
+  Filter: ((user_id = 1) AND (NOT is_paid))
+Planning Time: 0.050 ms
+Execution Time: 250.125 ms`
+
+func newTestProfiler(t *testing.T, plan string, opts ...ProfilerOption) (*Profiler, *fakeDriver, *bytes.Buffer) {
+	t.Helper()
+	d := &fakeDriver{plan: plan}
+	db := sql.OpenDB(connector{d})
+	t.Cleanup(func() { _ = db.Close() })
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	return NewProfiler(db, logger, opts...), d, &logs
+}
+
+type connector struct{ d *fakeDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+func TestProfiler_RecordsStatementStats(t *testing.T) {
+	p, _, _ := newTestProfiler(t, slowPlan, WithExplainSampleRate(0))
+	ctx := context.Background()
+
+	for range 3 {
+		rows, err := p.QueryContext(ctx, "SELECT id\n\t\tFROM transactions WHERE user_id = $1", 1)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		_ = rows.Close()
+	}
+	if _, err := p.ExecContext(ctx, "UPDATE transactions SET is_paid = true WHERE user_id = $1", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := p.QueryContext(ctx, "FAIL"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	byQuery := make(map[string]StatementStats)
+	for _, s := range p.Stats() {
+		byQuery[s.Query] = s
+	}
+	if s := byQuery["SELECT id FROM transactions WHERE user_id = $1"]; s.Calls != 3 || s.Errors != 0 {
+		t.Errorf("unexpected select stats %+v", s)
+	}
+	if s := byQuery["UPDATE transactions SET is_paid = true WHERE user_id = $1"]; s.Calls != 1 {
+		t.Errorf("unexpected update stats %+v", s)
+	}
+	if s := byQuery["FAIL"]; s.Calls != 1 || s.Errors != 1 {
+		t.Errorf("unexpected failed stats %+v", s)
+	}
+}
+
+func TestProfiler_LogsSlowPlanInRolledBackTransaction(t *testing.T) {
+	p, d, logs := newTestProfiler(t, slowPlan, WithExplainSampleRate(1), WithSlowPlanThreshold(100*time.Millisecond))
+
+	if _, err := p.ExecContext(context.Background(), "UPDATE transactions SET is_paid = true WHERE user_id = $1 AND is_paid = false", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "slow statement plan") || !strings.Contains(out, "seq_scan=true") {
+		t.Errorf("expected a slow plan log, got %q", out)
+	}
+	if d.commits != 0 || d.rollbacks != 1 {
+		t.Errorf("expected the EXPLAIN to be rolled back, got %d commits and %d rollbacks", d.commits, d.rollbacks)
+	}
+	if got := d.statements[len(d.statements)-1]; !strings.HasPrefix(got, "EXPLAIN (ANALYZE, BUFFERS) UPDATE") {
+		t.Errorf("unexpected explain statement %q", got)
+	}
+	if s := p.Stats()[0]; s.Explain != 1 {
+		t.Errorf("expected one explained run, got %d", s.Explain)
+	}
+}
+
+func TestProfiler_FastPlanNotLogged(t *testing.T) {
+	fastPlan := strings.Replace(slowPlan, "Execution Time: 250.125 ms", "Execution Time: 0.125 ms", 1)
+	p, _, logs := newTestProfiler(t, fastPlan, WithExplainSampleRate(1))
+
+	row := p.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM transactions")
+	_ = row.Err()
+	_ = p.Close()
+
+	if strings.Contains(logs.String(), "slow statement plan") {
+		t.Errorf("expected no log for a fast plan, got %q", logs.String())
+	}
+}
+
+func TestPlanExecutionTime(t *testing.T) {
+	got, ok := planExecutionTime(slowPlan)
+	if !ok || got != 250125*time.Microsecond {
+		t.Errorf("got %v, %v", got, ok)
+	}
+	if _, ok := planExecutionTime("Seq Scan on transactions"); ok {
+		t.Error("expected no execution time")
+	}
+}
@@ -0,0 +1,132 @@
+// Package events versions the JSON event payloads the services exchange.
+// Every payload carries its schema version in a "version" field; payloads
+// published before versioning have none and are version 1. Consumers pass
+// payloads through an Upcaster, which rewrites old versions one step at a
+// time into the current one before they are decoded, so a producer can move
+// to a new version while consumers still run the old code and the reverse.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Current payload versions, written by producers. Changing a payload
+// incompatibly means bumping its version and registering, in Default, the
+// step that upcasts the previous version.
+const (
+	TransactionCreatedVersion = 1
+	TransactionPaidVersion    = 1
+	PaymentRevertedVersion    = 1
+	ExportCompletedVersion    = 1
+	AccountDeletionVersion    = 1
+	NewDeviceLoginVersion     = 1
+	SpendAlertVersion         = 1
+	LimitApproachingVersion   = 1
+	AnalyticsAlertVersion     = 1
+)
+
+var (
+	// ErrUnsupportedVersion is returned by Upcast for payloads newer than
+	// the consumer's current version of their type
+	ErrUnsupportedVersion = errors.New("events: unsupported event version")
+	// ErrMissingStep is returned by Upcast when no step upcasts a version
+	// older than the current one
+	ErrMissingStep = errors.New("events: no upcast step for event version")
+)
+
+// Payload is an event decoded as a JSON object. Numbers are json.Numbers.
+type Payload map[string]any
+
+// Step rewrites a payload of one version into the next
+type Step func(Payload) error
+
+// Upcaster brings event payloads to the current version of their type.
+// Types it doesn't know pass through unchanged.
+type Upcaster struct {
+	current map[string]int
+	// steps holds, per type, the step from each version to the next
+	steps map[string]map[int]Step
+}
+
+// NewUpcaster returns an Upcaster that knows no event types
+func NewUpcaster() *Upcaster {
+	return &Upcaster{
+		current: make(map[string]int),
+		steps:   make(map[string]map[int]Step),
+	}
+}
+
+// Default returns an Upcaster for every event type the services publish
+func Default() *Upcaster {
+	u := NewUpcaster()
+	u.SetCurrent("transaction.created", TransactionCreatedVersion)
+	u.SetCurrent("transaction.paid", TransactionPaidVersion)
+	u.SetCurrent("transaction.payment_reverted", PaymentRevertedVersion)
+	u.SetCurrent("export.completed", ExportCompletedVersion)
+	u.SetCurrent("account.deletion_scheduled", AccountDeletionVersion)
+	u.SetCurrent("account.deletion_cancelled", AccountDeletionVersion)
+	u.SetCurrent("user.new_device_login", NewDeviceLoginVersion)
+	u.SetCurrent("alert.threshold_crossed", SpendAlertVersion)
+	u.SetCurrent("limit.approaching", LimitApproachingVersion)
+	u.SetCurrent("analytics.alert", AnalyticsAlertVersion)
+	return u
+}
+
+// SetCurrent sets the version payloads of eventType are upcast to
+func (u *Upcaster) SetCurrent(eventType string, version int) {
+	u.current[eventType] = version
+}
+
+// Register sets the step upcasting payloads of eventType from version from
+// to from+1
+func (u *Upcaster) Register(eventType string, from int, step Step) {
+	if u.steps[eventType] == nil {
+		u.steps[eventType] = make(map[int]Step)
+	}
+	u.steps[eventType][from] = step
+}
+
+// header holds the fields every payload shares
+type header struct {
+	EventType string `json:"event_type"`
+	Version   int    `json:"version"`
+}
+
+// Upcast returns value brought to the current version of its event type.
+// Payloads already current, and those of unknown types, are returned as
+// they are.
+func (u *Upcaster) Upcast(value []byte) ([]byte, error) {
+	var h header
+	if err := json.Unmarshal(value, &h); err != nil {
+		return nil, fmt.Errorf("events: invalid payload: %w", err)
+	}
+	current, ok := u.current[h.EventType]
+	version := max(h.Version, 1)
+	switch {
+	case !ok || version == current:
+		return value, nil
+	case version > current:
+		return nil, fmt.Errorf("%w: %s version %d, newest known is %d", ErrUnsupportedVersion, h.EventType, version, current)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	var payload Payload
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("events: invalid payload: %w", err)
+	}
+	for ; version < current; version++ {
+		step, ok := u.steps[h.EventType][version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s version %d", ErrMissingStep, h.EventType, version)
+		}
+		if err := step(payload); err != nil {
+			return nil, fmt.Errorf("events: upcasting %s version %d: %w", h.EventType, version, err)
+		}
+	}
+	payload["version"] = current
+	return json.Marshal(payload)
+}
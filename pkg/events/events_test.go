@@ -0,0 +1,80 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// newTestUpcaster upcasts "t" events to version 3: version 2 renamed amt
+// to amount, and version 3 added currency
+func newTestUpcaster() *Upcaster {
+	u := NewUpcaster()
+	u.SetCurrent("t", 3)
+	u.Register("t", 1, func(p Payload) error {
+		p["amount"] = p["amt"]
+		delete(p, "amt")
+		return nil
+	})
+	u.Register("t", 2, func(p Payload) error {
+		p["currency"] = "USD"
+		return nil
+	})
+	return u
+}
+
+func TestUpcast(t *testing.T) {
+	u := newTestUpcaster()
+	for _, tc := range []struct {
+		name, in string
+	}{
+		{"unversioned", `{"event_type":"t","amt":9007199254740993}`},
+		{"version 1", `{"event_type":"t","version":1,"amt":9007199254740993}`},
+		{"version 2", `{"event_type":"t","version":2,"amount":9007199254740993}`},
+	} {
+		out, err := u.Upcast([]byte(tc.in))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.name, err)
+		}
+		var got struct {
+			Version  int    `json:"version"`
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		}
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("%s: expected JSON, got %s: %v", tc.name, out, err)
+		}
+		// Numbers survive without a trip through float64
+		if got.Version != 3 || got.Amount != 9007199254740993 || got.Currency != "USD" {
+			t.Errorf("%s: expected version 3 with amount and currency, got %s", tc.name, out)
+		}
+	}
+}
+
+func TestUpcast_PassThrough(t *testing.T) {
+	u := newTestUpcaster()
+	for _, in := range []string{
+		`{"event_type":"t","version":3,"amount":1,"currency":"THB"}`,
+		`{"event_type":"other","amt":1}`,
+	} {
+		out, err := u.Upcast([]byte(in))
+		if err != nil || string(out) != in {
+			t.Errorf("expected %s unchanged, got %s, %v", in, out, err)
+		}
+	}
+}
+
+func TestUpcast_Errors(t *testing.T) {
+	u := newTestUpcaster()
+	if _, err := u.Upcast([]byte(`{"event_type":"t","version":4}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	u.SetCurrent("t", 5)
+	if _, err := u.Upcast([]byte(`{"event_type":"t","version":2}`)); !errors.Is(err, ErrMissingStep) {
+		t.Errorf("expected ErrMissingStep, got %v", err)
+	}
+	if _, err := u.Upcast([]byte(`not json`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,145 @@
+// Package fanout runs the backend calls behind a composite response
+// concurrently.
+//
+// Each call runs under its own timeout, so one slow backend can't hold up the
+// rest of the response. A call is either required, failing the whole group
+// and canceling the others, or optional, leaving a gap the response marks as
+// partial. Calls store their results themselves, typically in variables
+// captured by the function passed to Go:
+//
+//	g := fanout.New(ctx, fanout.WithTimeout(2*time.Second))
+//	g.Go("summary", func(ctx context.Context) (err error) {
+//		summary, err = client.GetSummary(ctx, req)
+//		return err
+//	})
+//	res, err := g.Wait()
+package fanout
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Group is a set of concurrent calls. A Group is used once: Go, then Wait.
+type Group struct {
+	eg      *errgroup.Group
+	ctx     context.Context
+	timeout time.Duration
+
+	mu     sync.Mutex
+	failed map[string]error
+}
+
+// Option configures a Group
+type Option func(*Group)
+
+// WithTimeout bounds every call to d, on top of the parent context's
+// deadline. Without it calls only end with the parent context.
+func WithTimeout(d time.Duration) Option {
+	return func(g *Group) {
+		g.timeout = d
+	}
+}
+
+// WithLimit caps how many calls run at once
+func WithLimit(n int) Option {
+	return func(g *Group) {
+		g.eg.SetLimit(n)
+	}
+}
+
+// New returns a Group whose calls run under ctx
+func New(ctx context.Context, opts ...Option) *Group {
+	eg, ctx := errgroup.WithContext(ctx)
+	g := &Group{eg: eg, ctx: ctx, failed: make(map[string]error)}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Go runs the optional call name. When fn fails, the result records it as
+// missing and the other calls carry on.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.eg.Go(func() error {
+		if err := g.call(fn); err != nil {
+			g.mu.Lock()
+			g.failed[name] = err
+			g.mu.Unlock()
+		}
+		return nil
+	})
+}
+
+// GoRequired runs the call name, which the response can't do without. When
+// fn fails, the other calls are canceled and Wait returns its error.
+func (g *Group) GoRequired(name string, fn func(ctx context.Context) error) {
+	g.eg.Go(func() error {
+		if err := g.call(fn); err != nil {
+			return &Error{Name: name, Err: err}
+		}
+		return nil
+	})
+}
+
+func (g *Group) call(fn func(ctx context.Context) error) error {
+	ctx := g.ctx
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+// Wait waits for every call. It returns an *Error for the first required
+// call that failed, and otherwise the optional calls that failed.
+func (g *Group) Wait() (Result, error) {
+	if err := g.eg.Wait(); err != nil {
+		return Result{}, err
+	}
+	return Result{failed: g.failed}, nil
+}
+
+// Result reports which optional calls failed
+type Result struct {
+	failed map[string]error
+}
+
+// Partial reports whether any optional call failed
+func (r Result) Partial() bool {
+	return len(r.failed) > 0
+}
+
+// Missing returns the names of the optional calls that failed, sorted
+func (r Result) Missing() []string {
+	names := make([]string, 0, len(r.failed))
+	for name := range r.failed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Err returns the error of the optional call name, nil when it succeeded
+func (r Result) Err(name string) error {
+	return r.failed[name]
+}
+
+// Error is the failure of a required call
+type Error struct {
+	Name string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
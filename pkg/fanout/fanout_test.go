@@ -0,0 +1,81 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGroup_Partial(t *testing.T) {
+	g := New(context.Background(), WithTimeout(20*time.Millisecond))
+
+	var summary string
+	g.Go("summary", func(ctx context.Context) error {
+		summary = "ok"
+		return nil
+	})
+	g.Go("recent", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	failed := errors.New("unavailable")
+	g.Go("alerts", func(ctx context.Context) error {
+		return failed
+	})
+
+	start := time.Now()
+	res, err := g.Wait()
+	if err != nil {
+		t.Fatalf("expected optional failures not to fail the group, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the slow call to be cut off by its timeout, waited %v", elapsed)
+	}
+	if summary != "ok" {
+		t.Errorf("expected the successful call's result, got %q", summary)
+	}
+	if !res.Partial() || !slices.Equal(res.Missing(), []string{"alerts", "recent"}) {
+		t.Errorf("expected alerts and recent missing, got %v", res.Missing())
+	}
+	if !errors.Is(res.Err("recent"), context.DeadlineExceeded) || res.Err("alerts") != failed || res.Err("summary") != nil {
+		t.Errorf("expected each call's error, got %v, %v and %v", res.Err("recent"), res.Err("alerts"), res.Err("summary"))
+	}
+}
+
+func TestGroup_RequiredFailure(t *testing.T) {
+	g := New(context.Background())
+
+	failed := errors.New("unavailable")
+	g.GoRequired("summary", func(ctx context.Context) error {
+		return failed
+	})
+	canceled := make(chan error, 1)
+	g.Go("recent", func(ctx context.Context) error {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return ctx.Err()
+	})
+
+	_, err := g.Wait()
+	var callErr *Error
+	if !errors.As(err, &callErr) || callErr.Name != "summary" || !errors.Is(err, failed) {
+		t.Fatalf("expected the required call's error, got %v", err)
+	}
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the other calls to be canceled, got %v", err)
+	}
+}
+
+func TestGroup_Complete(t *testing.T) {
+	g := New(context.Background(), WithLimit(1))
+	for _, name := range []string{"a", "b", "c"} {
+		g.GoRequired(name, func(ctx context.Context) error { return nil })
+	}
+
+	res, err := g.Wait()
+	if err != nil || res.Partial() || len(res.Missing()) != 0 {
+		t.Errorf("expected a complete result, got %v, %v", res.Missing(), err)
+	}
+}
@@ -0,0 +1,252 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+// Metadata keys used to carry caller identity
+const (
+	MetadataAuthorization = "authorization"
+	MetadataServiceToken  = "x-service-token"
+	MetadataUserID        = "x-user-id"
+	MetadataUsername      = "x-username"
+	MetadataTimezone      = "x-user-timezone"
+	MetadataLocale        = "x-user-locale"
+	MetadataScopes        = "x-user-scopes"
+	MetadataClientIP      = "x-forwarded-for"
+	MetadataClientCountry = "x-client-country"
+	MetadataClientASN     = "x-client-asn"
+	MetadataClientChannel = "x-client-channel"
+)
+
+// Identity is the authenticated end user of a gRPC request
+type Identity struct {
+	UserID   int
+	Username string
+	// Timezone and Locale are the user's preferences; empty when unknown
+	Timezone string
+	Locale   string
+	// Scopes are the API permissions granted to the caller
+	Scopes []string
+}
+
+// HasScope reports whether the identity was granted scope
+func (id *Identity) HasScope(scope string) bool {
+	return slices.Contains(id.Scopes, scope)
+}
+
+// Config holds interceptor configuration
+type Config struct {
+	// SecretKey validates "authorization: Bearer <jwt>" metadata
+	SecretKey string
+	// ServiceToken authenticates internal callers that forward an identity
+	// with x-user-id/x-username instead of a user token. Empty disables it.
+	ServiceToken string
+	// Required rejects calls without credentials; otherwise they pass through
+	// without an Identity in the context
+	Required bool
+	// MethodScopes maps full method names to the scope an authenticated
+	// caller needs to call them. Methods not listed need no scope.
+	MethodScopes map[string]string
+	// ClockSkew accepts tokens up to this long past expiry, for an auth
+	// service whose clock runs behind this one
+	ClockSkew time.Duration
+}
+
+type identityKey struct{}
+
+// FromContext returns the identity attached by the interceptor
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// UnaryServerInterceptor authenticates callers from request metadata.
+// A bearer JWT takes precedence over a forwarded identity.
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id, err := authenticate(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if id != nil {
+			if scope, ok := cfg.MethodScopes[info.FullMethod]; ok && !id.HasScope(scope) {
+				return nil, status.Errorf(codes.PermissionDenied, "missing scope %s", scope)
+			}
+			ctx = NewContext(ctx, id)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authenticate(ctx context.Context, cfg Config) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if token := first(md, MetadataAuthorization); token != "" {
+		parts := strings.Split(token, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata")
+		}
+		claims, err := jwt.ValidateToken(parts[1], cfg.SecretKey, jwt.WithLeeway(cfg.ClockSkew))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return &Identity{
+			UserID:   claims.UserID,
+			Username: claims.Username,
+			Timezone: claims.Timezone,
+			Locale:   claims.Locale,
+			Scopes:   claims.Scopes,
+		}, nil
+	}
+
+	if serviceToken := first(md, MetadataServiceToken); serviceToken != "" {
+		if !HasServiceToken(ctx, cfg.ServiceToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid service token")
+		}
+		userID, err := strconv.Atoi(first(md, MetadataUserID))
+		if err != nil || userID <= 0 {
+			return nil, status.Error(codes.Unauthenticated, "invalid forwarded user id")
+		}
+		return &Identity{
+			UserID:   userID,
+			Username: first(md, MetadataUsername),
+			Timezone: first(md, MetadataTimezone),
+			Locale:   first(md, MetadataLocale),
+			Scopes:   splitScopes(first(md, MetadataScopes)),
+		}, nil
+	}
+
+	if cfg.Required {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	return nil, nil
+}
+
+// WithBearerToken attaches a user JWT to outgoing gRPC metadata
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataAuthorization, "Bearer "+token)
+}
+
+// BearerToken returns the JWT the caller sent in authorization metadata, or
+// "" when it sent none
+func BearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token, ok := strings.CutPrefix(first(md, MetadataAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// WithForwardedIdentity attaches a service token and the end-user identity to
+// outgoing gRPC metadata
+func WithForwardedIdentity(ctx context.Context, serviceToken string, id Identity) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataServiceToken, serviceToken,
+		MetadataUserID, strconv.Itoa(id.UserID),
+		MetadataUsername, id.Username,
+		MetadataTimezone, id.Timezone,
+		MetadataLocale, id.Locale,
+		MetadataScopes, strings.Join(id.Scopes, " "),
+	)
+}
+
+// WithClientIP attaches the end user's address to outgoing gRPC metadata
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataClientIP, ip)
+}
+
+// ClientIP returns the address forwarded by the caller, or the peer address
+// when none was forwarded
+func ClientIP(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ip := first(md, MetadataClientIP); ip != "" {
+		return ip
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// WithClientGeo attaches where the end user's address is registered to
+// outgoing gRPC metadata. Empty values are left out.
+func WithClientGeo(ctx context.Context, country, asn string) context.Context {
+	var kv []string
+	if country != "" {
+		kv = append(kv, MetadataClientCountry, country)
+	}
+	if asn != "" {
+		kv = append(kv, MetadataClientASN, asn)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// ClientGeo returns the country code and AS number the caller forwarded,
+// empty when unknown
+func ClientGeo(ctx context.Context) (country, asn string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return first(md, MetadataClientCountry), first(md, MetadataClientASN)
+}
+
+// WithClientChannel attaches the kind of client a request came from, such as
+// "web" or "mobile", to outgoing gRPC metadata
+func WithClientChannel(ctx context.Context, channel string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataClientChannel, channel)
+}
+
+// ClientChannel returns the client kind the caller forwarded, empty when
+// unknown
+func ClientChannel(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return first(md, MetadataClientChannel)
+}
+
+// HasServiceToken reports whether the incoming metadata carries serviceToken.
+// It is always false when serviceToken is empty.
+func HasServiceToken(ctx context.Context, serviceToken string) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	got := first(md, MetadataServiceToken)
+	return serviceToken != "" && subtle.ConstantTimeCompare([]byte(got), []byte(serviceToken)) == 1
+}
+
+// splitScopes parses the space-separated scopes of a forwarded identity
+func splitScopes(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
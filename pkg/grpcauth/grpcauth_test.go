@@ -0,0 +1,162 @@
+package grpcauth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+)
+
+const testSecret = "test-secret-key"
+
+// call runs the interceptor with the given incoming metadata and returns the
+// identity seen by the handler
+func call(t *testing.T, cfg Config, md metadata.MD) (*Identity, error) {
+	t.Helper()
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	var seen *Identity
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen, _ = FromContext(ctx)
+		return nil, nil
+	}
+	_, err := UnaryServerInterceptor(cfg)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	return seen, err
+}
+
+func TestInterceptor_BearerToken(t *testing.T) {
+	token, err := jwt.GenerateTokenWithPreferences(42, "johndoe", jwt.Preferences{Timezone: "Asia/Bangkok"}, testSecret)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	id, err := call(t, Config{SecretKey: testSecret, Required: true},
+		metadata.Pairs(MetadataAuthorization, "Bearer "+token))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id == nil || id.UserID != 42 || id.Username != "johndoe" || id.Timezone != "Asia/Bangkok" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+}
+
+func TestInterceptor_InvalidBearerToken(t *testing.T) {
+	_, err := call(t, Config{SecretKey: testSecret},
+		metadata.Pairs(MetadataAuthorization, "Bearer not-a-token"))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestInterceptor_ForwardedIdentity(t *testing.T) {
+	cfg := Config{SecretKey: testSecret, ServiceToken: "svc", Required: true}
+	md := metadata.Pairs(MetadataServiceToken, "svc", MetadataUserID, "7", MetadataUsername, "alice")
+
+	id, err := call(t, cfg, md)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id == nil || id.UserID != 7 || id.Username != "alice" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+}
+
+func TestInterceptor_ForwardedIdentityRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		md   metadata.MD
+	}{
+		{"wrong token", Config{ServiceToken: "svc"}, metadata.Pairs(MetadataServiceToken, "other", MetadataUserID, "7")},
+		{"service tokens disabled", Config{}, metadata.Pairs(MetadataServiceToken, "svc", MetadataUserID, "7")},
+		{"missing user id", Config{ServiceToken: "svc"}, metadata.Pairs(MetadataServiceToken, "svc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := call(t, tt.cfg, tt.md); status.Code(err) != codes.Unauthenticated {
+				t.Errorf("expected Unauthenticated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestInterceptor_MissingCredentials(t *testing.T) {
+	if _, err := call(t, Config{Required: true}, metadata.MD{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated when required, got %v", err)
+	}
+
+	id, err := call(t, Config{}, metadata.MD{})
+	if err != nil || id != nil {
+		t.Errorf("expected anonymous pass-through, got identity %+v, error %v", id, err)
+	}
+}
+
+func TestWithForwardedIdentity_RoundTrip(t *testing.T) {
+	ctx := WithForwardedIdentity(context.Background(), "svc", Identity{UserID: 9, Username: "bob", Locale: "en-GB", Scopes: []string{jwt.ScopePaymentsRead}})
+	out, _ := metadata.FromOutgoingContext(ctx)
+
+	id, err := call(t, Config{ServiceToken: "svc"}, out)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id == nil || id.UserID != 9 || id.Username != "bob" || id.Locale != "en-GB" || !id.HasScope(jwt.ScopePaymentsRead) {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+}
+
+func TestInterceptor_MethodScopes(t *testing.T) {
+	const method = "/payment.PaymentService/CreateTransaction"
+	cfg := Config{SecretKey: testSecret, MethodScopes: map[string]string{method: jwt.ScopePaymentsWrite}}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		want   codes.Code
+	}{
+		{"granted", []string{jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite}, method, codes.OK},
+		{"missing", []string{jwt.ScopePaymentsRead}, method, codes.PermissionDenied},
+		{"no scopes", nil, method, codes.PermissionDenied},
+		{"unlisted method", nil, "/payment.PaymentService/GetSummary", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.IssueToken(1, "alice", testSecret, jwt.WithScopes(tt.scopes...))
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataAuthorization, "Bearer "+token))
+			_, err = UnaryServerInterceptor(cfg)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestClientGeo_RoundTrip(t *testing.T) {
+	ctx := WithClientGeo(context.Background(), "TH", "")
+	out, _ := metadata.FromOutgoingContext(ctx)
+
+	country, asn := ClientGeo(metadata.NewIncomingContext(context.Background(), out))
+	if country != "TH" || asn != "" {
+		t.Errorf("expected TH and no ASN, got %q and %q", country, asn)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	out, _ := metadata.FromOutgoingContext(WithBearerToken(context.Background(), "abc"))
+	if got := BearerToken(metadata.NewIncomingContext(context.Background(), out)); got != "abc" {
+		t.Errorf("expected abc, got %q", got)
+	}
+	basic := metadata.Pairs(MetadataAuthorization, "Basic abc")
+	if got := BearerToken(metadata.NewIncomingContext(context.Background(), basic)); got != "" {
+		t.Errorf("expected no token for basic auth, got %q", got)
+	}
+}
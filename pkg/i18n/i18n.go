@@ -0,0 +1,113 @@
+// Package i18n localizes error messages keyed by apperror codes.
+//
+// English is the source language: callers keep writing English messages and
+// the catalog only holds translations. A code without a translation falls back
+// to the caller's message, so responses never lose detail.
+package i18n
+
+import (
+	"sync"
+
+	"golang.org/x/text/language"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+// Catalog holds translated messages per language
+type Catalog struct {
+	mu       sync.RWMutex
+	source   language.Tag
+	tags     []language.Tag
+	messages map[language.Tag]map[string]string
+	matcher  language.Matcher
+}
+
+// NewCatalog creates an empty catalog whose messages are written in source
+func NewCatalog(source language.Tag) *Catalog {
+	c := &Catalog{
+		source:   source,
+		tags:     []language.Tag{source},
+		messages: make(map[language.Tag]map[string]string),
+	}
+	c.matcher = language.NewMatcher(c.tags)
+	return c
+}
+
+// Add registers translations for tag, keyed by apperror code
+func (c *Catalog) Add(tag language.Tag, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, ok := c.messages[tag]
+	if !ok {
+		existing = make(map[string]string, len(messages))
+		c.messages[tag] = existing
+		if tag != c.source {
+			c.tags = append(c.tags, tag)
+			c.matcher = language.NewMatcher(c.tags)
+		}
+	}
+	for code, msg := range messages {
+		existing[code] = msg
+	}
+}
+
+// Match returns the supported language that best fits an Accept-Language
+// header, or the source language when nothing matches
+func (c *Catalog) Match(acceptLanguage string) language.Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if acceptLanguage == "" {
+		return c.source
+	}
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return c.source
+	}
+	_, idx, conf := c.matcher.Match(prefs...)
+	if conf == language.No {
+		return c.source
+	}
+	return c.tags[idx]
+}
+
+// Message returns the translation of code in tag, or fallback when there is none
+func (c *Catalog) Message(tag language.Tag, code, fallback string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if msg, ok := c.messages[tag][code]; ok {
+		return msg
+	}
+	return fallback
+}
+
+// Default returns a catalog with the bundled translations
+func Default() *Catalog {
+	c := NewCatalog(language.English)
+	c.Add(language.Thai, map[string]string{
+		apperror.CodeBadRequest:          "คำขอไม่ถูกต้อง",
+		apperror.CodeUnauthorized:        "ไม่ได้รับอนุญาต",
+		apperror.CodeForbidden:           "ไม่มีสิทธิ์เข้าถึง",
+		apperror.CodeNotFound:            "ไม่พบข้อมูล",
+		apperror.CodeConflict:            "ข้อมูลซ้ำกับที่มีอยู่แล้ว",
+		apperror.CodeInternalServerError: "เกิดข้อผิดพลาดภายในระบบ",
+		apperror.CodeValidationFailed:    "ข้อมูลในคำขอไม่ถูกต้อง",
+		apperror.CodeMethodNotAllowed:    "ไม่รองรับเมธอดนี้",
+		apperror.CodeInvalidCredentials:  "ชื่อผู้ใช้หรือรหัสผ่านไม่ถูกต้อง",
+		apperror.CodeInvalidQuery:        "พารามิเตอร์ในคำขอไม่ถูกต้อง",
+		apperror.CodeInvalidTimezone:     "เขตเวลาไม่ถูกต้อง",
+		apperror.CodeInvalidLocale:       "ภาษาไม่ถูกต้อง",
+		apperror.CodeLimitExceeded:       "ยอดรวมเกินวงเงินสูงสุด",
+		apperror.CodeChargeFailed:        "การชำระเงินถูกปฏิเสธ รายการยังไม่ถูกชำระ",
+		apperror.CodeUpstreamUnavailable: "บริการปลายทางไม่พร้อมใช้งาน",
+		apperror.CodePayloadTooLarge:     "ข้อมูลในคำขอมีขนาดใหญ่เกินไป",
+		apperror.CodeQuotaExceeded:       "ใช้งานเกินโควตาคำขอรายวัน",
+		apperror.CodeMaintenance:         "ระบบอยู่ระหว่างปิดปรับปรุง กรุณาลองใหม่ภายหลัง",
+		apperror.CodeUnsupportedMedia:    "ไม่รองรับประเภทไฟล์นี้",
+		apperror.CodeRateLimited:         "มีคำขอมากเกินไป กรุณาลองใหม่ภายหลัง",
+		apperror.CodeCSRFFailed:          "โทเค็น CSRF ไม่ถูกต้อง",
+	})
+	return c
+}
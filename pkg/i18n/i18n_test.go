@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/tkaewplik/go-microservices/pkg/apperror"
+)
+
+func TestMatch(t *testing.T) {
+	c := Default()
+
+	tests := []struct {
+		name   string
+		header string
+		want   language.Tag
+	}{
+		{"empty", "", language.English},
+		{"thai", "th", language.Thai},
+		{"thai region", "th-TH,th;q=0.9", language.Thai},
+		{"quality order", "fr;q=0.5,th;q=0.8,en;q=0.1", language.Thai},
+		{"english", "en-GB", language.English},
+		{"unsupported", "de-DE", language.English},
+		{"malformed", "@@@", language.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Match(tt.header)
+			base, _ := got.Base()
+			wantBase, _ := tt.want.Base()
+			if base != wantBase {
+				t.Errorf("Match(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	c := Default()
+
+	if got := c.Message(language.English, apperror.CodeUnauthorized, "unauthorized"); got != "unauthorized" {
+		t.Errorf("english message = %q, want fallback", got)
+	}
+	if got := c.Message(language.Thai, apperror.CodeUnauthorized, "unauthorized"); got == "unauthorized" {
+		t.Error("expected thai translation")
+	}
+	if got := c.Message(language.Thai, "UNKNOWN_CODE", "something failed"); got != "something failed" {
+		t.Errorf("unknown code = %q, want fallback", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c := NewCatalog(language.English)
+	c.Add(language.Spanish, map[string]string{apperror.CodeNotFound: "no encontrado"})
+
+	tag := c.Match("es-MX")
+	if got := c.Message(tag, apperror.CodeNotFound, "not found"); got != "no encontrado" {
+		t.Errorf("Message = %q, want no encontrado", got)
+	}
+}
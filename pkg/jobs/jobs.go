@@ -0,0 +1,138 @@
+// Package jobs runs periodic maintenance jobs on one replica of a service at
+// a time. Replicas compete for a shared lock; the holder leads and runs
+// every job, and the others stand by until it lets go or dies.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+)
+
+// DefaultElectionInterval is how often a standby tries to take the lock
+const DefaultElectionInterval = 5 * time.Second
+
+// Job runs until its context is cancelled, doing its work on its own
+// schedule
+type Job func(ctx context.Context)
+
+// Runner runs its jobs while this replica holds the named lock. The jobs are
+// cancelled as soon as the lock's renewal fails, say when its database
+// connection drops; until then a new leader's jobs may overlap with them,
+// so jobs should still tolerate a rare repeat.
+type Runner struct {
+	locker   *lock.Locker
+	name     string
+	logger   *slog.Logger
+	interval time.Duration
+	clock    clock.Clock
+
+	jobs    map[string]Job
+	leading atomic.Bool
+}
+
+// Option configures a Runner
+type Option func(*Runner)
+
+// WithElectionInterval sets how often leadership is sought
+func WithElectionInterval(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// WithClock sets the clock elections tick by (default the system clock)
+func WithClock(c clock.Clock) Option {
+	return func(r *Runner) {
+		r.clock = clock.Or(c)
+	}
+}
+
+// NewRunner creates a Runner electing its leader by taking name from locker
+func NewRunner(locker *lock.Locker, name string, logger *slog.Logger, opts ...Option) *Runner {
+	r := &Runner{
+		locker:   locker,
+		name:     name,
+		logger:   logger,
+		interval: DefaultElectionInterval,
+		clock:    clock.Real,
+		jobs:     make(map[string]Job),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add registers a job under name; call before Run
+func (r *Runner) Add(name string, job Job) {
+	r.jobs[name] = job
+}
+
+// Leading reports whether this replica is running the jobs
+func (r *Runner) Leading() bool {
+	return r.leading.Load()
+}
+
+// Run competes for leadership until ctx is cancelled, running the jobs
+// whenever this replica leads
+func (r *Runner) Run(ctx context.Context) {
+	ticker := r.clock.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		lease, err := r.locker.TryAcquire(ctx, r.name)
+		switch {
+		case err == nil:
+			r.lead(ctx, lease)
+		case !errors.Is(err, lock.ErrHeld) && ctx.Err() == nil:
+			r.logger.Error("failed to seek job leadership", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// lead runs the jobs until ctx is cancelled or lease is lost, then releases
+// the lease once they have stopped
+func (r *Runner) lead(ctx context.Context, lease *lock.Lease) {
+	r.logger.Info("leading scheduled jobs", "jobs", len(r.jobs))
+	r.leading.Store(true)
+	jobCtx, cancel := lease.Context(ctx)
+	var wg sync.WaitGroup
+	for name, job := range r.jobs {
+		wg.Go(func() {
+			job(jobCtx)
+			if jobCtx.Err() == nil {
+				r.logger.Warn("scheduled job returned early", "job", name)
+			}
+		})
+	}
+
+	<-jobCtx.Done()
+	if errors.Is(context.Cause(jobCtx), lock.ErrLost) {
+		r.logger.Error("lost job leadership")
+	}
+	cancel()
+	wg.Wait()
+	r.leading.Store(false)
+
+	// The jobs have stopped, so another replica may take over
+	releaseCtx, cancelRelease := context.WithTimeout(context.Background(), r.interval)
+	defer cancelRelease()
+	if err := lease.Release(releaseCtx); err != nil {
+		r.logger.Warn("failed to release job leadership", "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+	"github.com/tkaewplik/go-microservices/pkg/lock"
+)
+
+// countingJob counts how many copies of it have started and are running
+func countingJob(started, running *atomic.Int32) Job {
+	return func(ctx context.Context) {
+		started.Add(1)
+		running.Add(1)
+		defer running.Add(-1)
+		<-ctx.Done()
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestRunner_OneLeaderRunsJobs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewFake(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	backend := lock.NewLocal(clk)
+	locker := lock.New(backend, lock.WithClock(clk), lock.WithTTL(3*time.Second))
+	var started, running atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewRunner(locker, "jobs", logger, WithClock(clk), WithElectionInterval(time.Second))
+	b := NewRunner(locker, "jobs", logger, WithClock(clk), WithElectionInterval(time.Second))
+	var wg sync.WaitGroup
+	for _, r := range []*Runner{a, b} {
+		r.Add("archive", countingJob(&started, &running))
+		wg.Go(func() { r.Run(ctx) })
+	}
+	// Two election tickers and the leader's renewal
+	clk.BlockUntil(3)
+	waitFor(t, "a leader", func() bool { return a.Leading() != b.Leading() })
+	waitFor(t, "the job to start", func() bool { return running.Load() == 1 })
+
+	// The leader's lock expires; it stops its job at its next renewal, and a
+	// new election runs it again on one replica
+	backend.Expire("jobs")
+	waitFor(t, "the job to move", func() bool {
+		clk.Advance(time.Second)
+		return started.Load() == 2 && running.Load() == 1
+	})
+
+	cancel()
+	wg.Wait()
+	if running.Load() != 0 {
+		t.Errorf("expected the jobs stopped, got %d running", running.Load())
+	}
+	lease, err := locker.TryAcquire(context.Background(), "jobs")
+	if err != nil {
+		t.Fatalf("expected the lock released, got %v", err)
+	}
+	_ = lease.Release(context.Background())
+}
@@ -1,39 +1,138 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+// API scopes granted by tokens
+const (
+	ScopePaymentsRead  = "payments:read"
+	ScopePaymentsWrite = "payments:write"
+	ScopeAnalyticsRead = "analytics:read"
 )
 
+// RoleAdmin is the role allowed to act on other users' data
+const RoleAdmin = "admin"
+
+// TokenTTL is how long an issued token is valid
+const TokenTTL = 24 * time.Hour
+
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	// Role is the user's role, e.g. "user" or "admin"; empty in tokens
+	// issued before roles existed
+	Role string `json:"role,omitempty"`
+	// Scopes are the API permissions the token grants
+	Scopes []string `json:"scopes,omitempty"`
+	Preferences
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, username, secretKey string) (string, error) {
+// Preferences are user settings carried in the token so services can use
+// them without a lookup
+type Preferences struct {
+	Timezone string `json:"tz,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+func GenerateToken(userID int, username, secretKey string, opts ...TokenOption) (string, error) {
+	return IssueToken(userID, username, secretKey, opts...)
+}
+
+// GenerateTokenWithPreferences issues a token that also carries prefs
+func GenerateTokenWithPreferences(userID int, username string, prefs Preferences, secretKey string) (string, error) {
+	return IssueToken(userID, username, secretKey, WithPreferences(prefs))
+}
+
+// TokenOption adds optional claims to an issued token
+type TokenOption func(*Claims)
+
+// WithPreferences sets the token's preference claims
+func WithPreferences(prefs Preferences) TokenOption {
+	return func(c *Claims) { c.Preferences = prefs }
+}
+
+// WithRole sets the token's role claim
+func WithRole(role string) TokenOption {
+	return func(c *Claims) { c.Role = role }
+}
+
+// WithScopes sets the token's scopes
+func WithScopes(scopes ...string) TokenOption {
+	return func(c *Claims) { c.Scopes = scopes }
+}
+
+// WithClock issues the token at c's current time instead of the system
+// clock's
+func WithClock(c clock.Clock) TokenOption {
+	return func(claims *Claims) { claims.setIssuedAt(c.Now()) }
+}
+
+func (c *Claims) setIssuedAt(now time.Time) {
+	c.IssuedAt = jwt.NewNumericDate(now)
+	c.ExpiresAt = jwt.NewNumericDate(now.Add(TokenTTL))
+}
+
+// IssueToken issues a token valid for TokenTTL
+func IssueToken(userID int, username, secretKey string, opts ...TokenOption) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	}
+	claims.setIssuedAt(time.Now())
+	for _, opt := range opts {
+		opt(&claims)
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secretKey))
 }
 
-func ValidateToken(tokenString, secretKey string) (*Claims, error) {
+// HasScope reports whether the token grants scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// ValidateOption changes how ValidateToken checks a token's times
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	clock  clock.Clock
+	leeway time.Duration
+}
+
+// ValidateWithClock checks expiry against c's current time instead of the
+// system clock's
+func ValidateWithClock(c clock.Clock) ValidateOption {
+	return func(o *validateOptions) { o.clock = c }
+}
+
+// WithLeeway accepts tokens up to d past expiry, to compensate for clock
+// skew between the issuing and validating services
+func WithLeeway(d time.Duration) ValidateOption {
+	return func(o *validateOptions) { o.leeway = d }
+}
+
+func ValidateToken(tokenString, secretKey string, opts ...ValidateOption) (*Claims, error) {
+	o := validateOptions{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(secretKey), nil
-	})
+	}, jwt.WithTimeFunc(o.clock.Now), jwt.WithLeeway(o.leeway))
 
 	if err != nil {
 		return nil, err
@@ -45,3 +144,32 @@ func ValidateToken(tokenString, secretKey string) (*Claims, error) {
 
 	return nil, fmt.Errorf("invalid token")
 }
+
+// Reasons a token failed validation, reported by FailureReason
+const (
+	ReasonMissing        = "missing"
+	ReasonMalformed      = "malformed"
+	ReasonExpired        = "expired"
+	ReasonNotYetValid    = "not_yet_valid"
+	ReasonBadSignature   = "bad_signature"
+	ReasonWrongAlgorithm = "wrong_algorithm"
+	ReasonInvalid        = "invalid"
+)
+
+// FailureReason classifies an error returned by ValidateToken
+func FailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ReasonMalformed
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ReasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ReasonNotYetValid
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return ReasonBadSignature
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ReasonWrongAlgorithm
+	default:
+		return ReasonInvalid
+	}
+}
@@ -1,8 +1,18 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
 )
 
 func TestGenerateToken_Success(t *testing.T) {
@@ -91,3 +101,171 @@ func TestTokenExpiration(t *testing.T) {
 		t.Errorf("expected expiry around %v, got %v", expectedExpiry, actualExpiry)
 	}
 }
+
+func TestValidateToken_Clock(t *testing.T) {
+	issued := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(issued)
+	token, err := GenerateToken(1, "testuser", "secret", WithClock(c))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := ValidateToken(token, "secret", ValidateWithClock(c))
+	if err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+	if !claims.IssuedAt.Equal(issued) || !claims.ExpiresAt.Equal(issued.Add(TokenTTL)) {
+		t.Errorf("unexpected times: issued %v, expires %v", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	c.Advance(TokenTTL + time.Second)
+	if _, err := ValidateToken(token, "secret", ValidateWithClock(c)); FailureReason(err) != ReasonExpired {
+		t.Errorf("expected the token expired, got %v", err)
+	}
+	if _, err := ValidateToken(token, "secret", ValidateWithClock(c), WithLeeway(time.Minute)); err != nil {
+		t.Errorf("expected leeway to accept the token, got %v", err)
+	}
+}
+
+func TestGenerateTokenWithPreferences(t *testing.T) {
+	secretKey := "test-secret-key"
+	prefs := Preferences{Timezone: "Asia/Bangkok", Locale: "th-TH"}
+
+	token, err := GenerateTokenWithPreferences(7, "somchai", prefs, secretKey)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := ValidateToken(token, secretKey)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.Preferences != prefs {
+		t.Errorf("expected preferences %+v, got %+v", prefs, claims.Preferences)
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	secretKey := "test-secret-key"
+	token, err := GenerateToken(1, "testuser", secretKey)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}).SignedString([]byte(secretKey))
+	if err != nil {
+		t.Fatalf("failed to sign expired token: %v", err)
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{UserID: 1}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to build unsigned token: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		secret string
+		want   string
+	}{
+		{"malformed", "not-a-token", secretKey, ReasonMalformed},
+		{"bad signature", token, "other-secret", ReasonBadSignature},
+		{"expired", expired, secretKey, ReasonExpired},
+		{"wrong algorithm", unsigned, secretKey, ReasonWrongAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateToken(tt.token, tt.secret)
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			if got := FailureReason(err); got != tt.want {
+				t.Errorf("FailureReason = %q, want %q (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+// FuzzValidateToken checks that ValidateToken never panics and only accepts
+// tokens whose HMAC signature really was made with the secret
+func FuzzValidateToken(f *testing.F) {
+	const secretKey = "fuzz-secret"
+
+	valid, err := GenerateTokenWithPreferences(1, "fuzzer", Preferences{Timezone: "UTC"}, secretKey)
+	if err != nil {
+		f.Fatalf("failed to generate token: %v", err)
+	}
+	forged, err := GenerateToken(1, "fuzzer", "other-secret")
+	if err != nil {
+		f.Fatalf("failed to generate token: %v", err)
+	}
+	f.Add(valid)
+	f.Add(forged)
+	f.Add("")
+	f.Add("a.b.c")
+	f.Add("eyJhbGciOiJub25lIn0.eyJ1c2VyX2lkIjoxfQ.")
+	f.Add(valid[:len(valid)-2])
+
+	f.Fuzz(func(t *testing.T, token string) {
+		claims, err := ValidateToken(token, secretKey)
+		if err != nil {
+			if FailureReason(err) == "" {
+				t.Errorf("empty failure reason for %v", err)
+			}
+			return
+		}
+		if claims == nil {
+			t.Fatal("nil claims without error")
+		}
+
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			t.Fatalf("accepted token with %d segments", len(parts))
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatalf("accepted token with undecodable signature: %v", err)
+		}
+		if !validHMAC(parts[0]+"."+parts[1], sig, secretKey) {
+			t.Fatalf("accepted token whose signature was not made with the secret: %q", token)
+		}
+	})
+}
+
+// validHMAC reports whether sig is an HS256, HS384 or HS512 signature of signed
+func validHMAC(signed string, sig []byte, secretKey string) bool {
+	for _, h := range []func() hash.Hash{sha256.New, sha512.New384, sha512.New} {
+		mac := hmac.New(h, []byte(secretKey))
+		mac.Write([]byte(signed))
+		if hmac.Equal(mac.Sum(nil), sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIssueToken_RoleAndScopes(t *testing.T) {
+	secretKey := "test-secret-key"
+
+	token, err := IssueToken(7, "somchai", secretKey, WithRole("admin"), WithScopes(ScopeAnalyticsRead))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	claims, err := ValidateToken(token, secretKey)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.Role != "admin" {
+		t.Errorf("expected role admin, got %q", claims.Role)
+	}
+	if !claims.HasScope(ScopeAnalyticsRead) || claims.HasScope(ScopePaymentsWrite) {
+		t.Errorf("expected only the analytics:read scope, got %v", claims.Scopes)
+	}
+}
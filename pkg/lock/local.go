@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+// Local keeps locks in process memory, for a single replica and for tests
+type Local struct {
+	clock clock.Clock
+
+	mu    sync.Mutex
+	locks map[string]localLock
+}
+
+type localLock struct {
+	owner   *localHandle
+	expires time.Time
+}
+
+// NewLocal creates a Local backend whose locks expire by c (default the
+// system clock)
+func NewLocal(c clock.Clock) *Local {
+	return &Local{clock: clock.Or(c), locks: make(map[string]localLock)}
+}
+
+// Lock takes name unless another holder has it and it hasn't expired
+func (b *Local) Lock(_ context.Context, name string, ttl time.Duration) (Handle, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if held, ok := b.locks[name]; ok && now.Before(held.expires) {
+		return nil, ErrHeld
+	}
+	h := &localHandle{backend: b, name: name}
+	b.locks[name] = localLock{owner: h, expires: now.Add(ttl)}
+	return h, nil
+}
+
+// Expire ends name's lock as if its TTL had passed, so its holder loses it
+// at its next renewal
+func (b *Local) Expire(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.locks, name)
+}
+
+type localHandle struct {
+	backend *Local
+	name    string
+}
+
+func (h *localHandle) Extend(_ context.Context, ttl time.Duration) error {
+	b := h.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	held, ok := b.locks[h.name]
+	if !ok || held.owner != h || !now.Before(held.expires) {
+		return ErrLost
+	}
+	b.locks[h.name] = localLock{owner: h, expires: now.Add(ttl)}
+	return nil
+}
+
+func (h *localHandle) Unlock(context.Context) error {
+	b := h.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if held, ok := b.locks[h.name]; ok && held.owner == h {
+		delete(b.locks, h.name)
+	}
+	return nil
+}
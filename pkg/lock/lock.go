@@ -0,0 +1,263 @@
+// Package lock provides named locks shared by the replicas of a service. A
+// Locker takes locks through a Backend (Postgres advisory locks, Redis keys
+// or process memory) and keeps them while they are held: each lock is
+// granted for a TTL and renewed in the background, and its Lease reports
+// when renewal fails so the holder can stop work it may no longer own.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+const (
+	// DefaultTTL is how long a lock outlives its last renewal; leases renew
+	// every third of it
+	DefaultTTL = 30 * time.Second
+	// DefaultRetryInterval is how often Acquire tries a lock held elsewhere
+	DefaultRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrHeld is returned when another holder has the lock
+	ErrHeld = errors.New("lock: held by another holder")
+	// ErrLost ends a lease whose lock expired or passed to another holder
+	// before it could be renewed
+	ErrLost = errors.New("lock: lost")
+	// ErrReleased ends a lease its holder released
+	ErrReleased = errors.New("lock: released")
+)
+
+// Backend takes locks in a store shared by the replicas
+type Backend interface {
+	// Lock takes name for ttl if it is free, returning ErrHeld when another
+	// holder has it
+	Lock(ctx context.Context, name string, ttl time.Duration) (Handle, error)
+}
+
+// Handle is a lock taken from a Backend
+type Handle interface {
+	// Extend keeps the lock for another ttl, returning ErrLost once it has
+	// expired or passed to another holder. Other errors may be transient.
+	Extend(ctx context.Context, ttl time.Duration) error
+	// Unlock frees the lock for other holders
+	Unlock(ctx context.Context) error
+}
+
+// Locker takes named locks from a Backend and renews them until released
+type Locker struct {
+	backend Backend
+	ttl     time.Duration
+	retry   time.Duration
+	clock   clock.Clock
+}
+
+// Option configures a Locker
+type Option func(*Locker)
+
+// WithTTL sets how long a lock outlives its last renewal, bounding how long
+// a crashed holder keeps it
+func WithTTL(ttl time.Duration) Option {
+	return func(l *Locker) {
+		if ttl > 0 {
+			l.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval sets how often Acquire tries a lock held elsewhere
+func WithRetryInterval(d time.Duration) Option {
+	return func(l *Locker) {
+		if d > 0 {
+			l.retry = d
+		}
+	}
+}
+
+// WithClock sets the clock renewals and retries tick by (default the system
+// clock)
+func WithClock(c clock.Clock) Option {
+	return func(l *Locker) {
+		l.clock = clock.Or(c)
+	}
+}
+
+// New creates a Locker taking its locks from backend
+func New(backend Backend, opts ...Option) *Locker {
+	l := &Locker{
+		backend: backend,
+		ttl:     DefaultTTL,
+		retry:   DefaultRetryInterval,
+		clock:   clock.Real,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// TryAcquire takes name if it is free, without waiting, returning ErrHeld
+// when another holder has it
+func (l *Locker) TryAcquire(ctx context.Context, name string) (*Lease, error) {
+	start := l.clock.Now()
+	handle, err := l.backend.Lock(ctx, name, l.ttl)
+	if err != nil {
+		return nil, err
+	}
+	lease := &Lease{
+		name:    name,
+		locker:  l,
+		handle:  handle,
+		done:    make(chan struct{}),
+		renewed: make(chan struct{}),
+	}
+	go lease.renew(start.Add(l.ttl))
+	return lease, nil
+}
+
+// Acquire waits for name until it is taken or ctx is done. Backend errors
+// are returned rather than retried.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	for {
+		lease, err := l.TryAcquire(ctx, name)
+		if !errors.Is(err, ErrHeld) {
+			return lease, err
+		}
+
+		timer := l.clock.NewTimer(l.retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// Do runs fn holding name, waiting for it first. fn's context is cancelled
+// if the lock is lost, and the lock is released once fn returns.
+func (l *Locker) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	lease, err := l.Acquire(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	leaseCtx, cancel := lease.Context(ctx)
+	err = fn(leaseCtx)
+	cancel()
+
+	releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), l.ttl)
+	defer cancelRelease()
+	if releaseErr := lease.Release(releaseCtx); releaseErr != nil && err == nil {
+		return fmt.Errorf("failed to release lock %s: %w", name, releaseErr)
+	}
+	return err
+}
+
+// Lease is a held lock, renewed in the background until it is released or
+// lost
+type Lease struct {
+	name   string
+	locker *Locker
+	handle Handle
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{}
+	// renewed is closed once the renewal goroutine has returned
+	renewed chan struct{}
+}
+
+// Name returns the name of the lock
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// Done is closed once the lease ends, by Release or because the lock was
+// lost
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns nil while the lease is held, then ErrLost or ErrReleased
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Context returns a copy of parent cancelled once the lease ends, with the
+// lease's Err as its cause
+func (l *Lease) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		select {
+		case <-l.done:
+			cancel(l.Err())
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// Release unlocks the lock. Releasing a lease that already ended does
+// nothing.
+func (l *Lease) Release(ctx context.Context) error {
+	if !l.end(ErrReleased) {
+		return nil
+	}
+	<-l.renewed
+	return l.handle.Unlock(ctx)
+}
+
+// end ends the lease with err, reporting whether it was still held
+func (l *Lease) end(err error) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false
+	}
+	l.err = err
+	close(l.done)
+	return true
+}
+
+// renew extends the lock every third of the TTL until the lease ends.
+// Failed renewals are retried until the lock's expiry passes, when the lock
+// is considered lost.
+func (l *Lease) renew(expires time.Time) {
+	defer close(l.renewed)
+	interval := l.locker.ttl / 3
+	ticker := l.locker.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C():
+		}
+
+		start := l.locker.clock.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := l.handle.Extend(ctx, l.locker.ttl)
+		cancel()
+		switch {
+		case err == nil:
+			expires = start.Add(l.locker.ttl)
+		case errors.Is(err, ErrLost) || !l.locker.clock.Now().Before(expires):
+			if l.end(ErrLost) {
+				// Free whatever part of the lock is still ours, so it is
+				// not held until it expires
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				_ = l.handle.Unlock(ctx)
+				cancel()
+			}
+			return
+		}
+	}
+}
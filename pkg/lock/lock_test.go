@@ -0,0 +1,170 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/clock"
+)
+
+var start = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// countingBackend counts the renewals of the locks it hands out
+type countingBackend struct {
+	Backend
+	extends atomic.Int32
+}
+
+func (b *countingBackend) Lock(ctx context.Context, name string, ttl time.Duration) (Handle, error) {
+	h, err := b.Backend.Lock(ctx, name, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return countingHandle{h, b}, nil
+}
+
+type countingHandle struct {
+	Handle
+	backend *countingBackend
+}
+
+func (h countingHandle) Extend(ctx context.Context, ttl time.Duration) error {
+	defer h.backend.extends.Add(1)
+	return h.Handle.Extend(ctx, ttl)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestLocker_RenewsUntilReleased(t *testing.T) {
+	clk := clock.NewFake(start)
+	backend := &countingBackend{Backend: NewLocal(clk)}
+	locker := New(backend, WithClock(clk), WithTTL(3*time.Second))
+
+	lease, err := locker.TryAcquire(context.Background(), "archive")
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	// Renewals every second keep the lock well past its TTL
+	clk.BlockUntil(1)
+	for i := range 10 {
+		clk.Advance(time.Second)
+		waitFor(t, "a renewal", func() bool { return backend.extends.Load() == int32(i+1) })
+	}
+	if _, err := locker.TryAcquire(context.Background(), "archive"); !errors.Is(err, ErrHeld) {
+		t.Fatalf("expected the renewed lock held, got %v", err)
+	}
+	if lease.Err() != nil {
+		t.Fatalf("expected the lease held, got %v", lease.Err())
+	}
+
+	if err := lease.Release(context.Background()); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if !errors.Is(lease.Err(), ErrReleased) {
+		t.Errorf("expected ErrReleased, got %v", lease.Err())
+	}
+	next, err := locker.TryAcquire(context.Background(), "archive")
+	if err != nil {
+		t.Fatalf("expected the released lock free, got %v", err)
+	}
+	_ = next.Release(context.Background())
+}
+
+func TestLocker_LostLeaseCancelsContext(t *testing.T) {
+	clk := clock.NewFake(start)
+	backend := NewLocal(clk)
+	locker := New(backend, WithClock(clk), WithTTL(3*time.Second))
+
+	lease, err := locker.TryAcquire(context.Background(), "backfill")
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	ctx, cancel := lease.Context(context.Background())
+	defer cancel()
+
+	clk.BlockUntil(1)
+	backend.Expire("backfill")
+	clk.Advance(time.Second)
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), ErrLost) || !errors.Is(lease.Err(), ErrLost) {
+		t.Errorf("expected the context cancelled by ErrLost, got %v", context.Cause(ctx))
+	}
+	if err := lease.Release(context.Background()); err != nil {
+		t.Errorf("expected releasing a lost lease to do nothing, got %v", err)
+	}
+}
+
+func TestLocker_AcquireWaits(t *testing.T) {
+	clk := clock.NewFake(start)
+	backend := NewLocal(clk)
+	locker := New(backend, WithClock(clk), WithTTL(3*time.Second), WithRetryInterval(time.Second))
+
+	// A holder that died without releasing keeps the lock until its TTL
+	// passes
+	if _, err := backend.Lock(context.Background(), "user:1", 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan *Lease)
+	go func() {
+		lease, err := locker.Acquire(context.Background(), "user:1")
+		if err != nil {
+			t.Errorf("Acquire() error = %v", err)
+		}
+		acquired <- lease
+	}()
+	for range 2 {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+	lease := <-acquired
+	if lease == nil {
+		return
+	}
+	defer func() { _ = lease.Release(context.Background()) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := locker.Do(ctx, "user:1", func(context.Context) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Do to give up with its context, got %v", err)
+	}
+}
+
+func TestLocal_ExpiresUnrenewedLocks(t *testing.T) {
+	clk := clock.NewFake(start)
+	backend := NewLocal(clk)
+
+	h, err := backend.Lock(context.Background(), "export", 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Lock(context.Background(), "export", 3*time.Second); !errors.Is(err, ErrHeld) {
+		t.Fatalf("expected ErrHeld, got %v", err)
+	}
+	clk.Advance(3 * time.Second)
+	if err := h.Extend(context.Background(), 3*time.Second); !errors.Is(err, ErrLost) {
+		t.Errorf("expected an expired lock lost, got %v", err)
+	}
+	if _, err := backend.Lock(context.Background(), "export", 3*time.Second); err != nil {
+		t.Errorf("expected an expired lock free, got %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("payment-service/jobs") != Key("payment-service/jobs") {
+		t.Error("expected the same name to give the same key")
+	}
+	if Key("payment-service/jobs") == Key("analytics-service/backfill") {
+		t.Error("expected different names to give different keys")
+	}
+}
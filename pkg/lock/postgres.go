@@ -0,0 +1,95 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Postgres takes session-level advisory locks, each on a connection of its
+// own from db's pool. Postgres releases a lock as soon as its connection
+// closes, even if the holder dies without releasing it, so the TTL only sets
+// how often the connection is checked.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres creates a Postgres backend on db
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Key hashes name to an advisory lock key
+func Key(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// Lock takes name's advisory lock without waiting
+func (p *Postgres) Lock(ctx context.Context, name string, _ time.Duration) (Handle, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := Key(name)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		discard(conn)
+		return nil, err
+	}
+	if !ok {
+		_ = conn.Close()
+		return nil, ErrHeld
+	}
+	return &postgresHandle{conn: conn, key: key}, nil
+}
+
+type postgresHandle struct {
+	mu   sync.Mutex
+	conn *sql.Conn
+	key  int64
+}
+
+// Extend pings the lock's connection; the lock lasts as long as it does
+func (h *postgresHandle) Extend(ctx context.Context, _ time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn == nil {
+		return ErrLost
+	}
+	if err := h.conn.PingContext(ctx); err != nil {
+		discard(h.conn)
+		h.conn = nil
+		return fmt.Errorf("%w: %v", ErrLost, err)
+	}
+	return nil
+}
+
+// Unlock unlocks and returns the connection to the pool
+func (h *postgresHandle) Unlock(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn == nil {
+		return nil
+	}
+	conn := h.conn
+	h.conn = nil
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", h.key); err != nil {
+		discard(conn)
+		return err
+	}
+	return conn.Close()
+}
+
+// discard closes conn rather than returning it to the pool, where it would
+// keep the lock held; ending the session releases the lock with it
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+}
@@ -0,0 +1,130 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces lock keys from other data in Redis
+const redisKeyPrefix = "lock:"
+
+var (
+	// extendScript and unlockScript only touch the key while it still holds
+	// the caller's token, so an expired lock taken by another holder is left
+	// alone
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Redis takes locks as keys holding a random token that expire after the
+// TTL. Given several independent servers it holds a lock only while a
+// majority of them agree, like a lighter Redlock: a lock taken or renewed
+// too slowly to leave any of its TTL is given up, but clock drift between
+// servers isn't compensated for.
+type Redis struct {
+	clients []*redis.Client
+}
+
+// NewRedis creates a Redis backend over one or more independent servers
+func NewRedis(clients ...*redis.Client) *Redis {
+	return &Redis{clients: clients}
+}
+
+// quorum is how many servers must agree on a lock
+func (r *Redis) quorum() int {
+	return len(r.clients)/2 + 1
+}
+
+// each runs fn on every server at once, counting those answering true and
+// false and joining the errors of the rest
+func (r *Redis) each(ctx context.Context, fn func(ctx context.Context, client *redis.Client) (bool, error)) (yes, no int, err error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	for _, client := range r.clients {
+		wg.Go(func() {
+			ok, callErr := fn(ctx, client)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case callErr != nil:
+				errs = append(errs, callErr)
+			case ok:
+				yes++
+			default:
+				no++
+			}
+		})
+	}
+	wg.Wait()
+	return yes, no, errors.Join(errs...)
+}
+
+// Lock sets name's key on every server that doesn't have it
+func (r *Redis) Lock(ctx context.Context, name string, ttl time.Duration) (Handle, error) {
+	h := &redisHandle{backend: r, key: redisKeyPrefix + name, token: rand.Text()}
+	start := time.Now()
+	taken, refused, err := r.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		return client.SetNX(ctx, h.key, h.token, ttl).Result()
+	})
+	if taken >= r.quorum() && time.Since(start) < ttl {
+		return h, nil
+	}
+
+	// Give back the servers that were taken, so the lock is free for the
+	// next try
+	_ = h.Unlock(context.WithoutCancel(ctx))
+	if refused > 0 || err == nil {
+		return nil, ErrHeld
+	}
+	return nil, err
+}
+
+type redisHandle struct {
+	backend *Redis
+	key     string
+	token   string
+}
+
+// Extend resets the key's expiry on the servers where it is still ours
+func (h *redisHandle) Extend(ctx context.Context, ttl time.Duration) error {
+	start := time.Now()
+	extended, lost, err := h.backend.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		n, err := extendScript.Run(ctx, client, []string{h.key}, h.token, ttl.Milliseconds()).Int()
+		return n == 1, err
+	})
+	if extended >= h.backend.quorum() && time.Since(start) < ttl {
+		return nil
+	}
+	if len(h.backend.clients)-lost < h.backend.quorum() {
+		return ErrLost
+	}
+	if err == nil {
+		err = errors.New("lock: renewal too slow")
+	}
+	return err
+}
+
+// Unlock deletes the key from the servers where it is still ours
+func (h *redisHandle) Unlock(ctx context.Context) error {
+	_, _, err := h.backend.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		n, err := unlockScript.Run(ctx, client, []string{h.key}, h.token).Int()
+		return n == 1, err
+	})
+	return err
+}
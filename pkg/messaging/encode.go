@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBuffer keeps an occasional large event from pinning its buffer in
+// the pool
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := &Buffer{}
+		b.enc = json.NewEncoder(&b.buf)
+		return b
+	},
+}
+
+// Buffer holds a JSON-encoded message in pooled memory. Release it once the
+// bytes are no longer referenced; until then it must not be shared.
+type Buffer struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// Marshal encodes v like json.Marshal but into a pooled Buffer, saving the
+// per-message allocation of the result
+func Marshal(v any) (*Buffer, error) {
+	b := bufferPool.Get().(*Buffer)
+	if err := b.enc.Encode(v); err != nil {
+		b.Release()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Bytes returns the encoded message, valid until Release
+func (b *Buffer) Bytes() []byte {
+	// Drop the newline json.Encoder appends so payloads match json.Marshal
+	out := b.buf.Bytes()
+	return out[:len(out)-1]
+}
+
+// Release returns the buffer to the pool. Only call it once the publish has
+// finished with the bytes: a writer that gave up on ctx may still hold them,
+// in which case let the garbage collector have the buffer instead.
+func (b *Buffer) Release() {
+	if b.buf.Cap() > maxPooledBuffer {
+		return
+	}
+	b.buf.Reset()
+	bufferPool.Put(b)
+}
@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func testEvent() *TransactionEvent {
+	return &TransactionEvent{
+		EventType:     EventTransactionCreated,
+		TransactionID: 42,
+		UserID:        7,
+		Amount:        250.5,
+		Description:   "coffee <& cake>",
+		Timestamp:     time.Date(2026, 10, 16, 9, 30, 0, 123456789, time.UTC),
+	}
+}
+
+func TestMarshal_MatchesJSONMarshal(t *testing.T) {
+	for _, v := range []any{testEvent(), &UserRegisteredEvent{Username: "alice"}, "plain", nil} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal failed: %v", err)
+		}
+
+		buf, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("got %s, want %s", buf.Bytes(), want)
+		}
+		buf.Release()
+	}
+}
+
+func TestMarshal_ReusedBufferStartsEmpty(t *testing.T) {
+	first, err := Marshal(testEvent())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	first.Release()
+
+	second, err := Marshal(1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer second.Release()
+	if got := string(second.Bytes()); got != "1" {
+		t.Errorf("expected 1, got %q", got)
+	}
+}
+
+func TestMarshal_Error(t *testing.T) {
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Error("expected error for an unsupported type")
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	event := testEvent()
+
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := json.Marshal(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			buf, err := Marshal(event)
+			if err != nil {
+				b.Fatal(err)
+			}
+			buf.Release()
+		}
+	})
+	b.Run("pooled-parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				buf, err := Marshal(event)
+				if err != nil {
+					b.Fatal(err)
+				}
+				buf.Release()
+			}
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrInjectedFault is returned by the writes a FaultyWriter fails on purpose
+var ErrInjectedFault = errors.New("messaging: injected fault")
+
+// PublishFaults describes faults injected into Kafka writes so retries,
+// outbox relays and reconciliation can be exercised in staging. The zero
+// value injects nothing.
+type PublishFaults struct {
+	// DropRate is the fraction of writes, from 0 to 1, reported as
+	// successful but never sent
+	DropRate float64
+	// ErrorRate is the fraction of writes failed with ErrInjectedFault
+	ErrorRate float64
+}
+
+// Enabled reports whether f injects any fault
+func (f PublishFaults) Enabled() bool {
+	return f.DropRate > 0 || f.ErrorRate > 0
+}
+
+// MessageWriter writes Kafka messages, as *kafka.Writer does
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// FaultyWriter drops or fails a fraction of the writes to the MessageWriter
+// it wraps. A write is all or nothing: every message in it is dropped or
+// failed together, as a broker outage would.
+type FaultyWriter struct {
+	MessageWriter
+	faults  PublishFaults
+	logger  *slog.Logger
+	roll    func() float64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewFaultyWriter wraps w with faults
+func NewFaultyWriter(w MessageWriter, faults PublishFaults, logger *slog.Logger) *FaultyWriter {
+	logger.Warn("Kafka fault injection enabled", "drop_rate", faults.DropRate, "error_rate", faults.ErrorRate)
+	return &FaultyWriter{
+		MessageWriter: w,
+		faults:        faults,
+		logger:        logger,
+		roll:          rand.Float64,
+	}
+}
+
+// WriteMessages writes msgs unless the write is picked to be dropped or
+// failed
+func (w *FaultyWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	roll := w.roll()
+	switch {
+	case roll < w.faults.DropRate:
+		w.dropped.Add(int64(len(msgs)))
+		w.logger.Warn("injected fault: Kafka write dropped", "messages", len(msgs))
+		return nil
+	case roll < w.faults.DropRate+w.faults.ErrorRate:
+		w.failed.Add(int64(len(msgs)))
+		return ErrInjectedFault
+	}
+	return w.MessageWriter.WriteMessages(ctx, msgs...)
+}
+
+// Injected returns how many messages were dropped and failed on purpose
+func (w *FaultyWriter) Injected() (dropped, failed int64) {
+	return w.dropped.Load(), w.failed.Load()
+}
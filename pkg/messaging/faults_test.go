@@ -0,0 +1,51 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// recordingWriter keeps the messages written to it
+type recordingWriter struct {
+	msgs []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+func TestFaultyWriter(t *testing.T) {
+	inner := &recordingWriter{}
+	w := NewFaultyWriter(inner, PublishFaults{DropRate: 0.1, ErrorRate: 0.2}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	two := []kafka.Message{{Value: []byte("a")}, {Value: []byte("b")}}
+
+	for _, tc := range []struct {
+		roll    float64
+		wantErr error
+	}{
+		{0.05, nil},              // dropped
+		{0.25, ErrInjectedFault}, // failed
+		{0.5, nil},               // written
+	} {
+		w.roll = func() float64 { return tc.roll }
+		if err := w.WriteMessages(ctx, two...); !errors.Is(err, tc.wantErr) {
+			t.Errorf("roll %v: expected %v, got %v", tc.roll, tc.wantErr, err)
+		}
+	}
+
+	if len(inner.msgs) != 2 {
+		t.Errorf("expected only the last write to reach the writer, got %d messages", len(inner.msgs))
+	}
+	if dropped, failed := w.Injected(); dropped != 2 || failed != 2 {
+		t.Errorf("expected 2 dropped and 2 failed, got %d and %d", dropped, failed)
+	}
+}
@@ -2,7 +2,6 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,6 +12,9 @@ import (
 // KafkaConfig holds Kafka connection configuration
 type KafkaConfig struct {
 	Brokers []string // e.g., ["localhost:9092"]
+	// Partitioner picks the partition of published messages; the zero value
+	// is DefaultPartitioner
+	Partitioner Partitioner
 }
 
 // KafkaProducer represents a Kafka producer
@@ -32,12 +34,12 @@ func NewKafkaProducer(cfg KafkaConfig, topic string, logger *slog.Logger) *Kafka
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(cfg.Brokers...),
 		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     cfg.Partitioner.Balancer(),
 		BatchTimeout: 10 * time.Millisecond,
 		RequiredAcks: kafka.RequireOne,
 	}
 
-	logger.Info("Kafka producer created", "brokers", cfg.Brokers, "topic", topic)
+	logger.Info("Kafka producer created", "brokers", cfg.Brokers, "topic", topic, "partitioner", cfg.Partitioner)
 
 	return &KafkaProducer{
 		writer: writer,
@@ -47,7 +49,7 @@ func NewKafkaProducer(cfg KafkaConfig, topic string, logger *slog.Logger) *Kafka
 
 // Publish publishes a message to Kafka
 func (p *KafkaProducer) Publish(ctx context.Context, key string, message interface{}) error {
-	value, err := json.Marshal(message)
+	value, err := Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
@@ -55,13 +57,14 @@ func (p *KafkaProducer) Publish(ctx context.Context, key string, message interfa
 	err = p.writer.WriteMessages(ctx,
 		kafka.Message{
 			Key:   []byte(key),
-			Value: value,
+			Value: value.Bytes(),
 			Time:  time.Now(),
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
+	value.Release()
 
 	p.logger.Debug("message published to Kafka", "key", key)
 	return nil
@@ -76,18 +79,31 @@ func (p *KafkaProducer) Close() error {
 	return nil
 }
 
-// NewKafkaConsumer creates a new Kafka consumer
-func NewKafkaConsumer(cfg KafkaConfig, topic, groupID string, logger *slog.Logger) *KafkaConsumer {
+// NewKafkaConsumer creates a new Kafka consumer of topics. A group with no
+// committed offsets starts at the beginning of each topic.
+func NewKafkaConsumer(cfg KafkaConfig, topics []string, groupID string, logger *slog.Logger) *KafkaConsumer {
+	return newKafkaConsumer(cfg, topics, groupID, kafka.FirstOffset, logger)
+}
+
+// NewKafkaTailConsumer creates a Kafka consumer for live notifications: a
+// group with no committed offsets starts at the end of each topic, so it
+// only sees messages published from then on
+func NewKafkaTailConsumer(cfg KafkaConfig, topics []string, groupID string, logger *slog.Logger) *KafkaConsumer {
+	return newKafkaConsumer(cfg, topics, groupID, kafka.LastOffset, logger)
+}
+
+func newKafkaConsumer(cfg KafkaConfig, topics []string, groupID string, startOffset int64, logger *slog.Logger) *KafkaConsumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        cfg.Brokers,
-		Topic:          topic,
+		GroupTopics:    topics,
 		GroupID:        groupID,
 		MinBytes:       1,    // 1B
 		MaxBytes:       10e6, // 10MB
 		CommitInterval: time.Second,
+		StartOffset:    startOffset,
 	})
 
-	logger.Info("Kafka consumer created", "brokers", cfg.Brokers, "topic", topic, "group", groupID)
+	logger.Info("Kafka consumer created", "brokers", cfg.Brokers, "topics", topics, "group", groupID, "from_end", startOffset == kafka.LastOffset)
 
 	return &KafkaConsumer{
 		reader: reader,
@@ -96,7 +112,7 @@ func NewKafkaConsumer(cfg KafkaConfig, topic, groupID string, logger *slog.Logge
 }
 
 // KafkaMessageHandler is a function that handles a Kafka message
-type KafkaMessageHandler func(key, value []byte) error
+type KafkaMessageHandler = KeyedMessageHandler
 
 // Consume starts consuming messages from Kafka
 func (c *KafkaConsumer) Consume(ctx context.Context, handler KafkaMessageHandler) error {
@@ -127,6 +143,12 @@ func (c *KafkaConsumer) Consume(ctx context.Context, handler KafkaMessageHandler
 	}
 }
 
+// Lag returns how many messages the consumer is behind the end of its
+// partitions, as of its last fetch
+func (c *KafkaConsumer) Lag() int64 {
+	return c.reader.Stats().Lag
+}
+
 // Close closes the Kafka consumer
 func (c *KafkaConsumer) Close() error {
 	if err := c.reader.Close(); err != nil {
@@ -144,11 +166,30 @@ const (
 
 // TransactionEvent represents a transaction event for Kafka
 type TransactionEvent struct {
-	EventType     string    `json:"event_type"`
-	TransactionID int       `json:"transaction_id"`
-	UserID        int       `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	Description   string    `json:"description"`
-	IsPaid        bool      `json:"is_paid"`
-	Timestamp     time.Time `json:"timestamp"`
+	EventType string `json:"event_type"`
+	// Version is the payload's schema version, see pkg/events
+	Version       int     `json:"version"`
+	TransactionID int     `json:"transaction_id"`
+	UserID        int     `json:"user_id"`
+	Amount        float64 `json:"amount"`
+	Description   string  `json:"description"`
+	IsPaid        bool    `json:"is_paid"`
+	// Source is where the request behind the event came from, when known
+	Source    *EventSource `json:"source,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// Client channels an EventSource may name
+const (
+	ChannelWeb    = "web"
+	ChannelMobile = "mobile"
+	ChannelAPI    = "api"
+)
+
+// EventSource describes the client behind an event
+type EventSource struct {
+	// Channel is ChannelWeb, ChannelMobile or ChannelAPI
+	Channel string `json:"channel,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 code of the client's address
+	Country string `json:"country,omitempty"`
 }
@@ -0,0 +1,37 @@
+// Package messaging publishes and consumes JSON events over Kafka, RabbitMQ
+// or NATS JetStream.
+package messaging
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KeyedMessageHandler handles one consumed message and the key it was
+// published with
+type KeyedMessageHandler func(key, value []byte) error
+
+// Publisher publishes keyed JSON messages to the topic or subject it was
+// created for. Messages with the same key are delivered in order.
+type Publisher interface {
+	Publish(ctx context.Context, key string, message interface{}) error
+	Close() error
+}
+
+// Subscriber consumes messages until ctx is cancelled
+type Subscriber interface {
+	Consume(ctx context.Context, handler KeyedMessageHandler) error
+	Close() error
+}
+
+var (
+	_ Publisher  = (*KafkaProducer)(nil)
+	_ Subscriber = (*KafkaConsumer)(nil)
+	_ Publisher  = (*NATSProducer)(nil)
+	_ Subscriber = (*NATSConsumer)(nil)
+	_ Subscriber = (*PrioritySubscriber)(nil)
+
+	_ MessageWriter = (*kafka.Writer)(nil)
+	_ MessageWriter = (*FaultyWriter)(nil)
+)
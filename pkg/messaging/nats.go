@@ -0,0 +1,174 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSKeyHeader carries a message's key, which JetStream has no field for
+const NATSKeyHeader = "Msg-Key"
+
+// natsAckWait is how long a delivered message may go unacknowledged before
+// JetStream redelivers it
+const natsAckWait = 30 * time.Second
+
+// NATSConfig holds NATS connection configuration
+type NATSConfig struct {
+	URL string // nats://host:4222
+}
+
+// NATS is a JetStream connection. Streams play the role of Kafka topics and
+// durable consumers that of consumer groups.
+type NATS struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	logger *slog.Logger
+}
+
+// NewNATS connects to the server at cfg.URL
+func NewNATS(cfg NATSConfig, logger *slog.Logger) (*NATS, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("go-microservices"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	logger.Info("connected to NATS", "url", cfg.URL)
+	return &NATS{conn: conn, js: js, logger: logger}, nil
+}
+
+// DeclareStream creates or updates a file-backed stream capturing subjects
+func (n *NATS) DeclareStream(ctx context.Context, name string, subjects ...string) error {
+	_, err := n.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: subjects,
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare stream %s: %w", name, err)
+	}
+	n.logger.Info("stream declared", "name", name, "subjects", subjects)
+	return nil
+}
+
+// DeclareConsumer creates or updates a durable consumer of stream. Consumers
+// sharing a durable name split the messages, like a Kafka consumer group; an
+// empty filterSubject receives the whole stream.
+func (n *NATS) DeclareConsumer(ctx context.Context, stream, durable, filterSubject string) error {
+	_, err := n.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: filterSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare consumer %s: %w", durable, err)
+	}
+	n.logger.Info("consumer declared", "stream", stream, "durable", durable)
+	return nil
+}
+
+// NewProducer returns a Publisher for subject, which a declared stream must
+// capture
+func (n *NATS) NewProducer(subject string) *NATSProducer {
+	return &NATSProducer{js: n.js, subject: subject, logger: n.logger}
+}
+
+// NewConsumer returns a Subscriber reading a declared durable consumer
+func (n *NATS) NewConsumer(stream, durable string) *NATSConsumer {
+	return &NATSConsumer{js: n.js, stream: stream, durable: durable, logger: n.logger}
+}
+
+// Close drains in-flight messages and closes the connection
+func (n *NATS) Close() error {
+	if err := n.conn.Drain(); err != nil {
+		return fmt.Errorf("failed to drain NATS connection: %w", err)
+	}
+	n.logger.Info("NATS connection closed")
+	return nil
+}
+
+// NATSProducer publishes to one subject
+type NATSProducer struct {
+	js      jetstream.JetStream
+	subject string
+	logger  *slog.Logger
+}
+
+// Publish publishes a message and waits for JetStream to store it
+func (p *NATSProducer) Publish(ctx context.Context, key string, message interface{}) error {
+	value, err := Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	msg := nats.NewMsg(p.subject)
+	msg.Header.Set(NATSKeyHeader, key)
+	msg.Data = value.Bytes()
+	if _, err := p.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	value.Release()
+
+	p.logger.Debug("message published to NATS", "subject", p.subject, "key", key)
+	return nil
+}
+
+// Close is a no-op; the NATS connection is closed by its owner
+func (p *NATSProducer) Close() error {
+	return nil
+}
+
+// NATSConsumer reads one durable consumer
+type NATSConsumer struct {
+	js      jetstream.JetStream
+	stream  string
+	durable string
+	logger  *slog.Logger
+}
+
+// Consume passes messages to handler until ctx is cancelled. Handled
+// messages are acknowledged; failed ones are redelivered.
+func (c *NATSConsumer) Consume(ctx context.Context, handler KeyedMessageHandler) error {
+	consumer, err := c.js.Consumer(ctx, c.stream, c.durable)
+	if err != nil {
+		return fmt.Errorf("failed to find consumer %s: %w", c.durable, err)
+	}
+
+	c.logger.Info("starting NATS consumer", "stream", c.stream, "durable", c.durable)
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		if err := handler([]byte(msg.Headers().Get(NATSKeyHeader)), msg.Data()); err != nil {
+			c.logger.Error("failed to handle message", "error", err, "subject", msg.Subject())
+			if err := msg.Nak(); err != nil {
+				c.logger.Error("failed to nak message", "error", err)
+			}
+			return
+		}
+		if err := msg.Ack(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+			c.logger.Error("failed to ack message", "error", err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start consumer %s: %w", c.durable, err)
+	}
+
+	<-ctx.Done()
+	cc.Drain()
+	<-cc.Closed()
+	return nil
+}
+
+// Close is a no-op; Consume stops with its context
+func (c *NATSConsumer) Close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tkaewplik/go-microservices/pkg/messaging/natsserver"
+)
+
+func newTestNATS(t *testing.T) *NATS {
+	t.Helper()
+	srv, err := natsserver.Start(natsserver.Config{Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to start embedded NATS: %v", err)
+	}
+	t.Cleanup(srv.Shutdown)
+
+	n, err := NewNATS(NATSConfig{URL: srv.ClientURL()}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = n.Close() })
+	return n
+}
+
+func TestNATS_PublishConsume(t *testing.T) {
+	n := newTestNATS(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := n.DeclareStream(ctx, "TRANSACTIONS", "transactions.>"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.DeclareConsumer(ctx, "TRANSACTIONS", "analytics", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	producer := n.NewProducer("transactions.created")
+	for _, userID := range []int{7, 8} {
+		event := TransactionEvent{EventType: "transaction.created", UserID: userID, Amount: 10}
+		if err := producer.Publish(ctx, strconv.Itoa(userID), event); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		keys       []string
+		failedOnce bool
+	)
+	got := make(chan struct{}, 2)
+	consumeCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- n.NewConsumer("TRANSACTIONS", "analytics").Consume(consumeCtx, func(key, value []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			// Fail the first delivery to check it is redelivered
+			if !failedOnce {
+				failedOnce = true
+				return errors.New("not yet")
+			}
+			keys = append(keys, string(key))
+			got <- struct{}{}
+			return nil
+		})
+	}()
+
+	for range 2 {
+		select {
+		case <-got:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+	stop()
+	if err := <-done; err != nil {
+		t.Errorf("expected Consume to stop cleanly, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	seen := map[string]bool{}
+	for _, k := range keys {
+		seen[k] = true
+	}
+	if !seen["7"] || !seen["8"] {
+		t.Errorf("expected both keys to be delivered, got %v", keys)
+	}
+}
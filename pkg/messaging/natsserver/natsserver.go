@@ -0,0 +1,63 @@
+// Package natsserver runs a NATS server with JetStream inside the process,
+// for single-binary deployments and tests where a separate broker is too
+// heavy. It lives apart from messaging so clients don't link the server.
+package natsserver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// startTimeout bounds how long Start waits for the server to accept clients
+const startTimeout = 10 * time.Second
+
+// ErrNotReady is returned when the server doesn't start in time
+var ErrNotReady = errors.New("embedded NATS server not ready")
+
+// Config configures an embedded server
+type Config struct {
+	// Host and Port to listen on; Port -1 picks a free port
+	Host string
+	Port int
+	// StoreDir keeps JetStream streams on disk; empty uses a temporary directory
+	StoreDir string
+}
+
+// Server is a running embedded NATS server
+type Server struct {
+	srv *server.Server
+}
+
+// Start starts a server for cfg and waits until it accepts clients
+func Start(cfg Config) (*Server, error) {
+	srv, err := server.NewServer(&server.Options{
+		Host:      cfg.Host,
+		Port:      cfg.Port,
+		JetStream: true,
+		StoreDir:  cfg.StoreDir,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedded NATS server: %w", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(startTimeout) {
+		srv.Shutdown()
+		return nil, ErrNotReady
+	}
+	return &Server{srv: srv}, nil
+}
+
+// ClientURL is the URL clients connect to
+func (s *Server) ClientURL() string {
+	return s.srv.ClientURL()
+}
+
+// Shutdown stops the server and waits for it to exit
+func (s *Server) Shutdown() {
+	s.srv.Shutdown()
+	s.srv.WaitForShutdown()
+}
@@ -0,0 +1,61 @@
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Partitioner picks the Kafka partition of each published message.
+//
+// Events are keyed by user ID, and Kafka only orders messages within a
+// partition, so a user's events stay in order only when every message with
+// that key lands on the same partition. The key-hashing partitioners
+// guarantee that for as long as the topic's partition count doesn't change;
+// adding partitions moves keys, and events published around the change may
+// be read out of order.
+type Partitioner string
+
+// Partitioners
+const (
+	// PartitionerHash hashes the key with FNV-1a, as sarama does
+	PartitionerHash Partitioner = "hash"
+	// PartitionerMurmur2 hashes the key as the Java client does, for topics
+	// shared with Java producers
+	PartitionerMurmur2 Partitioner = "murmur2"
+	// PartitionerCRC32 hashes the key as librdkafka does
+	PartitionerCRC32 Partitioner = "crc32"
+	// PartitionerLeastBytes sends each message to the partition that has
+	// received the least data. It ignores keys, so events are not ordered
+	// per user.
+	PartitionerLeastBytes Partitioner = "least_bytes"
+
+	// DefaultPartitioner keeps each user's events in order
+	DefaultPartitioner = PartitionerHash
+)
+
+// ParsePartitioner returns the partitioner named name; empty means
+// DefaultPartitioner
+func ParsePartitioner(name string) (Partitioner, error) {
+	switch p := Partitioner(name); p {
+	case "":
+		return DefaultPartitioner, nil
+	case PartitionerHash, PartitionerMurmur2, PartitionerCRC32, PartitionerLeastBytes:
+		return p, nil
+	}
+	return "", fmt.Errorf("messaging: unknown partitioner %q", name)
+}
+
+// Balancer returns the kafka-go balancer implementing p, DefaultPartitioner's
+// for the zero value
+func (p Partitioner) Balancer() kafka.Balancer {
+	switch p {
+	case PartitionerMurmur2:
+		return kafka.Murmur2Balancer{}
+	case PartitionerCRC32:
+		return kafka.CRC32Balancer{}
+	case PartitionerLeastBytes:
+		return &kafka.LeastBytes{}
+	}
+	return &kafka.Hash{}
+}
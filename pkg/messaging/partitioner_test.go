@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TestPartitioner_OrdersPerKey publishes interleaved events of many users and
+// reads each partition back in order, as a consumer would
+func TestPartitioner_OrdersPerKey(t *testing.T) {
+	partitions := []int{0, 1, 2, 3, 4, 5}
+	for _, p := range []Partitioner{PartitionerHash, PartitionerMurmur2, PartitionerCRC32} {
+		balancer := p.Balancer()
+		logs := make(map[int][]kafka.Message)
+		for seq := range 20 {
+			for user := 1; user <= 50; user++ {
+				msg := kafka.Message{Key: strconv.AppendInt(nil, int64(user), 10), Value: []byte(strconv.Itoa(seq))}
+				partition := balancer.Balance(msg, partitions...)
+				logs[partition] = append(logs[partition], msg)
+			}
+		}
+
+		home := make(map[string]int)
+		next := make(map[string]int)
+		for partition, log := range logs {
+			for _, msg := range log {
+				key := string(msg.Key)
+				if h, ok := home[key]; ok && h != partition {
+					t.Fatalf("%s: key %s went to partitions %d and %d", p, key, h, partition)
+				}
+				home[key] = partition
+				if want := strconv.Itoa(next[key]); string(msg.Value) != want {
+					t.Fatalf("%s: key %s read %s before %s", p, key, msg.Value, want)
+				}
+				next[key]++
+			}
+		}
+		if len(logs) < 2 {
+			t.Errorf("%s: expected users spread over partitions, got %d used", p, len(logs))
+		}
+	}
+}
+
+func TestParsePartitioner(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     Partitioner
+		balancer string
+	}{
+		{"", PartitionerHash, "*kafka.Hash"},
+		{"hash", PartitionerHash, "*kafka.Hash"},
+		{"murmur2", PartitionerMurmur2, "kafka.Murmur2Balancer"},
+		{"crc32", PartitionerCRC32, "kafka.CRC32Balancer"},
+		{"least_bytes", PartitionerLeastBytes, "*kafka.LeastBytes"},
+	}
+	for _, tt := range tests {
+		p, err := ParsePartitioner(tt.name)
+		if err != nil || p != tt.want {
+			t.Errorf("%q: expected %s, got %s, %v", tt.name, tt.want, p, err)
+		}
+		if got := fmt.Sprintf("%T", p.Balancer()); got != tt.balancer {
+			t.Errorf("%q: expected a %s, got %s", tt.name, tt.balancer, got)
+		}
+	}
+	if _, err := ParsePartitioner("round_robin"); err == nil {
+		t.Error("expected an unknown partitioner to be rejected")
+	}
+	if got := fmt.Sprintf("%T", Partitioner("").Balancer()); got != "*kafka.Hash" {
+		t.Errorf("expected the zero value to hash keys, got %s", got)
+	}
+}
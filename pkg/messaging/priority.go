@@ -0,0 +1,181 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Priority orders events for consumers. A broker delivers a topic in order,
+// so each priority gets its own topic and consumers read the topics of every
+// priority together, always taking a waiting event of higher priority first.
+// That way refunds and payment failures aren't stuck behind a backlog of
+// routine events.
+type Priority int
+
+// Priorities, lowest first
+const (
+	// PriorityBulk is for traffic that may lag, such as backfills
+	PriorityBulk Priority = iota
+	// PriorityDefault is for routine events
+	PriorityDefault
+	// PriorityHigh is for events someone is waiting on, such as refunds
+	PriorityHigh
+)
+
+// PriorityHeader carries a message's priority, for consumers reading one
+// topic to log or route on
+const PriorityHeader = "priority"
+
+// Event types consumed ahead of routine traffic
+const (
+	EventTransactionRefunded = "transaction.refunded"
+	EventPaymentFailed       = "payment.failed"
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBulk:
+		return "bulk"
+	case PriorityHigh:
+		return "high"
+	}
+	return "default"
+}
+
+// EventPriority returns the priority of an event type
+func EventPriority(eventType string) Priority {
+	switch eventType {
+	case EventTransactionRefunded, EventPaymentFailed:
+		return PriorityHigh
+	}
+	return PriorityDefault
+}
+
+// PriorityTopic returns the topic carrying topic's events of priority p:
+// topic itself for PriorityDefault, so existing consumers keep their
+// events, and topic with a ".high" or ".bulk" suffix otherwise
+func PriorityTopic(topic string, p Priority) string {
+	if p == PriorityDefault {
+		return topic
+	}
+	return topic + "." + p.String()
+}
+
+// PriorityTopics returns the topics carrying the events of priority p of
+// each of topics
+func PriorityTopics(topics []string, p Priority) []string {
+	out := make([]string, len(topics))
+	for i, topic := range topics {
+		out[i] = PriorityTopic(topic, p)
+	}
+	return out
+}
+
+// PrioritySubscriber consumes one Subscriber per priority, passing messages
+// to the handler one at a time, highest priority first. Each Subscriber has
+// at most one message waiting, so a message never waits behind more than
+// the one being handled.
+type PrioritySubscriber struct {
+	subs map[Priority]Subscriber
+}
+
+// NewPrioritySubscriber returns a PrioritySubscriber for the Subscribers of
+// each priority; priorities without one are skipped
+func NewPrioritySubscriber(subs map[Priority]Subscriber) *PrioritySubscriber {
+	return &PrioritySubscriber{subs: subs}
+}
+
+// delivery is a message waiting for the handler, and where its result goes
+type delivery struct {
+	key, value []byte
+	done       chan error
+}
+
+// Consume passes messages to handler until ctx is cancelled or one of the
+// Subscribers stops, returning that Subscriber's error
+func (s *PrioritySubscriber) Consume(ctx context.Context, handler KeyedMessageHandler) error {
+	var queues [PriorityHigh + 1]chan delivery
+	for p := range s.subs {
+		if p < PriorityBulk || p > PriorityHigh {
+			return fmt.Errorf("messaging: unknown priority %d", p)
+		}
+		queues[p] = make(chan delivery)
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	stopped := make(chan error, len(s.subs))
+	for p, sub := range s.subs {
+		wg.Go(func() {
+			stopped <- sub.Consume(subCtx, func(key, value []byte) error {
+				d := delivery{key: key, value: value, done: make(chan error, 1)}
+				select {
+				case queues[p] <- d:
+				case <-subCtx.Done():
+					return subCtx.Err()
+				}
+				return <-d.done
+			})
+		})
+	}
+	stop := func(err error) error {
+		cancel()
+		wg.Wait()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	for {
+		d, ok := waiting(queues)
+		if !ok {
+			select {
+			case d = <-queues[PriorityHigh]:
+			case d = <-queues[PriorityDefault]:
+			case d = <-queues[PriorityBulk]:
+			case err := <-stopped:
+				return stop(err)
+			case <-ctx.Done():
+				return stop(nil)
+			}
+		}
+		d.done <- handler(d.key, d.value)
+	}
+}
+
+// waiting returns the waiting message of highest priority, if any
+func waiting(queues [PriorityHigh + 1]chan delivery) (delivery, bool) {
+	for p := PriorityHigh; p >= PriorityBulk; p-- {
+		select {
+		case d := <-queues[p]:
+			return d, true
+		default:
+		}
+	}
+	return delivery{}, false
+}
+
+// Lag sums the lag of the Subscribers that report one, like KafkaConsumer
+func (s *PrioritySubscriber) Lag() int64 {
+	var lag int64
+	for _, sub := range s.subs {
+		if l, ok := sub.(interface{ Lag() int64 }); ok {
+			lag += l.Lag()
+		}
+	}
+	return lag
+}
+
+// Close closes every Subscriber
+func (s *PrioritySubscriber) Close() error {
+	var errs []error
+	for _, sub := range s.subs {
+		if err := sub.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSubscriber hands its keys to the handler once start is closed, then
+// waits for cancellation or returns err
+type fakeSubscriber struct {
+	keys  []string
+	start chan struct{}
+	err   error
+}
+
+func (s *fakeSubscriber) Consume(ctx context.Context, handler KeyedMessageHandler) error {
+	if s.start != nil {
+		select {
+		case <-s.start:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	for _, key := range s.keys {
+		if err := handler([]byte(key), nil); err != nil && ctx.Err() != nil {
+			return nil
+		}
+	}
+	if s.err != nil {
+		return s.err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (s *fakeSubscriber) Close() error { return nil }
+
+// laggingSubscriber is a fakeSubscriber that reports lag
+type laggingSubscriber struct {
+	fakeSubscriber
+	lag int64
+}
+
+func (s *laggingSubscriber) Lag() int64 { return s.lag }
+
+func TestPrioritySubscriber_Lag(t *testing.T) {
+	sub := NewPrioritySubscriber(map[Priority]Subscriber{
+		PriorityHigh:    &laggingSubscriber{lag: 2},
+		PriorityDefault: &laggingSubscriber{lag: 40},
+		PriorityBulk:    &fakeSubscriber{},
+	})
+	if got := sub.Lag(); got != 42 {
+		t.Errorf("expected a lag of 42, got %d", got)
+	}
+}
+
+func TestPrioritySubscriber_HighFirst(t *testing.T) {
+	start := make(chan struct{})
+	sub := NewPrioritySubscriber(map[Priority]Subscriber{
+		PriorityDefault: &fakeSubscriber{keys: []string{"created-1", "created-2"}},
+		PriorityHigh:    &fakeSubscriber{keys: []string{"refund"}, start: start},
+		PriorityBulk:    &fakeSubscriber{keys: []string{"backfill"}, start: start},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- sub.Consume(ctx, func(key, _ []byte) error {
+			mu.Lock()
+			keys = append(keys, string(key))
+			n := len(keys)
+			mu.Unlock()
+			if n == 1 {
+				// Let the refund and backfill queue up behind the first event
+				close(start)
+				time.Sleep(50 * time.Millisecond)
+			}
+			if n == 4 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error on cancellation, got %v", err)
+	}
+	if len(keys) != 4 || keys[0] != "created-1" || keys[1] != "refund" {
+		t.Errorf("expected the refund right after the first event, got %v", keys)
+	}
+	if i, j := slices.Index(keys, "created-1"), slices.Index(keys, "created-2"); i > j {
+		t.Errorf("expected one topic's events in order, got %v", keys)
+	}
+}
+
+func TestPrioritySubscriber_SubscriberFails(t *testing.T) {
+	failed := errors.New("broker gone")
+	sub := NewPrioritySubscriber(map[Priority]Subscriber{
+		PriorityDefault: &fakeSubscriber{},
+		PriorityHigh:    &fakeSubscriber{err: failed},
+	})
+
+	err := sub.Consume(context.Background(), func(_, _ []byte) error { return nil })
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the failed subscriber's error, got %v", err)
+	}
+}
+
+func TestEventPriority(t *testing.T) {
+	tests := map[string]Priority{
+		EventTransactionRefunded: PriorityHigh,
+		EventPaymentFailed:       PriorityHigh,
+		EventTransactionCreated:  PriorityDefault,
+	}
+	for eventType, want := range tests {
+		if got := EventPriority(eventType); got != want {
+			t.Errorf("%s: expected %v, got %v", eventType, want, got)
+		}
+	}
+
+	if got := PriorityTopic("transactions", PriorityDefault); got != "transactions" {
+		t.Errorf("expected default events on the base topic, got %s", got)
+	}
+	if got := PriorityTopic("transactions", PriorityHigh); got != "transactions.high" {
+		t.Errorf("expected transactions.high, got %s", got)
+	}
+	if got := PriorityTopics([]string{"transactions", "refunds"}, PriorityBulk); !slices.Equal(got, []string{"transactions.bulk", "refunds.bulk"}) {
+		t.Errorf("expected the bulk topic of each topic, got %v", got)
+	}
+}
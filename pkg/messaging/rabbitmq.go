@@ -2,7 +2,6 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
@@ -79,7 +78,7 @@ func (r *RabbitMQ) DeclareQueue(name string) (amqp.Queue, error) {
 
 // Publish publishes a message to a queue
 func (r *RabbitMQ) Publish(ctx context.Context, queueName string, message interface{}) error {
-	body, err := json.Marshal(message)
+	body, err := Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
@@ -91,7 +90,7 @@ func (r *RabbitMQ) Publish(ctx context.Context, queueName string, message interf
 		false,     // immediate
 		amqp.Publishing{
 			ContentType:  "application/json",
-			Body:         body,
+			Body:         body.Bytes(),
 			DeliveryMode: amqp.Persistent,
 			Timestamp:    time.Now(),
 		},
@@ -99,6 +98,7 @@ func (r *RabbitMQ) Publish(ctx context.Context, queueName string, message interf
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
+	body.Release()
 
 	r.logger.Debug("message published", "queue", queueName)
 	return nil
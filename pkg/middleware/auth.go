@@ -1,23 +1,54 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tkaewplik/go-microservices/pkg/jwt"
 )
 
 type AuthMiddleware struct {
 	secretKey string
+	clockSkew time.Duration
 }
 
-func NewAuthMiddleware(secretKey string) *AuthMiddleware {
-	return &AuthMiddleware{secretKey: secretKey}
+// AuthOption configures an AuthMiddleware
+type AuthOption func(*AuthMiddleware)
+
+// WithClockSkew accepts tokens up to d past expiry, for an auth service
+// whose clock runs behind this one
+func WithClockSkew(d time.Duration) AuthOption {
+	return func(m *AuthMiddleware) { m.clockSkew = d }
+}
+
+func NewAuthMiddleware(secretKey string, opts ...AuthOption) *AuthMiddleware {
+	m := &AuthMiddleware{secretKey: secretKey}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying claims
+func WithClaims(ctx context.Context, claims *jwt.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
 }
 
+// ClaimsFromContext returns the token claims Authenticate validated for a
+// request
+func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwt.Claims)
+	return claims, ok && claims != nil
+}
+
+// Authenticate rejects requests without a valid bearer token and passes the
+// token's claims to next in the request context
 func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -40,7 +71,7 @@ func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		claims, err := jwt.ValidateToken(parts[1], m.secretKey)
+		claims, err := jwt.ValidateToken(parts[1], m.secretKey, jwt.WithLeeway(m.clockSkew))
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -50,10 +81,6 @@ func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Add user info to request context
-		r.Header.Set("X-User-ID", fmt.Sprintf("%d", claims.UserID))
-		r.Header.Set("X-Username", claims.Username)
-
-		next(w, r)
+		next(w, r.WithContext(WithClaims(r.Context(), claims)))
 	}
 }
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FaultConfig describes faults injected into HTTP requests so retries,
+// timeouts and circuit breakers can be exercised in staging. The zero value
+// injects nothing.
+type FaultConfig struct {
+	// LatencyRate is the fraction of requests, from 0 to 1, delayed by a
+	// random duration up to MaxLatency
+	LatencyRate float64
+	MaxLatency  time.Duration
+	// ErrorRate is the fraction of requests answered with ErrorStatus
+	// instead of being served
+	ErrorRate float64
+	// ErrorStatus defaults to 503
+	ErrorStatus int
+	// SkipPaths are path prefixes never faulted, such as health checks
+	SkipPaths []string
+}
+
+// Enabled reports whether c injects any fault
+func (c FaultConfig) Enabled() bool {
+	return c.LatencyRate > 0 && c.MaxLatency > 0 || c.ErrorRate > 0
+}
+
+// Faults injects the faults described by cfg. Delays happen before the
+// error roll, so an injected error can also be slow, and end early when the
+// client goes away.
+func Faults(cfg FaultConfig) func(http.Handler) http.Handler {
+	return faults(cfg, rand.Float64)
+}
+
+func faults(cfg FaultConfig, roll func() float64) func(http.Handler) http.Handler {
+	if cfg.ErrorStatus == 0 {
+		cfg.ErrorStatus = http.StatusServiceUnavailable
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range cfg.SkipPaths {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if cfg.MaxLatency > 0 && roll() < cfg.LatencyRate {
+				delay := time.Duration(roll() * float64(cfg.MaxLatency))
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			if roll() < cfg.ErrorRate {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Injected-Fault", "error")
+				w.WriteHeader(cfg.ErrorStatus)
+				if err := json.NewEncoder(w).Encode(map[string]string{"error": "injected fault"}); err != nil {
+					log.Printf("Failed to encode response: %v", err)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rolls returns a roll function yielding values in turn
+func rolls(values ...float64) func() float64 {
+	return func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+}
+
+func TestFaults(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	cfg := FaultConfig{LatencyRate: 0.5, MaxLatency: 40 * time.Millisecond, ErrorRate: 0.1, SkipPaths: []string{"/health"}}
+
+	serve := func(path string, roll func() float64) (*httptest.ResponseRecorder, time.Duration) {
+		w := httptest.NewRecorder()
+		start := time.Now()
+		faults(cfg, roll)(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w, time.Since(start)
+	}
+
+	// Delayed by half of MaxLatency, then served
+	if w, took := serve("/payment", rolls(0.2, 0.5, 0.9)); w.Code != http.StatusOK || took < 20*time.Millisecond {
+		t.Errorf("expected a delayed 200, got %d after %v", w.Code, took)
+	}
+	// Not delayed, then failed
+	if w, _ := serve("/payment", rolls(0.7, 0.05)); w.Code != http.StatusServiceUnavailable || w.Header().Get("X-Injected-Fault") != "error" {
+		t.Errorf("expected an injected 503, got %d", w.Code)
+	}
+	// Skipped paths never roll
+	if w, _ := serve("/health", rolls()); w.Code != http.StatusOK {
+		t.Errorf("expected /health untouched, got %d", w.Code)
+	}
+}
+
+func TestFaultConfig_Enabled(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  FaultConfig
+		want bool
+	}{
+		{FaultConfig{}, false},
+		{FaultConfig{LatencyRate: 1}, false},
+		{FaultConfig{LatencyRate: 1, MaxLatency: time.Second}, true},
+		{FaultConfig{ErrorRate: 0.01}, true},
+	} {
+		if got := tc.cfg.Enabled(); got != tc.want {
+			t.Errorf("%+v: expected %v, got %v", tc.cfg, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// PrivateNetworks are the loopback, private and link-local ranges.
+// Operational endpoints only answer them unless told otherwise, so they
+// aren't exposed to the internet by default.
+var PrivateNetworks = []netip.Prefix{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+}
+
+// IPFilter admits clients by address. Denied prefixes win over allowed ones;
+// with no allowed prefixes every address not denied is admitted.
+type IPFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter creates an IPFilter
+func NewIPFilter(allow, deny []netip.Prefix) *IPFilter {
+	return &IPFilter{allow: allow, deny: deny}
+}
+
+// ParseIPFilter creates an IPFilter from comma-separated lists as read from
+// the environment. An empty allow list admits PrivateNetworks only.
+func ParseIPFilter(allow, deny string) (*IPFilter, error) {
+	allowed, err := ParseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("allow list: %w", err)
+	}
+	if len(allowed) == 0 {
+		allowed = PrivateNetworks
+	}
+	denied, err := ParseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("deny list: %w", err)
+	}
+	return NewIPFilter(allowed, denied), nil
+}
+
+// ParseCIDRs parses a comma-separated list of CIDRs; a bare address is a
+// prefix of one address. An empty list parses to none.
+func ParseCIDRs(list string) ([]netip.Prefix, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("empty entry in %q", list)
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", s, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Allowed reports whether addr may pass
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range f.deny {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, p := range f.allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedRequest reports whether r's connecting address may pass. Forwarding
+// headers are ignored, as any client can set them.
+func (f *IPFilter) AllowedRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && f.Allowed(addr)
+}
+
+// Restrict answers 403 to clients the filter doesn't admit
+func (f *IPFilter) Restrict(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !f.AllowedRequest(r) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(map[string]string{"error": "forbidden"}); err != nil {
+				log.Printf("Failed to encode response: %v", err)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
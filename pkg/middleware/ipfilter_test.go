@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseIPFilter(t *testing.T) {
+	f, err := ParseIPFilter("", "10.9.0.0/16, 192.168.1.7")
+	if err != nil {
+		t.Fatalf("expected the lists to parse, got %v", err)
+	}
+	tests := []struct {
+		remote  string
+		allowed bool
+	}{
+		{"127.0.0.1:5000", true},
+		{"10.1.2.3:5000", true},
+		{"[::ffff:10.1.2.3]:5000", true},
+		{"[fd00::1]:5000", true},
+		{"10.9.1.1:5000", false},
+		{"192.168.1.7:5000", false},
+		{"192.168.1.8:5000", true},
+		{"203.0.113.7:5000", false},
+		{"garbage", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.RemoteAddr = tt.remote
+		w := httptest.NewRecorder()
+		f.Restrict(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+		if got := w.Code == http.StatusOK; got != tt.allowed {
+			t.Errorf("%s: expected allowed %v, got status %d", tt.remote, tt.allowed, w.Code)
+		}
+	}
+
+	open, err := ParseIPFilter("0.0.0.0/0,::/0", "")
+	if err != nil {
+		t.Fatalf("expected the lists to parse, got %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	if !open.AllowedRequest(r) {
+		t.Error("expected an explicit allow list to admit public addresses")
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	prefixes, err := ParseCIDRs("10.0.0.0/8, 192.168.1.7 ,fd00::/8")
+	if err != nil {
+		t.Fatalf("expected the list to parse, got %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.7/32", "fd00::/8"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("expected %v, got %v", want, prefixes)
+	}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Errorf("expected %s, got %s", want[i], p)
+		}
+	}
+
+	if prefixes, err := ParseCIDRs(" "); err != nil || prefixes != nil {
+		t.Errorf("expected an empty list to parse to none, got %v, %v", prefixes, err)
+	}
+	for _, list := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.0/8,"} {
+		if _, err := ParseCIDRs(list); err == nil {
+			t.Errorf("expected %q to be rejected", list)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import "net/http"
+
+// BodyLimitOption configures MaxBodyBytes
+type BodyLimitOption func(map[string]int64)
+
+// WithPathLimit caps bodies sent to exactly path at limit instead, for
+// endpoints such as uploads that need more than the default
+func WithPathLimit(path string, limit int64) BodyLimitOption {
+	return func(paths map[string]int64) {
+		paths[path] = limit
+	}
+}
+
+// MaxBodyBytes caps request bodies at limit bytes. Reads past the limit fail
+// with *http.MaxBytesError, which request.DecodeJSON reports as
+// request.ErrBodyTooLarge.
+func MaxBodyBytes(limit int64, opts ...BodyLimitOption) func(http.Handler) http.Handler {
+	paths := make(map[string]int64)
+	for _, opt := range opts {
+		opt(paths)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := limit
+			if pathLimit, ok := paths[r.URL.Path]; ok {
+				n = pathLimit
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package pagination holds the page types shared by listing endpoints and
+// helpers for keyset pagination in SQL.
+//
+// A client asks for a page with a limit, and for every page after the first
+// with the cursor returned with the page before. A cursor is opaque to
+// clients: it encodes the position of the last item returned, so pages stay
+// consistent while items are added, unlike offsets.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxLimit is the largest page size any listing serves
+const MaxLimit = 1000
+
+var (
+	// ErrInvalidLimit is returned for a negative or non-numeric limit
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrInvalidCursor is returned for a cursor that wasn't issued by the
+	// listing it was passed to
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// PageRequest asks for one page of a listing
+type PageRequest struct {
+	// Limit is the page size; 0 asks for the listing's default
+	Limit int `json:"limit,omitempty"`
+	// Cursor is the NextCursor of the previous page; empty for the first
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// FromQuery reads the limit and cursor query parameters
+func FromQuery(q url.Values) (PageRequest, error) {
+	var p PageRequest
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return PageRequest{}, ErrInvalidLimit
+		}
+		p.Limit = limit
+	}
+	p.Cursor = q.Get("cursor")
+	return p, nil
+}
+
+// Normalize applies def when no limit was asked for and caps the limit at
+// MaxLimit. A def of 0 leaves such requests unpaged.
+func (p PageRequest) Normalize(def int) PageRequest {
+	if p.Limit == 0 {
+		p.Limit = def
+	}
+	p.Limit = min(p.Limit, MaxLimit)
+	return p
+}
+
+// Paged reports whether the request limits the number of items
+func (p PageRequest) Paged() bool {
+	return p.Limit > 0
+}
+
+// FetchLimit is how many items to read for the page: one more than the
+// limit, so the extra item shows whether another page follows. It is 0 for
+// unpaged requests.
+func (p PageRequest) FetchLimit() int {
+	if !p.Paged() {
+		return 0
+	}
+	return p.Limit + 1
+}
+
+// PageInfo describes the page a listing returned
+type PageInfo struct {
+	// Limit is the page size applied; 0 when the listing wasn't paged
+	Limit int `json:"limit,omitempty"`
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total counts the items across all pages
+	Total int64 `json:"total"`
+}
+
+// PageResponse is one page of a listing
+type PageResponse[T any] struct {
+	Items []T `json:"items"`
+	PageInfo
+}
+
+// NewPage builds the page for req from items read with req.FetchLimit().
+// cursorOf returns the position of an item, which becomes the next cursor
+// when more items follow.
+func NewPage[T any](items []T, req PageRequest, total int64, cursorOf func(T) any) (PageResponse[T], error) {
+	page := PageResponse[T]{Items: items, PageInfo: PageInfo{Limit: req.Limit, Total: total}}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if !req.Paged() || len(items) <= req.Limit {
+		return page, nil
+	}
+
+	page.Items = items[:req.Limit]
+	cursor, err := EncodeCursor(cursorOf(page.Items[req.Limit-1]))
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+	page.NextCursor = cursor
+	return page, nil
+}
+
+// EncodeCursor makes an opaque cursor of position, which must marshal to
+// JSON
+func EncodeCursor(position any) (string, error) {
+	b, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor into position
+func DecodeCursor(cursor string, position any) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, position); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// KeysetCondition compares the row tuple of columns with the positional
+// parameters starting at $argPos, selecting rows after a cursor: those that
+// sort later in descending or ascending order. With columns "created_at", "id"
+// and argPos 2 it returns "(created_at, id) < ($2, $3)" for descending order.
+// The columns must not come from user input.
+func KeysetCondition(columns []string, desc bool, argPos int) string {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	params := make([]string, len(columns))
+	for i := range columns {
+		params[i] = "$" + strconv.Itoa(argPos+i)
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(params, ", "))
+}
+
+// LimitClause returns " LIMIT n" for a positive n, or "" to read every row
+func LimitClause(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return " LIMIT " + strconv.Itoa(n)
+}
@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestNewPage_TrimsExtraItem(t *testing.T) {
+	req := PageRequest{Limit: 2}
+	page, err := NewPage([]int{5, 4, 3}, req, 10, func(n int) any { return n })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Items) != 2 || page.Total != 10 || page.NextCursor == "" {
+		t.Fatalf("expected two items and a next cursor, got %+v", page)
+	}
+
+	var last int
+	if err := DecodeCursor(page.NextCursor, &last); err != nil || last != 4 {
+		t.Errorf("expected the cursor to point at 4, got %d, %v", last, err)
+	}
+
+	page, _ = NewPage([]int{5, 4}, req, 2, func(n int) any { return n })
+	if page.NextCursor != "" {
+		t.Errorf("expected no cursor on the last page, got %q", page.NextCursor)
+	}
+}
+
+func TestNewPage_Unpaged(t *testing.T) {
+	page, err := NewPage[int](nil, PageRequest{}, 0, func(n int) any { return n })
+	if err != nil || page.Items == nil || page.NextCursor != "" {
+		t.Errorf("expected an empty, non-nil page, got %+v, %v", page, err)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	var position struct{ ID int }
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24"} {
+		if err := DecodeCursor(cursor, &position); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	p, err := FromQuery(url.Values{"limit": {"20"}, "cursor": {"abc"}})
+	if err != nil || p.Limit != 20 || p.Cursor != "abc" {
+		t.Errorf("unexpected page request %+v, %v", p, err)
+	}
+	for _, limit := range []string{"-1", "ten"} {
+		if _, err := FromQuery(url.Values{"limit": {limit}}); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("%q: expected ErrInvalidLimit, got %v", limit, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if p := (PageRequest{}).Normalize(50); p.Limit != 50 {
+		t.Errorf("expected the default, got %d", p.Limit)
+	}
+	if p := (PageRequest{Limit: 5000}).Normalize(50); p.Limit != MaxLimit {
+		t.Errorf("expected the limit to be capped, got %d", p.Limit)
+	}
+	if p := (PageRequest{}).Normalize(0); p.Paged() || p.FetchLimit() != 0 {
+		t.Errorf("expected an unpaged request, got %+v", p)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	if got := KeysetCondition([]string{"created_at", "id"}, true, 2); got != "(created_at, id) < ($2, $3)" {
+		t.Errorf("unexpected descending condition %q", got)
+	}
+	if got := KeysetCondition([]string{"id"}, false, 1); got != "(id) > ($1)" {
+		t.Errorf("unexpected ascending condition %q", got)
+	}
+	if got := LimitClause(0); got != "" {
+		t.Errorf("expected no LIMIT, got %q", got)
+	}
+}
@@ -0,0 +1,25 @@
+package pagination
+
+import (
+	pb "github.com/tkaewplik/go-microservices/proto/pagination"
+)
+
+// FromProto converts a gRPC page request; nil asks for the default page
+func FromProto(p *pb.PageRequest) PageRequest {
+	return PageRequest{Limit: int(p.GetLimit()), Cursor: p.GetCursor()}
+}
+
+// Proto converts p for a gRPC request
+func (p PageRequest) Proto() *pb.PageRequest {
+	return &pb.PageRequest{Limit: int32(p.Limit), Cursor: p.Cursor}
+}
+
+// InfoFromProto converts the page description of a gRPC response
+func InfoFromProto(info *pb.PageInfo) PageInfo {
+	return PageInfo{Limit: int(info.GetLimit()), NextCursor: info.GetNextCursor(), Total: info.GetTotal()}
+}
+
+// Proto converts info for a gRPC response
+func (info PageInfo) Proto() *pb.PageInfo {
+	return &pb.PageInfo{Limit: int32(info.Limit), NextCursor: info.NextCursor, Total: info.Total}
+}
@@ -0,0 +1,232 @@
+// Package report renders documents for users, such as monthly statements, as
+// PDF. It writes the small subset of PDF 1.4 reports need: text in the
+// standard Helvetica fonts, rules and paged tables.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page layout, in points, for A4 portrait
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+
+	bodySize    = 10.0
+	headingSize = 16.0
+	lineGap     = 1.4
+)
+
+// Align positions text within a table column
+type Align int
+
+// Alignments
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Column is a table column. Widths are fractions of the printable width.
+type Column struct {
+	Title string
+	Width float64
+	Align Align
+}
+
+// Document is a PDF being laid out top to bottom. Content flows onto new
+// pages as each fills up.
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// NewDocument starts a document with one empty page
+func NewDocument() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// reserve moves down by height, starting a new page first when it doesn't
+// fit, and reports whether it did
+func (d *Document) reserve(height float64) bool {
+	if d.y-height < margin {
+		d.newPage()
+		d.y -= height
+		return true
+	}
+	d.y -= height
+	return false
+}
+
+// Heading writes text in large bold type
+func (d *Document) Heading(text string) {
+	d.reserve(headingSize * lineGap)
+	d.text(margin, d.y, headingSize, true, text)
+}
+
+// Text writes a line of body text
+func (d *Document) Text(text string) {
+	d.reserve(bodySize * lineGap)
+	d.text(margin, d.y, bodySize, false, text)
+}
+
+// Pair writes a label and a right-aligned value on one line
+func (d *Document) Pair(label, value string) {
+	d.reserve(bodySize * lineGap)
+	d.text(margin, d.y, bodySize, false, label)
+	d.text(pageWidth-margin-textWidth(value, bodySize, true), d.y, bodySize, true, value)
+}
+
+// Space leaves height points blank
+func (d *Document) Space(height float64) {
+	d.reserve(height)
+}
+
+// Rule draws a line across the page
+func (d *Document) Rule() {
+	d.reserve(bodySize * 0.6)
+	d.line(d.y)
+}
+
+// Table writes rows under a header of columns, repeating the header on every
+// page the table spans. Cells too wide for their column are truncated.
+func (d *Document) Table(columns []Column, rows [][]string) {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Title
+	}
+	d.reserve(bodySize * lineGap)
+	d.row(columns, header, true)
+	for _, row := range rows {
+		if d.reserve(bodySize * lineGap) {
+			d.row(columns, header, true)
+			d.reserve(bodySize * lineGap)
+		}
+		d.row(columns, row, false)
+	}
+}
+
+// row writes cells at the current line, underlining a header
+func (d *Document) row(columns []Column, cells []string, header bool) {
+	width := pageWidth - 2*margin
+	x := margin
+	for i, c := range columns {
+		colWidth := c.Width * width
+		if i < len(cells) {
+			cell := fit(cells[i], colWidth-4, bodySize, header)
+			cellX := x
+			if c.Align == AlignRight {
+				cellX = x + colWidth - textWidth(cell, bodySize, header)
+			}
+			d.text(cellX, d.y, bodySize, header, cell)
+		}
+		x += colWidth
+	}
+	if header {
+		d.line(d.y - 3)
+	}
+}
+
+func (d *Document) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+func (d *Document) line(y float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, y, pageWidth-margin, y)
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page is then a
+	// page object followed by its content stream
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.WriteTo(w)
+}
+
+// escape makes s a PDF string literal body. The standard fonts only cover
+// Latin-1, so other characters print as '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r >= 0x80:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// textWidth estimates the width of s in points. Helvetica averages about
+// half an em per character, a little more in bold.
+func textWidth(s string, size float64, bold bool) float64 {
+	em := 0.52
+	if bold {
+		em = 0.56
+	}
+	return float64(len([]rune(s))) * size * em
+}
+
+// fit truncates s with an ellipsis to fit within width points
+func fit(s string, width, size float64, bold bool) string {
+	if textWidth(s, size, bold) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderStatement(t *testing.T) {
+	from := time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC)
+	s := Statement{
+		UserID:      7,
+		From:        from,
+		To:          from.AddDate(0, 1, 0),
+		GeneratedAt: from.AddDate(0, 1, 0),
+	}
+	for i := range 120 {
+		s.Transactions = append(s.Transactions, StatementLine{
+			ID:          i + 1,
+			CreatedAt:   from.Add(time.Duration(i) * time.Hour),
+			Description: "coffee (large)",
+			Amount:      10,
+			Paid:        i%2 == 0,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := RenderStatement(&buf, s); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	pdf := buf.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("expected a PDF header and trailer")
+	}
+	if !strings.Contains(pdf, "/Count 3 ") {
+		t.Errorf("expected 120 rows to span three pages")
+	}
+	for _, want := range []string{"(Statement for September 2026)", "(Period 2026-09-01 to 2026-09-30)",
+		`(coffee \(large\))`, "(1,200.00)", "(Paid \\(60\\))", "(600.00)"} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("expected the PDF to contain %s", want)
+		}
+	}
+
+	// Every xref entry must point at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("expected startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	entries := strings.Split(pdf[xref:], "\n")[3:]
+	for i, entry := range entries {
+		if !strings.HasSuffix(entry, " n ") {
+			break
+		}
+		off, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(pdf[off:], want) {
+			t.Errorf("expected xref entry %d to point at %q", i+1, want)
+		}
+	}
+}
+
+func TestStatementTotals(t *testing.T) {
+	s := Statement{Transactions: []StatementLine{
+		{Amount: 10, Paid: true},
+		{Amount: 2.5},
+		{Amount: 4.5},
+	}}
+	want := StatementTotals{Count: 3, Total: 17, PaidCount: 1, PaidTotal: 10, UnpaidCount: 2, UnpaidTotal: 7}
+	if got := s.Totals(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	for amount, want := range map[float64]string{
+		0:          "0.00",
+		999.5:      "999.50",
+		1234.567:   "1,234.57",
+		-1234567.1: "-1,234,567.10",
+	} {
+		if got := formatAmount(amount); got != want {
+			t.Errorf("formatAmount(%v): expected %q, got %q", amount, want, got)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := escape(`a(b)\c` + "\n" + "é" + "日"); got != `a\(b\)\\c?`+"\xe9"+"?" {
+		t.Errorf("unexpected escape: %q", got)
+	}
+}
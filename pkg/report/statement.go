@@ -0,0 +1,126 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Statement is a user's transactions over one period
+type Statement struct {
+	UserID int
+	// From and To bound the period, [From, To)
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	// Transactions are listed in the order given
+	Transactions []StatementLine
+}
+
+// StatementLine is one transaction on a statement
+type StatementLine struct {
+	ID          int
+	CreatedAt   time.Time
+	Description string
+	Amount      float64
+	Paid        bool
+}
+
+// StatementTotals sums a statement's transactions
+type StatementTotals struct {
+	Count       int
+	Total       float64
+	PaidCount   int
+	PaidTotal   float64
+	UnpaidCount int
+	UnpaidTotal float64
+}
+
+// Totals sums s's transactions, overall and by whether they are paid
+func (s Statement) Totals() StatementTotals {
+	var t StatementTotals
+	for _, line := range s.Transactions {
+		t.Count++
+		t.Total += line.Amount
+		if line.Paid {
+			t.PaidCount++
+			t.PaidTotal += line.Amount
+		} else {
+			t.UnpaidCount++
+			t.UnpaidTotal += line.Amount
+		}
+	}
+	return t
+}
+
+var statementColumns = []Column{
+	{Title: "Date", Width: 0.16},
+	{Title: "ID", Width: 0.10},
+	{Title: "Description", Width: 0.46},
+	{Title: "Status", Width: 0.12},
+	{Title: "Amount", Width: 0.16, Align: AlignRight},
+}
+
+// RenderStatement writes s as a PDF: its totals, the transaction table and a
+// summary of what is paid and still due. Dates print in the location of
+// s.From.
+func RenderStatement(w io.Writer, s Statement) error {
+	loc := s.From.Location()
+	totals := s.Totals()
+
+	d := NewDocument()
+	d.Heading("Statement for " + s.From.Format("January 2006"))
+	d.Text(fmt.Sprintf("Account %d", s.UserID))
+	d.Text(fmt.Sprintf("Period %s to %s", s.From.Format(time.DateOnly), s.To.Add(-time.Nanosecond).In(loc).Format(time.DateOnly)))
+	d.Text("Generated " + s.GeneratedAt.In(loc).Format("2006-01-02 15:04 MST"))
+	d.Space(bodySize)
+
+	d.Pair("Transactions", strconv.Itoa(totals.Count))
+	d.Pair("Total", formatAmount(totals.Total))
+	d.Rule()
+	d.Space(bodySize)
+
+	if len(s.Transactions) == 0 {
+		d.Text("No transactions in this period.")
+	} else {
+		rows := make([][]string, len(s.Transactions))
+		for i, line := range s.Transactions {
+			status := "Unpaid"
+			if line.Paid {
+				status = "Paid"
+			}
+			rows[i] = []string{
+				line.CreatedAt.In(loc).Format(time.DateOnly),
+				strconv.Itoa(line.ID),
+				line.Description,
+				status,
+				formatAmount(line.Amount),
+			}
+		}
+		d.Table(statementColumns, rows)
+	}
+	d.Space(bodySize)
+	d.Rule()
+
+	d.Pair(fmt.Sprintf("Paid (%d)", totals.PaidCount), formatAmount(totals.PaidTotal))
+	d.Pair(fmt.Sprintf("Unpaid (%d)", totals.UnpaidCount), formatAmount(totals.UnpaidTotal))
+	d.Pair("Amount due", formatAmount(totals.UnpaidTotal))
+
+	_, err := d.WriteTo(w)
+	return err
+}
+
+// formatAmount prints an amount with two decimals and thousands separators
+func formatAmount(amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if s[0] == '-' {
+		sign, s = "-", s[1:]
+	}
+	whole, frac := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + frac
+}
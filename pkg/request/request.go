@@ -0,0 +1,352 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single malformed field in a request body
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// MaxDepth is the deepest nesting of objects and arrays Decode accepts.
+// Set it once at startup.
+var MaxDepth = 32
+
+// ErrBodyTooLarge is matched by the DecodeError returned when the body was cut
+// off by http.MaxBytesReader
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// DecodeError is returned when a request body cannot be decoded
+type DecodeError struct {
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// Error implements the error interface
+func (e *DecodeError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Reason
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeJSON strictly decodes the request body into dst.
+// Unknown fields and type mismatches are reported per field. When dst is a
+// pointer to a struct every top-level field is checked, so a single response
+// lists all malformed fields instead of only the first one.
+func DecodeJSON(r *http.Request, dst interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return &DecodeError{
+				Message: fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit),
+				Err:     ErrBodyTooLarge,
+			}
+		}
+		return &DecodeError{Message: "failed to read request body"}
+	}
+	return Decode(body, dst)
+}
+
+// Decode strictly decodes a JSON document into dst
+func Decode(data []byte, dst interface{}) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &DecodeError{Message: "request body is empty"}
+	}
+	if err := checkDepth(data, MaxDepth); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return decodeStrict(data, dst, "")
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeStrict(data, &raw, ""); err != nil {
+		var decErr *DecodeError
+		if errors.As(err, &decErr) && len(decErr.Fields) > 0 {
+			// Top level is valid JSON but not an object
+			return &DecodeError{Message: "request body must be a JSON object"}
+		}
+		return err
+	}
+
+	fields := structFields(rv.Elem())
+	var fieldErrs []FieldError
+	for name, value := range raw {
+		field, ok := fields[strings.ToLower(name)]
+		if !ok {
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: "unknown field"})
+			continue
+		}
+		if err := decodeStrict(value, field.Addr().Interface(), name); err != nil {
+			var decErr *DecodeError
+			if errors.As(err, &decErr) && len(decErr.Fields) > 0 {
+				fieldErrs = append(fieldErrs, decErr.Fields...)
+			} else {
+				fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: "malformed value"})
+			}
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		sort.Slice(fieldErrs, func(i, j int) bool { return fieldErrs[i].Field < fieldErrs[j].Field })
+		return &DecodeError{Message: "invalid request body", Fields: fieldErrs}
+	}
+	return nil
+}
+
+// decodeStrict decodes a single JSON value with unknown fields disallowed
+func decodeStrict(data []byte, dst interface{}, path string) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return translate(err, path)
+	}
+	// Anything but whitespace after the value, including a stray closing
+	// bracket that dec.More would miss, is rejected
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return &DecodeError{Message: "request body must contain a single JSON value"}
+	}
+	return nil
+}
+
+// translate converts encoding/json errors into a DecodeError
+func translate(err error, path string) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return &DecodeError{Message: "malformed JSON"}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Message: "invalid request body",
+			Fields: []FieldError{{
+				Field:  joinPath(path, typeErr.Field),
+				Reason: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+			}},
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &DecodeError{
+			Message: "invalid request body",
+			Fields:  []FieldError{{Field: joinPath(path, name), Reason: "unknown field"}},
+		}
+	default:
+		return &DecodeError{Message: "invalid request body"}
+	}
+}
+
+// checkDepth rejects documents nested deeper than maxDepth before they are
+// decoded, so hostile payloads can't make the decoder recurse without bound
+func checkDepth(data []byte, maxDepth int) error {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return &DecodeError{Message: fmt.Sprintf("request body exceeds maximum nesting depth of %d", maxDepth)}
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// structFields maps lower-cased JSON names to the settable fields of v
+func structFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields[strings.ToLower(name)] = v.Field(i)
+	}
+	return fields
+}
+
+func joinPath(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	default:
+		return prefix + "." + field
+	}
+}
+
+// Form content types DecodeBody decodes as forms
+const (
+	ContentTypeForm          = "application/x-www-form-urlencoded"
+	ContentTypeMultipartForm = "multipart/form-data"
+)
+
+// formMemory is how much of a multipart body DecodeForm keeps in memory;
+// larger parts spill to temporary files, removed once it returns
+const formMemory = 1 << 20
+
+// DecodeBody decodes the request body into dst by its Content-Type: HTML
+// forms, and curl's -d and -F requests, with DecodeForm, and anything else as
+// JSON with DecodeJSON
+func DecodeBody(r *http.Request, dst interface{}) error {
+	switch mediaType(r) {
+	case ContentTypeForm, ContentTypeMultipartForm:
+		return DecodeForm(r, dst)
+	}
+	return DecodeJSON(r, dst)
+}
+
+// DecodeForm decodes the fields of a URL-encoded or multipart form body into
+// dst, a pointer to a struct, matching them to its JSON field names as Decode
+// does. Each field must have a single value parsable as its struct field's
+// type: a string, bool or number. Unknown fields, including files, and
+// unparsable values are reported per field.
+func DecodeForm(r *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return &DecodeError{Message: "form fields can only be decoded into a struct"}
+	}
+
+	var err error
+	if mediaType(r) == ContentTypeMultipartForm {
+		err = r.ParseMultipartForm(formMemory)
+		if r.MultipartForm != nil {
+			defer func() { _ = r.MultipartForm.RemoveAll() }()
+		}
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return &DecodeError{
+				Message: fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit),
+				Err:     ErrBodyTooLarge,
+			}
+		}
+		return &DecodeError{Message: "malformed form body", Err: err}
+	}
+
+	fields := structFields(rv.Elem())
+	var fieldErrs []FieldError
+	for name, values := range r.PostForm {
+		field, ok := fields[strings.ToLower(name)]
+		switch {
+		case !ok:
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: "unknown field"})
+		case len(values) != 1:
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: "expected a single value"})
+		case !setFormField(field, values[0]):
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: fmt.Sprintf("expected %s", field.Type())})
+		}
+	}
+	if r.MultipartForm != nil {
+		for name := range r.MultipartForm.File {
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Reason: "unknown field"})
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		sort.Slice(fieldErrs, func(i, j int) bool { return fieldErrs[i].Field < fieldErrs[j].Field })
+		return &DecodeError{Message: "invalid request body", Fields: fieldErrs}
+	}
+	return nil
+}
+
+// setFormField parses value into field, reporting whether it could
+func setFormField(field reflect.Value, value string) bool {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return false
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return false
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return false
+		}
+		field.SetFloat(f)
+	default:
+		return false
+	}
+	return true
+}
+
+// mediaType is r's Content-Type without parameters
+func mediaType(r *http.Request) string {
+	t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return t
+}
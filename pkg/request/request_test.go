@@ -0,0 +1,208 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testPayload struct {
+	Username string  `json:"username"`
+	Amount   float64 `json:"amount"`
+}
+
+func TestDecodeJSON_Success(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"username":"alice","amount":12.5}`))
+
+	var p testPayload
+	if err := DecodeJSON(r, &p); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if p.Username != "alice" || p.Amount != 12.5 {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+}
+
+func TestDecode_ReportsAllFieldErrors(t *testing.T) {
+	var p testPayload
+	err := Decode([]byte(`{"username":42,"amount":"ten","extra":true}`), &p)
+
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+
+	want := map[string]string{
+		"amount":   "expected float64, got string",
+		"extra":    "unknown field",
+		"username": "expected string, got number",
+	}
+	if len(decErr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), decErr.Fields)
+	}
+	for _, f := range decErr.Fields {
+		if want[f.Field] != f.Reason {
+			t.Errorf("field %s: expected reason %q, got %q", f.Field, want[f.Field], f.Reason)
+		}
+	}
+}
+
+func TestDecode_MalformedJSON(t *testing.T) {
+	var p testPayload
+	err := Decode([]byte(`{"username":`), &p)
+
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+	if len(decErr.Fields) != 0 {
+		t.Errorf("expected no field errors for syntax error, got %+v", decErr.Fields)
+	}
+}
+
+func TestDecode_EmptyBody(t *testing.T) {
+	var p testPayload
+	if err := Decode([]byte("  "), &p); err == nil {
+		t.Error("expected error for empty body")
+	}
+}
+
+func TestDecode_NotAnObject(t *testing.T) {
+	var p testPayload
+	err := Decode([]byte(`[1,2,3]`), &p)
+	if err == nil || err.Error() != "request body must be a JSON object" {
+		t.Errorf("expected object error, got %v", err)
+	}
+}
+
+func TestDecode_TrailingData(t *testing.T) {
+	var p testPayload
+	if err := Decode([]byte(`{"username":"a"} {"username":"b"}`), &p); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestDecode_MaxDepth(t *testing.T) {
+	var p testPayload
+	deep := `{"username":` + strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1) + `}`
+	err := Decode([]byte(deep), &p)
+	if err == nil || !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("expected depth error, got %v", err)
+	}
+
+	// Brackets inside strings don't count towards the depth
+	quoted := `{"username":"` + strings.Repeat(`[{\"`, MaxDepth+1) + `"}`
+	if err := Decode([]byte(quoted), &p); err != nil {
+		t.Errorf("expected no error for brackets in a string, got %v", err)
+	}
+}
+
+func TestDecodeJSON_BodyTooLarge(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"username":"`+strings.Repeat("a", 64)+`"}`))
+	r.Body = http.MaxBytesReader(w, r.Body, 16)
+
+	var p testPayload
+	err := DecodeJSON(r, &p)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected ErrBodyTooLarge, got %v", err)
+	}
+}
+
+// FuzzDecode checks that Decode never panics, only accepts valid JSON objects
+// and always explains a rejection
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(`{"username":"alice","amount":12.5}`))
+	f.Add([]byte(`{"username":42,"amount":"ten","extra":true}`))
+	f.Add([]byte(`{"username":"a"} {"username":"b"}`))
+	f.Add([]byte(`[1,2,3]`))
+	f.Add([]byte(`{"username":"\u0000\"[{"}`))
+	f.Add([]byte(`{"amount":1e400}`))
+	f.Add([]byte(strings.Repeat("[", 100)))
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p testPayload
+		err := Decode(data, &p)
+		if err == nil {
+			if !json.Valid(data) {
+				t.Fatalf("accepted invalid JSON %q", data)
+			}
+			var obj map[string]json.RawMessage
+			if json.Unmarshal(data, &obj) != nil {
+				t.Fatalf("accepted non-object %q", data)
+			}
+			return
+		}
+
+		var decErr *DecodeError
+		if !errors.As(err, &decErr) || decErr.Message == "" {
+			t.Fatalf("rejection without a DecodeError message: %v", err)
+		}
+	})
+}
+
+func TestDecodeBody_Forms(t *testing.T) {
+	var mp bytes.Buffer
+	mw := multipart.NewWriter(&mp)
+	_ = mw.WriteField("username", "alice")
+	_ = mw.WriteField("amount", "12.5")
+	_ = mw.Close()
+
+	for name, r := range map[string]*http.Request{
+		"urlencoded": httptest.NewRequest("POST", "/?extra=ignored", strings.NewReader("username=alice&amount=12.5")),
+		"multipart":  httptest.NewRequest("POST", "/", &mp),
+		"json":       httptest.NewRequest("POST", "/", strings.NewReader(`{"username":"alice","amount":12.5}`)),
+	} {
+		switch name {
+		case "urlencoded":
+			r.Header.Set("Content-Type", ContentTypeForm+"; charset=utf-8")
+		case "multipart":
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+		}
+		var p testPayload
+		if err := DecodeBody(r, &p); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if p.Username != "alice" || p.Amount != 12.5 {
+			t.Errorf("%s: unexpected payload: %+v", name, p)
+		}
+	}
+}
+
+func TestDecodeForm_ReportsAllFieldErrors(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("username=a&username=b&amount=ten&extra=1"))
+	r.Header.Set("Content-Type", ContentTypeForm)
+
+	var p testPayload
+	err := DecodeBody(r, &p)
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+	want := []FieldError{
+		{Field: "amount", Reason: "expected float64"},
+		{Field: "extra", Reason: "unknown field"},
+		{Field: "username", Reason: "expected a single value"},
+	}
+	if !reflect.DeepEqual(decErr.Fields, want) {
+		t.Errorf("expected %+v, got %+v", want, decErr.Fields)
+	}
+}
+
+func TestDecodeForm_BodyTooLarge(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("username="+strings.Repeat("a", 64)))
+	r.Header.Set("Content-Type", ContentTypeForm)
+	r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 16)
+
+	var p testPayload
+	if err := DecodeBody(r, &p); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected ErrBodyTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package requestid carries the ID of an end-user request through the
+// services serving it, so their logs can be correlated. The gateway assigns
+// the ID, gRPC metadata carries it to the backends, and LogHandler adds it to
+// records logged with the request's context.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Header carries the ID over HTTP
+	Header = "X-Request-ID"
+	// MetadataKey carries the ID in gRPC metadata
+	MetadataKey = "x-request-id"
+	// LogKey is the attribute the ID is logged as
+	LogKey = "request_id"
+)
+
+// maxLength bounds IDs from callers, which are echoed back and logged
+const maxLength = 64
+
+// New returns a random ID
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid accepts IDs of at most 64 letters, digits, '-', '_' and '.'
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type idKey struct{}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the ID ctx carries, empty when none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// outgoing attaches ctx's ID to outgoing gRPC metadata
+func outgoing(ctx context.Context) context.Context {
+	if id := FromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, MetadataKey, id)
+	}
+	return ctx
+}
+
+// incoming returns a copy of ctx carrying the ID from its incoming gRPC
+// metadata, when the caller sent a valid one
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(MetadataKey); len(values) > 0 && Valid(values[0]) {
+		return NewContext(ctx, values[0])
+	}
+	return ctx
+}
+
+// UnaryClientInterceptor forwards the request ID of a call's context
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor forwards the request ID of a stream's context
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// serverFailure reports whether code means the server failed the call, as
+// opposed to refusing a bad request
+func serverFailure(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// logFailure logs a call the server failed, with its request ID
+func logFailure(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	if st := status.Convert(err); err != nil && serverFailure(st.Code()) {
+		logger.ErrorContext(ctx, "gRPC call failed",
+			"method", method,
+			"code", st.Code().String(),
+			"message", st.Message(),
+			"duration_ms", time.Since(start).Milliseconds())
+	}
+}
+
+// UnaryServerInterceptor puts the caller's request ID in the call's context
+// and logs calls the server fails
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = incoming(ctx)
+		resp, err := handler(ctx, req)
+		logFailure(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor puts the caller's request ID in the stream's
+// context and logs streams the server fails
+func StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := incoming(ss.Context())
+		err := handler(srv, serverStream{ServerStream: ss, ctx: ctx})
+		logFailure(ctx, logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// serverStream is a grpc.ServerStream with its context replaced
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context { return s.ctx }
+
+// LogHandler adds the request ID to records logged with a request's context
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps h
+func NewLogHandler(h slog.Handler) LogHandler {
+	return LogHandler{h}
+}
+
+func (h LogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		rec.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return LogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h LogHandler) WithGroup(name string) slog.Handler {
+	return LogHandler{h.Handler.WithGroup(name)}
+}
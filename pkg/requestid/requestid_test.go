@@ -0,0 +1,82 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const method = "/payment.PaymentService/GetTransactions"
+
+// forward sends ctx through the client interceptor and returns the context
+// the server would see
+func forward(t *testing.T, ctx context.Context) context.Context {
+	t.Helper()
+	var md metadata.MD
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := UnaryClientInterceptor()(ctx, method, nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestPropagation(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&logs, nil)))
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	var got string
+	handler := func(ctx context.Context, _ any) (any, error) {
+		got = FromContext(ctx)
+		return nil, status.Error(codes.Internal, "failed to get transactions")
+	}
+	_, _ = interceptor(forward(t, NewContext(context.Background(), "req-1")), nil, info, handler)
+	if got != "req-1" {
+		t.Fatalf("expected the handler to see req-1, got %q", got)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("expected one failed call logged, got %q: %v", logs.String(), err)
+	}
+	if record[LogKey] != "req-1" || record["code"] != "Internal" || record["method"] != method {
+		t.Errorf("expected the failure logged with its request ID, got %v", record)
+	}
+
+	// Refused requests aren't the server's failure; malformed IDs are dropped
+	logs.Reset()
+	handler = func(ctx context.Context, _ any) (any, error) {
+		got = FromContext(ctx)
+		return nil, status.Error(codes.InvalidArgument, "bad cursor")
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "bad id\n"))
+	_, _ = interceptor(ctx, nil, info, handler)
+	if got != "" || logs.Len() != 0 {
+		t.Errorf("expected no ID and no log, got %q, %q", got, logs.String())
+	}
+}
+
+func TestValid(t *testing.T) {
+	for id, want := range map[string]bool{
+		New():                   true,
+		"a1.B2_c3-d4":           true,
+		"":                      false,
+		"has space":             false,
+		strings.Repeat("a", 65): false,
+	} {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package testutil
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+)
+
+// FakeAuthClient is an in-memory authpb.AuthServiceClient. Tokens are real
+// JWTs signed with Secret, so they also pass pkg/jwt and grpcauth checks.
+type FakeAuthClient struct {
+	Secret string
+	// Err, when set, is returned by every call
+	Err error
+
+	mu     sync.Mutex
+	users  map[string]*fakeUser
+	nextID int
+}
+
+// fakeRoleScopes mirrors the scopes auth-service grants each role
+var fakeRoleScopes = map[string][]string{
+	"user":  {jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite},
+	"admin": {jwt.ScopePaymentsRead, jwt.ScopePaymentsWrite, jwt.ScopeAnalyticsRead},
+}
+
+type fakeUser struct {
+	id       int
+	email    string
+	password string
+	role     string
+	prefs    jwt.Preferences
+	// purgeAt is set while deletion is pending
+	purgeAt *time.Time
+	// devices are keyed by device id, or user agent without one
+	devices []*authpb.Device
+	// channels are the notification channels; nil means the default, email
+	channels []string
+}
+
+// NewFakeAuthClient creates a FakeAuthClient that signs tokens with secret
+func NewFakeAuthClient(secret string) *FakeAuthClient {
+	return &FakeAuthClient{
+		Secret: secret,
+		users:  make(map[string]*fakeUser),
+		nextID: 1,
+	}
+}
+
+// AddUser registers a user directly and returns a token for it
+func (f *FakeAuthClient) AddUser(username, password string) (int, string) {
+	return f.AddUserWithRole(username, password, "user")
+}
+
+// AddUserWithRole is AddUser for a user with the given role
+func (f *FakeAuthClient) AddUserWithRole(username, password, role string) (int, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := &fakeUser{id: f.nextID, password: password, role: role}
+	f.nextID++
+	f.users[username] = user
+	token, _ := jwt.IssueToken(user.id, username, f.Secret, jwt.WithRole(role), jwt.WithScopes(fakeRoleScopes[role]...))
+	return user.id, token
+}
+
+func (f *FakeAuthClient) Register(ctx context.Context, in *authpb.RegisterRequest, opts ...grpc.CallOption) (*authpb.AuthResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[in.Username]; ok {
+		return nil, status.Error(codes.AlreadyExists, "username already exists")
+	}
+	user := &fakeUser{
+		id:       f.nextID,
+		email:    strings.ToLower(in.Email),
+		password: in.Password,
+		role:     "user",
+		prefs:    jwt.Preferences{Timezone: in.Timezone, Locale: in.Locale},
+	}
+	f.nextID++
+	f.users[in.Username] = user
+	return f.response(in.Username, user)
+}
+
+func (f *FakeAuthClient) Login(ctx context.Context, in *authpb.LoginRequest, opts ...grpc.CallOption) (*authpb.AuthResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	login := in.Username
+	if login == "" {
+		login = in.Email
+	}
+	username, user := f.findLocked(login)
+	if user == nil || user.password != in.Password {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	user.recordDevice(in)
+	return f.response(username, user)
+}
+
+func (u *fakeUser) recordDevice(in *authpb.LoginRequest) {
+	id := in.DeviceId
+	if id == "" {
+		id = in.UserAgent
+	}
+	now := timestamppb.Now()
+	for _, d := range u.devices {
+		if d.Id == id {
+			d.UserAgent, d.LastSeenAt = in.UserAgent, now
+			return
+		}
+	}
+	u.devices = append(u.devices, &authpb.Device{Id: id, UserAgent: in.UserAgent, FirstSeenAt: now, LastSeenAt: now})
+}
+
+// findLocked looks login up as a username, then as an email
+func (f *FakeAuthClient) findLocked(login string) (string, *fakeUser) {
+	if user, ok := f.users[login]; ok {
+		return login, user
+	}
+	for username, user := range f.users {
+		if user.email != "" && strings.EqualFold(user.email, login) {
+			return username, user
+		}
+	}
+	return "", nil
+}
+
+func (f *FakeAuthClient) ValidateToken(ctx context.Context, in *authpb.ValidateTokenRequest, opts ...grpc.CallOption) (*authpb.ValidateTokenResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	claims, err := jwt.ValidateToken(in.Token, f.Secret)
+	if err != nil {
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	}
+	return &authpb.ValidateTokenResponse{
+		Valid:    true,
+		UserId:   int64(claims.UserID),
+		Username: claims.Username,
+		Timezone: claims.Timezone,
+		Locale:   claims.Locale,
+		Role:     claims.Role,
+		Scopes:   claims.Scopes,
+	}, nil
+}
+
+func (f *FakeAuthClient) UpdatePreferences(ctx context.Context, in *authpb.UpdatePreferencesRequest, opts ...grpc.CallOption) (*authpb.AuthResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+
+	claims, err := jwt.ValidateToken(in.Token, f.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[claims.Username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	user.prefs = jwt.Preferences{Timezone: in.Timezone, Locale: in.Locale}
+	return f.response(claims.Username, user)
+}
+
+// DeleteAccount schedules deletion 30 days out. The fake never purges, so
+// the account keeps working.
+func (f *FakeAuthClient) DeleteAccount(ctx context.Context, in *authpb.DeleteAccountRequest, opts ...grpc.CallOption) (*authpb.DeleteAccountResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+
+	claims, err := jwt.ValidateToken(in.Token, f.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[claims.Username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if user.password != in.Password {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if user.purgeAt == nil {
+		purgeAt := time.Now().Add(30 * 24 * time.Hour)
+		user.purgeAt = &purgeAt
+	}
+	return &authpb.DeleteAccountResponse{PurgeAt: timestamppb.New(*user.purgeAt)}, nil
+}
+
+func (f *FakeAuthClient) CancelAccountDeletion(ctx context.Context, in *authpb.CancelAccountDeletionRequest, opts ...grpc.CallOption) (*authpb.CancelAccountDeletionResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+
+	claims, err := jwt.ValidateToken(in.Token, f.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[claims.Username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if user.purgeAt == nil {
+		return nil, status.Error(codes.FailedPrecondition, "account deletion not pending")
+	}
+	user.purgeAt = nil
+	return &authpb.CancelAccountDeletionResponse{}, nil
+}
+
+func (f *FakeAuthClient) ListDevices(ctx context.Context, in *authpb.ListDevicesRequest, opts ...grpc.CallOption) (*authpb.DeviceList, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+
+	claims, err := jwt.ValidateToken(in.Token, f.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[claims.Username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &authpb.DeviceList{Devices: append([]*authpb.Device(nil), user.devices...)}, nil
+}
+
+// GetAuthMetrics always reports zero counters
+func (f *FakeAuthClient) GetAuthMetrics(ctx context.Context, in *authpb.GetAuthMetricsRequest, opts ...grpc.CallOption) (*authpb.AuthMetrics, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return &authpb.AuthMetrics{}, nil
+}
+
+// CreateInviteCode is not supported; the fake registers anyone
+func (f *FakeAuthClient) CreateInviteCode(ctx context.Context, in *authpb.CreateInviteCodeRequest, opts ...grpc.CallOption) (*authpb.InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "invite codes not supported by FakeAuthClient")
+}
+
+// GetInviteCode is not supported
+func (f *FakeAuthClient) GetInviteCode(ctx context.Context, in *authpb.GetInviteCodeRequest, opts ...grpc.CallOption) (*authpb.InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "invite codes not supported by FakeAuthClient")
+}
+
+// ListInviteCodes is not supported
+func (f *FakeAuthClient) ListInviteCodes(ctx context.Context, in *authpb.ListInviteCodesRequest, opts ...grpc.CallOption) (*authpb.InviteCodeList, error) {
+	return nil, status.Error(codes.Unimplemented, "invite codes not supported by FakeAuthClient")
+}
+
+// UpdateInviteCode is not supported
+func (f *FakeAuthClient) UpdateInviteCode(ctx context.Context, in *authpb.UpdateInviteCodeRequest, opts ...grpc.CallOption) (*authpb.InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "invite codes not supported by FakeAuthClient")
+}
+
+// DeleteInviteCode is not supported
+func (f *FakeAuthClient) DeleteInviteCode(ctx context.Context, in *authpb.DeleteInviteCodeRequest, opts ...grpc.CallOption) (*authpb.DeleteInviteCodeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "invite codes not supported by FakeAuthClient")
+}
+
+// ListUsers is not supported
+func (f *FakeAuthClient) ListUsers(ctx context.Context, in *authpb.ListUsersRequest, opts ...grpc.CallOption) (*authpb.UserList, error) {
+	return nil, status.Error(codes.Unimplemented, "user listing not supported by FakeAuthClient")
+}
+
+// GetNotificationChannels returns the caller's channels. Lookups with the
+// service token are not supported.
+func (f *FakeAuthClient) GetNotificationChannels(ctx context.Context, in *authpb.GetNotificationChannelsRequest, opts ...grpc.CallOption) (*authpb.NotificationChannels, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+	if in.Token == "" {
+		return nil, status.Error(codes.Unimplemented, "service token lookups not supported by FakeAuthClient")
+	}
+	return f.updateChannels(in.Token, nil)
+}
+
+// UpdateNotificationChannels stores the channels as given, without the
+// service's validation
+func (f *FakeAuthClient) UpdateNotificationChannels(ctx context.Context, in *authpb.UpdateNotificationChannelsRequest, opts ...grpc.CallOption) (*authpb.NotificationChannels, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	fillFromMetadata(ctx, in)
+	channels := append([]string{}, in.Channels...)
+	return f.updateChannels(in.Token, &channels)
+}
+
+// updateChannels returns the token's user's channels after replacing them
+// with channels, when set
+func (f *FakeAuthClient) updateChannels(token string, channels *[]string) (*authpb.NotificationChannels, error) {
+	claims, err := jwt.ValidateToken(token, f.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[claims.Username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if channels != nil {
+		user.channels = *channels
+	}
+	resp := &authpb.NotificationChannels{UserId: int64(user.id), Channels: user.channels}
+	if user.channels == nil {
+		resp.Channels = []string{"email"}
+	}
+	return resp, nil
+}
+
+func (f *FakeAuthClient) response(username string, user *fakeUser) (*authpb.AuthResponse, error) {
+	token, err := jwt.IssueToken(user.id, username, f.Secret,
+		jwt.WithPreferences(user.prefs), jwt.WithRole(user.role), jwt.WithScopes(fakeRoleScopes[user.role]...))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate token")
+	}
+	resp := &authpb.AuthResponse{
+		Id:       int64(user.id),
+		Username: username,
+		Email:    user.email,
+		Role:     user.role,
+		Token:    token,
+		Timezone: user.prefs.Timezone,
+		Locale:   user.prefs.Locale,
+	}
+	if user.purgeAt != nil {
+		resp.DeletionScheduledAt = timestamppb.New(*user.purgeAt)
+	}
+	return resp, nil
+}
+
+var _ authpb.AuthServiceClient = (*FakeAuthClient)(nil)
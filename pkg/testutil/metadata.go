@@ -0,0 +1,57 @@
+package testutil
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/tkaewplik/go-microservices/pkg/grpcauth"
+)
+
+// fillFromMetadata fills the string fields of in the caller left empty from
+// its outgoing metadata, as the auth service's metadata interceptor does for
+// calls from the gateway's REST routes: token from the bearer token, and
+// user_agent and device_id from the REST client's headers
+func fillFromMetadata(ctx context.Context, in proto.Message) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	m := in.ProtoReflect()
+	setIfEmpty(m, "token", bearerToken(ctx))
+	setIfEmpty(m, "user_agent", firstValue(md, "grpcgateway-user-agent"))
+	setIfEmpty(m, "device_id", firstValue(md, "grpcgateway-device-id"))
+}
+
+// bearerToken returns the token in ctx's outgoing authorization metadata
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	token, _ := strings.CutPrefix(firstValue(md, grpcauth.MetadataAuthorization), "Bearer ")
+	return token
+}
+
+// setHeader hands md to the grpc.Header options in opts, as a call to the
+// real service does with its response headers
+func setHeader(opts []grpc.CallOption, md metadata.MD) {
+	for _, opt := range opts {
+		if header, ok := opt.(grpc.HeaderCallOption); ok {
+			*header.HeaderAddr = md
+		}
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func setIfEmpty(m protoreflect.Message, name protoreflect.Name, value string) {
+	fd := m.Descriptor().Fields().ByName(name)
+	if value == "" || fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() || m.Get(fd).String() != "" {
+		return
+	}
+	m.Set(fd, protoreflect.ValueOfString(value))
+}
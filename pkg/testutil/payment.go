@@ -0,0 +1,341 @@
+package testutil
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tkaewplik/go-microservices/pkg/jwt"
+	"github.com/tkaewplik/go-microservices/pkg/ulid"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+// FakePaymentClient is an in-memory paymentpb.PaymentServiceClient. It
+// enforces MaxTotal over a user's lifetime and ignores list options.
+type FakePaymentClient struct {
+	MaxTotal float64
+	// WarningThresholds are the fractions of MaxTotal GetSummary reports as
+	// limit warnings once reached
+	WarningThresholds []float64
+	// Err, when set, is returned by every call
+	Err error
+	// Now stamps new transactions, receipts and statements
+	Now func() time.Time
+	// Secret, when set, is the auth service's signing secret: a call
+	// carrying a bearer token then acts for the token's user, as calls to
+	// the payment service do
+	Secret string
+
+	mu           sync.Mutex
+	transactions []*paymentpb.Transaction
+	receipts     map[int64]*paymentpb.Receipt
+	statements   map[string]*paymentpb.Statement
+	nextID       int64
+}
+
+// NewFakePaymentClient creates a FakePaymentClient with the service's
+// default limit of 1000 and warnings at 80% and 95% of it
+func NewFakePaymentClient() *FakePaymentClient {
+	return &FakePaymentClient{
+		MaxTotal:          1000,
+		WarningThresholds: []float64{0.8, 0.95},
+		Now:               time.Now,
+		nextID:            1,
+		receipts:          make(map[int64]*paymentpb.Receipt),
+		statements:        make(map[string]*paymentpb.Statement),
+	}
+}
+
+func (f *FakePaymentClient) CreateTransaction(ctx context.Context, in *paymentpb.CreateTransactionRequest, opts ...grpc.CallOption) (*paymentpb.Transaction, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if err := f.resolveUserID(ctx, &in.UserId); err != nil {
+		return nil, err
+	}
+	if in.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := f.totalLocked(in.UserId)
+	if total+in.Amount > f.MaxTotal {
+		st, err := status.New(codes.FailedPrecondition, "total amount exceeds maximum").WithDetails(&errdetails.ErrorInfo{
+			Reason: "LIMIT_EXCEEDED",
+			Domain: "payment-service",
+			Metadata: map[string]string{
+				"current_total":   formatAmount(total),
+				"max_allowed":     formatAmount(f.MaxTotal),
+				"remaining_limit": formatAmount(max(f.MaxTotal-total, 0)),
+			},
+		})
+		if err != nil {
+			return nil, status.Error(codes.FailedPrecondition, "total amount exceeds maximum")
+		}
+		return nil, st.Err()
+	}
+
+	now := f.Now()
+	tx := &paymentpb.Transaction{
+		Id:          f.nextID,
+		UserId:      in.UserId,
+		Amount:      in.Amount,
+		Description: in.Description,
+		CreatedAt:   timestamppb.New(now),
+		Ulid:        ulid.Make(now),
+	}
+	f.nextID++
+	f.transactions = append(f.transactions, tx)
+
+	total += in.Amount
+	setHeader(opts, metadata.Pairs(
+		"current-total", formatAmount(total),
+		"remaining-limit", formatAmount(max(f.MaxTotal-total, 0)),
+	))
+	return proto.Clone(tx).(*paymentpb.Transaction), nil
+}
+
+func (f *FakePaymentClient) GetTransactions(ctx context.Context, in *paymentpb.GetTransactionsRequest, opts ...grpc.CallOption) (*paymentpb.TransactionList, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if err := f.resolveUserID(ctx, &in.UserId); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	list := &paymentpb.TransactionList{}
+	for _, tx := range f.transactions {
+		if tx.UserId == in.UserId {
+			list.Transactions = append(list.Transactions, proto.Clone(tx).(*paymentpb.Transaction))
+		}
+	}
+	return list, nil
+}
+
+func (f *FakePaymentClient) PayAllTransactions(ctx context.Context, in *paymentpb.PayRequest, opts ...grpc.CallOption) (*paymentpb.PayResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if err := f.resolveUserID(ctx, &in.UserId); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var paid int64
+	for _, tx := range f.transactions {
+		if tx.UserId == in.UserId && !tx.IsPaid {
+			tx.IsPaid = true
+			paid++
+		}
+	}
+	return &paymentpb.PayResponse{
+		Message:          "transactions paid successfully",
+		TransactionsPaid: paid,
+	}, nil
+}
+
+func (f *FakePaymentClient) GetSummary(ctx context.Context, in *paymentpb.GetSummaryRequest, opts ...grpc.CallOption) (*paymentpb.Summary, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if err := f.resolveUserID(ctx, &in.UserId); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	summary := &paymentpb.Summary{}
+	for _, tx := range f.transactions {
+		if tx.UserId != in.UserId {
+			continue
+		}
+		summary.TransactionCount++
+		summary.PeriodTotal += tx.Amount
+		if tx.IsPaid {
+			summary.PaidTotal += tx.Amount
+			summary.PaidCount++
+		} else {
+			summary.UnpaidTotal += tx.Amount
+			summary.UnpaidCount++
+		}
+	}
+	summary.RemainingLimit = max(f.MaxTotal-summary.PeriodTotal, 0)
+	summary.MaxAllowed = f.MaxTotal
+	for _, threshold := range f.WarningThresholds {
+		if summary.PeriodTotal >= threshold*f.MaxTotal {
+			summary.LimitWarnings = append(summary.LimitWarnings, threshold)
+		}
+	}
+	return summary, nil
+}
+
+func (f *FakePaymentClient) AttachReceipt(ctx context.Context, in *paymentpb.AttachReceiptRequest, opts ...grpc.CallOption) (*paymentpb.AttachReceiptResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, ok := f.findLocked(in.UserId, in.TransactionId, in.TransactionUlid)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	resp := &paymentpb.AttachReceiptResponse{Receipt: proto.Clone(in.Receipt).(*paymentpb.Receipt)}
+	if resp.Receipt.UploadedAt == nil {
+		resp.Receipt.UploadedAt = timestamppb.New(f.Now())
+	}
+	if old, ok := f.receipts[id]; ok {
+		resp.ReplacedKey = old.Key
+	}
+	f.receipts[id] = proto.Clone(resp.Receipt).(*paymentpb.Receipt)
+	return resp, nil
+}
+
+func (f *FakePaymentClient) GetReceipt(ctx context.Context, in *paymentpb.GetReceiptRequest, opts ...grpc.CallOption) (*paymentpb.Receipt, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, ok := f.findLocked(in.UserId, in.TransactionId, in.TransactionUlid)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	receipt, ok := f.receipts[id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "receipt not found")
+	}
+	return proto.Clone(receipt).(*paymentpb.Receipt), nil
+}
+
+func (f *FakePaymentClient) StreamAllTransactions(ctx context.Context, in *paymentpb.StreamAllTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[paymentpb.Transaction], error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stream := &transactionStream{}
+	for _, tx := range f.transactions {
+		if in.Before == nil || tx.GetCreatedAt().AsTime().Before(in.Before.AsTime()) {
+			stream.transactions = append(stream.transactions, proto.Clone(tx).(*paymentpb.Transaction))
+		}
+	}
+	return stream, nil
+}
+
+// GenerateStatement records a statement for the month without rendering one
+func (f *FakePaymentClient) GenerateStatement(ctx context.Context, in *paymentpb.StatementRequest, opts ...grpc.CallOption) (*paymentpb.Statement, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if in.UserId <= 0 || in.Month == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid statement request")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := "statements/user=" + strconv.Itoa(int(in.UserId)) + "/" + in.Month + ".pdf"
+	statement := &paymentpb.Statement{Key: key, Month: in.Month, Size: 1, GeneratedAt: timestamppb.New(f.Now())}
+	f.statements[key] = statement
+	return proto.Clone(statement).(*paymentpb.Statement), nil
+}
+
+// GetStatement returns a statement recorded by GenerateStatement
+func (f *FakePaymentClient) GetStatement(ctx context.Context, in *paymentpb.StatementRequest, opts ...grpc.CallOption) (*paymentpb.Statement, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statement, ok := f.statements["statements/user="+strconv.Itoa(int(in.UserId))+"/"+in.Month+".pdf"]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "statement not found")
+	}
+	return proto.Clone(statement).(*paymentpb.Statement), nil
+}
+
+// transactionStream replays transactions to a streaming client
+type transactionStream struct {
+	grpc.ClientStream
+	transactions []*paymentpb.Transaction
+}
+
+func (s *transactionStream) Recv() (*paymentpb.Transaction, error) {
+	if len(s.transactions) == 0 {
+		return nil, io.EOF
+	}
+	tx := s.transactions[0]
+	s.transactions = s.transactions[1:]
+	return tx, nil
+}
+
+// findLocked returns the ID of the user's transaction named by ID or, when
+// txULID is set, by ULID
+// resolveUserID sets userID to the user of ctx's bearer token when Secret is
+// set, rejecting a different one the caller asked for
+func (f *FakePaymentClient) resolveUserID(ctx context.Context, userID *int64) error {
+	token := bearerToken(ctx)
+	if f.Secret == "" || token == "" {
+		return nil
+	}
+	claims, err := jwt.ValidateToken(token, f.Secret)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if *userID != 0 && *userID != int64(claims.UserID) {
+		return status.Error(codes.PermissionDenied, "user_id does not match authenticated user")
+	}
+	*userID = int64(claims.UserID)
+	return nil
+}
+
+func (f *FakePaymentClient) findLocked(userID, transactionID int64, txULID string) (int64, bool) {
+	for _, tx := range f.transactions {
+		if txULID != "" && tx.Ulid == txULID || txULID == "" && tx.Id == transactionID {
+			return tx.Id, tx.UserId == userID
+		}
+	}
+	return 0, false
+}
+
+func (f *FakePaymentClient) totalLocked(userID int64) float64 {
+	var total float64
+	for _, tx := range f.transactions {
+		if tx.UserId == userID {
+			total += tx.Amount
+		}
+	}
+	return total
+}
+
+func formatAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+var _ paymentpb.PaymentServiceClient = (*FakePaymentClient)(nil)
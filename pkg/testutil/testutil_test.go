@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	authpb "github.com/tkaewplik/go-microservices/proto/auth"
+	paymentpb "github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+func TestNewBufconnServer(t *testing.T) {
+	conn := NewBufconnServer(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	})
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", resp.Status)
+	}
+}
+
+func TestFakeAuthClient(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeAuthClient("secret")
+
+	reg, err := client.Register(ctx, &authpb.RegisterRequest{Username: "alice", Password: "pw", Email: "alice@example.com", Timezone: "Asia/Bangkok"})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if _, err := client.Register(ctx, &authpb.RegisterRequest{Username: "alice", Password: "pw"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+	if _, err := client.Login(ctx, &authpb.LoginRequest{Username: "alice", Password: "wrong"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+	if login, err := client.Login(ctx, &authpb.LoginRequest{Email: "Alice@example.com", Password: "pw"}); err != nil || login.Username != "alice" {
+		t.Errorf("expected login by email as alice, got %+v, %v", login, err)
+	}
+
+	v, err := client.ValidateToken(ctx, &authpb.ValidateTokenRequest{Token: reg.Token})
+	if err != nil || !v.Valid || v.UserId != reg.Id || v.Timezone != "Asia/Bangkok" {
+		t.Errorf("unexpected validation: %+v, %v", v, err)
+	}
+}
+
+func TestFakePaymentClient_Limit(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakePaymentClient()
+
+	var header metadata.MD
+	if _, err := client.CreateTransaction(ctx, &paymentpb.CreateTransactionRequest{UserId: 1, Amount: 900}, grpc.Header(&header)); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if remaining := header.Get("remaining-limit"); len(remaining) != 1 || remaining[0] != "100.00" {
+		t.Errorf("expected remaining 100.00, got %v", remaining)
+	}
+
+	_, err := client.CreateTransaction(ctx, &paymentpb.CreateTransactionRequest{UserId: 1, Amount: 200})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if len(status.Convert(err).Details()) != 1 {
+		t.Error("expected ErrorInfo detail")
+	}
+
+	paid, err := client.PayAllTransactions(ctx, &paymentpb.PayRequest{UserId: 1})
+	if err != nil || paid.TransactionsPaid != 1 {
+		t.Errorf("unexpected pay result: %+v, %v", paid, err)
+	}
+}
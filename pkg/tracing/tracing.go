@@ -0,0 +1,173 @@
+// Package tracing traces requests across the services with OpenTelemetry.
+// Spans are exported over OTLP, and W3C trace context travels in HTTP
+// headers, gRPC metadata and Kafka message headers, so a request can be
+// followed from the gateway through auth and payment to the events it
+// published.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+// instrumentation names the tracer of the spans this package starts
+const instrumentation = "github.com/tkaewplik/go-microservices/pkg/tracing"
+
+// Setup installs the global tracer provider of service, exporting spans over
+// OTLP/gRPC to OTEL_EXPORTER_OTLP_ENDPOINT. The exporter, sampler and
+// resource follow the standard OTEL_* variables, e.g. OTEL_SERVICE_NAME
+// overrides service. Without an endpoint, or with OTEL_SDK_DISABLED=true,
+// nothing is exported but trace context is still propagated, so a traced
+// caller's traces pass through. The returned func flushes pending spans.
+func Setup(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	noop := func(context.Context) error { return nil }
+	if !Enabled() {
+		return noop, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return noop, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return noop, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Enabled reports whether the environment asks for spans to be exported
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// ServerHandler traces the calls a gRPC server serves, continuing the
+// callers' traces
+func ServerHandler() stats.Handler {
+	return otelgrpc.NewServerHandler()
+}
+
+// ClientHandler traces the calls of a gRPC client, sending the trace along
+func ClientHandler() stats.Handler {
+	return otelgrpc.NewClientHandler()
+}
+
+// HTTPHandler traces the requests h serves, continuing the callers' traces.
+// Spans are named after the ServeMux pattern that served the request, e.g.
+// "GET /transactions/{id}", rather than its path, which holds IDs.
+func HTTPHandler(h http.Handler, operation string) http.Handler {
+	return otelhttp.NewHandler(h, operation, otelhttp.WithSpanNameFormatter(spanName))
+}
+
+// Routed names the span of each request mux serves after the pattern that
+// routed it. HTTPHandler does so itself when it wraps the ServeMux directly;
+// middleware in between that copies the request hides the pattern from it,
+// so wrap the ServeMux with Routed then.
+func Routed(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if r.Pattern != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(spanName("", r))
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+	})
+}
+
+// spanName names the span of r after its pattern once routed
+func spanName(operation string, r *http.Request) string {
+	switch {
+	case r.Pattern == "":
+		return operation
+	case strings.Contains(r.Pattern, " "):
+		return r.Pattern
+	}
+	return r.Method + " " + r.Pattern
+}
+
+// headerCarrier carries trace context in Kafka message headers
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// StartPublish starts a producer span for writing msgs to topic and puts its
+// trace context in each message's headers, so consumers can continue the
+// trace. The caller ends the span, recording the write's error if any.
+func StartPublish(ctx context.Context, topic string, msgs []kafka.Message) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentation).Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingBatchMessageCount(len(msgs)),
+		),
+	)
+	propagator := otel.GetTextMapPropagator()
+	for i := range msgs {
+		propagator.Inject(ctx, headerCarrier{&msgs[i].Headers})
+	}
+	return ctx, span
+}
+
+// Extract returns a copy of ctx continuing the trace msg's headers carry
+func Extract(ctx context.Context, msg *kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{&msg.Headers})
+}
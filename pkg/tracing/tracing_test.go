@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a tracer provider recording spans for the test
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestStartPublish(t *testing.T) {
+	record(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "create transaction")
+	defer parent.End()
+
+	msgs := []kafka.Message{{Headers: []kafka.Header{{Key: "priority", Value: []byte("high")}}}}
+	_, span := StartPublish(ctx, "transactions", msgs)
+	span.End()
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), &msgs[0]))
+	if got.TraceID() != parent.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("expected the message to carry the publish span, got %v", got)
+	}
+	if len(msgs[0].Headers) != 2 || msgs[0].Headers[0].Key != "priority" {
+		t.Errorf("expected the existing headers kept, got %v", msgs[0].Headers)
+	}
+}
+
+func TestHTTPHandler_NamesSpansAfterPattern(t *testing.T) {
+	recorder := record(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/receipts/{id}", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("POST /transactions", func(http.ResponseWriter, *http.Request) {})
+	// Middleware copying the request hides the pattern unless Routed
+	// reports it
+	copying := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(r.Context()))
+		})
+	}
+
+	for name, handler := range map[string]http.Handler{
+		"direct":     HTTPHandler(mux, "gateway"),
+		"middleware": HTTPHandler(copying(Routed(mux)), "gateway"),
+	} {
+		recorder.Reset()
+		for _, r := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/receipts/42", nil),
+			httptest.NewRequest(http.MethodPost, "/transactions", nil),
+			httptest.NewRequest(http.MethodGet, "/unknown", nil),
+		} {
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		}
+
+		spans := recorder.Ended()
+		if len(spans) != 3 {
+			t.Fatalf("%s: expected 3 spans, got %d", name, len(spans))
+		}
+		for i, want := range []string{"GET /receipts/{id}", "POST /transactions", "gateway"} {
+			if spans[i].Name() != want {
+				t.Errorf("%s: span %d: expected %q, got %q", name, i, want, spans[i].Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package ulid generates ULIDs: 128-bit identifiers made of a millisecond
+// timestamp and 80 random bits, written as 26 Crockford base32 characters.
+// They sort by creation time like serial IDs but can't be guessed from one
+// another, so they are safe to expose in URLs.
+package ulid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Len is the length of a ULID's string form
+const Len = 26
+
+// ErrInvalid is returned by Parse for strings that aren't ULIDs
+var ErrInvalid = errors.New("ulid: invalid ULID")
+
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// decoding maps alphabet characters, in either case, to their values; other
+// bytes map to 0xFF
+var decoding [256]byte
+
+func init() {
+	for i := range decoding {
+		decoding[i] = 0xFF
+	}
+	for i := range len(alphabet) {
+		decoding[alphabet[i]] = byte(i)
+		decoding[alphabet[i]|0x20] = byte(i) // lower case; digits are unchanged
+	}
+}
+
+// ULID is a decoded ULID
+type ULID [16]byte
+
+// New returns a ULID for time t with random bits from crypto/rand
+func New(t time.Time) ULID {
+	var u ULID
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(u[:6], ms[2:])
+	_, _ = rand.Read(u[6:])
+	return u
+}
+
+// Make returns the string form of a new ULID for time t
+func Make(t time.Time) string {
+	return New(t).String()
+}
+
+// Parse decodes s, in either case
+func Parse(s string) (ULID, error) {
+	var u ULID
+	// 26 characters hold 130 bits, so the first may only use its low 3
+	if len(s) != Len || decoding[s[0]] > 7 {
+		return u, ErrInvalid
+	}
+	for i := range Len {
+		v := decoding[s[i]]
+		if v == 0xFF {
+			return ULID{}, ErrInvalid
+		}
+		for j := range 5 {
+			if p := i*5 - 2 + j; p >= 0 && v>>(4-j)&1 == 1 {
+				u[p/8] |= 1 << (7 - p%8)
+			}
+		}
+	}
+	return u, nil
+}
+
+// Valid reports whether s is a ULID
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// String returns u's 26 character upper case form
+func (u ULID) String() string {
+	var b [Len]byte
+	for i := range Len {
+		var v byte
+		for j := range 5 {
+			if p := i*5 - 2 + j; p >= 0 && u[p/8]>>(7-p%8)&1 == 1 {
+				v |= 1 << (4 - j)
+			}
+		}
+		b[i] = alphabet[v]
+	}
+	return string(b[:])
+}
+
+// Time returns the millisecond u was made at
+func (u ULID) Time() time.Time {
+	var ms [8]byte
+	copy(ms[2:], u[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(ms[:])))
+}
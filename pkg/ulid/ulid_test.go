@@ -0,0 +1,59 @@
+package ulid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_KnownULID(t *testing.T) {
+	// The example from the ULID specification
+	const s = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	u, err := Parse(s)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := u.Time().UnixMilli(); got != 1469922850259 {
+		t.Errorf("expected time 1469922850259, got %d", got)
+	}
+	if u.String() != s {
+		t.Errorf("expected %s back, got %s", s, u)
+	}
+	if lower, err := Parse(strings.ToLower(s)); err != nil || lower != u {
+		t.Errorf("expected lower case to parse the same, got %s, %v", lower, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	a, b := New(at), New(at)
+	if a == b {
+		t.Error("expected random bits to differ")
+	}
+	if !a.Time().Equal(at) {
+		t.Errorf("expected time %v, got %v", at, a.Time())
+	}
+	if parsed, err := Parse(a.String()); err != nil || parsed != a {
+		t.Errorf("expected %s to round trip, got %s, %v", a, parsed, err)
+	}
+
+	// Later ULIDs sort after earlier ones
+	if later := Make(at.Add(time.Millisecond)); later <= a.String() || later <= b.String() {
+		t.Errorf("expected %s to sort after %s and %s", later, a, b)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"01ARZ3NDEKTSV4RRFFQ69G5FA",   // too short
+		"01ARZ3NDEKTSV4RRFFQ69G5FAVX", // too long
+		"01ARZ3NDEKTSV4RRFFQ69G5FAU",  // U isn't in the alphabet
+		"81ARZ3NDEKTSV4RRFFQ69G5FAV",  // overflows 128 bits
+		"12345",
+	} {
+		if Valid(s) {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
@@ -0,0 +1,683 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: analytics/analytics.proto
+
+package analytics
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetStatsIfChangedRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Since *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	// Dimensions to break created transactions down by: channel, country
+	Facets        []string `protobuf:"bytes,2,rep,name=facets,proto3" json:"facets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsIfChangedRequest) Reset() {
+	*x = GetStatsIfChangedRequest{}
+	mi := &file_analytics_analytics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsIfChangedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsIfChangedRequest) ProtoMessage() {}
+
+func (x *GetStatsIfChangedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsIfChangedRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsIfChangedRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetStatsIfChangedRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *GetStatsIfChangedRequest) GetFacets() []string {
+	if x != nil {
+		return x.Facets
+	}
+	return nil
+}
+
+type GetStatsIfChangedResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Changed bool                   `protobuf:"varint,1,opt,name=changed,proto3" json:"changed,omitempty"`
+	// When the stats last changed
+	LastModified *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	// Unset when unchanged
+	Stats         *Stats `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsIfChangedResponse) Reset() {
+	*x = GetStatsIfChangedResponse{}
+	mi := &file_analytics_analytics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsIfChangedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsIfChangedResponse) ProtoMessage() {}
+
+func (x *GetStatsIfChangedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsIfChangedResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsIfChangedResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetStatsIfChangedResponse) GetChanged() bool {
+	if x != nil {
+		return x.Changed
+	}
+	return false
+}
+
+func (x *GetStatsIfChangedResponse) GetLastModified() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastModified
+	}
+	return nil
+}
+
+func (x *GetStatsIfChangedResponse) GetStats() *Stats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type Stats struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	TotalTransactions     int64                  `protobuf:"varint,1,opt,name=total_transactions,json=totalTransactions,proto3" json:"total_transactions,omitempty"`
+	TotalAmount           float64                `protobuf:"fixed64,2,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	TotalPaidTransactions int64                  `protobuf:"varint,3,opt,name=total_paid_transactions,json=totalPaidTransactions,proto3" json:"total_paid_transactions,omitempty"`
+	EventsProcessed       int64                  `protobuf:"varint,4,opt,name=events_processed,json=eventsProcessed,proto3" json:"events_processed,omitempty"`
+	// Event time of the newest event processed, RFC 3339; empty before any
+	LastEventTime   string `protobuf:"bytes,5,opt,name=last_event_time,json=lastEventTime,proto3" json:"last_event_time,omitempty"`
+	UniqueUsers     int64  `protobuf:"varint,6,opt,name=unique_users,json=uniqueUsers,proto3" json:"unique_users,omitempty"`
+	CardinalityMode string `protobuf:"bytes,7,opt,name=cardinality_mode,json=cardinalityMode,proto3" json:"cardinality_mode,omitempty"`
+	// Distinct active users keyed by window, e.g. "5m"
+	ActiveUsers map[string]int64 `protobuf:"bytes,8,rep,name=active_users,json=activeUsers,proto3" json:"active_users,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Instances merged, when the service runs as a cluster
+	Instances     int32                   `protobuf:"varint,9,opt,name=instances,proto3" json:"instances,omitempty"`
+	PeersFailed   int32                   `protobuf:"varint,10,opt,name=peers_failed,json=peersFailed,proto3" json:"peers_failed,omitempty"`
+	Facets        map[string]*FacetValues `protobuf:"bytes,11,rep,name=facets,proto3" json:"facets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_analytics_analytics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Stats) GetTotalTransactions() int64 {
+	if x != nil {
+		return x.TotalTransactions
+	}
+	return 0
+}
+
+func (x *Stats) GetTotalAmount() float64 {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return 0
+}
+
+func (x *Stats) GetTotalPaidTransactions() int64 {
+	if x != nil {
+		return x.TotalPaidTransactions
+	}
+	return 0
+}
+
+func (x *Stats) GetEventsProcessed() int64 {
+	if x != nil {
+		return x.EventsProcessed
+	}
+	return 0
+}
+
+func (x *Stats) GetLastEventTime() string {
+	if x != nil {
+		return x.LastEventTime
+	}
+	return ""
+}
+
+func (x *Stats) GetUniqueUsers() int64 {
+	if x != nil {
+		return x.UniqueUsers
+	}
+	return 0
+}
+
+func (x *Stats) GetCardinalityMode() string {
+	if x != nil {
+		return x.CardinalityMode
+	}
+	return ""
+}
+
+func (x *Stats) GetActiveUsers() map[string]int64 {
+	if x != nil {
+		return x.ActiveUsers
+	}
+	return nil
+}
+
+func (x *Stats) GetInstances() int32 {
+	if x != nil {
+		return x.Instances
+	}
+	return 0
+}
+
+func (x *Stats) GetPeersFailed() int32 {
+	if x != nil {
+		return x.PeersFailed
+	}
+	return 0
+}
+
+func (x *Stats) GetFacets() map[string]*FacetValues {
+	if x != nil {
+		return x.Facets
+	}
+	return nil
+}
+
+// FacetValues are the totals for each value of one dimension
+type FacetValues struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Values        map[string]*FacetTotals `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetValues) Reset() {
+	*x = FacetValues{}
+	mi := &file_analytics_analytics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetValues) ProtoMessage() {}
+
+func (x *FacetValues) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetValues.ProtoReflect.Descriptor instead.
+func (*FacetValues) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FacetValues) GetValues() map[string]*FacetTotals {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type FacetTotals struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  int64                  `protobuf:"varint,1,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetTotals) Reset() {
+	*x = FacetTotals{}
+	mi := &file_analytics_analytics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetTotals) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetTotals) ProtoMessage() {}
+
+func (x *FacetTotals) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetTotals.ProtoReflect.Descriptor instead.
+func (*FacetTotals) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FacetTotals) GetTransactions() int64 {
+	if x != nil {
+		return x.Transactions
+	}
+	return 0
+}
+
+func (x *FacetTotals) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// Filters are ANDed; an empty filter matches everything
+type SubscribeEventsRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	UserIds []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	// e.g. transaction.created, transaction.paid
+	EventTypes    []string `protobuf:"bytes,2,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventsRequest) Reset() {
+	*x = SubscribeEventsRequest{}
+	mi := &file_analytics_analytics_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventsRequest) ProtoMessage() {}
+
+func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SubscribeEventsRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *SubscribeEventsRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+// Event is a processed transaction event, as consumed from Kafka
+type Event struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	EventType            string                 `protobuf:"bytes,1,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Version              int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	TransactionId        int64                  `protobuf:"varint,3,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	UserId               int64                  `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount               float64                `protobuf:"fixed64,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Description          string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	TransactionsPaid     int64                  `protobuf:"varint,7,opt,name=transactions_paid,json=transactionsPaid,proto3" json:"transactions_paid,omitempty"`
+	TransactionsReverted int64                  `protobuf:"varint,8,opt,name=transactions_reverted,json=transactionsReverted,proto3" json:"transactions_reverted,omitempty"`
+	PaymentBatchId       string                 `protobuf:"bytes,9,opt,name=payment_batch_id,json=paymentBatchId,proto3" json:"payment_batch_id,omitempty"`
+	// Where the request behind the event came from; empty on older events
+	Channel       string                 `protobuf:"bytes,10,opt,name=channel,proto3" json:"channel,omitempty"`
+	Country       string                 `protobuf:"bytes,11,opt,name=country,proto3" json:"country,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_analytics_analytics_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_analytics_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_analytics_analytics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Event) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *Event) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Event) GetTransactionId() int64 {
+	if x != nil {
+		return x.TransactionId
+	}
+	return 0
+}
+
+func (x *Event) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Event) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Event) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Event) GetTransactionsPaid() int64 {
+	if x != nil {
+		return x.TransactionsPaid
+	}
+	return 0
+}
+
+func (x *Event) GetTransactionsReverted() int64 {
+	if x != nil {
+		return x.TransactionsReverted
+	}
+	return 0
+}
+
+func (x *Event) GetPaymentBatchId() string {
+	if x != nil {
+		return x.PaymentBatchId
+	}
+	return ""
+}
+
+func (x *Event) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Event) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Event) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+var File_analytics_analytics_proto protoreflect.FileDescriptor
+
+const file_analytics_analytics_proto_rawDesc = "" +
+	"\n" +
+	"\x19analytics/analytics.proto\x12\tanalytics\x1a\x1fgoogle/protobuf/timestamp.proto\"d\n" +
+	"\x18GetStatsIfChangedRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x16\n" +
+	"\x06facets\x18\x02 \x03(\tR\x06facets\"\x9e\x01\n" +
+	"\x19GetStatsIfChangedResponse\x12\x18\n" +
+	"\achanged\x18\x01 \x01(\bR\achanged\x12?\n" +
+	"\rlast_modified\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\flastModified\x12&\n" +
+	"\x05stats\x18\x03 \x01(\v2\x10.analytics.StatsR\x05stats\"\x82\x05\n" +
+	"\x05Stats\x12-\n" +
+	"\x12total_transactions\x18\x01 \x01(\x03R\x11totalTransactions\x12!\n" +
+	"\ftotal_amount\x18\x02 \x01(\x01R\vtotalAmount\x126\n" +
+	"\x17total_paid_transactions\x18\x03 \x01(\x03R\x15totalPaidTransactions\x12)\n" +
+	"\x10events_processed\x18\x04 \x01(\x03R\x0feventsProcessed\x12&\n" +
+	"\x0flast_event_time\x18\x05 \x01(\tR\rlastEventTime\x12!\n" +
+	"\funique_users\x18\x06 \x01(\x03R\vuniqueUsers\x12)\n" +
+	"\x10cardinality_mode\x18\a \x01(\tR\x0fcardinalityMode\x12D\n" +
+	"\factive_users\x18\b \x03(\v2!.analytics.Stats.ActiveUsersEntryR\vactiveUsers\x12\x1c\n" +
+	"\tinstances\x18\t \x01(\x05R\tinstances\x12!\n" +
+	"\fpeers_failed\x18\n" +
+	" \x01(\x05R\vpeersFailed\x124\n" +
+	"\x06facets\x18\v \x03(\v2\x1c.analytics.Stats.FacetsEntryR\x06facets\x1a>\n" +
+	"\x10ActiveUsersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1aQ\n" +
+	"\vFacetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.analytics.FacetValuesR\x05value:\x028\x01\"\x9c\x01\n" +
+	"\vFacetValues\x12:\n" +
+	"\x06values\x18\x01 \x03(\v2\".analytics.FacetValues.ValuesEntryR\x06values\x1aQ\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.analytics.FacetTotalsR\x05value:\x028\x01\"I\n" +
+	"\vFacetTotals\x12\"\n" +
+	"\ftransactions\x18\x01 \x01(\x03R\ftransactions\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\"T\n" +
+	"\x16SubscribeEventsRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\x12\x1f\n" +
+	"\vevent_types\x18\x02 \x03(\tR\n" +
+	"eventTypes\"\xb4\x03\n" +
+	"\x05Event\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\tR\teventType\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12%\n" +
+	"\x0etransaction_id\x18\x03 \x01(\x03R\rtransactionId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\x01R\x06amount\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12+\n" +
+	"\x11transactions_paid\x18\a \x01(\x03R\x10transactionsPaid\x123\n" +
+	"\x15transactions_reverted\x18\b \x01(\x03R\x14transactionsReverted\x12(\n" +
+	"\x10payment_batch_id\x18\t \x01(\tR\x0epaymentBatchId\x12\x18\n" +
+	"\achannel\x18\n" +
+	" \x01(\tR\achannel\x12\x18\n" +
+	"\acountry\x18\v \x01(\tR\acountry\x128\n" +
+	"\ttimestamp\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp2\xbc\x01\n" +
+	"\x10AnalyticsService\x12^\n" +
+	"\x11GetStatsIfChanged\x12#.analytics.GetStatsIfChangedRequest\x1a$.analytics.GetStatsIfChangedResponse\x12H\n" +
+	"\x0fSubscribeEvents\x12!.analytics.SubscribeEventsRequest\x1a\x10.analytics.Event0\x01B7Z5github.com/tkaewplik/go-microservices/proto/analyticsb\x06proto3"
+
+var (
+	file_analytics_analytics_proto_rawDescOnce sync.Once
+	file_analytics_analytics_proto_rawDescData []byte
+)
+
+func file_analytics_analytics_proto_rawDescGZIP() []byte {
+	file_analytics_analytics_proto_rawDescOnce.Do(func() {
+		file_analytics_analytics_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_analytics_analytics_proto_rawDesc), len(file_analytics_analytics_proto_rawDesc)))
+	})
+	return file_analytics_analytics_proto_rawDescData
+}
+
+var file_analytics_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_analytics_analytics_proto_goTypes = []any{
+	(*GetStatsIfChangedRequest)(nil),  // 0: analytics.GetStatsIfChangedRequest
+	(*GetStatsIfChangedResponse)(nil), // 1: analytics.GetStatsIfChangedResponse
+	(*Stats)(nil),                     // 2: analytics.Stats
+	(*FacetValues)(nil),               // 3: analytics.FacetValues
+	(*FacetTotals)(nil),               // 4: analytics.FacetTotals
+	(*SubscribeEventsRequest)(nil),    // 5: analytics.SubscribeEventsRequest
+	(*Event)(nil),                     // 6: analytics.Event
+	nil,                               // 7: analytics.Stats.ActiveUsersEntry
+	nil,                               // 8: analytics.Stats.FacetsEntry
+	nil,                               // 9: analytics.FacetValues.ValuesEntry
+	(*timestamppb.Timestamp)(nil),     // 10: google.protobuf.Timestamp
+}
+var file_analytics_analytics_proto_depIdxs = []int32{
+	10, // 0: analytics.GetStatsIfChangedRequest.since:type_name -> google.protobuf.Timestamp
+	10, // 1: analytics.GetStatsIfChangedResponse.last_modified:type_name -> google.protobuf.Timestamp
+	2,  // 2: analytics.GetStatsIfChangedResponse.stats:type_name -> analytics.Stats
+	7,  // 3: analytics.Stats.active_users:type_name -> analytics.Stats.ActiveUsersEntry
+	8,  // 4: analytics.Stats.facets:type_name -> analytics.Stats.FacetsEntry
+	9,  // 5: analytics.FacetValues.values:type_name -> analytics.FacetValues.ValuesEntry
+	10, // 6: analytics.Event.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 7: analytics.Stats.FacetsEntry.value:type_name -> analytics.FacetValues
+	4,  // 8: analytics.FacetValues.ValuesEntry.value:type_name -> analytics.FacetTotals
+	0,  // 9: analytics.AnalyticsService.GetStatsIfChanged:input_type -> analytics.GetStatsIfChangedRequest
+	5,  // 10: analytics.AnalyticsService.SubscribeEvents:input_type -> analytics.SubscribeEventsRequest
+	1,  // 11: analytics.AnalyticsService.GetStatsIfChanged:output_type -> analytics.GetStatsIfChangedResponse
+	6,  // 12: analytics.AnalyticsService.SubscribeEvents:output_type -> analytics.Event
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_analytics_analytics_proto_init() }
+func file_analytics_analytics_proto_init() {
+	if File_analytics_analytics_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_analytics_analytics_proto_rawDesc), len(file_analytics_analytics_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_analytics_analytics_proto_goTypes,
+		DependencyIndexes: file_analytics_analytics_proto_depIdxs,
+		MessageInfos:      file_analytics_analytics_proto_msgTypes,
+	}.Build()
+	File_analytics_analytics_proto = out.File
+	file_analytics_analytics_proto_goTypes = nil
+	file_analytics_analytics_proto_depIdxs = nil
+}
@@ -0,0 +1,992 @@
+// Code generated by protoc-gen-validate. DO NOT EDIT.
+// source: analytics/analytics.proto
+
+package analytics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ensure the imports are used
+var (
+	_ = bytes.MinRead
+	_ = errors.New("")
+	_ = fmt.Print
+	_ = utf8.UTFMax
+	_ = (*regexp.Regexp)(nil)
+	_ = (*strings.Reader)(nil)
+	_ = net.IPv4len
+	_ = time.Duration(0)
+	_ = (*url.URL)(nil)
+	_ = (*mail.Address)(nil)
+	_ = anypb.Any{}
+	_ = sort.Sort
+)
+
+// Validate checks the field values on GetStatsIfChangedRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetStatsIfChangedRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetStatsIfChangedRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetStatsIfChangedRequestMultiError, or nil if none found.
+func (m *GetStatsIfChangedRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetStatsIfChangedRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetSince()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetStatsIfChangedRequestValidationError{
+					field:  "Since",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetStatsIfChangedRequestValidationError{
+					field:  "Since",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetSince()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetStatsIfChangedRequestValidationError{
+				field:  "Since",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return GetStatsIfChangedRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetStatsIfChangedRequestMultiError is an error wrapping multiple validation
+// errors returned by GetStatsIfChangedRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetStatsIfChangedRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetStatsIfChangedRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetStatsIfChangedRequestMultiError) AllErrors() []error { return m }
+
+// GetStatsIfChangedRequestValidationError is the validation error returned by
+// GetStatsIfChangedRequest.Validate if the designated constraints aren't met.
+type GetStatsIfChangedRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetStatsIfChangedRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetStatsIfChangedRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetStatsIfChangedRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetStatsIfChangedRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetStatsIfChangedRequestValidationError) ErrorName() string {
+	return "GetStatsIfChangedRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetStatsIfChangedRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetStatsIfChangedRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetStatsIfChangedRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetStatsIfChangedRequestValidationError{}
+
+// Validate checks the field values on GetStatsIfChangedResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetStatsIfChangedResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetStatsIfChangedResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetStatsIfChangedResponseMultiError, or nil if none found.
+func (m *GetStatsIfChangedResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetStatsIfChangedResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Changed
+
+	if all {
+		switch v := interface{}(m.GetLastModified()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetStatsIfChangedResponseValidationError{
+					field:  "LastModified",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetStatsIfChangedResponseValidationError{
+					field:  "LastModified",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetLastModified()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetStatsIfChangedResponseValidationError{
+				field:  "LastModified",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetStats()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetStatsIfChangedResponseValidationError{
+					field:  "Stats",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetStatsIfChangedResponseValidationError{
+					field:  "Stats",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetStats()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetStatsIfChangedResponseValidationError{
+				field:  "Stats",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return GetStatsIfChangedResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetStatsIfChangedResponseMultiError is an error wrapping multiple validation
+// errors returned by GetStatsIfChangedResponse.ValidateAll() if the
+// designated constraints aren't met.
+type GetStatsIfChangedResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetStatsIfChangedResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetStatsIfChangedResponseMultiError) AllErrors() []error { return m }
+
+// GetStatsIfChangedResponseValidationError is the validation error returned by
+// GetStatsIfChangedResponse.Validate if the designated constraints aren't met.
+type GetStatsIfChangedResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetStatsIfChangedResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetStatsIfChangedResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetStatsIfChangedResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetStatsIfChangedResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetStatsIfChangedResponseValidationError) ErrorName() string {
+	return "GetStatsIfChangedResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetStatsIfChangedResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetStatsIfChangedResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetStatsIfChangedResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetStatsIfChangedResponseValidationError{}
+
+// Validate checks the field values on Stats with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Stats) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Stats with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in StatsMultiError, or nil if none found.
+func (m *Stats) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Stats) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for TotalTransactions
+
+	// no validation rules for TotalAmount
+
+	// no validation rules for TotalPaidTransactions
+
+	// no validation rules for EventsProcessed
+
+	// no validation rules for LastEventTime
+
+	// no validation rules for UniqueUsers
+
+	// no validation rules for CardinalityMode
+
+	// no validation rules for ActiveUsers
+
+	// no validation rules for Instances
+
+	// no validation rules for PeersFailed
+
+	{
+		sorted_keys := make([]string, len(m.GetFacets()))
+		i := 0
+		for key := range m.GetFacets() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetFacets()[key]
+			_ = val
+
+			// no validation rules for Facets[key]
+
+			if all {
+				switch v := interface{}(val).(type) {
+				case interface{ ValidateAll() error }:
+					if err := v.ValidateAll(); err != nil {
+						errors = append(errors, StatsValidationError{
+							field:  fmt.Sprintf("Facets[%v]", key),
+							reason: "embedded message failed validation",
+							cause:  err,
+						})
+					}
+				case interface{ Validate() error }:
+					if err := v.Validate(); err != nil {
+						errors = append(errors, StatsValidationError{
+							field:  fmt.Sprintf("Facets[%v]", key),
+							reason: "embedded message failed validation",
+							cause:  err,
+						})
+					}
+				}
+			} else if v, ok := interface{}(val).(interface{ Validate() error }); ok {
+				if err := v.Validate(); err != nil {
+					return StatsValidationError{
+						field:  fmt.Sprintf("Facets[%v]", key),
+						reason: "embedded message failed validation",
+						cause:  err,
+					}
+				}
+			}
+
+		}
+	}
+
+	if len(errors) > 0 {
+		return StatsMultiError(errors)
+	}
+
+	return nil
+}
+
+// StatsMultiError is an error wrapping multiple validation errors returned by
+// Stats.ValidateAll() if the designated constraints aren't met.
+type StatsMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StatsMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StatsMultiError) AllErrors() []error { return m }
+
+// StatsValidationError is the validation error returned by Stats.Validate if
+// the designated constraints aren't met.
+type StatsValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StatsValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StatsValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StatsValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StatsValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StatsValidationError) ErrorName() string { return "StatsValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StatsValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStats.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StatsValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StatsValidationError{}
+
+// Validate checks the field values on FacetValues with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *FacetValues) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on FacetValues with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in FacetValuesMultiError, or
+// nil if none found.
+func (m *FacetValues) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *FacetValues) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	{
+		sorted_keys := make([]string, len(m.GetValues()))
+		i := 0
+		for key := range m.GetValues() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetValues()[key]
+			_ = val
+
+			// no validation rules for Values[key]
+
+			if all {
+				switch v := interface{}(val).(type) {
+				case interface{ ValidateAll() error }:
+					if err := v.ValidateAll(); err != nil {
+						errors = append(errors, FacetValuesValidationError{
+							field:  fmt.Sprintf("Values[%v]", key),
+							reason: "embedded message failed validation",
+							cause:  err,
+						})
+					}
+				case interface{ Validate() error }:
+					if err := v.Validate(); err != nil {
+						errors = append(errors, FacetValuesValidationError{
+							field:  fmt.Sprintf("Values[%v]", key),
+							reason: "embedded message failed validation",
+							cause:  err,
+						})
+					}
+				}
+			} else if v, ok := interface{}(val).(interface{ Validate() error }); ok {
+				if err := v.Validate(); err != nil {
+					return FacetValuesValidationError{
+						field:  fmt.Sprintf("Values[%v]", key),
+						reason: "embedded message failed validation",
+						cause:  err,
+					}
+				}
+			}
+
+		}
+	}
+
+	if len(errors) > 0 {
+		return FacetValuesMultiError(errors)
+	}
+
+	return nil
+}
+
+// FacetValuesMultiError is an error wrapping multiple validation errors
+// returned by FacetValues.ValidateAll() if the designated constraints aren't met.
+type FacetValuesMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m FacetValuesMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m FacetValuesMultiError) AllErrors() []error { return m }
+
+// FacetValuesValidationError is the validation error returned by
+// FacetValues.Validate if the designated constraints aren't met.
+type FacetValuesValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e FacetValuesValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e FacetValuesValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e FacetValuesValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e FacetValuesValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e FacetValuesValidationError) ErrorName() string { return "FacetValuesValidationError" }
+
+// Error satisfies the builtin error interface
+func (e FacetValuesValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sFacetValues.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = FacetValuesValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = FacetValuesValidationError{}
+
+// Validate checks the field values on FacetTotals with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *FacetTotals) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on FacetTotals with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in FacetTotalsMultiError, or
+// nil if none found.
+func (m *FacetTotals) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *FacetTotals) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Transactions
+
+	// no validation rules for Amount
+
+	if len(errors) > 0 {
+		return FacetTotalsMultiError(errors)
+	}
+
+	return nil
+}
+
+// FacetTotalsMultiError is an error wrapping multiple validation errors
+// returned by FacetTotals.ValidateAll() if the designated constraints aren't met.
+type FacetTotalsMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m FacetTotalsMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m FacetTotalsMultiError) AllErrors() []error { return m }
+
+// FacetTotalsValidationError is the validation error returned by
+// FacetTotals.Validate if the designated constraints aren't met.
+type FacetTotalsValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e FacetTotalsValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e FacetTotalsValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e FacetTotalsValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e FacetTotalsValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e FacetTotalsValidationError) ErrorName() string { return "FacetTotalsValidationError" }
+
+// Error satisfies the builtin error interface
+func (e FacetTotalsValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sFacetTotals.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = FacetTotalsValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = FacetTotalsValidationError{}
+
+// Validate checks the field values on SubscribeEventsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *SubscribeEventsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on SubscribeEventsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// SubscribeEventsRequestMultiError, or nil if none found.
+func (m *SubscribeEventsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *SubscribeEventsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return SubscribeEventsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// SubscribeEventsRequestMultiError is an error wrapping multiple validation
+// errors returned by SubscribeEventsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type SubscribeEventsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SubscribeEventsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SubscribeEventsRequestMultiError) AllErrors() []error { return m }
+
+// SubscribeEventsRequestValidationError is the validation error returned by
+// SubscribeEventsRequest.Validate if the designated constraints aren't met.
+type SubscribeEventsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SubscribeEventsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SubscribeEventsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SubscribeEventsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SubscribeEventsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SubscribeEventsRequestValidationError) ErrorName() string {
+	return "SubscribeEventsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e SubscribeEventsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSubscribeEventsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SubscribeEventsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SubscribeEventsRequestValidationError{}
+
+// Validate checks the field values on Event with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Event) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Event with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in EventMultiError, or nil if none found.
+func (m *Event) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Event) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for EventType
+
+	// no validation rules for Version
+
+	// no validation rules for TransactionId
+
+	// no validation rules for UserId
+
+	// no validation rules for Amount
+
+	// no validation rules for Description
+
+	// no validation rules for TransactionsPaid
+
+	// no validation rules for TransactionsReverted
+
+	// no validation rules for PaymentBatchId
+
+	// no validation rules for Channel
+
+	// no validation rules for Country
+
+	if all {
+		switch v := interface{}(m.GetTimestamp()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, EventValidationError{
+					field:  "Timestamp",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, EventValidationError{
+					field:  "Timestamp",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetTimestamp()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return EventValidationError{
+				field:  "Timestamp",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return EventMultiError(errors)
+	}
+
+	return nil
+}
+
+// EventMultiError is an error wrapping multiple validation errors returned by
+// Event.ValidateAll() if the designated constraints aren't met.
+type EventMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EventMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EventMultiError) AllErrors() []error { return m }
+
+// EventValidationError is the validation error returned by Event.Validate if
+// the designated constraints aren't met.
+type EventValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EventValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EventValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EventValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EventValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EventValidationError) ErrorName() string { return "EventValidationError" }
+
+// Error satisfies the builtin error interface
+func (e EventValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEvent.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EventValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EventValidationError{}
@@ -1,8 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.6.0
-// - protoc             v6.33.2
-// source: proto/auth/auth.proto
+// - protoc             (unknown)
+// source: auth/auth.proto
 
 package auth
 
@@ -19,16 +19,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AuthService_Register_FullMethodName      = "/auth.AuthService/Register"
-	AuthService_Login_FullMethodName         = "/auth.AuthService/Login"
-	AuthService_ValidateToken_FullMethodName = "/auth.AuthService/ValidateToken"
+	AuthService_Register_FullMethodName                   = "/auth.AuthService/Register"
+	AuthService_Login_FullMethodName                      = "/auth.AuthService/Login"
+	AuthService_ValidateToken_FullMethodName              = "/auth.AuthService/ValidateToken"
+	AuthService_UpdatePreferences_FullMethodName          = "/auth.AuthService/UpdatePreferences"
+	AuthService_DeleteAccount_FullMethodName              = "/auth.AuthService/DeleteAccount"
+	AuthService_CancelAccountDeletion_FullMethodName      = "/auth.AuthService/CancelAccountDeletion"
+	AuthService_ListDevices_FullMethodName                = "/auth.AuthService/ListDevices"
+	AuthService_GetAuthMetrics_FullMethodName             = "/auth.AuthService/GetAuthMetrics"
+	AuthService_CreateInviteCode_FullMethodName           = "/auth.AuthService/CreateInviteCode"
+	AuthService_GetInviteCode_FullMethodName              = "/auth.AuthService/GetInviteCode"
+	AuthService_ListInviteCodes_FullMethodName            = "/auth.AuthService/ListInviteCodes"
+	AuthService_UpdateInviteCode_FullMethodName           = "/auth.AuthService/UpdateInviteCode"
+	AuthService_DeleteInviteCode_FullMethodName           = "/auth.AuthService/DeleteInviteCode"
+	AuthService_ListUsers_FullMethodName                  = "/auth.AuthService/ListUsers"
+	AuthService_GetNotificationChannels_FullMethodName    = "/auth.AuthService/GetNotificationChannels"
+	AuthService_UpdateNotificationChannels_FullMethodName = "/auth.AuthService/UpdateNotificationChannels"
 )
 
 // AuthServiceClient is the client API for AuthService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// AuthService provides authentication operations
+// AuthService provides authentication operations. RPCs with a google.api.http option
+// are also served as REST by the gateway (grpc-gateway): under /v1, and at the
+// additional_bindings, the paths clients used before /v1, in the gateway's
+// original JSON shapes. The rest are internal or admin only and reachable over
+// gRPC alone.
 type AuthServiceClient interface {
 	// Register creates a new user account
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AuthResponse, error)
@@ -36,6 +53,42 @@ type AuthServiceClient interface {
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*AuthResponse, error)
 	// ValidateToken validates a JWT token and returns user info
 	ValidateToken(ctx context.Context, in *ValidateTokenRequest, opts ...grpc.CallOption) (*ValidateTokenResponse, error)
+	// UpdatePreferences changes the caller's timezone and locale and returns a
+	// new token carrying them
+	UpdatePreferences(ctx context.Context, in *UpdatePreferencesRequest, opts ...grpc.CallOption) (*AuthResponse, error)
+	// DeleteAccount schedules the caller's account for deletion. Until the
+	// returned purge_at the user can still log in and cancel; after it logins
+	// fail and other services purge the user's data.
+	DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error)
+	// CancelAccountDeletion keeps an account whose deletion is pending
+	CancelAccountDeletion(ctx context.Context, in *CancelAccountDeletionRequest, opts ...grpc.CallOption) (*CancelAccountDeletionResponse, error)
+	// ListDevices returns the devices the caller has logged in from, most
+	// recently seen first
+	ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*DeviceList, error)
+	// GetAuthMetrics returns token validation counters. Admin only: the caller
+	// must send the service token in x-service-token metadata.
+	GetAuthMetrics(ctx context.Context, in *GetAuthMetricsRequest, opts ...grpc.CallOption) (*AuthMetrics, error)
+	// CreateInviteCode generates a signup invite code. Admin only, like
+	// GetAuthMetrics, as are the other invite code RPCs.
+	CreateInviteCode(ctx context.Context, in *CreateInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error)
+	// GetInviteCode returns one invite code with its usage
+	GetInviteCode(ctx context.Context, in *GetInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error)
+	// ListInviteCodes returns every invite code, newest first
+	ListInviteCodes(ctx context.Context, in *ListInviteCodesRequest, opts ...grpc.CallOption) (*InviteCodeList, error)
+	// UpdateInviteCode replaces an invite code's role, max uses and expiry
+	UpdateInviteCode(ctx context.Context, in *UpdateInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error)
+	// DeleteInviteCode revokes an invite code. Users who registered with it
+	// keep their accounts and role.
+	DeleteInviteCode(ctx context.Context, in *DeleteInviteCodeRequest, opts ...grpc.CallOption) (*DeleteInviteCodeResponse, error)
+	// ListUsers pages through every account in registration order. Admin
+	// only, like GetAuthMetrics.
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*UserList, error)
+	// GetNotificationChannels returns the channels a user wants notifications
+	// on. Users pass their token; the notification service instead sends the
+	// service token in x-service-token metadata and names user_id.
+	GetNotificationChannels(ctx context.Context, in *GetNotificationChannelsRequest, opts ...grpc.CallOption) (*NotificationChannels, error)
+	// UpdateNotificationChannels replaces the caller's notification channels
+	UpdateNotificationChannels(ctx context.Context, in *UpdateNotificationChannelsRequest, opts ...grpc.CallOption) (*NotificationChannels, error)
 }
 
 type authServiceClient struct {
@@ -76,11 +129,145 @@ func (c *authServiceClient) ValidateToken(ctx context.Context, in *ValidateToken
 	return out, nil
 }
 
+func (c *authServiceClient) UpdatePreferences(ctx context.Context, in *UpdatePreferencesRequest, opts ...grpc.CallOption) (*AuthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthResponse)
+	err := c.cc.Invoke(ctx, AuthService_UpdatePreferences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAccountResponse)
+	err := c.cc.Invoke(ctx, AuthService_DeleteAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) CancelAccountDeletion(ctx context.Context, in *CancelAccountDeletionRequest, opts ...grpc.CallOption) (*CancelAccountDeletionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelAccountDeletionResponse)
+	err := c.cc.Invoke(ctx, AuthService_CancelAccountDeletion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*DeviceList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceList)
+	err := c.cc.Invoke(ctx, AuthService_ListDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) GetAuthMetrics(ctx context.Context, in *GetAuthMetricsRequest, opts ...grpc.CallOption) (*AuthMetrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthMetrics)
+	err := c.cc.Invoke(ctx, AuthService_GetAuthMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) CreateInviteCode(ctx context.Context, in *CreateInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteCode)
+	err := c.cc.Invoke(ctx, AuthService_CreateInviteCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) GetInviteCode(ctx context.Context, in *GetInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteCode)
+	err := c.cc.Invoke(ctx, AuthService_GetInviteCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) ListInviteCodes(ctx context.Context, in *ListInviteCodesRequest, opts ...grpc.CallOption) (*InviteCodeList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteCodeList)
+	err := c.cc.Invoke(ctx, AuthService_ListInviteCodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) UpdateInviteCode(ctx context.Context, in *UpdateInviteCodeRequest, opts ...grpc.CallOption) (*InviteCode, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteCode)
+	err := c.cc.Invoke(ctx, AuthService_UpdateInviteCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) DeleteInviteCode(ctx context.Context, in *DeleteInviteCodeRequest, opts ...grpc.CallOption) (*DeleteInviteCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteInviteCodeResponse)
+	err := c.cc.Invoke(ctx, AuthService_DeleteInviteCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*UserList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserList)
+	err := c.cc.Invoke(ctx, AuthService_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) GetNotificationChannels(ctx context.Context, in *GetNotificationChannelsRequest, opts ...grpc.CallOption) (*NotificationChannels, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NotificationChannels)
+	err := c.cc.Invoke(ctx, AuthService_GetNotificationChannels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) UpdateNotificationChannels(ctx context.Context, in *UpdateNotificationChannelsRequest, opts ...grpc.CallOption) (*NotificationChannels, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NotificationChannels)
+	err := c.cc.Invoke(ctx, AuthService_UpdateNotificationChannels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AuthServiceServer is the server API for AuthService service.
 // All implementations must embed UnimplementedAuthServiceServer
 // for forward compatibility.
 //
-// AuthService provides authentication operations
+// AuthService provides authentication operations. RPCs with a google.api.http option
+// are also served as REST by the gateway (grpc-gateway): under /v1, and at the
+// additional_bindings, the paths clients used before /v1, in the gateway's
+// original JSON shapes. The rest are internal or admin only and reachable over
+// gRPC alone.
 type AuthServiceServer interface {
 	// Register creates a new user account
 	Register(context.Context, *RegisterRequest) (*AuthResponse, error)
@@ -88,6 +275,42 @@ type AuthServiceServer interface {
 	Login(context.Context, *LoginRequest) (*AuthResponse, error)
 	// ValidateToken validates a JWT token and returns user info
 	ValidateToken(context.Context, *ValidateTokenRequest) (*ValidateTokenResponse, error)
+	// UpdatePreferences changes the caller's timezone and locale and returns a
+	// new token carrying them
+	UpdatePreferences(context.Context, *UpdatePreferencesRequest) (*AuthResponse, error)
+	// DeleteAccount schedules the caller's account for deletion. Until the
+	// returned purge_at the user can still log in and cancel; after it logins
+	// fail and other services purge the user's data.
+	DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error)
+	// CancelAccountDeletion keeps an account whose deletion is pending
+	CancelAccountDeletion(context.Context, *CancelAccountDeletionRequest) (*CancelAccountDeletionResponse, error)
+	// ListDevices returns the devices the caller has logged in from, most
+	// recently seen first
+	ListDevices(context.Context, *ListDevicesRequest) (*DeviceList, error)
+	// GetAuthMetrics returns token validation counters. Admin only: the caller
+	// must send the service token in x-service-token metadata.
+	GetAuthMetrics(context.Context, *GetAuthMetricsRequest) (*AuthMetrics, error)
+	// CreateInviteCode generates a signup invite code. Admin only, like
+	// GetAuthMetrics, as are the other invite code RPCs.
+	CreateInviteCode(context.Context, *CreateInviteCodeRequest) (*InviteCode, error)
+	// GetInviteCode returns one invite code with its usage
+	GetInviteCode(context.Context, *GetInviteCodeRequest) (*InviteCode, error)
+	// ListInviteCodes returns every invite code, newest first
+	ListInviteCodes(context.Context, *ListInviteCodesRequest) (*InviteCodeList, error)
+	// UpdateInviteCode replaces an invite code's role, max uses and expiry
+	UpdateInviteCode(context.Context, *UpdateInviteCodeRequest) (*InviteCode, error)
+	// DeleteInviteCode revokes an invite code. Users who registered with it
+	// keep their accounts and role.
+	DeleteInviteCode(context.Context, *DeleteInviteCodeRequest) (*DeleteInviteCodeResponse, error)
+	// ListUsers pages through every account in registration order. Admin
+	// only, like GetAuthMetrics.
+	ListUsers(context.Context, *ListUsersRequest) (*UserList, error)
+	// GetNotificationChannels returns the channels a user wants notifications
+	// on. Users pass their token; the notification service instead sends the
+	// service token in x-service-token metadata and names user_id.
+	GetNotificationChannels(context.Context, *GetNotificationChannelsRequest) (*NotificationChannels, error)
+	// UpdateNotificationChannels replaces the caller's notification channels
+	UpdateNotificationChannels(context.Context, *UpdateNotificationChannelsRequest) (*NotificationChannels, error)
 	mustEmbedUnimplementedAuthServiceServer()
 }
 
@@ -107,6 +330,45 @@ func (UnimplementedAuthServiceServer) Login(context.Context, *LoginRequest) (*Au
 func (UnimplementedAuthServiceServer) ValidateToken(context.Context, *ValidateTokenRequest) (*ValidateTokenResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ValidateToken not implemented")
 }
+func (UnimplementedAuthServiceServer) UpdatePreferences(context.Context, *UpdatePreferencesRequest) (*AuthResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdatePreferences not implemented")
+}
+func (UnimplementedAuthServiceServer) DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedAuthServiceServer) CancelAccountDeletion(context.Context, *CancelAccountDeletionRequest) (*CancelAccountDeletionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelAccountDeletion not implemented")
+}
+func (UnimplementedAuthServiceServer) ListDevices(context.Context, *ListDevicesRequest) (*DeviceList, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDevices not implemented")
+}
+func (UnimplementedAuthServiceServer) GetAuthMetrics(context.Context, *GetAuthMetricsRequest) (*AuthMetrics, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAuthMetrics not implemented")
+}
+func (UnimplementedAuthServiceServer) CreateInviteCode(context.Context, *CreateInviteCodeRequest) (*InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateInviteCode not implemented")
+}
+func (UnimplementedAuthServiceServer) GetInviteCode(context.Context, *GetInviteCodeRequest) (*InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetInviteCode not implemented")
+}
+func (UnimplementedAuthServiceServer) ListInviteCodes(context.Context, *ListInviteCodesRequest) (*InviteCodeList, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListInviteCodes not implemented")
+}
+func (UnimplementedAuthServiceServer) UpdateInviteCode(context.Context, *UpdateInviteCodeRequest) (*InviteCode, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateInviteCode not implemented")
+}
+func (UnimplementedAuthServiceServer) DeleteInviteCode(context.Context, *DeleteInviteCodeRequest) (*DeleteInviteCodeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteInviteCode not implemented")
+}
+func (UnimplementedAuthServiceServer) ListUsers(context.Context, *ListUsersRequest) (*UserList, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedAuthServiceServer) GetNotificationChannels(context.Context, *GetNotificationChannelsRequest) (*NotificationChannels, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetNotificationChannels not implemented")
+}
+func (UnimplementedAuthServiceServer) UpdateNotificationChannels(context.Context, *UpdateNotificationChannelsRequest) (*NotificationChannels, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateNotificationChannels not implemented")
+}
 func (UnimplementedAuthServiceServer) mustEmbedUnimplementedAuthServiceServer() {}
 func (UnimplementedAuthServiceServer) testEmbeddedByValue()                     {}
 
@@ -182,6 +444,240 @@ func _AuthService_ValidateToken_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AuthService_UpdatePreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).UpdatePreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_UpdatePreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).UpdatePreferences(ctx, req.(*UpdatePreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_DeleteAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).DeleteAccount(ctx, req.(*DeleteAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_CancelAccountDeletion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelAccountDeletionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).CancelAccountDeletion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_CancelAccountDeletion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).CancelAccountDeletion(ctx, req.(*CancelAccountDeletionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDevicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).ListDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_ListDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).ListDevices(ctx, req.(*ListDevicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_GetAuthMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAuthMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).GetAuthMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_GetAuthMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).GetAuthMetrics(ctx, req.(*GetAuthMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_CreateInviteCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInviteCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).CreateInviteCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_CreateInviteCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).CreateInviteCode(ctx, req.(*CreateInviteCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_GetInviteCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInviteCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).GetInviteCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_GetInviteCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).GetInviteCode(ctx, req.(*GetInviteCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_ListInviteCodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInviteCodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).ListInviteCodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_ListInviteCodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).ListInviteCodes(ctx, req.(*ListInviteCodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_UpdateInviteCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateInviteCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).UpdateInviteCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_UpdateInviteCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).UpdateInviteCode(ctx, req.(*UpdateInviteCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_DeleteInviteCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteInviteCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).DeleteInviteCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_DeleteInviteCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).DeleteInviteCode(ctx, req.(*DeleteInviteCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_GetNotificationChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationChannelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).GetNotificationChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_GetNotificationChannels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).GetNotificationChannels(ctx, req.(*GetNotificationChannelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_UpdateNotificationChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNotificationChannelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).UpdateNotificationChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_UpdateNotificationChannels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).UpdateNotificationChannels(ctx, req.(*UpdateNotificationChannelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AuthService_ServiceDesc is the grpc.ServiceDesc for AuthService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,7 +697,59 @@ var AuthService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ValidateToken",
 			Handler:    _AuthService_ValidateToken_Handler,
 		},
+		{
+			MethodName: "UpdatePreferences",
+			Handler:    _AuthService_UpdatePreferences_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _AuthService_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "CancelAccountDeletion",
+			Handler:    _AuthService_CancelAccountDeletion_Handler,
+		},
+		{
+			MethodName: "ListDevices",
+			Handler:    _AuthService_ListDevices_Handler,
+		},
+		{
+			MethodName: "GetAuthMetrics",
+			Handler:    _AuthService_GetAuthMetrics_Handler,
+		},
+		{
+			MethodName: "CreateInviteCode",
+			Handler:    _AuthService_CreateInviteCode_Handler,
+		},
+		{
+			MethodName: "GetInviteCode",
+			Handler:    _AuthService_GetInviteCode_Handler,
+		},
+		{
+			MethodName: "ListInviteCodes",
+			Handler:    _AuthService_ListInviteCodes_Handler,
+		},
+		{
+			MethodName: "UpdateInviteCode",
+			Handler:    _AuthService_UpdateInviteCode_Handler,
+		},
+		{
+			MethodName: "DeleteInviteCode",
+			Handler:    _AuthService_DeleteInviteCode_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _AuthService_ListUsers_Handler,
+		},
+		{
+			MethodName: "GetNotificationChannels",
+			Handler:    _AuthService_GetNotificationChannels_Handler,
+		},
+		{
+			MethodName: "UpdateNotificationChannels",
+			Handler:    _AuthService_UpdateNotificationChannels_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/auth/auth.proto",
+	Metadata: "auth/auth.proto",
 }
@@ -0,0 +1,219 @@
+// Package breaking detects wire and JSON incompatible changes between two
+// versions of the service contracts
+package breaking
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Violation is a single incompatible change
+type Violation struct {
+	File    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.File + ": " + v.Message
+}
+
+// Check compares current against previous and returns every change that would
+// break existing clients: deleted files, messages, fields, enum values,
+// services and methods, changed field numbers, types, names or cardinality,
+// and changed method signatures. Deleting a field or enum value is allowed
+// when its number is reserved.
+func Check(previous, current *descriptorpb.FileDescriptorSet) []Violation {
+	currentFiles := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, f := range current.GetFile() {
+		currentFiles[f.GetName()] = f
+	}
+
+	var violations []Violation
+	for _, prev := range previous.GetFile() {
+		c := &checker{file: prev.GetName()}
+		cur, ok := currentFiles[prev.GetName()]
+		if !ok {
+			c.report("file deleted")
+		} else {
+			c.checkFile(prev, cur)
+		}
+		violations = append(violations, c.violations...)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].File < violations[j].File })
+	return violations
+}
+
+type checker struct {
+	file       string
+	violations []Violation
+}
+
+func (c *checker) report(format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{File: c.file, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) checkFile(prev, cur *descriptorpb.FileDescriptorProto) {
+	if prev.GetPackage() != cur.GetPackage() {
+		c.report("package changed from %q to %q", prev.GetPackage(), cur.GetPackage())
+	}
+	if prev.GetOptions().GetGoPackage() != cur.GetOptions().GetGoPackage() {
+		c.report("go_package changed from %q to %q", prev.GetOptions().GetGoPackage(), cur.GetOptions().GetGoPackage())
+	}
+
+	prefix := prev.GetPackage()
+	c.checkMessages(prefix, prev.GetMessageType(), cur.GetMessageType())
+	c.checkEnums(prefix, prev.GetEnumType(), cur.GetEnumType())
+	c.checkServices(prefix, prev.GetService(), cur.GetService())
+}
+
+func (c *checker) checkMessages(prefix string, prev, cur []*descriptorpb.DescriptorProto) {
+	byName := make(map[string]*descriptorpb.DescriptorProto, len(cur))
+	for _, m := range cur {
+		byName[m.GetName()] = m
+	}
+
+	for _, p := range prev {
+		name := qualify(prefix, p.GetName())
+		m, ok := byName[p.GetName()]
+		if !ok {
+			c.report("message %s deleted", name)
+			continue
+		}
+		c.checkFields(name, p, m)
+		c.checkMessages(name, p.GetNestedType(), m.GetNestedType())
+		c.checkEnums(name, p.GetEnumType(), m.GetEnumType())
+	}
+}
+
+func (c *checker) checkFields(message string, prev, cur *descriptorpb.DescriptorProto) {
+	byNumber := make(map[int32]*descriptorpb.FieldDescriptorProto, len(cur.GetField()))
+	for _, f := range cur.GetField() {
+		byNumber[f.GetNumber()] = f
+	}
+
+	for _, p := range prev.GetField() {
+		f, ok := byNumber[p.GetNumber()]
+		if !ok {
+			if !messageReserved(cur, p.GetNumber()) {
+				c.report("field %s.%s (%d) deleted without reserving its number", message, p.GetName(), p.GetNumber())
+			}
+			continue
+		}
+		if p.GetName() != f.GetName() {
+			c.report("field %s (%d) renamed from %q to %q", message, p.GetNumber(), p.GetName(), f.GetName())
+		}
+		if p.GetType() != f.GetType() || p.GetTypeName() != f.GetTypeName() {
+			c.report("field %s.%s (%d) changed type from %s to %s", message, p.GetName(), p.GetNumber(), fieldType(p), fieldType(f))
+		}
+		if p.GetLabel() != f.GetLabel() {
+			c.report("field %s.%s (%d) changed cardinality from %s to %s", message, p.GetName(), p.GetNumber(), p.GetLabel(), f.GetLabel())
+		}
+	}
+}
+
+func (c *checker) checkEnums(prefix string, prev, cur []*descriptorpb.EnumDescriptorProto) {
+	byName := make(map[string]*descriptorpb.EnumDescriptorProto, len(cur))
+	for _, e := range cur {
+		byName[e.GetName()] = e
+	}
+
+	for _, p := range prev {
+		name := qualify(prefix, p.GetName())
+		e, ok := byName[p.GetName()]
+		if !ok {
+			c.report("enum %s deleted", name)
+			continue
+		}
+
+		values := make(map[int32]*descriptorpb.EnumValueDescriptorProto, len(e.GetValue()))
+		for _, v := range e.GetValue() {
+			values[v.GetNumber()] = v
+		}
+		for _, pv := range p.GetValue() {
+			v, ok := values[pv.GetNumber()]
+			if !ok {
+				if !enumReserved(e, pv.GetNumber()) {
+					c.report("enum value %s.%s (%d) deleted without reserving its number", name, pv.GetName(), pv.GetNumber())
+				}
+				continue
+			}
+			if pv.GetName() != v.GetName() {
+				c.report("enum value %s (%d) renamed from %q to %q", name, pv.GetNumber(), pv.GetName(), v.GetName())
+			}
+		}
+	}
+}
+
+func (c *checker) checkServices(prefix string, prev, cur []*descriptorpb.ServiceDescriptorProto) {
+	byName := make(map[string]*descriptorpb.ServiceDescriptorProto, len(cur))
+	for _, s := range cur {
+		byName[s.GetName()] = s
+	}
+
+	for _, p := range prev {
+		name := qualify(prefix, p.GetName())
+		s, ok := byName[p.GetName()]
+		if !ok {
+			c.report("service %s deleted", name)
+			continue
+		}
+
+		methods := make(map[string]*descriptorpb.MethodDescriptorProto, len(s.GetMethod()))
+		for _, m := range s.GetMethod() {
+			methods[m.GetName()] = m
+		}
+		for _, pm := range p.GetMethod() {
+			m, ok := methods[pm.GetName()]
+			if !ok {
+				c.report("method %s.%s deleted", name, pm.GetName())
+				continue
+			}
+			if pm.GetInputType() != m.GetInputType() {
+				c.report("method %s.%s changed request type from %s to %s", name, pm.GetName(), pm.GetInputType(), m.GetInputType())
+			}
+			if pm.GetOutputType() != m.GetOutputType() {
+				c.report("method %s.%s changed response type from %s to %s", name, pm.GetName(), pm.GetOutputType(), m.GetOutputType())
+			}
+			if pm.GetClientStreaming() != m.GetClientStreaming() || pm.GetServerStreaming() != m.GetServerStreaming() {
+				c.report("method %s.%s changed streaming mode", name, pm.GetName())
+			}
+		}
+	}
+}
+
+func messageReserved(m *descriptorpb.DescriptorProto, number int32) bool {
+	for _, r := range m.GetReservedRange() {
+		// Message reserved ranges are end-exclusive
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func enumReserved(e *descriptorpb.EnumDescriptorProto, number int32) bool {
+	for _, r := range e.GetReservedRange() {
+		// Enum reserved ranges are end-inclusive
+		if number >= r.GetStart() && number <= r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldType(f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetTypeName() != "" {
+		return f.GetTypeName()
+	}
+	return f.GetType().String()
+}
+
+func qualify(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
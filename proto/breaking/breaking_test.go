@@ -0,0 +1,156 @@
+package breaking
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/tkaewplik/go-microservices/proto/analytics"
+	"github.com/tkaewplik/go-microservices/proto/auth"
+	"github.com/tkaewplik/go-microservices/proto/pagination"
+	"github.com/tkaewplik/go-microservices/proto/payment"
+)
+
+const baselinePath = "testdata/baseline.binpb"
+
+var update = flag.Bool("update", false, "rewrite the contract baseline from the current descriptors")
+
+// contracts returns the descriptors of every service contract
+func contracts() *descriptorpb.FileDescriptorSet {
+	files := []protoreflect.FileDescriptor{
+		analytics.File_analytics_analytics_proto,
+		auth.File_auth_auth_proto,
+		pagination.File_pagination_pagination_proto,
+		payment.File_payment_payment_proto,
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range files {
+		fdp := protodesc.ToFileDescriptorProto(fd)
+		fdp.SourceCodeInfo = nil
+		set.File = append(set.File, fdp)
+	}
+	return set
+}
+
+// TestContractsCompatibleWithBaseline fails when a contract change would break
+// deployed clients. After an intentional, coordinated break regenerate the
+// baseline with: go test ./breaking -update
+func TestContractsCompatibleWithBaseline(t *testing.T) {
+	current := contracts()
+
+	if *update {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(current)
+		if err != nil {
+			t.Fatalf("failed to marshal baseline: %v", err)
+		}
+		if err := os.WriteFile(baselinePath, data, 0o644); err != nil {
+			t.Fatalf("failed to write baseline: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(baselinePath)
+	if err != nil {
+		t.Fatalf("failed to read baseline: %v", err)
+	}
+	var baseline descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &baseline); err != nil {
+		t.Fatalf("failed to parse baseline: %v", err)
+	}
+
+	for _, v := range Check(&baseline, current) {
+		t.Errorf("breaking change: %s", v)
+	}
+}
+
+func testFile(fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.FileDescriptorSet {
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("test.proto"),
+		Package:     proto.String("test"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Msg"), Field: fields}},
+	}}}
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
+
+func TestCheck_AddingFieldIsCompatible(t *testing.T) {
+	prev := testFile(field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32))
+	cur := testFile(
+		field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+	if v := Check(prev, cur); len(v) != 0 {
+		t.Errorf("expected no violations, got %v", v)
+	}
+}
+
+func TestCheck_FieldChanges(t *testing.T) {
+	prev := testFile(
+		field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+		field("note", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+	cur := testFile(
+		field("user_id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+
+	want := map[string]bool{
+		`field test.Msg (1) renamed from "id" to "user_id"`:                      true,
+		"field test.Msg.amount (2) changed type from TYPE_DOUBLE to TYPE_STRING": true,
+		"field test.Msg.note (3) deleted without reserving its number":           true,
+	}
+	got := Check(prev, cur)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d violations, got %v", len(want), got)
+	}
+	for _, v := range got {
+		if !want[v.Message] {
+			t.Errorf("unexpected violation: %s", v)
+		}
+	}
+}
+
+func TestCheck_ReservedFieldDeletionIsCompatible(t *testing.T) {
+	prev := testFile(
+		field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		field("note", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+	cur := testFile(field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32))
+	cur.File[0].MessageType[0].ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{
+		{Start: proto.Int32(3), End: proto.Int32(4)},
+	}
+	if v := Check(prev, cur); len(v) != 0 {
+		t.Errorf("expected no violations, got %v", v)
+	}
+}
+
+func TestCheck_MethodChanges(t *testing.T) {
+	prev := contracts()
+	cur := contracts()
+	for _, f := range cur.File {
+		if f.GetPackage() != "payment" {
+			continue
+		}
+		svc := f.Service[0]
+		svc.Method[0].OutputType = proto.String(".payment.PayResponse")
+		svc.Method = svc.Method[:len(svc.Method)-1]
+	}
+
+	if v := Check(prev, cur); len(v) != 2 {
+		t.Errorf("expected 2 violations, got %v", v)
+	}
+}
@@ -1,14 +1,18 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.36.11
-// 	protoc        v6.33.2
-// source: proto/payment/payment.proto
+// 	protoc        (unknown)
+// source: payment/payment.proto
 
 package payment
 
 import (
+	_ "github.com/envoyproxy/protoc-gen-validate/validate"
+	pagination "github.com/tkaewplik/go-microservices/proto/pagination"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -22,18 +26,124 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SortBy int32
+
+const (
+	SortBy_SORT_BY_UNSPECIFIED SortBy = 0
+	SortBy_SORT_BY_CREATED_AT  SortBy = 1
+	SortBy_SORT_BY_AMOUNT      SortBy = 2
+)
+
+// Enum value maps for SortBy.
+var (
+	SortBy_name = map[int32]string{
+		0: "SORT_BY_UNSPECIFIED",
+		1: "SORT_BY_CREATED_AT",
+		2: "SORT_BY_AMOUNT",
+	}
+	SortBy_value = map[string]int32{
+		"SORT_BY_UNSPECIFIED": 0,
+		"SORT_BY_CREATED_AT":  1,
+		"SORT_BY_AMOUNT":      2,
+	}
+)
+
+func (x SortBy) Enum() *SortBy {
+	p := new(SortBy)
+	*p = x
+	return p
+}
+
+func (x SortBy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortBy) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_payment_proto_enumTypes[0].Descriptor()
+}
+
+func (SortBy) Type() protoreflect.EnumType {
+	return &file_payment_payment_proto_enumTypes[0]
+}
+
+func (x SortBy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortBy.Descriptor instead.
+func (SortBy) EnumDescriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{0}
+}
+
+type SortOrder int32
+
+const (
+	SortOrder_SORT_ORDER_UNSPECIFIED SortOrder = 0
+	SortOrder_SORT_ORDER_ASC         SortOrder = 1
+	SortOrder_SORT_ORDER_DESC        SortOrder = 2
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_UNSPECIFIED",
+		1: "SORT_ORDER_ASC",
+		2: "SORT_ORDER_DESC",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_UNSPECIFIED": 0,
+		"SORT_ORDER_ASC":         1,
+		"SORT_ORDER_DESC":        2,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_payment_proto_enumTypes[1].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_payment_payment_proto_enumTypes[1]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{1}
+}
+
 type CreateTransactionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UserId      int64                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount      float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// IANA timezone for limit period boundaries, for callers without an
+	// authenticated identity. With one, periods follow the identity's
+	// preference, and a different timezone here is rejected.
+	Timezone string `protobuf:"bytes,4,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// Client identifier that makes importing the transaction idempotent: a
+	// second transaction with the same reference is rejected with
+	// ALREADY_EXISTS. Empty for ordinary creates.
+	ReferenceId   string `protobuf:"bytes,6,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateTransactionRequest) Reset() {
 	*x = CreateTransactionRequest{}
-	mi := &file_proto_payment_payment_proto_msgTypes[0]
+	mi := &file_payment_payment_proto_msgTypes[0]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -45,7 +155,7 @@ func (x *CreateTransactionRequest) String() string {
 func (*CreateTransactionRequest) ProtoMessage() {}
 
 func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[0]
+	mi := &file_payment_payment_proto_msgTypes[0]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -58,10 +168,10 @@ func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTransactionRequest.ProtoReflect.Descriptor instead.
 func (*CreateTransactionRequest) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{0}
+	return file_payment_payment_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *CreateTransactionRequest) GetUserId() int32 {
+func (x *CreateTransactionRequest) GetUserId() int64 {
 	if x != nil {
 		return x.UserId
 	}
@@ -82,16 +192,41 @@ func (x *CreateTransactionRequest) GetDescription() string {
 	return ""
 }
 
+func (x *CreateTransactionRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
 type GetTransactionsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,7,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Transaction fields to return (e.g. "id", "amount", "is_paid").
+	// Unset returns every field.
+	FieldMask *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=field_mask,json=fieldMask,proto3" json:"field_mask,omitempty"`
+	// Defaults to SORT_BY_CREATED_AT
+	SortBy SortBy `protobuf:"varint,3,opt,name=sort_by,json=sortBy,proto3,enum=payment.SortBy" json:"sort_by,omitempty"`
+	// Defaults to SORT_ORDER_DESC
+	Order SortOrder `protobuf:"varint,4,opt,name=order,proto3,enum=payment.SortOrder" json:"order,omitempty"`
+	// Unset or a zero limit returns every transaction
+	Page *pagination.PageRequest `protobuf:"bytes,5,opt,name=page,proto3" json:"page,omitempty"`
+	// Also list paid transactions moved to the archive
+	IncludeArchived bool `protobuf:"varint,6,opt,name=include_archived,json=includeArchived,proto3" json:"include_archived,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *GetTransactionsRequest) Reset() {
 	*x = GetTransactionsRequest{}
-	mi := &file_proto_payment_payment_proto_msgTypes[1]
+	mi := &file_payment_payment_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -103,7 +238,7 @@ func (x *GetTransactionsRequest) String() string {
 func (*GetTransactionsRequest) ProtoMessage() {}
 
 func (x *GetTransactionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[1]
+	mi := &file_payment_payment_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -116,26 +251,61 @@ func (x *GetTransactionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetTransactionsRequest.ProtoReflect.Descriptor instead.
 func (*GetTransactionsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{1}
+	return file_payment_payment_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *GetTransactionsRequest) GetUserId() int32 {
+func (x *GetTransactionsRequest) GetUserId() int64 {
 	if x != nil {
 		return x.UserId
 	}
 	return 0
 }
 
+func (x *GetTransactionsRequest) GetFieldMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.FieldMask
+	}
+	return nil
+}
+
+func (x *GetTransactionsRequest) GetSortBy() SortBy {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortBy_SORT_BY_UNSPECIFIED
+}
+
+func (x *GetTransactionsRequest) GetOrder() SortOrder {
+	if x != nil {
+		return x.Order
+	}
+	return SortOrder_SORT_ORDER_UNSPECIFIED
+}
+
+func (x *GetTransactionsRequest) GetPage() *pagination.PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+func (x *GetTransactionsRequest) GetIncludeArchived() bool {
+	if x != nil {
+		return x.IncludeArchived
+	}
+	return false
+}
+
 type PayRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PayRequest) Reset() {
 	*x = PayRequest{}
-	mi := &file_proto_payment_payment_proto_msgTypes[2]
+	mi := &file_payment_payment_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -147,7 +317,7 @@ func (x *PayRequest) String() string {
 func (*PayRequest) ProtoMessage() {}
 
 func (x *PayRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[2]
+	mi := &file_payment_payment_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -160,10 +330,10 @@ func (x *PayRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PayRequest.ProtoReflect.Descriptor instead.
 func (*PayRequest) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{2}
+	return file_payment_payment_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *PayRequest) GetUserId() int32 {
+func (x *PayRequest) GetUserId() int64 {
 	if x != nil {
 		return x.UserId
 	}
@@ -171,20 +341,23 @@ func (x *PayRequest) GetUserId() int32 {
 }
 
 type Transaction struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
-	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	IsPaid        bool                   `protobuf:"varint,5,opt,name=is_paid,json=isPaid,proto3" json:"is_paid,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,8,opt,name=id,proto3" json:"id,omitempty"`
+	UserId      int64                  `protobuf:"varint,9,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount      float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	IsPaid      bool                   `protobuf:"varint,5,opt,name=is_paid,json=isPaid,proto3" json:"is_paid,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// External identifier, safe to show where id would let clients guess
+	// other transactions; empty for transactions created before ULIDs
+	Ulid          string `protobuf:"bytes,7,opt,name=ulid,proto3" json:"ulid,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Transaction) Reset() {
 	*x = Transaction{}
-	mi := &file_proto_payment_payment_proto_msgTypes[3]
+	mi := &file_payment_payment_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -196,7 +369,7 @@ func (x *Transaction) String() string {
 func (*Transaction) ProtoMessage() {}
 
 func (x *Transaction) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[3]
+	mi := &file_payment_payment_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -209,17 +382,17 @@ func (x *Transaction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
 func (*Transaction) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{3}
+	return file_payment_payment_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *Transaction) GetId() int32 {
+func (x *Transaction) GetId() int64 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *Transaction) GetUserId() int32 {
+func (x *Transaction) GetUserId() int64 {
 	if x != nil {
 		return x.UserId
 	}
@@ -254,16 +427,24 @@ func (x *Transaction) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Transaction) GetUlid() string {
+	if x != nil {
+		return x.Ulid
+	}
+	return ""
+}
+
 type TransactionList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Page          *pagination.PageInfo   `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TransactionList) Reset() {
 	*x = TransactionList{}
-	mi := &file_proto_payment_payment_proto_msgTypes[4]
+	mi := &file_payment_payment_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -275,7 +456,7 @@ func (x *TransactionList) String() string {
 func (*TransactionList) ProtoMessage() {}
 
 func (x *TransactionList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[4]
+	mi := &file_payment_payment_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -288,7 +469,7 @@ func (x *TransactionList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TransactionList.ProtoReflect.Descriptor instead.
 func (*TransactionList) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{4}
+	return file_payment_payment_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *TransactionList) GetTransactions() []*Transaction {
@@ -298,6 +479,13 @@ func (x *TransactionList) GetTransactions() []*Transaction {
 	return nil
 }
 
+func (x *TransactionList) GetPage() *pagination.PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
 type PayResponse struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Message          string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -308,7 +496,7 @@ type PayResponse struct {
 
 func (x *PayResponse) Reset() {
 	*x = PayResponse{}
-	mi := &file_proto_payment_payment_proto_msgTypes[5]
+	mi := &file_payment_payment_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -320,7 +508,7 @@ func (x *PayResponse) String() string {
 func (*PayResponse) ProtoMessage() {}
 
 func (x *PayResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_payment_payment_proto_msgTypes[5]
+	mi := &file_payment_payment_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -333,7 +521,7 @@ func (x *PayResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PayResponse.ProtoReflect.Descriptor instead.
 func (*PayResponse) Descriptor() ([]byte, []int) {
-	return file_proto_payment_payment_proto_rawDescGZIP(), []int{5}
+	return file_payment_payment_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *PayResponse) GetMessage() string {
@@ -350,96 +538,814 @@ func (x *PayResponse) GetTransactionsPaid() int64 {
 	return 0
 }
 
-var File_proto_payment_payment_proto protoreflect.FileDescriptor
+type GetSummaryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// IANA timezone for limit period boundaries, for callers without an
+	// authenticated identity. With one, periods follow the identity's
+	// preference, and a different timezone here is rejected.
+	Timezone      string `protobuf:"bytes,2,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSummaryRequest) Reset() {
+	*x = GetSummaryRequest{}
+	mi := &file_payment_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSummaryRequest) ProtoMessage() {}
+
+func (x *GetSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetSummaryRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *GetSummaryRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+type Summary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UnpaidTotal      float64                `protobuf:"fixed64,1,opt,name=unpaid_total,json=unpaidTotal,proto3" json:"unpaid_total,omitempty"`
+	PaidTotal        float64                `protobuf:"fixed64,2,opt,name=paid_total,json=paidTotal,proto3" json:"paid_total,omitempty"`
+	UnpaidCount      int64                  `protobuf:"varint,3,opt,name=unpaid_count,json=unpaidCount,proto3" json:"unpaid_count,omitempty"`
+	PaidCount        int64                  `protobuf:"varint,4,opt,name=paid_count,json=paidCount,proto3" json:"paid_count,omitempty"`
+	TransactionCount int64                  `protobuf:"varint,5,opt,name=transaction_count,json=transactionCount,proto3" json:"transaction_count,omitempty"`
+	RemainingLimit   float64                `protobuf:"fixed64,6,opt,name=remaining_limit,json=remainingLimit,proto3" json:"remaining_limit,omitempty"`
+	// Total of transactions in the current limit period
+	PeriodTotal float64 `protobuf:"fixed64,7,opt,name=period_total,json=periodTotal,proto3" json:"period_total,omitempty"`
+	// The spending limit per period
+	MaxAllowed float64 `protobuf:"fixed64,8,opt,name=max_allowed,json=maxAllowed,proto3" json:"max_allowed,omitempty"`
+	// When the current limit period ends
+	ResetsAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=resets_at,json=resetsAt,proto3" json:"resets_at,omitempty"`
+	// Warning thresholds, as fractions of max_allowed, that period_total has
+	// reached. Crossing one publishes a limit.approaching event.
+	LimitWarnings []float64 `protobuf:"fixed64,10,rep,packed,name=limit_warnings,json=limitWarnings,proto3" json:"limit_warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_payment_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Summary) GetUnpaidTotal() float64 {
+	if x != nil {
+		return x.UnpaidTotal
+	}
+	return 0
+}
+
+func (x *Summary) GetPaidTotal() float64 {
+	if x != nil {
+		return x.PaidTotal
+	}
+	return 0
+}
+
+func (x *Summary) GetUnpaidCount() int64 {
+	if x != nil {
+		return x.UnpaidCount
+	}
+	return 0
+}
+
+func (x *Summary) GetPaidCount() int64 {
+	if x != nil {
+		return x.PaidCount
+	}
+	return 0
+}
+
+func (x *Summary) GetTransactionCount() int64 {
+	if x != nil {
+		return x.TransactionCount
+	}
+	return 0
+}
+
+func (x *Summary) GetRemainingLimit() float64 {
+	if x != nil {
+		return x.RemainingLimit
+	}
+	return 0
+}
+
+func (x *Summary) GetPeriodTotal() float64 {
+	if x != nil {
+		return x.PeriodTotal
+	}
+	return 0
+}
+
+func (x *Summary) GetMaxAllowed() float64 {
+	if x != nil {
+		return x.MaxAllowed
+	}
+	return 0
+}
+
+func (x *Summary) GetResetsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResetsAt
+	}
+	return nil
+}
+
+func (x *Summary) GetLimitWarnings() []float64 {
+	if x != nil {
+		return x.LimitWarnings
+	}
+	return nil
+}
+
+// Receipt is the metadata of a file stored in blob storage
+type Receipt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Blob storage key; clients download through signed gateway URLs
+	Key         string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ContentType string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size        int64  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Name of the uploaded file, for display
+	Filename      string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	UploadedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=uploaded_at,json=uploadedAt,proto3" json:"uploaded_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	mi := &file_payment_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Receipt) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Receipt) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Receipt) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Receipt) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Receipt) GetUploadedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UploadedAt
+	}
+	return nil
+}
+
+type AttachReceiptRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          int64                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TransactionId   int64                  `protobuf:"varint,6,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Receipt         *Receipt               `protobuf:"bytes,3,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	TransactionUlid string                 `protobuf:"bytes,4,opt,name=transaction_ulid,json=transactionUlid,proto3" json:"transaction_ulid,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AttachReceiptRequest) Reset() {
+	*x = AttachReceiptRequest{}
+	mi := &file_payment_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachReceiptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachReceiptRequest) ProtoMessage() {}
+
+func (x *AttachReceiptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachReceiptRequest.ProtoReflect.Descriptor instead.
+func (*AttachReceiptRequest) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AttachReceiptRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AttachReceiptRequest) GetTransactionId() int64 {
+	if x != nil {
+		return x.TransactionId
+	}
+	return 0
+}
+
+func (x *AttachReceiptRequest) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *AttachReceiptRequest) GetTransactionUlid() string {
+	if x != nil {
+		return x.TransactionUlid
+	}
+	return ""
+}
+
+type AttachReceiptResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Receipt *Receipt               `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	// Key of the receipt this one replaced, for the caller to delete; empty
+	// when the transaction had none
+	ReplacedKey   string `protobuf:"bytes,2,opt,name=replaced_key,json=replacedKey,proto3" json:"replaced_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachReceiptResponse) Reset() {
+	*x = AttachReceiptResponse{}
+	mi := &file_payment_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachReceiptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-const file_proto_payment_payment_proto_rawDesc = "" +
+func (*AttachReceiptResponse) ProtoMessage() {}
+
+func (x *AttachReceiptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachReceiptResponse.ProtoReflect.Descriptor instead.
+func (*AttachReceiptResponse) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AttachReceiptResponse) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *AttachReceiptResponse) GetReplacedKey() string {
+	if x != nil {
+		return x.ReplacedKey
+	}
+	return ""
+}
+
+type GetReceiptRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          int64                  `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TransactionId   int64                  `protobuf:"varint,5,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	TransactionUlid string                 `protobuf:"bytes,3,opt,name=transaction_ulid,json=transactionUlid,proto3" json:"transaction_ulid,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetReceiptRequest) Reset() {
+	*x = GetReceiptRequest{}
+	mi := &file_payment_payment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReceiptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReceiptRequest) ProtoMessage() {}
+
+func (x *GetReceiptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReceiptRequest.ProtoReflect.Descriptor instead.
+func (*GetReceiptRequest) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetReceiptRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *GetReceiptRequest) GetTransactionId() int64 {
+	if x != nil {
+		return x.TransactionId
+	}
+	return 0
+}
+
+func (x *GetReceiptRequest) GetTransactionUlid() string {
+	if x != nil {
+		return x.TransactionUlid
+	}
+	return ""
+}
+
+type StreamAllTransactionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only transactions created before this are streamed; unset means now
+	Before        *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=before,proto3" json:"before,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAllTransactionsRequest) Reset() {
+	*x = StreamAllTransactionsRequest{}
+	mi := &file_payment_payment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAllTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAllTransactionsRequest) ProtoMessage() {}
+
+func (x *StreamAllTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAllTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*StreamAllTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamAllTransactionsRequest) GetBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+type StatementRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Month to cover, YYYY-MM in the service's statement timezone
+	Month         string `protobuf:"bytes,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatementRequest) Reset() {
+	*x = StatementRequest{}
+	mi := &file_payment_payment_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatementRequest) ProtoMessage() {}
+
+func (x *StatementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatementRequest.ProtoReflect.Descriptor instead.
+func (*StatementRequest) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StatementRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *StatementRequest) GetMonth() string {
+	if x != nil {
+		return x.Month
+	}
+	return ""
+}
+
+// Statement is a monthly statement PDF stored in blob storage
+type Statement struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Blob storage key; clients download through signed gateway URLs
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Month covered, YYYY-MM
+	Month         string                 `protobuf:"bytes,2,opt,name=month,proto3" json:"month,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	GeneratedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Statement) Reset() {
+	*x = Statement{}
+	mi := &file_payment_payment_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Statement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Statement) ProtoMessage() {}
+
+func (x *Statement) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_payment_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Statement.ProtoReflect.Descriptor instead.
+func (*Statement) Descriptor() ([]byte, []int) {
+	return file_payment_payment_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Statement) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Statement) GetMonth() string {
+	if x != nil {
+		return x.Month
+	}
+	return ""
+}
+
+func (x *Statement) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Statement) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+var File_payment_payment_proto protoreflect.FileDescriptor
+
+const file_payment_payment_proto_rawDesc = "" +
+	"\n" +
+	"\x15payment/payment.proto\x12\apayment\x1a\x1cgoogle/api/annotations.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17validate/validate.proto\x1a\x1bpagination/pagination.proto\"\xcb\x01\n" +
+	"\x18CreateTransactionRequest\x12 \n" +
+	"\auser_id\x18\x05 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x12&\n" +
+	"\x06amount\x18\x02 \x01(\x01B\x0e\xfaB\v\x12\t!\x00\x00\x00\x00\x00\x00\x00\x00R\x06amount\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1a\n" +
+	"\btimezone\x18\x04 \x01(\tR\btimezone\x12!\n" +
+	"\freference_id\x18\x06 \x01(\tR\vreferenceIdJ\x04\b\x01\x10\x02\"\xbb\x02\n" +
+	"\x16GetTransactionsRequest\x12 \n" +
+	"\auser_id\x18\a \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x129\n" +
 	"\n" +
-	"\x1bproto/payment/payment.proto\x12\apayment\x1a\x1fgoogle/protobuf/timestamp.proto\"m\n" +
-	"\x18CreateTransactionRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x16\n" +
-	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\"1\n" +
-	"\x16GetTransactionsRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x05R\x06userId\"%\n" +
+	"field_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\tfieldMask\x122\n" +
+	"\asort_by\x18\x03 \x01(\x0e2\x0f.payment.SortByB\b\xfaB\x05\x82\x01\x02\x10\x01R\x06sortBy\x122\n" +
+	"\x05order\x18\x04 \x01(\x0e2\x12.payment.SortOrderB\b\xfaB\x05\x82\x01\x02\x10\x01R\x05order\x12+\n" +
+	"\x04page\x18\x05 \x01(\v2\x17.pagination.PageRequestR\x04page\x12)\n" +
+	"\x10include_archived\x18\x06 \x01(\bR\x0fincludeArchivedJ\x04\b\x01\x10\x02\"4\n" +
 	"\n" +
-	"PayRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x05R\x06userId\"\xc4\x01\n" +
+	"PayRequest\x12 \n" +
+	"\auser_id\x18\x02 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userIdJ\x04\b\x01\x10\x02\"\xe4\x01\n" +
 	"\vTransaction\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x16\n" +
+	"\x02id\x18\b \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\t \x01(\x03R\x06userId\x12\x16\n" +
 	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12 \n" +
 	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x17\n" +
 	"\ais_paid\x18\x05 \x01(\bR\x06isPaid\x129\n" +
 	"\n" +
-	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"K\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x12\n" +
+	"\x04ulid\x18\a \x01(\tR\x04ulidJ\x04\b\x01\x10\x02J\x04\b\x02\x10\x03\"u\n" +
 	"\x0fTransactionList\x128\n" +
-	"\ftransactions\x18\x01 \x03(\v2\x14.payment.TransactionR\ftransactions\"T\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x14.payment.TransactionR\ftransactions\x12(\n" +
+	"\x04page\x18\x02 \x01(\v2\x14.pagination.PageInfoR\x04page\"T\n" +
 	"\vPayResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12+\n" +
-	"\x11transactions_paid\x18\x02 \x01(\x03R\x10transactionsPaid2\xed\x01\n" +
-	"\x0ePaymentService\x12L\n" +
-	"\x11CreateTransaction\x12!.payment.CreateTransactionRequest\x1a\x14.payment.Transaction\x12L\n" +
-	"\x0fGetTransactions\x12\x1f.payment.GetTransactionsRequest\x1a\x18.payment.TransactionList\x12?\n" +
-	"\x12PayAllTransactions\x12\x13.payment.PayRequest\x1a\x14.payment.PayResponseB5Z3github.com/tkaewplik/go-microservices/proto/paymentb\x06proto3"
+	"\x11transactions_paid\x18\x02 \x01(\x03R\x10transactionsPaid\"W\n" +
+	"\x11GetSummaryRequest\x12 \n" +
+	"\auser_id\x18\x03 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x12\x1a\n" +
+	"\btimezone\x18\x02 \x01(\tR\btimezoneJ\x04\b\x01\x10\x02\"\x87\x03\n" +
+	"\aSummary\x12!\n" +
+	"\funpaid_total\x18\x01 \x01(\x01R\vunpaidTotal\x12\x1d\n" +
+	"\n" +
+	"paid_total\x18\x02 \x01(\x01R\tpaidTotal\x12!\n" +
+	"\funpaid_count\x18\x03 \x01(\x03R\vunpaidCount\x12\x1d\n" +
+	"\n" +
+	"paid_count\x18\x04 \x01(\x03R\tpaidCount\x12+\n" +
+	"\x11transaction_count\x18\x05 \x01(\x03R\x10transactionCount\x12'\n" +
+	"\x0fremaining_limit\x18\x06 \x01(\x01R\x0eremainingLimit\x12!\n" +
+	"\fperiod_total\x18\a \x01(\x01R\vperiodTotal\x12\x1f\n" +
+	"\vmax_allowed\x18\b \x01(\x01R\n" +
+	"maxAllowed\x127\n" +
+	"\tresets_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bresetsAt\x12%\n" +
+	"\x0elimit_warnings\x18\n" +
+	" \x03(\x01R\rlimitWarnings\"\xc6\x01\n" +
+	"\aReceipt\x12\x19\n" +
+	"\x03key\x18\x01 \x01(\tB\a\xfaB\x04r\x02\x10\x01R\x03key\x12*\n" +
+	"\fcontent_type\x18\x02 \x01(\tB\a\xfaB\x04r\x02\x10\x01R\vcontentType\x12\x1b\n" +
+	"\x04size\x18\x03 \x01(\x03B\a\xfaB\x04\"\x02 \x00R\x04size\x12\x1a\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\x12;\n" +
+	"\vuploaded_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"uploadedAt\"\xe2\x01\n" +
+	"\x14AttachReceiptRequest\x12 \n" +
+	"\auser_id\x18\x05 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x12.\n" +
+	"\x0etransaction_id\x18\x06 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\rtransactionId\x124\n" +
+	"\areceipt\x18\x03 \x01(\v2\x10.payment.ReceiptB\b\xfaB\x05\x8a\x01\x02\x10\x01R\areceipt\x126\n" +
+	"\x10transaction_ulid\x18\x04 \x01(\tB\v\xfaB\br\x06\x98\x01\x1a\xd0\x01\x01R\x0ftransactionUlidJ\x04\b\x01\x10\x02J\x04\b\x02\x10\x03\"f\n" +
+	"\x15AttachReceiptResponse\x12*\n" +
+	"\areceipt\x18\x01 \x01(\v2\x10.payment.ReceiptR\areceipt\x12!\n" +
+	"\freplaced_key\x18\x02 \x01(\tR\vreplacedKey\"\xa9\x01\n" +
+	"\x11GetReceiptRequest\x12 \n" +
+	"\auser_id\x18\x04 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x12.\n" +
+	"\x0etransaction_id\x18\x05 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\rtransactionId\x126\n" +
+	"\x10transaction_ulid\x18\x03 \x01(\tB\v\xfaB\br\x06\x98\x01\x1a\xd0\x01\x01R\x0ftransactionUlidJ\x04\b\x01\x10\x02J\x04\b\x02\x10\x03\"R\n" +
+	"\x1cStreamAllTransactionsRequest\x122\n" +
+	"\x06before\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x06before\"s\n" +
+	"\x10StatementRequest\x12 \n" +
+	"\auser_id\x18\x03 \x01(\x03B\a\xfaB\x04\"\x02(\x00R\x06userId\x127\n" +
+	"\x05month\x18\x02 \x01(\tB!\xfaB\x1er\x1c2\x1a^[0-9]{4}-(0[1-9]|1[0-2])$R\x05monthJ\x04\b\x01\x10\x02\"\x86\x01\n" +
+	"\tStatement\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\tR\x05month\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12=\n" +
+	"\fgenerated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt*M\n" +
+	"\x06SortBy\x12\x17\n" +
+	"\x13SORT_BY_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12SORT_BY_CREATED_AT\x10\x01\x12\x12\n" +
+	"\x0eSORT_BY_AMOUNT\x10\x02*P\n" +
+	"\tSortOrder\x12\x1a\n" +
+	"\x16SORT_ORDER_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSORT_ORDER_ASC\x10\x01\x12\x13\n" +
+	"\x0fSORT_ORDER_DESC\x10\x022\xa9\a\n" +
+	"\x0ePaymentService\x12\x8d\x01\n" +
+	"\x11CreateTransaction\x12!.payment.CreateTransactionRequest\x1a\x14.payment.Transaction\"?\x82\xd3\xe4\x93\x029:\x01*Z\x1a:\x01*\"\x15/payment/transactions\"\x18/v1/payment/transactions\x12\x8c\x01\n" +
+	"\x0fGetTransactions\x12\x1f.payment.GetTransactionsRequest\x1a\x18.payment.TransactionList\">\x82\xd3\xe4\x93\x028Z\x1c\x12\x1a/payment/transactions/list\x12\x18/v1/payment/transactions\x12\x88\x01\n" +
+	"\x12PayAllTransactions\x12\x13.payment.PayRequest\x1a\x14.payment.PayResponse\"G\x82\xd3\xe4\x93\x02A:\x01*Z\x1e:\x01*\"\x19/payment/transactions/pay\"\x1c/v1/payment/transactions/pay\x12\x85\x01\n" +
+	"\n" +
+	"GetSummary\x12\x1a.payment.GetSummaryRequest\x1a\x10.payment.Summary\"I\x82\xd3\xe4\x93\x02CZ\x1f\x12\x1d/payment/transactions/summary\x12 /v1/payment/transactions/summary\x12N\n" +
+	"\rAttachReceipt\x12\x1d.payment.AttachReceiptRequest\x1a\x1e.payment.AttachReceiptResponse\x12:\n" +
+	"\n" +
+	"GetReceipt\x12\x1a.payment.GetReceiptRequest\x1a\x10.payment.Receipt\x12V\n" +
+	"\x15StreamAllTransactions\x12%.payment.StreamAllTransactionsRequest\x1a\x14.payment.Transaction0\x01\x12B\n" +
+	"\x11GenerateStatement\x12\x19.payment.StatementRequest\x1a\x12.payment.Statement\x12=\n" +
+	"\fGetStatement\x12\x19.payment.StatementRequest\x1a\x12.payment.StatementB5Z3github.com/tkaewplik/go-microservices/proto/paymentb\x06proto3"
 
 var (
-	file_proto_payment_payment_proto_rawDescOnce sync.Once
-	file_proto_payment_payment_proto_rawDescData []byte
+	file_payment_payment_proto_rawDescOnce sync.Once
+	file_payment_payment_proto_rawDescData []byte
 )
 
-func file_proto_payment_payment_proto_rawDescGZIP() []byte {
-	file_proto_payment_payment_proto_rawDescOnce.Do(func() {
-		file_proto_payment_payment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_payment_payment_proto_rawDesc), len(file_proto_payment_payment_proto_rawDesc)))
+func file_payment_payment_proto_rawDescGZIP() []byte {
+	file_payment_payment_proto_rawDescOnce.Do(func() {
+		file_payment_payment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_payment_payment_proto_rawDesc), len(file_payment_payment_proto_rawDesc)))
 	})
-	return file_proto_payment_payment_proto_rawDescData
-}
-
-var file_proto_payment_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
-var file_proto_payment_payment_proto_goTypes = []any{
-	(*CreateTransactionRequest)(nil), // 0: payment.CreateTransactionRequest
-	(*GetTransactionsRequest)(nil),   // 1: payment.GetTransactionsRequest
-	(*PayRequest)(nil),               // 2: payment.PayRequest
-	(*Transaction)(nil),              // 3: payment.Transaction
-	(*TransactionList)(nil),          // 4: payment.TransactionList
-	(*PayResponse)(nil),              // 5: payment.PayResponse
-	(*timestamppb.Timestamp)(nil),    // 6: google.protobuf.Timestamp
-}
-var file_proto_payment_payment_proto_depIdxs = []int32{
-	6, // 0: payment.Transaction.created_at:type_name -> google.protobuf.Timestamp
-	3, // 1: payment.TransactionList.transactions:type_name -> payment.Transaction
-	0, // 2: payment.PaymentService.CreateTransaction:input_type -> payment.CreateTransactionRequest
-	1, // 3: payment.PaymentService.GetTransactions:input_type -> payment.GetTransactionsRequest
-	2, // 4: payment.PaymentService.PayAllTransactions:input_type -> payment.PayRequest
-	3, // 5: payment.PaymentService.CreateTransaction:output_type -> payment.Transaction
-	4, // 6: payment.PaymentService.GetTransactions:output_type -> payment.TransactionList
-	5, // 7: payment.PaymentService.PayAllTransactions:output_type -> payment.PayResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
-}
-
-func init() { file_proto_payment_payment_proto_init() }
-func file_proto_payment_payment_proto_init() {
-	if File_proto_payment_payment_proto != nil {
+	return file_payment_payment_proto_rawDescData
+}
+
+var file_payment_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_payment_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_payment_payment_proto_goTypes = []any{
+	(SortBy)(0),                          // 0: payment.SortBy
+	(SortOrder)(0),                       // 1: payment.SortOrder
+	(*CreateTransactionRequest)(nil),     // 2: payment.CreateTransactionRequest
+	(*GetTransactionsRequest)(nil),       // 3: payment.GetTransactionsRequest
+	(*PayRequest)(nil),                   // 4: payment.PayRequest
+	(*Transaction)(nil),                  // 5: payment.Transaction
+	(*TransactionList)(nil),              // 6: payment.TransactionList
+	(*PayResponse)(nil),                  // 7: payment.PayResponse
+	(*GetSummaryRequest)(nil),            // 8: payment.GetSummaryRequest
+	(*Summary)(nil),                      // 9: payment.Summary
+	(*Receipt)(nil),                      // 10: payment.Receipt
+	(*AttachReceiptRequest)(nil),         // 11: payment.AttachReceiptRequest
+	(*AttachReceiptResponse)(nil),        // 12: payment.AttachReceiptResponse
+	(*GetReceiptRequest)(nil),            // 13: payment.GetReceiptRequest
+	(*StreamAllTransactionsRequest)(nil), // 14: payment.StreamAllTransactionsRequest
+	(*StatementRequest)(nil),             // 15: payment.StatementRequest
+	(*Statement)(nil),                    // 16: payment.Statement
+	(*fieldmaskpb.FieldMask)(nil),        // 17: google.protobuf.FieldMask
+	(*pagination.PageRequest)(nil),       // 18: pagination.PageRequest
+	(*timestamppb.Timestamp)(nil),        // 19: google.protobuf.Timestamp
+	(*pagination.PageInfo)(nil),          // 20: pagination.PageInfo
+}
+var file_payment_payment_proto_depIdxs = []int32{
+	17, // 0: payment.GetTransactionsRequest.field_mask:type_name -> google.protobuf.FieldMask
+	0,  // 1: payment.GetTransactionsRequest.sort_by:type_name -> payment.SortBy
+	1,  // 2: payment.GetTransactionsRequest.order:type_name -> payment.SortOrder
+	18, // 3: payment.GetTransactionsRequest.page:type_name -> pagination.PageRequest
+	19, // 4: payment.Transaction.created_at:type_name -> google.protobuf.Timestamp
+	5,  // 5: payment.TransactionList.transactions:type_name -> payment.Transaction
+	20, // 6: payment.TransactionList.page:type_name -> pagination.PageInfo
+	19, // 7: payment.Summary.resets_at:type_name -> google.protobuf.Timestamp
+	19, // 8: payment.Receipt.uploaded_at:type_name -> google.protobuf.Timestamp
+	10, // 9: payment.AttachReceiptRequest.receipt:type_name -> payment.Receipt
+	10, // 10: payment.AttachReceiptResponse.receipt:type_name -> payment.Receipt
+	19, // 11: payment.StreamAllTransactionsRequest.before:type_name -> google.protobuf.Timestamp
+	19, // 12: payment.Statement.generated_at:type_name -> google.protobuf.Timestamp
+	2,  // 13: payment.PaymentService.CreateTransaction:input_type -> payment.CreateTransactionRequest
+	3,  // 14: payment.PaymentService.GetTransactions:input_type -> payment.GetTransactionsRequest
+	4,  // 15: payment.PaymentService.PayAllTransactions:input_type -> payment.PayRequest
+	8,  // 16: payment.PaymentService.GetSummary:input_type -> payment.GetSummaryRequest
+	11, // 17: payment.PaymentService.AttachReceipt:input_type -> payment.AttachReceiptRequest
+	13, // 18: payment.PaymentService.GetReceipt:input_type -> payment.GetReceiptRequest
+	14, // 19: payment.PaymentService.StreamAllTransactions:input_type -> payment.StreamAllTransactionsRequest
+	15, // 20: payment.PaymentService.GenerateStatement:input_type -> payment.StatementRequest
+	15, // 21: payment.PaymentService.GetStatement:input_type -> payment.StatementRequest
+	5,  // 22: payment.PaymentService.CreateTransaction:output_type -> payment.Transaction
+	6,  // 23: payment.PaymentService.GetTransactions:output_type -> payment.TransactionList
+	7,  // 24: payment.PaymentService.PayAllTransactions:output_type -> payment.PayResponse
+	9,  // 25: payment.PaymentService.GetSummary:output_type -> payment.Summary
+	12, // 26: payment.PaymentService.AttachReceipt:output_type -> payment.AttachReceiptResponse
+	10, // 27: payment.PaymentService.GetReceipt:output_type -> payment.Receipt
+	5,  // 28: payment.PaymentService.StreamAllTransactions:output_type -> payment.Transaction
+	16, // 29: payment.PaymentService.GenerateStatement:output_type -> payment.Statement
+	16, // 30: payment.PaymentService.GetStatement:output_type -> payment.Statement
+	22, // [22:31] is the sub-list for method output_type
+	13, // [13:22] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_payment_payment_proto_init() }
+func file_payment_payment_proto_init() {
+	if File_payment_payment_proto != nil {
 		return
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_payment_payment_proto_rawDesc), len(file_proto_payment_payment_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_payment_proto_rawDesc), len(file_payment_payment_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
-		GoTypes:           file_proto_payment_payment_proto_goTypes,
-		DependencyIndexes: file_proto_payment_payment_proto_depIdxs,
-		MessageInfos:      file_proto_payment_payment_proto_msgTypes,
+		GoTypes:           file_payment_payment_proto_goTypes,
+		DependencyIndexes: file_payment_payment_proto_depIdxs,
+		EnumInfos:         file_payment_payment_proto_enumTypes,
+		MessageInfos:      file_payment_payment_proto_msgTypes,
 	}.Build()
-	File_proto_payment_payment_proto = out.File
-	file_proto_payment_payment_proto_goTypes = nil
-	file_proto_payment_payment_proto_depIdxs = nil
+	File_payment_payment_proto = out.File
+	file_payment_payment_proto_goTypes = nil
+	file_payment_payment_proto_depIdxs = nil
 }
@@ -0,0 +1,2140 @@
+// Code generated by protoc-gen-validate. DO NOT EDIT.
+// source: payment/payment.proto
+
+package payment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ensure the imports are used
+var (
+	_ = bytes.MinRead
+	_ = errors.New("")
+	_ = fmt.Print
+	_ = utf8.UTFMax
+	_ = (*regexp.Regexp)(nil)
+	_ = (*strings.Reader)(nil)
+	_ = net.IPv4len
+	_ = time.Duration(0)
+	_ = (*url.URL)(nil)
+	_ = (*mail.Address)(nil)
+	_ = anypb.Any{}
+	_ = sort.Sort
+)
+
+// Validate checks the field values on CreateTransactionRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateTransactionRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateTransactionRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateTransactionRequestMultiError, or nil if none found.
+func (m *CreateTransactionRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateTransactionRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := CreateTransactionRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetAmount() <= 0 {
+		err := CreateTransactionRequestValidationError{
+			field:  "Amount",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Description
+
+	// no validation rules for Timezone
+
+	// no validation rules for ReferenceId
+
+	if len(errors) > 0 {
+		return CreateTransactionRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateTransactionRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateTransactionRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateTransactionRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateTransactionRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateTransactionRequestMultiError) AllErrors() []error { return m }
+
+// CreateTransactionRequestValidationError is the validation error returned by
+// CreateTransactionRequest.Validate if the designated constraints aren't met.
+type CreateTransactionRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateTransactionRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateTransactionRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateTransactionRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateTransactionRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateTransactionRequestValidationError) ErrorName() string {
+	return "CreateTransactionRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateTransactionRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateTransactionRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateTransactionRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateTransactionRequestValidationError{}
+
+// Validate checks the field values on GetTransactionsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetTransactionsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetTransactionsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetTransactionsRequestMultiError, or nil if none found.
+func (m *GetTransactionsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetTransactionsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := GetTransactionsRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetFieldMask()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetTransactionsRequestValidationError{
+					field:  "FieldMask",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetTransactionsRequestValidationError{
+					field:  "FieldMask",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetFieldMask()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetTransactionsRequestValidationError{
+				field:  "FieldMask",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if _, ok := SortBy_name[int32(m.GetSortBy())]; !ok {
+		err := GetTransactionsRequestValidationError{
+			field:  "SortBy",
+			reason: "value must be one of the defined enum values",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := SortOrder_name[int32(m.GetOrder())]; !ok {
+		err := GetTransactionsRequestValidationError{
+			field:  "Order",
+			reason: "value must be one of the defined enum values",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetPage()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetTransactionsRequestValidationError{
+					field:  "Page",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetTransactionsRequestValidationError{
+					field:  "Page",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetPage()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetTransactionsRequestValidationError{
+				field:  "Page",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for IncludeArchived
+
+	if len(errors) > 0 {
+		return GetTransactionsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetTransactionsRequestMultiError is an error wrapping multiple validation
+// errors returned by GetTransactionsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetTransactionsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetTransactionsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetTransactionsRequestMultiError) AllErrors() []error { return m }
+
+// GetTransactionsRequestValidationError is the validation error returned by
+// GetTransactionsRequest.Validate if the designated constraints aren't met.
+type GetTransactionsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetTransactionsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetTransactionsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetTransactionsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetTransactionsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetTransactionsRequestValidationError) ErrorName() string {
+	return "GetTransactionsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetTransactionsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetTransactionsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetTransactionsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetTransactionsRequestValidationError{}
+
+// Validate checks the field values on PayRequest with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *PayRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on PayRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in PayRequestMultiError, or
+// nil if none found.
+func (m *PayRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *PayRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := PayRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return PayRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// PayRequestMultiError is an error wrapping multiple validation errors
+// returned by PayRequest.ValidateAll() if the designated constraints aren't met.
+type PayRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PayRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PayRequestMultiError) AllErrors() []error { return m }
+
+// PayRequestValidationError is the validation error returned by
+// PayRequest.Validate if the designated constraints aren't met.
+type PayRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PayRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PayRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PayRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PayRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PayRequestValidationError) ErrorName() string { return "PayRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PayRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPayRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PayRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PayRequestValidationError{}
+
+// Validate checks the field values on Transaction with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Transaction) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Transaction with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in TransactionMultiError, or
+// nil if none found.
+func (m *Transaction) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Transaction) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for UserId
+
+	// no validation rules for Amount
+
+	// no validation rules for Description
+
+	// no validation rules for IsPaid
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, TransactionValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, TransactionValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return TransactionValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Ulid
+
+	if len(errors) > 0 {
+		return TransactionMultiError(errors)
+	}
+
+	return nil
+}
+
+// TransactionMultiError is an error wrapping multiple validation errors
+// returned by Transaction.ValidateAll() if the designated constraints aren't met.
+type TransactionMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m TransactionMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m TransactionMultiError) AllErrors() []error { return m }
+
+// TransactionValidationError is the validation error returned by
+// Transaction.Validate if the designated constraints aren't met.
+type TransactionValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e TransactionValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e TransactionValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e TransactionValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e TransactionValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e TransactionValidationError) ErrorName() string { return "TransactionValidationError" }
+
+// Error satisfies the builtin error interface
+func (e TransactionValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sTransaction.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = TransactionValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = TransactionValidationError{}
+
+// Validate checks the field values on TransactionList with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *TransactionList) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on TransactionList with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// TransactionListMultiError, or nil if none found.
+func (m *TransactionList) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *TransactionList) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetTransactions() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, TransactionListValidationError{
+						field:  fmt.Sprintf("Transactions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, TransactionListValidationError{
+						field:  fmt.Sprintf("Transactions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return TransactionListValidationError{
+					field:  fmt.Sprintf("Transactions[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if all {
+		switch v := interface{}(m.GetPage()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, TransactionListValidationError{
+					field:  "Page",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, TransactionListValidationError{
+					field:  "Page",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetPage()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return TransactionListValidationError{
+				field:  "Page",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return TransactionListMultiError(errors)
+	}
+
+	return nil
+}
+
+// TransactionListMultiError is an error wrapping multiple validation errors
+// returned by TransactionList.ValidateAll() if the designated constraints
+// aren't met.
+type TransactionListMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m TransactionListMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m TransactionListMultiError) AllErrors() []error { return m }
+
+// TransactionListValidationError is the validation error returned by
+// TransactionList.Validate if the designated constraints aren't met.
+type TransactionListValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e TransactionListValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e TransactionListValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e TransactionListValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e TransactionListValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e TransactionListValidationError) ErrorName() string { return "TransactionListValidationError" }
+
+// Error satisfies the builtin error interface
+func (e TransactionListValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sTransactionList.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = TransactionListValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = TransactionListValidationError{}
+
+// Validate checks the field values on PayResponse with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *PayResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on PayResponse with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in PayResponseMultiError, or
+// nil if none found.
+func (m *PayResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *PayResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Message
+
+	// no validation rules for TransactionsPaid
+
+	if len(errors) > 0 {
+		return PayResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// PayResponseMultiError is an error wrapping multiple validation errors
+// returned by PayResponse.ValidateAll() if the designated constraints aren't met.
+type PayResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PayResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PayResponseMultiError) AllErrors() []error { return m }
+
+// PayResponseValidationError is the validation error returned by
+// PayResponse.Validate if the designated constraints aren't met.
+type PayResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PayResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PayResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PayResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PayResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PayResponseValidationError) ErrorName() string { return "PayResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PayResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPayResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PayResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PayResponseValidationError{}
+
+// Validate checks the field values on GetSummaryRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetSummaryRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetSummaryRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetSummaryRequestMultiError, or nil if none found.
+func (m *GetSummaryRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetSummaryRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := GetSummaryRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Timezone
+
+	if len(errors) > 0 {
+		return GetSummaryRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetSummaryRequestMultiError is an error wrapping multiple validation errors
+// returned by GetSummaryRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetSummaryRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetSummaryRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetSummaryRequestMultiError) AllErrors() []error { return m }
+
+// GetSummaryRequestValidationError is the validation error returned by
+// GetSummaryRequest.Validate if the designated constraints aren't met.
+type GetSummaryRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetSummaryRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetSummaryRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetSummaryRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetSummaryRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetSummaryRequestValidationError) ErrorName() string {
+	return "GetSummaryRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetSummaryRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetSummaryRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetSummaryRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetSummaryRequestValidationError{}
+
+// Validate checks the field values on Summary with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Summary) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Summary with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in SummaryMultiError, or nil if none found.
+func (m *Summary) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Summary) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for UnpaidTotal
+
+	// no validation rules for PaidTotal
+
+	// no validation rules for UnpaidCount
+
+	// no validation rules for PaidCount
+
+	// no validation rules for TransactionCount
+
+	// no validation rules for RemainingLimit
+
+	// no validation rules for PeriodTotal
+
+	// no validation rules for MaxAllowed
+
+	if all {
+		switch v := interface{}(m.GetResetsAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, SummaryValidationError{
+					field:  "ResetsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, SummaryValidationError{
+					field:  "ResetsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetResetsAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return SummaryValidationError{
+				field:  "ResetsAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return SummaryMultiError(errors)
+	}
+
+	return nil
+}
+
+// SummaryMultiError is an error wrapping multiple validation errors returned
+// by Summary.ValidateAll() if the designated constraints aren't met.
+type SummaryMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SummaryMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SummaryMultiError) AllErrors() []error { return m }
+
+// SummaryValidationError is the validation error returned by Summary.Validate
+// if the designated constraints aren't met.
+type SummaryValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SummaryValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SummaryValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SummaryValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SummaryValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SummaryValidationError) ErrorName() string { return "SummaryValidationError" }
+
+// Error satisfies the builtin error interface
+func (e SummaryValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSummary.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SummaryValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SummaryValidationError{}
+
+// Validate checks the field values on Receipt with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Receipt) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Receipt with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in ReceiptMultiError, or nil if none found.
+func (m *Receipt) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Receipt) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetKey()) < 1 {
+		err := ReceiptValidationError{
+			field:  "Key",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetContentType()) < 1 {
+		err := ReceiptValidationError{
+			field:  "ContentType",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetSize() <= 0 {
+		err := ReceiptValidationError{
+			field:  "Size",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Filename
+
+	if all {
+		switch v := interface{}(m.GetUploadedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, ReceiptValidationError{
+					field:  "UploadedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, ReceiptValidationError{
+					field:  "UploadedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUploadedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return ReceiptValidationError{
+				field:  "UploadedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return ReceiptMultiError(errors)
+	}
+
+	return nil
+}
+
+// ReceiptMultiError is an error wrapping multiple validation errors returned
+// by Receipt.ValidateAll() if the designated constraints aren't met.
+type ReceiptMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ReceiptMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ReceiptMultiError) AllErrors() []error { return m }
+
+// ReceiptValidationError is the validation error returned by Receipt.Validate
+// if the designated constraints aren't met.
+type ReceiptValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ReceiptValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ReceiptValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ReceiptValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ReceiptValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ReceiptValidationError) ErrorName() string { return "ReceiptValidationError" }
+
+// Error satisfies the builtin error interface
+func (e ReceiptValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sReceipt.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ReceiptValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ReceiptValidationError{}
+
+// Validate checks the field values on AttachReceiptRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *AttachReceiptRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AttachReceiptRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// AttachReceiptRequestMultiError, or nil if none found.
+func (m *AttachReceiptRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AttachReceiptRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := AttachReceiptRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetTransactionId() < 0 {
+		err := AttachReceiptRequestValidationError{
+			field:  "TransactionId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetReceipt() == nil {
+		err := AttachReceiptRequestValidationError{
+			field:  "Receipt",
+			reason: "value is required",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetReceipt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, AttachReceiptRequestValidationError{
+					field:  "Receipt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, AttachReceiptRequestValidationError{
+					field:  "Receipt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetReceipt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return AttachReceiptRequestValidationError{
+				field:  "Receipt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if m.GetTransactionUlid() != "" {
+
+		if utf8.RuneCountInString(m.GetTransactionUlid()) != 26 {
+			err := AttachReceiptRequestValidationError{
+				field:  "TransactionUlid",
+				reason: "value length must be 26 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return AttachReceiptRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// AttachReceiptRequestMultiError is an error wrapping multiple validation
+// errors returned by AttachReceiptRequest.ValidateAll() if the designated
+// constraints aren't met.
+type AttachReceiptRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AttachReceiptRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AttachReceiptRequestMultiError) AllErrors() []error { return m }
+
+// AttachReceiptRequestValidationError is the validation error returned by
+// AttachReceiptRequest.Validate if the designated constraints aren't met.
+type AttachReceiptRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AttachReceiptRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AttachReceiptRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AttachReceiptRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AttachReceiptRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AttachReceiptRequestValidationError) ErrorName() string {
+	return "AttachReceiptRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e AttachReceiptRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAttachReceiptRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AttachReceiptRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AttachReceiptRequestValidationError{}
+
+// Validate checks the field values on AttachReceiptResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *AttachReceiptResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AttachReceiptResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// AttachReceiptResponseMultiError, or nil if none found.
+func (m *AttachReceiptResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AttachReceiptResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetReceipt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, AttachReceiptResponseValidationError{
+					field:  "Receipt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, AttachReceiptResponseValidationError{
+					field:  "Receipt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetReceipt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return AttachReceiptResponseValidationError{
+				field:  "Receipt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for ReplacedKey
+
+	if len(errors) > 0 {
+		return AttachReceiptResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// AttachReceiptResponseMultiError is an error wrapping multiple validation
+// errors returned by AttachReceiptResponse.ValidateAll() if the designated
+// constraints aren't met.
+type AttachReceiptResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AttachReceiptResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AttachReceiptResponseMultiError) AllErrors() []error { return m }
+
+// AttachReceiptResponseValidationError is the validation error returned by
+// AttachReceiptResponse.Validate if the designated constraints aren't met.
+type AttachReceiptResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AttachReceiptResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AttachReceiptResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AttachReceiptResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AttachReceiptResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AttachReceiptResponseValidationError) ErrorName() string {
+	return "AttachReceiptResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e AttachReceiptResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAttachReceiptResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AttachReceiptResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AttachReceiptResponseValidationError{}
+
+// Validate checks the field values on GetReceiptRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetReceiptRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetReceiptRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetReceiptRequestMultiError, or nil if none found.
+func (m *GetReceiptRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetReceiptRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := GetReceiptRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetTransactionId() < 0 {
+		err := GetReceiptRequestValidationError{
+			field:  "TransactionId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetTransactionUlid() != "" {
+
+		if utf8.RuneCountInString(m.GetTransactionUlid()) != 26 {
+			err := GetReceiptRequestValidationError{
+				field:  "TransactionUlid",
+				reason: "value length must be 26 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return GetReceiptRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetReceiptRequestMultiError is an error wrapping multiple validation errors
+// returned by GetReceiptRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetReceiptRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetReceiptRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetReceiptRequestMultiError) AllErrors() []error { return m }
+
+// GetReceiptRequestValidationError is the validation error returned by
+// GetReceiptRequest.Validate if the designated constraints aren't met.
+type GetReceiptRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetReceiptRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetReceiptRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetReceiptRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetReceiptRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetReceiptRequestValidationError) ErrorName() string {
+	return "GetReceiptRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetReceiptRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetReceiptRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetReceiptRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetReceiptRequestValidationError{}
+
+// Validate checks the field values on StreamAllTransactionsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *StreamAllTransactionsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StreamAllTransactionsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StreamAllTransactionsRequestMultiError, or nil if none found.
+func (m *StreamAllTransactionsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StreamAllTransactionsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetBefore()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StreamAllTransactionsRequestValidationError{
+					field:  "Before",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StreamAllTransactionsRequestValidationError{
+					field:  "Before",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetBefore()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StreamAllTransactionsRequestValidationError{
+				field:  "Before",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return StreamAllTransactionsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// StreamAllTransactionsRequestMultiError is an error wrapping multiple
+// validation errors returned by StreamAllTransactionsRequest.ValidateAll() if
+// the designated constraints aren't met.
+type StreamAllTransactionsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StreamAllTransactionsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StreamAllTransactionsRequestMultiError) AllErrors() []error { return m }
+
+// StreamAllTransactionsRequestValidationError is the validation error returned
+// by StreamAllTransactionsRequest.Validate if the designated constraints
+// aren't met.
+type StreamAllTransactionsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StreamAllTransactionsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StreamAllTransactionsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StreamAllTransactionsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StreamAllTransactionsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StreamAllTransactionsRequestValidationError) ErrorName() string {
+	return "StreamAllTransactionsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e StreamAllTransactionsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStreamAllTransactionsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StreamAllTransactionsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StreamAllTransactionsRequestValidationError{}
+
+// Validate checks the field values on StatementRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *StatementRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StatementRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StatementRequestMultiError, or nil if none found.
+func (m *StatementRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StatementRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetUserId() < 0 {
+		err := StatementRequestValidationError{
+			field:  "UserId",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if !_StatementRequest_Month_Pattern.MatchString(m.GetMonth()) {
+		err := StatementRequestValidationError{
+			field:  "Month",
+			reason: "value does not match regex pattern \"^[0-9]{4}-(0[1-9]|1[0-2])$\"",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return StatementRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// StatementRequestMultiError is an error wrapping multiple validation errors
+// returned by StatementRequest.ValidateAll() if the designated constraints
+// aren't met.
+type StatementRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StatementRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StatementRequestMultiError) AllErrors() []error { return m }
+
+// StatementRequestValidationError is the validation error returned by
+// StatementRequest.Validate if the designated constraints aren't met.
+type StatementRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StatementRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StatementRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StatementRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StatementRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StatementRequestValidationError) ErrorName() string { return "StatementRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StatementRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStatementRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StatementRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StatementRequestValidationError{}
+
+var _StatementRequest_Month_Pattern = regexp.MustCompile("^[0-9]{4}-(0[1-9]|1[0-2])$")
+
+// Validate checks the field values on Statement with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Statement) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Statement with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in StatementMultiError, or nil
+// if none found.
+func (m *Statement) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Statement) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Key
+
+	// no validation rules for Month
+
+	// no validation rules for Size
+
+	if all {
+		switch v := interface{}(m.GetGeneratedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StatementValidationError{
+					field:  "GeneratedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StatementValidationError{
+					field:  "GeneratedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetGeneratedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StatementValidationError{
+				field:  "GeneratedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return StatementMultiError(errors)
+	}
+
+	return nil
+}
+
+// StatementMultiError is an error wrapping multiple validation errors returned
+// by Statement.ValidateAll() if the designated constraints aren't met.
+type StatementMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StatementMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StatementMultiError) AllErrors() []error { return m }
+
+// StatementValidationError is the validation error returned by
+// Statement.Validate if the designated constraints aren't met.
+type StatementValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StatementValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StatementValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StatementValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StatementValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StatementValidationError) ErrorName() string { return "StatementValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StatementValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStatement.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StatementValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StatementValidationError{}
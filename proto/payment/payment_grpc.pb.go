@@ -1,8 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.6.0
-// - protoc             v6.33.2
-// source: proto/payment/payment.proto
+// - protoc             (unknown)
+// source: payment/payment.proto
 
 package payment
 
@@ -19,23 +19,61 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PaymentService_CreateTransaction_FullMethodName  = "/payment.PaymentService/CreateTransaction"
-	PaymentService_GetTransactions_FullMethodName    = "/payment.PaymentService/GetTransactions"
-	PaymentService_PayAllTransactions_FullMethodName = "/payment.PaymentService/PayAllTransactions"
+	PaymentService_CreateTransaction_FullMethodName     = "/payment.PaymentService/CreateTransaction"
+	PaymentService_GetTransactions_FullMethodName       = "/payment.PaymentService/GetTransactions"
+	PaymentService_PayAllTransactions_FullMethodName    = "/payment.PaymentService/PayAllTransactions"
+	PaymentService_GetSummary_FullMethodName            = "/payment.PaymentService/GetSummary"
+	PaymentService_AttachReceipt_FullMethodName         = "/payment.PaymentService/AttachReceipt"
+	PaymentService_GetReceipt_FullMethodName            = "/payment.PaymentService/GetReceipt"
+	PaymentService_StreamAllTransactions_FullMethodName = "/payment.PaymentService/StreamAllTransactions"
+	PaymentService_GenerateStatement_FullMethodName     = "/payment.PaymentService/GenerateStatement"
+	PaymentService_GetStatement_FullMethodName          = "/payment.PaymentService/GetStatement"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// PaymentService provides payment operations
+// PaymentService provides payment operations. RPCs with a google.api.http option
+// are also served as REST by the gateway (grpc-gateway): under /v1, and at the
+// additional_bindings, the paths clients used before /v1, in the gateway's
+// original JSON shapes. The rest are internal or admin only and reachable over
+// gRPC alone.
 type PaymentServiceClient interface {
-	// CreateTransaction creates a new transaction
+	// CreateTransaction creates a new transaction and reports the spending headroom
+	// left after it in the current-total and remaining-limit response headers
+	// (the user's total including the transaction, and how much more they can
+	// spend). Exceeding the limit fails with FAILED_PRECONDITION and an ErrorInfo
+	// (reason LIMIT_EXCEEDED) carrying current_total, max_allowed,
+	// remaining_limit and resets_at.
 	CreateTransaction(ctx context.Context, in *CreateTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
 	// GetTransactions returns all transactions for a user
 	GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*TransactionList, error)
-	// PayAllTransactions marks all unpaid transactions as paid
+	// PayAllTransactions marks all unpaid transactions as paid. With a payment
+	// provider configured they are then charged for; a declined charge leaves
+	// them unpaid and fails with FAILED_PRECONDITION and an ErrorInfo (reason
+	// CHARGE_FAILED).
 	PayAllTransactions(ctx context.Context, in *PayRequest, opts ...grpc.CallOption) (*PayResponse, error)
+	// GetSummary returns paid and unpaid totals, counts, the remaining limit and
+	// the limit warnings reached
+	GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error)
+	// AttachReceipt records an uploaded receipt on a transaction, replacing any
+	// earlier one. NOT_FOUND when the user has no such transaction.
+	AttachReceipt(ctx context.Context, in *AttachReceiptRequest, opts ...grpc.CallOption) (*AttachReceiptResponse, error)
+	// GetReceipt returns a transaction's receipt; NOT_FOUND when there is none
+	GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*Receipt, error)
+	// StreamAllTransactions streams every user's transactions created before
+	// the cutoff, archived ones included, in ID order, so a consumer starting
+	// from scratch can catch up before reading events. Admin only: the caller
+	// must send the service token in x-service-token metadata.
+	StreamAllTransactions(ctx context.Context, in *StreamAllTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transaction], error)
+	// GenerateStatement renders the user's statement for a month as a PDF in
+	// blob storage, replacing any earlier one. UNAVAILABLE when statements are
+	// not configured.
+	GenerateStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (*Statement, error)
+	// GetStatement returns the stored statement for a month; NOT_FOUND when it
+	// has not been generated
+	GetStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (*Statement, error)
 }
 
 type paymentServiceClient struct {
@@ -76,18 +114,119 @@ func (c *paymentServiceClient) PayAllTransactions(ctx context.Context, in *PayRe
 	return out, nil
 }
 
+func (c *paymentServiceClient) GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Summary)
+	err := c.cc.Invoke(ctx, PaymentService_GetSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) AttachReceipt(ctx context.Context, in *AttachReceiptRequest, opts ...grpc.CallOption) (*AttachReceiptResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AttachReceiptResponse)
+	err := c.cc.Invoke(ctx, PaymentService_AttachReceipt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*Receipt, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Receipt)
+	err := c.cc.Invoke(ctx, PaymentService_GetReceipt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) StreamAllTransactions(ctx context.Context, in *StreamAllTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transaction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[0], PaymentService_StreamAllTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamAllTransactionsRequest, Transaction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentService_StreamAllTransactionsClient = grpc.ServerStreamingClient[Transaction]
+
+func (c *paymentServiceClient) GenerateStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (*Statement, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Statement)
+	err := c.cc.Invoke(ctx, PaymentService_GenerateStatement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (*Statement, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Statement)
+	err := c.cc.Invoke(ctx, PaymentService_GetStatement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
 //
-// PaymentService provides payment operations
+// PaymentService provides payment operations. RPCs with a google.api.http option
+// are also served as REST by the gateway (grpc-gateway): under /v1, and at the
+// additional_bindings, the paths clients used before /v1, in the gateway's
+// original JSON shapes. The rest are internal or admin only and reachable over
+// gRPC alone.
 type PaymentServiceServer interface {
-	// CreateTransaction creates a new transaction
+	// CreateTransaction creates a new transaction and reports the spending headroom
+	// left after it in the current-total and remaining-limit response headers
+	// (the user's total including the transaction, and how much more they can
+	// spend). Exceeding the limit fails with FAILED_PRECONDITION and an ErrorInfo
+	// (reason LIMIT_EXCEEDED) carrying current_total, max_allowed,
+	// remaining_limit and resets_at.
 	CreateTransaction(context.Context, *CreateTransactionRequest) (*Transaction, error)
 	// GetTransactions returns all transactions for a user
 	GetTransactions(context.Context, *GetTransactionsRequest) (*TransactionList, error)
-	// PayAllTransactions marks all unpaid transactions as paid
+	// PayAllTransactions marks all unpaid transactions as paid. With a payment
+	// provider configured they are then charged for; a declined charge leaves
+	// them unpaid and fails with FAILED_PRECONDITION and an ErrorInfo (reason
+	// CHARGE_FAILED).
 	PayAllTransactions(context.Context, *PayRequest) (*PayResponse, error)
+	// GetSummary returns paid and unpaid totals, counts, the remaining limit and
+	// the limit warnings reached
+	GetSummary(context.Context, *GetSummaryRequest) (*Summary, error)
+	// AttachReceipt records an uploaded receipt on a transaction, replacing any
+	// earlier one. NOT_FOUND when the user has no such transaction.
+	AttachReceipt(context.Context, *AttachReceiptRequest) (*AttachReceiptResponse, error)
+	// GetReceipt returns a transaction's receipt; NOT_FOUND when there is none
+	GetReceipt(context.Context, *GetReceiptRequest) (*Receipt, error)
+	// StreamAllTransactions streams every user's transactions created before
+	// the cutoff, archived ones included, in ID order, so a consumer starting
+	// from scratch can catch up before reading events. Admin only: the caller
+	// must send the service token in x-service-token metadata.
+	StreamAllTransactions(*StreamAllTransactionsRequest, grpc.ServerStreamingServer[Transaction]) error
+	// GenerateStatement renders the user's statement for a month as a PDF in
+	// blob storage, replacing any earlier one. UNAVAILABLE when statements are
+	// not configured.
+	GenerateStatement(context.Context, *StatementRequest) (*Statement, error)
+	// GetStatement returns the stored statement for a month; NOT_FOUND when it
+	// has not been generated
+	GetStatement(context.Context, *StatementRequest) (*Statement, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -107,6 +246,24 @@ func (UnimplementedPaymentServiceServer) GetTransactions(context.Context, *GetTr
 func (UnimplementedPaymentServiceServer) PayAllTransactions(context.Context, *PayRequest) (*PayResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method PayAllTransactions not implemented")
 }
+func (UnimplementedPaymentServiceServer) GetSummary(context.Context, *GetSummaryRequest) (*Summary, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedPaymentServiceServer) AttachReceipt(context.Context, *AttachReceiptRequest) (*AttachReceiptResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AttachReceipt not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetReceipt(context.Context, *GetReceiptRequest) (*Receipt, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedPaymentServiceServer) StreamAllTransactions(*StreamAllTransactionsRequest, grpc.ServerStreamingServer[Transaction]) error {
+	return status.Error(codes.Unimplemented, "method StreamAllTransactions not implemented")
+}
+func (UnimplementedPaymentServiceServer) GenerateStatement(context.Context, *StatementRequest) (*Statement, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateStatement not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetStatement(context.Context, *StatementRequest) (*Statement, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStatement not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -182,6 +339,107 @@ func _PaymentService_PayAllTransactions_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetSummary(ctx, req.(*GetSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_AttachReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttachReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).AttachReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_AttachReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).AttachReceipt(ctx, req.(*AttachReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetReceipt(ctx, req.(*GetReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_StreamAllTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAllTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).StreamAllTransactions(m, &grpc.GenericServerStream[StreamAllTransactionsRequest, Transaction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentService_StreamAllTransactionsServer = grpc.ServerStreamingServer[Transaction]
+
+func _PaymentService_GenerateStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GenerateStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GenerateStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GenerateStatement(ctx, req.(*StatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetStatement(ctx, req.(*StatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,7 +459,33 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PayAllTransactions",
 			Handler:    _PaymentService_PayAllTransactions_Handler,
 		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _PaymentService_GetSummary_Handler,
+		},
+		{
+			MethodName: "AttachReceipt",
+			Handler:    _PaymentService_AttachReceipt_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _PaymentService_GetReceipt_Handler,
+		},
+		{
+			MethodName: "GenerateStatement",
+			Handler:    _PaymentService_GenerateStatement_Handler,
+		},
+		{
+			MethodName: "GetStatement",
+			Handler:    _PaymentService_GetStatement_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAllTransactions",
+			Handler:       _PaymentService_StreamAllTransactions_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/payment/payment.proto",
+	Metadata: "payment/payment.proto",
 }